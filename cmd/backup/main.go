@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/backup"
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/db"
+	"github.com/your-org/leandro-agent/internal/phone"
+)
+
+// Backup e restauração de clientes, atributos e mensagens.
+//
+//	go run ./cmd/backup dump                          # backup-20240601-0300.jsonl.gz (+ .enc com BACKUP_KEY)
+//	go run ./cmd/backup dump -out /backups/x.gz -s3   # e envia para BACKUP_S3_BUCKET
+//	go run ./cmd/backup restore -in backup.jsonl.gz   # insere o que falta (nunca sobrescreve)
+//	go run ./cmd/backup restore -in s3://backup-20240601-0300.jsonl.gz.enc -phone 5511999999999
+//	go run ./cmd/backup restore -in backup.jsonl.gz -dry-run
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cfg := config.Load()
+	var key []byte
+	if cfg.BackupKey != "" {
+		k, err := backup.ParseKey(cfg.BackupKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		key = k
+	}
+	store := &backup.S3{
+		Endpoint: cfg.BackupS3Endpoint, Region: cfg.BackupS3Region, Bucket: cfg.BackupS3Bucket,
+		AccessKey: cfg.AWSAccessKeyID, SecretKey: cfg.AWSSecretKey,
+	}
+
+	switch os.Args[1] {
+	case "dump":
+		fs := flag.NewFlagSet("dump", flag.ExitOnError)
+		out := fs.String("out", "", "output file (default backup-<date>.jsonl.gz[.enc])")
+		upload := fs.Bool("s3", false, "upload the file to BACKUP_S3_BUCKET/BACKUP_S3_PREFIX")
+		_ = fs.Parse(os.Args[2:])
+		runDump(cfg, key, store, *out, *upload)
+	case "restore":
+		fs := flag.NewFlagSet("restore", flag.ExitOnError)
+		in := fs.String("in", "", "backup file, or s3://<key> inside BACKUP_S3_BUCKET")
+		only := fs.String("phone", "", "restore only this phone")
+		dry := fs.Bool("dry-run", false, "read and check everything, then roll back")
+		_ = fs.Parse(os.Args[2:])
+		if *in == "" {
+			log.Fatal("-in is required")
+		}
+		runRestore(cfg, key, store, *in, *only, *dry)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: backup dump [-out file] [-s3] | backup restore -in file|s3://key [-phone N] [-dry-run]")
+	os.Exit(2)
+}
+
+func runDump(cfg config.Config, key []byte, store *backup.S3, out string, upload bool) {
+	if out == "" {
+		out = "backup-" + time.Now().In(cfg.Location()).Format("20060102-1504") + ".jsonl.gz"
+		if key != nil {
+			out += ".enc"
+		}
+	}
+	pool, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("db connect error: %v", err)
+	}
+	defer pool.Close()
+	ctx := context.Background()
+
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Fatalf("create %s: %v", out, err)
+	}
+	var w io.Writer = f
+	var enc io.WriteCloser
+	if key != nil {
+		if enc, err = backup.Encrypt(f, key); err != nil {
+			log.Fatalf("encrypt: %v", err)
+		}
+		w = enc
+	}
+	stats, err := backup.Dump(ctx, pool, w)
+	if err == nil && enc != nil {
+		err = enc.Close()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(out)
+		log.Fatalf("dump error: %v", err)
+	}
+	log.Printf("dump %s written (encrypted=%v): %s", out, key != nil, stats)
+
+	if !upload {
+		return
+	}
+	f, err = os.Open(out)
+	if err != nil {
+		log.Fatalf("open %s: %v", out, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Fatalf("stat %s: %v", out, err)
+	}
+	objectKey := cfg.BackupS3Prefix + path.Base(out)
+	if err := store.Put(ctx, objectKey, f, info.Size()); err != nil {
+		log.Fatalf("s3 upload error: %v", err)
+	}
+	log.Printf("uploaded to s3://%s/%s", cfg.BackupS3Bucket, objectKey)
+}
+
+func runRestore(cfg config.Config, key []byte, store *backup.S3, in, only string, dry bool) {
+	ctx := context.Background()
+	var src io.ReadCloser
+	if objectKey, ok := strings.CutPrefix(in, "s3://"); ok {
+		body, err := store.Get(ctx, cfg.BackupS3Prefix+objectKey)
+		if err != nil {
+			log.Fatalf("s3 download error: %v", err)
+		}
+		src = body
+	} else {
+		f, err := os.Open(in)
+		if err != nil {
+			log.Fatalf("open %s: %v", in, err)
+		}
+		src = f
+	}
+	defer src.Close()
+	r, err := backup.Open(src, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if only != "" {
+		n, ok := phone.Normalize(only)
+		if !ok {
+			log.Fatalf("invalid -phone %q", only)
+		}
+		only = n
+	}
+	pool, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("db connect error: %v", err)
+	}
+	defer pool.Close()
+	if err := db.AutoMigrate(ctx, pool); err != nil {
+		log.Fatalf("db migrate error: %v", err)
+	}
+
+	stats, err := backup.Restore(ctx, pool, r, backup.RestoreOptions{Phone: only, DryRun: dry})
+	if err != nil {
+		log.Fatalf("restore error (nothing was written): %v", err)
+	}
+	verb := "restored"
+	if dry {
+		verb = "would restore (dry run)"
+	}
+	log.Printf("%s: %s; skipped: %s", verb, stats.Restored, stats.Skipped)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// dbStats são os contadores de pg_stat_database do banco da instância; a diferença
+// antes/depois da carga mostra o custo de banco por mensagem.
+type dbStats struct {
+	ok          bool
+	commits     int64
+	rollbacks   int64
+	inserted    int64
+	updated     int64
+	fetched     int64
+	blksRead    int64
+	blksHit     int64
+	tempBytes   int64
+	deadlocks   int64
+	connections int64
+}
+
+func readDBStats(ctx context.Context, pool *pgxpool.Pool) (dbStats, error) {
+	var s dbStats
+	err := pool.QueryRow(ctx, `
+		SELECT xact_commit, xact_rollback, tup_inserted, tup_updated, tup_fetched,
+		       blks_read, blks_hit, temp_bytes, deadlocks, numbackends
+		FROM pg_stat_database WHERE datname = current_database()
+	`).Scan(&s.commits, &s.rollbacks, &s.inserted, &s.updated, &s.fetched,
+		&s.blksRead, &s.blksHit, &s.tempBytes, &s.deadlocks, &s.connections)
+	s.ok = err == nil
+	return s, err
+}
+
+// sub devolve a diferença; connections fica com o valor atual.
+func (s dbStats) sub(o dbStats) dbStats {
+	return dbStats{
+		ok:          s.ok,
+		commits:     s.commits - o.commits,
+		rollbacks:   s.rollbacks - o.rollbacks,
+		inserted:    s.inserted - o.inserted,
+		updated:     s.updated - o.updated,
+		fetched:     s.fetched - o.fetched,
+		blksRead:    s.blksRead - o.blksRead,
+		blksHit:     s.blksHit - o.blksHit,
+		tempBytes:   s.tempBytes - o.tempBytes,
+		deadlocks:   s.deadlocks - o.deadlocks,
+		connections: s.connections,
+	}
+}
+
+func (s dbStats) print() {
+	fmt.Printf("db: commits=%d rollbacks=%d inserted=%d updated=%d fetched=%d blks_read=%d blks_hit=%d temp_bytes=%d deadlocks=%d backends=%d\n",
+		s.commits, s.rollbacks, s.inserted, s.updated, s.fetched, s.blksRead, s.blksHit, s.tempBytes, s.deadlocks, s.connections)
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/db"
+)
+
+// Gerador de carga do pipeline do webhook.
+//
+// Sobe upstreams falsos (Uazapi + OpenAI) e dispara payloads sintéticos no webhook
+// de uma instância local, medindo a latência do ack HTTP e a latência fim a fim
+// (webhook -> envio da resposta na Uazapi falsa). A instância deve apontar para
+// os upstreams falsos e ter buffer/delay curtos, por exemplo:
+//
+//	UAZAPI_BASE_SEND=http://localhost:9099 UAZAPI_BASE_DOWNLOAD=http://localhost:9099 \
+//	OPENAI_BASE_URL=http://localhost:9099/v1 BUFFER_TIMEOUT_SECONDS=1 \
+//	REPLY_DELAY_MIN_MS=0 REPLY_DELAY_MAX_MS=0 go run ./cmd/server
+//
+//	go run ./cmd/loadtest -rps 50 -duration 30s -phones 200
+//
+// Os benchmarks do parse e do processor ficam nos testes: make bench.
+func main() {
+	target := flag.String("target", "http://localhost:8080/webhook/Leandro-JW", "webhook URL of the instance under test")
+	fakeAddr := flag.String("fake", ":9099", "listen address of the fake Uazapi/OpenAI upstreams")
+	rps := flag.Int("rps", 20, "webhook requests per second")
+	duration := flag.Duration("duration", 30*time.Second, "how long to send load")
+	phones := flag.Int("phones", 100, "number of distinct synthetic clients")
+	drain := flag.Duration("drain", 20*time.Second, "how long to wait for pending replies after the load stops")
+	runDelay := flag.Duration("run-delay", 0, "simulated OpenAI run time (fake upstream)")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "sign requests with this WEBHOOK_SECRET")
+	dbURL := flag.String("db", os.Getenv("DATABASE_URL"), "database of the instance, for pg_stat_database deltas (optional)")
+	flag.Parse()
+
+	if *rps <= 0 || *phones <= 0 {
+		log.Fatal("-rps and -phones must be positive")
+	}
+
+	fake := newFakeUpstream(*runDelay)
+	srv := &http.Server{Addr: *fakeAddr, Handler: fake}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("fake upstream: %v", err)
+		}
+	}()
+	defer srv.Close()
+
+	ctx := context.Background()
+	var before dbStats
+	if *dbURL != "" {
+		pool, err := db.Connect(*dbURL)
+		if err != nil {
+			log.Fatalf("db connect error: %v", err)
+		}
+		defer pool.Close()
+		if before, err = readDBStats(ctx, pool); err != nil {
+			log.Printf("pg_stat_database unavailable: %v", err)
+		}
+		defer func() {
+			after, err := readDBStats(ctx, pool)
+			if err == nil && before.ok {
+				after.sub(before).print()
+			}
+		}()
+	}
+
+	lt := &loadTest{
+		target: *target,
+		secret: *secret,
+		client: &http.Client{Timeout: 30 * time.Second},
+		fake:   fake,
+	}
+	log.Printf("sending %d rps for %s to %s (%d phones)", *rps, *duration, *target, *phones)
+	lt.run(*rps, *duration, *phones)
+
+	// espera as respostas pendentes
+	deadline := time.Now().Add(*drain)
+	for time.Now().Before(deadline) && fake.pendingCount() > 0 {
+		time.Sleep(200 * time.Millisecond)
+	}
+	lt.report()
+}
+
+type loadTest struct {
+	target string
+	secret string
+	client *http.Client
+	fake   *fakeUpstream
+
+	mu       sync.Mutex
+	acks     []time.Duration
+	statuses map[int]int
+	errors   int64
+	sent     int64
+}
+
+func (lt *loadTest) run(rps int, d time.Duration, phones int) {
+	lt.statuses = map[int]int{}
+	tick := time.NewTicker(time.Second / time.Duration(rps))
+	defer tick.Stop()
+	stop := time.After(d)
+	var wg sync.WaitGroup
+	for i := 0; ; i++ {
+		select {
+		case <-stop:
+			wg.Wait()
+			return
+		case <-tick.C:
+			phone := "5599" + fmt.Sprintf("%09d", i%phones)
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				lt.send(phone, i)
+			}(i)
+		}
+	}
+}
+
+func (lt *loadTest) send(phone string, i int) {
+	id := fmt.Sprintf("LT%d%06d", time.Now().UnixNano(), i)
+	body, _ := json.Marshal(map[string]any{
+		"EventType": "messages",
+		"message": map[string]any{
+			"chatid":      phone + "@s.whatsapp.net",
+			"messageid":   id,
+			"messageType": "conversation",
+			"content":     fmt.Sprintf("mensagem de carga %d", i),
+			"senderName":  "Carga " + phone[len(phone)-4:],
+		},
+	})
+	req, _ := http.NewRequest(http.MethodPost, lt.target, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if lt.secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(lt.secret))
+		mac.Write([]byte(ts + "." + id + "."))
+		mac.Write(body)
+		req.Header.Set("X-Webhook-Timestamp", ts)
+		req.Header.Set("X-Webhook-Nonce", id)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	start := time.Now()
+	lt.fake.expect(phone, start)
+	atomic.AddInt64(&lt.sent, 1)
+	resp, err := lt.client.Do(req)
+	if err != nil {
+		atomic.AddInt64(&lt.errors, 1)
+		lt.fake.forget(phone)
+		return
+	}
+	resp.Body.Close()
+	lt.mu.Lock()
+	lt.acks = append(lt.acks, time.Since(start))
+	lt.statuses[resp.StatusCode]++
+	lt.mu.Unlock()
+	if resp.StatusCode != http.StatusOK {
+		lt.fake.forget(phone)
+	}
+}
+
+func (lt *loadTest) report() {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	e2e, pending := lt.fake.results()
+	fmt.Printf("\nrequests: %d sent, %d transport errors\n", lt.sent, lt.errors)
+	codes := make([]int, 0, len(lt.statuses))
+	for c := range lt.statuses {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		fmt.Printf("  HTTP %d: %d\n", c, lt.statuses[c])
+	}
+	printLatencies("webhook ack", lt.acks)
+	printLatencies("end-to-end", e2e)
+	fmt.Printf("replies: %d received, %d messages still without reply\n", len(e2e), pending)
+	fmt.Printf("fake upstream calls: %s\n", lt.fake.callSummary())
+}
+
+func printLatencies(label string, ds []time.Duration) {
+	if len(ds) == 0 {
+		fmt.Printf("%-12s n=0\n", label)
+		return
+	}
+	s := append([]time.Duration(nil), ds...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	pct := func(p float64) time.Duration { return s[int(p*float64(len(s)-1))] }
+	fmt.Printf("%-12s n=%d p50=%s p95=%s p99=%s max=%s\n", label, len(s),
+		pct(0.50).Round(time.Millisecond), pct(0.95).Round(time.Millisecond),
+		pct(0.99).Round(time.Millisecond), s[len(s)-1].Round(time.Millisecond))
+}
+
+// ----------------- upstreams falsos -----------------
+
+// fakeUpstream responde como Uazapi (/send/*, /message/download) e como a API da
+// OpenAI (/v1/...), e mede quando a resposta de cada telefone é enviada.
+type fakeUpstream struct {
+	runDelay time.Duration
+
+	mu      sync.Mutex
+	pending map[string][]time.Time // phone -> mensagens ainda sem resposta
+	e2e     []time.Duration
+	calls   map[string]int
+	seq     int64
+}
+
+func newFakeUpstream(runDelay time.Duration) *fakeUpstream {
+	return &fakeUpstream{runDelay: runDelay, pending: map[string][]time.Time{}, calls: map[string]int{}}
+}
+
+func (f *fakeUpstream) expect(phone string, at time.Time) {
+	f.mu.Lock()
+	f.pending[phone] = append(f.pending[phone], at)
+	f.mu.Unlock()
+}
+
+// forget descarta a mensagem mais recente do telefone (requisição rejeitada).
+func (f *fakeUpstream) forget(phone string) {
+	f.mu.Lock()
+	if p := f.pending[phone]; len(p) > 0 {
+		f.pending[phone] = p[:len(p)-1]
+	}
+	f.mu.Unlock()
+}
+
+// replied fecha todas as mensagens pendentes do telefone: o buffer junta as
+// mensagens próximas numa resposta só.
+func (f *fakeUpstream) replied(phone string) {
+	now := time.Now()
+	f.mu.Lock()
+	for _, at := range f.pending[phone] {
+		f.e2e = append(f.e2e, now.Sub(at))
+	}
+	delete(f.pending, phone)
+	f.mu.Unlock()
+}
+
+func (f *fakeUpstream) pendingCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, p := range f.pending {
+		n += len(p)
+	}
+	return n
+}
+
+func (f *fakeUpstream) results() ([]time.Duration, int) {
+	n := f.pendingCount()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.e2e...), n
+}
+
+func (f *fakeUpstream) callSummary() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.calls))
+	for k := range f.calls {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, f.calls[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *fakeUpstream) id(prefix string) string {
+	return prefix + strconv.FormatInt(atomic.AddInt64(&f.seq, 1), 36)
+}
+
+func (f *fakeUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	key := r.Method + " " + normalizePath(path)
+	f.mu.Lock()
+	f.calls[key]++
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	// Uazapi
+	case strings.HasPrefix(path, "/send/"):
+		var body struct {
+			Number string `json:"number"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.replied(body.Number)
+		writeJSON(w, map[string]any{"messageid": f.id("fake"), "messageTimestamp": time.Now().UnixMilli()})
+	case path == "/message/download":
+		writeJSON(w, map[string]any{"fileURL": "http://" + r.Host + "/files/fake.bin"})
+	case strings.HasPrefix(path, "/files/"):
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(bytes.Repeat([]byte{0}, 1024))
+
+	// OpenAI
+	case r.Method == http.MethodPost && path == "/v1/threads":
+		writeJSON(w, map[string]any{"id": f.id("thread_")})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/messages"):
+		writeJSON(w, map[string]any{"id": f.id("msg_")})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/runs"):
+		writeJSON(w, map[string]any{"id": f.id("run_"), "status": "queued"})
+	case r.Method == http.MethodGet && strings.Contains(path, "/runs/"):
+		if f.runDelay > 0 {
+			time.Sleep(f.runDelay)
+		}
+		writeJSON(w, map[string]any{"id": "run", "status": "completed"})
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/messages"):
+		writeJSON(w, map[string]any{"data": []any{map[string]any{
+			"role":    "assistant",
+			"content": []any{map[string]any{"type": "text", "text": map[string]any{"value": "Resposta automática de teste de carga."}}},
+		}}})
+	case path == "/v1/chat/completions":
+		writeJSON(w, map[string]any{"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": "{}"}}}})
+	case path == "/v1/moderations":
+		writeJSON(w, map[string]any{"results": []any{map[string]any{"flagged": false}}})
+	case path == "/v1/audio/speech":
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write(bytes.Repeat([]byte{0}, 256))
+	case path == "/v1/audio/transcriptions":
+		writeJSON(w, map[string]any{"text": "áudio de teste de carga"})
+	default:
+		writeJSON(w, map[string]any{})
+	}
+}
+
+// normalizePath troca IDs por "*" para agrupar as chamadas no resumo.
+func normalizePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, s := range parts {
+		if strings.HasPrefix(s, "thread_") || strings.HasPrefix(s, "run_") {
+			parts[i] = "*"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	_ = json.NewEncoder(w).Encode(v)
+}
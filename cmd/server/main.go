@@ -7,12 +7,25 @@ import (
 	"os"
 	"strings"
 	"time"
+	_ "time/tzdata" // fusos horários na imagem alpine
 
 	"github.com/your-org/leandro-agent/internal/config"
 	"github.com/your-org/leandro-agent/internal/db"
+	"github.com/your-org/leandro-agent/internal/digest"
+	"github.com/your-org/leandro-agent/internal/document"
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/feed"
 	"github.com/your-org/leandro-agent/internal/handlers"
+	"github.com/your-org/leandro-agent/internal/media"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/reengage"
+	"github.com/your-org/leandro-agent/internal/retention"
+	"github.com/your-org/leandro-agent/internal/scheduler"
+	"github.com/your-org/leandro-agent/internal/sink"
 
+	"github.com/your-org/leandro-agent/internal/trace"
 	"github.com/your-org/leandro-agent/internal/uazapi"
+	"github.com/your-org/leandro-agent/internal/upstream"
 )
 
 // --- helpers ENV ---
@@ -63,6 +76,9 @@ func newUazapiFromEnv() *uazapi.Client {
 	if delayAsString {
 		cli = cli.WithDelayAsString(true)
 	}
+	if getenvBool("DRY_RUN", false) {
+		cli = cli.WithDryRun(true)
+	}
 
 	return cli
 }
@@ -79,8 +95,67 @@ func main() {
 		log.Fatalf("db migrate error: %v", err)
 	}
 
+	// Circuit breaker dos caminhos da Uazapi (compartilhado por todos os clients)
+	uazapi.SetBreakerPolicy(cfg.UazapiBreakerFailures, time.Duration(cfg.UazapiBreakerCooldownSeconds)*time.Second)
+
+	// Taxa de envio por instância (também compartilhada)
+	ramp, err := uazapi.ParseRamp(cfg.OutboundRampUp)
+	if err != nil {
+		log.Printf("OUTBOUND_RAMP_UP ignorado: %v", err)
+	}
+	uazapi.SetShaperPolicy(uazapi.ShaperPolicy{
+		MaxPerMinute: cfg.OutboundMaxPerMinute,
+		MinInterval:  time.Duration(cfg.OutboundMinIntervalMs) * time.Millisecond,
+		Jitter:       time.Duration(cfg.OutboundJitterMs) * time.Millisecond,
+		RampStart:    cfg.OutboundRampStart,
+		Ramp:         ramp,
+		MaxWait:      time.Duration(cfg.OutboundMaxWaitSeconds) * time.Second,
+		Location:     cfg.Location(),
+	})
+
+	// Conexões com os provedores: um transporte compartilhado, aquecido na subida
+	up := upstream.New(upstream.OptionsFrom(cfg))
+	go up.Warm(context.Background(), []string{cfg.OpenAIBaseURL, cfg.UazapiBaseSend, cfg.UazapiBaseDownload, cfg.TranscribeURL}, cfg.HTTPPrewarmConns)
+
 	// Uazapi client (NO-WAIT)
-	uaz := newUazapiFromEnv()
+	uaz := newUazapiFromEnv().
+		WithDownloadTimeout(time.Duration(cfg.UazapiDownloadTimeoutSeconds) * time.Second).
+		WithDownloadRetries(cfg.UazapiDownloadRetries).
+		WithTransport(trace.Transport(up))
+
+	if cfg.AudioPreprocess && !media.HasFFmpeg(cfg.FFmpegPath) {
+		log.Printf("ffmpeg não encontrado (%s): áudios serão transcritos sem pré-processamento", cfg.FFmpegPath)
+	}
+	if document.PDFExtractor() == document.PDFBuiltin {
+		log.Printf("pdftotext não encontrado: PDFs usam o extrator embutido (sem suporte a alguns formatos)")
+	}
+	log.Printf("document extractors: pdf=%s docx xlsx csv txt", document.PDFExtractor())
+
+	ai := openai.New(cfg.OpenAIAPIKey, cfg.OpenAIAssistantID, cfg.OpenAIChatModel, cfg.OpenAITranscribeModel)
+	ai.BaseURL = cfg.OpenAIBaseURL
+	ai.SetTransport(trace.Transport(up))
+
+	// Jobs periódicos rodam em uma só réplica por janela (lease em scheduled_jobs)
+	sched := scheduler.New(pool)
+
+	// Resumo diário para administradores
+	digestJob := digest.New(cfg, pool, ai, uaz).WithScheduler(sched)
+	go digestJob.Start(context.Background())
+
+	// Reengajamento noturno de leads silenciosos
+	reengageJob := reengage.New(cfg, pool, ai, uaz).WithScheduler(sched)
+
+	// Retenção de dados (mensagens e threads antigas)
+	retentionJob := retention.New(pool, ai, cfg.RetentionDays, time.Duration(cfg.RetentionIntervalHours)*time.Hour).WithScheduler(sched)
+	go retentionJob.Start(context.Background())
+
+	// Sink de analytics (BigQuery/ClickHouse), se configurado
+	if sk, err := sink.New(cfg); err != nil {
+		log.Printf("sink disabled: %v", err)
+	} else if sk != nil {
+		exp := sink.NewExporter(pool, sk, cfg.SinkBatchSize, time.Duration(cfg.SinkIntervalSeconds)*time.Second)
+		go exp.Run(context.Background())
+	}
 
 	mux := http.NewServeMux()
 
@@ -95,11 +170,100 @@ func main() {
 	// mux.Handle("/webhook/Leandro-JW", handlers.NewWebhookHandlerWithUazapi(cfg, pool, uaz))
 
 	// Enquanto não tiver o construtor acima, mantém o antigo:
-	mux.Handle("/webhook/Leandro-JW", handlers.NewWebhookHandler(cfg, pool))
+	hub := feed.NewHub()
+	var bus events.Bus = events.NewLocal()
+	if cfg.EventBus == "postgres" {
+		bus = events.NewPostgres(context.Background(), pool)
+	}
+	wh := handlers.NewWebhookHandler(cfg, pool, hub, bus, up)
+	// reengajamento começa depois do webhook: os envios passam pelo histórico em duas fases
+	reengageJob.WithDeliver(wh.Deliver)
+	go reengageJob.Start(context.Background())
+	// readiness: banco + teste do canal no start + estado dos circuit breakers da Uazapi
+	mux.Handle("GET /readyz", handlers.NewReadyHandler(pool, wh.Warmup))
+	// Multi-tenant: /webhook/t/{slug} ou X-Tenant-Token; sem tenant, credenciais do ENV
+	tenants := wh.Tenants()
+	mux.Handle("/webhook/Leandro-JW", tenants.WebhookHandler())
+	mux.Handle("/webhook/t/{tenant}", tenants.WebhookHandler())
+	// Situação de cada mensagem recebida (ID devolvido na resposta do webhook)
+	mux.Handle("GET /status/{id}", tenants.StatusHandler())
+
+	// Payload nativo versionado (n8n, Make, scripts). Aceita INGEST_TOKEN ou o token
+	// de um tenant; sem nenhum dos dois a rota responde 401.
+	mux.Handle("/api/v1/inbound", tenants.IngestHandler())
+	mux.Handle("/api/send", tenants.SendHandler())
+	// Confirmação de pagamento/formulário: encerra a ação pendente do cliente
+	mux.Handle("/api/v1/confirmations", tenants.ConfirmationHandler())
+	// Chat no site continuando a conversa do WhatsApp (WIDGET_SECRET)
+	mux.Handle("/api/v1/widget/tokens", tenants.WidgetTokenHandler())
+	mux.Handle("/widget/v1/messages", tenants.WidgetHandler())
+	// Webhooks de pagamento (Stripe, Mercado Pago, Pix): registram e avisam o cliente
+	payments := tenants.PaymentWebhookHandler()
+	mux.Handle("POST /webhook/payments/{provider}", payments)
+	mux.Handle("POST /webhook/payments/pix/pix", payments)
+
+	// Admin (ADMIN_TOKEN = papel admin; chaves de API em /admin/api-keys com papéis)
+	if cfg.AdminToken != "" {
+		auth := handlers.NewAuth(cfg, pool)
+		mux.Handle("/admin/feed", handlers.NewFeedHandler(auth, hub))
+		mux.Handle("/admin/maintenance", wh.MaintenanceHandler())
+		mux.Handle("/admin/debug/capture", wh.CaptureHandler())
+		mux.Handle("/admin/digest", handlers.NewDigestHandler(cfg, auth, digestJob))
+		mux.Handle("DELETE /admin/clients/{phone}", handlers.NewDeleteClientHandler(auth, retentionJob))
+		mux.Handle("POST /admin/clients/{phone}/transfer", wh.TransferHandler())
+		mux.Handle("GET /admin/clients/{phone}/transfers", wh.TransferHandler())
+		mux.Handle("/admin/clients/{phone}/summary", wh.SummaryHandler())
+		mux.Handle("/admin/clients/{phone}/language", wh.LanguageHandler())
+		mux.Handle("GET /admin/clients/{phone}/export", wh.ClientExportHandler())
+		mux.Handle("POST /admin/clients/{phone}/erasure", wh.ErasureHandler())
+		mux.Handle("GET /admin/erasures", wh.ErasuresHandler())
+		notes := handlers.NewClientNotesHandler(auth, pool)
+		mux.Handle("/admin/clients/{phone}/notes", notes)
+		mux.Handle("DELETE /admin/clients/{phone}/notes/{id}", notes)
+		tags := handlers.NewClientTagsHandler(auth, pool)
+		mux.Handle("/admin/clients/{phone}/tags", tags)
+		mux.Handle("DELETE /admin/clients/{phone}/tags/{tag}", tags)
+		mux.Handle("POST /admin/conversations/{phone}/reply", wh.OperatorReplyHandler())
+		mux.Handle("POST /admin/channels/{channel}/posts", wh.ChannelPostHandler())
+		mux.Handle("/admin/status-posts", wh.StatusPostsHandler())
+		mux.Handle("DELETE /admin/status-posts/{id}", wh.StatusPostsHandler())
+		intents := wh.IntentsHandler()
+		mux.Handle("/admin/intents", intents)
+		mux.Handle("/admin/intents/{id}", intents)
+		mux.Handle("POST /admin/intents/test", intents)
+		mux.Handle("GET /admin/payments", wh.PaymentsHandler())
+		mux.Handle("GET /admin/leads", handlers.NewLeadsHandler(auth, pool))
+		mux.Handle("PATCH /admin/leads/{id}", handlers.NewLeadUpdateHandler(auth, pool))
+		products := handlers.NewProductsHandler(auth, pool)
+		mux.Handle("/admin/products", products)
+		mux.Handle("/admin/products/{id}", products)
+		mux.Handle("GET /admin/budget", wh.BudgetHandler())
+		mux.Handle("GET /admin/abuse", wh.AbuseHandler())
+		mux.Handle("DELETE /admin/abuse/{phone}", wh.AbuseHandler())
+		mux.Handle("/admin/reengage", wh.ReengageHandler(reengageJob))
+		mux.Handle("/admin/settings", wh.SettingsHandler())
+		mux.Handle("/admin/settings/{instance}", wh.SettingsHandler())
+		mux.Handle("/admin/tenants", tenants.TenantsHandler())
+		mux.Handle("GET /admin/webhook/batches", wh.BatchStatsHandler())
+		mux.Handle("GET /admin/audio-replies", wh.AudioRepliesHandler())
+		mux.Handle("GET /admin/webhook/load", wh.LoadHandler())
+		mux.Handle("GET /admin/slo", wh.SLOHandler())
+		mux.Handle("GET /admin/dead-letters", wh.DeadLettersHandler())
+		mux.Handle("GET /admin/messages/status", wh.MessageStatusHandler())
+		mux.Handle("GET /admin/outbound", handlers.NewOutboundHandler(auth))
+		mux.Handle("GET /admin/jobs", handlers.NewJobsHandler(auth, pool))
+		mux.Handle("/admin/experiments", handlers.NewExperimentsHandler(auth, pool))
+		mux.Handle("GET /admin/experiments/{name}/metrics", handlers.NewExperimentMetricsHandler(auth, pool))
+		keys := handlers.NewAPIKeysHandler(auth, pool)
+		mux.Handle("/admin/api-keys", keys)
+		mux.Handle("DELETE /admin/api-keys/{id}", keys)
+	} else {
+		log.Println("ADMIN_TOKEN vazio: rotas /admin desativadas")
+	}
 
 	srv := &http.Server{
 		Addr:              cfg.Addr,
-		Handler:           mux,
+		Handler:           trace.Middleware(wh.Recover(mux)),
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
@@ -108,6 +272,4 @@ func main() {
 		log.Println("server error:", err)
 		os.Exit(1)
 	}
-
-	_ = uaz // evita "declared and not used" enquanto não injeta no handler
 }
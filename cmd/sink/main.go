@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/db"
+	"github.com/your-org/leandro-agent/internal/sink"
+)
+
+// Ferramenta de linha de comando do sink de analytics.
+//
+//	go run ./cmd/sink -schema             # cria as tabelas no destino
+//	go run ./cmd/sink                     # exporta o que falta (incremental)
+//	go run ./cmd/sink -backfill 2024-01-01 # reexporta tudo desde a data
+func main() {
+	schemaOnly := flag.Bool("schema", false, "only create/verify destination tables")
+	backfill := flag.String("backfill", "", "re-export rows created since this date (YYYY-MM-DD)")
+	flag.Parse()
+
+	cfg := config.Load()
+	sk, err := sink.New(cfg)
+	if err != nil {
+		log.Fatalf("sink config error: %v", err)
+	}
+	if sk == nil {
+		log.Fatal("SINK_KIND is not set")
+	}
+
+	pool, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("db connect error: %v", err)
+	}
+	defer pool.Close()
+	ctx := context.Background()
+	if err := db.AutoMigrate(ctx, pool); err != nil {
+		log.Fatalf("db migrate error: %v", err)
+	}
+
+	if *schemaOnly {
+		if err := sk.EnsureSchema(ctx, sink.Tables); err != nil {
+			log.Fatalf("schema error: %v", err)
+		}
+		log.Println("schema ok")
+		return
+	}
+
+	exp := sink.NewExporter(pool, sk, cfg.SinkBatchSize, 0)
+	var n int
+	if *backfill != "" {
+		since, perr := time.ParseInLocation("2006-01-02", *backfill, cfg.Location())
+		if perr != nil {
+			log.Fatalf("invalid -backfill date: %v", perr)
+		}
+		n, err = exp.Backfill(ctx, since)
+	} else {
+		if err = sk.EnsureSchema(ctx, sink.Tables); err == nil {
+			n, err = exp.ExportAll(ctx)
+		}
+	}
+	if err != nil {
+		log.Fatalf("export error after %d rows: %v", n, err)
+	}
+	log.Printf("exported %d rows", n)
+}
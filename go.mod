@@ -3,6 +3,7 @@ module github.com/your-org/leandro-agent
 go 1.22.0
 
 require (
+    github.com/andybalholm/brotli v1.2.5
     github.com/jackc/pgx/v5 v5.5.4
     github.com/jackc/pgpassfile v1.0.0 // indirect
     github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
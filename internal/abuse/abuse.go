@@ -0,0 +1,138 @@
+// internal/abuse/abuse.go
+package abuse
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Motivos de sinalização.
+const (
+	ReasonFrequency  = "frequency"
+	ReasonRepeated   = "repeated"
+	ReasonLinkOnly   = "link_only"
+	ReasonModeration = "moderation"
+)
+
+// Config define os limites das heurísticas. Limites <= 0 desativam a regra.
+type Config struct {
+	MaxPerMinute  int           // mensagens por minuto
+	RepeatLimit   int           // mesmo texto dentro de Window
+	LinkOnlyLimit int           // mensagens só com links dentro de Window
+	Window        time.Duration // janela das regras de repetição/links
+	Cooldown      time.Duration // tempo sem processamento após sinalizar
+}
+
+type sample struct {
+	at       time.Time
+	text     string
+	linkOnly bool
+}
+
+// Detector aplica as heurísticas por telefone e guarda os cooldowns ativos (em memória).
+type Detector struct {
+	cfg Config
+
+	mu        sync.Mutex
+	history   map[string][]sample
+	cooldowns map[string]time.Time
+}
+
+func NewDetector(cfg Config) *Detector {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Minute
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Minute
+	}
+	return &Detector{cfg: cfg, history: make(map[string][]sample), cooldowns: make(map[string]time.Time)}
+}
+
+// Cooldown é a duração aplicada a cada sinalização.
+func (d *Detector) Cooldown() time.Duration { return d.cfg.Cooldown }
+
+var urlRe = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// linkOnly indica se o texto é composto apenas por links (e pontuação/espaços).
+func linkOnly(text string) bool {
+	if !urlRe.MatchString(text) {
+		return false
+	}
+	rest := urlRe.ReplaceAllString(text, "")
+	return strings.Trim(rest, " \t\r\n.,;:!?-()[]<>\"'") == ""
+}
+
+// Observe registra a mensagem e diz se o remetente deve ser sinalizado.
+func (d *Detector) Observe(phone, text string, now time.Time) (reason string, flagged bool) {
+	norm := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	s := sample{at: now, text: norm, linkOnly: linkOnly(text)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keep := d.history[phone][:0]
+	for _, old := range d.history[phone] {
+		if now.Sub(old.at) < d.cfg.Window {
+			keep = append(keep, old)
+		}
+	}
+	hist := append(keep, s)
+	d.history[phone] = hist
+
+	perMinute, repeats, links := 0, 0, 0
+	for _, old := range hist {
+		if now.Sub(old.at) < time.Minute {
+			perMinute++
+		}
+		if norm != "" && old.text == norm {
+			repeats++
+		}
+		if old.linkOnly {
+			links++
+		}
+	}
+	switch {
+	case d.cfg.MaxPerMinute > 0 && perMinute > d.cfg.MaxPerMinute:
+		return ReasonFrequency, true
+	case d.cfg.RepeatLimit > 0 && repeats >= d.cfg.RepeatLimit:
+		return ReasonRepeated, true
+	case d.cfg.LinkOnlyLimit > 0 && s.linkOnly && links >= d.cfg.LinkOnlyLimit:
+		return ReasonLinkOnly, true
+	}
+	return "", false
+}
+
+// InCooldown indica se o telefone está em cooldown e até quando.
+func (d *Detector) InCooldown(phone string, now time.Time) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	until, ok := d.cooldowns[phone]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !now.Before(until) {
+		delete(d.cooldowns, phone)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// StartCooldown aplica (ou estende) o cooldown e zera o histórico do telefone.
+func (d *Detector) StartCooldown(phone string, until time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cur, ok := d.cooldowns[phone]; !ok || until.After(cur) {
+		d.cooldowns[phone] = until
+	}
+	delete(d.history, phone)
+}
+
+// Lift remove o cooldown do telefone.
+func (d *Detector) Lift(phone string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.cooldowns, phone)
+	delete(d.history, phone)
+}
@@ -0,0 +1,165 @@
+// internal/analytics/analytics.go
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Count é um par rótulo/quantidade usado nos rankings.
+type Count struct {
+	Label string `json:"label"`
+	N     int    `json:"n"`
+}
+
+// Stats agrega a atividade do agente num intervalo [From, To).
+type Stats struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	Conversations int     `json:"conversations"` // clientes que receberam ao menos uma resposta
+	NewClients    int     `json:"new_clients"`
+	Inbound       int     `json:"inbound"`  // mensagens individuais recebidas
+	Outbound      int     `json:"outbound"` // respostas enviadas
+	Failures      int     `json:"failures"`
+	FailureStages []Count `json:"failure_stages"`
+	InboundTypes  []Count `json:"inbound_types"`
+
+	// Reações e mensagens só com emoji não são perguntas: contadas à parte
+	Questions int     `json:"questions"` // recebidas menos emoji/reações
+	EmojiOnly int     `json:"emoji_only"`
+	Reactions int     `json:"reactions"`
+	TopEmojis []Count `json:"top_emojis"`
+
+	// Pesquisa de satisfação: enviadas, respondidas e nota média (1-5) no intervalo
+	CSATSent     int     `json:"csat_sent"`
+	CSATAnswered int     `json:"csat_answered"`
+	CSATAverage  float64 `json:"csat_average"`
+	CSATRatings  []Count `json:"csat_ratings"`
+
+	// Estimativa grosseira: ~4 caracteres por token sobre tudo que entrou/saiu.
+	EstTokens  int     `json:"est_tokens"`
+	EstCostUSD float64 `json:"est_cost_usd"`
+}
+
+func countRows(ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) ([]Count, error) {
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Count
+	for rows.Next() {
+		var c Count
+		if err := rows.Scan(&c.Label, &c.N); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Compute calcula as estatísticas do intervalo. costPer1K é o custo estimado (USD) por 1000 tokens.
+func Compute(ctx context.Context, pool *pgxpool.Pool, from, to time.Time, costPer1K float64) (Stats, error) {
+	st := Stats{From: from, To: to}
+
+	// Mensagens individuais de entrada têm ext_id; o texto agrupado do buffer não.
+	var chars int64
+	err := pool.QueryRow(ctx, `
+		SELECT
+		  COUNT(DISTINCT client_id) FILTER (WHERE role='assistant'),
+		  COUNT(*) FILTER (WHERE role='user' AND ext_id IS NOT NULL),
+		  COUNT(*) FILTER (WHERE role='assistant'),
+		  COALESCE(SUM(length(content)) FILTER (WHERE role='assistant' OR ext_id IS NULL), 0)
+		FROM messages
+		WHERE created_at >= $1 AND created_at < $2
+	`, from, to).Scan(&st.Conversations, &st.Inbound, &st.Outbound, &chars)
+	if err != nil {
+		return st, err
+	}
+	st.EstTokens = int(chars / 4)
+	st.EstCostUSD = float64(st.EstTokens) / 1000 * costPer1K
+
+	if err := pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM clients WHERE created_at >= $1 AND created_at < $2
+	`, from, to).Scan(&st.NewClients); err != nil {
+		return st, err
+	}
+
+	if st.FailureStages, err = countRows(ctx, pool, `
+		SELECT stage, COUNT(*) FROM failures
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY stage ORDER BY COUNT(*) DESC
+	`, from, to); err != nil {
+		return st, err
+	}
+	for _, c := range st.FailureStages {
+		st.Failures += c.N
+	}
+
+	if err := pool.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(rating), COALESCE(AVG(rating), 0)
+		FROM csat WHERE sent_at >= $1 AND sent_at < $2
+	`, from, to).Scan(&st.CSATSent, &st.CSATAnswered, &st.CSATAverage); err != nil {
+		return st, err
+	}
+	if st.CSATRatings, err = countRows(ctx, pool, `
+		SELECT rating::text, COUNT(*) FROM csat
+		WHERE rating IS NOT NULL AND sent_at >= $1 AND sent_at < $2
+		GROUP BY rating ORDER BY rating DESC
+	`, from, to); err != nil {
+		return st, err
+	}
+
+	if st.InboundTypes, err = countRows(ctx, pool, `
+		SELECT type, COUNT(*) FROM messages
+		WHERE role='user' AND ext_id IS NOT NULL AND created_at >= $1 AND created_at < $2
+		GROUP BY type ORDER BY COUNT(*) DESC
+	`, from, to); err != nil {
+		return st, err
+	}
+	for _, c := range st.InboundTypes {
+		switch c.Label {
+		case "emoji":
+			st.EmojiOnly = c.N
+		case "reaction":
+			st.Reactions = c.N
+		}
+	}
+	st.Questions = st.Inbound - st.EmojiOnly - st.Reactions
+	if st.TopEmojis, err = countRows(ctx, pool, `
+		SELECT content, COUNT(*) FROM messages
+		WHERE role='user' AND type IN ('emoji','reaction') AND content <> ''
+		  AND created_at >= $1 AND created_at < $2
+		GROUP BY content ORDER BY COUNT(*) DESC LIMIT 5
+	`, from, to); err != nil {
+		return st, err
+	}
+	return st, nil
+}
+
+// InboundSample devolve até limit textos recebidos no intervalo (para classificar intenções),
+// sem reações e mensagens só com emoji.
+func InboundSample(ctx context.Context, pool *pgxpool.Pool, from, to time.Time, limit int) ([]string, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT content FROM messages
+		WHERE role='user' AND ext_id IS NOT NULL AND type NOT IN ('emoji','reaction')
+		  AND created_at >= $1 AND created_at < $2
+		ORDER BY created_at DESC LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
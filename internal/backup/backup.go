@@ -0,0 +1,310 @@
+// internal/backup/backup.go
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+Backup e restauração dos dados de conversa (clientes, atributos e mensagens).
+
+O arquivo é JSON Lines comprimido com gzip (opcionalmente criptografado, ver
+crypt.go): a primeira linha é o cabeçalho e cada linha seguinte é uma linha de
+tabela (row_to_json), com os clientes antes das tabelas que dependem deles.
+
+A restauração nunca sobrescreve: linhas que já existem são puladas. Cliente que
+já existe com outro id (recriado depois da perda) é reaproveitado e os
+atributos/mensagens do backup passam a apontar para ele. Com Phone, restaura só
+aquele telefone. Tudo numa transação: se algo falhar, nada é gravado.
+*/
+
+// Format identifica o cabeçalho do arquivo.
+const Format = "leandro-backup"
+
+// Version do formato gravado por Dump.
+const Version = 1
+
+// Tables na ordem de dump/restauração (dependências primeiro).
+var Tables = []string{"clients", "client_facts", "messages"}
+
+// Header é a primeira linha do arquivo.
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Tables    []string  `json:"tables"`
+}
+
+type record struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// Stats conta linhas por tabela.
+type Stats map[string]int
+
+func (s Stats) String() string {
+	parts := make([]string, 0, len(Tables))
+	for _, t := range Tables {
+		parts = append(parts, fmt.Sprintf("%s=%d", t, s[t]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Dump grava todas as linhas de Tables em w, num instantâneo consistente
+// (transação REPEATABLE READ somente leitura).
+func Dump(ctx context.Context, pool *pgxpool.Pool, w io.Writer) (Stats, error) {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(Header{Format: Format, Version: Version, CreatedAt: time.Now().UTC(), Tables: Tables}); err != nil {
+		return nil, err
+	}
+	stats := Stats{}
+	for _, table := range Tables {
+		// nomes fixos de Tables: seguro interpolar
+		rows, err := tx.Query(ctx, `SELECT row_to_json(t)::text FROM `+table+` t ORDER BY id`)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %w", table, err)
+		}
+		for rows.Next() {
+			var row string
+			if err := rows.Scan(&row); err != nil {
+				rows.Close()
+				return stats, fmt.Errorf("%s: %w", table, err)
+			}
+			if err := enc.Encode(record{Table: table, Row: json.RawMessage(row)}); err != nil {
+				rows.Close()
+				return stats, err
+			}
+			stats[table]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return stats, fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return stats, err
+	}
+	return stats, zw.Close()
+}
+
+// RestoreOptions ajusta a restauração.
+type RestoreOptions struct {
+	Phone  string // só este telefone ("" = todos)
+	DryRun bool   // lê e confere tudo, mas desfaz a transação no fim
+}
+
+// RestoreStats conta as linhas gravadas e as puladas (já existiam ou fora do filtro).
+type RestoreStats struct {
+	Restored Stats
+	Skipped  Stats
+}
+
+// ErrFormat indica arquivo que não é um backup (ou de versão desconhecida).
+var ErrFormat = errors.New("not a backup file")
+
+// Restore lê um arquivo gravado por Dump (já descriptografado) e insere as linhas
+// que faltam no banco.
+func Restore(ctx context.Context, pool *pgxpool.Pool, r io.Reader, opts RestoreOptions) (RestoreStats, error) {
+	stats := RestoreStats{Restored: Stats{}, Skipped: Stats{}}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return stats, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	defer zr.Close()
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	var h Header
+	if err := dec.Decode(&h); err != nil || h.Format != Format {
+		return stats, ErrFormat
+	}
+	if h.Version > Version {
+		return stats, fmt.Errorf("%w: version %d", ErrFormat, h.Version)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback(ctx)
+
+	rs := &restorer{tx: tx, clients: map[int64]int64{}, columns: map[string]map[string]bool{}}
+	for {
+		var rec record
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return stats, fmt.Errorf("read: %w", err)
+		}
+		var row map[string]any
+		if err := json.Unmarshal(rec.Row, &row); err != nil {
+			return stats, fmt.Errorf("%s: %w", rec.Table, err)
+		}
+		var ok bool
+		switch rec.Table {
+		case "clients":
+			if opts.Phone != "" && row["phone"] != opts.Phone {
+				stats.Skipped[rec.Table]++
+				continue
+			}
+			ok, err = rs.client(ctx, row)
+		case "client_facts", "messages":
+			ok, err = rs.child(ctx, rec.Table, row)
+		default:
+			continue // tabela de versão futura
+		}
+		if err != nil {
+			return stats, fmt.Errorf("%s: %w", rec.Table, err)
+		}
+		if ok {
+			stats.Restored[rec.Table]++
+		} else {
+			stats.Skipped[rec.Table]++
+		}
+	}
+
+	if opts.DryRun {
+		return stats, nil
+	}
+	for _, table := range Tables {
+		// ids explícitos: a sequência precisa passar do maior restaurado (setval
+		// não é desfeito pelo rollback, por isso fica fora do dry run)
+		if _, err := tx.Exec(ctx, `SELECT setval(pg_get_serial_sequence($1, 'id'), COALESCE((SELECT MAX(id) FROM `+table+`), 0) + 1, false)`, table); err != nil {
+			return stats, fmt.Errorf("%s sequence: %w", table, err)
+		}
+	}
+	return stats, tx.Commit(ctx)
+}
+
+type restorer struct {
+	tx      pgx.Tx
+	clients map[int64]int64            // id no backup -> id no banco
+	columns map[string]map[string]bool // colunas atuais de cada tabela
+}
+
+// client insere o cliente ou reaproveita o que já existe com o mesmo telefone.
+func (rs *restorer) client(ctx context.Context, row map[string]any) (bool, error) {
+	oldID, ok := rowID(row["id"])
+	if !ok {
+		return false, errors.New("row without id")
+	}
+	tenantID, _ := rowID(row["tenant_id"])
+	var existing int64
+	err := rs.tx.QueryRow(ctx, `SELECT id FROM clients WHERE COALESCE(tenant_id, 0) = $1 AND phone = $2`,
+		tenantID, row["phone"]).Scan(&existing)
+	if err == nil {
+		rs.clients[oldID] = existing
+		return false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, err
+	}
+	var taken bool
+	if err := rs.tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM clients WHERE id = $1)`, oldID).Scan(&taken); err != nil {
+		return false, err
+	}
+	if taken {
+		// id ocupado por outro cliente: ganha um id novo
+		delete(row, "id")
+	}
+	newID, inserted, err := rs.insert(ctx, "clients", row)
+	if err != nil || !inserted {
+		return false, err
+	}
+	rs.clients[oldID] = newID
+	return true, nil
+}
+
+// child insere atributo/mensagem de um cliente restaurado (ou reaproveitado).
+func (rs *restorer) child(ctx context.Context, table string, row map[string]any) (bool, error) {
+	oldClient, _ := rowID(row["client_id"])
+	clientID, ok := rs.clients[oldClient]
+	if !ok {
+		return false, nil // cliente fora do filtro
+	}
+	row["client_id"] = clientID
+	if id, _ := rowID(row["id"]); id > 0 {
+		var taken bool
+		if err := rs.tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1)`, id).Scan(&taken); err != nil {
+			return false, err
+		}
+		if taken {
+			return false, nil // já restaurado (ou id reaproveitado): não sobrescreve
+		}
+	}
+	_, inserted, err := rs.insert(ctx, table, row)
+	return inserted, err
+}
+
+// insert grava as colunas do backup que ainda existem na tabela; as ausentes
+// ficam com o default. Conflito de unicidade não é erro: devolve inserted=false.
+func (rs *restorer) insert(ctx context.Context, table string, row map[string]any) (int64, bool, error) {
+	cols, err := rs.tableColumns(ctx, table)
+	if err != nil {
+		return 0, false, err
+	}
+	names := make([]string, 0, len(row))
+	for k := range row {
+		if cols[k] {
+			names = append(names, pgx.Identifier{k}.Sanitize())
+		}
+	}
+	b, err := json.Marshal(row)
+	if err != nil {
+		return 0, false, err
+	}
+	list := strings.Join(names, ", ")
+	var id int64
+	err = rs.tx.QueryRow(ctx, `INSERT INTO `+table+` (`+list+`)
+		SELECT `+list+` FROM json_populate_record(NULL::`+table+`, $1::json)
+		ON CONFLICT DO NOTHING RETURNING id`, string(b)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	return id, err == nil, err
+}
+
+func (rs *restorer) tableColumns(ctx context.Context, table string) (map[string]bool, error) {
+	if cols, ok := rs.columns[table]; ok {
+		return cols, nil
+	}
+	rows, err := rs.tx.Query(ctx, `SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := map[string]bool{}
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		cols[c] = true
+	}
+	rs.columns[table] = cols
+	return cols, rows.Err()
+}
+
+// rowID lê um id numérico do JSON decodificado.
+func rowID(v any) (int64, bool) {
+	f, ok := v.(float64)
+	return int64(f), ok
+}
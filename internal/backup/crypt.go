@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+Criptografia do arquivo (BACKUP_KEY): AES-256-GCM em blocos de 64 KiB.
+
+	"LBKENC1\n" | [tamanho uint32][nonce 12][cifrado] ...
+
+Cada bloco autentica o seu número e se é o último, então blocos trocados de
+ordem ou um arquivo truncado são detectados na leitura.
+*/
+
+var encMagic = []byte("LBKENC1\n")
+
+const chunkSize = 64 << 10
+
+// ErrKeyRequired indica backup criptografado sem BACKUP_KEY.
+var ErrKeyRequired = errors.New("backup is encrypted: BACKUP_KEY required")
+
+// ParseKey lê a chave de 32 bytes em base64 ou hex (ex.: openssl rand -base64 32).
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(s); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	return nil, errors.New("BACKUP_KEY must be 32 bytes in base64 or hex")
+}
+
+// Encrypt devolve um writer que criptografa para w. Close grava o último bloco
+// (não fecha w).
+func Encrypt(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(encMagic); err != nil {
+		return nil, err
+	}
+	return &encWriter{w: w, aead: aead}, nil
+}
+
+type encWriter struct {
+	w    io.Writer
+	aead cipher.AEAD
+	buf  []byte
+	n    uint64
+}
+
+func (e *encWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) > 0 {
+		room := chunkSize - len(e.buf)
+		if room > len(p) {
+			room = len(p)
+		}
+		e.buf = append(e.buf, p[:room]...)
+		p = p[room:]
+		// só sela o bloco cheio quando chega mais dado: o último vai no Close
+		if len(e.buf) == chunkSize && len(p) > 0 {
+			if err := e.seal(false); err != nil {
+				return total - len(p), err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (e *encWriter) Close() error { return e.seal(true) }
+
+func (e *encWriter) seal(last bool) error {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	out := e.aead.Seal(nil, nonce, e.buf, chunkAAD(e.n, last))
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(out)))
+	for _, part := range [][]byte{size[:], nonce, out} {
+		if _, err := e.w.Write(part); err != nil {
+			return err
+		}
+	}
+	e.buf, e.n = e.buf[:0], e.n+1
+	return nil
+}
+
+// Open detecta se r é criptografado e devolve o conteúdo em claro. key nil só
+// aceita arquivos sem criptografia.
+func Open(r io.Reader, key []byte) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(encMagic))
+	if err != nil || !bytes.Equal(head, encMagic) {
+		return br, nil
+	}
+	if key == nil {
+		return nil, ErrKeyRequired
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = br.Discard(len(encMagic))
+	return &decReader{r: br, aead: aead}, nil
+}
+
+type decReader struct {
+	r    io.Reader
+	aead cipher.AEAD
+	buf  []byte
+	n    uint64
+	done bool
+}
+
+func (d *decReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// next lê e abre um bloco. O último é reconhecido pelo AAD; fim do arquivo
+// antes dele é truncamento.
+func (d *decReader) next() error {
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		return fmt.Errorf("encrypted backup truncated: %w", err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > chunkSize+uint32(d.aead.Overhead()) {
+		return errors.New("encrypted backup corrupted: invalid chunk size")
+	}
+	chunk := make([]byte, d.aead.NonceSize()+int(n))
+	if _, err := io.ReadFull(d.r, chunk); err != nil {
+		return fmt.Errorf("encrypted backup truncated: %w", err)
+	}
+	nonce, sealed := chunk[:d.aead.NonceSize()], chunk[d.aead.NonceSize():]
+	plain, err := d.aead.Open(nil, nonce, sealed, chunkAAD(d.n, false))
+	if err != nil {
+		if plain, err = d.aead.Open(nil, nonce, sealed, chunkAAD(d.n, true)); err != nil {
+			return errors.New("encrypted backup: wrong BACKUP_KEY or corrupted file")
+		}
+		d.done = true
+	}
+	d.buf, d.n = plain, d.n+1
+	return nil
+}
+
+func chunkAAD(n uint64, last bool) []byte {
+	aad := make([]byte, 9)
+	binary.BigEndian.PutUint64(aad, n)
+	if last {
+		aad[8] = 1
+	}
+	return aad
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
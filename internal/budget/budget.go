@@ -0,0 +1,191 @@
+// internal/budget/budget.go
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// Limits são os tetos de um escopo (global ou por cliente). Valores <= 0 não limitam.
+type Limits struct {
+	DailyTokens   int64
+	MonthlyTokens int64
+	DailyUSD      float64
+	MonthlyUSD    float64
+}
+
+func (l Limits) enabled() bool {
+	return l.DailyTokens > 0 || l.MonthlyTokens > 0 || l.DailyUSD > 0 || l.MonthlyUSD > 0
+}
+
+// Config define os orçamentos e como avisar quando um limiar é atingido.
+type Config struct {
+	Global    Limits
+	PerClient Limits
+	CostPer1K float64        // USD por 1000 tokens, para estimar o custo de cada run
+	Location  *time.Location // fuso em que o dia/mês começam
+	Alert     func(msg string)
+}
+
+// Status é a situação do orçamento para uma conversa.
+type Status struct {
+	Exceeded bool    `json:"exceeded"`
+	Scope    string  `json:"scope,omitempty"`  // global | client
+	Period   string  `json:"period,omitempty"` // day | month
+	Ratio    float64 `json:"ratio"`            // maior fração consumida entre os limites
+}
+
+// Guard consulta o consumo registrado em openai_usage e compara com os limites.
+// Avisos de 80% e 100% são emitidos uma vez por escopo/período.
+type Guard struct {
+	pool *pgxpool.Pool
+	cfg  Config
+
+	mu      sync.Mutex
+	alerted map[string]bool
+}
+
+func New(pool *pgxpool.Pool, cfg Config) *Guard {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	return &Guard{pool: pool, cfg: cfg, alerted: make(map[string]bool)}
+}
+
+// Enabled indica se há algum limite configurado.
+func (g *Guard) Enabled() bool {
+	return g.cfg.Global.enabled() || g.cfg.PerClient.enabled()
+}
+
+func (g *Guard) periods(now time.Time) (time.Time, time.Time) {
+	now = now.In(g.cfg.Location)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, g.cfg.Location)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, g.cfg.Location)
+	return day, month
+}
+
+// Check avalia os limites globais e do cliente antes de uma nova run.
+func (g *Guard) Check(ctx context.Context, clientID int64) (Status, error) {
+	var st Status
+	if !g.Enabled() {
+		return st, nil
+	}
+	day, month := g.periods(time.Now())
+	if g.cfg.Global.enabled() {
+		d, m, err := models.UsageSince(ctx, g.pool, nil, day, month)
+		if err != nil {
+			return st, err
+		}
+		st = worst(st, evaluate("global", g.cfg.Global, d, m))
+	}
+	if g.cfg.PerClient.enabled() && clientID > 0 {
+		d, m, err := models.UsageSince(ctx, g.pool, &clientID, day, month)
+		if err != nil {
+			return st, err
+		}
+		cst := evaluate("client", g.cfg.PerClient, d, m)
+		if cst.Exceeded && g.cfg.Alert != nil {
+			start := day
+			if cst.Period == "month" {
+				start = month
+			}
+			g.once(fmt.Sprintf("client|%d|%s", clientID, start.Format("2006-01-02")), fmt.Sprintf(
+				"Orçamento OpenAI por cliente atingido (cliente %d, %s): %d tokens hoje, %d no mês", clientID, periodName(cst.Period), d.Tokens, m.Tokens))
+		}
+		st = worst(st, cst)
+	}
+	return st, nil
+}
+
+// Record grava o consumo de uma run e dispara os avisos de limiar globais.
+func (g *Guard) Record(ctx context.Context, clientID int64, model string, prompt, completion, total int64) error {
+	u := models.Usage{
+		Model: model, PromptTokens: prompt, CompletionTokens: completion, TotalTokens: total,
+		CostUSD: float64(total) / 1000 * g.cfg.CostPer1K,
+	}
+	if clientID > 0 {
+		u.ClientID = &clientID
+	}
+	if err := models.RecordUsage(ctx, g.pool, u); err != nil {
+		return err
+	}
+	if !g.cfg.Global.enabled() || g.cfg.Alert == nil {
+		return nil
+	}
+	now := time.Now()
+	day, month := g.periods(now)
+	d, m, err := models.UsageSince(ctx, g.pool, nil, day, month)
+	if err != nil {
+		return err
+	}
+	g.alert("dia "+day.Format("2006-01-02"), ratio(g.cfg.Global.DailyTokens, g.cfg.Global.DailyUSD, d), d)
+	g.alert("mês "+month.Format("2006-01"), ratio(g.cfg.Global.MonthlyTokens, g.cfg.Global.MonthlyUSD, m), m)
+	return nil
+}
+
+func (g *Guard) alert(period string, r float64, t models.UsageTotals) {
+	for _, threshold := range []float64{1.0, 0.8} {
+		if r < threshold {
+			continue
+		}
+		g.once(fmt.Sprintf("%s|%.0f", period, threshold*100), fmt.Sprintf(
+			"Orçamento OpenAI (%s): %.0f%% consumido — %d tokens, US$ %.2f", period, r*100, t.Tokens, t.CostUSD))
+		return
+	}
+}
+
+// once emite o aviso uma única vez por chave.
+func (g *Guard) once(key, msg string) {
+	g.mu.Lock()
+	done := g.alerted[key]
+	g.alerted[key] = true
+	g.mu.Unlock()
+	if !done {
+		g.cfg.Alert(msg)
+	}
+}
+
+func periodName(p string) string {
+	if p == "month" {
+		return "limite mensal"
+	}
+	return "limite diário"
+}
+
+// evaluate compara os totais do dia/mês com os limites do escopo.
+func evaluate(scope string, l Limits, d, m models.UsageTotals) Status {
+	st := Status{Scope: scope}
+	if r := ratio(l.DailyTokens, l.DailyUSD, d); r > st.Ratio {
+		st.Ratio, st.Period = r, "day"
+	}
+	if r := ratio(l.MonthlyTokens, l.MonthlyUSD, m); r > st.Ratio {
+		st.Ratio, st.Period = r, "month"
+	}
+	st.Exceeded = st.Ratio >= 1
+	return st
+}
+
+func ratio(tokens int64, usd float64, t models.UsageTotals) float64 {
+	r := 0.0
+	if tokens > 0 {
+		r = float64(t.Tokens) / float64(tokens)
+	}
+	if usd > 0 {
+		if x := t.CostUSD / usd; x > r {
+			r = x
+		}
+	}
+	return r
+}
+
+func worst(a, b Status) Status {
+	if b.Exceeded && !a.Exceeded || b.Exceeded == a.Exceeded && b.Ratio > a.Ratio {
+		return b
+	}
+	return a
+}
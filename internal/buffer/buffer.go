@@ -5,6 +5,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/your-org/leandro-agent/internal/clock"
 )
 
 // buffer guarda mensagens, um timer e uma "geração" para invalidar timers antigos.
@@ -13,8 +15,9 @@ type buffer struct {
 	mu       sync.Mutex
 	msgs     []string
 	lastKind string
-	timer    *time.Timer
+	timer    clock.Timer
 	gen      uint64
+	firstAt  time.Time // chegada da primeira mensagem pendente (limite das extensões)
 }
 
 // Manager gerencia buffers por telefone e dispara o flush após timeout
@@ -24,6 +27,7 @@ type Manager struct {
 	buffers   map[string]*buffer
 	timeout   time.Duration
 	flushFunc func(phone, combined, lastKind string)
+	clock     clock.Clock
 }
 
 func NewManager(timeout time.Duration, flushFunc func(phone, combined, lastKind string)) *Manager {
@@ -31,12 +35,28 @@ func NewManager(timeout time.Duration, flushFunc func(phone, combined, lastKind
 		buffers:   make(map[string]*buffer),
 		timeout:   timeout,
 		flushFunc: flushFunc,
+		clock:     clock.Real,
 	}
 }
 
+// WithClock troca o relógio dos timers (clock.Fake em testes simula a janela sem
+// esperar). Chamar antes do primeiro AddMessage.
+func (m *Manager) WithClock(c clock.Clock) *Manager {
+	m.clock = clock.Or(c)
+	return m
+}
+
 // AddMessage adiciona a mensagem ao buffer do telefone e reinicia o timer (debounce deslizante).
 // Mensagens consecutivas iguais são ignoradas. Guarda o tipo da ÚLTIMA mensagem (kind).
 func (m *Manager) AddMessage(phone, text, kind string) {
+	m.AddMessageWithTimeout(phone, text, kind, m.timeout)
+}
+
+// AddMessageWithTimeout é AddMessage com janela própria (ajuste por número do bot).
+func (m *Manager) AddMessageWithTimeout(phone, text, kind string, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = m.timeout
+	}
 	normalized := strings.TrimSpace(text)
 	if normalized == "" {
 		return
@@ -51,6 +71,9 @@ func (m *Manager) AddMessage(phone, text, kind string) {
 	m.mu.Unlock()
 
 	buf.mu.Lock()
+	if len(buf.msgs) == 0 {
+		buf.firstAt = m.clock.Now()
+	}
 	// dedupe consecutivo
 	n := len(buf.msgs)
 	if n == 0 || buf.msgs[n-1] != normalized {
@@ -65,10 +88,40 @@ func (m *Manager) AddMessage(phone, text, kind string) {
 	if buf.timer != nil {
 		buf.timer.Stop()
 	}
-	buf.timer = time.AfterFunc(m.timeout, func() { m.flushIfCurrent(phone, currentGen) })
+	buf.timer = m.clock.AfterFunc(timeout, func() { m.flushIfCurrent(phone, currentGen) })
 	buf.mu.Unlock()
 }
 
+// Extend adia o flush do telefone enquanto o usuário está digitando: o timer passa a
+// disparar em `by`, sem ultrapassar maxWait contado da primeira mensagem pendente.
+// Não cria buffer: sem mensagens pendentes, não há o que adiar.
+func (m *Manager) Extend(phone string, by, maxWait time.Duration) {
+	m.mu.Lock()
+	buf, ok := m.buffers[phone]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	if len(buf.msgs) == 0 || buf.timer == nil {
+		return
+	}
+	if maxWait > 0 {
+		if left := buf.firstAt.Add(maxWait).Sub(m.clock.Now()); left < by {
+			by = left
+		}
+	}
+	if by <= 0 {
+		return
+	}
+	buf.gen++
+	currentGen := buf.gen
+	buf.timer.Stop()
+	buf.timer = m.clock.AfterFunc(by, func() { m.flushIfCurrent(phone, currentGen) })
+}
+
 // flushIfCurrent só executa o flush se a geração do timer ainda for a atual.
 // Evita flush duplo quando uma mensagem chega no fim da janela.
 func (m *Manager) flushIfCurrent(phone string, genAtSchedule uint64) {
@@ -96,7 +149,28 @@ func (m *Manager) flushIfCurrent(phone string, genAtSchedule uint64) {
 		m.flushFunc(phone, combined, lastKind)
 	}
 
+	// uma mensagem que chegou durante o flush abriu nova geração no mesmo buffer:
+	// ele fica para o próximo timer
 	m.mu.Lock()
-	delete(m.buffers, phone)
+	buf.mu.Lock()
+	if m.buffers[phone] == buf && buf.gen == genAtSchedule {
+		delete(m.buffers, phone)
+	}
+	buf.mu.Unlock()
 	m.mu.Unlock()
 }
+
+// Pending devolve quantos telefones têm buffer aberto (aguardando o flush).
+func (m *Manager) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.buffers)
+}
+
+// Has indica se o telefone já tem mensagens aguardando o flush.
+func (m *Manager) Has(phone string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.buffers[phone]
+	return ok
+}
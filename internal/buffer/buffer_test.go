@@ -0,0 +1,203 @@
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/clock"
+)
+
+type flush struct {
+	phone, combined, lastKind string
+	at                        time.Time
+}
+
+var start = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestManager devolve um Manager com relógio manual e os flushes registrados.
+func newTestManager(timeout time.Duration) (*Manager, *clock.Fake, *[]flush) {
+	fake := clock.NewFake(start)
+	var got []flush
+	m := NewManager(timeout, func(phone, combined, lastKind string) {
+		got = append(got, flush{phone, combined, lastKind, fake.Now()})
+	}).WithClock(fake)
+	return m, fake, &got
+}
+
+func TestDebounceWindow(t *testing.T) {
+	m, fake, got := newTestManager(10 * time.Second)
+
+	m.AddMessage("5511", "oi", "text")
+	fake.Advance(9 * time.Second)
+	if len(*got) != 0 {
+		t.Fatalf("flushed before the window: %+v", *got)
+	}
+	// nova mensagem reinicia a janela
+	m.AddMessage("5511", "tudo bem?", "audio")
+	fake.Advance(9 * time.Second)
+	if len(*got) != 0 {
+		t.Fatalf("flushed before the restarted window: %+v", *got)
+	}
+	fake.Advance(time.Second)
+	if len(*got) != 1 {
+		t.Fatalf("flushes = %d, want 1", len(*got))
+	}
+	f := (*got)[0]
+	if want := "Mensagens recentes do usuário:\n- oi\n- tudo bem?"; f.combined != want {
+		t.Errorf("combined = %q, want %q", f.combined, want)
+	}
+	if f.lastKind != "audio" {
+		t.Errorf("lastKind = %q, want audio", f.lastKind)
+	}
+	if want := start.Add(19 * time.Second); !f.at.Equal(want) {
+		t.Errorf("flushed at %v, want %v", f.at, want)
+	}
+	if m.Pending() != 0 || m.Has("5511") {
+		t.Errorf("buffer still open after flush: pending=%d", m.Pending())
+	}
+}
+
+func TestDedupeAndEmpty(t *testing.T) {
+	m, fake, got := newTestManager(time.Second)
+
+	m.AddMessage("5511", "   ", "text")
+	if m.Has("5511") {
+		t.Fatal("blank message opened a buffer")
+	}
+	m.AddMessage("5511", "oi", "text")
+	m.AddMessage("5511", " oi ", "text")
+	m.AddMessage("5511", "oi?", "text")
+	m.AddMessage("5511", "oi", " TEXT ")
+	fake.Advance(time.Second)
+	if len(*got) != 1 {
+		t.Fatalf("flushes = %d, want 1", len(*got))
+	}
+	if want := "Mensagens recentes do usuário:\n- oi\n- oi?\n- oi"; (*got)[0].combined != want {
+		t.Errorf("combined = %q, want %q", (*got)[0].combined, want)
+	}
+	if (*got)[0].lastKind != "text" {
+		t.Errorf("lastKind = %q, want text", (*got)[0].lastKind)
+	}
+}
+
+func TestPhonesAreIndependent(t *testing.T) {
+	m, fake, got := newTestManager(10 * time.Second)
+
+	m.AddMessage("a", "1", "text")
+	m.AddMessageWithTimeout("b", "2", "text", 3*time.Second)
+	m.AddMessageWithTimeout("c", "3", "text", 0) // 0 = janela padrão
+	if m.Pending() != 3 {
+		t.Fatalf("Pending() = %d, want 3", m.Pending())
+	}
+	fake.Advance(3 * time.Second)
+	if len(*got) != 1 || (*got)[0].phone != "b" {
+		t.Fatalf("flushes after 3s = %+v, want only b", *got)
+	}
+	fake.Advance(7 * time.Second)
+	if len(*got) != 3 {
+		t.Fatalf("flushes after 10s = %d, want 3", len(*got))
+	}
+	if m.Pending() != 0 {
+		t.Errorf("Pending() = %d after all flushes", m.Pending())
+	}
+}
+
+func TestExtend(t *testing.T) {
+	m, fake, got := newTestManager(5 * time.Second)
+
+	m.Extend("5511", time.Minute, 0) // sem buffer: nada a adiar
+	if m.Has("5511") || fake.Pending() != 0 {
+		t.Fatal("Extend without messages opened a buffer")
+	}
+
+	m.AddMessage("5511", "oi", "text")
+	fake.Advance(4 * time.Second)
+	m.Extend("5511", 10*time.Second, 0) // digitando: adia 10s a partir de agora
+	fake.Advance(9 * time.Second)
+	if len(*got) != 0 {
+		t.Fatalf("flushed while extended: %+v", *got)
+	}
+	fake.Advance(time.Second)
+	if len(*got) != 1 {
+		t.Fatalf("flushes = %d, want 1 at the end of the extension", len(*got))
+	}
+	if want := start.Add(14 * time.Second); !(*got)[0].at.Equal(want) {
+		t.Errorf("flushed at %v, want %v", (*got)[0].at, want)
+	}
+}
+
+func TestExtendMaxWait(t *testing.T) {
+	m, fake, got := newTestManager(5 * time.Second)
+	base := fake.Now()
+
+	m.AddMessage("5511", "oi", "text")
+	fake.Advance(4 * time.Second)
+	// maxWait de 6s desde a primeira mensagem: só sobram 2s dos 10s pedidos
+	m.Extend("5511", 10*time.Second, 6*time.Second)
+	fake.Advance(2 * time.Second)
+	if len(*got) != 1 {
+		t.Fatalf("flushes = %d, want 1 at maxWait", len(*got))
+	}
+	if want := base.Add(6 * time.Second); !(*got)[0].at.Equal(want) {
+		t.Errorf("flushed at %v, want %v", (*got)[0].at, want)
+	}
+
+	// maxWait já estourado: Extend não mexe no timer
+	m.AddMessage("5511", "de novo", "text")
+	fake.Advance(3 * time.Second)
+	m.Extend("5511", time.Minute, 3*time.Second)
+	fake.Advance(2 * time.Second)
+	if len(*got) != 2 {
+		t.Fatalf("flushes = %d, want 2 (Extend past maxWait must not delay)", len(*got))
+	}
+}
+
+func TestStaleGenerationIgnored(t *testing.T) {
+	m, fake, got := newTestManager(5 * time.Second)
+
+	m.AddMessage("5511", "oi", "text")   // geração 1
+	m.AddMessage("5511", "tudo", "text") // geração 2
+	// um timer antigo que dispara (ex.: Stop perdeu a corrida) não faz o flush
+	m.flushIfCurrent("5511", 1)
+	if len(*got) != 0 || !m.Has("5511") {
+		t.Fatalf("stale timer flushed: %+v", *got)
+	}
+	m.Extend("5511", 10*time.Second, 0) // geração 3
+	m.flushIfCurrent("5511", 2)
+	if len(*got) != 0 {
+		t.Fatalf("timer replaced by Extend flushed: %+v", *got)
+	}
+	fake.Advance(10 * time.Second)
+	if len(*got) != 1 {
+		t.Fatalf("flushes = %d, want 1", len(*got))
+	}
+	m.flushIfCurrent("5511", 3) // buffer já fechado
+	if len(*got) != 1 {
+		t.Fatalf("flush after close ran again: %+v", *got)
+	}
+}
+
+func TestMessageDuringFlushIsKept(t *testing.T) {
+	fake := clock.NewFake(start)
+	var (
+		m   *Manager
+		got []string
+	)
+	m = NewManager(5*time.Second, func(phone, combined, _ string) {
+		got = append(got, combined)
+		if len(got) == 1 {
+			// chega outra mensagem enquanto o flush anterior ainda roda
+			m.AddMessage(phone, "mais uma", "text")
+		}
+	}).WithClock(fake)
+
+	m.AddMessage("5511", "oi", "text")
+	fake.Advance(5 * time.Second)
+	if !m.Has("5511") {
+		t.Fatal("buffer with the message received during the flush was dropped")
+	}
+	fake.Advance(5 * time.Second)
+	if len(got) != 2 || got[1] != "Mensagens recentes do usuário:\n- mais uma" {
+		t.Fatalf("flushes = %q, want the second message flushed on its own", got)
+	}
+}
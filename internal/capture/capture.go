@@ -0,0 +1,218 @@
+// internal/capture/capture.go
+package capture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry é uma chamada HTTP capturada, já sem segredos nem mídia.
+type Entry struct {
+	ID          uint64            `json:"id"`
+	At          time.Time         `json:"at"`
+	Provider    string            `json:"provider"` // openai | uazapi
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	Status      int               `json:"status,omitempty"`
+	DurationMs  int64             `json:"duration_ms"`
+	ReqHeaders  map[string]string `json:"request_headers,omitempty"`
+	ReqBody     string            `json:"request_body,omitempty"`
+	RespHeaders map[string]string `json:"response_headers,omitempty"`
+	RespBody    string            `json:"response_body,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// State descreve a captura para o endpoint administrativo.
+type State struct {
+	Enabled bool       `json:"enabled"`
+	Until   *time.Time `json:"until,omitempty"`
+	Entries int        `json:"entries"`
+	Size    int        `json:"size"`
+}
+
+// Recorder guarda as últimas chamadas num buffer circular. Desligado, o transporte
+// só repassa a requisição; ligado, sempre com prazo, para não ficar ativo esquecido.
+type Recorder struct {
+	mu      sync.Mutex
+	until   time.Time
+	entries []Entry
+	next    int
+	full    bool
+	seq     uint64
+	maxBody int
+}
+
+// New cria o gravador com espaço para size chamadas e corpos de até maxBody bytes.
+func New(size, maxBody int) *Recorder {
+	if size <= 0 {
+		size = 200
+	}
+	if maxBody <= 0 {
+		maxBody = 64 << 10
+	}
+	return &Recorder{entries: make([]Entry, size), maxBody: maxBody}
+}
+
+// Enable liga a captura por d.
+func (r *Recorder) Enable(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.until = time.Now().Add(d)
+}
+
+// Disable desliga a captura; o que já foi gravado continua disponível.
+func (r *Recorder) Disable() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.until = time.Time{}
+}
+
+// Active indica se a captura está ligada agora.
+func (r *Recorder) Active() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Now().Before(r.until)
+}
+
+// State resume a captura (ligada, prazo, ocupação do buffer).
+func (r *Recorder) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := State{Size: len(r.entries), Entries: r.next}
+	if r.full {
+		st.Entries = len(r.entries)
+	}
+	if time.Now().Before(r.until) {
+		until := r.until
+		st.Enabled, st.Until = true, &until
+	}
+	return st
+}
+
+// Entries devolve as chamadas mais recentes primeiro, opcionalmente de um provedor só.
+func (r *Recorder) Entries(provider string, limit int) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next
+	if r.full {
+		n = len(r.entries)
+	}
+	out := make([]Entry, 0, n)
+	for i := 1; i <= n; i++ {
+		e := r.entries[(r.next-i+len(r.entries))%len(r.entries)]
+		if provider != "" && e.Provider != provider {
+			continue
+		}
+		out = append(out, e)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+// Clear descarta as chamadas gravadas.
+func (r *Recorder) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.entries)
+	r.next, r.full = 0, false
+}
+
+func (r *Recorder) add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.ID = r.seq
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Transport envolve base (nil = http.DefaultTransport) gravando as chamadas do provedor.
+func (r *Recorder) Transport(provider string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{rec: r, provider: provider, base: base}
+}
+
+type transport struct {
+	rec      *Recorder
+	provider string
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.rec.Active() {
+		return t.base.RoundTrip(req)
+	}
+	e := Entry{
+		At:         time.Now(),
+		Provider:   t.provider,
+		Method:     req.Method,
+		URL:        redactURL(req.URL),
+		ReqHeaders: redactHeaders(req.Header),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// a requisição original não é alterada (RoundTripper não pode mexer nela)
+		clone := req.Clone(req.Context())
+		clone.Body = io.NopCloser(bytes.NewReader(b))
+		clone.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }
+		req = clone
+		e.ReqBody = sanitizeBody(req.Header.Get("Content-Type"), b, t.rec.maxBody)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	e.DurationMs = time.Since(e.At).Milliseconds()
+	if err != nil {
+		e.Error = err.Error()
+		t.rec.add(e)
+		return nil, err
+	}
+	e.Status = resp.StatusCode
+	e.RespHeaders = redactHeaders(resp.Header)
+	if isMedia(resp.Header.Get("Content-Type")) {
+		// áudio, imagens e arquivos baixados: não vale a pena ler só para registrar
+		e.RespBody = elided(resp.Header.Get("Content-Type"), int(resp.ContentLength))
+		t.rec.add(e)
+		return resp, nil
+	}
+	b, rerr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if rerr != nil {
+		// o chamador recebe o mesmo erro ao ler o corpo
+		e.Error = rerr.Error()
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(b), errReader{rerr}))
+	} else {
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	e.RespBody = sanitizeBody(resp.Header.Get("Content-Type"), b, t.rec.maxBody)
+	t.rec.add(e)
+	return resp, nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func isMedia(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	for _, p := range []string{"audio/", "image/", "video/", "application/pdf", "application/octet-stream", "multipart/"} {
+		if strings.HasPrefix(ct, p) {
+			return true
+		}
+	}
+	return false
+}
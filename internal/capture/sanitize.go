@@ -0,0 +1,132 @@
+package capture
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// sensitiveKey reconhece nomes de cabeçalho, parâmetro ou campo JSON com segredo.
+func sensitiveKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range []string{"authorization", "token", "api_key", "apikey", "api-key", "secret", "password", "cookie", "signature"} {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if sensitiveKey(k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	if q := c.Query(); len(q) > 0 {
+		for k := range q {
+			if sensitiveKey(k) {
+				q.Set(k, redacted)
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// sanitizeBody prepara o corpo para o registro: JSON sai sem segredos e sem mídia
+// em base64, texto é só cortado em max bytes e o resto vira um marcador.
+func sanitizeBody(contentType string, b []byte, max int) string {
+	if len(b) == 0 {
+		return ""
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case isMedia(mt):
+		return elided(mt, len(b))
+	case mt == "application/json" || strings.HasSuffix(mt, "+json") || (mt == "" && json.Valid(b)):
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return truncate(string(b), max)
+		}
+		out, _ := json.Marshal(scrubJSON(v))
+		return truncate(string(out), max)
+	case mt == "application/x-www-form-urlencoded":
+		q, err := url.ParseQuery(string(b))
+		if err != nil {
+			return truncate(string(b), max)
+		}
+		for k := range q {
+			if sensitiveKey(k) {
+				q.Set(k, redacted)
+			}
+		}
+		return truncate(q.Encode(), max)
+	case strings.HasPrefix(mt, "text/") || mt == "":
+		return truncate(string(b), max)
+	}
+	return elided(mt, len(b))
+}
+
+var base64Re = regexp.MustCompile(`^(data:[\w/+.-]+;base64,)?[A-Za-z0-9+/=\r\n]+$`)
+
+// scrubJSON troca segredos por [redacted] e strings longas em base64 (mídia) por um marcador.
+func scrubJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if s, ok := val.(string); ok && sensitiveKey(k) && s != "" {
+				t[k] = redacted
+				continue
+			}
+			t[k] = scrubJSON(val)
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = scrubJSON(t[i])
+		}
+		return t
+	case string:
+		if len(t) > 512 && base64Re.MatchString(t) {
+			return elided("base64", len(t))
+		}
+		return t
+	}
+	return v
+}
+
+func elided(kind string, n int) string {
+	if kind == "" {
+		kind = "binary"
+	}
+	if n < 0 {
+		return "[" + kind + " omitted]"
+	}
+	return "[" + kind + ", " + strconv.Itoa(n) + " bytes omitted]"
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "…[" + strconv.Itoa(len(s)-max) + " bytes truncated]"
+}
@@ -0,0 +1,191 @@
+// internal/catalog/catalog.go
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Busca aproximada no catálogo e formatação para o WhatsApp.
+
+Catálogos são pequenos (centenas a poucos milhares de itens): a busca carrega os
+produtos ativos e pontua em memória, tolerando acento, caixa, plural simples e
+erro de digitação (distância de edição 1 em palavras de 4+ letras, 2 em 8+).
+*/
+
+// Search devolve até limit produtos que casam com a consulta, melhores primeiro.
+// Consulta vazia devolve os primeiros do catálogo.
+func Search(products []models.Product, query string, limit int) []models.Product {
+	if limit <= 0 {
+		limit = 5
+	}
+	terms := tokens(query)
+	type scored struct {
+		p     models.Product
+		score int
+	}
+	var hits []scored
+	for _, p := range products {
+		if len(terms) == 0 {
+			hits = append(hits, scored{p, 0})
+			continue
+		}
+		if s := score(p, terms); s > 0 {
+			hits = append(hits, scored{p, s})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	out := make([]models.Product, 0, min(limit, len(hits)))
+	for _, h := range hits {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, h.p)
+	}
+	return out
+}
+
+// score soma a melhor correspondência de cada termo; termo sem correspondência
+// zera o produto (todos os termos precisam aparecer de alguma forma).
+func score(p models.Product, terms []string) int {
+	if sku := fold(p.SKU); sku != "" && fold(strings.Join(terms, "")) == sku {
+		return 1000
+	}
+	name, other := tokens(p.Name), tokens(p.Category+" "+p.Description+" "+p.SKU)
+	total := 0
+	for _, t := range terms {
+		best := max(match(t, name)*3, match(t, other))
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// match pontua um termo contra as palavras: igual 10, prefixo 6, erro de digitação 4.
+func match(term string, words []string) int {
+	best := 0
+	for _, w := range words {
+		switch {
+		case w == term || singular(w) == singular(term):
+			return 10
+		case len(term) >= 3 && strings.HasPrefix(w, term):
+			best = max(best, 6)
+		case typo(term, w):
+			best = max(best, 4)
+		}
+	}
+	return best
+}
+
+func typo(a, b string) bool {
+	n := min(len([]rune(a)), len([]rune(b)))
+	switch {
+	case n >= 8:
+		return distance(a, b) <= 2
+	case n >= 4:
+		return distance(a, b) <= 1
+	}
+	return false
+}
+
+// singular tira o plural simples do português ("portas" -> "porta", "vidroes" fica).
+func singular(w string) string {
+	if len(w) > 3 && strings.HasSuffix(w, "s") {
+		return strings.TrimSuffix(w, "s")
+	}
+	return w
+}
+
+// distance é a distância de Levenshtein entre a e b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+)
+
+func fold(s string) string {
+	return accents.Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// stopwords não contam na busca ("preço da porta de vidro" -> porta, vidro).
+var stopwords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "de": true, "da": true, "do": true, "das": true,
+	"dos": true, "e": true, "em": true, "um": true, "uma": true, "para": true, "pra": true,
+	"com": true, "preco": true, "valor": true, "quanto": true, "custa": true, "qual": true,
+}
+
+func tokens(s string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(fold(s), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if !stopwords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// Price formata o preço em reais ("R$ 1.234,50"); nil é "sob consulta".
+func Price(v *float64) string {
+	if v == nil {
+		return "sob consulta"
+	}
+	cents := int64(*v*100 + 0.5)
+	reais := strconv.FormatInt(cents/100, 10)
+	var b strings.Builder
+	for i, c := range reais {
+		if i > 0 && (len(reais)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return fmt.Sprintf("R$ %s,%02d", b.String(), cents%100)
+}
+
+// Format descreve o produto para uma mensagem do WhatsApp:
+//
+//	*Porta de vidro 2,10m* (PV-210)
+//	R$ 1.234,50 / un
+//	Vidro temperado 8mm, incolor.
+func Format(p models.Product) string {
+	var b strings.Builder
+	b.WriteString("*" + p.Name + "*")
+	if p.SKU != "" {
+		b.WriteString(" (" + p.SKU + ")")
+	}
+	b.WriteString("\n" + Price(p.Price))
+	if p.Price != nil && p.Unit != "" {
+		b.WriteString(" / " + p.Unit)
+	}
+	if d := strings.TrimSpace(p.Description); d != "" {
+		b.WriteString("\n" + d)
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+// internal/clock/clock.go
+package clock
+
+import (
+	crand "crypto/rand"
+	"math/big"
+	"time"
+)
+
+/*
+Relógio e sorteio injetáveis.
+
+Buffer, atraso de resposta e jobs agendados dependem de tempo e de sorteio; com
+Clock e Rand no lugar de time.* e crypto/rand, um teste simula janelas de
+debounce, atrasos e horários com Fake (avançando o relógio à mão) sem dormir.
+Em produção vale Real e Crypto.
+*/
+
+// Clock é a parte do pacote time usada pelos componentes com temporizadores.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer é um temporizador criado por AfterFunc.
+type Timer interface {
+	Stop() bool
+}
+
+// Ticker é um ticker criado por NewTicker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real é o relógio do sistema.
+var Real Clock = realClock{}
+
+// Or devolve c, ou Real se c for nil.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+// Rand sorteia inteiros em [0, n).
+type Rand interface {
+	Int63n(n int64) int64
+}
+
+// RandFunc adapta uma função a Rand (ex.: sorteio fixo em testes).
+type RandFunc func(n int64) int64
+
+func (f RandFunc) Int63n(n int64) int64 { return f(n) }
+
+// Crypto sorteia com crypto/rand (seguro entre goroutines, sem semente).
+var Crypto Rand = cryptoRand{}
+
+type cryptoRand struct{}
+
+func (cryptoRand) Int63n(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	v, err := crand.Int(crand.Reader, big.NewInt(n))
+	if err != nil {
+		return 0
+	}
+	return v.Int64()
+}
@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake é um relógio manual: o tempo só anda com Advance, que dispara em ordem os
+// temporizadores vencidos (AfterFunc roda na goroutine de Advance).
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	seq     int
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	fake   *Fake
+	id     int
+	at     time.Time
+	period time.Duration // > 0 para tickers
+	fn     func()
+	ch     chan time.Time
+}
+
+// NewFake cria um relógio parado em start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	f.add(&fakeWaiter{at: f.Now().Add(d), ch: ch})
+	return ch
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	return f.add(&fakeWaiter{at: f.Now().Add(d), fn: fn})
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(&fakeWaiter{at: f.Now().Add(d), period: d, ch: make(chan time.Time, 1)})}
+}
+
+// Pending devolve quantos temporizadores aguardam (útil para esperar o código
+// testado agendar antes de avançar).
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// Advance anda o relógio d, disparando os temporizadores vencidos na ordem.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	end := f.now.Add(d)
+	f.mu.Unlock()
+	for {
+		f.mu.Lock()
+		sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+		if len(f.waiters) == 0 || f.waiters[0].at.After(end) {
+			f.now = end
+			f.mu.Unlock()
+			return
+		}
+		w := f.waiters[0]
+		f.now = w.at
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			f.waiters = f.waiters[1:]
+		}
+		now := f.now
+		f.mu.Unlock()
+
+		if w.fn != nil {
+			w.fn()
+		} else {
+			select {
+			case w.ch <- now:
+			default: // como no time.Ticker, ticks não lidos são descartados
+			}
+		}
+	}
+}
+
+func (f *Fake) add(w *fakeWaiter) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	w.fake, w.id = f, f.seq
+	f.waiters = append(f.waiters, w)
+	return w
+}
+
+func (f *Fake) remove(id int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, w := range f.waiters {
+		if w.id == id {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fakeWaiter) Stop() bool { return w.fake.remove(w.id) }
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()               { t.w.Stop() }
@@ -1,13 +1,14 @@
 package config
 
 import (
-	crand "crypto/rand"
+	"encoding/json"
 	"log"
-	"math/big"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/your-org/leandro-agent/internal/clock"
 )
 
 type Config struct {
@@ -18,23 +19,476 @@ type Config struct {
 	OpenAIAssistantID     string
 	OpenAIChatModel       string
 	OpenAITranscribeModel string
+	OpenAIMemoryModel     string
+	OpenAIBaseURL         string // ENV: OPENAI_BASE_URL (default https://api.openai.com/v1) — proxy ou upstream falso
+
+	// Parâmetros das runs do assistente (sobrepõem os do painel da OpenAI; bot_settings
+	// ajusta por número). Negativo/0/vazio = o que está no assistente.
+	RunTemperature         float64 // ENV: OPENAI_RUN_TEMPERATURE (0–2)
+	RunTopP                float64 // ENV: OPENAI_RUN_TOP_P (0–1)
+	RunMaxPromptTokens     int     // ENV: OPENAI_RUN_MAX_PROMPT_TOKENS (mín. 256)
+	RunMaxCompletionTokens int     // ENV: OPENAI_RUN_MAX_COMPLETION_TOKENS (mín. 256)
+	RunTruncation          string  // ENV: OPENAI_RUN_TRUNCATION (auto | last_messages:N)
+	RunReasoningEffort     string  // ENV: OPENAI_RUN_REASONING_EFFORT (low | medium | high; só modelos de raciocínio)
+
+	// Citações do file search na resposta: "strip" remove as marcações; "footnotes"
+	// troca por [1], [2] e lista os arquivos no fim. ENV: CITATIONS_MODE (default strip)
+	CitationsMode string
+
+	// Barramento de eventos internos: "local" (em processo) ou "postgres" (LISTEN/NOTIFY,
+	// entrega os eventos a todas as réplicas). ENV: EVENT_BUS (default local)
+	EventBus string
+
+	// Por quanto tempo GET /status/{id} responde sobre uma mensagem do webhook.
+	// ENV: STATUS_TTL_MINUTES (default 60)
+	StatusTTLMinutes int
+
+	// Assistentes nomeados para transferência de conversa (ex.: vendas -> suporte).
+	// ENV: ASSISTANTS (JSON), ex.: {"vendas":"asst_123","suporte":"asst_456"}.
+	// "default" sempre aponta para OPENAI_ASSISTANT_ID, salvo se redefinido.
+	Assistants map[string]string
+
+	// Memória de longo prazo: extrai fatos do cliente após cada conversa e injeta nas runs.
+	MemoryEnabled bool // ENV: MEMORY_ENABLED (default true)
+
+	// Resumo da conversa em clients.summary, atualizado a cada SUMMARY_EVERY_MESSAGES
+	// mensagens novas; usado no handoff, na transferência, no reengajamento e no painel.
+	SummaryEnabled       bool // ENV: SUMMARY_ENABLED (default true)
+	SummaryEveryMessages int  // ENV: SUMMARY_EVERY_MESSAGES (default 6)
+
+	// Clientes que escrevem em outro idioma: translate traduz a mensagem para o
+	// assistente e a resposta de volta; instruct só pede ao assistente para responder
+	// no idioma do cliente.
+	TranslateMode     string // ENV: TRANSLATE_MODE (off | translate | instruct; default off)
+	TranslateMinChars int    // ENV: TRANSLATE_MIN_CHARS (default 12) — textos menores não trocam o idioma detectado
+
+	// Não repete "Olá! Como posso ajudar?" para quem já foi cumprimentado nesta janela
+	// (instrução extra na run + remoção da saudação da resposta). 0 desativa.
+	GreetingWindowHours int // ENV: GREETING_WINDOW_HOURS (default 24)
+
+	// Contexto em toda run: data/hora no BUSINESS_TIMEZONE e perfil do cliente
+	// (nome, tags, última conversa), para não inventar datas nem tratar conhecido como novo.
+	RunContextEnabled bool // ENV: RUN_CONTEXT_ENABLED (default true)
+
+	// Enriquecimento pelo CRM: no primeiro contato (e depois a cada CACHE_HOURS) faz
+	// POST {"phone","tenant_id"} em ENRICH_URL; os campos devolvidos viram atributos
+	// do cliente e entram no contexto da run. Vazio = desativado.
+	EnrichURL        string // ENV: ENRICH_URL
+	EnrichToken      string // ENV: ENRICH_TOKEN — enviado como Bearer
+	EnrichTimeoutMs  int    // ENV: ENRICH_TIMEOUT_MS (default 1500) — a consulta atrasa a 1ª resposta
+	EnrichCacheHours int    // ENV: ENRICH_CACHE_HOURS (default 24; 0 = só no primeiro contato)
 
 	UazapiBaseSend      string
 	UazapiTokenSend     string
 	UazapiBaseDownload  string
 	UazapiTokenDownload string
 
+	// Download de mídia: timeout por tentativa (separado do envio) e tentativas extras,
+	// retomando com Range a partir dos bytes já recebidos.
+	UazapiDownloadTimeoutSeconds int // ENV: UAZAPI_DOWNLOAD_TIMEOUT_SECONDS (default 60)
+	UazapiDownloadRetries        int // ENV: UAZAPI_DOWNLOAD_RETRIES (default 3)
+
+	// Circuit breaker por caminho da Uazapi: após N falhas seguidas o caminho é pulado pelo cooldown.
+	UazapiBreakerFailures        int // ENV: UAZAPI_BREAKER_FAILURES (default 5; 0 = desativado)
+	UazapiBreakerCooldownSeconds int // ENV: UAZAPI_BREAKER_COOLDOWN_SECONDS (default 30)
+
+	// Verificação do número (/chat/check) antes de envios proativos e avulsos: número
+	// sem WhatsApp é pulado. O resultado fica no cliente e vale por CACHE_HOURS.
+	NumberCheckEnabled    bool // ENV: NUMBER_CHECK_ENABLED (default true)
+	NumberCheckCacheHours int  // ENV: NUMBER_CHECK_CACHE_HOURS (default 168)
+
+	// Taxa de envio por instância, contra banimento do número (ver uazapi/shaper.go).
+	// Respostas têm prioridade sobre envios em massa (campanhas, reengajamento, CSAT, status).
+	OutboundMaxPerMinute   int       // ENV: OUTBOUND_MAX_PER_MINUTE (default 0 = sem limite)
+	OutboundMinIntervalMs  int       // ENV: OUTBOUND_MIN_INTERVAL_MS (default 0) — entre duas mensagens da instância
+	OutboundJitterMs       int       // ENV: OUTBOUND_JITTER_MS (default 0) — atraso aleatório somado ao intervalo
+	OutboundRampStart      time.Time // ENV: OUTBOUND_RAMP_START (AAAA-MM-DD) — ativação do número, dia 1 da rampa
+	OutboundRampUp         string    // ENV: OUTBOUND_RAMP_UP (ex.: "1:50,3:150,7:400") — dia:limite diário dos envios em massa
+	OutboundMaxWaitSeconds int       // ENV: OUTBOUND_MAX_WAIT_SECONDS (default 300) — espera máxima na fila
+
 	TTSVoice string
 	TTSSpeed float64
 
+	// Provedor da voz dos áudios. Com elevenlabs, TTS_VOICE (e tts_voice em
+	// bot_settings) é o voice_id da ElevenLabs, inclusive vozes clonadas.
+	TTSProvider          string  // ENV: TTS_PROVIDER (openai | elevenlabs; default openai)
+	ElevenLabsAPIKey     string  // ENV: ELEVENLABS_API_KEY
+	ElevenLabsVoiceID    string  // ENV: ELEVENLABS_VOICE_ID (voz padrão quando TTS_VOICE não é definido)
+	ElevenLabsModel      string  // ENV: ELEVENLABS_MODEL (default "eleven_multilingual_v2")
+	ElevenLabsStability  float64 // ENV: ELEVENLABS_STABILITY (0..1, default 0.5)
+	ElevenLabsSimilarity float64 // ENV: ELEVENLABS_SIMILARITY (0..1, default 0.75)
+	ElevenLabsBaseURL    string  // ENV: ELEVENLABS_BASE_URL (default https://api.elevenlabs.io)
+
+	// Pré-processamento de áudio (ffmpeg) antes da transcrição
+	AudioPreprocess bool   // ENV: AUDIO_PREPROCESS (default true)
+	FFmpegPath      string // ENV: FFMPEG_PATH (default "ffmpeg")
+
+	// Imagens e vídeos enviados acima do limite do WhatsApp passam pelo ffmpeg
+	// (redimensiona; vídeo em H.264 baseline) antes do envio.
+	MediaTranscode  bool // ENV: MEDIA_TRANSCODE (default true; sem ffmpeg fica desligado)
+	MediaImageMaxMB int  // ENV: MEDIA_IMAGE_MAX_MB (default 5)
+	MediaVideoMaxMB int  // ENV: MEDIA_VIDEO_MAX_MB (default 16)
+
+	// Transcrição: OpenAI (padrão) ou Whisper local (binário ou servidor HTTP na rede interna)
+	TranscribeBackend        string // ENV: TRANSCRIBE_BACKEND (openai | exec | http; default openai)
+	TranscribeCommand        string // ENV: TRANSCRIBE_COMMAND (exec; ex.: "whisper-cli -m /models/ggml-small.bin -l {lang} -nt -np -f {file}")
+	TranscribeURL            string // ENV: TRANSCRIBE_URL (http; ex.: http://whisper:8000/v1/audio/transcriptions)
+	TranscribeModel          string // ENV: TRANSCRIBE_MODEL (http; campo "model" enviado ao servidor, opcional)
+	TranscribeLanguage       string // ENV: TRANSCRIBE_LANGUAGE (default "pt")
+	TranscribeTimeoutSeconds int    // ENV: TRANSCRIBE_TIMEOUT_SECONDS (default 120)
+	TranscribeFallbackOpenAI bool   // ENV: TRANSCRIBE_FALLBACK_OPENAI (default false) — se o local falhar, usa a OpenAI
+
+	// Áudio longo: acima de AUDIO_SUMMARY_SECONDS o assistente recebe um resumo com
+	// trechos literais; o histórico guarda a transcrição completa
+	AudioSummarySeconds int // ENV: AUDIO_SUMMARY_SECONDS (default 120; 0 desativa)
+	AudioSummaryQuotes  int // ENV: AUDIO_SUMMARY_QUOTES (default 3)
+
+	// Cache de áudio TTS para frases repetidas
+	TTSCacheEnabled  bool // ENV: TTS_CACHE_ENABLED (default true)
+	TTSCacheTTLHours int  // ENV: TTS_CACHE_TTL_HOURS (default 720 = 30 dias)
+	TTSCacheMaxChars int  // ENV: TTS_CACHE_MAX_CHARS (default 300); textos maiores não são cacheados
+
+	// Resposta em áudio que não pôde ser gerada ou entregue sai em texto
+	AudioTextFallback bool   // ENV: AUDIO_TEXT_FALLBACK (default true)
+	AudioFallbackNote string // ENV: AUDIO_FALLBACK_NOTE (antes do texto; vazio = sem nota)
+
 	// Timeout do buffer (segundos) via ENV: BUFFER_TIMEOUT_SECONDS
 	BufferTimeoutSeconds int
 
+	// Eventos de presença ("digitando...") estendem o buffer
+	TypingExtendSeconds  int // ENV: TYPING_EXTEND_SECONDS (default 10; 0 desativa)
+	TypingMaxWaitSeconds int // ENV: TYPING_MAX_WAIT_SECONDS (default 60) — teto desde a 1ª mensagem
+
+	// Álbuns: espera pelas imagens e descreve em paralelo
+	AlbumWaitSeconds int // ENV: ALBUM_WAIT_SECONDS (default 4; 0 desativa o agrupamento)
+	AlbumParallelism int // ENV: ALBUM_PARALLELISM (default 3)
+
+	// Memória de imagens: perguntas seguintes sobre uma foto rodam a visão de novo nela
+	VisionFollowup   bool // ENV: VISION_FOLLOWUP (default true) — ferramenta inspect_image
+	VisionMemoryDays int  // ENV: VISION_MEMORY_DAYS (default 7) — por quanto tempo a foto pode ser consultada
+
 	// ---------- NOVO: Delay antes de responder ----------
 	// Delay mínimo/máximo em milissegundos. Se ambos 0, não há atraso.
 	ReplyDelayMinMs   int  // ENV: REPLY_DELAY_MIN_MS (ex.: 1500)
 	ReplyDelayMaxMs   int  // ENV: REPLY_DELAY_MAX_MS (ex.: 3500)
 	TypingDuringDelay bool // ENV: TYPING_DURING_DELAY (true/false). Se true, tenta acionar "digitando..." no provedor.
+
+	// Conexões HTTP com OpenAI/Uazapi/Whisper: um transporte compartilhado
+	HTTPMaxIdlePerHost     int  // ENV: HTTP_MAX_IDLE_PER_HOST (default 32)
+	HTTPIdleTimeoutSeconds int  // ENV: HTTP_IDLE_TIMEOUT_SECONDS (default 90)
+	HTTP2Enabled           bool // ENV: HTTP2_ENABLED (default true)
+	HTTPPrewarmConns       int  // ENV: HTTP_PREWARM_CONNS (default 2; 0 desativa) — conexões abertas na subida
+
+	// Runs longas: aviso intermediário e tempo máximo de espera
+	RunTimeoutSeconds   int    // ENV: RUN_TIMEOUT_SECONDS (default 20)
+	InterimAfterSeconds int    // ENV: INTERIM_AFTER_SECONDS (default 15; 0 desativa)
+	InterimMessage      string // ENV: INTERIM_MESSAGE
+
+	// Run concluída sem texto do assistente: roda mais uma vez pedindo resposta em texto;
+	// se ainda vier vazia, o cliente recebe o aviso "empty_reply" de FALLBACK_MESSAGES.
+	EmptyReplyRetry bool // ENV: EMPTY_REPLY_RETRY (default true)
+
+	// Mensagem nova durante uma run do mesmo cliente: cancela a run em andamento e
+	// responde às mensagens juntas numa run nova (senão responderia à pergunta velha).
+	RunSupersede bool // ENV: RUN_SUPERSEDE (default true)
+
+	// Registra no assistente as funções (create_lead, update_status, get_status) ao iniciar.
+	AssistantSyncTools bool // ENV: ASSISTANT_SYNC_TOOLS (default false)
+
+	// Catálogo (tabela products, /admin/products): registra search_products e get_price
+	// para preços virem do banco e não do modelo.
+	CatalogEnabled bool // ENV: CATALOG_ENABLED (default false)
+
+	// Respostas por intenção (tabela intent_rules, /admin/intents): perguntas
+	// determinísticas (horário, endereço...) respondidas do modelo, sem a IA
+	IntentsEnabled    bool // ENV: INTENTS_ENABLED (default false)
+	IntentMaxWords    int  // ENV: INTENT_MAX_WORDS (default 12) — mensagens maiores vão para a IA
+	IntentAddToThread bool // ENV: INTENT_ADD_TO_THREAD (default true) — pergunta e resposta entram na thread
+
+	// Ação pendente: depois de um link de pagamento/formulário, mensagens curtas sobre
+	// o assunto ("já paguei", "ok", "qual o pix?") não vão para a IA até a confirmação
+	// (POST /api/v1/confirmations) ou o fim da janela; assunto novo segue normal.
+	PendingActionEnabled      bool     // ENV: PENDING_ACTION_ENABLED (default false)
+	PendingActionMinutes      int      // ENV: PENDING_ACTION_MINUTES (default 30) — janela de espera
+	PendingActionLinkHosts    []string // ENV: PENDING_ACTION_LINK_HOSTS — hosts de pagamento/formulário nas respostas do assistente
+	PendingActionKeywords     []string // ENV: PENDING_ACTION_KEYWORDS — palavras do assunto ("pag*" = prefixo)
+	PendingActionMaxWords     int      // ENV: PENDING_ACTION_MAX_WORDS (default 12) — mensagens maiores vão para a IA
+	PendingActionReply        string   // ENV: PENDING_ACTION_REPLY — lembrete enviado ao segurar ({{link}}; "" = só registra)
+	PendingActionConfirmReply string   // ENV: PENDING_ACTION_CONFIRM_REPLY — aviso de pagamento confirmado ("" = não avisa)
+
+	// Webhooks de pagamento (POST /webhook/payments/{provider}): o pagamento é ligado
+	// ao cliente pela referência da ação pendente ou pelo telefone, registrado em
+	// payments, avisado no WhatsApp e anotado na thread. Provedor sem segredo responde 404.
+	StripeWebhookSecret      string // ENV: STRIPE_WEBHOOK_SECRET (whsec_...)
+	MercadoPagoWebhookSecret string // ENV: MERCADOPAGO_WEBHOOK_SECRET (assinatura x-signature)
+	MercadoPagoAccessToken   string // ENV: MERCADOPAGO_ACCESS_TOKEN (consulta do pagamento notificado)
+	PixWebhookToken          string // ENV: PIX_WEBHOOK_TOKEN (?token= na URL cadastrada no PSP)
+	PaymentReply             string // ENV: PAYMENT_REPLY — aviso ao cliente ({{valor}}; "" = não avisa)
+
+	// Validação estrita de JID: sem busca no corpo bruto, telefone com 10-15 dígitos.
+	JIDStrict bool // ENV: JID_STRICT (default false)
+
+	// DRY_RUN: todo o pipeline executa, mas os envios à Uazapi são só logados e persistidos.
+	DryRun bool // ENV: DRY_RUN
+
+	// Token das rotas administrativas (/admin/*). Se vazio, as rotas não são expostas.
+	AdminToken string // ENV: ADMIN_TOKEN
+
+	// Token das integrações (/api/v1/inbound, /api/send). Se vazio, usa o ADMIN_TOKEN.
+	IngestToken string // ENV: INGEST_TOKEN
+
+	// Chat no site (widget) continuando a conversa do WhatsApp, com token assinado.
+	WidgetSecret     string   // ENV: WIDGET_SECRET — assina os tokens; vazio desativa o widget
+	WidgetTokenHours int      // ENV: WIDGET_TOKEN_HOURS (default 72)
+	WidgetOrigins    []string // ENV: WIDGET_ORIGINS — origens liberadas no CORS ("*" = todas)
+	WidgetURL        string   // ENV: WIDGET_URL — página do chat; o link leva ?t=<token>
+
+	// Assinatura do webhook (HMAC-SHA256 de "timestamp.nonce.corpo" com este segredo).
+	// Se vazio, o webhook não é verificado. Com segredo, eventos fora da janela ou com
+	// nonce já visto são rejeitados (proteção contra replay).
+	WebhookSecret              string // ENV: WEBHOOK_SECRET
+	WebhookReplayWindowSeconds int    // ENV: WEBHOOK_REPLAY_WINDOW_SECONDS (default 300)
+	// Verificação da URL por GET (Meta Cloud API, 360dialog...): com token definido,
+	// GET ?hub.verify_token=...&hub.challenge=... devolve o challenge e HEAD responde 200.
+	WebhookVerifyToken string // ENV: WEBHOOK_VERIFY_TOKEN (vazio = GET/HEAD respondem 405)
+
+	// Contrapressão: com o processo saturado o webhook responde 429 + Retry-After
+	// (o gateway reenvia depois) em vez de aceitar trabalho sem limite.
+	BackpressureMaxRuns           int    // ENV: BACKPRESSURE_MAX_RUNS (default 0 = sem limite) — runs do assistente em andamento
+	BackpressureMaxPending        int    // ENV: BACKPRESSURE_MAX_PENDING (default 0 = sem limite) — conversas aguardando o buffer
+	BackpressurePolicy            string // ENV: BACKPRESSURE_POLICY (reject | new_only; default reject) — new_only aceita conversas já no buffer
+	BackpressureRetryAfterSeconds int    // ENV: BACKPRESSURE_RETRY_AFTER_SECONDS (default 30)
+
+	// Fila das runs com faixas de prioridade pelas tags do cliente (ver handlers/lanes.go)
+	RunWorkers          int      // ENV: RUN_WORKERS (default 0 = sem fila, cada flush roda na hora) — runs simultâneas
+	QueuePriorityTags   []string // ENV: QUEUE_PRIORITY_TAGS (default "vip,pago") — faixa priority
+	QueueBulkTags       []string // ENV: QUEUE_BULK_TAGS (ex.: "campanha") — faixa bulk
+	QueueMaxWaitSeconds int      // ENV: QUEUE_MAX_WAIT_SECONDS (default 30) — acima disso a run passa na frente das faixas altas
+
+	// Mensagens ao cliente quando algo falha, por categoria (transcription_failed,
+	// document_too_large, media_failed, system_busy, empty_reply, internal_error). ENV: FALLBACK_MESSAGES (JSON)
+	// sobrescreve os textos padrão; "" numa categoria desativa o aviso.
+	FallbackMessages        map[string]string
+	FallbackCooldownMinutes int // ENV: FALLBACK_COOLDOWN_MINUTES (default 10) — no máx. 1 aviso por categoria/cliente
+	DocumentMaxMB           int // ENV: DOCUMENT_MAX_MB (default 15)
+
+	// Documentos também vão para a OpenAI (Files + file_search na thread): o assistente
+	// consulta o documento na conversa toda, não só o resumo. O assistente precisa ter
+	// file_search ativo. Os arquivos são apagados após o TTL.
+	DocumentFileSearch   bool // ENV: DOCUMENT_FILE_SEARCH (default false)
+	DocumentFileTTLHours int  // ENV: DOCUMENT_FILE_TTL_HOURS (default 168 = 7 dias)
+
+	// Captura de depuração das chamadas à OpenAI e à Uazapi (corpos sem segredos nem
+	// mídia), ligada por prazo via /admin/debug/capture. MINUTES > 0 liga já no boot.
+	DebugCaptureMinutes int // ENV: DEBUG_CAPTURE_MINUTES (default 0)
+	DebugCaptureSize    int // ENV: DEBUG_CAPTURE_SIZE (default 200) — chamadas guardadas
+	DebugCaptureMaxKB   int // ENV: DEBUG_CAPTURE_MAX_KB (default 64) — por corpo
+
+	// Textos enviados ao assistente acima deste tamanho (caracteres) são cortados com
+	// um aviso ao modelo; o histórico guarda a mensagem completa. 0 desativa.
+	InboundMaxChars int // ENV: INBOUND_MAX_CHARS (default 8000)
+
+	// Ligações recebidas: recusa automática e resposta pedindo mensagem ("" desativa).
+	CallAutoReject bool   // ENV: CALL_AUTO_REJECT (default true)
+	CallReply      string // ENV: CALL_REPLY
+
+	// Aviso enviado (uma vez por cliente) quando o modo manutenção está ativo.
+	MaintenanceMessage string // ENV: MAINTENANCE_MESSAGE
+
+	// Variáveis do negócio para {{placeholders}} nas respostas.
+	// ENV: BUSINESS_VARS (JSON), ex.: {"horario_funcionamento":"Seg-Sex 9h-18h","endereco":"Rua X, 10"}
+	BusinessVars map[string]string
+
+	// Fuso horário do negócio (relatórios, horários). ENV: BUSINESS_TIMEZONE
+	BusinessTimezone string
+
+	// Custo estimado (USD) por 1000 tokens, usado nas estimativas de relatório.
+	OpenAICostPer1KTokens float64 // ENV: OPENAI_COST_PER_1K_TOKENS
+
+	// ---------- Orçamento OpenAI (consumo real das runs, tabela openai_usage) ----------
+	// Limites <= 0 não limitam. Ao estourar, usa BUDGET_FALLBACK_MODEL se definido;
+	// senão responde com BUDGET_EXCEEDED_MESSAGE. Avisos de 80%/100% vão para BUDGET_ALERT_NOTIFY.
+	BudgetDailyTokens         int      // ENV: BUDGET_DAILY_TOKENS
+	BudgetMonthlyTokens       int      // ENV: BUDGET_MONTHLY_TOKENS
+	BudgetDailyUSD            float64  // ENV: BUDGET_DAILY_USD
+	BudgetMonthlyUSD          float64  // ENV: BUDGET_MONTHLY_USD
+	BudgetClientDailyTokens   int      // ENV: BUDGET_CLIENT_DAILY_TOKENS
+	BudgetClientMonthlyTokens int      // ENV: BUDGET_CLIENT_MONTHLY_TOKENS
+	BudgetClientDailyUSD      float64  // ENV: BUDGET_CLIENT_DAILY_USD
+	BudgetClientMonthlyUSD    float64  // ENV: BUDGET_CLIENT_MONTHLY_USD
+	BudgetFallbackModel       string   // ENV: BUDGET_FALLBACK_MODEL (ex.: gpt-4o-mini)
+	BudgetExceededMessage     string   // ENV: BUDGET_EXCEEDED_MESSAGE
+	BudgetAlertNotify         []string // ENV: BUDGET_ALERT_NOTIFY (default DIGEST_WHATSAPP)
+
+	// Links enviados pelo cliente: busca a página e resume para o assistente.
+	LinkUnfurlEnabled        bool // ENV: LINK_UNFURL_ENABLED (default true)
+	LinkUnfurlTimeoutSeconds int  // ENV: LINK_UNFURL_TIMEOUT_SECONDS (default 8)
+	LinkUnfurlMaxKB          int  // ENV: LINK_UNFURL_MAX_KB (default 1024)
+	LinkUnfurlMaxLinks       int  // ENV: LINK_UNFURL_MAX_LINKS (default 2)
+
+	// ---------- Spam/abuso ----------
+	AbuseEnabled         bool // ENV: ABUSE_ENABLED (default true)
+	AbuseMaxPerMinute    int  // ENV: ABUSE_MAX_PER_MINUTE (default 20)
+	AbuseRepeatLimit     int  // ENV: ABUSE_REPEAT_LIMIT (default 5) — mesmo texto em 10 min
+	AbuseLinkOnlyLimit   int  // ENV: ABUSE_LINK_ONLY_LIMIT (default 3) — mensagens só com links em 10 min
+	AbuseCooldownMinutes int  // ENV: ABUSE_COOLDOWN_MINUTES (default 30)
+	AbuseModelCheck      bool // ENV: ABUSE_MODEL_CHECK (default false) — moderação da OpenAI nos textos
+
+	// Cache dos ajustes por número (tabela bot_settings, API /admin/settings).
+	SettingsCacheSeconds int // ENV: SETTINGS_CACHE_SECONDS (default 30)
+
+	// Respostas estruturadas (JSON com text/buttons/media/handoff) do assistente.
+	ReplyDirectives bool     // ENV: REPLY_DIRECTIVES (default true)
+	HandoffNotify   []string // ENV: HANDOFF_NOTIFY (telefones avisados quando o assistente pede handoff)
+
+	// Avisos a um grupo do WhatsApp da equipe, enviados pelo próprio número do bot
+	// (tenant padrão), nos eventos escolhidos. No horário de silêncio os avisos
+	// esperam e saem juntos no fim dele.
+	TeamNotifyGroup     string            // ENV: TEAM_NOTIFY_GROUP — JID do grupo (…@g.us); vazio desativa
+	TeamNotifyEvents    []string          // ENV: TEAM_NOTIFY_EVENTS (default lead.created,handoff.requested,payment.received)
+	TeamNotifyTemplates map[string]string // ENV: TEAM_NOTIFY_TEMPLATES (JSON tópico → texto; {phone} {name} {content} {time})
+	TeamQuietHours      string            // ENV: TEAM_QUIET_HOURS (ex.: 22:00-07:00 no BUSINESS_TIMEZONE; vazio = sem silêncio)
+
+	// Converte o markdown das respostas (tabelas, listas, títulos) para a formatação do WhatsApp.
+	// Tabelas: "auto" (monoespaçada se couber na tela, senão tópicos), "monospace" ou "bullets".
+	ReplyMarkdown  bool   // ENV: REPLY_MARKDOWN (default true)
+	ReplyTableMode string // ENV: REPLY_TABLE_MODE (default auto)
+
+	// Assinatura das respostas em texto (ex.: "— Leandro, assistente virtual"), com as
+	// mesmas variáveis das respostas ({{primeiro_nome}}, BUSINESS_VARS) e {{variante}}.
+	// Clientes com a tag SKIP_TAG não recebem. O marcador invisível leva a variante
+	// do experimento A/B no fim do texto enviado (processor.ExtractVariantMarker).
+	ReplyFooter        string // ENV: REPLY_FOOTER (vazio = sem assinatura)
+	ReplyFooterSkipTag string // ENV: REPLY_FOOTER_SKIP_TAG (default "sem_assinatura")
+	ReplyVariantMarker bool   // ENV: REPLY_VARIANT_MARKER (default false)
+
+	// ---------- Resumo diário ----------
+	DigestWhatsApp []string // ENV: DIGEST_WHATSAPP (telefones separados por vírgula)
+	DigestEmails   []string // ENV: DIGEST_EMAILS (e-mails separados por vírgula)
+	DigestHour     int      // ENV: DIGEST_HOUR (0-23, default 8)
+
+	SMTPHost string // ENV: SMTP_HOST
+	SMTPPort int    // ENV: SMTP_PORT (default 587)
+	SMTPUser string // ENV: SMTP_USER
+	SMTPPass string // ENV: SMTP_PASS
+	SMTPFrom string // ENV: SMTP_FROM
+
+	// ---------- Reengajamento de leads silenciosos ----------
+	ReengageEnabled     bool   // ENV: REENGAGE_ENABLED (default false)
+	ReengageHour        int    // ENV: REENGAGE_HOUR (0-23, default 10; fuso BUSINESS_TIMEZONE)
+	ReengageAfterDays   int    // ENV: REENGAGE_AFTER_DAYS (default 7) — dias sem mensagens
+	ReengageMaxDays     int    // ENV: REENGAGE_MAX_DAYS (default 60) — ignora conversas mais antigas
+	ReengageTag         string // ENV: REENGAGE_TAG (opcional; ex.: "lead")
+	ReengageMaxAttempts int    // ENV: REENGAGE_MAX_ATTEMPTS (default 1) — por silêncio do cliente
+	ReengageBatch       int    // ENV: REENGAGE_BATCH (default 50)
+	ReengagePaceSeconds int    // ENV: REENGAGE_PACE_SECONDS (default 20) — intervalo entre envios
+	ReengageFooter      string // ENV: REENGAGE_FOOTER
+
+	// ---------- Pesquisa de satisfação (CSAT) ----------
+	// Enviada quando o assistente marca o atendimento como resolvido (diretiva "resolved")
+	// ou quando a conversa fica parada após uma resposta.
+	CSATEnabled           bool   // ENV: CSAT_ENABLED (default false)
+	CSATInactivityMinutes int    // ENV: CSAT_INACTIVITY_MINUTES (default 60; 0 = só pela diretiva)
+	CSATCooldownDays      int    // ENV: CSAT_COOLDOWN_DAYS (default 7) — no máx. 1 pesquisa por cliente no período
+	CSATAnswerHours       int    // ENV: CSAT_ANSWER_HOURS (default 24) — prazo para aceitar a nota
+	CSATQuestion          string // ENV: CSAT_QUESTION
+	CSATThanks            string // ENV: CSAT_THANKS
+
+	// ---------- SLO de latência das respostas ----------
+	// Ex.: 95% das respostas em até 30s. O alerta sai quando a taxa de consumo do
+	// orçamento de erro (erros / (1 - objetivo)) passa de SLO_BURN_RATE na janela curta.
+	SLOTargetSeconds        int      // ENV: SLO_TARGET_SECONDS (default 30; 0 = desativado)
+	SLOObjective            float64  // ENV: SLO_OBJECTIVE (default 0.95)
+	SLOWindowHours          int      // ENV: SLO_WINDOW_HOURS (default 24) — janela do SLO
+	SLOAlertWindowMinutes   int      // ENV: SLO_ALERT_WINDOW_MINUTES (default 60) — janela curta do alerta
+	SLOBurnRate             float64  // ENV: SLO_BURN_RATE (default 2)
+	SLOMinEvents            int      // ENV: SLO_MIN_EVENTS (default 10) — respostas mínimas na janela curta para alertar
+	SLOAlertCooldownMinutes int      // ENV: SLO_ALERT_COOLDOWN_MINUTES (default 60)
+	SLOAlertWebhook         string   // ENV: SLO_ALERT_WEBHOOK — POST {"text": ...} (compatível com Slack)
+	SLOAlertNotify          []string // ENV: SLO_ALERT_NOTIFY (default BUDGET_ALERT_NOTIFY)
+
+	// ---------- Panics recuperados ----------
+	// Viram dead_letters + aviso internal_error ao cliente + alerta (com cooldown por etapa).
+	PanicAlertCooldownMinutes int      // ENV: PANIC_ALERT_COOLDOWN_MINUTES (default 10)
+	PanicAlertWebhook         string   // ENV: PANIC_ALERT_WEBHOOK (default SLO_ALERT_WEBHOOK)
+	PanicAlertNotify          []string // ENV: PANIC_ALERT_NOTIFY (default SLO_ALERT_NOTIFY)
+
+	// Opt-out de mensagens ativas
+	OptOutKeywords []string // ENV: OPT_OUT_KEYWORDS (default "parar,sair,stop,descadastrar")
+	OptOutReply    string   // ENV: OPT_OUT_REPLY
+
+	// Cliente silencia o bot ("silenciar por 1 dia", "pausar por 2 horas"; "reativar" desfaz).
+	// As mensagens continuam registradas, só não são respondidas; ao fim avisa o cliente.
+	MuteEnabled      bool   // ENV: MUTE_ENABLED (default true)
+	MuteDefaultHours int    // ENV: MUTE_DEFAULT_HOURS (default 24) — sem prazo no pedido
+	MuteMaxDays      int    // ENV: MUTE_MAX_DAYS (default 30)
+	MuteReply        string // ENV: MUTE_REPLY — confirmação; %s = data/hora do fim
+	UnmuteMessage    string // ENV: UNMUTE_MESSAGE — aviso ao fim do silêncio ("" = não avisa)
+
+	// Comandos do cliente respondidos sem passar pela IA ("menu", "atendente",
+	// "áudio off"/"áudio on", "apagar meus dados"). ENV: CHAT_COMMANDS lista os
+	// ativos (default todos; vazio desativa); CHAT_COMMAND_REPLIES (JSON) troca os textos
+	// ("apagar" leva %s no lugar do código de confirmação).
+	ChatCommands       []string
+	ChatCommandReplies map[string]string
+
+	// Exclusão de dados pedida pelo cliente ("apagar meus dados") ou pelo admin
+	ErasureConfirmMinutes int  // ENV: ERASURE_CONFIRM_MINUTES (default 30) — validade do código de confirmação
+	ErasureSendExport     bool // ENV: ERASURE_SEND_EXPORT (default true) — envia a cópia dos dados antes de apagar
+
+	// Teste do canal no start: envia uma mensagem ao número de monitoramento e espera a
+	// confirmação de entrega; sem ela o /readyz responde 503.
+	WarmupNumber            string // ENV: WARMUP_NUMBER — vazio desativa
+	WarmupText              string // ENV: WARMUP_TEXT — %s = data/hora do start
+	WarmupAckTimeoutSeconds int    // ENV: WARMUP_ACK_TIMEOUT_SECONDS (default 120)
+
+	// Envio em duas fases: a mensagem fica "pending" no histórico até a resposta da Uazapi;
+	// pendentes além do prazo (processo parou no meio do envio) viram "failed" sem reenvio.
+	SendPendingTimeoutMinutes int // ENV: SEND_PENDING_TIMEOUT_MINUTES (default 15)
+
+	// Mensagens encaminhadas: as "encaminhadas com frequência" (correntes) não vão para a IA
+	ForwardedChainScore  int    // ENV: FORWARDED_CHAIN_SCORE (default 5; 0 = desativado) — forwardingScore mínimo
+	ForwardedChainAction string // ENV: FORWARDED_CHAIN_ACTION (reply | ack | assistant; default reply)
+	ForwardedChainReply  string // ENV: FORWARDED_CHAIN_REPLY — texto enviado no modo reply
+
+	// Mensagens só com emoji ("👍", "❤️"): resposta fixa, só registro ou assistente.
+	// Reações a mensagens são sempre só registradas.
+	EmojiAction string // ENV: EMOJI_ACTION (reply | ack | assistant; default ack)
+	EmojiReply  string // ENV: EMOJI_REPLY — texto enviado no modo reply (default "😊")
+
+	// ---------- Retenção (LGPD) ----------
+	RetentionDays          int // ENV: RETENTION_DAYS (0 = desativado)
+	RetentionIntervalHours int // ENV: RETENTION_INTERVAL_HOURS (default 24)
+
+	// Mascaramento antes de gravar as mensagens (o assistente recebe o texto
+	// original). A cópia sem máscara só é guardada com KEEP_ORIGINAL.
+	RedactProfanity    bool     // ENV: REDACT_PROFANITY (default false)
+	RedactPII          bool     // ENV: REDACT_PII (default false) — CPF e cartões
+	RedactWords        []string // ENV: REDACT_WORDS — palavras extras mascaradas (vírgulas)
+	RedactKeepOriginal bool     // ENV: REDACT_KEEP_ORIGINAL (default false) — guarda a cópia sem máscara
+
+	// ---------- Sink de analytics (opcional) ----------
+	SinkKind            string // ENV: SINK_KIND ("" | clickhouse | bigquery)
+	SinkIntervalSeconds int    // ENV: SINK_INTERVAL_SECONDS (default 300)
+	SinkBatchSize       int    // ENV: SINK_BATCH_SIZE (default 500)
+
+	ClickHouseURL      string // ENV: CLICKHOUSE_URL (ex.: http://clickhouse:8123)
+	ClickHouseDatabase string // ENV: CLICKHOUSE_DATABASE
+	ClickHouseUser     string // ENV: CLICKHOUSE_USER
+	ClickHousePassword string // ENV: CLICKHOUSE_PASSWORD
+
+	BigQueryProject         string // ENV: BIGQUERY_PROJECT (default: project_id da credencial)
+	BigQueryDataset         string // ENV: BIGQUERY_DATASET
+	BigQueryCredentialsFile string // ENV: BIGQUERY_CREDENTIALS_FILE (JSON da service account)
+
+	// ---------- Backup (go run ./cmd/backup) ----------
+	BackupKey        string // ENV: BACKUP_KEY (32 bytes em base64 ou hex; vazio = sem criptografia)
+	BackupS3Bucket   string // ENV: BACKUP_S3_BUCKET (vazio = só arquivo local)
+	BackupS3Prefix   string // ENV: BACKUP_S3_PREFIX (ex.: "leandro/")
+	BackupS3Region   string // ENV: BACKUP_S3_REGION (default us-east-1)
+	BackupS3Endpoint string // ENV: BACKUP_S3_ENDPOINT (default AWS; MinIO/R2/Spaces: URL do serviço)
+	AWSAccessKeyID   string // ENV: AWS_ACCESS_KEY_ID
+	AWSSecretKey     string // ENV: AWS_SECRET_ACCESS_KEY
 }
 
 // getenv retorna o valor do env var ou um default.
@@ -56,6 +510,47 @@ func getenvInt(key string, def int) int {
 	return def
 }
 
+func getenvFloat(key string, def float64) float64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return f
+	}
+	return def
+}
+
+// runTokenLimit lê um limite de tokens da run: 0 desliga; a OpenAI exige ao menos 256.
+func runTokenLimit(key string) int {
+	n := getenvInt(key, 0)
+	if n > 0 && n < 256 {
+		log.Printf("%s abaixo do mínimo da OpenAI (%d): usando 256", key, n)
+		n = 256
+	}
+	return max(n, 0)
+}
+
+// validTruncation aceita "", "auto" ou "last_messages:N" (N >= 1).
+func validTruncation(s string) bool {
+	if s == "" || s == "auto" {
+		return true
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "last_messages:"))
+	return strings.HasPrefix(s, "last_messages:") && err == nil && n >= 1
+}
+
+// getenvList lê uma lista separada por vírgulas, ignorando itens vazios.
+func getenvList(key string) []string {
+	var out []string
+	for _, p := range strings.Split(os.Getenv(key), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func getenvBool(key string, def bool) bool {
 	v := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
 	if v == "" {
@@ -79,6 +574,11 @@ func Load() Config {
 		OpenAIAssistantID:     os.Getenv("OPENAI_ASSISTANT_ID"),
 		OpenAIChatModel:       getenv("OPENAI_CHAT_MODEL", "gpt-4o-mini"),
 		OpenAITranscribeModel: getenv("OPENAI_TRANSCRIBE_MODEL", "whisper-1"),
+		OpenAIMemoryModel:     getenv("OPENAI_MEMORY_MODEL", "gpt-4o-mini"),
+		OpenAIBaseURL:         strings.TrimRight(getenv("OPENAI_BASE_URL", "https://api.openai.com/v1"), "/"),
+		MemoryEnabled:         getenvBool("MEMORY_ENABLED", true),
+		GreetingWindowHours:   getenvInt("GREETING_WINDOW_HOURS", 24),
+		RunContextEnabled:     getenvBool("RUN_CONTEXT_ENABLED", true),
 
 		UazapiBaseSend:     os.Getenv("UAZAPI_BASE_SEND"),
 		UazapiTokenSend:    os.Getenv("UAZAPI_TOKEN_SEND"),
@@ -87,7 +587,524 @@ func Load() Config {
 			os.Getenv("UAZAPI_TOKEN_SEND")),
 
 		TTSVoice: getenv("TTS_VOICE", "onyx"),
+
+		AdminToken: strings.TrimSpace(os.Getenv("ADMIN_TOKEN")),
+
+		IngestToken: getenv("INGEST_TOKEN", strings.TrimSpace(os.Getenv("ADMIN_TOKEN"))),
+
+		WebhookSecret:      strings.TrimSpace(os.Getenv("WEBHOOK_SECRET")),
+		WebhookVerifyToken: strings.TrimSpace(os.Getenv("WEBHOOK_VERIFY_TOKEN")),
+
+		MaintenanceMessage: getenv("MAINTENANCE_MESSAGE",
+			"Estamos em manutenção rápida, voltamos já! Sua mensagem foi recebida e será respondida assim que possível."),
+	}
+
+	cfg.AssistantSyncTools = getenvBool("ASSISTANT_SYNC_TOOLS", false)
+	cfg.CatalogEnabled = getenvBool("CATALOG_ENABLED", false)
+	cfg.IntentsEnabled = getenvBool("INTENTS_ENABLED", false)
+	cfg.IntentMaxWords = getenvInt("INTENT_MAX_WORDS", 12)
+	cfg.IntentAddToThread = getenvBool("INTENT_ADD_TO_THREAD", true)
+	cfg.StripeWebhookSecret = strings.TrimSpace(os.Getenv("STRIPE_WEBHOOK_SECRET"))
+	cfg.MercadoPagoWebhookSecret = strings.TrimSpace(os.Getenv("MERCADOPAGO_WEBHOOK_SECRET"))
+	cfg.MercadoPagoAccessToken = strings.TrimSpace(os.Getenv("MERCADOPAGO_ACCESS_TOKEN"))
+	cfg.PixWebhookToken = strings.TrimSpace(os.Getenv("PIX_WEBHOOK_TOKEN"))
+	cfg.PaymentReply = "Recebemos seu pagamento de {{valor}} ✅ Obrigado, {{primeiro_nome}}!"
+	if v, ok := os.LookupEnv("PAYMENT_REPLY"); ok {
+		cfg.PaymentReply = strings.TrimSpace(v) // vazio: não avisa
+	}
+	cfg.SummaryEnabled = getenvBool("SUMMARY_ENABLED", true)
+	cfg.SummaryEveryMessages = getenvInt("SUMMARY_EVERY_MESSAGES", 6)
+	if cfg.SummaryEveryMessages <= 0 {
+		cfg.SummaryEveryMessages = 6
+	}
+	cfg.TranslateMode = strings.ToLower(getenv("TRANSLATE_MODE", "off"))
+	switch cfg.TranslateMode {
+	case "off", "translate", "instruct":
+	default:
+		log.Printf("TRANSLATE_MODE inválido (%q): usando off", cfg.TranslateMode)
+		cfg.TranslateMode = "off"
+	}
+	cfg.TranslateMinChars = getenvInt("TRANSLATE_MIN_CHARS", 12)
+	cfg.PendingActionEnabled = getenvBool("PENDING_ACTION_ENABLED", false)
+	cfg.PendingActionMinutes = getenvInt("PENDING_ACTION_MINUTES", 30)
+	if cfg.PendingActionMinutes <= 0 {
+		cfg.PendingActionMinutes = 30
+	}
+	cfg.PendingActionLinkHosts = getenvList("PENDING_ACTION_LINK_HOSTS")
+	if len(cfg.PendingActionLinkHosts) == 0 {
+		cfg.PendingActionLinkHosts = []string{"mpago.la", "mercadopago.com.br", "pag.ae", "pagseguro.uol.com.br",
+			"checkout.stripe.com", "buy.stripe.com", "pay.hotmart.com", "asaas.com", "forms.gle"}
+	}
+	cfg.PendingActionKeywords = getenvList("PENDING_ACTION_KEYWORDS")
+	if len(cfg.PendingActionKeywords) == 0 {
+		cfg.PendingActionKeywords = []string{"pag*", "pix", "boleto", "cartao", "link", "comprovante", "formulario",
+			"preench*", "ok", "blz", "beleza", "certo", "obrigad*", "valeu", "aguard*"}
+	}
+	cfg.PendingActionMaxWords = getenvInt("PENDING_ACTION_MAX_WORDS", 12)
+	cfg.PendingActionReply = "Seu link continua disponível: {{link}} 🙂 Assim que a confirmação chegar eu te aviso por aqui."
+	if v, ok := os.LookupEnv("PENDING_ACTION_REPLY"); ok {
+		cfg.PendingActionReply = strings.TrimSpace(v) // vazio: só registra
+	}
+	cfg.PendingActionConfirmReply = "Pagamento confirmado! ✅ Obrigado, {{primeiro_nome}}."
+	if v, ok := os.LookupEnv("PENDING_ACTION_CONFIRM_REPLY"); ok {
+		cfg.PendingActionConfirmReply = strings.TrimSpace(v) // vazio: não avisa
+	}
+	cfg.DryRun = getenvBool("DRY_RUN", false)
+	if cfg.DryRun {
+		log.Println("DRY_RUN ativo: nenhuma mensagem será entregue pela Uazapi")
+	}
+
+	cfg.JIDStrict = getenvBool("JID_STRICT", false)
+
+	cfg.HTTPMaxIdlePerHost = getenvInt("HTTP_MAX_IDLE_PER_HOST", 32)
+	cfg.HTTPIdleTimeoutSeconds = getenvInt("HTTP_IDLE_TIMEOUT_SECONDS", 90)
+	cfg.HTTP2Enabled = getenvBool("HTTP2_ENABLED", true)
+	cfg.HTTPPrewarmConns = getenvInt("HTTP_PREWARM_CONNS", 2)
+
+	cfg.RunTimeoutSeconds = getenvInt("RUN_TIMEOUT_SECONDS", 20)
+	if cfg.RunTimeoutSeconds <= 0 {
+		cfg.RunTimeoutSeconds = 20
+	}
+	cfg.InterimAfterSeconds = getenvInt("INTERIM_AFTER_SECONDS", 15)
+	cfg.InterimMessage = getenv("INTERIM_MESSAGE", "Estou verificando, um instante…")
+	cfg.EmptyReplyRetry = getenvBool("EMPTY_REPLY_RETRY", true)
+	cfg.RunSupersede = getenvBool("RUN_SUPERSEDE", true)
+
+	cfg.AudioPreprocess = getenvBool("AUDIO_PREPROCESS", true)
+	cfg.FFmpegPath = getenv("FFMPEG_PATH", "ffmpeg")
+	cfg.MediaTranscode = getenvBool("MEDIA_TRANSCODE", true)
+	cfg.MediaImageMaxMB = getenvInt("MEDIA_IMAGE_MAX_MB", 5)
+	cfg.MediaVideoMaxMB = getenvInt("MEDIA_VIDEO_MAX_MB", 16)
+
+	cfg.TranscribeBackend = strings.ToLower(getenv("TRANSCRIBE_BACKEND", "openai"))
+	cfg.TranscribeCommand = getenv("TRANSCRIBE_COMMAND", "")
+	cfg.TranscribeURL = getenv("TRANSCRIBE_URL", "")
+	cfg.TranscribeModel = getenv("TRANSCRIBE_MODEL", "")
+	cfg.TranscribeLanguage = getenv("TRANSCRIBE_LANGUAGE", "pt")
+	cfg.TranscribeTimeoutSeconds = getenvInt("TRANSCRIBE_TIMEOUT_SECONDS", 120)
+	cfg.TranscribeFallbackOpenAI = getenvBool("TRANSCRIBE_FALLBACK_OPENAI", false)
+	cfg.AudioSummarySeconds = getenvInt("AUDIO_SUMMARY_SECONDS", 120)
+	cfg.AudioSummaryQuotes = getenvInt("AUDIO_SUMMARY_QUOTES", 3)
+	switch {
+	case cfg.TranscribeBackend == "openai":
+	case cfg.TranscribeBackend == "exec" && cfg.TranscribeCommand != "":
+	case cfg.TranscribeBackend == "http" && cfg.TranscribeURL != "":
+	default:
+		log.Printf("TRANSCRIBE_BACKEND inválido ou incompleto (%q): usando openai", cfg.TranscribeBackend)
+		cfg.TranscribeBackend = "openai"
+	}
+
+	cfg.TTSProvider = strings.ToLower(getenv("TTS_PROVIDER", "openai"))
+	cfg.ElevenLabsAPIKey = strings.TrimSpace(os.Getenv("ELEVENLABS_API_KEY"))
+	cfg.ElevenLabsVoiceID = strings.TrimSpace(os.Getenv("ELEVENLABS_VOICE_ID"))
+	cfg.ElevenLabsModel = getenv("ELEVENLABS_MODEL", "eleven_multilingual_v2")
+	cfg.ElevenLabsStability = getenvFloat("ELEVENLABS_STABILITY", 0.5)
+	cfg.ElevenLabsSimilarity = getenvFloat("ELEVENLABS_SIMILARITY", 0.75)
+	cfg.ElevenLabsBaseURL = strings.TrimRight(getenv("ELEVENLABS_BASE_URL", "https://api.elevenlabs.io"), "/")
+	if cfg.TTSProvider == "elevenlabs" && os.Getenv("TTS_VOICE") == "" {
+		cfg.TTSVoice = cfg.ElevenLabsVoiceID
+	}
+	switch {
+	case cfg.TTSProvider == "openai":
+	case cfg.TTSProvider == "elevenlabs" && cfg.ElevenLabsAPIKey != "" && cfg.TTSVoice != "":
+	default:
+		log.Printf("TTS_PROVIDER inválido ou incompleto (%q): usando openai", cfg.TTSProvider)
+		cfg.TTSProvider = "openai"
+		cfg.TTSVoice = getenv("TTS_VOICE", "onyx")
+	}
+
+	cfg.TTSCacheEnabled = getenvBool("TTS_CACHE_ENABLED", true)
+	cfg.TTSCacheTTLHours = getenvInt("TTS_CACHE_TTL_HOURS", 720)
+	if cfg.TTSCacheTTLHours <= 0 {
+		cfg.TTSCacheTTLHours = 720
+	}
+	cfg.TTSCacheMaxChars = getenvInt("TTS_CACHE_MAX_CHARS", 300)
+	cfg.AudioTextFallback = getenvBool("AUDIO_TEXT_FALLBACK", true)
+	cfg.AudioFallbackNote = "(Não consegui enviar o áudio, então segue a resposta por escrito.)"
+	if v, ok := os.LookupEnv("AUDIO_FALLBACK_NOTE"); ok {
+		cfg.AudioFallbackNote = strings.TrimSpace(v)
+	}
+
+	if s := strings.TrimSpace(os.Getenv("BUSINESS_VARS")); s != "" {
+		if err := json.Unmarshal([]byte(s), &cfg.BusinessVars); err != nil {
+			log.Printf("BUSINESS_VARS inválido (esperado objeto JSON de strings): %v", err)
+		}
+	}
+
+	cfg.CitationsMode = strings.ToLower(getenv("CITATIONS_MODE", "strip"))
+	if cfg.CitationsMode != "strip" && cfg.CitationsMode != "footnotes" {
+		log.Printf("CITATIONS_MODE inválido (%q): usando strip", cfg.CitationsMode)
+		cfg.CitationsMode = "strip"
+	}
+
+	cfg.EventBus = strings.ToLower(getenv("EVENT_BUS", "local"))
+	if cfg.EventBus != "local" && cfg.EventBus != "postgres" {
+		log.Printf("EVENT_BUS inválido (%q): usando local", cfg.EventBus)
+		cfg.EventBus = "local"
+	}
+	cfg.StatusTTLMinutes = getenvInt("STATUS_TTL_MINUTES", 60)
+	if cfg.StatusTTLMinutes <= 0 {
+		cfg.StatusTTLMinutes = 60
+	}
+
+	cfg.Assistants = map[string]string{}
+	if s := strings.TrimSpace(os.Getenv("ASSISTANTS")); s != "" {
+		if err := json.Unmarshal([]byte(s), &cfg.Assistants); err != nil {
+			log.Printf("ASSISTANTS inválido (esperado objeto JSON de strings): %v", err)
+		}
+	}
+	if _, ok := cfg.Assistants["default"]; !ok && cfg.OpenAIAssistantID != "" {
+		cfg.Assistants["default"] = cfg.OpenAIAssistantID
+	}
+
+	cfg.FallbackMessages = map[string]string{
+		"transcription_failed": "Desculpe, não consegui entender seu áudio. Pode enviar de novo ou escrever a mensagem?",
+		"document_too_large":   "Esse documento é grande demais para eu analisar. Pode enviar um arquivo menor ou só as páginas importantes?",
+		"media_failed":         "Não consegui abrir o arquivo que você enviou. Pode tentar enviar novamente?",
+		"system_busy":          "Estou com uma instabilidade no momento e não consegui responder. Pode repetir sua mensagem em alguns minutos?",
+		"empty_reply":          "Desculpe, não consegui formular uma resposta agora. Pode reformular sua pergunta?",
+		"internal_error":       "Desculpe, tive um problema aqui e não consegui responder. Pode mandar sua mensagem de novo?",
+	}
+	if s := strings.TrimSpace(os.Getenv("FALLBACK_MESSAGES")); s != "" {
+		var custom map[string]string
+		if err := json.Unmarshal([]byte(s), &custom); err != nil {
+			log.Printf("FALLBACK_MESSAGES inválido (esperado objeto JSON de strings): %v", err)
+		}
+		for k, v := range custom {
+			cfg.FallbackMessages[k] = v
+		}
+	}
+	cfg.CallAutoReject = getenvBool("CALL_AUTO_REJECT", true)
+	cfg.CallReply = "Oi! Não atendemos ligações por aqui, mas é só me mandar uma mensagem que eu respondo rapidinho. 😊"
+	if v, ok := os.LookupEnv("CALL_REPLY"); ok {
+		cfg.CallReply = strings.TrimSpace(v) // vazio desativa a resposta
+	}
+	cfg.FallbackCooldownMinutes = getenvInt("FALLBACK_COOLDOWN_MINUTES", 10)
+	cfg.DocumentMaxMB = getenvInt("DOCUMENT_MAX_MB", 15)
+	cfg.EnrichURL = strings.TrimSpace(os.Getenv("ENRICH_URL"))
+	cfg.EnrichToken = strings.TrimSpace(os.Getenv("ENRICH_TOKEN"))
+	cfg.EnrichTimeoutMs = getenvInt("ENRICH_TIMEOUT_MS", 1500)
+	if cfg.EnrichTimeoutMs <= 0 {
+		cfg.EnrichTimeoutMs = 1500
+	}
+	cfg.EnrichCacheHours = getenvInt("ENRICH_CACHE_HOURS", 24)
+	cfg.DocumentFileSearch = getenvBool("DOCUMENT_FILE_SEARCH", false)
+	cfg.DocumentFileTTLHours = getenvInt("DOCUMENT_FILE_TTL_HOURS", 168)
+	if cfg.DocumentFileTTLHours <= 0 {
+		cfg.DocumentFileTTLHours = 168
+	}
+	cfg.InboundMaxChars = getenvInt("INBOUND_MAX_CHARS", 8000)
+	cfg.DebugCaptureMinutes = getenvInt("DEBUG_CAPTURE_MINUTES", 0)
+	cfg.DebugCaptureSize = getenvInt("DEBUG_CAPTURE_SIZE", 200)
+	cfg.DebugCaptureMaxKB = getenvInt("DEBUG_CAPTURE_MAX_KB", 64)
+
+	cfg.WebhookReplayWindowSeconds = getenvInt("WEBHOOK_REPLAY_WINDOW_SECONDS", 300)
+	if cfg.WebhookReplayWindowSeconds <= 0 {
+		cfg.WebhookReplayWindowSeconds = 300
+	}
+	cfg.WidgetSecret = strings.TrimSpace(os.Getenv("WIDGET_SECRET"))
+	cfg.WidgetTokenHours = getenvInt("WIDGET_TOKEN_HOURS", 72)
+	if cfg.WidgetTokenHours <= 0 {
+		cfg.WidgetTokenHours = 72
+	}
+	cfg.WidgetOrigins = getenvList("WIDGET_ORIGINS")
+	cfg.WidgetURL = strings.TrimSpace(os.Getenv("WIDGET_URL"))
+
+	cfg.BackpressureMaxRuns = getenvInt("BACKPRESSURE_MAX_RUNS", 0)
+	cfg.BackpressureMaxPending = getenvInt("BACKPRESSURE_MAX_PENDING", 0)
+	cfg.BackpressurePolicy = strings.ToLower(getenv("BACKPRESSURE_POLICY", "reject"))
+	switch cfg.BackpressurePolicy {
+	case "reject", "new_only":
+	default:
+		log.Printf("BACKPRESSURE_POLICY inválido (%q): usando reject", cfg.BackpressurePolicy)
+		cfg.BackpressurePolicy = "reject"
+	}
+	cfg.BackpressureRetryAfterSeconds = getenvInt("BACKPRESSURE_RETRY_AFTER_SECONDS", 30)
+	if cfg.BackpressureRetryAfterSeconds <= 0 {
+		cfg.BackpressureRetryAfterSeconds = 30
+	}
+	cfg.RunWorkers = getenvInt("RUN_WORKERS", 0)
+	cfg.QueuePriorityTags = []string{"vip", "pago"}
+	if _, ok := os.LookupEnv("QUEUE_PRIORITY_TAGS"); ok {
+		cfg.QueuePriorityTags = getenvList("QUEUE_PRIORITY_TAGS")
+	}
+	cfg.QueueBulkTags = getenvList("QUEUE_BULK_TAGS")
+	cfg.QueueMaxWaitSeconds = getenvInt("QUEUE_MAX_WAIT_SECONDS", 30)
+	if cfg.QueueMaxWaitSeconds <= 0 {
+		cfg.QueueMaxWaitSeconds = 30
+	}
+
+	cfg.UazapiDownloadTimeoutSeconds = getenvInt("UAZAPI_DOWNLOAD_TIMEOUT_SECONDS", 60)
+	cfg.UazapiDownloadRetries = getenvInt("UAZAPI_DOWNLOAD_RETRIES", 3)
+	cfg.UazapiBreakerFailures = getenvInt("UAZAPI_BREAKER_FAILURES", 5)
+	cfg.UazapiBreakerCooldownSeconds = getenvInt("UAZAPI_BREAKER_COOLDOWN_SECONDS", 30)
+	cfg.NumberCheckEnabled = getenvBool("NUMBER_CHECK_ENABLED", true)
+	cfg.NumberCheckCacheHours = getenvInt("NUMBER_CHECK_CACHE_HOURS", 168)
+	if cfg.NumberCheckCacheHours < 0 {
+		cfg.NumberCheckCacheHours = 0
+	}
+
+	cfg.BusinessTimezone = getenv("BUSINESS_TIMEZONE", "America/Sao_Paulo")
+
+	cfg.OutboundMaxPerMinute = getenvInt("OUTBOUND_MAX_PER_MINUTE", 0)
+	cfg.OutboundMinIntervalMs = getenvInt("OUTBOUND_MIN_INTERVAL_MS", 0)
+	cfg.OutboundJitterMs = getenvInt("OUTBOUND_JITTER_MS", 0)
+	if v := strings.TrimSpace(os.Getenv("OUTBOUND_RAMP_START")); v != "" {
+		if t, err := time.ParseInLocation("2006-01-02", v, cfg.Location()); err == nil {
+			cfg.OutboundRampStart = t
+		} else {
+			log.Printf("OUTBOUND_RAMP_START inválido (esperado AAAA-MM-DD): %q", v)
+		}
+	}
+	cfg.OutboundRampUp = strings.TrimSpace(os.Getenv("OUTBOUND_RAMP_UP"))
+	cfg.OutboundMaxWaitSeconds = getenvInt("OUTBOUND_MAX_WAIT_SECONDS", 300)
+	cfg.OpenAICostPer1KTokens = getenvFloat("OPENAI_COST_PER_1K_TOKENS", 0.002)
+
+	cfg.BudgetDailyTokens = getenvInt("BUDGET_DAILY_TOKENS", 0)
+	cfg.BudgetMonthlyTokens = getenvInt("BUDGET_MONTHLY_TOKENS", 0)
+	cfg.BudgetDailyUSD = getenvFloat("BUDGET_DAILY_USD", 0)
+	cfg.BudgetMonthlyUSD = getenvFloat("BUDGET_MONTHLY_USD", 0)
+	cfg.BudgetClientDailyTokens = getenvInt("BUDGET_CLIENT_DAILY_TOKENS", 0)
+	cfg.BudgetClientMonthlyTokens = getenvInt("BUDGET_CLIENT_MONTHLY_TOKENS", 0)
+	cfg.BudgetClientDailyUSD = getenvFloat("BUDGET_CLIENT_DAILY_USD", 0)
+	cfg.BudgetClientMonthlyUSD = getenvFloat("BUDGET_CLIENT_MONTHLY_USD", 0)
+	cfg.BudgetFallbackModel = strings.TrimSpace(os.Getenv("BUDGET_FALLBACK_MODEL"))
+	cfg.BudgetExceededMessage = getenv("BUDGET_EXCEEDED_MESSAGE",
+		"Estamos com muita demanda agora e não consigo responder. Por favor, volte mais tarde que retomamos seu atendimento!")
+
+	cfg.LinkUnfurlEnabled = getenvBool("LINK_UNFURL_ENABLED", true)
+	cfg.LinkUnfurlTimeoutSeconds = getenvInt("LINK_UNFURL_TIMEOUT_SECONDS", 8)
+	cfg.LinkUnfurlMaxKB = getenvInt("LINK_UNFURL_MAX_KB", 1024)
+	cfg.LinkUnfurlMaxLinks = getenvInt("LINK_UNFURL_MAX_LINKS", 2)
+
+	cfg.AbuseEnabled = getenvBool("ABUSE_ENABLED", true)
+	cfg.AbuseMaxPerMinute = getenvInt("ABUSE_MAX_PER_MINUTE", 20)
+	cfg.AbuseRepeatLimit = getenvInt("ABUSE_REPEAT_LIMIT", 5)
+	cfg.AbuseLinkOnlyLimit = getenvInt("ABUSE_LINK_ONLY_LIMIT", 3)
+	cfg.AbuseCooldownMinutes = getenvInt("ABUSE_COOLDOWN_MINUTES", 30)
+	cfg.AbuseModelCheck = getenvBool("ABUSE_MODEL_CHECK", false)
+
+	cfg.SettingsCacheSeconds = getenvInt("SETTINGS_CACHE_SECONDS", 30)
+	cfg.ReplyDirectives = getenvBool("REPLY_DIRECTIVES", true)
+	cfg.HandoffNotify = getenvList("HANDOFF_NOTIFY")
+	cfg.TeamNotifyGroup = strings.TrimSpace(os.Getenv("TEAM_NOTIFY_GROUP"))
+	cfg.TeamNotifyEvents = []string{"lead.created", "handoff.requested", "payment.received"}
+	if _, ok := os.LookupEnv("TEAM_NOTIFY_EVENTS"); ok {
+		cfg.TeamNotifyEvents = getenvList("TEAM_NOTIFY_EVENTS")
+	}
+	cfg.TeamNotifyTemplates = map[string]string{
+		"lead.created":      "🆕 Novo lead de {name} ({phone}): {content}",
+		"handoff.requested": "🙋 {name} ({phone}) pediu atendimento humano.",
+		"payment.received":  "💰 Pagamento de {name} ({phone}): {content}",
+	}
+	if s := strings.TrimSpace(os.Getenv("TEAM_NOTIFY_TEMPLATES")); s != "" {
+		var custom map[string]string
+		if err := json.Unmarshal([]byte(s), &custom); err != nil {
+			log.Printf("TEAM_NOTIFY_TEMPLATES inválido (esperado objeto JSON de strings): %v", err)
+		}
+		for k, v := range custom {
+			cfg.TeamNotifyTemplates[k] = v
+		}
+	}
+	cfg.TeamQuietHours = strings.TrimSpace(os.Getenv("TEAM_QUIET_HOURS"))
+	if _, _, ok := parseQuietHours(cfg.TeamQuietHours); cfg.TeamQuietHours != "" && !ok {
+		log.Printf("TEAM_QUIET_HOURS inválido (%q): esperado HH:MM-HH:MM", cfg.TeamQuietHours)
+		cfg.TeamQuietHours = ""
+	}
+	cfg.ReplyMarkdown = getenvBool("REPLY_MARKDOWN", true)
+	cfg.ReplyTableMode = strings.ToLower(getenv("REPLY_TABLE_MODE", "auto"))
+	switch cfg.ReplyTableMode {
+	case "auto", "monospace", "bullets":
+	default:
+		log.Printf("REPLY_TABLE_MODE inválido (%q): usando auto", cfg.ReplyTableMode)
+		cfg.ReplyTableMode = "auto"
 	}
+	cfg.ReplyFooter = strings.TrimSpace(os.Getenv("REPLY_FOOTER"))
+	cfg.ReplyFooterSkipTag = getenv("REPLY_FOOTER_SKIP_TAG", "sem_assinatura")
+	cfg.ReplyVariantMarker = getenvBool("REPLY_VARIANT_MARKER", false)
+
+	cfg.ReengageEnabled = getenvBool("REENGAGE_ENABLED", false)
+	cfg.ReengageHour = getenvInt("REENGAGE_HOUR", 10)
+	if cfg.ReengageHour < 0 || cfg.ReengageHour > 23 {
+		cfg.ReengageHour = 10
+	}
+	cfg.ReengageAfterDays = getenvInt("REENGAGE_AFTER_DAYS", 7)
+	cfg.ReengageMaxDays = getenvInt("REENGAGE_MAX_DAYS", 60)
+	cfg.ReengageTag = getenv("REENGAGE_TAG", "")
+	cfg.ReengageMaxAttempts = getenvInt("REENGAGE_MAX_ATTEMPTS", 1)
+	cfg.ReengageBatch = getenvInt("REENGAGE_BATCH", 50)
+	cfg.ReengagePaceSeconds = getenvInt("REENGAGE_PACE_SECONDS", 20)
+	cfg.ReengageFooter = getenv("REENGAGE_FOOTER", "(Se não quiser mais receber mensagens como esta, responda PARAR.)")
+	cfg.CSATEnabled = getenvBool("CSAT_ENABLED", false)
+	cfg.CSATInactivityMinutes = getenvInt("CSAT_INACTIVITY_MINUTES", 60)
+	cfg.CSATCooldownDays = getenvInt("CSAT_COOLDOWN_DAYS", 7)
+	cfg.CSATAnswerHours = getenvInt("CSAT_ANSWER_HOURS", 24)
+	cfg.CSATQuestion = getenv("CSAT_QUESTION", "Como você avalia o nosso atendimento? Responda com uma nota de 1 (muito ruim) a 5 (excelente).")
+	cfg.CSATThanks = getenv("CSAT_THANKS", "Obrigado pela avaliação! 🙏")
+	cfg.SLOTargetSeconds = getenvInt("SLO_TARGET_SECONDS", 30)
+	cfg.SLOObjective = getenvFloat("SLO_OBJECTIVE", 0.95)
+	if cfg.SLOObjective <= 0 || cfg.SLOObjective >= 1 {
+		log.Printf("SLO_OBJECTIVE inválido (%v): usando 0.95", cfg.SLOObjective)
+		cfg.SLOObjective = 0.95
+	}
+	cfg.SLOWindowHours = getenvInt("SLO_WINDOW_HOURS", 24)
+	if cfg.SLOWindowHours <= 0 {
+		cfg.SLOWindowHours = 24
+	}
+	cfg.SLOAlertWindowMinutes = getenvInt("SLO_ALERT_WINDOW_MINUTES", 60)
+	if cfg.SLOAlertWindowMinutes <= 0 {
+		cfg.SLOAlertWindowMinutes = 60
+	}
+	cfg.SLOBurnRate = getenvFloat("SLO_BURN_RATE", 2)
+	cfg.SLOMinEvents = getenvInt("SLO_MIN_EVENTS", 10)
+	cfg.SLOAlertCooldownMinutes = getenvInt("SLO_ALERT_COOLDOWN_MINUTES", 60)
+	cfg.SLOAlertWebhook = strings.TrimSpace(os.Getenv("SLO_ALERT_WEBHOOK"))
+	cfg.PanicAlertCooldownMinutes = getenvInt("PANIC_ALERT_COOLDOWN_MINUTES", 10)
+	cfg.PanicAlertWebhook = getenv("PANIC_ALERT_WEBHOOK", cfg.SLOAlertWebhook)
+	cfg.OptOutKeywords = getenvList("OPT_OUT_KEYWORDS")
+	if len(cfg.OptOutKeywords) == 0 {
+		cfg.OptOutKeywords = []string{"parar", "sair", "stop", "descadastrar"}
+	}
+	cfg.OptOutReply = getenv("OPT_OUT_REPLY", "Tudo bem! Você não vai mais receber mensagens nossas por iniciativa própria. Se precisar, é só chamar aqui.")
+	cfg.MuteEnabled = getenvBool("MUTE_ENABLED", true)
+	cfg.MuteDefaultHours = getenvInt("MUTE_DEFAULT_HOURS", 24)
+	if cfg.MuteDefaultHours <= 0 {
+		cfg.MuteDefaultHours = 24
+	}
+	cfg.MuteMaxDays = getenvInt("MUTE_MAX_DAYS", 30)
+	if cfg.MuteMaxDays <= 0 {
+		cfg.MuteMaxDays = 30
+	}
+	cfg.MuteReply = getenv("MUTE_REPLY", "Combinado! Não vou responder por aqui até %s. Se mudar de ideia, é só escrever REATIVAR.")
+	cfg.ChatCommands = []string{"menu", "atendente", "audio", "apagar"}
+	if _, ok := os.LookupEnv("CHAT_COMMANDS"); ok {
+		cfg.ChatCommands = getenvList("CHAT_COMMANDS")
+	}
+	cfg.ChatCommandReplies = map[string]string{
+		"menu":        "Posso te ajudar com qualquer dúvida, é só escrever. Também dá para usar estes comandos:",
+		"atendente":   "Certo! Já chamei um atendente, ele continua a conversa por aqui em breve.",
+		"audio_off":   "Combinado! A partir de agora respondo só por texto, mesmo quando você mandar áudio. Para voltar, escreva ÁUDIO ON.",
+		"audio_on":    "Pronto! Quando você mandar áudio, eu respondo em áudio também.",
+		"apagar":      "Para apagar todos os seus dados (conversa, cadastro e arquivos), responda com o código %s nos próximos minutos. Antes de apagar, envio aqui uma cópia dos seus dados.",
+		"apagar_done": "Pronto: seus dados foram apagados. Se voltar a escrever, começamos uma conversa nova.",
+	}
+	if s := strings.TrimSpace(os.Getenv("CHAT_COMMAND_REPLIES")); s != "" {
+		var custom map[string]string
+		if err := json.Unmarshal([]byte(s), &custom); err != nil {
+			log.Printf("CHAT_COMMAND_REPLIES inválido (esperado objeto JSON de strings): %v", err)
+		}
+		for k, v := range custom {
+			cfg.ChatCommandReplies[k] = v
+		}
+	}
+	cfg.UnmuteMessage = getenv("UNMUTE_MESSAGE", "Oi! Estou de volta por aqui. Se precisar de algo, é só chamar. 🙂")
+	cfg.ForwardedChainScore = getenvInt("FORWARDED_CHAIN_SCORE", 5)
+	cfg.ForwardedChainAction = strings.ToLower(getenv("FORWARDED_CHAIN_ACTION", "reply"))
+	switch cfg.ForwardedChainAction {
+	case "reply", "ack", "assistant":
+	default:
+		log.Printf("FORWARDED_CHAIN_ACTION inválido (%q): usando reply", cfg.ForwardedChainAction)
+		cfg.ForwardedChainAction = "reply"
+	}
+	cfg.RunTemperature = getenvFloat("OPENAI_RUN_TEMPERATURE", -1)
+	if cfg.RunTemperature > 2 {
+		log.Printf("OPENAI_RUN_TEMPERATURE fora de 0–2 (%v): usando a do assistente", cfg.RunTemperature)
+		cfg.RunTemperature = -1
+	}
+	cfg.RunTopP = getenvFloat("OPENAI_RUN_TOP_P", -1)
+	if cfg.RunTopP > 1 {
+		log.Printf("OPENAI_RUN_TOP_P fora de 0–1 (%v): usando o do assistente", cfg.RunTopP)
+		cfg.RunTopP = -1
+	}
+	cfg.RunMaxPromptTokens = runTokenLimit("OPENAI_RUN_MAX_PROMPT_TOKENS")
+	cfg.RunMaxCompletionTokens = runTokenLimit("OPENAI_RUN_MAX_COMPLETION_TOKENS")
+	cfg.RunTruncation = strings.TrimSpace(os.Getenv("OPENAI_RUN_TRUNCATION"))
+	if !validTruncation(cfg.RunTruncation) {
+		log.Printf("OPENAI_RUN_TRUNCATION inválido (%q): esperado auto ou last_messages:N", cfg.RunTruncation)
+		cfg.RunTruncation = ""
+	}
+	cfg.RunReasoningEffort = strings.ToLower(strings.TrimSpace(os.Getenv("OPENAI_RUN_REASONING_EFFORT")))
+	switch cfg.RunReasoningEffort {
+	case "", "low", "medium", "high":
+	default:
+		log.Printf("OPENAI_RUN_REASONING_EFFORT inválido (%q): usando o do assistente", cfg.RunReasoningEffort)
+		cfg.RunReasoningEffort = ""
+	}
+	cfg.ErasureConfirmMinutes = getenvInt("ERASURE_CONFIRM_MINUTES", 30)
+	if cfg.ErasureConfirmMinutes <= 0 {
+		cfg.ErasureConfirmMinutes = 30
+	}
+	cfg.ErasureSendExport = getenvBool("ERASURE_SEND_EXPORT", true)
+	cfg.WarmupNumber = strings.TrimSpace(os.Getenv("WARMUP_NUMBER"))
+	cfg.WarmupText = getenv("WARMUP_TEXT", "✅ Agente iniciado em %s: teste de entrega do WhatsApp.")
+	cfg.WarmupAckTimeoutSeconds = getenvInt("WARMUP_ACK_TIMEOUT_SECONDS", 120)
+	if cfg.WarmupAckTimeoutSeconds <= 0 {
+		cfg.WarmupAckTimeoutSeconds = 120
+	}
+	cfg.SendPendingTimeoutMinutes = getenvInt("SEND_PENDING_TIMEOUT_MINUTES", 15)
+	if cfg.SendPendingTimeoutMinutes <= 0 {
+		cfg.SendPendingTimeoutMinutes = 15
+	}
+	cfg.ForwardedChainReply = getenv("FORWARDED_CHAIN_REPLY", "Recebi a mensagem encaminhada! Se tiver alguma dúvida sobre ela ou quiser falar com a gente, é só escrever aqui.")
+
+	cfg.EmojiAction = strings.ToLower(getenv("EMOJI_ACTION", "ack"))
+	switch cfg.EmojiAction {
+	case "reply", "ack", "assistant":
+	default:
+		log.Printf("EMOJI_ACTION inválido (%q): usando ack", cfg.EmojiAction)
+		cfg.EmojiAction = "ack"
+	}
+	cfg.EmojiReply = getenv("EMOJI_REPLY", "😊")
+
+	cfg.DigestWhatsApp = getenvList("DIGEST_WHATSAPP")
+	cfg.DigestEmails = getenvList("DIGEST_EMAILS")
+	cfg.BudgetAlertNotify = getenvList("BUDGET_ALERT_NOTIFY")
+	if len(cfg.BudgetAlertNotify) == 0 {
+		cfg.BudgetAlertNotify = cfg.DigestWhatsApp
+	}
+	cfg.SLOAlertNotify = getenvList("SLO_ALERT_NOTIFY")
+	if len(cfg.SLOAlertNotify) == 0 {
+		cfg.SLOAlertNotify = cfg.BudgetAlertNotify
+	}
+	cfg.PanicAlertNotify = getenvList("PANIC_ALERT_NOTIFY")
+	if len(cfg.PanicAlertNotify) == 0 {
+		cfg.PanicAlertNotify = cfg.SLOAlertNotify
+	}
+	cfg.DigestHour = getenvInt("DIGEST_HOUR", 8)
+	if cfg.DigestHour < 0 || cfg.DigestHour > 23 {
+		cfg.DigestHour = 8
+	}
+	cfg.SMTPHost = os.Getenv("SMTP_HOST")
+	cfg.SMTPPort = getenvInt("SMTP_PORT", 587)
+	cfg.SMTPUser = os.Getenv("SMTP_USER")
+	cfg.SMTPPass = os.Getenv("SMTP_PASS")
+	cfg.SMTPFrom = os.Getenv("SMTP_FROM")
+
+	cfg.RetentionDays = getenvInt("RETENTION_DAYS", 0)
+	cfg.RetentionIntervalHours = getenvInt("RETENTION_INTERVAL_HOURS", 24)
+	cfg.RedactProfanity = getenvBool("REDACT_PROFANITY", false)
+	cfg.RedactPII = getenvBool("REDACT_PII", false)
+	cfg.RedactWords = getenvList("REDACT_WORDS")
+	cfg.RedactKeepOriginal = getenvBool("REDACT_KEEP_ORIGINAL", false)
+
+	cfg.SinkKind = strings.ToLower(strings.TrimSpace(os.Getenv("SINK_KIND")))
+	cfg.SinkIntervalSeconds = getenvInt("SINK_INTERVAL_SECONDS", 300)
+	cfg.SinkBatchSize = getenvInt("SINK_BATCH_SIZE", 500)
+	cfg.ClickHouseURL = os.Getenv("CLICKHOUSE_URL")
+	cfg.ClickHouseDatabase = getenv("CLICKHOUSE_DATABASE", "default")
+	cfg.ClickHouseUser = os.Getenv("CLICKHOUSE_USER")
+	cfg.ClickHousePassword = os.Getenv("CLICKHOUSE_PASSWORD")
+	cfg.BigQueryProject = os.Getenv("BIGQUERY_PROJECT")
+	cfg.BigQueryDataset = os.Getenv("BIGQUERY_DATASET")
+	cfg.BigQueryCredentialsFile = os.Getenv("BIGQUERY_CREDENTIALS_FILE")
+
+	cfg.BackupKey = os.Getenv("BACKUP_KEY")
+	cfg.BackupS3Bucket = os.Getenv("BACKUP_S3_BUCKET")
+	cfg.BackupS3Prefix = os.Getenv("BACKUP_S3_PREFIX")
+	cfg.BackupS3Region = getenv("BACKUP_S3_REGION", "us-east-1")
+	cfg.BackupS3Endpoint = strings.TrimRight(os.Getenv("BACKUP_S3_ENDPOINT"), "/")
+	cfg.AWSAccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	cfg.AWSSecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
 
 	// TTS speed
 	if s := os.Getenv("TTS_SPEED"); s != "" {
@@ -109,6 +1126,16 @@ func Load() Config {
 		cfg.BufferTimeoutSeconds = 15
 	}
 
+	cfg.TypingExtendSeconds = getenvInt("TYPING_EXTEND_SECONDS", 10)
+	cfg.TypingMaxWaitSeconds = getenvInt("TYPING_MAX_WAIT_SECONDS", 60)
+	cfg.AlbumWaitSeconds = getenvInt("ALBUM_WAIT_SECONDS", 4)
+	cfg.AlbumParallelism = getenvInt("ALBUM_PARALLELISM", 3)
+	cfg.VisionFollowup = getenvBool("VISION_FOLLOWUP", true)
+	cfg.VisionMemoryDays = getenvInt("VISION_MEMORY_DAYS", 7)
+	if cfg.VisionMemoryDays <= 0 {
+		cfg.VisionMemoryDays = 7
+	}
+
 	// ---------- NOVO: Delay configurável ----------
     // Delay mínimo e máximo para exibir o indicador de "digitando...".
     // Se as variáveis de ambiente não forem definidas, assume‑se um intervalo
@@ -144,9 +1171,52 @@ func Load() Config {
 	return cfg
 }
 
+// Location retorna o fuso do negócio (BUSINESS_TIMEZONE), com fallback para UTC.
+func (c Config) Location() *time.Location {
+	if loc, err := time.LoadLocation(c.BusinessTimezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
 // ReplyDelay retorna a duração de espera antes de responder, aplicando jitter uniforme.
 // Se Min/Max forem 0, retorna 0 (sem atraso).
 func (c Config) ReplyDelay() time.Duration {
+	// sorteio criptograficamente seguro (evita races do math/rand)
+	return c.ReplyDelayFrom(clock.Crypto)
+}
+
+// InTeamQuietHours indica se t cai no horário de silêncio dos avisos à equipe
+// (TEAM_QUIET_HOURS, no fuso do negócio). A janela pode passar da meia-noite.
+func (c Config) InTeamQuietHours(t time.Time) bool {
+	start, end, ok := parseQuietHours(c.TeamQuietHours)
+	if !ok || start == end {
+		return false
+	}
+	lt := t.In(c.Location())
+	m := lt.Hour()*60 + lt.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// parseQuietHours lê "HH:MM-HH:MM" em minutos desde a meia-noite.
+func parseQuietHours(s string) (start, end int, ok bool) {
+	a, b, found := strings.Cut(s, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ta, err1 := time.Parse("15:04", strings.TrimSpace(a))
+	tb, err2 := time.Parse("15:04", strings.TrimSpace(b))
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return ta.Hour()*60 + ta.Minute(), tb.Hour()*60 + tb.Minute(), true
+}
+
+// ReplyDelayFrom é ReplyDelay com a fonte de sorteio injetada (fixa em testes).
+func (c Config) ReplyDelayFrom(r clock.Rand) time.Duration {
 	min := c.ReplyDelayMinMs
 	max := c.ReplyDelayMaxMs
 	if min <= 0 && max <= 0 {
@@ -155,13 +1225,9 @@ func (c Config) ReplyDelay() time.Duration {
 	if max < min {
 		max = min
 	}
-	// sorteio criptograficamente seguro (evita races do math/rand)
 	ms := min
 	if max > min {
-		n, err := crand.Int(crand.Reader, big.NewInt(int64(max-min+1)))
-		if err == nil {
-			ms = min + int(n.Int64())
-		}
+		ms = min + int(r.Int63n(int64(max-min+1)))
 	}
 	return time.Duration(ms) * time.Millisecond
 }
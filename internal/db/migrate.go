@@ -31,8 +31,723 @@ CREATE TABLE IF NOT EXISTS messages (
 CREATE INDEX IF NOT EXISTS idx_messages_client_time ON messages (client_id, created_at DESC);
 `
 
+// clientFactsSQL mirrors migrations/002_client_facts.sql
+const clientFactsSQL = `
+CREATE TABLE IF NOT EXISTS client_facts (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  key TEXT NOT NULL,
+  value TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (client_id, key)
+);
+`
+
+// messageFlagsSQL mirrors migrations/003_message_flags.sql
+const messageFlagsSQL = `
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS ephemeral BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS view_once BOOLEAN NOT NULL DEFAULT false;
+`
+
+// outboundIDsSQL mirrors migrations/004_outbound_ids.sql
+const outboundIDsSQL = `
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS provider_at TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_ext_id ON messages (ext_id);
+`
+
+// inboundQueueSQL mirrors migrations/005_inbound_queue.sql
+const inboundQueueSQL = `
+CREATE TABLE IF NOT EXISTS inbound_queue (
+  id BIGSERIAL PRIMARY KEY,
+  phone TEXT NOT NULL,
+  content TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// failuresSQL mirrors migrations/006_failures.sql
+const failuresSQL = `
+CREATE TABLE IF NOT EXISTS failures (
+  id BIGSERIAL PRIMARY KEY,
+  phone TEXT NOT NULL,
+  stage TEXT NOT NULL,
+  error TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_failures_time ON failures (created_at DESC);
+`
+
+// ttsCacheSQL mirrors migrations/007_tts_cache.sql
+const ttsCacheSQL = `
+CREATE TABLE IF NOT EXISTS tts_cache (
+  key TEXT PRIMARY KEY,
+  voice TEXT NOT NULL,
+  speed DOUBLE PRECISION NOT NULL,
+  audio BYTEA NOT NULL,
+  hits BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// sinkStateSQL mirrors migrations/008_sink_state.sql
+const sinkStateSQL = `
+CREATE TABLE IF NOT EXISTS sink_state (
+  name TEXT PRIMARY KEY,
+  last_id BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// lidMapSQL mirrors migrations/009_lid_map.sql
+const lidMapSQL = `
+CREATE TABLE IF NOT EXISTS lid_map (
+  lid TEXT PRIMARY KEY,
+  phone TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lid_map_phone ON lid_map (phone);
+`
+
+// purgeAuditSQL mirrors migrations/010_purge_audit.sql
+const purgeAuditSQL = `
+CREATE TABLE IF NOT EXISTS purge_audit (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,       -- messages | thread | client
+  phone TEXT NULL,
+  detail TEXT NOT NULL DEFAULT '',
+  affected BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// leadsSQL mirrors migrations/011_leads.sql
+const leadsSQL = `
+CREATE TABLE IF NOT EXISTS leads (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL DEFAULT 'lead',       -- lead | order
+  title TEXT NOT NULL,
+  details TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'novo',     -- novo | em_andamento | ganho | perdido | cancelado
+  value NUMERIC(12,2) NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_leads_client ON leads (client_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_leads_status ON leads (status, updated_at DESC);
+`
+
+// apiKeysSQL mirrors migrations/012_api_keys.sql
+const apiKeysSQL = `
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  role TEXT NOT NULL,
+  key_prefix TEXT NOT NULL,
+  key_hash TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_used_at TIMESTAMPTZ NULL,
+  revoked_at TIMESTAMPTZ NULL
+);
+`
+
+// botSettingsSQL mirrors migrations/013_bot_settings.sql
+const botSettingsSQL = `
+CREATE TABLE IF NOT EXISTS bot_settings (
+  instance TEXT PRIMARY KEY,
+  reply_delay_min_ms INT NULL,
+  reply_delay_max_ms INT NULL,
+  tts_voice TEXT NULL,
+  tts_speed DOUBLE PRECISION NULL,
+  buffer_timeout_seconds INT NULL,
+  business_hours TEXT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// abuseEventsSQL mirrors migrations/014_abuse_events.sql
+const abuseEventsSQL = `
+CREATE TABLE IF NOT EXISTS abuse_events (
+  id BIGSERIAL PRIMARY KEY,
+  phone TEXT NOT NULL,
+  reason TEXT NOT NULL,       -- frequency | repeated | link_only | moderation
+  detail TEXT NOT NULL DEFAULT '',
+  cooldown_until TIMESTAMPTZ NOT NULL,
+  lifted_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_abuse_events_phone ON abuse_events (phone, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_abuse_events_active ON abuse_events (cooldown_until) WHERE lifted_at IS NULL;
+`
+
+// reengagementSQL mirrors migrations/015_reengagement.sql
+const reengagementSQL = `
+CREATE TABLE IF NOT EXISTS client_tags (
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  tag TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (client_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_tags_tag ON client_tags (tag);
+
+-- Cliente pediu para não receber mensagens ativas (ex.: respondeu "PARAR")
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS opted_out_at TIMESTAMPTZ NULL;
+
+CREATE TABLE IF NOT EXISTS reengagements (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  message TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reengagements_client ON reengagements (client_id, created_at DESC);
+`
+
+// greetingsSQL mirrors migrations/016_greetings.sql
+const greetingsSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS last_greeted_at TIMESTAMPTZ NULL;
+`
+
+// assistantTransfersSQL mirrors migrations/017_assistant_transfers.sql
+const assistantTransfersSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS assistant TEXT NULL;
+
+-- Uma thread por cliente e assistente, para retomar ao voltar a um assistente
+CREATE TABLE IF NOT EXISTS client_threads (
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  assistant TEXT NOT NULL,
+  thread_id TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (client_id, assistant)
+);
+
+-- Trilha de auditoria das transferências
+CREATE TABLE IF NOT EXISTS assistant_transfers (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  from_assistant TEXT NOT NULL,
+  to_assistant TEXT NOT NULL,
+  thread_id TEXT NOT NULL,
+  summary TEXT NOT NULL DEFAULT '',
+  reason TEXT NOT NULL DEFAULT '',
+  actor TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistant_transfers_client ON assistant_transfers (client_id, created_at DESC);
+`
+
+// openaiUsageSQL mirrors migrations/018_openai_usage.sql
+const openaiUsageSQL = `
+CREATE TABLE IF NOT EXISTS openai_usage (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  model TEXT NOT NULL DEFAULT '',
+  prompt_tokens BIGINT NOT NULL DEFAULT 0,
+  completion_tokens BIGINT NOT NULL DEFAULT 0,
+  total_tokens BIGINT NOT NULL DEFAULT 0,
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_openai_usage_created ON openai_usage (created_at);
+CREATE INDEX IF NOT EXISTS idx_openai_usage_client ON openai_usage (client_id, created_at);
+`
+
+// tenantsSQL mirrors migrations/019_tenants.sql
+const tenantsSQL = `
+-- tenant_id NULL = tenant padrão (credenciais do ENV)
+CREATE TABLE IF NOT EXISTS tenants (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL UNIQUE,              -- usado na rota /webhook/t/{slug}
+  name TEXT NOT NULL DEFAULT '',
+  token TEXT NULL UNIQUE,                 -- identifica o tenant no webhook e nas integrações
+  openai_api_key TEXT NULL,
+  openai_assistant_id TEXT NULL,
+  uazapi_base_send TEXT NULL,
+  uazapi_token_send TEXT NULL,
+  uazapi_base_download TEXT NULL,
+  uazapi_token_download TEXT NULL,
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL;
+ALTER TABLE inbound_queue ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE;
+
+-- o mesmo telefone pode ser cliente de mais de um tenant
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_phone_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_clients_tenant_phone ON clients ((COALESCE(tenant_id, 0)), phone);
+CREATE INDEX IF NOT EXISTS idx_messages_tenant_time ON messages (tenant_id, created_at DESC);
+`
+
+// forwardedMessagesSQL mirrors migrations/020_forwarded_messages.sql
+const forwardedMessagesSQL = `
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS forwarded BOOLEAN NOT NULL DEFAULT false;
+`
+
+// csatSQL mirrors migrations/021_csat.sql
+const csatSQL = `
+CREATE TABLE IF NOT EXISTS csat (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  reason TEXT NOT NULL,                 -- resolved | inactivity
+  rating SMALLINT NULL CHECK (rating BETWEEN 1 AND 5),
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  answered_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_csat_client ON csat (client_id, sent_at DESC);
+CREATE INDEX IF NOT EXISTS idx_csat_sent ON csat (sent_at);
+`
+
+// experimentsSQL mirrors migrations/022_experiments.sql
+const experimentsSQL = `
+CREATE TABLE IF NOT EXISTS experiments (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT false,
+  variants JSONB NOT NULL DEFAULT '[]',  -- [{"name","weight","assistant_id","instructions"}]
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_experiments_tenant_name ON experiments ((COALESCE(tenant_id, 0)), name);
+
+CREATE TABLE IF NOT EXISTS experiment_assignments (
+  experiment_id BIGINT NOT NULL REFERENCES experiments(id) ON DELETE CASCADE,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  variant TEXT NOT NULL,
+  assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (experiment_id, client_id)
+);
+
+-- "experimento:variante" das mensagens e runs feitas sob o experimento
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS variant TEXT NULL;
+ALTER TABLE openai_usage ADD COLUMN IF NOT EXISTS variant TEXT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_variant ON messages (variant) WHERE variant IS NOT NULL;
+`
+
+// scheduledJobsSQL mirrors migrations/023_scheduled_jobs.sql
+const scheduledJobsSQL = `
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+  name TEXT PRIMARY KEY,
+  owner TEXT NULL,                      -- réplica (host:pid) que detém o lease
+  lease_until TIMESTAMPTZ NULL,
+  slot TIMESTAMPTZ NULL,                -- janela da última execução (ex.: dia do digest)
+  status TEXT NOT NULL DEFAULT 'idle',  -- idle | running | ok | error
+  last_error TEXT NULL,
+  started_at TIMESTAMPTZ NULL,
+  finished_at TIMESTAMPTZ NULL,
+  runs BIGINT NOT NULL DEFAULT 0
+);
+`
+
+// threadFilesSQL mirrors migrations/024_thread_files.sql
+const threadFilesSQL = `
+CREATE TABLE IF NOT EXISTS thread_files (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  file_id TEXT NOT NULL UNIQUE,
+  filename TEXT NOT NULL,
+  bytes BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  attached_at TIMESTAMPTZ NULL,   -- anexado a uma mensagem da thread
+  expires_at TIMESTAMPTZ NOT NULL,
+  deleted_at TIMESTAMPTZ NULL     -- apagado na OpenAI
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_files_expiry ON thread_files (expires_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_thread_files_client ON thread_files (client_id) WHERE deleted_at IS NULL;
+`
+
+// replyLatencySQL mirrors migrations/025_reply_latency.sql
+const replyLatencySQL = `
+CREATE TABLE IF NOT EXISTS reply_latency (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  received_at TIMESTAMPTZ NOT NULL,  -- primeira mensagem do lote no webhook
+  replied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  latency_ms BIGINT NOT NULL,
+  ok BOOLEAN NOT NULL DEFAULT true   -- false = a run falhou (conta como fora do SLO)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reply_latency_replied ON reply_latency (replied_at);
+`
+
+// clientNotesSQL mirrors migrations/026_client_notes.sql
+const clientNotesSQL = `
+CREATE TABLE IF NOT EXISTS client_notes (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  note TEXT NOT NULL,
+  author TEXT NOT NULL,           -- nome da chave de API (ou "admin")
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_notes_client ON client_notes (client_id, created_at DESC);
+
+-- id para a exportação incremental (sink) e autoria das tags; NULL = assistente/sistema
+ALTER TABLE client_tags ADD COLUMN IF NOT EXISTS id BIGINT GENERATED BY DEFAULT AS IDENTITY;
+ALTER TABLE client_tags ADD COLUMN IF NOT EXISTS created_by TEXT NULL;
+`
+
+// clientCRMSQL mirrors migrations/027_client_crm.sql
+const clientCRMSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS crm JSONB NULL;              -- último retorno do CRM (campos planos)
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS crm_enriched_at TIMESTAMPTZ NULL;
+`
+
+// statusPostsSQL mirrors migrations/028_status_posts.sql
+const statusPostsSQL = `
+CREATE TABLE IF NOT EXISTS status_posts (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  text TEXT NOT NULL DEFAULT '',
+  media_type TEXT NOT NULL DEFAULT '',     -- vazio = status de texto
+  media_url TEXT NOT NULL DEFAULT '',
+  background_color INT NOT NULL DEFAULT 0,
+  font INT NOT NULL DEFAULT 0,
+  send_at TIMESTAMPTZ NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',  -- pending | sent | failed | canceled
+  message_id TEXT NULL,
+  error TEXT NULL,
+  created_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  sent_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_posts_due ON status_posts (send_at) WHERE status = 'pending';
+`
+
+// clientMuteSQL mirrors migrations/029_client_mute.sql
+const clientMuteSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS muted_until TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_clients_muted_until ON clients (muted_until) WHERE muted_until IS NOT NULL;
+`
+
+// clientUnreachableSQL mirrors migrations/030_client_unreachable.sql
+const clientUnreachableSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS unreachable_at TIMESTAMPTZ NULL;
+`
+
+// numberCheckSQL mirrors migrations/031_number_check.sql
+const numberCheckSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS whatsapp_exists BOOLEAN NULL;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS whatsapp_checked_at TIMESTAMPTZ NULL;
+`
+
+// messageRedactionSQL mirrors migrations/032_message_redaction.sql
+const messageRedactionSQL = `
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_original TEXT NULL;
+`
+
+// productsSQL mirrors migrations/033_products.sql
+const productsSQL = `
+CREATE TABLE IF NOT EXISTS products (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  sku TEXT NOT NULL DEFAULT '',
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  category TEXT NOT NULL DEFAULT '',
+  price NUMERIC(12,2) NULL,               -- NULL = sob consulta
+  unit TEXT NOT NULL DEFAULT '',          -- un, kg, m²...
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products ((COALESCE(tenant_id, 0)), sku) WHERE sku <> '';
+CREATE INDEX IF NOT EXISTS idx_products_tenant ON products ((COALESCE(tenant_id, 0)), active);
+`
+
+// intentRulesSQL mirrors migrations/034_intent_rules.sql
+const intentRulesSQL = `
+CREATE TABLE IF NOT EXISTS intent_rules (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  keywords TEXT[] NOT NULL DEFAULT '{}',  -- qualquer uma casa (sem acento/caixa)
+  pattern TEXT NOT NULL DEFAULT '',       -- regex opcional (Go RE2, sem caixa)
+  reply TEXT NOT NULL,                    -- aceita {{nome}} e demais variáveis da resposta
+  priority INT NOT NULL DEFAULT 0,        -- maior vence quando várias casam
+  active BOOLEAN NOT NULL DEFAULT true,
+  hits BIGINT NOT NULL DEFAULT 0,
+  last_hit_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_intent_rules_name ON intent_rules ((COALESCE(tenant_id, 0)), name);
+`
+
+// pendingActionsSQL mirrors migrations/035_pending_actions.sql
+const pendingActionsSQL = `
+CREATE TABLE IF NOT EXISTS pending_actions (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL DEFAULT 'payment',   -- payment | form
+  reference TEXT NOT NULL DEFAULT '',     -- id do pedido/cobrança no sistema externo
+  url TEXT NOT NULL DEFAULT '',
+  held INT NOT NULL DEFAULT 0,            -- mensagens do cliente seguradas na espera
+  expires_at TIMESTAMPTZ NOT NULL,
+  resolved_at TIMESTAMPTZ NULL,
+  resolution TEXT NULL,                   -- paid | completed | failed | cancelled | replaced
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_actions_open ON pending_actions (client_id) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pending_actions_reference ON pending_actions (reference) WHERE reference <> '';
+`
+
+// paymentsSQL mirrors migrations/036_payments.sql
+const paymentsSQL = `
+CREATE TABLE IF NOT EXISTS payments (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,                 -- mercadopago | stripe | pix
+  external_id TEXT NOT NULL,              -- id do pagamento no provedor
+  reference TEXT NOT NULL DEFAULT '',     -- pedido / client_reference_id / txid
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  status TEXT NOT NULL,                   -- paid | pending | failed | refunded
+  amount_cents BIGINT NOT NULL DEFAULT 0,
+  currency TEXT NOT NULL DEFAULT 'BRL',
+  paid_at TIMESTAMPTZ NULL,
+  notified_at TIMESTAMPTZ NULL,           -- cliente avisado (uma vez por pagamento)
+  raw JSONB NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_external ON payments ((COALESCE(tenant_id, 0)), provider, external_id);
+CREATE INDEX IF NOT EXISTS idx_payments_client ON payments (client_id, created_at DESC);
+`
+
+// clientSummarySQL mirrors migrations/037_client_summary.sql
+const clientSummarySQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS summary TEXT NULL;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS summary_message_id BIGINT NULL;   -- última mensagem já resumida
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS summary_updated_at TIMESTAMPTZ NULL;
+`
+
+// deadLettersSQL mirrors migrations/038_dead_letters.sql
+const deadLettersSQL = `
+CREATE TABLE IF NOT EXISTS dead_letters (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL DEFAULT '',         -- cliente afetado ('' em workers/rotas sem cliente)
+  stage TEXT NOT NULL,                    -- process | album | http POST /webhook | worker csat ...
+  payload TEXT NOT NULL DEFAULT '',       -- mensagem combinada / rota, para reprocessar à mão
+  error TEXT NOT NULL,
+  stack TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_created ON dead_letters ((COALESCE(tenant_id, 0)), created_at DESC);
+`
+
+// translationSQL mirrors migrations/039_translation.sql
+const translationSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS language TEXT NULL;            -- idioma detectado (ISO 639-1)
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS language_override TEXT NULL;   -- fixado pelo operador ('pt' = nunca traduzir)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS language TEXT NULL;           -- idioma de content, quando traduzida
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_translated TEXT NULL; -- versão em português de content
+`
+
+// clientAudioOffSQL mirrors migrations/040_client_audio_off.sql
+const clientAudioOffSQL = `
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS audio_replies_off BOOLEAN NOT NULL DEFAULT false;
+`
+
+// dataErasuresSQL mirrors migrations/041_data_erasures.sql
+const dataErasuresSQL = `
+CREATE TABLE IF NOT EXISTS data_erasures (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL,
+  requested_by TEXT NOT NULL,                 -- client | admin
+  status TEXT NOT NULL DEFAULT 'pending',     -- pending | completed | expired | failed
+  code TEXT NOT NULL DEFAULT '',              -- código que o cliente responde para confirmar
+  expires_at TIMESTAMPTZ NULL,
+  exported BOOLEAN NOT NULL DEFAULT false,    -- cópia dos dados enviada antes da exclusão
+  affected BIGINT NOT NULL DEFAULT 0,         -- linhas apagadas
+  detail TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  completed_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_erasures_pending ON data_erasures ((COALESCE(tenant_id, 0)), phone) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_data_erasures_created ON data_erasures ((COALESCE(tenant_id, 0)), created_at DESC);
+`
+
+// botSettingsRunSQL mirrors migrations/042_bot_settings_run.sql
+const botSettingsRunSQL = `
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_temperature DOUBLE PRECISION NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_top_p DOUBLE PRECISION NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_max_prompt_tokens INT NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_max_completion_tokens INT NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_truncation TEXT NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_reasoning_effort TEXT NULL;
+`
+
+// teamNotificationsSQL mirrors migrations/043_team_notifications.sql
+const teamNotificationsSQL = `
+CREATE TABLE IF NOT EXISTS team_notifications (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  event_key TEXT NOT NULL UNIQUE,            -- hash do evento (o mesmo em todas as réplicas)
+  topic TEXT NOT NULL,
+  text TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',    -- pending | sent | failed
+  attempts INT NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  sent_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_notifications_pending ON team_notifications ((COALESCE(tenant_id, 0)), id) WHERE status = 'pending';
+`
+
+// messageStatusSQL mirrors migrations/044_message_status.sql
+const messageStatusSQL = `
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status TEXT NULL;       -- pending | sent | delivered | read | played | failed (NULL = entrada/legado)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status_at TIMESTAMPTZ NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS send_error TEXT NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS send_key TEXT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_send_key ON messages (send_key) WHERE send_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_pending ON messages (created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_messages_status_time ON messages ((COALESCE(tenant_id, 0)), created_at DESC) WHERE status IS NOT NULL;
+`
+
+// clientImagesSQL mirrors migrations/045_client_images.sql
+const clientImagesSQL = `
+CREATE TABLE IF NOT EXISTS client_images (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  ext_id TEXT NULL,                -- messageid do WhatsApp (baixa de novo pela Uazapi)
+  media_url TEXT NOT NULL,         -- URL usada na descrição (pode expirar)
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_images_client ON client_images (client_id, created_at DESC);
+`
+
+// maintenanceWindowsSQL mirrors migrations/046_maintenance_windows.sql
+const maintenanceWindowsSQL = `
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  since TIMESTAMPTZ NULL,
+  until TIMESTAMPTZ NULL,          -- NULL = sem prazo
+  message TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_maintenance_windows_tenant ON maintenance_windows ((COALESCE(tenant_id, 0)));
+
+CREATE TABLE IF NOT EXISTS maintenance_notices (
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL,
+  window_since TIMESTAMPTZ NOT NULL,   -- since da janela em que o aviso saiu
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_maintenance_notices ON maintenance_notices ((COALESCE(tenant_id, 0)), phone, window_since);
+`
+
+// erasureNoticeSQL mirrors migrations/047_erasure_notice.sql
+const erasureNoticeSQL = `
+ALTER TABLE data_erasures ADD COLUMN IF NOT EXISTS notice_status TEXT NULL;   -- pending | sent | failed
+ALTER TABLE data_erasures ADD COLUMN IF NOT EXISTS notice_ext_id TEXT NULL;   -- messageid do aviso na Uazapi
+`
+
+// webhookNoncesSQL mirrors migrations/048_webhook_nonces.sql
+const webhookNoncesSQL = `
+CREATE TABLE IF NOT EXISTS webhook_nonces (
+  nonce TEXT PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,   -- quem recebeu primeiro
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_nonces_expires ON webhook_nonces (expires_at);
+`
+
+// migrations are applied in order on startup. Every statement must be idempotent.
+var migrations = []string{
+	schemaSQL,
+	clientFactsSQL,
+	messageFlagsSQL,
+	outboundIDsSQL,
+	inboundQueueSQL,
+	failuresSQL,
+	ttsCacheSQL,
+	sinkStateSQL,
+	lidMapSQL,
+	purgeAuditSQL,
+	leadsSQL,
+	apiKeysSQL,
+	botSettingsSQL,
+	abuseEventsSQL,
+	reengagementSQL,
+	greetingsSQL,
+	assistantTransfersSQL,
+	openaiUsageSQL,
+	tenantsSQL,
+	forwardedMessagesSQL,
+	csatSQL,
+	experimentsSQL,
+	scheduledJobsSQL,
+	threadFilesSQL,
+	replyLatencySQL,
+	clientNotesSQL,
+	clientCRMSQL,
+	statusPostsSQL,
+	clientMuteSQL,
+	clientUnreachableSQL,
+	numberCheckSQL,
+	messageRedactionSQL,
+	productsSQL,
+	intentRulesSQL,
+	pendingActionsSQL,
+	paymentsSQL,
+	clientSummarySQL,
+	deadLettersSQL,
+	translationSQL,
+	clientAudioOffSQL,
+	dataErasuresSQL,
+	botSettingsRunSQL,
+	teamNotificationsSQL,
+	messageStatusSQL,
+	clientImagesSQL,
+	maintenanceWindowsSQL,
+	erasureNoticeSQL,
+	webhookNoncesSQL,
+}
+
 // AutoMigrate applies the schema on startup.
 func AutoMigrate(ctx context.Context, pool *pgxpool.Pool) error {
-	_, err := pool.Exec(ctx, schemaSQL)
-	return err
+	for _, m := range migrations {
+		if _, err := pool.Exec(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,197 @@
+// internal/digest/digest.go
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/analytics"
+	"github.com/your-org/leandro-agent/internal/clock"
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/scheduler"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+// Job envia diariamente o resumo de atividade aos administradores (WhatsApp e/ou e-mail).
+type Job struct {
+	cfg  config.Config
+	pool *pgxpool.Pool
+	ai   *openai.Client
+	wpp  *uazapi.Client
+
+	sched *scheduler.Scheduler
+	clock clock.Clock
+}
+
+func New(cfg config.Config, pool *pgxpool.Pool, ai *openai.Client, wpp *uazapi.Client) *Job {
+	return &Job{cfg: cfg, pool: pool, ai: ai, wpp: wpp, clock: clock.Real}
+}
+
+// WithScheduler faz o envio diário rodar em uma só réplica.
+func (j *Job) WithScheduler(s *scheduler.Scheduler) *Job {
+	j.sched = s
+	return j
+}
+
+// WithClock troca o relógio do envio diário (testes).
+func (j *Job) WithClock(c clock.Clock) *Job {
+	j.clock = clock.Or(c)
+	return j
+}
+
+// Enabled indica se há algum destinatário configurado.
+func (j *Job) Enabled() bool {
+	return len(j.cfg.DigestWhatsApp) > 0 || len(j.cfg.DigestEmails) > 0
+}
+
+// Start roda o loop diário até o ctx ser cancelado. O envio ocorre em DIGEST_HOUR
+// (fuso BUSINESS_TIMEZONE) e cobre o dia anterior.
+func (j *Job) Start(ctx context.Context) {
+	if !j.Enabled() {
+		return
+	}
+	loc := j.cfg.Location()
+	for {
+		now := j.clock.Now().In(loc)
+		next := time.Date(now.Year(), now.Month(), now.Day(), j.cfg.DigestHour, 0, 0, 0, loc)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.clock.After(next.Sub(now)):
+		}
+		ran, err := j.sched.Once(ctx, "digest", next, func(ctx context.Context) error {
+			return j.Run(ctx, next.AddDate(0, 0, -1))
+		})
+		if err != nil {
+			log.Printf("digest error: %v", err)
+		} else if !ran {
+			log.Printf("digest for %s already sent by another replica", next.AddDate(0, 0, -1).Format("2006-01-02"))
+		}
+	}
+}
+
+// Run calcula e envia o resumo do dia (no fuso de negócio) que contém day.
+func (j *Job) Run(ctx context.Context, day time.Time) error {
+	loc := j.cfg.Location()
+	d := day.In(loc)
+	from := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+
+	st, err := analytics.Compute(ctx, j.pool, from, to, j.cfg.OpenAICostPer1KTokens)
+	if err != nil {
+		return err
+	}
+	intents := j.topIntents(ctx, from, to)
+	text := Format(st, intents)
+
+	var errs []string
+	for _, phone := range j.cfg.DigestWhatsApp {
+		if _, err := j.wpp.SendText(ctx, phone, text); err != nil {
+			errs = append(errs, "whatsapp "+phone+": "+err.Error())
+		}
+	}
+	if len(j.cfg.DigestEmails) > 0 {
+		subject := "Resumo diário " + from.Format("02/01/2006")
+		if err := j.sendEmail(subject, text); err != nil {
+			errs = append(errs, "email: "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("digest send: %s", strings.Join(errs, "; "))
+	}
+	log.Printf("digest sent for %s", from.Format("2006-01-02"))
+	return nil
+}
+
+// topIntents pede ao modelo as principais intenções numa amostra das mensagens do dia.
+// Falhas não impedem o envio do resumo.
+func (j *Job) topIntents(ctx context.Context, from, to time.Time) string {
+	if j.ai == nil {
+		return ""
+	}
+	sample, err := analytics.InboundSample(ctx, j.pool, from, to, 200)
+	if err != nil || len(sample) == 0 {
+		return ""
+	}
+	joined := "- " + strings.Join(sample, "\n- ")
+	if len(joined) > 12000 {
+		joined = joined[:12000]
+	}
+	out, err := j.ai.ChatComplete(ctx,
+		"Você analisa mensagens de clientes de um atendimento por WhatsApp. Liste as 5 principais intenções (ex.: preço, horário, agendamento), "+
+			"uma por linha, no formato '<intenção>: <quantidade aproximada>'. Responda em Português, sem introdução.",
+		joined, 200)
+	if err != nil {
+		log.Printf("digest intents error: %v", err)
+		return ""
+	}
+	return out
+}
+
+// Format gera o texto do resumo (compatível com WhatsApp e e-mail texto puro).
+func Format(st analytics.Stats, intents string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Resumo diário — %s*\n\n", st.From.Format("02/01/2006"))
+	fmt.Fprintf(&b, "Conversas atendidas: %d\n", st.Conversations)
+	fmt.Fprintf(&b, "Novos clientes: %d\n", st.NewClients)
+	fmt.Fprintf(&b, "Mensagens recebidas: %d\n", st.Inbound)
+	if st.EmojiOnly+st.Reactions > 0 {
+		fmt.Fprintf(&b, "  • perguntas/textos: %d; só emoji: %d; reações: %d\n", st.Questions, st.EmojiOnly, st.Reactions)
+	}
+	fmt.Fprintf(&b, "Respostas enviadas: %d\n", st.Outbound)
+	fmt.Fprintf(&b, "Falhas: %d\n", st.Failures)
+	for _, c := range st.FailureStages {
+		fmt.Fprintf(&b, "  • %s: %d\n", c.Label, c.N)
+	}
+	if len(st.InboundTypes) > 0 {
+		b.WriteString("\nTipos de mensagem:\n")
+		for _, c := range st.InboundTypes {
+			fmt.Fprintf(&b, "  • %s: %d\n", c.Label, c.N)
+		}
+	}
+	if len(st.TopEmojis) > 0 {
+		b.WriteString("\nEmojis mais usados:")
+		for _, c := range st.TopEmojis {
+			fmt.Fprintf(&b, " %s %d", c.Label, c.N)
+		}
+		b.WriteString("\n")
+	}
+	if st.CSATAnswered > 0 {
+		fmt.Fprintf(&b, "\nSatisfação (CSAT): %.1f/5 em %d de %d pesquisas\n", st.CSATAverage, st.CSATAnswered, st.CSATSent)
+	}
+	if strings.TrimSpace(intents) != "" {
+		b.WriteString("\nPrincipais intenções:\n")
+		b.WriteString(strings.TrimSpace(intents))
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nCusto estimado: ~%d tokens ≈ US$ %.2f\n", st.EstTokens, st.EstCostUSD)
+	return b.String()
+}
+
+func (j *Job) sendEmail(subject, body string) error {
+	c := j.cfg
+	if c.SMTPHost == "" || c.SMTPFrom == "" {
+		return fmt.Errorf("SMTP_HOST/SMTP_FROM not configured")
+	}
+	addr := fmt.Sprintf("%s:%d", c.SMTPHost, c.SMTPPort)
+	var auth smtp.Auth
+	if c.SMTPUser != "" {
+		auth = smtp.PlainAuth("", c.SMTPUser, c.SMTPPass, c.SMTPHost)
+	}
+	msg := "From: " + c.SMTPFrom + "\r\n" +
+		"To: " + strings.Join(c.DigestEmails, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+	return smtp.SendMail(addr, auth, c.SMTPFrom, c.DigestEmails, []byte(msg))
+}
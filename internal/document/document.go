@@ -0,0 +1,147 @@
+// internal/document/document.go
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formatos reconhecidos.
+const (
+	KindPDF  = "pdf"
+	KindDOCX = "docx"
+	KindXLSX = "xlsx"
+	KindCSV  = "csv"
+	KindTXT  = "txt"
+)
+
+// ErrUnsupported indica um arquivo cujo formato não tem extrator.
+var ErrUnsupported = errors.New("unsupported document format")
+
+// maxEntrySize limita o XML descompactado de DOCX/XLSX (proteção contra zip bomb).
+const maxEntrySize = 32 << 20
+
+// maxTextLen limita o texto devolvido; o resumo usa só o começo de qualquer forma.
+const maxTextLen = 200000
+
+// Detect identifica o formato pelos bytes iniciais e, na falta deles, pelo
+// mimetype ou pela extensão do nome do arquivo.
+func Detect(data []byte, mimetype, filename string) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF")):
+		return KindPDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		if zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+			for _, f := range zr.File {
+				switch f.Name {
+				case "word/document.xml":
+					return KindDOCX
+				case "xl/workbook.xml":
+					return KindXLSX
+				}
+			}
+		}
+		return ""
+	}
+
+	mimetype = strings.ToLower(strings.TrimSpace(strings.SplitN(mimetype, ";", 2)[0]))
+	ext := strings.ToLower(path.Ext(filename))
+	switch {
+	case mimetype == "application/pdf" || ext == ".pdf":
+		return KindPDF
+	case mimetype == "text/csv" || mimetype == "application/csv" || ext == ".csv":
+		return KindCSV
+	case strings.HasPrefix(mimetype, "text/") || ext == ".txt" || ext == ".md":
+		return KindTXT
+	}
+	// sem pista nenhuma: texto puro é tratado como .txt
+	if mimetype == "" || mimetype == "application/octet-stream" {
+		if len(data) > 0 && utf8.Valid(data[:min(len(data), 4096)]) && !bytes.ContainsRune(data[:min(len(data), 4096)], 0) {
+			return KindTXT
+		}
+	}
+	return ""
+}
+
+// Extract devolve o texto do documento (planilhas viram um resumo tabular) e o
+// formato detectado.
+func Extract(ctx context.Context, data []byte, mimetype, filename string) (string, string, error) {
+	kind := Detect(data, mimetype, filename)
+	var (
+		text string
+		err  error
+	)
+	switch kind {
+	case KindPDF:
+		text, err = extractPDF(ctx, data)
+	case KindDOCX:
+		text, err = extractDOCX(data)
+	case KindXLSX:
+		var sheets []sheet
+		if sheets, err = readXLSX(data); err == nil {
+			text = summarizeSheets(sheets)
+		}
+	case KindCSV:
+		var rows [][]string
+		if rows, err = readCSV(data); err == nil {
+			text = summarizeSheets([]sheet{{name: strings.TrimSuffix(path.Base(filename), path.Ext(filename)), rows: rows}})
+		}
+	case KindTXT:
+		text = decodeText(data)
+	default:
+		return "", "", ErrUnsupported
+	}
+	if err != nil {
+		return "", kind, err
+	}
+	text = strings.TrimSpace(text)
+	if len(text) > maxTextLen {
+		text = text[:maxTextLen]
+	}
+	return text, kind, nil
+}
+
+// decodeText aceita UTF-8 (com ou sem BOM) e cai para Latin-1, comum em exportações
+// antigas do Excel/Windows.
+func decodeText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	r := make([]rune, len(data))
+	for i, b := range data {
+		r[i] = rune(b)
+	}
+	return string(r)
+}
+
+// readZipEntry lê um arquivo do pacote OOXML respeitando maxEntrySize.
+func readZipEntry(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, maxEntrySize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > maxEntrySize {
+			return nil, errors.New(name + ": entry too large")
+		}
+		return b, nil
+	}
+	return nil, errEntryNotFound
+}
+
+var errEntryNotFound = errors.New("zip entry not found")
@@ -0,0 +1,97 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// extractDOCX percorre word/document.xml: textos (w:t), tabulações e quebras viram
+// texto puro; parágrafos e linhas de tabela terminam em quebra de linha e células
+// são separadas por " | ". Tabelas aninhadas ficam na mesma linha da célula externa.
+func extractDOCX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	doc, err := readZipEntry(zr, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	inText := false
+	cellDepth := 0     // parágrafos dentro de células não quebram a linha
+	firstCell := false // primeira célula da linha da tabela
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			case "tr":
+				firstCell = true
+			case "tc":
+				if !firstCell {
+					b.WriteString(" | ")
+				}
+				firstCell = false
+				cellDepth++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if cellDepth > 0 {
+					b.WriteByte(' ')
+				} else {
+					b.WriteByte('\n')
+				}
+			case "tc":
+				cellDepth--
+			case "tr":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return collapseBlankLines(b.String()), nil
+}
+
+// collapseBlankLines reduz sequências de linhas vazias a uma só.
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	blank := false
+	for _, l := range lines {
+		l = strings.TrimRight(l, " \t")
+		if l == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,649 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+/*
+Extração de texto de PDF.
+
+O caminho preferido é o pdftotext (poppler), que lida com qualquer PDF. Em
+containers sem poppler, cai num extrator mínimo em Go: lê os content streams
+(sem filtro ou FlateDecode, inclusive dentro de object streams), interpreta os
+operadores de texto (Tj, TJ, ', ", Td, T*...) e decodifica as strings pelo
+ToUnicode da fonte quando há, ou como WinAnsi/Latin-1. Dá conta de PDFs gerados
+por editores e sistemas (notas, boletos, orçamentos); PDFs escaneados continuam
+sem texto.
+*/
+
+// Extratores de PDF.
+const (
+	PDFPdftotext = "pdftotext"
+	PDFBuiltin   = "builtin"
+)
+
+// ErrNoText indica um PDF sem texto extraível (escaneado ou com fontes sem mapa).
+var ErrNoText = errors.New("pdf has no extractable text")
+
+var (
+	pdftotextOnce sync.Once
+	pdftotextOK   bool
+)
+
+// HasPdftotext informa se o binário do pdftotext está disponível.
+func HasPdftotext() bool {
+	pdftotextOnce.Do(func() {
+		_, err := exec.LookPath("pdftotext")
+		pdftotextOK = err == nil
+	})
+	return pdftotextOK
+}
+
+// PDFExtractor devolve o extrator de PDF em uso (para o log de startup).
+func PDFExtractor() string {
+	if HasPdftotext() {
+		return PDFPdftotext
+	}
+	return PDFBuiltin
+}
+
+// extractPDF usa o pdftotext e, sem ele ou se ele falhar, o extrator em Go.
+func extractPDF(ctx context.Context, data []byte) (string, error) {
+	if HasPdftotext() {
+		text, err := openai.ExtractPDFText(ctx, data)
+		if err == nil || ctx.Err() != nil {
+			return text, err
+		}
+		if builtin, berr := extractPDFBuiltin(data); berr == nil {
+			return builtin, nil
+		}
+		return "", err
+	}
+	return extractPDFBuiltin(data)
+}
+
+// pdfObject é um objeto indireto: o dicionário (texto cru) e o stream decodificado.
+type pdfObject struct {
+	dict   string
+	stream []byte
+	hasStm bool
+}
+
+var (
+	objRe       = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	refRe       = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s*(\d+)\s+\d+\s+R`)
+	fontDictRe  = regexp.MustCompile(`/Font\s*<<([^>]*)>>`)
+	fontRefRe   = regexp.MustCompile(`/Font\s+(\d+)\s+\d+\s+R`)
+	toUnicodeRe = regexp.MustCompile(`/ToUnicode\s+(\d+)\s+\d+\s+R`)
+	hexPairRe   = regexp.MustCompile(`<([0-9A-Fa-f]*)>`)
+	codespaceRe = regexp.MustCompile(`begincodespacerange\s*<([0-9A-Fa-f]+)>`)
+	filterRe    = regexp.MustCompile(`/Filter\s*(\[[^\]]*\]|/[A-Za-z0-9]+)`)
+)
+
+// extractPDFBuiltin é o extrator mínimo em Go.
+func extractPDFBuiltin(data []byte) (string, error) {
+	objs := parsePDFObjects(data)
+
+	// fontes: nome do recurso (/F1) -> objeto da fonte -> ToUnicode
+	cmaps := map[int]*cmap{}
+	for num, o := range objs {
+		if !strings.Contains(o.dict, "/Font") {
+			continue
+		}
+		if m := toUnicodeRe.FindStringSubmatch(o.dict); m != nil {
+			ref, _ := strconv.Atoi(m[1])
+			if cm := objs[ref]; cm != nil && cm.hasStm {
+				cmaps[num] = parseCMap(cm.stream)
+			}
+		}
+	}
+	fonts := map[string]*cmap{}
+	addFonts := func(dict string) {
+		for _, m := range refRe.FindAllStringSubmatch(dict, -1) {
+			num, _ := strconv.Atoi(m[2])
+			if _, seen := fonts[m[1]]; !seen && cmaps[num] != nil {
+				fonts[m[1]] = cmaps[num]
+			}
+		}
+	}
+	for _, o := range objs {
+		for _, m := range fontDictRe.FindAllStringSubmatch(o.dict, -1) {
+			addFonts(m[1])
+		}
+		for _, m := range fontRefRe.FindAllStringSubmatch(o.dict, -1) {
+			num, _ := strconv.Atoi(m[1])
+			if fo := objs[num]; fo != nil {
+				addFonts(fo.dict)
+			}
+		}
+	}
+
+	// content streams na ordem dos objetos (em geral, a das páginas)
+	nums := make([]int, 0, len(objs))
+	for num := range objs {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	var b strings.Builder
+	for _, num := range nums {
+		o := objs[num]
+		if !o.hasStm || !isContentStream(o.dict, o.stream) {
+			continue
+		}
+		contentText(&b, o.stream, fonts)
+		b.WriteByte('\n')
+		if b.Len() > maxTextLen {
+			break
+		}
+	}
+	text := tidyPDFText(b.String())
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// parsePDFObjects lê os objetos do arquivo e os de dentro dos object streams.
+func parsePDFObjects(data []byte) map[int]*pdfObject {
+	objs := map[int]*pdfObject{}
+	locs := objRe.FindAllSubmatchIndex(data, -1)
+	for i, loc := range locs {
+		num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		body := data[loc[1]:end]
+		if e := bytes.Index(body, []byte("endobj")); e >= 0 {
+			body = body[:e]
+		}
+		o := &pdfObject{dict: string(body)}
+		if s := streamStart(body); s >= 0 {
+			o.dict = string(body[:s])
+			raw := body[s:]
+			if e := bytes.LastIndex(raw, []byte("endstream")); e >= 0 {
+				raw = raw[:e]
+			}
+			if stm, ok := decodeStream(o.dict, raw); ok {
+				o.stream, o.hasStm = stm, true
+			}
+		}
+		objs[num] = o
+	}
+	// object streams (PDF 1.5+): fontes e dicionários costumam ficar aqui
+	for _, o := range objs {
+		if o.hasStm && strings.Contains(o.dict, "/ObjStm") {
+			for num, sub := range splitObjStm(o.dict, o.stream) {
+				if _, ok := objs[num]; !ok {
+					objs[num] = sub
+				}
+			}
+		}
+	}
+	return objs
+}
+
+// streamStart devolve onde começam os dados do stream do objeto (-1 sem stream).
+func streamStart(body []byte) int {
+	i := bytes.Index(body, []byte("stream"))
+	for i >= 0 {
+		if i < 3 || string(body[i-3:i]) != "end" {
+			j := i + len("stream")
+			if j < len(body) && body[j] == '\r' {
+				j++
+			}
+			if j < len(body) && body[j] == '\n' {
+				j++
+			}
+			return j
+		}
+		next := bytes.Index(body[i+1:], []byte("stream"))
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	return -1
+}
+
+// decodeStream aplica o filtro do stream. Só sem filtro e FlateDecode; o resto
+// (imagens, ASCII85, LZW...) é ignorado.
+func decodeStream(dict string, raw []byte) ([]byte, bool) {
+	if strings.Contains(dict, "/Subtype/Image") || strings.Contains(dict, "/Subtype /Image") {
+		return nil, false
+	}
+	raw = bytes.TrimRight(raw, "\r\n")
+	if !strings.Contains(dict, "/Filter") {
+		return raw, true
+	}
+	m := filterRe.FindStringSubmatch(dict)
+	if m == nil || strings.Join(strings.Fields(strings.Trim(m[1], "[]")), "") != "/FlateDecode" {
+		return nil, false
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxEntrySize))
+	if err != nil && len(out) == 0 {
+		return nil, false
+	}
+	return out, true // streams truncados ainda rendem o que foi lido
+}
+
+// splitObjStm separa os objetos de um object stream (/N pares "número offset", /First).
+func splitObjStm(dict string, stm []byte) map[int]*pdfObject {
+	n := dictInt(dict, "/N")
+	first := dictInt(dict, "/First")
+	if n <= 0 || first <= 0 || first > len(stm) {
+		return nil
+	}
+	head := strings.Fields(string(stm[:first]))
+	out := map[int]*pdfObject{}
+	for i := 0; i+1 < len(head) && i/2 < n; i += 2 {
+		num, err1 := strconv.Atoi(head[i])
+		off, err2 := strconv.Atoi(head[i+1])
+		// offsets vêm do arquivo (upload do cliente): negativos ou fora do stream são ignorados
+		if err1 != nil || err2 != nil || off < 0 || first+off > len(stm) {
+			continue
+		}
+		end := len(stm)
+		if i+3 < len(head) {
+			if next, err := strconv.Atoi(head[i+3]); err == nil && first+next <= len(stm) && next >= off {
+				end = first + next
+			}
+		}
+		out[num] = &pdfObject{dict: string(stm[first+off : end])}
+	}
+	return out
+}
+
+// dictInt lê um inteiro direto do dicionário (ex.: /N 12).
+func dictInt(dict, key string) int {
+	i := strings.Index(dict, key+" ")
+	if i < 0 {
+		return 0
+	}
+	fields := strings.Fields(dict[i+len(key):])
+	if len(fields) == 0 {
+		return 0
+	}
+	v, _ := strconv.Atoi(strings.TrimRight(fields[0], "/>"))
+	return v
+}
+
+// isContentStream descarta streams que não são de página (xref, fontes, cmaps, metadados).
+func isContentStream(dict string, stm []byte) bool {
+	for _, t := range []string{"/XRef", "/ObjStm", "/Metadata", "/FontFile", "/Length1", "/Subtype/Type1C", "/Subtype /Type1C"} {
+		if strings.Contains(dict, t) {
+			return false
+		}
+	}
+	return bytes.Contains(stm, []byte("BT")) && !bytes.Contains(stm, []byte("begincmap"))
+}
+
+// cmap é o mapa ToUnicode de uma fonte: código (de width bytes) -> texto.
+type cmap struct {
+	width int
+	m     map[uint32]string
+}
+
+// parseCMap lê as seções bfchar e bfrange de um CMap ToUnicode.
+func parseCMap(stm []byte) *cmap {
+	cm := &cmap{width: 1, m: map[uint32]string{}}
+	s := string(stm)
+	if m := codespaceRe.FindStringSubmatch(s); m != nil {
+		cm.width = max(1, len(m[1])/2)
+	}
+	for _, sec := range sections(s, "beginbfchar", "endbfchar") {
+		hs := hexPairRe.FindAllStringSubmatch(sec, -1)
+		for i := 0; i+1 < len(hs); i += 2 {
+			cm.m[hexCode(hs[i][1])] = utf16Hex(hs[i+1][1])
+		}
+	}
+	for _, sec := range sections(s, "beginbfrange", "endbfrange") {
+		for _, line := range strings.Split(sec, "\n") {
+			hs := hexPairRe.FindAllStringSubmatch(line, -1)
+			if len(hs) < 3 {
+				continue
+			}
+			lo, hi := hexCode(hs[0][1]), hexCode(hs[1][1])
+			if hi < lo || hi-lo > 0xFFFF {
+				continue
+			}
+			if strings.Contains(line, "[") {
+				// <lo> <hi> [<d1> <d2> ...]
+				for i, d := range hs[2:] {
+					if lo+uint32(i) > hi {
+						break
+					}
+					cm.m[lo+uint32(i)] = utf16Hex(d[1])
+				}
+				continue
+			}
+			dst := []rune(utf16Hex(hs[2][1]))
+			if len(dst) == 0 {
+				continue
+			}
+			for c := lo; c <= hi; c++ {
+				r := append([]rune{}, dst...)
+				r[len(r)-1] += rune(c - lo)
+				cm.m[c] = string(r)
+			}
+		}
+	}
+	return cm
+}
+
+// sections devolve os trechos entre begin e end.
+func sections(s, begin, end string) []string {
+	var out []string
+	for {
+		i := strings.Index(s, begin)
+		if i < 0 {
+			return out
+		}
+		s = s[i+len(begin):]
+		j := strings.Index(s, end)
+		if j < 0 {
+			return append(out, s)
+		}
+		out = append(out, s[:j])
+		s = s[j+len(end):]
+	}
+}
+
+func hexCode(h string) uint32 {
+	v, _ := strconv.ParseUint(h, 16, 32)
+	return uint32(v)
+}
+
+// utf16Hex decodifica o destino de um CMap (UTF-16BE em hexa).
+func utf16Hex(h string) string {
+	b := hexBytes(h)
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+func hexBytes(h string) []byte {
+	h = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, h)
+	if len(h)%2 == 1 {
+		h += "0"
+	}
+	out := make([]byte, 0, len(h)/2)
+	for i := 0; i+1 < len(h); i += 2 {
+		v, err := strconv.ParseUint(h[i:i+2], 16, 8)
+		if err != nil {
+			break
+		}
+		out = append(out, byte(v))
+	}
+	return out
+}
+
+// decode converte os bytes de uma string do PDF em texto.
+func (cm *cmap) decode(b []byte) string {
+	var sb strings.Builder
+	if cm == nil {
+		// WinAnsi/PDFDocEncoding: para acentos do português, equivale ao Latin-1
+		for _, c := range b {
+			sb.WriteRune(rune(c))
+		}
+		return sb.String()
+	}
+	for i := 0; i+cm.width <= len(b); i += cm.width {
+		var code uint32
+		for _, c := range b[i : i+cm.width] {
+			code = code<<8 | uint32(c)
+		}
+		if s, ok := cm.m[code]; ok {
+			sb.WriteString(s)
+		} else if cm.width == 1 {
+			sb.WriteRune(rune(code))
+		}
+	}
+	return sb.String()
+}
+
+// contentText interpreta os operadores de texto de um content stream.
+func contentText(b *strings.Builder, stm []byte, fonts map[string]*cmap) {
+	var (
+		font     *cmap
+		operands []any // []byte (string), float64, string (nome) ou []any (array)
+		arr      []any
+		inArray  bool
+		lastY    float64
+	)
+	push := func(v any) {
+		if inArray {
+			arr = append(arr, v)
+		} else {
+			operands = append(operands, v)
+		}
+	}
+	num := func(i int) float64 {
+		if i < len(operands) {
+			if f, ok := operands[i].(float64); ok {
+				return f
+			}
+		}
+		return 0
+	}
+	show := func(v any) {
+		if s, ok := v.([]byte); ok {
+			b.WriteString(font.decode(s))
+		}
+	}
+
+	p := 0
+	for p < len(stm) {
+		c := stm[p]
+		switch {
+		case isPDFSpace(c):
+			p++
+		case c == '%':
+			for p < len(stm) && stm[p] != '\n' && stm[p] != '\r' {
+				p++
+			}
+		case c == '(':
+			s, n := literalString(stm[p:])
+			push(s)
+			p += n
+		case c == '<' && p+1 < len(stm) && stm[p+1] == '<', c == '>' && p+1 < len(stm) && stm[p+1] == '>':
+			p += 2
+		case c == '<':
+			e := bytes.IndexByte(stm[p:], '>')
+			if e < 0 {
+				return
+			}
+			push(hexBytes(string(stm[p+1 : p+e])))
+			p += e + 1
+		case c == '[':
+			inArray, arr = true, nil
+			p++
+		case c == ']':
+			inArray = false
+			operands = append(operands, arr)
+			p++
+		case c == '/':
+			e := p + 1
+			for e < len(stm) && !isPDFSpace(stm[e]) && !isPDFDelim(stm[e]) {
+				e++
+			}
+			push(string(stm[p+1 : e]))
+			p = e
+		case c == '{', c == '}', c == ')', c == '>':
+			p++
+		default:
+			e := p
+			for e < len(stm) && !isPDFSpace(stm[e]) && !isPDFDelim(stm[e]) {
+				e++
+			}
+			tok := string(stm[p:e])
+			p = e
+			if f, err := strconv.ParseFloat(tok, 64); err == nil {
+				push(f)
+				continue
+			}
+			switch tok {
+			case "Tf":
+				if len(operands) > 0 {
+					if name, ok := operands[0].(string); ok {
+						font = fonts[name]
+					}
+				}
+			case "Tj":
+				if len(operands) > 0 {
+					show(operands[len(operands)-1])
+				}
+			case "'", "\"":
+				b.WriteByte('\n')
+				if len(operands) > 0 {
+					show(operands[len(operands)-1])
+				}
+			case "TJ":
+				if len(operands) > 0 {
+					items, _ := operands[len(operands)-1].([]any)
+					for _, it := range items {
+						if f, ok := it.(float64); ok && f < -200 {
+							b.WriteByte(' ') // recuo grande entre glifos = espaço
+						}
+						show(it)
+					}
+				}
+			case "Td", "TD":
+				if num(1) != 0 {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(' ')
+				}
+			case "Tm":
+				if y := num(5); y != lastY {
+					b.WriteByte('\n')
+					lastY = y
+				} else {
+					b.WriteByte(' ')
+				}
+			case "T*", "ET":
+				b.WriteByte('\n')
+			case "ID":
+				// imagem inline: pula os dados binários até EI
+				if e := bytes.Index(stm[p:], []byte("EI")); e >= 0 {
+					p += e + 2
+				} else {
+					p = len(stm)
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+// literalString lê "(...)" com parênteses aninhados e escapes. Devolve os bytes e
+// quanto foi consumido.
+func literalString(s []byte) ([]byte, int) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+			out = append(out, c)
+		case '\\':
+			i++
+			if i >= len(s) {
+				return out, i
+			}
+			switch e := s[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r':
+				if i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v, n := 0, 0
+					for n < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7' {
+						v = v*8 + int(s[i]-'0')
+						i++
+						n++
+					}
+					i--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, len(s)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// tidyPDFText remove caracteres de controle, espaços repetidos e linhas vazias.
+func tidyPDFText(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return ' '
+			}
+			if unicode.IsControl(r) || r == unicode.ReplacementChar {
+				return -1
+			}
+			return r
+		}, line)
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,236 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// pdfFile monta um PDF mínimo com os objetos dados (numerados a partir de 1).
+// O extrator não usa a xref, então ela fica de fora.
+func pdfFile(objs ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	for i, o := range objs {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+// stream monta um objeto com stream sem filtro.
+func stream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// flateStream monta um objeto com stream FlateDecode.
+func flateStream(dict string, data []byte) string {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+	return fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", dict, z.Len(), z.String())
+}
+
+const page = "<< /Type /Page /Resources << /Font << /F1 3 0 R >> >> /Contents 2 0 R >>"
+
+func TestExtractPDFBuiltin(t *testing.T) {
+	toUnicode := "/CIDInit /ProcSet findresource begin\nbegincmap\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n" +
+		"2 beginbfchar\n<0001> <004F>\n<0002> <0069>\nendbfchar\n" +
+		"1 beginbfrange\n<0010> <0012> <0061>\nendbfrange\n" +
+		"endcmap\nend"
+
+	tests := []struct {
+		name string
+		pdf  []byte
+		want string
+	}{
+		{
+			name: "texto simples",
+			pdf: pdfFile(page,
+				stream("", "BT /F1 12 Tf 72 720 Td (Orcamento 2024) Tj ET"),
+				"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"),
+			want: "Orcamento 2024",
+		},
+		{
+			name: "acentos latin-1 em octal",
+			pdf: pdfFile(page,
+				stream("", `BT /F1 12 Tf (Or\347amento v\341lido) Tj ET`),
+				"<< /Type /Font /Subtype /Type1 >>"),
+			want: "Orçamento válido",
+		},
+		{
+			name: "TJ com recuo entre palavras",
+			pdf: pdfFile(page,
+				stream("", "BT /F1 12 Tf [(Total)-300(R$)-250(10,00)] TJ ET"),
+				"<< /Type /Font /Subtype /Type1 >>"),
+			want: "Total R$ 10,00",
+		},
+		{
+			name: "linhas por Td e T*",
+			pdf: pdfFile(page,
+				stream("", "BT /F1 12 Tf (Linha 1) Tj 0 -14 Td (Linha 2) Tj T* (Linha 3) Tj ET"),
+				"<< /Type /Font /Subtype /Type1 >>"),
+			want: "Linha 1\nLinha 2\nLinha 3",
+		},
+		{
+			name: "stream FlateDecode",
+			pdf: pdfFile(page,
+				flateStream("", []byte("BT /F1 12 Tf (Boleto pago) Tj ET")),
+				"<< /Type /Font /Subtype /Type1 >>"),
+			want: "Boleto pago",
+		},
+		{
+			name: "ToUnicode de dois bytes",
+			pdf: pdfFile(page,
+				stream("", "BT /F1 12 Tf <000100020010001100120002> Tj ET"),
+				"<< /Type /Font /Subtype /Type0 /ToUnicode 4 0 R >>",
+				stream("", toUnicode)),
+			want: "Oiabci",
+		},
+		{
+			name: "fonte dentro de object stream",
+			// /F1 aponta para o objeto 5, que só existe dentro do object stream 3
+			pdf: pdfFile("<< /Type /Page /Resources << /Font << /F1 5 0 R >> >> /Contents 2 0 R >>",
+				stream("", "BT /F1 12 Tf <00010002> Tj ET"),
+				flateStream("/Type /ObjStm /N 1 /First 4", []byte("5 0 << /Type /Font /Subtype /Type0 /ToUnicode 4 0 R >>")),
+				stream("", toUnicode)),
+			want: "Oi",
+		},
+		{
+			name: "imagem inline ignorada",
+			pdf: pdfFile(page,
+				stream("", "BT /F1 12 Tf (Antes) Tj ET BI /W 1 /H 1 ID \x00\xffBT(lixo)Tj EI BT (Depois) Tj ET"),
+				"<< /Type /Font /Subtype /Type1 >>"),
+			want: "Antes\nDepois",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractPDFBuiltin(tt.pdf)
+			if err != nil {
+				t.Fatalf("extractPDFBuiltin() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("extractPDFBuiltin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPDFBuiltinNoText(t *testing.T) {
+	scanned := pdfFile(page,
+		stream("", "q 612 0 0 792 0 0 cm /Im1 Do Q"),
+		"<< /Type /XObject /Subtype /Image /Width 1 /Height 1 >>")
+	if _, err := extractPDFBuiltin(scanned); !errors.Is(err, ErrNoText) {
+		t.Fatalf("extractPDFBuiltin() error = %v, want ErrNoText", err)
+	}
+}
+
+func TestSplitObjStm(t *testing.T) {
+	tests := []struct {
+		name string
+		dict string
+		stm  string
+		want map[int]string
+	}{
+		{
+			name: "dois objetos",
+			dict: "/Type /ObjStm /N 2 /First 9",
+			stm:  "7 0 8 10 << /A 1 >>  << /B 2 >>",
+			want: map[int]string{7: "<< /A 1 >>", 8: "  << /B 2 >>"},
+		},
+		{
+			name: "offset negativo",
+			dict: "/Type /ObjStm /N 1 /First 6",
+			stm:  "5 -20 << /A 1 >>",
+			want: map[int]string{},
+		},
+		{
+			name: "offset além do stream",
+			dict: "/Type /ObjStm /N 1 /First 6",
+			stm:  "5 900 << /A 1 >>",
+			want: map[int]string{},
+		},
+		{
+			name: "próximo offset antes do atual",
+			dict: "/Type /ObjStm /N 2 /First 8",
+			stm:  "1 5 2 0 << /A 1 >>",
+			want: map[int]string{1: " 1 >>", 2: "<< /A 1 >>"},
+		},
+		{
+			name: "First fora do stream",
+			dict: "/Type /ObjStm /N 1 /First 99",
+			stm:  "1 0 << >>",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitObjStm(tt.dict, []byte(tt.stm))
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("splitObjStm() = %v, want nil", got)
+				}
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitObjStm() returned %d objects, want %d", len(got), len(tt.want))
+			}
+			for num, dict := range tt.want {
+				if o := got[num]; o == nil || o.dict != dict {
+					t.Errorf("object %d = %+v, want dict %q", num, o, dict)
+				}
+			}
+		})
+	}
+}
+
+func TestLiteralString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		n    int
+	}{
+		{`(abc) Tj`, "abc", 5},
+		{`(a(b)c)`, "a(b)c", 7},
+		{`(a\)b)`, "a)b", 6},
+		{`(l1\nl2)`, "l1\nl2", 8},
+		{`(\101\102)`, "AB", 10},
+		{"(quebra\\\ncontinua)", "quebracontinua", 18},
+		{`(sem fim`, "sem fim", 8},
+	}
+	for _, tt := range tests {
+		got, n := literalString([]byte(tt.in))
+		if string(got) != tt.want || n != tt.n {
+			t.Errorf("literalString(%q) = %q, %d; want %q, %d", tt.in, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestTidyPDFText(t *testing.T) {
+	in := "  Nota\tfiscal \x01\n\n\n  nº 123  \n"
+	if got, want := tidyPDFText(in), "Nota fiscal\nnº 123"; got != want {
+		t.Errorf("tidyPDFText() = %q, want %q", got, want)
+	}
+}
+
+// FuzzExtractPDFBuiltin garante que PDFs malformados (uploads de clientes) não
+// derrubam o extrator.
+func FuzzExtractPDFBuiltin(f *testing.F) {
+	f.Add(pdfFile(page, stream("", "BT /F1 12 Tf (Oi) Tj ET"), "<< /Type /Font >>"))
+	f.Add(pdfFile(page, flateStream("/Type /ObjStm /N 1 /First 6", []byte("5 -20 << /A 1 >>"))))
+	f.Add(pdfFile(page, stream("", "BT [(a)-300<00>] TJ ET"), "<< /ToUnicode 4 0 R >>",
+		stream("", "begincodespacerange <0000> <FFFF> beginbfrange <0000> <FFFF> <0041> endbfrange")))
+	f.Add([]byte("1 0 obj << /Filter /FlateDecode >> stream\nxx"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		text, err := extractPDFBuiltin(data)
+		if err == nil && strings.TrimSpace(text) == "" {
+			t.Fatalf("extractPDFBuiltin() returned empty text without error")
+		}
+	})
+}
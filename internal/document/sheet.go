@@ -0,0 +1,369 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxSheetRows limita as linhas lidas por planilha.
+const maxSheetRows = 20000
+
+// fullSheetRows: planilhas até esse tamanho vão inteiras no texto; acima disso, só
+// as primeiras linhas (sampleRows) junto com as estatísticas.
+const (
+	fullSheetRows = 50
+	sampleRows    = 15
+)
+
+type sheet struct {
+	name string
+	rows [][]string
+}
+
+// readCSV detecta o separador (",", ";" ou tab) pela primeira linha.
+func readCSV(data []byte) ([][]string, error) {
+	text := decodeText(data)
+	first, _, _ := strings.Cut(text, "\n")
+	sep, best := ',', strings.Count(first, ",")
+	for _, c := range []rune{';', '\t'} {
+		if n := strings.Count(first, string(c)); n > best {
+			sep, best = c, n
+		}
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = sep
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	var rows [][]string
+	for len(rows) < maxSheetRows {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, rec)
+	}
+	return rows, nil
+}
+
+// readXLSX lê todas as abas: nomes em xl/workbook.xml, caminhos em
+// xl/_rels/workbook.xml.rels e textos compartilhados em xl/sharedStrings.xml.
+func readXLSX(data []byte) ([]sheet, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if b, err := readZipEntry(zr, "xl/sharedStrings.xml"); err == nil {
+		if shared, err = parseSharedStrings(b); err != nil {
+			return nil, err
+		}
+	} else if err != errEntryNotFound {
+		return nil, err
+	}
+
+	wb, err := readZipEntry(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	targets := map[string]string{}
+	if b, err := readZipEntry(zr, "xl/_rels/workbook.xml.rels"); err == nil {
+		var rels struct {
+			Items []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		if err := xml.Unmarshal(b, &rels); err != nil {
+			return nil, err
+		}
+		for _, r := range rels.Items {
+			t := r.Target
+			if strings.HasPrefix(t, "/") {
+				t = strings.TrimPrefix(t, "/")
+			} else {
+				t = "xl/" + t
+			}
+			targets[r.ID] = t
+		}
+	}
+
+	var book struct {
+		Sheets []struct {
+			Name  string     `xml:"name,attr"`
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(wb, &book); err != nil {
+		return nil, err
+	}
+	var out []sheet
+	for i, s := range book.Sheets {
+		target := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		for _, a := range s.Attrs {
+			if a.Name.Local == "id" && targets[a.Value] != "" {
+				target = targets[a.Value]
+			}
+		}
+		b, err := readZipEntry(zr, target)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", s.Name, err)
+		}
+		rows, err := parseSheet(b, shared)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", s.Name, err)
+		}
+		out = append(out, sheet{name: s.Name, rows: rows})
+	}
+	return out, nil
+}
+
+func parseSharedStrings(b []byte) ([]string, error) {
+	var out []string
+	var cur strings.Builder
+	inText, inPhonetic := false, false
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				inPhonetic = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				out = append(out, cur.String())
+			case "t":
+				inText = false
+			case "rPh":
+				inPhonetic = false
+			}
+		case xml.CharData:
+			if inText && !inPhonetic {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+// parseSheet monta a grade a partir de <row>/<c>, posicionando cada célula pela
+// referência (A1, B1...) para preservar colunas vazias.
+func parseSheet(b []byte, shared []string) ([][]string, error) {
+	var rows [][]string
+	var row []string
+	var cellType, cellRef string
+	var val strings.Builder
+	inValue := false
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = nil
+			case "c":
+				cellType, cellRef = "", ""
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "t":
+						cellType = a.Value
+					case "r":
+						cellRef = a.Value
+					}
+				}
+				val.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := val.String()
+				if cellType == "s" {
+					if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(shared) {
+						v = shared[i]
+					}
+				} else if cellType == "b" {
+					v = map[string]string{"0": "FALSO", "1": "VERDADEIRO"}[v]
+				}
+				col := columnIndex(cellRef)
+				if col < 0 {
+					col = len(row)
+				}
+				for len(row) <= col {
+					row = append(row, "")
+				}
+				row[col] = strings.TrimSpace(v)
+			case "row":
+				rows = append(rows, row)
+				if len(rows) >= maxSheetRows {
+					return rows, nil
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				val.Write(t)
+			}
+		}
+	}
+}
+
+// columnIndex converte "C12" em 2. Devolve -1 sem referência.
+func columnIndex(ref string) int {
+	n := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		n = n*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 || n > 16384 {
+		return -1
+	}
+	return n - 1
+}
+
+// summarizeSheets descreve cada aba: tamanho, colunas, estatísticas das colunas
+// numéricas e as linhas (todas, se forem poucas).
+func summarizeSheets(sheets []sheet) string {
+	var b strings.Builder
+	for _, s := range sheets {
+		rows := trimEmptyRows(s.rows)
+		if len(rows) == 0 {
+			continue
+		}
+		header, body := rows[0], rows[1:]
+		cols := 0
+		for _, r := range rows {
+			cols = max(cols, len(r))
+		}
+		name := s.name
+		if name == "" {
+			name = "dados"
+		}
+		fmt.Fprintf(&b, "Planilha %q: %d linhas x %d colunas\n", name, len(body), cols)
+		fmt.Fprintf(&b, "Colunas: %s\n", strings.Join(header, ", "))
+
+		for c := 0; c < cols; c++ {
+			if st, ok := columnStats(body, c); ok {
+				label := fmt.Sprintf("coluna %d", c+1)
+				if c < len(header) && header[c] != "" {
+					label = header[c]
+				}
+				fmt.Fprintf(&b, "- %s: soma %s, média %s, mín %s, máx %s\n",
+					label, formatNumber(st.sum), formatNumber(st.sum/float64(st.n)), formatNumber(st.min), formatNumber(st.max))
+			}
+		}
+
+		show := body
+		if len(body) > fullSheetRows {
+			show = body[:sampleRows]
+			fmt.Fprintf(&b, "Primeiras %d linhas:\n", sampleRows)
+		} else {
+			b.WriteString("Linhas:\n")
+		}
+		for _, r := range show {
+			b.WriteString(strings.Join(r, " | "))
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func trimEmptyRows(rows [][]string) [][]string {
+	out := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		if strings.TrimSpace(strings.Join(r, "")) != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+type stats struct {
+	n             int
+	sum, min, max float64
+}
+
+// columnStats considera a coluna numérica quando ao menos 80% dos valores
+// preenchidos são números.
+func columnStats(rows [][]string, col int) (stats, bool) {
+	var st stats
+	filled := 0
+	for _, r := range rows {
+		if col >= len(r) || r[col] == "" {
+			continue
+		}
+		filled++
+		v, ok := parseNumber(r[col])
+		if !ok {
+			continue
+		}
+		if st.n == 0 || v < st.min {
+			st.min = v
+		}
+		if st.n == 0 || v > st.max {
+			st.max = v
+		}
+		st.sum += v
+		st.n++
+	}
+	return st, st.n > 0 && st.n*5 >= filled*4
+}
+
+// parseNumber aceita "1234.5", "1.234,50", "R$ 10" e "15%".
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "R$"))
+	s = strings.TrimSuffix(s, "%")
+	s = strings.ReplaceAll(s, " ", "")
+	if s == "" {
+		return 0, false
+	}
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0 && comma > dot:
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	case dot >= 0 && comma >= 0:
+		s = strings.ReplaceAll(s, ",", "")
+	case comma >= 0:
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
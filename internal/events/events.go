@@ -0,0 +1,129 @@
+// internal/events/events.go
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Tópicos publicados pelo pipeline.
+const (
+	MessageReceived         = "message.received"         // mensagem do cliente registrada
+	ReplySent               = "reply.sent"               // mensagem enviada ao cliente
+	RunFailed               = "run.failed"               // falha em alguma etapa (Stage/Error)
+	HandoffRequested        = "handoff.requested"        // cliente precisa de atendimento humano
+	CallReceived            = "call.received"            // ligação recebida
+	ConversationTransferred = "conversation.transferred" // conversa mudou de assistente
+	BudgetAlert             = "budget.alert"             // limiar de orçamento atingido
+	NoteAdded               = "note.added"               // nota interna do assistente (não enviada ao cliente)
+	SLOAlert                = "slo.alert"                // SLO de latência consumindo o orçamento de erro rápido demais
+	PaymentReceived         = "payment.received"         // pagamento confirmado pelo provedor (webhook de pagamento)
+	PanicRecovered          = "panic.recovered"          // panic recuperado (dead_letters)
+	MessageStatus           = "message.status"           // confirmação de envio/entrega/leitura (ExtID, Type = status)
+	LeadCreated             = "lead.created"             // lead/pedido registrado pelo assistente (Content = título)
+
+	// All assina todos os tópicos.
+	All = "*"
+)
+
+// Event é o envelope publicado no barramento. Campos não usados pelo tópico ficam vazios.
+type Event struct {
+	Topic     string    `json:"topic"`
+	Tenant    int64     `json:"tenant_id,omitempty"` // 0 = tenant padrão
+	Phone     string    `json:"phone,omitempty"`
+	ClientID  int64     `json:"client_id,omitempty"`
+	Role      string    `json:"role,omitempty"` // user | assistant | system
+	Type      string    `json:"type,omitempty"` // text | audio | image ...
+	Content   string    `json:"content,omitempty"`
+	ExtID     string    `json:"ext_id,omitempty"`
+	Stage     string    `json:"stage,omitempty"`      // run.failed
+	Error     string    `json:"error,omitempty"`      // run.failed
+	RequestID string    `json:"request_id,omitempty"` // X-Request-ID da interação
+	At        time.Time `json:"at"`
+}
+
+// Handler recebe os eventos de uma assinatura.
+type Handler func(ctx context.Context, ev Event)
+
+// Bus desacopla quem produz eventos de quem reage a eles (feed, alertas, analytics).
+type Bus interface {
+	Publish(ctx context.Context, ev Event)
+	Subscribe(topic string, fn Handler) (cancel func())
+}
+
+type subscription struct {
+	topic string
+	fn    Handler
+	ch    chan Event
+	done  chan struct{}
+}
+
+// Local é o barramento em processo. Cada assinante tem fila e goroutine próprias:
+// Publish nunca bloqueia e um assinante lento só perde os próprios eventos.
+type Local struct {
+	mu   sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+func NewLocal() *Local {
+	return &Local{subs: make(map[*subscription]struct{})}
+}
+
+func (b *Local) Subscribe(topic string, fn Handler) func() {
+	s := &subscription{topic: topic, fn: fn, ch: make(chan Event, 256), done: make(chan struct{})}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	go func() {
+		for {
+			select {
+			case ev := <-s.ch:
+				s.deliver(ev)
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.mu.Unlock()
+			close(s.done)
+		})
+	}
+}
+
+func (s *subscription) deliver(ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("event handler panic (%s): %v", ev.Topic, r)
+		}
+	}()
+	s.fn(context.Background(), ev)
+}
+
+func (b *Local) Publish(_ context.Context, ev Event) {
+	if b == nil {
+		return
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if s.topic != All && s.topic != ev.Topic {
+			continue
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			log.Printf("event bus: slow subscriber dropped %s", ev.Topic)
+		}
+	}
+}
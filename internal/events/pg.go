@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel é o canal do LISTEN/NOTIFY.
+const Channel = "leandro_events"
+
+// maxNotifyPayload fica abaixo do limite de 8000 bytes do NOTIFY.
+const maxNotifyPayload = 7500
+
+// Postgres distribui os eventos via NOTIFY, para que todas as réplicas (inclusive a
+// que publicou) entreguem aos seus assinantes locais. Se o NOTIFY falhar, o evento
+// é entregue só localmente.
+type Postgres struct {
+	pool  *pgxpool.Pool
+	local *Local
+}
+
+// NewPostgres inicia o LISTEN em uma conexão dedicada (reconecta em caso de erro).
+func NewPostgres(ctx context.Context, pool *pgxpool.Pool) *Postgres {
+	b := &Postgres{pool: pool, local: NewLocal()}
+	go b.listen(ctx)
+	return b
+}
+
+func (b *Postgres) Subscribe(topic string, fn Handler) func() {
+	return b.local.Subscribe(topic, fn)
+}
+
+func (b *Postgres) Publish(ctx context.Context, ev Event) {
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	payload, err := json.Marshal(ev)
+	if err == nil && len(payload) > maxNotifyPayload {
+		// conteúdo longo: o resumo basta para os assinantes
+		ev.Content = truncate(ev.Content, len(ev.Content)-(len(payload)-maxNotifyPayload)-16)
+		payload, err = json.Marshal(ev)
+	}
+	if err == nil {
+		_, err = b.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, Channel, string(payload))
+	}
+	if err != nil {
+		log.Printf("event bus notify error (delivering locally): %v", err)
+		b.local.Publish(ctx, ev)
+	}
+}
+
+func (b *Postgres) listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := b.listenOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("event bus listen error: %v", err)
+			time.Sleep(2 * time.Second)
+		}
+	}
+}
+
+func (b *Postgres) listenOnce(ctx context.Context) error {
+	pc, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// a conexão fica presa no LISTEN: sai do pool e é fechada ao final
+	conn := pc.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			log.Printf("event bus invalid payload: %v", err)
+			continue
+		}
+		b.local.Publish(ctx, ev)
+	}
+}
+
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	// não corta no meio de um caractere UTF-8
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,68 @@
+// internal/experiment/experiment.go
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Experimentos A/B (canary) de assistentes e instruções.
+
+Cada experimento divide os clientes entre variantes por peso (ex.: 90/10). O bucket
+é sorteado de forma determinística pelo hash (experimento, cliente) e gravado na
+primeira run, então o cliente fica na mesma variante mesmo se os pesos mudarem.
+*/
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,40}$`)
+
+// Validate confere nome, variantes (nomes únicos) e pesos positivos.
+func Validate(e models.Experiment) error {
+	if !nameRe.MatchString(e.Name) {
+		return errors.New("invalid experiment name")
+	}
+	if len(e.Variants) < 2 {
+		return errors.New("an experiment needs at least two variants")
+	}
+	seen := map[string]bool{}
+	for _, v := range e.Variants {
+		if !nameRe.MatchString(v.Name) {
+			return fmt.Errorf("invalid variant name %q", v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variant %q", v.Name)
+		}
+		seen[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("variant %q: weight must be positive", v.Name)
+		}
+	}
+	return nil
+}
+
+// Pick escolhe a variante do cliente proporcionalmente aos pesos. O mesmo
+// (experimento, cliente) cai sempre na mesma variante.
+func Pick(e models.Experiment, clientID int64) string {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + ":" + strconv.FormatInt(clientID, 10)))
+	n := int(h.Sum32() % uint32(total))
+	for _, v := range e.Variants {
+		if n < v.Weight {
+			return v.Name
+		}
+		n -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1].Name
+}
@@ -0,0 +1,143 @@
+// internal/feed/feed.go
+package feed
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event representa uma mensagem (entrada ou saída) publicada no feed ao vivo.
+type Event struct {
+	Tenant    int64     `json:"tenant_id,omitempty"`
+	Phone     string    `json:"phone"`
+	Direction string    `json:"direction"` // "inbound" | "outbound"
+	Role      string    `json:"role"`      // "user" | "assistant" | "operator" | "system"
+	Type      string    `json:"type"`      // "text" | "audio" | "image" | "document"
+	Content   string    `json:"content"`
+	ExtID     string    `json:"ext_id,omitempty"`
+	Tags      []string  `json:"tags,omitempty"` // tags do cliente no momento da publicação
+	At        time.Time `json:"at"`
+}
+
+// Filter restringe os eventos entregues a um assinante. Campos vazios não filtram.
+// Tags combina com eventos de clientes que tenham ao menos uma das tags.
+type Filter struct {
+	Phones  map[string]bool
+	Tenants map[int64]bool
+	Tags    map[string]bool
+}
+
+// ParsePhones monta um Filter a partir de uma lista separada por vírgulas.
+func ParsePhones(list string) Filter {
+	return Filter{Phones: parseList(list, strings.TrimSpace)}
+}
+
+// ParseTags preenche f.Tags a partir de uma lista separada por vírgulas, com
+// cada tag normalizada por norm (a mesma regra usada ao gravar as tags).
+func (f Filter) ParseTags(list string, norm func(string) string) Filter {
+	f.Tags = parseList(list, norm)
+	return f
+}
+
+func parseList(list string, norm func(string) string) map[string]bool {
+	var m map[string]bool
+	for _, v := range strings.Split(list, ",") {
+		v = norm(v)
+		if v == "" {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]bool)
+		}
+		m[v] = true
+	}
+	return m
+}
+
+func (f Filter) match(ev Event) bool {
+	if len(f.Phones) > 0 && !f.Phones[ev.Phone] {
+		return false
+	}
+	if len(f.Tenants) > 0 && !f.Tenants[ev.Tenant] {
+		return false
+	}
+	if len(f.Tags) > 0 && !slices.ContainsFunc(ev.Tags, func(t string) bool { return f.Tags[t] }) {
+		return false
+	}
+	return true
+}
+
+type subscriber struct {
+	ch     chan Event
+	filter Filter
+}
+
+// Hub distribui eventos para todos os assinantes conectados.
+// Publish nunca bloqueia: assinantes lentos perdem eventos em vez de travar o pipeline.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*subscriber]struct{})}
+}
+
+// Subscribe registra um assinante e retorna o canal de eventos e a função de cancelamento.
+func (h *Hub) Subscribe(f Filter) (<-chan Event, func()) {
+	s := &subscriber{ch: make(chan Event, 64), filter: f}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, s)
+			h.mu.Unlock()
+			close(s.ch)
+		})
+	}
+	return s.ch, cancel
+}
+
+// WantsTags informa se algum assinante filtra por tag: só então vale resolver as
+// tags do cliente antes de Publish.
+func (h *Hub) WantsTags() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if len(s.filter.Tags) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish envia o evento para os assinantes cujo filtro combina.
+func (h *Hub) Publish(ev Event) {
+	if h == nil {
+		return
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if !s.filter.match(ev) {
+			continue
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			// assinante lento: descarta
+		}
+	}
+}
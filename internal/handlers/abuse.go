@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/abuse"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// loadCooldowns restaura os cooldowns ainda ativos após um restart.
+func (h *WebhookHandler) loadCooldowns(ctx context.Context) {
+	active, err := models.ActiveCooldowns(ctx, h.pool)
+	if err != nil {
+		log.Printf("abuse cooldowns load error: %v", err)
+		return
+	}
+	for phone, until := range active {
+		h.abuse.StartCooldown(phone, until)
+	}
+}
+
+// screenAbuse decide se a mensagem deve ficar fora do processamento pela IA:
+// remetente em cooldown, heurísticas (frequência, repetição, só links) ou, se
+// ABUSE_MODEL_CHECK, moderação da OpenAI. A mensagem já foi persistida antes.
+func (h *WebhookHandler) screenAbuse(ctx context.Context, phone, text, kind string) bool {
+	if !h.cfg.AbuseEnabled {
+		return false
+	}
+	now := time.Now()
+	if _, ok := h.abuse.InCooldown(phone, now); ok {
+		return true
+	}
+
+	reason, flagged := h.abuse.Observe(phone, text, now)
+	detail := text
+	if !flagged && h.cfg.AbuseModelCheck && kind == "text" {
+		mctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		m, err := h.ai.Moderate(mctx, text)
+		cancel()
+		if err != nil {
+			log.Printf("moderation error: %v", err) // na dúvida, processa normalmente
+		} else if m.Flagged {
+			reason, flagged = abuse.ReasonModeration, true
+			detail = strings.Join(m.Categories, ",") + ": " + text
+		}
+	}
+	if !flagged {
+		return false
+	}
+
+	until := now.Add(h.abuse.Cooldown())
+	h.abuse.StartCooldown(phone, until)
+	log.Printf("abuse detected for %s (%s): cooldown until %s", phone, reason, until.Format(time.RFC3339))
+	if err := models.RecordAbuseEvent(ctx, h.pool, phone, reason, detail, until); err != nil {
+		log.Printf("db record abuse error: %v", err)
+	}
+	return true
+}
+
+// AbuseHandler expõe os eventos de spam/abuso para revisão:
+//
+//	GET    /admin/abuse?phone=55...&limit=100   (analyst)
+//	DELETE /admin/abuse/{phone}                 encerra o cooldown (operator)
+func (h *WebhookHandler) AbuseHandler() http.Handler {
+	return h.auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		switch r.Method {
+		case http.MethodGet:
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			events, err := models.ListAbuseEvents(ctx, h.pool, r.URL.Query().Get("phone"), limit)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, events)
+
+		case http.MethodDelete:
+			if !hasRole(r, RoleOperator) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			phone := r.PathValue("phone")
+			if phone == "" {
+				http.Error(w, "phone required", http.StatusBadRequest)
+				return
+			}
+			n, err := models.LiftCooldown(ctx, h.pool, phone)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			h.abuse.Lift(phone)
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true, "lifted": n})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
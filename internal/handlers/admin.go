@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// requestToken lê o token da requisição: "Authorization: Bearer <token>",
+// header "X-Admin-Token" ou ?token= (útil p/ WebSocket no browser).
+func requestToken(r *http.Request) string {
+	tok := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if tok == "" {
+		tok = r.Header.Get("X-Admin-Token")
+	}
+	if tok == "" {
+		tok = r.URL.Query().Get("token")
+	}
+	return tok
+}
+
+// requireToken exige o token informado; token vazio bloqueia tudo.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// limitBody limita o corpo das requisições administrativas a 1 MiB.
+func limitBody(r *http.Request) io.Reader {
+	return io.LimitReader(r.Body, 1<<20)
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+/*
+Álbuns: o WhatsApp envia um "albumMessage" (com a quantidade esperada) seguido de
+cada imagem como mensagem própria, ligada ao álbum por messageAssociation. Em vez de
+descrever imagem a imagem (e o buffer fechar no meio do álbum), as imagens são
+reunidas por telefone até chegarem todas ou ALBUM_WAIT_SECONDS sem novidades,
+descritas em paralelo (até ALBUM_PARALLELISM por vez) e entregues ao assistente
+como uma única mensagem.
+*/
+
+type albumItem struct {
+	MessageID string
+	Caption   string
+}
+
+type pendingAlbum struct {
+	id        string
+	clientID  int64
+	expected  int // 0 = desconhecido (álbum sem albumMessage)
+	items     []albumItem
+	ephemeral bool
+	viewOnce  bool
+	timer     *time.Timer
+}
+
+type albumCollector struct {
+	mu      sync.Mutex
+	byPhone map[string]*pendingAlbum
+}
+
+func newAlbumCollector() *albumCollector {
+	return &albumCollector{byPhone: make(map[string]*pendingAlbum)}
+}
+
+// albumExpected lê a quantidade de imagens anunciada num albumMessage.
+func albumExpected(msg incomingMessage) (int, bool) {
+	if !strings.EqualFold(msg.MessageType, "albumMessage") {
+		return 0, false
+	}
+	var c struct {
+		ExpectedImageCount int `json:"expectedImageCount"`
+	}
+	_ = json.Unmarshal(msg.Content, &c)
+	return c.ExpectedImageCount, true
+}
+
+// albumParentID devolve o ID do álbum ao qual a imagem pertence ("" se avulsa).
+func albumParentID(msg incomingMessage) string {
+	type assoc struct {
+		MessageAssociation struct {
+			ParentMessageKey struct {
+				ID string `json:"id"`
+			} `json:"parentMessageKey"`
+		} `json:"messageAssociation"`
+	}
+	var c struct {
+		MessageContextInfo assoc `json:"messageContextInfo"`
+		ContextInfo        assoc `json:"contextInfo"`
+	}
+	if err := json.Unmarshal(msg.Content, &c); err != nil {
+		return ""
+	}
+	if id := c.MessageContextInfo.MessageAssociation.ParentMessageKey.ID; id != "" {
+		return id
+	}
+	return c.ContextInfo.MessageAssociation.ParentMessageKey.ID
+}
+
+func imageCaption(msg incomingMessage) string {
+	var c struct {
+		Caption string `json:"caption"`
+	}
+	_ = json.Unmarshal(msg.Content, &c)
+	return strings.TrimSpace(c.Caption)
+}
+
+// collectAlbum retém mensagens de álbum. Retorna true se a mensagem foi consumida
+// (o processamento segue em segundo plano quando o álbum fecha).
+func (h *WebhookHandler) collectAlbum(phone string, clientID int64, msg incomingMessage) bool {
+	isImage := strings.EqualFold(msg.MessageType, "imageMessage") || strings.EqualFold(msg.MessageType, "image")
+	expected, isAlbum := albumExpected(msg)
+	if !isAlbum && !isImage {
+		return false
+	}
+
+	c := h.albums
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cur := c.byPhone[phone]
+
+	if isAlbum {
+		if cur != nil {
+			h.flushAlbumLocked(phone, cur)
+		}
+		c.byPhone[phone] = &pendingAlbum{id: msg.MessageID, clientID: clientID, expected: expected}
+		h.touchAlbumLocked(phone, c.byPhone[phone])
+		return true
+	}
+
+	parent := albumParentID(msg)
+	switch {
+	case cur != nil && (parent == "" || parent == cur.id):
+	case parent != "":
+		if cur != nil {
+			h.flushAlbumLocked(phone, cur)
+		}
+		cur = &pendingAlbum{id: parent, clientID: clientID}
+		c.byPhone[phone] = cur
+	default:
+		return false // imagem avulsa: fluxo normal
+	}
+
+	cur.items = append(cur.items, albumItem{MessageID: msg.MessageID, Caption: imageCaption(msg)})
+	cur.ephemeral = cur.ephemeral || msg.Ephemeral
+	cur.viewOnce = cur.viewOnce || msg.ViewOnce
+	if cur.expected > 0 && len(cur.items) >= cur.expected {
+		h.flushAlbumLocked(phone, cur)
+		return true
+	}
+	h.touchAlbumLocked(phone, cur)
+	return true
+}
+
+// touchAlbumLocked reinicia o prazo do álbum e segura o buffer do telefone para
+// que o texto enviado junto não seja respondido antes das imagens.
+func (h *WebhookHandler) touchAlbumLocked(phone string, a *pendingAlbum) {
+	wait := time.Duration(h.cfg.AlbumWaitSeconds) * time.Second
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	a.timer = time.AfterFunc(wait, func() {
+		h.albums.mu.Lock()
+		defer h.albums.mu.Unlock()
+		if h.albums.byPhone[phone] == a {
+			h.flushAlbumLocked(phone, a)
+		}
+	})
+	h.bufMgr.Extend(phone, wait+time.Duration(h.cfg.BufferTimeoutSeconds)*time.Second,
+		time.Duration(h.cfg.TypingMaxWaitSeconds)*time.Second)
+}
+
+func (h *WebhookHandler) flushAlbumLocked(phone string, a *pendingAlbum) {
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	delete(h.albums.byPhone, phone)
+	if len(a.items) == 0 {
+		return
+	}
+	go h.processAlbum(h.scope(context.Background()), phone, a)
+}
+
+// processAlbum descreve as imagens em paralelo e envia o conjunto como uma mensagem.
+func (h *WebhookHandler) processAlbum(ctx context.Context, phone string, a *pendingAlbum) {
+	defer h.recoverMessage(ctx, phone, "album", "")
+	descs, tags := h.describeAlbum(ctx, a)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Descrição das imagens do álbum (%d):", len(a.items))
+	failed := 0
+	for i, it := range a.items {
+		d := descs[i]
+		if d == "" {
+			failed++
+			d = "(não foi possível descrever a imagem)"
+		}
+		fmt.Fprintf(&b, "\n%s: %s", withImageTag(fmt.Sprintf("Imagem %d", i+1), tags[i]), d)
+		if it.Caption != "" {
+			fmt.Fprintf(&b, " (legenda: %s)", it.Caption)
+		}
+	}
+	if failed == len(a.items) {
+		h.failAndNotify(a.clientID, phone, "album describe", fallbackMedia, fmt.Errorf("all %d images failed", failed))
+		return
+	}
+	text := processor.SanitizeText(removeRefs(b.String()))
+
+	extID := a.id
+	if extID == "" {
+		extID = a.items[0].MessageID
+	}
+	h.saveMessage(ctx, phone, models.Message{
+		ClientID: a.clientID, Role: "user", Type: "image", Content: text, ExtID: &extID,
+		Ephemeral: a.ephemeral, ViewOnce: a.viewOnce,
+	})
+	if _, err := h.dispatchInbound(ctx, phone, text, "image"); err != nil {
+		h.fail(phone, "album dispatch", err)
+	}
+}
+
+// describeAlbum baixa e descreve cada imagem com paralelismo limitado e devolve
+// também as marcas da memória de imagens. Posições com erro ficam vazias (o erro
+// é registrado em failures).
+func (h *WebhookHandler) describeAlbum(ctx context.Context, a *pendingAlbum) (descs, tags []string) {
+	items := a.items
+	limit := h.cfg.AlbumParallelism
+	if limit <= 0 {
+		limit = 1
+	}
+	descs = make([]string, len(items))
+	tags = make([]string, len(items))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, it := range items {
+		wg.Add(1)
+		go func(i int, it albumItem) {
+			defer wg.Done()
+			defer h.recoverWorker(ctx, "album item")
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			_, url, err := h.wpp.DownloadByMessageID(ctx, it.MessageID)
+			if err != nil {
+				log.Printf("album download %s error: %v", it.MessageID, err)
+				return
+			}
+			desc, err := h.ai.VisionDescribe(ctx, url)
+			if err != nil {
+				log.Printf("album vision %s error: %v", it.MessageID, err)
+				return
+			}
+			descs[i] = strings.TrimSpace(desc)
+			if !a.viewOnce {
+				tags[i] = h.rememberImage(ctx, a.clientID, it.MessageID, url, desc)
+			}
+		}(i, it)
+	}
+	wg.Wait()
+	return descs, tags
+}
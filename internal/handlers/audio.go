@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/media"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+// transcribe pré-processa o áudio com ffmpeg (quando habilitado) e envia para transcrição
+// (Whisper local com TRANSCRIBE_BACKEND exec/http, senão OpenAI).
+// Se o ffmpeg falhar, transcreve o original para não perder a mensagem.
+func (h *WebhookHandler) transcribe(ctx context.Context, audio []byte, filename string) (string, error) {
+	if h.cfg.AudioPreprocess {
+		if data, name, err := media.PrepareForTranscription(ctx, h.cfg.FFmpegPath, audio); err != nil {
+			log.Printf("audio preprocess error (using original): %v", err)
+		} else {
+			audio, filename = data, name
+		}
+	}
+	if h.whisper == nil {
+		return h.ai.Transcribe(ctx, audio, filename)
+	}
+	text, err := h.whisper.Transcribe(ctx, audio, filename)
+	if err != nil && h.cfg.TranscribeFallbackOpenAI {
+		log.Printf("local transcription error (falling back to openai): %v", err)
+		return h.ai.Transcribe(ctx, audio, filename)
+	}
+	return text, err
+}
+
+// wordsPerSecond estima a duração do áudio pela transcrição quando o evento não
+// traz "seconds" (fala em português gira em torno de 150 palavras por minuto).
+const wordsPerSecond = 2.5
+
+const audioSummaryPrompt = "Você resume mensagens de voz de clientes no WhatsApp para o atendente. " +
+	"Responda só com JSON no formato {\"resumo\":\"...\",\"trechos\":[\"...\"]}: " +
+	"em \"resumo\", no máximo 5 frases com o que o cliente quer, dados citados (nomes, valores, datas, pedidos) e perguntas feitas; " +
+	"em \"trechos\", até %d frases copiadas literalmente da transcrição que sejam importantes para responder. Em Português."
+
+// summarizeAudio troca a transcrição de um áudio longo (acima de
+// AUDIO_SUMMARY_SECONDS) por "Resumo do áudio (3min): ..." com os trechos
+// principais. Curto, desativado ou se o resumo falhar, devolve a transcrição.
+func (h *WebhookHandler) summarizeAudio(ctx context.Context, content json.RawMessage, transcript string) string {
+	if h.cfg.AudioSummarySeconds <= 0 {
+		return transcript
+	}
+	secs := audioSeconds(content, transcript)
+	if secs < h.cfg.AudioSummarySeconds {
+		return transcript
+	}
+	out, err := h.ai.ChatComplete(ctx, fmt.Sprintf(audioSummaryPrompt, max(h.cfg.AudioSummaryQuotes, 0)), transcript, 600)
+	if err != nil {
+		log.Printf("audio summary error (using full transcript): %v", err)
+		return transcript
+	}
+	summary, quotes := parseAudioSummary(out, transcript, h.cfg.AudioSummaryQuotes)
+	if summary == "" {
+		return transcript
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Resumo do áudio (%s): %s", audioLength(secs), summary)
+	if len(quotes) > 0 {
+		b.WriteString("\nTrechos do áudio:")
+		for _, q := range quotes {
+			fmt.Fprintf(&b, "\n- \"%s\"", q)
+		}
+	}
+	log.Printf("audio of %ds summarized (%d -> %d chars)", secs, len(transcript), b.Len())
+	return processor.SanitizeText(b.String())
+}
+
+// audioSeconds lê a duração do evento ({"seconds":185}) ou a estima pela transcrição.
+func audioSeconds(content json.RawMessage, transcript string) int {
+	var meta struct {
+		Seconds float64 `json:"seconds"`
+	}
+	if json.Unmarshal(content, &meta) == nil && meta.Seconds > 0 {
+		return int(meta.Seconds)
+	}
+	return int(float64(len(strings.Fields(transcript))) / wordsPerSecond)
+}
+
+// audioLength formata a duração como "45s", "3min" ou "2min30s".
+func audioLength(secs int) string {
+	switch {
+	case secs < 60:
+		return fmt.Sprintf("%ds", secs)
+	case secs%60 == 0:
+		return fmt.Sprintf("%dmin", secs/60)
+	default:
+		return fmt.Sprintf("%dmin%02ds", secs/60, secs%60)
+	}
+}
+
+// parseAudioSummary lê a resposta do modelo. Trechos que não aparecem na
+// transcrição (parafraseados ou inventados) são descartados; resposta fora do
+// JSON vira o próprio resumo.
+func parseAudioSummary(out, transcript string, maxQuotes int) (string, []string) {
+	out = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(out), "```json"), "```"))
+	var parsed struct {
+		Summary string   `json:"resumo"`
+		Quotes  []string `json:"trechos"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return out, nil
+	}
+	lower := strings.ToLower(transcript)
+	var quotes []string
+	for _, q := range parsed.Quotes {
+		q = strings.Trim(strings.TrimSpace(q), `"“”`)
+		if q == "" || len(quotes) >= maxQuotes || !strings.Contains(lower, strings.ToLower(strings.TrimRight(q, ".!?…"))) {
+			continue
+		}
+		quotes = append(quotes, q)
+	}
+	return strings.TrimSpace(parsed.Summary), quotes
+}
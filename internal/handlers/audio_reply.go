@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+Resposta em áudio com texto de reserva (AUDIO_TEXT_FALLBACK).
+
+Quando o cliente manda áudio, a resposta sai em voz (TTS). Se a voz não puder ser
+gerada ou o envio do áudio falhar, a resposta já existe em texto: ela sai como
+texto, com AUDIO_FALLBACK_NOTE antes, em vez de o cliente ficar sem nada.
+
+	GET /admin/audio-replies   contadores desde o start (todos os tenants)
+*/
+
+// audioStats separa as falhas de entrega de áudio das demais falhas de envio.
+type audioStats struct {
+	sent            atomic.Int64 // áudios entregues
+	ttsFailed       atomic.Int64 // voz não gerada
+	deliveryFailed  atomic.Int64 // Uazapi recusou o áudio
+	textFallbacks   atomic.Int64 // resposta entregue em texto no lugar do áudio
+	fallbackFailed  atomic.Int64 // nem o texto de reserva saiu
+	fallbackSkipped atomic.Int64 // AUDIO_TEXT_FALLBACK desligado
+}
+
+// sendAudioReply envia reply em voz; se a voz ou o envio falhar, manda o texto.
+func (h *WebhookHandler) sendAudioReply(ctx context.Context, client models.Client, phone string, bcfg config.Config, reply string, delayMs int) {
+	audioBytes, err := h.speech(ctx, bcfg, reply)
+	if err != nil {
+		h.audio.ttsFailed.Add(1)
+		if !h.cfg.AudioTextFallback {
+			h.audio.fallbackSkipped.Add(1)
+			h.failAndNotify(client.ID, phone, "tts", fallbackBusy, err)
+			return
+		}
+		h.fail(phone, "tts", err)
+		h.audioTextFallback(ctx, client, phone, reply, delayMs)
+		return
+	}
+	_, err = h.deliver(ctx, phone, models.Message{ClientID: client.ID, Type: "audio", Content: reply}, func(ctx context.Context) (uazapi.SendResult, error) {
+		return h.wpp.SendMediaWithDelay(ctx, phone, "audio", audioBytes, delayMs)
+	})
+	if err != nil {
+		h.audio.deliveryFailed.Add(1)
+		h.fail(phone, "uazapi send audio", err)
+		if h.cfg.AudioTextFallback {
+			h.audioTextFallback(ctx, client, phone, reply, 0)
+			return
+		}
+		h.audio.fallbackSkipped.Add(1)
+		return
+	}
+	h.audio.sent.Add(1)
+}
+
+// audioTextFallback entrega a resposta em texto, com a nota de AUDIO_FALLBACK_NOTE.
+func (h *WebhookHandler) audioTextFallback(ctx context.Context, client models.Client, phone, reply string, delayMs int) {
+	text := reply
+	if h.cfg.AudioFallbackNote != "" {
+		text = h.cfg.AudioFallbackNote + "\n\n" + reply
+	}
+	// o histórico guarda a resposta (sem a nota), como texto: foi o que o cliente recebeu
+	_, err := h.deliver(ctx, phone, models.Message{ClientID: client.ID, Type: "text", Content: reply}, func(ctx context.Context) (uazapi.SendResult, error) {
+		return h.wpp.SendTextWithDelay(ctx, phone, text, delayMs)
+	})
+	if err != nil {
+		h.audio.fallbackFailed.Add(1)
+		h.fail(phone, "uazapi send audio fallback", err)
+		return
+	}
+	h.audio.textFallbacks.Add(1)
+}
+
+// AudioRepliesHandler expõe GET /admin/audio-replies.
+func (h *WebhookHandler) AudioRepliesHandler() http.Handler {
+	return h.auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := h.audio
+		writeJSON(w, http.StatusOK, map[string]any{
+			"text_fallback_enabled": h.cfg.AudioTextFallback,
+			"sent":                  s.sent.Load(),
+			"tts_failed":            s.ttsFailed.Load(),
+			"delivery_failed":       s.deliveryFailed.Load(),
+			"text_fallbacks":        s.textFallbacks.Load(),
+			"fallback_failed":       s.fallbackFailed.Load(),
+			"fallback_skipped":      s.fallbackSkipped.Load(),
+		})
+	}))
+}
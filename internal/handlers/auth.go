@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// Papéis das chaves de API, do menor para o maior privilégio.
+// analyst: só leitura; operator: opera (manutenção, leads, digest); admin: tudo, inclusive chaves e exclusões.
+const (
+	RoleAnalyst  = "analyst"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var roleRank = map[string]int{RoleAnalyst: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// principal identifica quem fez a requisição administrativa.
+type principal struct {
+	Name   string
+	Role   string
+	Tenant int64 // chave de um tenant; 0 = acesso a todos (ADMIN_TOKEN ou chave sem tenant)
+}
+
+type principalKey struct{}
+
+func principalFrom(ctx context.Context) (principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(principal)
+	return p, ok
+}
+
+// hasRole indica se a requisição autenticada tem ao menos o papel min.
+func hasRole(r *http.Request, min string) bool {
+	p, ok := principalFrom(r.Context())
+	return ok && roleRank[p.Role] >= roleRank[min]
+}
+
+// Auth valida o ADMIN_TOKEN (papel admin) ou chaves de API da tabela api_keys.
+type Auth struct {
+	adminToken string
+	pool       *pgxpool.Pool
+}
+
+func NewAuth(cfg config.Config, pool *pgxpool.Pool) *Auth {
+	return &Auth{adminToken: cfg.AdminToken, pool: pool}
+}
+
+func (a *Auth) authenticate(r *http.Request) (principal, bool) {
+	tok := requestToken(r)
+	if tok == "" {
+		return principal{}, false
+	}
+	if a.adminToken != "" && subtle.ConstantTimeCompare([]byte(tok), []byte(a.adminToken)) == 1 {
+		return principal{Name: "ADMIN_TOKEN", Role: RoleAdmin}, true
+	}
+	if a.pool == nil {
+		return principal{}, false
+	}
+	k, ok, err := models.LookupAPIKey(r.Context(), a.pool, hashAPIKey(tok))
+	if err != nil {
+		log.Printf("api key lookup error: %v", err)
+		return principal{}, false
+	}
+	if !ok {
+		return principal{}, false
+	}
+	p := principal{Name: k.Name, Role: k.Role}
+	if k.TenantID != nil {
+		p.Tenant = *k.TenantID
+	}
+	return p, true
+}
+
+// Require exige autenticação com papel >= min (401 sem credencial válida, 403 sem papel).
+// Chaves de um tenant ficam restritas a ele; as demais escolhem o tenant pelo cabeçalho
+// X-Tenant (slug, ou "default" para o tenant padrão) ou veem todos.
+func (a *Auth) Require(min string, next http.Handler) http.Handler {
+	return a.require(min, false, next)
+}
+
+// RequireRoot é Require para rotas que valem para o deploy inteiro (ajustes,
+// tenants...): chaves de tenant recebem 403.
+func (a *Auth) RequireRoot(min string, next http.Handler) http.Handler {
+	return a.require(min, true, next)
+}
+
+func (a *Auth) require(min string, root bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := a.authenticate(r)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if roleRank[p.Role] < roleRank[min] || (root && p.Tenant != 0) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		ctx := context.WithValue(r.Context(), principalKey{}, p)
+		switch slug := strings.TrimSpace(r.Header.Get("X-Tenant")); {
+		case p.Tenant != 0:
+			ctx = models.WithTenant(ctx, p.Tenant)
+		case slug == "default":
+			ctx = models.WithTenant(ctx, 0)
+		case slug != "" && a.pool != nil:
+			t, found, err := models.GetTenantBySlug(ctx, a.pool, slug)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !found {
+				http.Error(w, "unknown tenant", http.StatusNotFound)
+				return
+			}
+			ctx = models.WithTenant(ctx, t.ID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAPIKey gera uma chave aleatória ("lk_" + 32 bytes em base64url).
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "lk_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewAPIKeysHandler expõe a gestão de chaves (somente admin):
+//
+//	GET    /admin/api-keys
+//	POST   /admin/api-keys       {"name":"suporte-ana","role":"analyst"} -> a chave aparece só nesta resposta
+//
+// Com X-Tenant (ou usando uma chave de tenant) as chaves criadas e listadas são as do tenant.
+//	DELETE /admin/api-keys/{id}
+func NewAPIKeysHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	return auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		switch r.Method {
+		case http.MethodGet:
+			keys, err := models.ListAPIKeys(ctx, pool)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, keys)
+
+		case http.MethodPost:
+			var req struct {
+				Name string `json:"name"`
+				Role string `json:"role"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&req); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			req.Name = strings.TrimSpace(req.Name)
+			if req.Name == "" || roleRank[req.Role] == 0 {
+				http.Error(w, "name and role (analyst|operator|admin) are required", http.StatusBadRequest)
+				return
+			}
+			key, err := newAPIKey()
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "key error", err)
+				return
+			}
+			// chave criada com X-Tenant (ou por uma chave de tenant) fica restrita ao tenant
+			var tenantID *int64
+			if t, ok := models.TenantFrom(ctx); ok && t != 0 {
+				tenantID = &t
+			}
+			k, err := models.CreateAPIKey(ctx, pool, req.Name, req.Role, key[:10], hashAPIKey(key), tenantID)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			p, _ := principalFrom(ctx)
+			log.Printf("api key %d (%s, %s) created by %s", k.ID, k.Name, k.Role, p.Name)
+			writeJSON(w, http.StatusCreated, map[string]any{"key": key, "api_key": k})
+
+		case http.MethodDelete:
+			id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+			ok, err := models.RevokeAPIKey(ctx, pool, id)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !ok {
+				http.Error(w, "api key not found", http.StatusNotFound)
+				return
+			}
+			p, _ := principalFrom(ctx)
+			log.Printf("api key %d revoked by %s", id, p.Name)
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
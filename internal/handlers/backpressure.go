@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/phone"
+)
+
+/*
+Contrapressão do webhook.
+
+Cada flush do buffer vira uma run do assistente em segundo plano; sem limite, um
+pico de mensagens abre runs sem fim e tudo fica lento. Com BACKPRESSURE_MAX_RUNS
+(runs em andamento) ou BACKPRESSURE_MAX_PENDING (conversas aguardando o buffer)
+atingidos, o webhook responde 429 com Retry-After antes de gravar ou baixar
+qualquer coisa, e o gateway reenvia depois.
+
+Política (BACKPRESSURE_POLICY):
+  - reject:   recusa toda mensagem nova enquanto saturado;
+  - new_only: aceita mensagens de conversas que já estão no buffer (serão
+    agrupadas na mesma run) e recusa só as demais.
+
+Ligações, presença e eco do próprio bot sempre passam (não geram run).
+Os limites e contadores valem para o processo todo (todos os tenants).
+*/
+
+const (
+	shedPolicyReject  = "reject"
+	shedPolicyNewOnly = "new_only"
+)
+
+type loadShedder struct {
+	maxRuns    int
+	maxPending int
+	policy     string
+	retryAfter time.Duration
+
+	runs         atomic.Int64
+	shedRequests atomic.Int64
+	shedEvents   atomic.Int64
+	lastShed     atomic.Int64 // unix
+}
+
+func newLoadShedder(cfg config.Config) *loadShedder {
+	return &loadShedder{
+		maxRuns:    cfg.BackpressureMaxRuns,
+		maxPending: cfg.BackpressureMaxPending,
+		policy:     cfg.BackpressurePolicy,
+		retryAfter: time.Duration(cfg.BackpressureRetryAfterSeconds) * time.Second,
+	}
+}
+
+func (l *loadShedder) begin() { l.runs.Add(1) }
+func (l *loadShedder) end()   { l.runs.Add(-1) }
+
+// pendingBuffers soma as conversas aguardando flush em todos os pipelines e as
+// runs aguardando worker (RUN_WORKERS).
+func (h *WebhookHandler) pendingBuffers() int {
+	n := 0
+	for _, th := range h.tenants.all() {
+		n += th.bufMgr.Pending()
+	}
+	if h.queue != nil {
+		n += h.queue.Len()
+	}
+	return n
+}
+
+// overloaded devolve o motivo ("runs" ou "pending") se os eventos devem ser
+// recusados agora; "" para aceitar.
+func (h *WebhookHandler) overloaded(events []webhookEvent) string {
+	l := h.load
+	if l.maxRuns <= 0 && l.maxPending <= 0 {
+		return ""
+	}
+	reason := ""
+	switch {
+	case l.maxRuns > 0 && l.runs.Load() >= int64(l.maxRuns):
+		reason = "runs"
+	case l.maxPending > 0 && h.pendingBuffers() >= l.maxPending:
+		reason = "pending"
+	default:
+		return ""
+	}
+	for _, ev := range events {
+		m := ev.msg
+		if m.Call != nil || m.Presence != nil || m.Receipt != nil || m.FromMe || m.WasSentByAPI || isNewsletterEvent(m) {
+			continue
+		}
+		if l.policy == shedPolicyNewOnly && h.buffered(m) {
+			continue
+		}
+		return reason
+	}
+	return ""
+}
+
+// buffered indica se a conversa do evento já tem mensagens aguardando o flush.
+func (h *WebhookHandler) buffered(m incomingMessage) bool {
+	for _, cand := range []string{m.ChatID, m.Sender} {
+		if p, ok := phone.FromJID(cand); ok {
+			return h.bufMgr.Has(p)
+		}
+	}
+	return false
+}
+
+// shedLoad responde 429 com Retry-After.
+func (h *WebhookHandler) shedLoad(w http.ResponseWriter, events int, reason string) {
+	l := h.load
+	l.shedRequests.Add(1)
+	l.shedEvents.Add(int64(events))
+	l.lastShed.Store(time.Now().Unix())
+	secs := int(l.retryAfter / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	writeJSON(w, http.StatusTooManyRequests, map[string]any{"ok": false, "error": "overloaded", "reason": reason, "retry_after": secs})
+}
+
+// LoadHandler expõe GET /admin/webhook/load: runs em andamento, conversas no
+// buffer, limites e quantas requisições foram recusadas desde o start.
+func (h *WebhookHandler) LoadHandler() http.Handler {
+	return h.auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := h.load
+		body := map[string]any{
+			"runs":          l.runs.Load(),
+			"max_runs":      l.maxRuns,
+			"pending":       h.pendingBuffers(),
+			"max_pending":   l.maxPending,
+			"policy":        l.policy,
+			"shed_requests": l.shedRequests.Load(),
+			"shed_events":   l.shedEvents.Load(),
+			"upstream_http": h.upstream.Stats(),
+		}
+		if ts := l.lastShed.Load(); ts > 0 {
+			body["last_shed_at"] = time.Unix(ts, 0)
+		}
+		if h.queue != nil {
+			body["lanes"] = h.queue.Stats()
+		}
+		writeJSON(w, http.StatusOK, body)
+	}))
+}
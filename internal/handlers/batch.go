@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+)
+
+/*
+Lotes do webhook. O gateway pode juntar vários eventos num array; cada um passa
+pelo mesmo fluxo de um evento avulso. Eventos do mesmo chat são processados em
+sequência, na ordem do array (o buffer recebe as mensagens na ordem certa); chats
+diferentes rodam em paralelo. A resposta traz o resultado de cada evento.
+*/
+
+// batchKey agrupa os eventos do mesmo chat. Eventos sem chat (ligação, presença, confirmação)
+// ficam cada um no seu grupo.
+func batchKey(msg incomingMessage, i int) string {
+	switch {
+	case msg.ChatID != "":
+		return msg.ChatID
+	case msg.Sender != "":
+		return msg.Sender
+	}
+	return "#" + strconv.Itoa(i)
+}
+
+// serveBatch processa todos os eventos do lote e responde 200 com os resultados
+// na ordem do array.
+func (h *WebhookHandler) serveBatch(ctx context.Context, w http.ResponseWriter, events []webhookEvent) {
+	results := make([]batchResult, len(events))
+	groups := map[string][]int{}
+	var order []string
+	for i, ev := range events {
+		k := batchKey(ev.msg, i)
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, k := range order {
+		idx := groups[k]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, i := range idx {
+				results[i] = h.batchEvent(ctx, events[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Code >= 400 {
+			failed++
+		}
+	}
+	log.Printf("webhook batch: %d events, %d chats, %d failed", len(events), len(order), failed)
+	writeJSON(w, http.StatusOK, map[string]any{"ok": failed == 0, "batch": len(events), "failed": failed, "results": results})
+}
+
+// batchResult é a resposta de um evento dentro do lote.
+// batchEvent processa um evento do lote; um panic vira 500 só nesse evento (a
+// goroutine do lote não tem o Recover do mux).
+func (h *WebhookHandler) batchEvent(ctx context.Context, ev webhookEvent) batchResult {
+	rec := &batchWriter{header: http.Header{}, code: http.StatusOK}
+	func() {
+		defer func() {
+			if v := recover(); v != nil {
+				h.panicked(ctx, "", "webhook batch event", string(ev.raw), v, debug.Stack(), false)
+				rec.body.Reset()
+				http.Error(rec, "internal error", http.StatusInternalServerError)
+			}
+		}()
+		h.handleEvent(ctx, rec, ev.msg, ev.raw)
+	}()
+	return rec.result()
+}
+
+type batchResult struct {
+	Code int `json:"code"`
+	Body any `json:"body,omitempty"`
+}
+
+// batchWriter guarda a resposta de um evento do lote.
+type batchWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *batchWriter) Header() http.Header         { return b.header }
+func (b *batchWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *batchWriter) WriteHeader(code int)        { b.code = code }
+
+func (b *batchWriter) result() batchResult {
+	r := batchResult{Code: b.code}
+	raw := bytes.TrimSpace(b.body.Bytes())
+	var v any
+	if err := json.Unmarshal(raw, &v); err == nil {
+		r.Body = v
+	} else if len(raw) > 0 {
+		r.Body = string(raw)
+	}
+	return r
+}
+
+// batchBuckets são os limites superiores das faixas de tamanho de lote.
+var batchBuckets = []int{1, 2, 5, 10, 25, 50}
+
+// batchStats conta os tamanhos dos lotes recebidos pelo webhook desde o start.
+type batchStats struct {
+	mu       sync.Mutex
+	requests int64
+	events   int64
+	max      int
+	buckets  [7]int64 // batchBuckets + "mais que 50"
+}
+
+func (s *batchStats) observe(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	s.events += int64(n)
+	if n > s.max {
+		s.max = n
+	}
+	i := 0
+	for i < len(batchBuckets) && n > batchBuckets[i] {
+		i++
+	}
+	s.buckets[i]++
+}
+
+func (s *batchStats) snapshot() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := map[string]int64{}
+	lo := 1
+	for i, hi := range batchBuckets {
+		label := strconv.Itoa(hi)
+		if hi > lo {
+			label = strconv.Itoa(lo) + "-" + strconv.Itoa(hi)
+		}
+		sizes[label] = s.buckets[i]
+		lo = hi + 1
+	}
+	sizes[">"+strconv.Itoa(batchBuckets[len(batchBuckets)-1])] = s.buckets[len(batchBuckets)]
+	avg := 0.0
+	if s.requests > 0 {
+		avg = float64(s.events) / float64(s.requests)
+	}
+	return map[string]any{"requests": s.requests, "events": s.events, "max": s.max, "avg": avg, "sizes": sizes}
+}
+
+// BatchStatsHandler expõe GET /admin/webhook/batches: quantos eventos chegam por
+// requisição do webhook (todos os tenants, desde o start do processo).
+func (h *WebhookHandler) BatchStatsHandler() http.Handler {
+	return h.auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, h.batches.snapshot())
+	}))
+}
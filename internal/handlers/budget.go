@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/budget"
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+// newBudgetGuard monta o controle de orçamento a partir das ENVs BUDGET_*.
+func (h *WebhookHandler) newBudgetGuard(cfg config.Config) *budget.Guard {
+	return budget.New(h.pool, budget.Config{
+		Global: budget.Limits{
+			DailyTokens: int64(cfg.BudgetDailyTokens), MonthlyTokens: int64(cfg.BudgetMonthlyTokens),
+			DailyUSD: cfg.BudgetDailyUSD, MonthlyUSD: cfg.BudgetMonthlyUSD,
+		},
+		PerClient: budget.Limits{
+			DailyTokens: int64(cfg.BudgetClientDailyTokens), MonthlyTokens: int64(cfg.BudgetClientMonthlyTokens),
+			DailyUSD: cfg.BudgetClientDailyUSD, MonthlyUSD: cfg.BudgetClientMonthlyUSD,
+		},
+		CostPer1K: cfg.OpenAICostPer1KTokens,
+		Location:  cfg.Location(),
+		Alert:     h.budgetAlert,
+	})
+}
+
+// budgetAlert avisa os operadores (log, barramento de eventos e BUDGET_ALERT_NOTIFY).
+func (h *WebhookHandler) budgetAlert(msg string) {
+	log.Printf("budget alert: %s", msg)
+	h.publish(context.Background(), events.Event{Topic: events.BudgetAlert, Role: "system", Type: "budget", Content: msg})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		for _, op := range h.cfg.BudgetAlertNotify {
+			if _, err := h.wpp.SendText(ctx, op, msg); err != nil {
+				log.Println("uazapi send budget alert error:", err)
+			}
+		}
+	}()
+}
+
+// budgetModel decide o modelo da próxima run. ok=false quando o orçamento estourou
+// e não há modelo mais barato: o cliente recebe BUDGET_EXCEEDED_MESSAGE.
+func (h *WebhookHandler) budgetModel(ctx context.Context, clientID int64) (string, bool) {
+	st, err := h.budget.Check(ctx, clientID)
+	if err != nil {
+		log.Printf("budget check error: %v", err)
+		return "", true
+	}
+	if !st.Exceeded {
+		return "", true
+	}
+	if h.cfg.BudgetFallbackModel != "" {
+		return h.cfg.BudgetFallbackModel, true
+	}
+	return "", false
+}
+
+// sendBudgetExceeded responde com o texto de orçamento esgotado (no máx. 1 por cooldown).
+func (h *WebhookHandler) sendBudgetExceeded(ctx context.Context, clientID int64, phone string) {
+	text := h.cfg.BudgetExceededMessage
+	cooldown := time.Duration(h.cfg.FallbackCooldownMinutes) * time.Minute
+	if text == "" || !h.fallbacks.allow(phone+"|budget", cooldown) {
+		return
+	}
+	if _, err := h.deliverText(ctx, clientID, phone, text); err != nil {
+		h.fail(phone, "uazapi send budget", err)
+	}
+}
+
+// recordRunUsage grava o consumo de uma run terminal em openai_usage.
+func (h *WebhookHandler) recordRunUsage(ctx context.Context, clientID int64, run openai.Run) {
+	if run.Usage == nil {
+		return
+	}
+	u := run.Usage
+	if err := h.budget.Record(ctx, clientID, run.Model, u.PromptTokens, u.CompletionTokens, u.TotalTokens); err != nil {
+		log.Printf("usage record error: %v", err)
+	}
+}
+
+// BudgetHandler expõe GET /admin/budget?phone=55... com o consumo do dia/mês
+// (global e, se informado, do cliente) e a situação frente aos limites.
+func (h *WebhookHandler) BudgetHandler() http.Handler {
+	return h.auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		now := time.Now().In(h.cfg.Location())
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+		out := map[string]any{}
+		d, m, err := models.UsageSince(ctx, h.pool, nil, day, month)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		out["global"] = map[string]any{"day": d, "month": m}
+
+		var clientID int64
+		if phone := r.URL.Query().Get("phone"); phone != "" {
+			c, ok, err := h.clients.ByPhone(ctx, phone)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !ok {
+				http.Error(w, "client not found", http.StatusNotFound)
+				return
+			}
+			clientID = c.ID
+			cd, cm, err := models.UsageSince(ctx, h.pool, &clientID, day, month)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			out["client"] = map[string]any{"day": cd, "month": cm}
+		}
+		st, err := h.budget.Check(ctx, clientID)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		out["status"] = st
+		writeJSON(w, http.StatusOK, out)
+	}))
+}
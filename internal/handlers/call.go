@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/phone"
+)
+
+// callEvent é uma chamada de voz/vídeo recebida pelo WhatsApp.
+type callEvent struct {
+	JID    string
+	CallID string
+	Status string // offer | ringing | accept | reject | terminate | timeout ...
+}
+
+// incoming indica o início da chamada (os demais status só encerram/atualizam).
+func (c callEvent) incoming() bool {
+	switch c.Status {
+	case "", "offer", "ringing", "incoming", "call_offer":
+		return true
+	}
+	return false
+}
+
+// parseCall reconhece eventos de chamada nos formatos conhecidos:
+//
+//	{"EventType":"call","event":{"From":"5511...@s.whatsapp.net","CallID":"ABC","Type":"offer"}}
+//	{"body":{"EventType":"call","call":{"from":"5511...@s.whatsapp.net","id":"ABC","status":"ringing"}}}
+func parseCall(trimmed []byte) (callEvent, bool) {
+	var root map[string]any
+	if err := json.Unmarshal(trimmed, &root); err != nil {
+		return callEvent{}, false
+	}
+	if body, ok := root["body"].(map[string]any); ok {
+		root = body
+	}
+	evType := strings.ToLower(firstString(root, "EventType", "eventType", "event_type", "type"))
+	if !strings.Contains(evType, "call") {
+		return callEvent{}, false
+	}
+
+	var c callEvent
+	for _, key := range []string{"event", "call", "data"} {
+		obj, ok := root[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		if c.Status == "" {
+			c.Status = strings.ToLower(firstString(obj, "Status", "status", "Type", "type", "State", "state"))
+		}
+		if c.JID == "" {
+			c.JID = firstString(obj, "From", "from", "CallCreator", "callCreator", "Chat", "chat", "chatid", "caller")
+		}
+		if c.CallID == "" {
+			c.CallID = firstString(obj, "CallID", "callId", "callid", "ID", "id")
+		}
+	}
+	if c.JID == "" {
+		c.JID = firstString(root, "from", "chatid", "chat")
+	}
+	if c.CallID == "" {
+		c.CallID = firstString(root, "callId", "callid", "id")
+	}
+	return c, true
+}
+
+// resolveJID converte um JID (telefone ou @lid) no telefone do cliente.
+func (h *WebhookHandler) resolveJID(ctx context.Context, jid string) (string, bool) {
+	if number, ok := phone.FromJID(jid); ok {
+		return number, true
+	}
+	lid, ok := phone.LID(jid)
+	if !ok {
+		return "", false
+	}
+	if mapped, found, err := models.LookupLID(ctx, h.pool, lid); err == nil && found {
+		return mapped, true
+	}
+	return lid, true
+}
+
+// handleCall recusa a chamada (CALL_AUTO_REJECT) e pede que o cliente escreva
+// (CALL_REPLY), no máximo uma vez por cooldown de avisos.
+func (h *WebhookHandler) handleCall(ctx context.Context, c callEvent) {
+	if !c.incoming() {
+		return
+	}
+	phone, ok := h.resolveJID(ctx, c.JID)
+	if !ok {
+		log.Printf("call event without caller: %+v", c)
+		return
+	}
+	log.Printf("incoming call from %s (id=%s)", phone, c.CallID)
+
+	if h.cfg.CallAutoReject && c.CallID != "" {
+		if err := h.wpp.RejectCall(ctx, phone, c.CallID); err != nil {
+			h.fail(phone, "uazapi call reject", err)
+		}
+	}
+	h.publish(ctx, events.Event{Topic: events.CallReceived, Phone: phone, Role: "user", Type: "call", Content: "ligação recebida"})
+
+	if h.cfg.CallReply == "" {
+		return
+	}
+	cooldown := time.Duration(h.cfg.FallbackCooldownMinutes) * time.Minute
+	if !h.fallbacks.allow(phone+"|call", cooldown) {
+		return
+	}
+	client, err := h.clients.GetOrCreate(ctx, phone, nil)
+	if err != nil {
+		h.fail(phone, "call db", err)
+		return
+	}
+	text := h.interpolateReply(client, h.cfg.CallReply)
+	if _, err := h.deliverText(ctx, client.ID, phone, text); err != nil {
+		h.fail(phone, "uazapi send call reply", err)
+		return
+	}
+}
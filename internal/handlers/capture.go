@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxCaptureMinutes limita o prazo da captura: ela existe para diagnóstico, não para ficar ligada.
+const maxCaptureMinutes = 120
+
+// CaptureHandler expõe a captura de depuração das chamadas à OpenAI e à Uazapi (admin):
+//
+//	GET    /admin/debug/capture?provider=uazapi&limit=50   estado e chamadas (recentes primeiro)
+//	POST   /admin/debug/capture {"enabled":true,"minutes":15}
+//	DELETE /admin/debug/capture                            descarta o que foi gravado
+//
+// Tokens e cabeçalhos de autenticação são trocados por [redacted]; mídia e base64 não são guardados.
+func (h *WebhookHandler) CaptureHandler() http.Handler {
+	return h.auth.RequireRoot(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			writeJSON(w, http.StatusOK, map[string]any{
+				"state":   h.capture.State(),
+				"entries": h.capture.Entries(r.URL.Query().Get("provider"), limit),
+			})
+
+		case http.MethodPost:
+			var req struct {
+				Enabled bool `json:"enabled"`
+				Minutes int  `json:"minutes"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&req); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			if req.Enabled {
+				if req.Minutes <= 0 {
+					req.Minutes = 15
+				}
+				req.Minutes = min(req.Minutes, maxCaptureMinutes)
+				h.capture.Enable(time.Duration(req.Minutes) * time.Minute)
+				log.Printf("debug capture enabled for %d minutes", req.Minutes)
+			} else {
+				h.capture.Disable()
+				log.Println("debug capture disabled")
+			}
+			writeJSON(w, http.StatusOK, h.capture.State())
+
+		case http.MethodDelete:
+			h.capture.Clear()
+			writeJSON(w, http.StatusOK, h.capture.State())
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+)
+
+/*
+Verificação da URL do webhook.
+
+Gateways como Meta Cloud API e 360dialog validam a URL antes de enviar eventos:
+
+	GET /webhook?hub.mode=subscribe&hub.verify_token=<WEBHOOK_VERIFY_TOKEN>&hub.challenge=123
+	200 "123" (text/plain)                      token confere
+	403                                         token errado
+
+Também aceita verify_token/token e challenge/echo sem o prefixo "hub.". HEAD
+responde 200 (checagem de disponibilidade). Sem WEBHOOK_VERIFY_TOKEN, GET e HEAD
+continuam 405.
+*/
+
+// serveChallenge trata GET/HEAD no webhook. Devolve false se a verificação está
+// desligada (o chamador responde 405).
+func (h *WebhookHandler) serveChallenge(w http.ResponseWriter, r *http.Request) bool {
+	if h.cfg.WebhookVerifyToken == "" {
+		return false
+	}
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return true
+	}
+	q := r.URL.Query()
+	token := firstParam(q.Get, "hub.verify_token", "verify_token", "token")
+	challenge := firstParam(q.Get, "hub.challenge", "challenge", "echo")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.WebhookVerifyToken)) != 1 {
+		log.Printf("webhook verification rejected (mode %q)", q.Get("hub.mode"))
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return true
+	}
+	if challenge == "" {
+		http.Error(w, "missing challenge", http.StatusBadRequest)
+		return true
+	}
+	log.Printf("webhook verified (mode %q)", q.Get("hub.mode"))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(challenge))
+	return true
+}
+
+// firstParam devolve o primeiro parâmetro não vazio entre os nomes dados.
+func firstParam(get func(string) string, names ...string) string {
+	for _, n := range names {
+		if v := get(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/phone"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+Canais do WhatsApp (newsletter, JID "...@newsletter").
+
+Eventos vindos de canais (publicações, reações) não são conversas: o webhook os
+ignora antes de criar cliente ou chamar a IA. Publicar no canal é pelo admin:
+
+	POST /admin/channels/{channel}/posts
+	{"text": "Promoção da semana!", "media_type": "image", "media_url": "https://..."}
+
+{channel} é o JID ou só o número do canal. Papel mínimo: operator.
+*/
+
+// isNewsletterEvent indica um evento de canal (chat ou remetente @newsletter).
+func isNewsletterEvent(msg incomingMessage) bool {
+	for _, cand := range []string{msg.ChatID, msg.Sender} {
+		if j, ok := phone.ParseJID(cand); ok && j.IsNewsletter() {
+			return true
+		}
+	}
+	return false
+}
+
+var channelMediaTypes = map[string]bool{"image": true, "video": true, "document": true, "audio": true}
+
+// ChannelPostHandler expõe POST /admin/channels/{channel}/posts.
+func (h *WebhookHandler) ChannelPostHandler() http.Handler {
+	return h.auth.Require(RoleOperator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+
+		var in struct {
+			Text      string `json:"text"`
+			MediaType string `json:"media_type"`
+			MediaURL  string `json:"media_url"`
+		}
+		if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		post := uazapi.ChannelPost{Text: strings.TrimSpace(in.Text), MediaURL: strings.TrimSpace(in.MediaURL)}
+		switch {
+		case post.MediaURL != "":
+			post.MediaType = strings.ToLower(in.MediaType)
+			if post.MediaType == "" {
+				post.MediaType = "image"
+			}
+			if !channelMediaTypes[post.MediaType] {
+				http.Error(w, "invalid media_type", http.StatusBadRequest)
+				return
+			}
+		case post.Text == "":
+			http.Error(w, "text or media_url required", http.StatusBadRequest)
+			return
+		}
+
+		channel := r.PathValue("channel")
+		res, err := h.wpp.SendToChannel(ctx, channel, post)
+		if errors.Is(err, uazapi.ErrNotChannel) {
+			http.Error(w, "invalid channel", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			writeErr(w, http.StatusBadGateway, "send error", err)
+			return
+		}
+		p, _ := principalFrom(ctx)
+		log.Printf("channel post to %s by %s", channel, p.Name)
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "message_id": res.MessageID})
+	}))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+// Modos de CITATIONS_MODE.
+const (
+	citationsStrip     = "strip"     // remove as marcações (padrão)
+	citationsFootnotes = "footnotes" // troca por [1], [2] e lista as fontes no fim
+)
+
+var spaceBeforePunct = regexp.MustCompile(`[ \t]+([.,;:!?])`)
+
+// renderCitations trata as anotações de citação (file search) da resposta. Devolve o
+// texto sem as marcações "【4:0†fonte】" e, no modo footnotes, o rodapé com as fontes
+// ("[1] manual.pdf"), que é anexado depois do parse do envelope estruturado.
+func (h *WebhookHandler) renderCitations(ctx context.Context, msg openai.AssistantText) (string, string) {
+	text := msg.Value
+	footnotes := h.cfg.CitationsMode == citationsFootnotes
+
+	numbers := map[string]int{}
+	var sources []string
+	for _, a := range msg.Annotations {
+		if a.Text == "" {
+			continue
+		}
+		replacement := ""
+		if fileID := a.FileID(); footnotes && a.Type == "file_citation" && fileID != "" {
+			n, ok := numbers[fileID]
+			if !ok {
+				n = len(numbers) + 1
+				numbers[fileID] = n
+				name, err := h.ai.FileName(ctx, fileID)
+				if err != nil || name == "" {
+					log.Printf("citation file name error (%s): %v", fileID, err)
+					name = "documento " + fmt.Sprint(n)
+				}
+				sources = append(sources, fmt.Sprintf("[%d] %s", n, name))
+			}
+			replacement = fmt.Sprintf(" [%d]", n)
+		}
+		text = strings.ReplaceAll(text, a.Text, replacement)
+	}
+	text = removeRefs(text)
+	text = strings.ReplaceAll(text, "  [", " [")
+	text = spaceBeforePunct.ReplaceAllString(text, "$1")
+	return text, strings.Join(sources, "\n")
+}
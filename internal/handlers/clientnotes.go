@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// maxClientNote limita o tamanho de uma nota de operador (em caracteres).
+const maxClientNote = 4000
+
+// clientByPhone carrega o cliente do {phone} da rota (no tenant da requisição).
+// false quando já respondeu com erro.
+func clientByPhone(w http.ResponseWriter, r *http.Request, pool *pgxpool.Pool) (models.Client, bool) {
+	client, ok, err := models.GetClientByPhone(r.Context(), pool, r.PathValue("phone"))
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "db error", err)
+		return client, false
+	}
+	if !ok {
+		http.Error(w, "client not found", http.StatusNotFound)
+		return client, false
+	}
+	return client, true
+}
+
+// NewClientNotesHandler guarda notas livres dos operadores sobre o cliente,
+// assinadas com o nome da chave de API:
+//
+//	GET    /admin/clients/{phone}/notes              lista, mais recentes primeiro (analyst)
+//	POST   /admin/clients/{phone}/notes {"note"}     adiciona (operator)
+//	DELETE /admin/clients/{phone}/notes/{id}         remove (operator)
+func NewClientNotesHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	return auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if r.Method != http.MethodGet && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		client, ok := clientByPhone(w, r, pool)
+		if !ok {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			list, err := models.ListClientNotes(ctx, pool, client.ID)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+
+		case http.MethodPost:
+			var in struct {
+				Note string `json:"note"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			in.Note = strings.TrimSpace(in.Note)
+			if in.Note == "" || utf8.RuneCountInString(in.Note) > maxClientNote {
+				http.Error(w, "note must have 1 to 4000 characters", http.StatusBadRequest)
+				return
+			}
+			p, _ := principalFrom(ctx)
+			n, err := models.AddClientNote(ctx, pool, client.ID, in.Note, p.Name)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, n)
+
+		case http.MethodDelete:
+			id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+			found, err := models.DeleteClientNote(ctx, pool, client.ID, id)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !found {
+				http.Error(w, "note not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+// NewClientTagsHandler marca clientes manualmente. As tags valem para os mesmos
+// filtros das tags do assistente (REENGAGE_TAG, contexto da run):
+//
+//	GET    /admin/clients/{phone}/tags                   lista com autoria (analyst)
+//	POST   /admin/clients/{phone}/tags {"tags":["vip"]}  adiciona (operator)
+//	DELETE /admin/clients/{phone}/tags/{tag}             remove (operator)
+func NewClientTagsHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	return auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if r.Method != http.MethodGet && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		client, ok := clientByPhone(w, r, pool)
+		if !ok {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+
+		case http.MethodPost:
+			var in struct {
+				Tags []string `json:"tags"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			for _, t := range in.Tags {
+				if t = models.NormalizeTag(t); t == "" || len(t) > 64 {
+					http.Error(w, "invalid tag", http.StatusBadRequest)
+					return
+				}
+			}
+			p, _ := principalFrom(ctx)
+			for _, t := range in.Tags {
+				if err := models.AddClientTag(ctx, pool, client.ID, t, p.Name); err != nil {
+					writeErr(w, http.StatusInternalServerError, "db error", err)
+					return
+				}
+			}
+
+		case http.MethodDelete:
+			found, err := models.RemoveClientTag(ctx, pool, client.ID, r.PathValue("tag"))
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !found {
+				http.Error(w, "tag not found", http.StatusNotFound)
+				return
+			}
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		list, err := models.ListClientTagDetails(ctx, pool, client.ID)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}))
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/your-org/leandro-agent/internal/retention"
+)
+
+// NewDeleteClientHandler expõe DELETE /admin/clients/{phone}: apaga o cliente,
+// suas mensagens e a thread na OpenAI, com registro em purge_audit.
+func NewDeleteClientHandler(auth *Auth, job *retention.Job) http.Handler {
+	return auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		phone := r.PathValue("phone")
+		ok, err := job.DeleteClient(r.Context(), phone, "admin delete")
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "delete error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, "client not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "phone": phone})
+	}))
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Comandos do cliente (CHAT_COMMANDS).
+
+Palavras-chave que o cliente escreve sozinhas na mensagem são tratadas aqui, sem
+passar pela IA (a mensagem fica registrada como as outras):
+
+  - menu, opções: lista os comandos ativos;
+  - atendente, humano: pede atendimento humano (avisa HANDOFF_NOTIFY);
+  - áudio off / áudio on: respostas só em texto, mesmo a áudios, ou de volta em voz;
+  - apagar meus dados: pede o código que confirma a exclusão (ver erasure.go).
+
+CHAT_COMMANDS escolhe os ativos (menu, atendente, audio, apagar) e
+CHAT_COMMAND_REPLIES troca as respostas (chaves menu, atendente, audio_off,
+audio_on, apagar; "" não responde).
+*/
+
+// chatCommand é um comando do cliente: frases aceitas (após normalizeCommand) e a ação.
+type chatCommand struct {
+	name    string // chave em CHAT_COMMANDS
+	help    string // linha no menu
+	phrases []string
+	run     func(h *WebhookHandler, ctx context.Context, client models.Client, phone, phrase string) string // nil = menu
+}
+
+var chatCommands = []chatCommand{
+	{
+		name:    "menu",
+		phrases: []string{"menu", "opções", "opcoes", "comandos"},
+	},
+	{
+		name:    "atendente",
+		help:    "*atendente* — falar com uma pessoa da equipe",
+		phrases: []string{"atendente", "humano", "falar com atendente", "falar com um atendente", "falar com humano", "falar com uma pessoa"},
+		run:     (*WebhookHandler).commandHuman,
+	},
+	{
+		name: "audio",
+		help: "*áudio off* / *áudio on* — respostas só por texto ou em áudio",
+		phrases: []string{"áudio off", "audio off", "sem áudio", "sem audio", "desativar áudio", "desativar audio",
+			"áudio on", "audio on", "com áudio", "com audio", "ativar áudio", "ativar audio"},
+		run: (*WebhookHandler).commandAudio,
+	},
+	{
+		name:    "apagar",
+		help:    "*apagar meus dados* — pedir a exclusão dos seus dados",
+		phrases: []string{"apagar meus dados", "excluir meus dados", "deletar meus dados", "apagar dados"},
+		run:     (*WebhookHandler).commandForget,
+	},
+}
+
+// normalizeCommand deixa o texto comparável às frases dos comandos.
+func normalizeCommand(text string) string {
+	t := strings.ToLower(strings.Trim(strings.TrimSpace(text), ".!?"))
+	return strings.Join(strings.Fields(t), " ")
+}
+
+// matchCommand devolve o comando ativo correspondente ao texto (nil se não for comando).
+func (h *WebhookHandler) matchCommand(text string) (*chatCommand, string) {
+	t := normalizeCommand(text)
+	if t == "" || len(t) > 40 {
+		return nil, ""
+	}
+	for i := range chatCommands {
+		c := &chatCommands[i]
+		if slices.Contains(c.phrases, t) && slices.Contains(h.cfg.ChatCommands, c.name) {
+			return c, t
+		}
+	}
+	return nil, ""
+}
+
+// handleChatCommand executa o comando do cliente, se o texto for um. Devolve false
+// para seguir o fluxo normal.
+func (h *WebhookHandler) handleChatCommand(ctx context.Context, client models.Client, phone, text string) bool {
+	if h.confirmErasure(ctx, client, phone, text) {
+		return true
+	}
+	c, phrase := h.matchCommand(text)
+	if c == nil {
+		return false
+	}
+	log.Printf("chat command %q from %s", c.name, phone)
+	reply := h.commandMenu()
+	if c.run != nil {
+		reply = c.run(h, ctx, client, phone, phrase)
+	}
+	h.sendCommandReply(ctx, client.ID, phone, reply)
+	return true
+}
+
+// commandMenu lista os comandos ativos depois do texto de CHAT_COMMAND_REPLIES.menu.
+func (h *WebhookHandler) commandMenu() string {
+	lines := []string{h.cfg.ChatCommandReplies["menu"]}
+	for _, c := range chatCommands {
+		if c.help != "" && slices.Contains(h.cfg.ChatCommands, c.name) {
+			lines = append(lines, "• "+c.help)
+		}
+	}
+	if lines[0] == "" {
+		lines = lines[1:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (h *WebhookHandler) commandHuman(ctx context.Context, client models.Client, phone, _ string) string {
+	h.requestHandoff(ctx, client.ID, phone)
+	return h.cfg.ChatCommandReplies["atendente"]
+}
+
+func (h *WebhookHandler) commandAudio(ctx context.Context, client models.Client, phone, phrase string) string {
+	off := strings.HasPrefix(phrase, "sem ") || strings.HasPrefix(phrase, "desativar ") || strings.HasSuffix(phrase, " off")
+	if err := models.SetClientAudioOff(ctx, h.pool, client.ID, off); err != nil {
+		h.fail(phone, "db audio off", err)
+		return ""
+	}
+	if off {
+		return h.cfg.ChatCommandReplies["audio_off"]
+	}
+	return h.cfg.ChatCommandReplies["audio_on"]
+}
+
+func (h *WebhookHandler) commandForget(ctx context.Context, client models.Client, phone, _ string) string {
+	text, err := h.requestErasure(ctx, client, phone, "client")
+	if err != nil {
+		h.fail(phone, "db data erasure", err)
+		return ""
+	}
+	return text
+}
+
+// sendCommandReply envia e registra a resposta de um comando ("" não envia).
+func (h *WebhookHandler) sendCommandReply(ctx context.Context, clientID int64, phone, text string) {
+	if text == "" {
+		return
+	}
+	if _, err := h.deliverText(ctx, clientID, phone, text); err != nil {
+		h.fail(phone, "uazapi send command reply", err)
+	}
+}
+
+// audioRepliesOff indica se o cliente pediu respostas só em texto ("áudio off").
+func (h *WebhookHandler) audioRepliesOff(ctx context.Context, clientID int64) bool {
+	if !slices.Contains(h.cfg.ChatCommands, "audio") {
+		return false
+	}
+	off, err := models.ClientAudioOff(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("db audio off error: %v", err)
+	}
+	return off
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+Pesquisa de satisfação (CSAT).
+
+A pergunta (CSAT_QUESTION, nota de 1 a 5 por texto — botões do WhatsApp aceitam
+no máximo 3 opções) sai quando:
+  - a resposta do assistente traz a diretiva "resolved": true; ou
+  - a conversa fica CSAT_INACTIVITY_MINUTES parada depois de uma resposta.
+
+A próxima mensagem do cliente com uma nota, dentro de CSAT_ANSWER_HOURS, é gravada
+em csat e respondida com CSAT_THANKS, sem passar pela IA.
+*/
+
+const (
+	csatResolved   = "resolved"
+	csatInactivity = "inactivity"
+
+	csatScanInterval = time.Minute
+	// conversas paradas há mais tempo que isso não recebem pesquisa (ex.: logo após ligar o CSAT)
+	csatMaxAge = 24 * time.Hour
+)
+
+// sendCSAT envia a pergunta ao cliente e registra a pesquisa, respeitando CSAT_COOLDOWN_DAYS.
+func (h *WebhookHandler) sendCSAT(ctx context.Context, clientID int64, phone, reason string) {
+	cooldown := time.Duration(h.cfg.CSATCooldownDays) * 24 * time.Hour
+	recent, err := models.SurveyedSince(ctx, h.pool, clientID, time.Now().Add(-cooldown))
+	if err != nil {
+		log.Printf("csat load error: %v", err)
+		return
+	}
+	if recent {
+		return
+	}
+	if _, err := h.deliverText(uazapi.WithPriority(ctx, uazapi.PriorityBulk), clientID, phone, h.cfg.CSATQuestion); err != nil {
+		h.fail(phone, "uazapi send csat", err)
+		return
+	}
+	if err := models.RecordCSATSurvey(ctx, h.pool, clientID, reason); err != nil {
+		log.Printf("db record csat error: %v", err)
+	}
+	log.Printf("csat survey sent to %s (%s)", phone, reason)
+}
+
+// csatLoop procura conversas paradas e envia a pesquisa até o ctx ser cancelado.
+func (h *WebhookHandler) csatLoop(ctx context.Context) {
+	ctx = h.scope(ctx)
+	t := time.NewTicker(csatScanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-t.C:
+			if h.maint.active() {
+				continue
+			}
+			// uma réplica por varredura: duas mandariam a pesquisa em dobro
+			name := fmt.Sprintf("csat:%d", h.tenantID)
+			if _, err := h.sched.Once(ctx, name, tick.Truncate(csatScanInterval), h.scanCSAT); err != nil {
+				log.Printf("csat scan error: %v", err)
+			}
+		}
+	}
+}
+
+// scanCSAT envia a pesquisa às conversas paradas.
+func (h *WebhookHandler) scanCSAT(ctx context.Context) error {
+	idle := time.Duration(h.cfg.CSATInactivityMinutes) * time.Minute
+	cands, err := models.ListCSATCandidates(ctx, h.pool, models.CSATQuery{
+		Idle:     idle,
+		MaxAge:   idle + csatMaxAge,
+		Cooldown: time.Duration(h.cfg.CSATCooldownDays) * 24 * time.Hour,
+		Limit:    20,
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range cands {
+		h.sendCSAT(ctx, c.ClientID, c.Phone, csatInactivity)
+	}
+	return nil
+}
+
+var ratingRe = regexp.MustCompile(`^\D{0,12}?([1-5])\D{0,12}$`)
+
+// parseRating lê uma nota de 1 a 5 ("5", "nota 4", "3 estrelas", "⭐⭐⭐⭐").
+func parseRating(text string) (int, bool) {
+	t := strings.TrimSpace(text)
+	if n := strings.Count(t, "⭐"); n > 0 {
+		if n <= 5 && strings.TrimSpace(strings.ReplaceAll(t, "⭐", "")) == "" {
+			return n, true
+		}
+		return 0, false
+	}
+	m := ratingRe.FindStringSubmatch(t)
+	if m == nil {
+		return 0, false
+	}
+	return int(m[1][0] - '0'), true
+}
+
+// answerCSAT grava a nota se o cliente tem pesquisa pendente e o texto é uma nota.
+// Devolve false para o texto seguir o fluxo normal.
+func (h *WebhookHandler) answerCSAT(ctx context.Context, clientID int64, phone, text string) bool {
+	if !h.cfg.CSATEnabled {
+		return false
+	}
+	rating, ok := parseRating(text)
+	if !ok {
+		return false
+	}
+	since := time.Now().Add(-time.Duration(h.cfg.CSATAnswerHours) * time.Hour)
+	id, pending, err := models.PendingCSAT(ctx, h.pool, clientID, since)
+	if err != nil {
+		log.Printf("csat load error: %v", err)
+		return false
+	}
+	if !pending {
+		return false
+	}
+	if err := models.AnswerCSAT(ctx, h.pool, id, rating); err != nil {
+		log.Printf("db answer csat error: %v", err)
+		return false
+	}
+	log.Printf("csat rating %d from %s", rating, phone)
+	if h.cfg.CSATThanks == "" {
+		return true
+	}
+	if _, err := h.deliverText(ctx, clientID, phone, h.cfg.CSATThanks); err != nil {
+		log.Println("uazapi send csat thanks error:", err)
+	}
+	return true
+}
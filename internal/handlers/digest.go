@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/digest"
+)
+
+// NewDigestHandler expõe POST /admin/digest para disparar o resumo manualmente.
+// ?date=2024-05-31 (default: ontem, no fuso do negócio).
+func NewDigestHandler(cfg config.Config, auth *Auth, job *digest.Job) http.Handler {
+	return auth.RequireRoot(RoleOperator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		day := time.Now().In(cfg.Location()).AddDate(0, 0, -1)
+		if s := r.URL.Query().Get("date"); s != "" {
+			d, err := time.ParseInLocation("2006-01-02", s, cfg.Location())
+			if err != nil {
+				http.Error(w, "invalid date", http.StatusBadRequest)
+				return
+			}
+			day = d
+		}
+		if err := job.Run(r.Context(), day); err != nil {
+			writeErr(w, http.StatusBadGateway, "digest error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	}))
+}
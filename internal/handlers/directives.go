@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+Resposta estruturada do assistente (opcional). Se a resposta for um JSON válido
+neste formato, cada diretiva é renderizada; senão, o texto vai como está.
+
+	{
+	  "text": "Posso agendar para amanhã?",           // obrigatório, exceto quando há media
+	  "buttons": ["Sim", "Não", "Outro horário"],     // até 3, até 20 caracteres cada
+	  "media": {"type": "image", "url": "https://...", "caption": "Tabela"},
+	  "handoff": true,                                 // pede atendimento humano
+	  "resolved": true                                 // atendimento concluído: envia a pesquisa CSAT
+	}
+*/
+
+type replyMedia struct {
+	Type    string `json:"type"` // image | video | document | audio
+	URL     string `json:"url"`
+	Caption string `json:"caption"`
+}
+
+type replyEnvelope struct {
+	Text     string      `json:"text"`
+	Buttons  []string    `json:"buttons"`
+	Media    *replyMedia `json:"media"`
+	Handoff  bool        `json:"handoff"`
+	Resolved bool        `json:"resolved"`
+}
+
+const (
+	maxReplyButtons   = 3
+	maxReplyButtonLen = 20
+)
+
+func (e *replyEnvelope) validate() error {
+	e.Text = strings.TrimSpace(e.Text)
+	if e.Text == "" && e.Media == nil {
+		return errors.New("text is required")
+	}
+	if len(e.Buttons) > maxReplyButtons {
+		return fmt.Errorf("at most %d buttons", maxReplyButtons)
+	}
+	for i, b := range e.Buttons {
+		b = strings.TrimSpace(b)
+		if b == "" || utf8.RuneCountInString(b) > maxReplyButtonLen {
+			return fmt.Errorf("button %d must have 1-%d characters", i+1, maxReplyButtonLen)
+		}
+		e.Buttons[i] = b
+	}
+	if len(e.Buttons) > 0 && e.Text == "" {
+		return errors.New("buttons require text")
+	}
+	if m := e.Media; m != nil {
+		switch m.Type {
+		case "image", "video", "document", "audio":
+		default:
+			return fmt.Errorf("invalid media type %q", m.Type)
+		}
+		if !strings.HasPrefix(m.URL, "https://") && !strings.HasPrefix(m.URL, "http://") {
+			return errors.New("media url must be http(s)")
+		}
+	}
+	return nil
+}
+
+// parseReplyEnvelope tenta ler a resposta como envelope JSON (aceita cercas ```json).
+// Qualquer erro de parse/validação devolve ok=false e a resposta segue como texto puro.
+func parseReplyEnvelope(reply string) (replyEnvelope, bool) {
+	s := strings.TrimSpace(reply)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "```json"), "```")
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+	}
+	if !strings.HasPrefix(s, "{") {
+		return replyEnvelope{}, false
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.DisallowUnknownFields()
+	var env replyEnvelope
+	if err := dec.Decode(&env); err != nil {
+		log.Printf("reply envelope parse error (sending as text): %v", err)
+		return replyEnvelope{}, false
+	}
+	if err := env.validate(); err != nil {
+		log.Printf("reply envelope invalid (sending as text): %v", err)
+		return replyEnvelope{}, false
+	}
+	return env, true
+}
+
+// hasDirectives indica se há algo além de texto a renderizar.
+func (e replyEnvelope) hasDirectives() bool {
+	return len(e.Buttons) > 0 || e.Media != nil || e.Handoff || e.Resolved
+}
+
+// renderDirectives envia texto/botões, depois a mídia, e por fim sinaliza o handoff
+// (ou, com o atendimento resolvido, envia a pesquisa CSAT).
+func (h *WebhookHandler) renderDirectives(ctx context.Context, client models.Client, phone string, env replyEnvelope, delayMs int) {
+	text := env.Text
+	if text != "" {
+		if len(env.Buttons) > 0 {
+			m := models.Message{ClientID: client.ID, Type: "buttons", Content: text + "\n[" + strings.Join(env.Buttons, " | ") + "]"}
+			_, err := h.deliver(ctx, phone, m, func(ctx context.Context) (uazapi.SendResult, error) {
+				return h.wpp.SendButtons(ctx, phone, text, env.Buttons, "")
+			})
+			if err == nil {
+				text = ""
+			} else {
+				// instâncias sem suporte a botões: opções numeradas no texto
+				h.fail(phone, "uazapi send buttons", err)
+				text = withNumberedOptions(text, env.Buttons)
+			}
+		}
+		if text != "" {
+			_, err := h.deliver(ctx, phone, models.Message{ClientID: client.ID, Type: "text", Content: text}, func(ctx context.Context) (uazapi.SendResult, error) {
+				return h.wpp.SendTextWithDelay(ctx, phone, text, delayMs)
+			})
+			if err != nil {
+				h.fail(phone, "uazapi send text", err)
+			}
+		}
+	}
+
+	if m := env.Media; m != nil {
+		caption := h.interpolateReply(client, m.Caption)
+		content := m.URL
+		if caption != "" {
+			content = caption + "\n" + m.URL
+		}
+		_, err := h.deliver(ctx, phone, models.Message{ClientID: client.ID, Type: m.Type, Content: content}, func(ctx context.Context) (uazapi.SendResult, error) {
+			return h.wpp.SendMediaURL(ctx, phone, m.Type, m.URL, caption)
+		})
+		if err != nil {
+			h.fail(phone, "uazapi send media", err)
+		}
+	}
+
+	if env.Handoff {
+		h.requestHandoff(ctx, client.ID, phone)
+	} else if env.Resolved && h.cfg.CSATEnabled {
+		h.sendCSAT(ctx, client.ID, phone, csatResolved)
+	}
+}
+
+func withNumberedOptions(text string, options []string) string {
+	var b strings.Builder
+	b.WriteString(text)
+	b.WriteString("\n")
+	for i, o := range options {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, o)
+	}
+	return b.String()
+}
+
+// requestHandoff avisa os operadores (barramento de eventos e HANDOFF_NOTIFY) que o
+// cliente pediu/precisa de atendimento humano. Fica no histórico (type "handoff")
+// para a taxa de handoff dos experimentos.
+func (h *WebhookHandler) requestHandoff(ctx context.Context, clientID int64, phone string) {
+	log.Printf("handoff requested for %s", phone)
+	if _, err := h.messages.Insert(ctx, models.Message{ClientID: clientID, Role: "system", Type: "handoff", Content: "atendimento humano solicitado"}); err != nil {
+		log.Printf("db insert handoff error: %v", err)
+	}
+	h.publish(ctx, events.Event{Topic: events.HandoffRequested, Phone: phone, Role: "system", Type: "handoff", Content: "atendimento humano solicitado"})
+	if len(h.cfg.HandoffNotify) == 0 {
+		return
+	}
+	notice := "Atendimento humano solicitado pelo cliente " + phone
+	if s := h.currentSummary(ctx, clientID); s != "" {
+		notice += "\n\nResumo: " + s
+	}
+	for _, op := range h.cfg.HandoffNotify {
+		if _, err := h.wpp.SendText(ctx, op, notice); err != nil {
+			log.Println("uazapi send handoff notice error:", err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Documentos consultáveis na conversa toda (DOCUMENT_FILE_SEARCH).
+
+Além do resumo de sempre, o documento recebido vai para a OpenAI Files e fica
+aguardando o flush do buffer; a mensagem do usuário da próxima run sai com ele
+anexado (file_search), e a OpenAI o indexa no vector store da thread. Cada arquivo
+fica registrado em thread_files e é apagado na OpenAI quando passa do
+DOCUMENT_FILE_TTL_HOURS (ou quando a thread/cliente é expurgado pela retenção).
+*/
+
+const fileCleanupInterval = time.Hour
+
+// stageDocument envia o documento à OpenAI Files para ser anexado na próxima run.
+// Falhas só ficam no log: o resumo do documento segue normalmente.
+func (h *WebhookHandler) stageDocument(ctx context.Context, clientID int64, phone string, data []byte, filename string) {
+	if !h.cfg.DocumentFileSearch {
+		return
+	}
+	name := filepath.Base(filename)
+	if name == "" || name == "." || name == "/" {
+		name = "documento"
+	}
+	fileID, err := h.ai.UploadFile(ctx, data, name)
+	if err != nil {
+		log.Printf("openai upload document error (%s): %v", phone, err)
+		return
+	}
+	expires := time.Now().Add(time.Duration(h.cfg.DocumentFileTTLHours) * time.Hour)
+	if err := models.InsertThreadFile(ctx, h.pool, clientID, fileID, name, len(data), expires); err != nil {
+		log.Printf("db insert thread file error: %v", err)
+	}
+	h.staged.Update(phone, func(ids []string, _ bool) ([]string, bool) {
+		return append(ids, fileID), true
+	})
+}
+
+// addUserMessage envia a mensagem do usuário à thread com os documentos pendentes
+// do telefone anexados.
+func (h *WebhookHandler) addUserMessage(ctx context.Context, threadID, phone, prompt string) error {
+	files, _ := h.staged.LoadAndDelete(phone)
+	if len(files) == 0 {
+		return h.ai.AddUserMessage(ctx, threadID, prompt)
+	}
+	if err := h.ai.AddUserMessageWithFiles(ctx, threadID, prompt, files); err != nil {
+		return err
+	}
+	if err := models.MarkThreadFilesAttached(ctx, h.pool, files); err != nil {
+		log.Printf("db mark thread files error: %v", err)
+	}
+	return nil
+}
+
+// fileCleanupLoop apaga na OpenAI os documentos vencidos até o ctx ser cancelado.
+func (h *WebhookHandler) fileCleanupLoop(ctx context.Context) {
+	ctx = h.scope(ctx)
+	t := time.NewTicker(fileCleanupInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-t.C:
+			name := fmt.Sprintf("document_files:%d", h.tenantID)
+			if _, err := h.sched.Once(ctx, name, tick.Truncate(fileCleanupInterval), h.deleteExpiredFiles); err != nil {
+				log.Printf("document files cleanup error: %v", err)
+			}
+		}
+	}
+}
+
+// deleteExpiredFiles apaga os documentos vencidos do tenant.
+func (h *WebhookHandler) deleteExpiredFiles(ctx context.Context) error {
+	for {
+		files, err := models.ListExpiredThreadFiles(ctx, h.pool, 100)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return nil
+		}
+		for _, f := range files {
+			if err := h.ai.DeleteFile(ctx, f.FileID); err != nil {
+				return fmt.Errorf("delete file %s: %w", f.FileID, err)
+			}
+			if err := models.MarkThreadFileDeleted(ctx, h.pool, f.FileID); err != nil {
+				return err
+			}
+		}
+		log.Printf("document files: %d expired files deleted", len(files))
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/your-org/leandro-agent/internal/document"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+// summarizeDocument extrai o texto do anexo (PDF, DOCX, XLSX, CSV ou TXT) e o
+// resume para o assistente. Sem resumo, segue o começo do texto extraído.
+func (h *WebhookHandler) summarizeDocument(ctx context.Context, data []byte, mimetype, filename string) string {
+	extracted, kind, err := document.Extract(ctx, data, mimetype, filename)
+	switch {
+	case errors.Is(err, document.ErrUnsupported):
+		log.Printf("document: unsupported format (mimetype=%q name=%q)", mimetype, filename)
+		extracted = "(formato de documento não suportado)"
+	case errors.Is(err, document.ErrNoText):
+		extracted = "(documento sem texto)"
+	case err != nil:
+		log.Printf("document %s extract error: %v", kind, err)
+		extracted = "(não foi possível extrair texto do documento)"
+	case extracted == "":
+		extracted = "(documento sem texto)"
+	}
+	summary, err := h.ai.SummarizeText(ctx, extracted)
+	if err != nil {
+		if len(extracted) > 4000 {
+			extracted = extracted[:4000]
+		}
+		return processor.SanitizeText(removeRefs(extracted))
+	}
+	return processor.SanitizeText(removeRefs("Resumo do documento: " + summary))
+}
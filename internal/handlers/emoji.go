@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// reactionEmoji lê o emoji de uma reação ("👍" ou {"text":"👍","key":{...}}).
+// Vazio quando a reação foi removida.
+func reactionEmoji(content json.RawMessage) string {
+	var s string
+	if json.Unmarshal(content, &s) == nil {
+		return s
+	}
+	var r struct {
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal(content, &r)
+	return r.Text
+}
+
+// handleEmoji trata reações e mensagens só com emoji sem passar pela IA (a mensagem
+// já foi gravada com type "reaction"/"emoji" e entra nas métricas). No modo reply,
+// um emoji (não reação) recebe EMOJI_REPLY, no máx. 1 vez por cooldown.
+func (h *WebhookHandler) handleEmoji(ctx context.Context, client models.Client, phone, kind, emoji string) {
+	log.Printf("%s from %s: %s", kind, phone, emoji)
+	if kind != "emoji" || h.cfg.EmojiAction != "reply" || h.cfg.EmojiReply == "" {
+		return
+	}
+	cooldown := time.Duration(h.cfg.FallbackCooldownMinutes) * time.Minute
+	if !h.fallbacks.allow(phone+"|emoji", cooldown) {
+		return
+	}
+	if _, err := h.deliverText(ctx, client.ID, phone, h.cfg.EmojiReply); err != nil {
+		log.Println("uazapi send emoji reply error:", err)
+	}
+}
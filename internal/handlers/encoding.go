@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Limites do corpo do webhook: comprimido (como chega) e já descomprimido.
+// O segundo protege contra "zip bombs".
+const (
+	maxWebhookBody         = 4 << 20
+	maxWebhookDecompressed = 8 << 20
+)
+
+var (
+	errBodyTooLarge        = errors.New("body too large")
+	errUnsupportedEncoding = errors.New("unsupported content-encoding")
+)
+
+// readBody lê o corpo aplicando o Content-Encoding (gzip, deflate, br; em cadeia se
+// houver mais de um). Sem cabeçalho, detecta gzip pelo magic number, pois alguns
+// gateways comprimem sem avisar.
+func readBody(r *http.Request) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		return raw, err
+	}
+	if len(raw) > maxWebhookBody {
+		return nil, errBodyTooLarge
+	}
+
+	var encs []string
+	for _, e := range strings.Split(r.Header.Get("Content-Encoding"), ",") {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" && e != "identity" {
+			encs = append(encs, e)
+		}
+	}
+	if len(encs) == 0 && len(raw) > 2 && raw[0] == 0x1f && raw[1] == 0x8b {
+		encs = []string{"gzip"}
+	}
+
+	// Os encodings são aplicados na ordem listada; desfaz do último para o primeiro.
+	for i := len(encs) - 1; i >= 0; i-- {
+		if raw, err = decompress(encs[i], raw); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+func decompress(enc string, data []byte) ([]byte, error) {
+	var rd io.Reader
+	switch enc {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		defer zr.Close()
+		rd = zr
+	case "deflate":
+		// "deflate" deveria ser zlib, mas há servidores que mandam deflate cru.
+		if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+			defer zr.Close()
+			rd = zr
+		} else {
+			fr := flate.NewReader(bytes.NewReader(data))
+			defer fr.Close()
+			rd = fr
+		}
+	case "br":
+		rd = brotli.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, enc)
+	}
+	out, err := io.ReadAll(io.LimitReader(rd, maxWebhookDecompressed+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", enc, err)
+	}
+	if len(out) > maxWebhookDecompressed {
+		return nil, errBodyTooLarge
+	}
+	return out, nil
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Enriquecimento pelo CRM.
+
+No primeiro contato (e depois a cada ENRICH_CACHE_HOURS) a run consulta ENRICH_URL:
+
+	POST {"phone":"5511999999999","tenant_id":0}
+	200  {"name":"Maria","tier":"gold","open_orders":2}   campos do cliente
+	404                                                    telefone desconhecido
+
+Os campos viram atributos do cliente (client_facts), o instantâneo fica em
+clients.crm e entra no contexto da run; "name" também preenche o nome do cliente
+se ainda não houver. A consulta tem prazo curto (ENRICH_TIMEOUT_MS): estourado
+ou com erro, a run segue sem os dados e o telefone só é consultado de novo após
+o cooldown dos avisos (FALLBACK_COOLDOWN_MINUTES).
+*/
+
+// maxEnrichFields limita os campos aceitos do CRM (o resto é ignorado).
+const maxEnrichFields = 30
+
+// enrichClient consulta o CRM se o cliente nunca foi enriquecido ou o
+// instantâneo venceu.
+func (h *WebhookHandler) enrichClient(ctx context.Context, client models.Client) {
+	if h.cfg.EnrichURL == "" {
+		return
+	}
+	_, at, err := models.ClientCRM(ctx, h.pool, client.ID)
+	if err != nil {
+		log.Printf("crm load error: %v", err)
+		return
+	}
+	if at != nil && (h.cfg.EnrichCacheHours <= 0 || time.Since(*at) < time.Duration(h.cfg.EnrichCacheHours)*time.Hour) {
+		return
+	}
+	cooldown := time.Duration(h.cfg.FallbackCooldownMinutes) * time.Minute
+	if !h.fallbacks.allow(client.Phone+"|enrich", cooldown) {
+		return
+	}
+
+	fields, err := h.lookupCRM(ctx, client.Phone)
+	if err != nil {
+		log.Printf("crm lookup %s error: %v", client.Phone, err)
+		return
+	}
+	if err := models.SaveClientCRM(ctx, h.pool, client.ID, fields, fields["name"]); err != nil {
+		log.Printf("db save crm error: %v", err)
+		return
+	}
+	if len(fields) == 0 {
+		return
+	}
+	if err := models.UpsertClientFacts(ctx, h.pool, client.ID, fields); err != nil {
+		log.Printf("db crm attributes error: %v", err)
+	}
+	log.Printf("client %s enriched from crm (%d fields)", client.Phone, len(fields))
+}
+
+// lookupCRM faz a consulta. Telefone desconhecido (404) devolve mapa vazio.
+func (h *WebhookHandler) lookupCRM(ctx context.Context, phone string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.cfg.EnrichTimeoutMs)*time.Millisecond)
+	defer cancel()
+	body, _ := json.Marshal(map[string]any{"phone": phone, "tenant_id": h.tenantID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.EnrichURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.cfg.EnrichToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.cfg.EnrichToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var raw map[string]any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+	if raw == nil {
+		return nil, errors.New("empty response")
+	}
+	return crmFields(raw), nil
+}
+
+// crmFields achata o retorno do CRM em atributos texto (objetos e listas viram JSON).
+func crmFields(raw map[string]any) map[string]string {
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := map[string]string{}
+	for _, k := range keys {
+		key := strings.TrimSpace(k)
+		if key == "" || len(out) >= maxEnrichFields {
+			continue
+		}
+		var v string
+		switch t := raw[k].(type) {
+		case nil:
+			continue
+		case string:
+			v = strings.TrimSpace(t)
+		default:
+			b, _ := json.Marshal(t)
+			v = string(b)
+		}
+		if v != "" {
+			out[key] = v
+		}
+	}
+	return out
+}
+
+// crmContext devolve a linha do contexto da run com os dados do CRM.
+func (h *WebhookHandler) crmContext(ctx context.Context, clientID int64) string {
+	if h.cfg.EnrichURL == "" {
+		return ""
+	}
+	fields, _, err := models.ClientCRM(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("run context crm error: %v", err)
+		return ""
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + ": " + fields[k]
+	}
+	return "- Dados do CRM: " + strings.Join(parts, "; ")
+}
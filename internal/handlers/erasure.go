@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/retention"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+Exclusão e exportação dos dados de um cliente (LGPD).
+
+O cliente pede com "apagar meus dados" (comando apagar) e recebe um código de 4
+dígitos; respondendo o código em até ERASURE_CONFIRM_MINUTES, os dados são
+exportados (enviados a ele como documento JSON, com ERASURE_SEND_EXPORT) e apagados:
+thread e documentos na OpenAI, cadastro, mensagens e tudo que depende dele, e as
+linhas guardadas pelo telefone (falhas, fila, abuso). Cada pedido fica em
+data_erasures (quem pediu, quando, quantas linhas) e em purge_audit.
+
+	GET  /admin/clients/{phone}/export    todos os dados do cliente em JSON (admin)
+	POST /admin/clients/{phone}/erasure   {"confirm": "whatsapp"} pede confirmação ao cliente;
+	                                      {"confirm": "now"} exporta e apaga já (admin)
+	GET  /admin/erasures?limit=           pedidos e exclusões concluídas (admin)
+*/
+
+var errNoClient = errors.New("client not found")
+
+// clientExport é o arquivo entregue ao cliente e ao admin.
+type clientExport struct {
+	Phone      string                       `json:"phone"`
+	ExportedAt time.Time                    `json:"exported_at"`
+	Tables     map[string][]json.RawMessage `json:"tables"`
+}
+
+func (h *WebhookHandler) exportClient(ctx context.Context, client models.Client) ([]byte, error) {
+	tables, err := models.ExportClient(ctx, h.pool, client)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(clientExport{Phone: client.Phone, ExportedAt: time.Now().UTC(), Tables: tables}, "", "  ")
+}
+
+// requestErasure abre um pedido com código de confirmação e devolve o texto que
+// pede a confirmação ao cliente.
+func (h *WebhookHandler) requestErasure(ctx context.Context, client models.Client, phone, requestedBy string) (string, error) {
+	code := fmt.Sprintf("%04d", rand.IntN(10000))
+	expires := time.Now().Add(time.Duration(h.cfg.ErasureConfirmMinutes) * time.Minute)
+	if _, err := models.CreateErasure(ctx, h.pool, models.Erasure{
+		Phone: phone, RequestedBy: requestedBy, Status: "pending", Code: code, ExpiresAt: &expires,
+	}); err != nil {
+		return "", err
+	}
+	log.Printf("data erasure requested for %s (by %s)", phone, requestedBy)
+	if _, err := h.messages.Insert(ctx, models.Message{ClientID: client.ID, Role: "system", Type: "data_erasure", Content: "exclusão de dados solicitada (" + requestedBy + ")"}); err != nil {
+		log.Printf("db insert data erasure request error: %v", err)
+	}
+	text := h.cfg.ChatCommandReplies["apagar"]
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, code)
+	}
+	return text, nil
+}
+
+// confirmErasure trata a resposta com o código de um pedido pendente; a exclusão
+// roda em background. Devolve false se o texto não confirma nada.
+func (h *WebhookHandler) confirmErasure(ctx context.Context, client models.Client, phone, text string) bool {
+	code := strings.TrimSpace(text)
+	if len(code) != 4 {
+		return false
+	}
+	if _, err := strconv.Atoi(code); err != nil {
+		return false
+	}
+	e, ok, err := models.PendingErasure(ctx, h.pool, phone)
+	if err != nil {
+		log.Printf("db pending erasure error: %v", err)
+		return false
+	}
+	if !ok || e.Code != code {
+		return false
+	}
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		defer h.recoverWorker(ctx, "erasure")
+		if _, err := h.erase(ctx, e, client, h.cfg.ErasureSendExport); err != nil {
+			h.fail(phone, "data erasure", err)
+		}
+	}()
+	return true
+}
+
+// erase exporta (enviando a cópia ao cliente se sendExport) e apaga os dados do
+// cliente, registrando o resultado no pedido e.
+func (h *WebhookHandler) erase(ctx context.Context, e models.Erasure, client models.Client, sendExport bool) (int64, error) {
+	exported := false
+	if sendExport {
+		data, err := h.exportClient(ctx, client)
+		if err == nil {
+			m := models.Message{ClientID: client.ID, Type: "document", Content: "Cópia dos seus dados"}
+			_, err = h.deliver(ctx, client.Phone, m, func(ctx context.Context) (uazapi.SendResult, error) {
+				return h.wpp.SendMediaWithCaption(ctx, client.Phone, "document", data, "Cópia dos seus dados")
+			})
+		}
+		if err != nil {
+			// sem a cópia, não apaga: o cliente pode pedir de novo
+			_ = models.FinishErasure(ctx, h.pool, e.ID, "failed", false, 0, "export: "+err.Error())
+			return 0, fmt.Errorf("export: %w", err)
+		}
+		exported = true
+	}
+	n, err := retention.EraseClient(ctx, h.pool, h.ai, client, fmt.Sprintf("erasure #%d (%s)", e.ID, e.RequestedBy))
+	if err != nil {
+		_ = models.FinishErasure(ctx, h.pool, e.ID, "failed", exported, n, err.Error())
+		return n, err
+	}
+	if err := models.FinishErasure(ctx, h.pool, e.ID, "completed", exported, n, ""); err != nil {
+		log.Printf("db finish erasure error: %v", err)
+	}
+	log.Printf("data of %s erased (%d rows)", client.Phone, n)
+	if text := h.cfg.ChatCommandReplies["apagar_done"]; text != "" && e.RequestedBy == "client" {
+		h.sendErasureNotice(ctx, e.ID, client.Phone, text)
+	}
+	return n, nil
+}
+
+// sendErasureNotice avisa o cliente que os dados foram apagados. O cadastro e o
+// histórico não existem mais, então as duas fases do envio (outbox.go) ficam no
+// próprio pedido (data_erasures.notice_status).
+func (h *WebhookHandler) sendErasureNotice(ctx context.Context, id int64, phone, text string) {
+	if err := models.MarkErasureNotice(ctx, h.pool, id, models.MessagePending, ""); err != nil {
+		log.Printf("db erasure notice error: %v", err)
+	}
+	res, err := h.wpp.SendText(ctx, phone, text)
+	dbctx := context.WithoutCancel(ctx)
+	if err != nil {
+		log.Println("uazapi send erasure done error:", err)
+		if merr := models.MarkErasureNotice(dbctx, h.pool, id, models.MessageFailed, ""); merr != nil {
+			log.Printf("db erasure notice error: %v", merr)
+		}
+		return
+	}
+	if merr := models.MarkErasureNotice(dbctx, h.pool, id, models.MessageSent, res.MessageID); merr != nil {
+		log.Printf("db erasure notice error: %v", merr)
+	}
+}
+
+// ClientExportHandler expõe GET /admin/clients/{phone}/export.
+func (h *WebhookHandler) ClientExportHandler() http.Handler {
+	return h.auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+		client, ok, err := h.clients.ByPhone(ctx, r.PathValue("phone"))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, errNoClient.Error(), http.StatusNotFound)
+			return
+		}
+		data, err := h.exportClient(ctx, client)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "export error", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+client.Phone+`.json"`)
+		_, _ = w.Write(data)
+	}))
+}
+
+// ErasureHandler expõe POST /admin/clients/{phone}/erasure.
+func (h *WebhookHandler) ErasureHandler() http.Handler {
+	return h.auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+		var in struct {
+			Confirm string `json:"confirm"`
+		}
+		if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil || (in.Confirm != "whatsapp" && in.Confirm != "now") {
+			http.Error(w, `body must be {"confirm": "whatsapp" | "now"}`, http.StatusBadRequest)
+			return
+		}
+		client, ok, err := h.clients.ByPhone(ctx, r.PathValue("phone"))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, errNoClient.Error(), http.StatusNotFound)
+			return
+		}
+		if in.Confirm == "whatsapp" {
+			text, err := h.requestErasure(ctx, client, client.Phone, "admin")
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			h.sendCommandReply(ctx, client.ID, client.Phone, text)
+			writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "status": "pending"})
+			return
+		}
+		e := models.Erasure{Phone: client.Phone, RequestedBy: "admin", Status: "pending"}
+		if e.ID, err = models.CreateErasure(ctx, h.pool, e); err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		n, err := h.erase(ctx, e, client, false)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "erasure error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "status": "completed", "erasure_id": e.ID, "affected": n})
+	}))
+}
+
+// ErasuresHandler expõe GET /admin/erasures.
+func (h *WebhookHandler) ErasuresHandler() http.Handler {
+	return h.auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+		list, err := models.ListErasures(ctx, h.pool, limit)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}))
+}
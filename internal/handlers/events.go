@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/feed"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/trace"
+)
+
+// publish envia um evento ao barramento interno. O feed ao vivo e demais módulos
+// reagem via assinatura, sem o pipeline conhecê-los.
+func (h *WebhookHandler) publish(ctx context.Context, ev events.Event) {
+	if h.events == nil {
+		return
+	}
+	ev.Tenant = h.tenantID
+	if ev.RequestID == "" {
+		ev.RequestID = trace.RequestID(ctx)
+	}
+	h.events.Publish(ctx, ev)
+}
+
+// subscribeEvents liga os consumidores internos ao barramento.
+func (h *WebhookHandler) subscribeEvents() {
+	if h.events == nil || h.feed == nil {
+		return
+	}
+	h.events.Subscribe(events.All, func(ctx context.Context, ev events.Event) {
+		if fe, ok := feedEvent(ev); ok {
+			fe.Tags = h.feedTags(ctx, fe)
+			h.feed.Publish(fe)
+		}
+	})
+}
+
+// feedTags resolve as tags do cliente do evento, só quando algum assinante do
+// feed filtra por tag (a consulta roda na goroutine do assinante, fora do pipeline).
+func (h *WebhookHandler) feedTags(ctx context.Context, fe feed.Event) []string {
+	if fe.Phone == "" || !h.feed.WantsTags() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(models.WithTenant(ctx, fe.Tenant), 2*time.Second)
+	defer cancel()
+	tags, err := models.ClientTagsByPhone(ctx, h.pool, fe.Phone)
+	if err != nil {
+		log.Printf("feed tags %s: %v", fe.Phone, err)
+		return nil
+	}
+	return tags
+}
+
+// feedEvent converte um evento do barramento no formato do feed dos operadores.
+func feedEvent(ev events.Event) (feed.Event, bool) {
+	fe := feed.Event{Tenant: ev.Tenant, Phone: ev.Phone, Direction: "outbound", Role: ev.Role, Type: ev.Type, Content: ev.Content, ExtID: ev.ExtID, At: ev.At}
+	switch ev.Topic {
+	case events.MessageReceived, events.CallReceived:
+		fe.Direction = "inbound"
+	case events.ReplySent:
+	case events.RunFailed:
+		fe.Role, fe.Type, fe.Content = "system", "failure", ev.Stage+": "+ev.Error
+	case events.HandoffRequested, events.ConversationTransferred, events.BudgetAlert, events.NoteAdded, events.SLOAlert, events.LeadCreated:
+		fe.Role = "system"
+	default:
+		return feed.Event{}, false
+	}
+	return fe, true
+}
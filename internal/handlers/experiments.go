@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/experiment"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// experimentArm devolve a variante do experimento ativo para o cliente (bucket fixo)
+// e a tag gravada nas mensagens e no consumo. Tag vazia fora de experimento.
+func (h *WebhookHandler) experimentArm(ctx context.Context, clientID int64) (models.ExperimentVariant, string) {
+	e, ok, err := models.ActiveExperiment(ctx, h.pool)
+	if err != nil {
+		log.Printf("experiment load error: %v", err)
+		return models.ExperimentVariant{}, ""
+	}
+	if !ok {
+		return models.ExperimentVariant{}, ""
+	}
+	name, err := models.AssignVariant(ctx, h.pool, e.ID, clientID, experiment.Pick(e, clientID))
+	if err != nil {
+		log.Printf("experiment assign error: %v", err)
+		return models.ExperimentVariant{}, ""
+	}
+	v, ok := e.Variant(name)
+	if !ok {
+		// variante removida do experimento: o cliente segue com o padrão, sem tag
+		return models.ExperimentVariant{}, ""
+	}
+	return v, e.Tag(name)
+}
+
+// NewExperimentsHandler cadastra os experimentos do tenant:
+//
+//	GET  /admin/experiments
+//	POST /admin/experiments {"name":"prompt-curto","active":true,"variants":[
+//	        {"name":"controle","weight":90},
+//	        {"name":"curto","weight":10,"instructions":"Responda em no máximo 2 frases."},
+//	        {"name":"novo","weight":10,"assistant_id":"asst_..."}]}
+//
+// POST com nome existente atualiza; ativar um experimento desativa os outros.
+func NewExperimentsHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	list := auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out, err := models.ListExperiments(r.Context(), pool)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}))
+	save := auth.Require(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in models.Experiment
+		if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if err := experiment.Validate(in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		saved, err := models.UpsertExperiment(r.Context(), pool, in)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		p, _ := principalFrom(r.Context())
+		log.Printf("experiment %s saved by %s (active=%v)", saved.Name, p.Name, saved.Active)
+		writeJSON(w, http.StatusOK, saved)
+	}))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			list.ServeHTTP(w, r)
+		case http.MethodPost, http.MethodPut:
+			save.ServeHTTP(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// NewExperimentMetricsHandler expõe GET /admin/experiments/{name}/metrics: por
+// variante, clientes, tamanho médio das respostas, CSAT e taxa de handoff.
+func NewExperimentMetricsHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	return auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		e, ok, err := models.GetExperimentByName(ctx, pool, r.PathValue("name"))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, "experiment not found", http.StatusNotFound)
+			return
+		}
+		metrics, err := models.ExperimentMetrics(ctx, pool, e)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"experiment": e, "variants": metrics})
+	}))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/state"
+)
+
+// Categorias de falha com aviso ao cliente (textos em cfg.FallbackMessages).
+const (
+	fallbackTranscription = "transcription_failed"
+	fallbackDocumentLarge = "document_too_large"
+	fallbackMedia         = "media_failed"
+	fallbackBusy          = "system_busy"
+	fallbackEmptyReply    = "empty_reply"
+	fallbackInternal      = "internal_error"
+)
+
+var errDocumentTooLarge = errors.New("document too large")
+
+// fallbackLimiter evita repetir o mesmo aviso ao cliente dentro do cooldown.
+type fallbackLimiter struct {
+	last state.Map[string, time.Time]
+}
+
+func (l *fallbackLimiter) allow(key string, cooldown time.Duration) bool {
+	now := time.Now()
+	allowed := false
+	l.last.Update(key, func(t time.Time, ok bool) (time.Time, bool) {
+		if ok && now.Sub(t) < cooldown {
+			return t, true
+		}
+		allowed = true
+		return now, true
+	})
+	if allowed {
+		// limpeza preguiçosa para o mapa não crescer indefinidamente
+		l.last.DeleteFunc(func(k string, t time.Time) bool { return k != key && now.Sub(t) >= cooldown })
+	}
+	return allowed
+}
+
+// notifyFailure envia ao cliente o texto amigável da categoria. O erro técnico
+// continua só no log/failures (via h.fail).
+func (h *WebhookHandler) notifyFailure(ctx context.Context, clientID int64, phone, category string) {
+	text := h.cfg.FallbackMessages[category]
+	if text == "" || phone == "" {
+		return
+	}
+	cooldown := time.Duration(h.cfg.FallbackCooldownMinutes) * time.Minute
+	if !h.fallbacks.allow(phone+"|"+category, cooldown) {
+		return
+	}
+	var err error
+	if clientID > 0 {
+		_, err = h.deliverText(ctx, clientID, phone, text)
+	} else {
+		// sem cliente no banco não há histórico a gravar
+		_, err = h.wpp.SendText(ctx, phone, text)
+	}
+	if err != nil {
+		log.Println("uazapi send fallback error:", err)
+	}
+}
+
+// failAndNotify registra a falha e avisa o cliente.
+func (h *WebhookHandler) failAndNotify(clientID int64, phone, stage, category string, err error) {
+	h.fail(phone, stage, err)
+	h.recordLatency(context.Background(), clientID, phone, false)
+	h.statuses.fail(phone, stage)
+	h.notifyFailure(context.Background(), clientID, phone, category)
+}
+
+// normalizeFallback escolhe a categoria do aviso para uma falha de normalização.
+func normalizeFallback(messageType string, err error) string {
+	if errors.Is(err, errDocumentTooLarge) {
+		return fallbackDocumentLarge
+	}
+	switch messageType {
+	case "audiomessage", "audio", "pttmessage":
+		return fallbackTranscription
+	}
+	return fallbackMedia
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/feed"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/ws"
+)
+
+// NewFeedHandler expõe o feed ao vivo de conversas via WebSocket.
+// Filtros opcionais: ?phone=5511999999999,5511888888888 e ?tags=vip,lead (clientes com
+// ao menos uma das tags). Chaves de um tenant só veem o próprio tenant.
+func NewFeedHandler(auth *Auth, hub *feed.Hub) http.Handler {
+	return auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := ws.Upgrade(w, r)
+		if err != nil {
+			log.Printf("feed upgrade error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		filter := feed.ParsePhones(r.URL.Query().Get("phone")).ParseTags(r.URL.Query().Get("tags"), models.NormalizeTag)
+		if tenant, ok := models.TenantFrom(r.Context()); ok {
+			filter.Tenants = map[int64]bool{tenant: true}
+		}
+		events, cancel := hub.Subscribe(filter)
+		defer cancel()
+
+		done := make(chan struct{})
+		go func() {
+			_ = conn.ReadLoop()
+			close(done)
+		}()
+
+		ping := time.NewTicker(30 * time.Second)
+		defer ping.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ping.C:
+				if err := conn.Ping(); err != nil {
+					return
+				}
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				b, _ := json.Marshal(ev)
+				if err := conn.WriteText(b); err != nil {
+					return
+				}
+			}
+		}
+	}))
+}
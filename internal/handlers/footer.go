@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+// withFooter anexa a assinatura (REPLY_FOOTER) à resposta em texto. Não repete se
+// o assistente já assinou e pula clientes com a tag REPLY_FOOTER_SKIP_TAG.
+func (h *WebhookHandler) withFooter(ctx context.Context, client models.Client, reply string) string {
+	if h.cfg.ReplyFooter == "" || strings.TrimSpace(reply) == "" {
+		return reply
+	}
+	if tag := models.NormalizeTag(h.cfg.ReplyFooterSkipTag); tag != "" {
+		tags, err := models.ListClientTags(ctx, h.pool, client.ID)
+		if err != nil {
+			log.Printf("footer tags load error: %v", err)
+		} else if slices.Contains(tags, tag) {
+			return reply
+		}
+	}
+	vars := h.replyVars(client)
+	vars["variante"] = models.VariantFrom(ctx)
+	footer := processor.Interpolate(h.cfg.ReplyFooter, vars)
+	if footer == "" || strings.HasSuffix(strings.TrimSpace(reply), footer) {
+		return reply
+	}
+	return reply + "\n\n" + footer
+}
+
+// variantMarker devolve o sufixo invisível com a variante do experimento da run
+// (REPLY_VARIANT_MARKER); vazio fora de experimentos.
+func (h *WebhookHandler) variantMarker(ctx context.Context) string {
+	if !h.cfg.ReplyVariantMarker {
+		return ""
+	}
+	return processor.VariantMarker(models.VariantFrom(ctx))
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// readContextInfo lê as flags de encaminhamento do contextInfo da mensagem
+// ({"text":"...","contextInfo":{"isForwarded":true,"forwardingScore":5}}), para
+// payloads que não as trazem no topo.
+func (m *incomingMessage) readContextInfo(content json.RawMessage) {
+	var c struct {
+		ContextInfo struct {
+			IsForwarded     bool `json:"isForwarded"`
+			ForwardingScore int  `json:"forwardingScore"`
+		} `json:"contextInfo"`
+	}
+	if len(content) == 0 || content[0] != '{' || json.Unmarshal(content, &c) != nil {
+		return
+	}
+	m.IsForwarded = m.IsForwarded || c.ContextInfo.IsForwarded
+	m.ForwardScore = max(m.ForwardScore, c.ContextInfo.ForwardingScore)
+}
+
+// isChainMessage indica uma mensagem "encaminhada com frequência" (corrente).
+func (h *WebhookHandler) isChainMessage(msg incomingMessage) bool {
+	return h.cfg.ForwardedChainScore > 0 && msg.ForwardScore >= h.cfg.ForwardedChainScore
+}
+
+// forwardedContext avisa o assistente de que o cliente não escreveu o texto, só o encaminhou.
+func forwardedContext(msg incomingMessage) string {
+	if msg.ForwardScore >= 5 {
+		return "[mensagem encaminhada com frequência] "
+	}
+	return "[mensagem encaminhada] "
+}
+
+// handleChainMessage trata uma corrente sem passar pela IA: no modo reply envia
+// FORWARDED_CHAIN_REPLY (no máx. 1 vez por cooldown); no modo ack só registra.
+func (h *WebhookHandler) handleChainMessage(ctx context.Context, client models.Client, phone string) {
+	log.Printf("chain message from %s (%s)", phone, h.cfg.ForwardedChainAction)
+	if h.cfg.ForwardedChainAction != "reply" || h.cfg.ForwardedChainReply == "" {
+		return
+	}
+	cooldown := time.Duration(h.cfg.FallbackCooldownMinutes) * time.Minute
+	if !h.fallbacks.allow(phone+"|forwarded", cooldown) {
+		return
+	}
+	if _, err := h.deliverText(ctx, client.ID, phone, h.cfg.ForwardedChainReply); err != nil {
+		log.Println("uazapi send forwarded reply error:", err)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+const greetingInstruction = "Você já cumprimentou este cliente recentemente: não comece a resposta com saudações " +
+	"como \"Olá! Como posso ajudar?\"; vá direto ao assunto."
+
+// greetedRecently indica se o assistente cumprimentou o cliente dentro de GREETING_WINDOW_HOURS.
+func (h *WebhookHandler) greetedRecently(ctx context.Context, clientID int64) bool {
+	if h.cfg.GreetingWindowHours <= 0 {
+		return false
+	}
+	at, err := models.LastGreetedAt(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("greeting load error: %v", err)
+		return false
+	}
+	return at != nil && time.Since(*at) < time.Duration(h.cfg.GreetingWindowHours)*time.Hour
+}
+
+// throttleGreeting remove a saudação da resposta se o cliente já foi cumprimentado
+// na janela; caso contrário, registra quando a resposta abre com uma saudação.
+func (h *WebhookHandler) throttleGreeting(ctx context.Context, clientID int64, recent bool, reply string) string {
+	if h.cfg.GreetingWindowHours <= 0 {
+		return reply
+	}
+	if recent {
+		if out, ok := processor.StripGreeting(reply); ok {
+			return out
+		}
+		return reply
+	}
+	if processor.StartsWithGreeting(reply) {
+		if err := models.MarkGreeted(ctx, h.pool, clientID); err != nil {
+			log.Printf("greeting save error: %v", err)
+		}
+	}
+	return reply
+}
+
+// joinInstructions junta blocos de additional_instructions não vazios.
+func joinInstructions(parts ...string) string {
+	out := ""
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		if out != "" {
+			out += "\n\n"
+		}
+		out += p
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/phone"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+/*
+Payload nativo (estável e versionado) aceito em POST /api/v1/inbound.
+Serve para n8n, Make ou scripts empurrarem mensagens no mesmo pipeline sem imitar o formato da Uazapi.
+
+	{
+	  "v": 1,                          // obrigatório; versões desconhecidas são rejeitadas
+	  "phone": "5511999999999",        // obrigatório; apenas dígitos (DDI+DDD+número)
+	  "name": "Maria",                 // opcional
+	  "id": "crm-123",                 // opcional; vira ext_id da mensagem
+	  "type": "text",                  // text | audio | image | document (default text)
+	  "text": "Olá!",                  // obrigatório para type=text
+	  "media_url": "https://..."       // obrigatório para audio/image/document
+	}
+
+Autenticação: "Authorization: Bearer <INGEST_TOKEN>".
+Resposta: {"ok":true} ou {"ok":true,"queued":"maintenance"}.
+*/
+
+const nativeSchemaVersion = 1
+
+type nativeInbound struct {
+	V        int    `json:"v"`
+	Phone    string `json:"phone"`
+	Name     string `json:"name"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	MediaURL string `json:"media_url"`
+}
+
+func (n *nativeInbound) validate() error {
+	if n.V != nativeSchemaVersion {
+		return fmt.Errorf("unsupported schema version %d", n.V)
+	}
+	n.Phone = strings.TrimSpace(n.Phone)
+	if !phone.Valid(n.Phone) {
+		return errors.New("phone must be 10-15 digits")
+	}
+	n.Type = strings.ToLower(strings.TrimSpace(n.Type))
+	if n.Type == "" {
+		n.Type = "text"
+	}
+	switch n.Type {
+	case "text":
+		if strings.TrimSpace(n.Text) == "" {
+			return errors.New("text is required")
+		}
+	case "audio", "image", "document":
+		if !strings.HasPrefix(n.MediaURL, "http://") && !strings.HasPrefix(n.MediaURL, "https://") {
+			return errors.New("media_url is required for " + n.Type)
+		}
+	default:
+		return errors.New("unknown type " + n.Type)
+	}
+	return nil
+}
+
+var mediaHTTP = &http.Client{Timeout: 60 * time.Second}
+
+// fetchMedia baixa a mídia de uma URL externa (limite de 25 MiB).
+func fetchMedia(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := mediaHTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetch media status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 25<<20))
+}
+
+// normalizeNative converte o payload nativo em texto para o LLM (mesma lógica de normalizeInput).
+func (h *WebhookHandler) normalizeNative(ctx context.Context, clientID int64, n nativeInbound) (string, error) {
+	switch n.Type {
+	case "audio":
+		data, err := fetchMedia(ctx, n.MediaURL)
+		if err != nil {
+			return "", err
+		}
+		t, err := h.transcribe(ctx, data, "audio.ogg")
+		if err != nil {
+			return "", err
+		}
+		return processor.SanitizeText(removeRefs(t)), nil
+	case "image":
+		desc, err := h.ai.VisionDescribe(ctx, n.MediaURL)
+		if err != nil {
+			return "", err
+		}
+		// o id do canal nativo não serve para baixar pela Uazapi: fica só a URL
+		tag := h.rememberImage(ctx, clientID, "", n.MediaURL, desc)
+		return processor.SanitizeText(removeRefs(withImageTag("Descrição da imagem", tag) + ": " + desc)), nil
+	case "document":
+		data, err := fetchMedia(ctx, n.MediaURL)
+		if err != nil {
+			return "", err
+		}
+		name := n.MediaURL
+		if u, err := url.Parse(n.MediaURL); err == nil {
+			name = u.Path
+		}
+		h.stageDocument(ctx, clientID, n.Phone, data, name)
+		return h.summarizeDocument(ctx, data, "", name), nil
+	default:
+		return processor.SanitizeText(removeRefs(n.Text)), nil
+	}
+}
+
+// IngestHandler expõe POST /api/v1/inbound com o payload nativo versionado.
+func (h *WebhookHandler) IngestHandler() http.Handler {
+	return requireToken(h.cfg.IngestToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := h.scope(r.Context())
+
+		var in nativeInbound
+		if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		if err := in.validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+
+		var namePtr *string
+		if in.Name != "" {
+			namePtr = &in.Name
+		}
+		client, err := h.clients.GetOrCreate(ctx, in.Phone, namePtr)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+
+		text, err := h.normalizeNative(ctx, client.ID, in)
+		if err != nil {
+			writeErr(w, http.StatusBadGateway, "normalize error", err)
+			return
+		}
+
+		m := models.Message{ClientID: client.ID, Role: "user", Type: in.Type, Content: text}
+		if in.ID != "" {
+			m.ExtID = &in.ID
+		}
+		if in.Type == "text" || in.Type == "audio" {
+			text, m.Language, m.Translated = h.translateInbound(ctx, client.ID, in.Phone, text)
+		}
+		h.saveMessage(ctx, in.Phone, m)
+
+		queued, err := h.dispatchInbound(ctx, in.Phone, text, in.Type)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if queued {
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true, "queued": "maintenance"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	}))
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/intent"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Respostas por intenção (INTENTS_ENABLED).
+
+Perguntas determinísticas ("qual o horário?", "onde fica a loja?", "manda a tabela
+de preços") são respondidas na hora a partir de intent_rules, sem a run da
+OpenAI. A resposta aceita as variáveis das respostas ({{nome}}, BUSINESS_VARS) e,
+com INTENT_ADD_TO_THREAD, pergunta e resposta entram na thread para o assistente
+ter o contexto depois. Cada resposta soma em hits/last_hit_at da regra.
+
+	GET    /admin/intents                 regras com os acertos (analyst)
+	POST   /admin/intents                 {"name","keywords":["horario","funciona*"],"pattern","reply","priority","active"} (operator)
+	GET    /admin/intents/{id}            (analyst)
+	PUT    /admin/intents/{id}            substitui os campos (operator)
+	DELETE /admin/intents/{id}            (operator)
+	POST   /admin/intents/test {"text"}   qual regra responderia (analyst)
+*/
+
+// intentCacheTTL limita quanto tempo uma réplica usa regras desatualizadas.
+const intentCacheTTL = 30 * time.Second
+
+// intentCache guarda as regras compiladas do tenant.
+type intentCache struct {
+	mu       sync.Mutex
+	matcher  *intent.Matcher
+	loadedAt time.Time
+}
+
+func (c *intentCache) invalidate() {
+	c.mu.Lock()
+	c.matcher = nil
+	c.mu.Unlock()
+}
+
+// intentMatcher devolve as regras ativas do tenant, recarregando após intentCacheTTL.
+func (h *WebhookHandler) intentMatcher(ctx context.Context) *intent.Matcher {
+	c := &h.intents
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.matcher != nil && time.Since(c.loadedAt) < intentCacheTTL {
+		return c.matcher
+	}
+	rules, err := models.ListIntentRules(ctx, h.pool, true)
+	if err != nil {
+		log.Printf("intent rules load error: %v", err)
+		return c.matcher // mantém as anteriores (nil = nenhuma)
+	}
+	c.matcher, c.loadedAt = intent.Compile(rules, h.cfg.IntentMaxWords), time.Now()
+	return c.matcher
+}
+
+// answerIntent responde a mensagem por uma regra, sem passar pela IA. Devolve
+// false se nenhuma regra casa (ou há mensagens no buffer: a resposta sairia fora
+// de ordem).
+func (h *WebhookHandler) answerIntent(ctx context.Context, client models.Client, phone, text string) bool {
+	if !h.cfg.IntentsEnabled || h.bufMgr.Has(phone) {
+		return false
+	}
+	rule, ok := h.intentMatcher(ctx).Match(text)
+	if !ok {
+		return false
+	}
+	reply := strings.TrimSpace(h.interpolateReply(client, rule.Reply))
+	if reply == "" {
+		return false
+	}
+	if _, err := h.deliverText(ctx, client.ID, phone, reply); err != nil {
+		h.fail(phone, "uazapi send intent reply", err)
+		return false // segue para o assistente
+	}
+	if err := models.RecordIntentHit(ctx, h.pool, rule.ID); err != nil {
+		log.Printf("db intent hit error: %v", err)
+	}
+	log.Printf("intent %q answered %s without the assistant", rule.Name, phone)
+
+	if h.cfg.IntentAddToThread && client.ThreadID != nil && *client.ThreadID != "" {
+		go h.threadIntent(context.WithoutCancel(ctx), phone, *client.ThreadID, text, reply)
+	}
+	return true
+}
+
+// threadIntent registra pergunta e resposta na thread. Com run ativa a OpenAI
+// recusa: fica só no histórico.
+func (h *WebhookHandler) threadIntent(ctx context.Context, phone, threadID, text, reply string) {
+	defer h.recoverWorker(ctx, "intent thread")
+	if err := h.ai.AddUserMessage(ctx, threadID, text); err != nil {
+		log.Printf("intent thread error (%s): %v", phone, err)
+		return
+	}
+	if err := h.ai.AddAssistantMessage(ctx, threadID, reply); err != nil {
+		log.Printf("intent thread error (%s): %v", phone, err)
+	}
+}
+
+// intentInput é o corpo de POST/PUT em /admin/intents.
+type intentInput struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+	Pattern  string   `json:"pattern"`
+	Reply    string   `json:"reply"`
+	Priority int      `json:"priority"`
+	Active   *bool    `json:"active"`
+}
+
+// rule valida a entrada; a mensagem de erro vai direto na resposta 400.
+func (in intentInput) rule() (models.IntentRule, string) {
+	r := models.IntentRule{
+		Name: strings.TrimSpace(in.Name), Pattern: strings.TrimSpace(in.Pattern), Reply: strings.TrimSpace(in.Reply),
+		Priority: in.Priority, Active: true, Keywords: []string{},
+	}
+	if in.Active != nil {
+		r.Active = *in.Active
+	}
+	for _, kw := range in.Keywords {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			r.Keywords = append(r.Keywords, kw)
+		}
+	}
+	switch {
+	case r.Name == "" || r.Reply == "":
+		return r, "name and reply are required"
+	case len(r.Keywords) == 0 && r.Pattern == "":
+		return r, "keywords or pattern required"
+	case len(r.Name) > 100 || len(r.Reply) > 4000 || len(r.Pattern) > 500 || len(r.Keywords) > 50:
+		return r, "name, reply, pattern or keywords too long"
+	}
+	if err := intent.ValidPattern(r.Pattern); err != nil {
+		return r, "invalid pattern: " + err.Error()
+	}
+	return r, ""
+}
+
+// IntentsHandler expõe /admin/intents (ver comentário do arquivo).
+func (h *WebhookHandler) IntentsHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+		test := strings.HasSuffix(r.URL.Path, "/test")
+		if r.Method != http.MethodGet && !test && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var id int64
+		if raw := r.PathValue("id"); raw != "" {
+			var err error
+			if id, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch {
+		case test && r.Method == http.MethodPost:
+			var in struct {
+				Text string `json:"text"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			rules, err := models.ListIntentRules(ctx, h.pool, true)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			rule, ok := intent.Compile(rules, h.cfg.IntentMaxWords).Match(in.Text)
+			if !ok {
+				writeJSON(w, http.StatusOK, map[string]any{"matched": false})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"matched": true, "rule": rule})
+
+		case r.Method == http.MethodGet && id == 0:
+			rules, err := models.ListIntentRules(ctx, h.pool, false)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, rules)
+
+		case r.Method == http.MethodGet:
+			rule, ok, err := models.GetIntentRule(ctx, h.pool, id)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !ok {
+				http.Error(w, "intent not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, rule)
+
+		case r.Method == http.MethodPost && id == 0, r.Method == http.MethodPut && id != 0:
+			var in intentInput
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			rule, msg := in.rule()
+			if msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			var (
+				saved models.IntentRule
+				err   error
+				ok    = true
+			)
+			if id == 0 {
+				saved, err = models.CreateIntentRule(ctx, h.pool, rule)
+			} else {
+				rule.ID = id
+				saved, ok, err = models.UpdateIntentRule(ctx, h.pool, rule)
+			}
+			switch {
+			case errors.Is(err, models.ErrDuplicateIntent):
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case err != nil:
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			case !ok:
+				http.Error(w, "intent not found", http.StatusNotFound)
+				return
+			}
+			h.intents.invalidate()
+			p, _ := principalFrom(ctx)
+			log.Printf("intent %q saved by %s", saved.Name, p.Name)
+			status := http.StatusOK
+			if id == 0 {
+				status = http.StatusCreated
+			}
+			writeJSON(w, status, saved)
+
+		case r.Method == http.MethodDelete && id != 0:
+			ok, err := models.DeleteIntentRule(ctx, h.pool, id)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !ok {
+				http.Error(w, "intent not found", http.StatusNotFound)
+				return
+			}
+			h.intents.invalidate()
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/phone"
+)
+
+// resolvePhone identifica o cliente da mensagem.
+//
+// Ordem: chatid/sender com JID de telefone → sender_pn → LID mapeado em lid_map →
+// o próprio LID ("123@lid") quando o telefone ainda é desconhecido. Sempre que LID
+// e telefone aparecem juntos, o mapeamento é gravado.
+// Com JID_STRICT=true não há busca de JID no corpo bruto e o telefone deve ter 10-15 dígitos.
+func (h *WebhookHandler) resolvePhone(ctx context.Context, msg incomingMessage, raw []byte) (string, bool) {
+	strict := h.cfg.JIDStrict
+
+	var lid string
+	for _, cand := range []string{msg.ChatID, msg.Sender, msg.SenderLID, msg.ChatLID} {
+		if l, ok := phone.LID(cand); ok {
+			lid = l
+			break
+		}
+	}
+
+	number, ok := phone.FromJID(msg.ChatID)
+	if !ok && msg.Sender != "" {
+		number, ok = phone.FromJID(msg.Sender)
+	}
+	if !ok && msg.SenderPN != "" {
+		if p, pok := phone.FromJID(msg.SenderPN); pok {
+			number, ok = p, true
+		} else if d := phone.Digits(msg.SenderPN); d != "" {
+			number, ok = d, true
+		}
+	}
+	if !ok && lid == "" && !strict {
+		if jid, found := phone.FindJID(string(raw)); found {
+			if p, pok := phone.FromJID(jid); pok {
+				number, ok = p, true
+			} else if l, lok := phone.LID(jid); lok {
+				lid = l
+			}
+		}
+	}
+	if ok && strict && !phone.Valid(number) {
+		ok = false
+	}
+
+	if ok {
+		if lid != "" {
+			if err := models.SaveLID(ctx, h.pool, lid, number); err != nil {
+				log.Printf("db save lid error: %v", err)
+			}
+		}
+		return number, true
+	}
+	if lid == "" {
+		return "", false
+	}
+	if p, found, err := models.LookupLID(ctx, h.pool, lid); err != nil {
+		log.Printf("db lookup lid error: %v", err)
+	} else if found {
+		return p, true
+	}
+	// Só o LID é conhecido: ele vira a identidade do cliente (e o destino dos envios).
+	return lid, true
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// NewJobsHandler expõe GET /admin/jobs: situação dos jobs periódicos (digest,
+// reengajamento, retenção, CSAT) entre as réplicas — quem detém o lease, última
+// janela, resultado e erro.
+func NewJobsHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	return auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jobs, err := models.ListScheduledJobs(r.Context(), pool)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, jobs)
+	}))
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Fila das runs com faixas de prioridade (RUN_WORKERS).
+
+Sem RUN_WORKERS cada flush do buffer vira uma run na hora, como antes. Com
+RUN_WORKERS > 0 as runs de todos os tenants passam por uma fila com três faixas,
+escolhidas pelas tags do cliente:
+  - priority: QUEUE_PRIORITY_TAGS (default "vip,pago") — clientes pagantes;
+  - bulk:     QUEUE_BULK_TAGS (ex.: "campanha") — tráfego de campanhas;
+  - standard: o resto (e clientes ainda sem cadastro).
+
+Os RUN_WORKERS workers pegam sempre a faixa mais alta com fila; num pico as
+runs pagantes saem primeiro. Proteção contra inanição: a run que espera há mais
+de QUEUE_MAX_WAIT_SECONDS passa na frente (a mais antiga primeiro), e a faixa
+conta a promoção. Dentro da faixa a ordem é de chegada.
+
+As runs na fila contam como pendentes na contrapressão (BACKPRESSURE_MAX_PENDING).
+GET /admin/webhook/load traz "lanes": fila, em andamento, atendidas, promovidas e
+espera média/máxima por faixa desde o start.
+*/
+
+// Faixas da fila, da mais alta para a mais baixa.
+const (
+	lanePriority = "priority"
+	laneStandard = "standard"
+	laneBulk     = "bulk"
+)
+
+var laneOrder = []string{lanePriority, laneStandard, laneBulk}
+
+type laneJob struct {
+	enqueued time.Time
+	run      func()
+}
+
+// laneStats são as métricas de uma faixa, expostas no /admin/webhook/load.
+type laneStats struct {
+	Queued    int   `json:"queued"`
+	Running   int   `json:"running"`
+	Done      int64 `json:"done"`
+	Promoted  int64 `json:"promoted"` // atendidas pela proteção contra inanição
+	WaitAvgMs int64 `json:"wait_avg_ms"`
+	WaitMaxMs int64 `json:"wait_max_ms"`
+
+	waitTotal time.Duration
+}
+
+// runQueue é a fila das runs do processo (compartilhada pelos tenants).
+type runQueue struct {
+	maxWait time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	lanes map[string][]laneJob
+	stats map[string]*laneStats
+}
+
+// newRunQueue inicia os workers; nil sem RUN_WORKERS (runs na hora).
+func newRunQueue(cfg config.Config) *runQueue {
+	if cfg.RunWorkers <= 0 {
+		return nil
+	}
+	q := &runQueue{
+		maxWait: time.Duration(cfg.QueueMaxWaitSeconds) * time.Second,
+		now:     time.Now,
+		lanes:   map[string][]laneJob{},
+		stats:   map[string]*laneStats{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	for _, l := range laneOrder {
+		q.stats[l] = &laneStats{}
+	}
+	for i := 0; i < cfg.RunWorkers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// push põe a run na faixa.
+func (q *runQueue) push(lane string, run func()) {
+	q.mu.Lock()
+	q.lanes[lane] = append(q.lanes[lane], laneJob{enqueued: q.now(), run: run})
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// next espera e tira a próxima run: a que passou de maxWait (a mais antiga) ou a
+// primeira da faixa mais alta com fila.
+func (q *runQueue) next() (string, laneJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.lenLocked() == 0 {
+		q.cond.Wait()
+	}
+	now := q.now()
+	lane, top := "", ""
+	for _, l := range laneOrder {
+		jobs := q.lanes[l]
+		if len(jobs) == 0 {
+			continue
+		}
+		if top == "" {
+			top = l
+		}
+		if now.Sub(jobs[0].enqueued) >= q.maxWait && (lane == "" || jobs[0].enqueued.Before(q.lanes[lane][0].enqueued)) {
+			lane = l
+		}
+	}
+	if lane == "" {
+		lane = top
+	} else if lane != top {
+		q.stats[lane].Promoted++
+	}
+	st := q.stats[lane]
+	job := q.lanes[lane][0]
+	q.lanes[lane] = q.lanes[lane][1:]
+
+	wait := now.Sub(job.enqueued)
+	st.Running++
+	st.waitTotal += wait
+	if ms := wait.Milliseconds(); ms > st.WaitMaxMs {
+		st.WaitMaxMs = ms
+	}
+	return lane, job
+}
+
+func (q *runQueue) work() {
+	for {
+		lane, job := q.next()
+		q.runJob(lane, job)
+	}
+}
+
+// runJob roda a run (o flush já recupera panics da conversa; este recover
+// mantém o worker vivo em qualquer caso).
+func (q *runQueue) runJob(lane string, job laneJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("run queue panic (%s lane): %v", lane, r)
+		}
+		q.mu.Lock()
+		st := q.stats[lane]
+		st.Running--
+		st.Done++
+		q.mu.Unlock()
+	}()
+	job.run()
+}
+
+func (q *runQueue) lenLocked() int {
+	n := 0
+	for _, jobs := range q.lanes {
+		n += len(jobs)
+	}
+	return n
+}
+
+// Len devolve quantas runs aguardam worker.
+func (q *runQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lenLocked()
+}
+
+// Stats devolve uma cópia das métricas por faixa.
+func (q *runQueue) Stats() map[string]laneStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make(map[string]laneStats, len(q.stats))
+	for l, st := range q.stats {
+		s := *st
+		s.Queued = len(q.lanes[l])
+		if served := s.Done + int64(s.Running); served > 0 {
+			s.WaitAvgMs = (s.waitTotal / time.Duration(served)).Milliseconds()
+		}
+		out[l] = s
+	}
+	return out
+}
+
+// runLane escolhe a faixa da conversa pelas tags do cliente.
+func (h *WebhookHandler) runLane(ctx context.Context, phone string) string {
+	if len(h.cfg.QueuePriorityTags) == 0 && len(h.cfg.QueueBulkTags) == 0 {
+		return laneStandard
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	tags, err := models.ClientTagsByPhone(ctx, h.pool, phone)
+	if err != nil {
+		log.Printf("db client tags (run lane) error: %v", err)
+		return laneStandard
+	}
+	hasAny := func(want []string) bool {
+		return slices.ContainsFunc(want, func(t string) bool { return slices.Contains(tags, models.NormalizeTag(t)) })
+	}
+	switch {
+	case hasAny(h.cfg.QueuePriorityTags):
+		return lanePriority
+	case hasAny(h.cfg.QueueBulkTags):
+		return laneBulk
+	}
+	return laneStandard
+}
+
+// dispatchRun roda a run do flush na hora ou, com RUN_WORKERS, pela fila da faixa
+// do cliente.
+func (h *WebhookHandler) dispatchRun(phone string, run func()) {
+	if h.queue == nil {
+		go run()
+		return
+	}
+	go func() {
+		defer h.recoverWorker(context.Background(), "run lane")
+		h.queue.push(h.runLane(h.scope(context.Background()), phone), run)
+	}()
+}
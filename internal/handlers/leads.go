@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// NewLeadsHandler expõe GET /admin/leads?status=novo&phone=55...&limit=100
+// com os leads/pedidos criados pelo assistente.
+func NewLeadsHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	return auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f := models.LeadFilter{Phone: q.Get("phone"), Status: q.Get("status")}
+		if f.Status != "" && !models.ValidLeadStatus(f.Status) {
+			http.Error(w, "invalid status", http.StatusBadRequest)
+			return
+		}
+		f.Limit, _ = strconv.Atoi(q.Get("limit"))
+		leads, err := models.ListLeads(r.Context(), pool, f)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, leads)
+	}))
+}
+
+// NewLeadUpdateHandler expõe PATCH /admin/leads/{id}
+// com {"status":"ganho","note":"Fechado por telefone"}.
+func NewLeadUpdateHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	return auth.Require(RoleOperator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Status string `json:"status"`
+			Note   string `json:"note"`
+		}
+		if err := json.NewDecoder(limitBody(r)).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if !models.ValidLeadStatus(req.Status) {
+			http.Error(w, "invalid status", http.StatusBadRequest)
+			return
+		}
+		l, err := models.UpdateLead(r.Context(), pool, id, req.Status, req.Note)
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "lead not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, l)
+	}))
+}
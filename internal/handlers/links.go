@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/processor"
+	"github.com/your-org/leandro-agent/internal/unfurl"
+)
+
+// unfurlLinks acrescenta ao texto o resumo das páginas dos links enviados, para o
+// assistente não receber só a URL crua. Falhas (robots, timeout, tamanho) mantêm o texto original.
+func (h *WebhookHandler) unfurlLinks(ctx context.Context, text string) string {
+	if !h.cfg.LinkUnfurlEnabled || h.unfurl == nil {
+		return text
+	}
+	urls := unfurl.FindURLs(text, h.cfg.LinkUnfurlMaxLinks)
+	if len(urls) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	b.WriteString(text)
+	for _, u := range urls {
+		page, err := h.unfurl.Fetch(ctx, u)
+		if err != nil {
+			log.Printf("unfurl %s: %v", u, err)
+			continue
+		}
+		content := page.Text
+		if summary, err := h.ai.SummarizeText(ctx, page.Title+"\n\n"+page.Text); err == nil {
+			content = summary
+		} else if len(content) > 2000 {
+			content = content[:2000]
+		}
+		b.WriteString("\n\nConteúdo do link enviado (" + u + ")")
+		if page.Title != "" {
+			b.WriteString(" — " + page.Title)
+		}
+		b.WriteString(": " + strings.TrimSpace(content))
+	}
+	return processor.SanitizeText(removeRefs(b.String()))
+}
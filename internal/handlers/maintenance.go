@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Modo manutenção por tenant, gravado em maintenance_windows: vale para todas as
+réplicas e sobrevive a restarts (o prazo inclusive). Cada pipeline guarda uma
+cópia relida a cada maintenanceSyncInterval; quem encontra a janela vencida a
+fecha. Ao fechar, as mensagens retidas em inbound_queue são reivindicadas
+(DELETE ... SKIP LOCKED) e voltam ao buffer de uma réplica só.
+*/
+
+const maintenanceSyncInterval = 5 * time.Second
+
+// maintenance é a cópia local da janela do tenant. Enquanto ativa, as mensagens
+// recebidas vão para inbound_queue e o cliente recebe o aviso no máximo uma vez
+// por janela (maintenance_notices).
+type maintenance struct {
+	mu      sync.Mutex
+	enabled bool
+	since   time.Time
+	until   time.Time // zero = sem prazo
+	message string
+}
+
+type maintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Since   *time.Time `json:"since,omitempty"`
+	Until   *time.Time `json:"until,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+func (m *maintenance) state() maintenanceState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := maintenanceState{Enabled: m.enabled, Message: m.message}
+	if m.enabled {
+		since := m.since
+		st.Since = &since
+		if !m.until.IsZero() {
+			until := m.until
+			st.Until = &until
+		}
+	}
+	return st
+}
+
+func (m *maintenance) active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.enabled
+}
+
+// set troca a cópia local pela janela do banco e devolve se ela estava ativa.
+func (m *maintenance) set(w models.MaintenanceWindow) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	was := m.enabled
+	m.enabled, m.since, m.message = w.Enabled, w.Since, w.Message
+	m.until = time.Time{}
+	if w.Until != nil {
+		m.until = *w.Until
+	}
+	return was
+}
+
+// notice devolve o aviso e o início da janela ativa.
+func (m *maintenance) notice() (string, time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.message, m.since, m.enabled
+}
+
+// syncMaintenance relê a janela do tenant, fechando-a se o prazo passou. Devolve
+// true quando ela acabou de fechar (aqui ou em outra réplica).
+func (h *WebhookHandler) syncMaintenance(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(h.scope(ctx), 5*time.Second)
+	defer cancel()
+	w, err := models.GetMaintenance(ctx, h.pool)
+	if err == nil && w.Enabled && w.Until != nil && !w.Until.After(time.Now()) {
+		if closed, err := models.DisableMaintenance(ctx, h.pool, true); err != nil {
+			log.Printf("db maintenance expire error: %v", err)
+		} else if closed {
+			log.Println("maintenance window expired")
+		}
+		w, err = models.GetMaintenance(ctx, h.pool)
+	}
+	if err != nil {
+		log.Printf("db maintenance load error: %v", err)
+		return false
+	}
+	return h.maint.set(w) && !w.Enabled
+}
+
+// maintenanceLoop acompanha a janela e devolve as mensagens retidas ao buffer
+// quando ela fecha (também as de uma janela anterior ao restart).
+func (h *WebhookHandler) maintenanceLoop(ctx context.Context) {
+	h.drainInboundQueue(ctx)
+	t := time.NewTicker(maintenanceSyncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if h.syncMaintenance(ctx) {
+			h.drainInboundQueue(ctx)
+		}
+	}
+}
+
+// enableMaintenance liga o modo manutenção. Com d > 0, desliga sozinho ao fim do prazo.
+func (h *WebhookHandler) enableMaintenance(ctx context.Context, d time.Duration, message string) error {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = h.cfg.MaintenanceMessage
+	}
+	var until *time.Time
+	if d > 0 {
+		t := time.Now().Add(d)
+		until = &t
+	}
+	w, err := models.EnableMaintenance(h.scope(ctx), h.pool, until, message)
+	if err != nil {
+		return err
+	}
+	h.maint.set(w)
+	log.Printf("maintenance enabled (until=%v)", until)
+	return nil
+}
+
+// disableMaintenance desliga o modo manutenção e processa a fila acumulada.
+func (h *WebhookHandler) disableMaintenance(ctx context.Context) error {
+	closed, err := models.DisableMaintenance(h.scope(ctx), h.pool, false)
+	if err != nil {
+		return err
+	}
+	h.maint.set(models.MaintenanceWindow{})
+	if closed {
+		log.Println("maintenance disabled")
+	}
+	go h.drainInboundQueue(h.life)
+	return nil
+}
+
+// holdForMaintenance enfileira a mensagem e envia o aviso (uma vez por cliente por janela).
+func (h *WebhookHandler) holdForMaintenance(ctx context.Context, phone, text, kind string) error {
+	if err := models.EnqueueInbound(ctx, h.pool, phone, text, kind); err != nil {
+		return err
+	}
+	notice, since, ok := h.maint.notice()
+	if !ok || notice == "" {
+		return nil
+	}
+	go func() {
+		ctx := h.scope(context.WithoutCancel(ctx))
+		first, err := models.ClaimMaintenanceNotice(ctx, h.pool, phone, since)
+		if err != nil {
+			log.Printf("db maintenance notice error: %v", err)
+			return
+		}
+		if !first {
+			return
+		}
+		// a mensagem retida já foi gravada, então o cliente existe
+		client, ok, err := h.clients.ByPhone(ctx, phone)
+		if err != nil || !ok {
+			log.Printf("maintenance notice client %s lookup error: %v", phone, err)
+			return
+		}
+		if _, err := h.deliverText(ctx, client.ID, phone, notice); err != nil {
+			log.Println("uazapi send maintenance notice error:", err)
+		}
+	}()
+	return nil
+}
+
+// drainInboundQueue devolve ao buffer as mensagens retidas, em ordem de chegada.
+// As linhas são reivindicadas de uma vez: outra réplica drenando ao mesmo tempo
+// não recebe as mesmas mensagens.
+func (h *WebhookHandler) drainInboundQueue(ctx context.Context) {
+	ctx = h.scope(ctx)
+	defer h.recoverWorker(ctx, "inbound queue")
+	if h.maint.active() {
+		return
+	}
+	items, err := models.ClaimQueuedInbound(ctx, h.pool)
+	if err != nil {
+		log.Printf("inbound queue claim error: %v", err)
+		return
+	}
+	for _, it := range items {
+		h.bufMgr.AddMessage(it.Phone, it.Content, it.Kind)
+	}
+	if len(items) > 0 {
+		log.Printf("inbound queue drained: %d messages", len(items))
+	}
+}
+
+// MaintenanceHandler expõe GET (analyst) e POST (operator) /admin/maintenance.
+// POST {"enabled":true,"minutes":30,"message":"Voltamos já!"}
+// A janela vale para o tenant da chave (ou do cabeçalho X-Tenant).
+func (h *WebhookHandler) MaintenanceHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := h.scoped(r.Context())
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, h.maint.state())
+		case http.MethodPost:
+			if !hasRole(r, RoleOperator) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			var req struct {
+				Enabled bool   `json:"enabled"`
+				Minutes int    `json:"minutes"`
+				Message string `json:"message"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&req); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			var err error
+			if req.Enabled {
+				err = h.enableMaintenance(r.Context(), time.Duration(req.Minutes)*time.Minute, req.Message)
+			} else {
+				err = h.disableMaintenance(r.Context())
+			}
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, h.maint.state())
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
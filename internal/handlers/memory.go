@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// memoryInstructions monta as additional_instructions da run com os fatos conhecidos do cliente.
+// Assim o assistente "lembra" do usuário mesmo após reset de thread.
+func (h *WebhookHandler) memoryInstructions(ctx context.Context, clientID int64) string {
+	if !h.cfg.MemoryEnabled {
+		return ""
+	}
+	facts, err := models.GetClientFacts(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("memory load error: %v", err)
+		return ""
+	}
+	if len(facts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Fatos conhecidos sobre este cliente (de conversas anteriores):\n")
+	for _, f := range facts {
+		b.WriteString("- " + f.Key + ": " + f.Value + "\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// updateMemory extrai fatos duráveis da última troca e atualiza a tabela client_facts.
+// Roda em background após o envio da resposta; falhas só são logadas.
+func (h *WebhookHandler) updateMemory(ctx context.Context, clientID int64, userText, reply string) {
+	if !h.cfg.MemoryEnabled {
+		return
+	}
+	defer h.recoverWorker(ctx, "memory")
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	known := map[string]string{}
+	facts, err := models.GetClientFacts(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("memory load error: %v", err)
+		return
+	}
+	for _, f := range facts {
+		known[f.Key] = f.Value
+	}
+
+	conversation := "Cliente: " + userText + "\nAssistente: " + reply
+	updates, err := h.ai.ExtractFacts(ctx, conversation, known)
+	if err != nil {
+		log.Printf("memory extract error: %v", err)
+		return
+	}
+	// só grava o que mudou
+	for k, v := range updates {
+		if old, ok := known[k]; (ok && old == v) || (!ok && v == "") {
+			delete(updates, k)
+		}
+	}
+	if len(updates) == 0 {
+		return
+	}
+	if err := models.UpsertClientFacts(ctx, h.pool, clientID, updates); err != nil {
+		log.Printf("memory save error: %v", err)
+	}
+}
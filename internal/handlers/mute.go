@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/tools"
+)
+
+/*
+Silêncio pedido pelo cliente.
+
+"silenciar por 1 dia", "pausar por 2 horas", "mutar" (MUTE_DEFAULT_HOURS) ou a
+ferramenta mute_conversation (quando o pedido vem em linguagem natural) gravam
+clients.muted_until. Até lá as mensagens do cliente são registradas, mas não vão
+para o assistente nem recebem resposta; "reativar" (ou "desmutar") encerra antes.
+No fim do prazo o cliente recebe UNMUTE_MESSAGE.
+*/
+
+const unmuteScanInterval = time.Minute
+
+var (
+	muteRe   = regexp.MustCompile(`^(?:por favor,?\s*)?(?:silenci(?:ar|a|e)|mutar|mute|pausar)(?:\s+(?:o\s+)?(?:bot|rob[oô]|conversa|atendimento|mensagens))?(?:\s+(?:por|durante)\s+(\d+|um|uma|dois|duas|tr[eê]s)\s*(minutos?|min|horas?|h|dias?|semanas?))?[\s.!]*$`)
+	unmuteRe = regexp.MustCompile(`^(?:por favor,?\s*)?(?:reativ\w*|desmut\w*|dessilenci\w*|despaus\w*|voltar a responder)(?:\s+(?:o\s+)?(?:bot|rob[oô]|conversa|atendimento))?[\s.!]*$`)
+)
+
+var muteNumbers = map[string]int{"um": 1, "uma": 1, "dois": 2, "duas": 2, "três": 3, "tres": 3}
+
+// parseMute reconhece os comandos de silêncio. mute=true com a duração pedida (0 =
+// padrão); unmute=true para reativar.
+func parseMute(text string) (d time.Duration, mute, unmute bool) {
+	t := strings.ToLower(strings.TrimSpace(text))
+	if unmuteRe.MatchString(t) {
+		return 0, false, true
+	}
+	m := muteRe.FindStringSubmatch(t)
+	if m == nil {
+		return 0, false, false
+	}
+	if m[1] == "" {
+		return 0, true, false
+	}
+	n, ok := muteNumbers[m[1]]
+	if !ok {
+		n, _ = strconv.Atoi(m[1])
+	}
+	unit := time.Hour
+	switch {
+	case strings.HasPrefix(m[2], "min"):
+		unit = time.Minute
+	case strings.HasPrefix(m[2], "d"):
+		unit = 24 * time.Hour
+	case strings.HasPrefix(m[2], "s"):
+		unit = 7 * 24 * time.Hour
+	}
+	return time.Duration(n) * unit, true, false
+}
+
+// muteClient silencia o bot para o cliente. d <= 0 usa MUTE_DEFAULT_HOURS; o prazo
+// é limitado a MUTE_MAX_DAYS.
+func (h *WebhookHandler) muteClient(ctx context.Context, clientID int64, d time.Duration) (time.Time, error) {
+	if d <= 0 {
+		d = time.Duration(h.cfg.MuteDefaultHours) * time.Hour
+	}
+	d = min(d, time.Duration(h.cfg.MuteMaxDays)*24*time.Hour)
+	until := time.Now().Add(d)
+	return until, models.SetClientMute(ctx, h.pool, clientID, &until)
+}
+
+// handleMuteCommand trata "silenciar"/"reativar" sem passar pela IA. Devolve false
+// se o texto não é um comando.
+func (h *WebhookHandler) handleMuteCommand(ctx context.Context, client models.Client, phone, text string) bool {
+	if !h.cfg.MuteEnabled {
+		return false
+	}
+	d, mute, unmute := parseMute(text)
+	switch {
+	case unmute:
+		if !h.isMuted(ctx, client.ID) {
+			return false // sem silêncio ativo, "reativar" segue para o assistente
+		}
+		if err := models.SetClientMute(ctx, h.pool, client.ID, nil); err != nil {
+			h.fail(phone, "db unmute", err)
+			return true
+		}
+		log.Printf("client %s unmuted the bot", phone)
+		h.sendMuteText(ctx, client.ID, phone, h.cfg.UnmuteMessage)
+	case mute:
+		until, err := h.muteClient(ctx, client.ID, d)
+		if err != nil {
+			h.fail(phone, "db mute", err)
+			return true
+		}
+		log.Printf("client %s muted the bot until %s", phone, until.Format(time.RFC3339))
+		h.sendMuteText(ctx, client.ID, phone, h.muteReply(until))
+	default:
+		return false
+	}
+	return true
+}
+
+// isMuted indica se o cliente silenciou o bot (mensagens só são registradas).
+func (h *WebhookHandler) isMuted(ctx context.Context, clientID int64) bool {
+	if !h.cfg.MuteEnabled {
+		return false
+	}
+	until, err := models.ClientMutedUntil(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("mute load error: %v", err)
+		return false
+	}
+	return until != nil
+}
+
+// muteReply formata MUTE_REPLY com o fim do silêncio no fuso do negócio.
+func (h *WebhookHandler) muteReply(until time.Time) string {
+	if !strings.Contains(h.cfg.MuteReply, "%s") {
+		return h.cfg.MuteReply
+	}
+	return fmt.Sprintf(h.cfg.MuteReply, until.In(h.cfg.Location()).Format("02/01 às 15:04"))
+}
+
+func (h *WebhookHandler) sendMuteText(ctx context.Context, clientID int64, phone, text string) {
+	if text == "" {
+		return
+	}
+	if _, err := h.deliverText(ctx, clientID, phone, text); err != nil {
+		log.Println("uazapi send mute reply error:", err)
+	}
+}
+
+// registerMuteTool deixa o assistente silenciar a conversa quando o pedido vem em
+// linguagem natural ("me deixa em paz até amanhã"). A resposta da run confirma.
+func (h *WebhookHandler) registerMuteTool() {
+	if !h.cfg.MuteEnabled {
+		return
+	}
+	h.tools.Register(tools.Tool{
+		Def: openai.FunctionTool{
+			Name:        "mute_conversation",
+			Description: "Silencia o atendimento automático para este cliente quando ele pedir para não receber respostas por um tempo. As mensagens dele continuam registradas.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"hours": map[string]any{"type": "number", "description": "Por quantas horas silenciar (ex.: 24 para um dia)"},
+				},
+			},
+		},
+		Run: func(ctx context.Context, call tools.Call, args json.RawMessage) (any, error) {
+			var in struct {
+				Hours float64 `json:"hours"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			until, err := h.muteClient(ctx, call.ClientID, time.Duration(in.Hours*float64(time.Hour)))
+			if err != nil {
+				return nil, err
+			}
+			log.Printf("client %s muted the bot until %s (assistant)", call.Phone, until.Format(time.RFC3339))
+			return map[string]any{"ok": true, "muted_until": until.In(h.cfg.Location()).Format("02/01/2006 15:04")}, nil
+		},
+	})
+}
+
+// unmuteLoop avisa os clientes cujo silêncio acabou até o ctx ser cancelado.
+func (h *WebhookHandler) unmuteLoop(ctx context.Context) {
+	ctx = h.scope(ctx)
+	t := time.NewTicker(unmuteScanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-t.C:
+			name := fmt.Sprintf("unmute:%d", h.tenantID)
+			if _, err := h.sched.Once(ctx, name, tick.Truncate(unmuteScanInterval), h.notifyUnmuted); err != nil {
+				log.Printf("unmute scan error: %v", err)
+			}
+		}
+	}
+}
+
+// notifyUnmuted encerra os silêncios vencidos e avisa cada cliente.
+func (h *WebhookHandler) notifyUnmuted(ctx context.Context) error {
+	expired, err := models.ClearExpiredMutes(ctx, h.pool, 50)
+	if err != nil {
+		return err
+	}
+	for _, m := range expired {
+		h.sendMuteText(ctx, m.ClientID, m.Phone, h.cfg.UnmuteMessage)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+/*
+Notas internas do assistente. As instruções do assistente podem pedir anotações
+para a equipe ou para ele mesmo, fora do texto enviado ao cliente:
+
+	Claro, te envio o orçamento ainda hoje. <note>cliente quer orçamento de 50 unidades</note>
+	<note key="interesse">plano anual</note>
+
+Cada nota vira uma mensagem role "system" / type "note" (aparece no feed e no
+histórico); com key, também atualiza o atributo do cliente (client_facts), que volta
+nas próximas runs junto com a memória.
+*/
+
+// saveNotes persiste as notas extraídas da resposta.
+func (h *WebhookHandler) saveNotes(ctx context.Context, clientID int64, phone string, notes []processor.Note) {
+	attrs := map[string]string{}
+	for _, n := range notes {
+		if _, err := h.messages.Insert(ctx, models.Message{ClientID: clientID, Role: "system", Type: "note", Content: n.Text}); err != nil {
+			log.Printf("db insert note error: %v", err)
+		}
+		h.publish(ctx, events.Event{Topic: events.NoteAdded, Phone: phone, ClientID: clientID, Role: "system", Type: "note", Content: n.Text})
+		if n.Key != "" {
+			attrs[n.Key] = n.Text
+		}
+	}
+	if len(attrs) == 0 {
+		return
+	}
+	if err := models.UpsertClientFacts(ctx, h.pool, clientID, attrs); err != nil {
+		log.Printf("db note attributes error: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+POST /admin/conversations/{phone}/reply — um atendente humano responde pelo número do
+agente; para o cliente, a mensagem chega como se fosse do assistente.
+
+	{
+	  "text": "Oi! Aqui é a Ana, vou te ajudar com o pedido.",
+	  "add_to_thread": true   // também grava na thread da OpenAI como fala do assistente
+	}
+
+A mensagem fica no histórico com role "operator". Papel mínimo: operator.
+Com o header "Idempotency-Key" a mesma resposta não é enviada duas vezes
+(clique duplo no painel): a repetição devolve o message_id do primeiro envio.
+*/
+type operatorReply struct {
+	Text        string `json:"text"`
+	AddToThread bool   `json:"add_to_thread"`
+}
+
+// OperatorReplyHandler expõe POST /admin/conversations/{phone}/reply.
+func (h *WebhookHandler) OperatorReplyHandler() http.Handler {
+	return h.auth.Require(RoleOperator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := r.Context()
+		h := h.scoped(ctx)
+
+		var in operatorReply
+		if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		in.Text = strings.TrimSpace(in.Text)
+		if in.Text == "" {
+			http.Error(w, "text required", http.StatusBadRequest)
+			return
+		}
+		client, ok, err := h.clients.ByPhone(ctx, r.PathValue("phone"))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, "client not found", http.StatusNotFound)
+			return
+		}
+
+		m := models.Message{ClientID: client.ID, Role: "operator", Type: "text", Content: in.Text}
+		sendCtx := withSendKey(ctx, strings.TrimSpace(r.Header.Get("Idempotency-Key")))
+		res, err := h.deliver(sendCtx, client.Phone, m, func(ctx context.Context) (uazapi.SendResult, error) {
+			return h.wpp.SendText(ctx, client.Phone, in.Text)
+		})
+		if errors.Is(err, errSendInFlight) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			writeErr(w, http.StatusBadGateway, "send error", err)
+			return
+		}
+
+		p, _ := principalFrom(ctx)
+		log.Printf("operator reply to %s by %s", client.Phone, p.Name)
+
+		// Sem thread ainda não há contexto a completar; com run ativa a OpenAI recusa
+		// a mensagem — o envio ao cliente já aconteceu, então só informa.
+		threaded := false
+		if in.AddToThread && client.ThreadID != nil && *client.ThreadID != "" {
+			if err := h.ai.AddAssistantMessage(ctx, *client.ThreadID, in.Text); err != nil {
+				log.Printf("operator reply thread error (%s): %v", client.Phone, err)
+			} else {
+				threaded = true
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "message_id": res.MessageID, "threaded": threaded})
+	}))
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+// NewOutboundHandler expõe GET /admin/outbound: fila de envio de cada instância
+// (enviadas no último minuto e no dia, limite da rampa, respostas e envios em massa
+// esperando). O estado é do processo, então a rota é só da chave raiz.
+func NewOutboundHandler(auth *Auth) http.Handler {
+	return auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, uazapi.Shapers())
+	}))
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+Envio em duas fases.
+
+Toda mensagem de saída passa por deliver: primeiro é gravada no histórico com
+status "pending", depois a Uazapi é chamada e a linha vai para "sent" (com o id
+do provedor) ou "failed" (com o erro). Os recibos do webhook (handleReceipt)
+avançam para delivered/read/played; um status nunca volta atrás. A exceção é o
+aviso de exclusão de dados concluída: sem cadastro, as fases ficam no pedido
+(data_erasures.notice_status).
+
+Com chave de envio (withSendKey; em /api/send o header Idempotency-Key) o envio
+é único: repetir a chamada depois de aceita devolve o mesmo message_id sem
+reenviar; só um envio que falhou é tentado de novo. Mensagens presas em
+"pending" além de SEND_PENDING_TIMEOUT_MINUTES (o processo parou entre gravar e
+enviar) viram "failed" e não são reenviadas: não dá para saber se chegaram.
+
+GET /admin/messages/status?hours=24[&status=failed][&limit=100] — contagem por
+status e as últimas mensagens (as com falha, por padrão).
+*/
+
+// errSendInFlight: outra chamada com a mesma chave de envio ainda está enviando.
+var errSendInFlight = errors.New("send with the same key in progress")
+
+const stalePendingInterval = time.Minute
+
+type sendKeyKey struct{}
+
+// withSendKey torna idempotentes os envios feitos com ctx (ver deliver).
+func withSendKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sendKeyKey{}, key)
+}
+
+// sendKey devolve a chave de envio de ctx, separada por tenant.
+func (h *WebhookHandler) sendKey(ctx context.Context) string {
+	key, _ := ctx.Value(sendKeyKey{}).(string)
+	if key == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d:%s", h.tenantID, key)
+}
+
+// deliver envia m em duas fases: grava como pending, chama send e registra o
+// resultado. Role vazio vale "assistant". Sem banco o envio segue e o histórico
+// é gravado depois, como antes.
+func (h *WebhookHandler) deliver(ctx context.Context, phone string, m models.Message, send func(context.Context) (uazapi.SendResult, error)) (uazapi.SendResult, error) {
+	if m.Role == "" {
+		m.Role = "assistant"
+	}
+	stored := h.redactMessage(withReplyTranslation(ctx, m))
+	rec, claimed, err := models.ClaimMessageSend(ctx, h.pool, stored, h.sendKey(ctx))
+	if err != nil {
+		log.Printf("db claim message send error: %v", err)
+		res, err := send(ctx)
+		if err == nil {
+			h.saveMessage(ctx, phone, withSendResult(m, res))
+		}
+		return res, err
+	}
+	if !claimed {
+		if rec.Done() && rec.ExtID != nil {
+			log.Printf("send to %s skipped: message %d already %s", phone, rec.ID, rec.Status)
+			return uazapi.SendResult{MessageID: *rec.ExtID}, nil
+		}
+		return uazapi.SendResult{}, errSendInFlight
+	}
+
+	res, err := send(ctx)
+	// o resultado é gravado mesmo que a requisição que pediu o envio tenha acabado
+	dbctx := context.WithoutCancel(ctx)
+	if err != nil {
+		if ferr := models.MarkMessageFailed(dbctx, h.pool, rec.ID, err); ferr != nil {
+			log.Printf("db mark message failed error: %v", ferr)
+		}
+		return res, err
+	}
+	var at *time.Time
+	if !res.Timestamp.IsZero() {
+		at = &res.Timestamp
+	}
+	if merr := models.MarkMessageSent(dbctx, h.pool, rec.ID, res.MessageID, at); merr != nil {
+		log.Printf("db mark message sent error: %v", merr)
+	}
+	h.publish(ctx, events.Event{Topic: events.ReplySent, Phone: phone, ClientID: m.ClientID, Role: stored.Role, Type: stored.Type, Content: stored.Content, ExtID: res.MessageID})
+	return res, nil
+}
+
+// Deliver é deliver para envios de fora do pipeline (reengajamento).
+func (h *WebhookHandler) Deliver(ctx context.Context, phone string, m models.Message, send func(context.Context) (uazapi.SendResult, error)) (uazapi.SendResult, error) {
+	return h.deliver(ctx, phone, m, send)
+}
+
+// withSendResult completa m com o id e o horário devolvidos pela Uazapi.
+func withSendResult(m models.Message, res uazapi.SendResult) models.Message {
+	out := outboundMessage(m.ClientID, m.Type, m.Content, res)
+	m.ExtID, m.ProviderAt = out.ExtID, out.ProviderAt
+	return m
+}
+
+// updateMessageStatus aplica um recibo às mensagens enviadas com o id (com ou
+// sem o prefixo "owner:").
+func (h *WebhookHandler) updateMessageStatus(ctx context.Context, id, status string) {
+	switch status {
+	case models.MessageSent, models.MessageDelivered, models.MessageRead, models.MessagePlayed, models.MessageFailed:
+	default:
+		return
+	}
+	n, err := models.AdvanceMessageStatus(ctx, h.pool, id, status)
+	if err == nil && n == 0 {
+		if bare := bareMessageID(id); bare != id {
+			_, err = models.AdvanceMessageStatus(ctx, h.pool, bare, status)
+		}
+	}
+	if err != nil {
+		log.Printf("db message status error: %v", err)
+	}
+}
+
+// stalePendingLoop marca como falha os envios presos em "pending" (uma réplica por vez).
+func (h *WebhookHandler) stalePendingLoop(ctx context.Context) {
+	t := time.NewTicker(stalePendingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-t.C:
+			if _, err := h.sched.Once(ctx, "stale_pending_messages", tick.Truncate(stalePendingInterval), h.failStalePending); err != nil {
+				log.Printf("stale pending messages error: %v", err)
+			}
+		}
+	}
+}
+
+func (h *WebhookHandler) failStalePending(ctx context.Context) error {
+	n, err := models.FailStaleMessages(ctx, h.pool, time.Duration(h.cfg.SendPendingTimeoutMinutes)*time.Minute)
+	if n > 0 {
+		log.Printf("%d outbound messages stuck in pending marked as failed", n)
+	}
+	return err
+}
+
+// MessageStatusHandler expõe GET /admin/messages/status.
+func (h *WebhookHandler) MessageStatusHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		q := r.URL.Query()
+		hours, _ := strconv.Atoi(q.Get("hours"))
+		if hours <= 0 || hours > 24*90 {
+			hours = 24
+		}
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+		status := q.Get("status")
+		if !q.Has("status") {
+			status = models.MessageFailed
+		}
+		since := time.Now().Add(-time.Duration(hours) * time.Hour)
+		counts, err := models.MessageStatusCounts(ctx, h.pool, since)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		list, err := models.ListMessageSends(ctx, h.pool, status, since, limit)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"since": since, "counts": counts, "messages": list})
+	}))
+}
+
+// deliverText envia text ao cliente com deliver (mensagem "text" do assistente).
+func (h *WebhookHandler) deliverText(ctx context.Context, clientID int64, phone, text string) (uazapi.SendResult, error) {
+	return h.deliver(ctx, phone, models.Message{ClientID: clientID, Type: "text", Content: text}, func(ctx context.Context) (uazapi.SendResult, error) {
+		return h.wpp.SendText(ctx, phone, text)
+	})
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/catalog"
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/payments"
+	"github.com/your-org/leandro-agent/internal/phone"
+	"github.com/your-org/leandro-agent/internal/processor"
+	"github.com/your-org/leandro-agent/internal/trace"
+)
+
+/*
+Webhooks de pagamento.
+
+	POST /webhook/payments/stripe        Stripe-Signature (STRIPE_WEBHOOK_SECRET)
+	POST /webhook/payments/mercadopago   x-signature (MERCADOPAGO_WEBHOOK_SECRET); o pagamento é
+	                                     consultado na API com MERCADOPAGO_ACCESS_TOKEN
+	POST /webhook/payments/pix[/pix]     ?token=PIX_WEBHOOK_TOKEN (padrão Bacen; o PSP acrescenta /pix)
+	GET  /admin/payments?phone=&limit=   pagamentos registrados (analyst)
+
+Tenant: ?tenant_token= na URL cadastrada no provedor (sem ele, tenant padrão).
+
+O pagamento é ligado ao cliente pela referência de uma ação pendente (/api/send
+com "pending": reference = external_reference / client_reference_id / txid) ou,
+sem ela, pelo telefone do pagador (metadata.phone na Stripe e no Mercado Pago).
+Cliente desconhecido: o pagamento só fica registrado.
+
+Pagamento aprovado encerra a ação pendente, avisa o cliente (PAYMENT_REPLY, uma
+vez por pagamento mesmo com retentativas do provedor), deixa uma nota interna na
+thread (o assistente passa a saber que está pago) e publica payment.received.
+*/
+
+// PaymentWebhookHandler expõe POST /webhook/payments/{provider}.
+func (h *WebhookHandler) PaymentWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := h.scope(r.Context())
+		provider := r.PathValue("provider")
+		if provider == "" {
+			provider = "pix" // POST .../pix/pix
+		}
+		body, err := io.ReadAll(limitBody(r))
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+
+		var list []payments.Payment
+		switch provider {
+		case "stripe":
+			if h.cfg.StripeWebhookSecret == "" {
+				http.NotFound(w, r)
+				return
+			}
+			if err := payments.VerifyStripe(body, r.Header.Get("Stripe-Signature"), h.cfg.StripeWebhookSecret, time.Now()); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			list, err = payments.ParseStripe(body)
+
+		case "mercadopago":
+			if h.cfg.MercadoPagoWebhookSecret == "" || h.cfg.MercadoPagoAccessToken == "" {
+				http.NotFound(w, r)
+				return
+			}
+			// um só id: o assinado é o consultado e aplicado
+			id, ok, err := payments.MercadoPagoNotification(body, r.URL.Query())
+			if err != nil {
+				http.Error(w, "payment id mismatch", http.StatusUnauthorized)
+				return
+			}
+			if !ok {
+				writeJSON(w, http.StatusOK, map[string]any{"ok": true, "ignored": "not a payment"})
+				return
+			}
+			if err := payments.VerifyMercadoPago(r.Header.Get("x-signature"), r.Header.Get("x-request-id"), id, h.cfg.MercadoPagoWebhookSecret, time.Now()); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			p, err := h.mercadoPago().Payment(ctx, id)
+			if err != nil {
+				// 5xx: o Mercado Pago reenvia a notificação
+				writeErr(w, http.StatusBadGateway, "mercadopago error", err)
+				return
+			}
+			list = []payments.Payment{p}
+
+		case "pix":
+			if h.cfg.PixWebhookToken == "" {
+				http.NotFound(w, r)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(h.cfg.PixWebhookToken)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			list, err = payments.ParsePix(body)
+
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		out := make([]models.Payment, 0, len(list))
+		for _, p := range list {
+			rec, err := h.recordPayment(ctx, p)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			out = append(out, rec)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "payments": out})
+	})
+}
+
+func (h *WebhookHandler) mercadoPago() *payments.MercadoPago {
+	return &payments.MercadoPago{
+		Token: h.cfg.MercadoPagoAccessToken,
+		HTTP:  &http.Client{Timeout: 15 * time.Second, Transport: trace.Transport(h.capture.Transport("mercadopago", h.upstream))},
+	}
+}
+
+// paymentClient acha o cliente do pagamento: referência da ação pendente, senão telefone.
+func (h *WebhookHandler) paymentClient(ctx context.Context, p payments.Payment) (models.Client, bool, error) {
+	number := ""
+	if p.Reference != "" {
+		n, ok, err := models.PendingActionPhone(ctx, h.pool, p.Reference)
+		if err != nil {
+			return models.Client{}, false, err
+		}
+		if ok {
+			number = n
+		}
+	}
+	if number == "" {
+		n, ok := phone.Normalize(p.Phone)
+		if !ok {
+			return models.Client{}, false, nil
+		}
+		number = n
+	}
+	return h.clients.ByPhone(ctx, number)
+}
+
+// recordPayment registra a notificação e, no primeiro aviso de pagamento aprovado
+// de um cliente conhecido, encerra a espera e avisa o cliente.
+func (h *WebhookHandler) recordPayment(ctx context.Context, p payments.Payment) (models.Payment, error) {
+	client, known, err := h.paymentClient(ctx, p)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	in := models.Payment{
+		Provider: p.Provider, ExternalID: p.ExternalID, Reference: p.Reference, Status: p.Status,
+		AmountCents: p.AmountCents, Currency: p.Currency, PaidAt: p.PaidAt,
+	}
+	if in.Currency == "" {
+		in.Currency = "BRL"
+	}
+	if known {
+		in.ClientID = &client.ID
+	}
+	rec, err := models.UpsertPayment(ctx, h.pool, in, p.Raw)
+	if err != nil {
+		return rec, err
+	}
+	log.Printf("payment %s %s: %s %s (ref=%q, client=%q)", rec.Provider, rec.ExternalID, rec.Status, paymentAmount(rec), rec.Reference, rec.Phone)
+	if rec.Status != payments.StatusPaid || !known {
+		return rec, nil
+	}
+	claimed, err := models.ClaimPaymentNotice(ctx, h.pool, rec.ID)
+	if err != nil || !claimed {
+		return rec, err
+	}
+	h.paymentReceived(ctx, client, rec)
+	return rec, nil
+}
+
+// paymentReceived encerra a ação pendente, avisa o cliente e anota na thread.
+func (h *WebhookHandler) paymentReceived(ctx context.Context, client models.Client, p models.Payment) {
+	resolved, err := models.ResolvePendingActions(ctx, h.pool, p.Reference, client.Phone, "paid")
+	if err == nil && len(resolved) == 0 && p.Reference != "" {
+		// referência do provedor sem ação correspondente: encerra a espera do telefone
+		_, err = models.ResolvePendingActions(ctx, h.pool, "", client.Phone, "paid")
+	}
+	if err != nil {
+		log.Printf("db pending action error (%s): %v", client.Phone, err)
+	}
+
+	amount := paymentAmount(p)
+	if h.cfg.PaymentReply != "" {
+		vars := h.replyVars(client)
+		vars["valor"] = amount
+		if text := processor.Interpolate(h.cfg.PaymentReply, vars); text != "" {
+			if _, err := h.deliverText(ctx, client.ID, client.Phone, text); err != nil {
+				h.fail(client.Phone, "uazapi send payment reply", err)
+			}
+		}
+	}
+
+	if client.ThreadID != nil && *client.ThreadID != "" {
+		note := "[Contexto interno — pagamento confirmado: " + amount + " via " + p.Provider
+		if p.Reference != "" {
+			note += ", referência " + p.Reference
+		}
+		note += ". Não peça esse pagamento de novo.]"
+		if err := h.ai.AddUserMessage(ctx, *client.ThreadID, note); err != nil {
+			log.Printf("payment thread note error (%s): %v", client.Phone, err)
+		}
+	}
+	h.publish(ctx, events.Event{Topic: events.PaymentReceived, Phone: client.Phone, ClientID: client.ID,
+		Role: "system", Type: "payment", Content: amount + " via " + p.Provider, ExtID: p.ExternalID})
+}
+
+// paymentAmount formata o valor ("R$ 110,00"; outras moedas com o código: "USD 10,00").
+func paymentAmount(p models.Payment) string {
+	v := float64(p.AmountCents) / 100
+	s := catalog.Price(&v)
+	if p.Currency != "" && p.Currency != "BRL" {
+		s = p.Currency + strings.TrimPrefix(s, "R$")
+	}
+	return s
+}
+
+// PaymentsHandler expõe GET /admin/payments.
+func (h *WebhookHandler) PaymentsHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := r.Context()
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+		var clientID int64
+		if raw := r.URL.Query().Get("phone"); raw != "" {
+			number, ok := phone.Normalize(raw)
+			if !ok {
+				http.Error(w, "invalid phone", http.StatusBadRequest)
+				return
+			}
+			client, found, err := h.clients.ByPhone(ctx, number)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !found {
+				writeJSON(w, http.StatusOK, []models.Payment{})
+				return
+			}
+			clientID = client.ID
+		}
+		list, err := models.ListPayments(ctx, h.pool, clientID, limit)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}))
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/phone"
+	"github.com/your-org/leandro-agent/internal/processor"
+	"github.com/your-org/leandro-agent/internal/unfurl"
+)
+
+/*
+Ação pendente (PENDING_ACTION_ENABLED).
+
+Quando o cliente recebe um link de pagamento ou formulário (resposta do assistente
+com host de PENDING_ACTION_LINK_HOSTS, ou POST /api/send com "pending"), a
+conversa fica aguardando por PENDING_ACTION_MINUTES. Nesse tempo, mensagens curtas
+sobre o assunto (PENDING_ACTION_KEYWORDS: "já paguei", "ok", "qual o pix?") são
+registradas mas não vão para o assistente, que tenderia a repetir o link ou
+confirmar um pagamento que ainda não chegou; o cliente recebe no máximo um
+lembrete (PENDING_ACTION_REPLY) a cada 10 minutos. Assunto novo segue normal.
+
+O sistema de pagamento encerra a espera:
+
+	POST /api/v1/confirmations
+	{
+	  "reference": "pedido-123",   // o "reference" usado no /api/send (ou "phone")
+	  "status": "paid",            // paid | completed | failed | cancelled (default paid)
+	  "text": "...",               // aviso ao cliente (default PENDING_ACTION_CONFIRM_REPLY em paid/completed)
+	  "notify": true               // false = só encerra, sem aviso
+	}
+
+O aviso também entra na thread, para o assistente saber que o pagamento chegou.
+Autenticação: "Authorization: Bearer <INGEST_TOKEN>" (ou token do tenant).
+*/
+
+// pendingReplyCooldown espaça os lembretes enviados enquanto a ação está pendente.
+const pendingReplyCooldown = 10 * time.Minute
+
+// pendingLink devolve o primeiro link de pagamento/formulário de text ("" = nenhum).
+func (h *WebhookHandler) pendingLink(text string) string {
+	for _, raw := range unfurl.FindURLs(text, 5) {
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+		for _, want := range h.cfg.PendingActionLinkHosts {
+			want = strings.ToLower(want)
+			if host == want || strings.HasSuffix(host, "."+want) {
+				return raw
+			}
+		}
+	}
+	return ""
+}
+
+// pendingKind classifica o link: formulário ou pagamento.
+func pendingKind(link string) string {
+	if strings.Contains(strings.ToLower(link), "form") {
+		return "form"
+	}
+	return "payment"
+}
+
+// openPendingAction abre a espera do cliente. window <= 0 usa PENDING_ACTION_MINUTES.
+func (h *WebhookHandler) openPendingAction(ctx context.Context, clientID int64, phone, kind, reference, link string, window time.Duration) (models.PendingAction, error) {
+	if window <= 0 {
+		window = time.Duration(h.cfg.PendingActionMinutes) * time.Minute
+	}
+	p, err := models.CreatePendingAction(ctx, h.pool, clientID, kind, reference, link, time.Now().Add(window))
+	if err != nil {
+		return p, err
+	}
+	log.Printf("pending %s opened for %s until %s (ref=%q)", kind, phone, p.ExpiresAt.Format(time.RFC3339), reference)
+	return p, nil
+}
+
+// trackPendingLink abre a espera quando a resposta do assistente leva um link de
+// pagamento/formulário.
+func (h *WebhookHandler) trackPendingLink(ctx context.Context, clientID int64, phone, reply string) {
+	if !h.cfg.PendingActionEnabled {
+		return
+	}
+	link := h.pendingLink(reply)
+	if link == "" {
+		return
+	}
+	if _, err := h.openPendingAction(ctx, clientID, phone, pendingKind(link), "", link, 0); err != nil {
+		log.Printf("db pending action error (%s): %v", phone, err)
+	}
+}
+
+// holdPending segura a mensagem sobre a ação pendente (fica só registrada). Devolve
+// false sem espera ativa ou quando a mensagem é de outro assunto.
+func (h *WebhookHandler) holdPending(ctx context.Context, client models.Client, phone, text string) bool {
+	if !h.cfg.PendingActionEnabled {
+		return false
+	}
+	p, ok, err := models.ActivePendingAction(ctx, h.pool, client.ID)
+	if err != nil {
+		log.Printf("db pending action error (%s): %v", phone, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	if _, onTopic := h.pendingTopic.Match(text); !onTopic {
+		return false
+	}
+	if err := models.HoldPendingAction(ctx, h.pool, p.ID); err != nil {
+		log.Printf("db pending action error (%s): %v", phone, err)
+	}
+	log.Printf("pending %s: held message from %s", p.Kind, phone)
+	if h.cfg.PendingActionReply != "" && h.fallbacks.allow("pending:"+phone, pendingReplyCooldown) {
+		h.sendPendingText(ctx, client, phone, h.cfg.PendingActionReply, p.URL, false)
+	}
+	return true
+}
+
+// sendPendingText envia o lembrete/aviso com {{link}} e as variáveis da resposta.
+// Com toThread, o texto também entra na thread do assistente.
+func (h *WebhookHandler) sendPendingText(ctx context.Context, client models.Client, phone, tpl, link string, toThread bool) bool {
+	vars := h.replyVars(client)
+	vars["link"] = link
+	text := processor.Interpolate(tpl, vars)
+	if text == "" {
+		return false
+	}
+	if _, err := h.deliverText(ctx, client.ID, phone, text); err != nil {
+		h.fail(phone, "uazapi send pending text", err)
+		return false
+	}
+	if toThread && client.ThreadID != nil && *client.ThreadID != "" {
+		if err := h.ai.AddAssistantMessage(ctx, *client.ThreadID, text); err != nil {
+			log.Printf("pending thread error (%s): %v", phone, err)
+		}
+	}
+	return true
+}
+
+// sendPending é o campo "pending" de POST /api/send.
+type sendPending struct {
+	Kind      string `json:"kind"`      // payment | form (default payment)
+	Reference string `json:"reference"` // id no sistema externo, usado na confirmação
+	Minutes   int    `json:"minutes"`   // janela (default PENDING_ACTION_MINUTES)
+}
+
+type confirmationRequest struct {
+	Reference string `json:"reference"`
+	Phone     string `json:"phone"`
+	Status    string `json:"status"`
+	Text      string `json:"text"`
+	Notify    *bool  `json:"notify"`
+}
+
+// ConfirmationHandler expõe POST /api/v1/confirmations.
+func (h *WebhookHandler) ConfirmationHandler() http.Handler {
+	return requireToken(h.cfg.IngestToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := h.scope(r.Context())
+
+		var req confirmationRequest
+		if err := json.NewDecoder(limitBody(r)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		req.Reference = strings.TrimSpace(req.Reference)
+		if req.Reference == "" {
+			number, ok := phone.Normalize(req.Phone)
+			if !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "reference or phone is required"})
+				return
+			}
+			req.Phone = number
+		}
+		req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+		switch req.Status {
+		case "":
+			req.Status = "paid"
+		case "paid", "completed", "failed", "cancelled":
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "status must be paid, completed, failed or cancelled"})
+			return
+		}
+
+		actions, err := models.ResolvePendingActions(ctx, h.pool, req.Reference, req.Phone, req.Status)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		text := strings.TrimSpace(req.Text)
+		if text == "" && (req.Status == "paid" || req.Status == "completed") {
+			text = h.cfg.PendingActionConfirmReply
+		}
+		notified := 0
+		for _, p := range actions {
+			log.Printf("pending %s of %s resolved: %s (ref=%q)", p.Kind, p.Phone, req.Status, p.Reference)
+			if text == "" || (req.Notify != nil && !*req.Notify) {
+				continue
+			}
+			client, err := h.clients.GetOrCreate(ctx, p.Phone, nil)
+			if err != nil {
+				log.Printf("db client error (%s): %v", p.Phone, err)
+				continue
+			}
+			if h.sendPendingText(ctx, client, p.Phone, text, p.URL, true) {
+				notified++
+			}
+		}
+		// repetição do mesmo aviso (retentativa do provedor) não é erro: resolved=0
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "resolved": len(actions), "notified": notified, "actions": actions})
+	}))
+}
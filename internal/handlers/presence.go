@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// presenceEvent é um evento de presença da Uazapi ("digitando...", "gravando áudio...").
+type presenceEvent struct {
+	JID   string
+	State string // composing | recording | paused | available | unavailable
+}
+
+// typing indica se o usuário está ativamente escrevendo/gravando.
+func (p presenceEvent) typing() bool {
+	return p.State == "composing" || p.State == "recording"
+}
+
+// parsePresence reconhece eventos de presença nos formatos conhecidos:
+//
+//	{"EventType":"presence","event":{"Chat":"5511...@s.whatsapp.net","State":"composing"}}
+//	{"body":{"EventType":"chat_presence","presence":{"id":"...","presence":"composing"}}}
+func parsePresence(trimmed []byte) (presenceEvent, bool) {
+	var root map[string]any
+	if err := json.Unmarshal(trimmed, &root); err != nil {
+		return presenceEvent{}, false
+	}
+	if body, ok := root["body"].(map[string]any); ok {
+		root = body
+	}
+	evType := strings.ToLower(firstString(root, "EventType", "eventType", "event_type", "type"))
+	if !strings.Contains(evType, "presence") {
+		return presenceEvent{}, false
+	}
+
+	var p presenceEvent
+	for _, key := range []string{"event", "presence", "data"} {
+		obj, ok := root[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		if p.State == "" {
+			p.State = strings.ToLower(firstString(obj, "State", "state", "presence", "Presence", "type"))
+		}
+		if p.JID == "" {
+			p.JID = firstString(obj, "Chat", "chat", "chatid", "id", "from", "Sender", "sender")
+		}
+	}
+	if p.State == "" {
+		p.State = strings.ToLower(firstString(root, "state", "presence"))
+	}
+	if p.JID == "" {
+		p.JID = firstString(root, "chatid", "chat", "from")
+	}
+	return p, true
+}
+
+func firstString(m map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// handlePresence estende o debounce do buffer enquanto o usuário digita.
+// Só age se já houver mensagens pendentes para o telefone.
+func (h *WebhookHandler) handlePresence(ctx context.Context, p presenceEvent) {
+	if h.cfg.TypingExtendSeconds <= 0 || !p.typing() {
+		return
+	}
+	phone, ok := h.resolveJID(ctx, p.JID)
+	if !ok {
+		return
+	}
+	h.bufMgr.Extend(phone,
+		time.Duration(h.cfg.TypingExtendSeconds)*time.Second,
+		time.Duration(h.cfg.TypingMaxWaitSeconds)*time.Second)
+}
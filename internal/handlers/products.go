@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/catalog"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// productInput é o corpo de POST/PUT em /admin/products.
+type productInput struct {
+	SKU         string   `json:"sku"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	Price       *float64 `json:"price"`
+	Unit        string   `json:"unit"`
+	Active      *bool    `json:"active"`
+}
+
+// product valida a entrada; a mensagem de erro vai direto na resposta 400.
+func (in productInput) product() (models.Product, string) {
+	p := models.Product{
+		SKU: strings.TrimSpace(in.SKU), Name: strings.TrimSpace(in.Name), Description: strings.TrimSpace(in.Description),
+		Category: strings.TrimSpace(in.Category), Price: in.Price, Unit: strings.TrimSpace(in.Unit), Active: true,
+	}
+	if in.Active != nil {
+		p.Active = *in.Active
+	}
+	switch {
+	case p.Name == "":
+		return p, "name is required"
+	case len(p.Name) > 200 || len(p.SKU) > 64 || len(p.Description) > 2000:
+		return p, "name, sku or description too long"
+	case p.Price != nil && *p.Price < 0:
+		return p, "price must not be negative"
+	}
+	return p, ""
+}
+
+// NewProductsHandler mantém o catálogo usado por search_products/get_price:
+//
+//	GET    /admin/products?q=porta&limit=20&active=1   lista ou busca aproximada (analyst)
+//	POST   /admin/products {"sku","name","description","category","price","unit","active"}  (operator)
+//	GET    /admin/products/{id}                        (analyst)
+//	PUT    /admin/products/{id}                        substitui os campos (operator)
+//	DELETE /admin/products/{id}                        (operator)
+//
+// price null = "sob consulta". Cada item traz também "whatsapp", o texto que o
+// assistente recebe.
+func NewProductsHandler(auth *Auth, pool *pgxpool.Pool) http.Handler {
+	type productView struct {
+		models.Product
+		WhatsApp string `json:"whatsapp"`
+	}
+	view := func(p models.Product) productView { return productView{p, catalog.Format(p)} }
+
+	return auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if r.Method != http.MethodGet && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var id int64
+		if raw := r.PathValue("id"); raw != "" {
+			var err error
+			if id, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch {
+		case r.Method == http.MethodGet && id == 0:
+			q := r.URL.Query()
+			list, err := models.ListProducts(ctx, pool, q.Get("active") == "1")
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			if limit <= 0 || limit > 500 {
+				limit = 500
+			}
+			if term := strings.TrimSpace(q.Get("q")); term != "" {
+				list = catalog.Search(list, term, limit)
+			} else if len(list) > limit {
+				list = list[:limit]
+			}
+			out := make([]productView, len(list))
+			for i, p := range list {
+				out[i] = view(p)
+			}
+			writeJSON(w, http.StatusOK, out)
+
+		case r.Method == http.MethodGet:
+			p, ok, err := models.GetProduct(ctx, pool, id)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !ok {
+				http.Error(w, "product not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, view(p))
+
+		case r.Method == http.MethodPost && id == 0, r.Method == http.MethodPut && id != 0:
+			var in productInput
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			p, msg := in.product()
+			if msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			var (
+				saved models.Product
+				ok    = true
+				err   error
+				code  = http.StatusCreated
+			)
+			if id == 0 {
+				saved, err = models.CreateProduct(ctx, pool, p)
+			} else {
+				p.ID, code = id, http.StatusOK
+				saved, ok, err = models.UpdateProduct(ctx, pool, p)
+			}
+			switch {
+			case errors.Is(err, models.ErrDuplicateSKU):
+				http.Error(w, "sku already in use", http.StatusConflict)
+			case err != nil:
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+			case !ok:
+				http.Error(w, "product not found", http.StatusNotFound)
+			default:
+				writeJSON(w, code, view(saved))
+			}
+
+		case r.Method == http.MethodDelete && id != 0:
+			found, err := models.DeleteProduct(ctx, pool, id)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !found {
+				http.Error(w, "product not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+// NewReadyHandler expõe GET /readyz: 503 se o banco não responde ou se o teste do
+// canal no start falhou (warmup, WARMUP_NUMBER); os circuit breakers da Uazapi
+// aparecem no corpo ("degraded" com algum caminho aberto), sem derrubar a
+// readiness — um caminho alternativo aberto é normal em instâncias que não o têm.
+func NewReadyHandler(pool *pgxpool.Pool, warmup func() WarmupStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		body := map[string]any{"status": "ok", "db": "ok"}
+		code := http.StatusOK
+		if err := pool.Ping(ctx); err != nil {
+			body["status"], body["db"] = "unavailable", err.Error()
+			code = http.StatusServiceUnavailable
+		}
+
+		if warmup != nil {
+			ws := warmup()
+			body["warmup"] = ws
+			if ws.State == warmupFailed {
+				body["status"] = "unavailable"
+				code = http.StatusServiceUnavailable
+			}
+		}
+
+		states := uazapi.Breakers()
+		open := 0
+		for _, st := range states {
+			if st.State != uazapi.BreakerClosed {
+				open++
+			}
+		}
+		if open > 0 && code == http.StatusOK {
+			body["status"] = "degraded"
+		}
+		body["uazapi_breakers"] = states
+		body["uazapi_open_paths"] = open
+		writeJSON(w, code, body)
+	})
+}
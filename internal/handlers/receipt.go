@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/events"
+)
+
+// receiptEvent é a confirmação de uma mensagem enviada (servidor, entrega, leitura).
+type receiptEvent struct {
+	JID    string
+	IDs    []string
+	Status string // sent | delivered | read | played | failed
+}
+
+// receiptStatus normaliza os nomes de status da Uazapi/WhatsApp.
+func receiptStatus(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "deliver"):
+		return "delivered"
+	case strings.Contains(s, "played"):
+		return "played"
+	case strings.Contains(s, "read"):
+		return "read"
+	case strings.Contains(s, "server"), s == "sent":
+		return "sent"
+	case strings.Contains(s, "fail"), strings.Contains(s, "error"):
+		return "failed"
+	}
+	return s
+}
+
+// delivered indica que a mensagem chegou ao aparelho.
+func (r receiptEvent) delivered() bool {
+	return r.Status == "delivered" || r.Status == "read" || r.Status == "played"
+}
+
+// parseReceipt reconhece confirmações de mensagem nos formatos conhecidos:
+//
+//	{"EventType":"messages_update","event":{"Type":"Delivered","MessageIDs":["3EB0..."],"Chat":"5511...@s.whatsapp.net"}}
+//	{"body":{"EventType":"receipt","message":{"messageid":"3EB0...","status":"DELIVERY_ACK","chatid":"5511..."}}}
+func parseReceipt(trimmed []byte) (receiptEvent, bool) {
+	var root map[string]any
+	if err := json.Unmarshal(trimmed, &root); err != nil {
+		return receiptEvent{}, false
+	}
+	if body, ok := root["body"].(map[string]any); ok {
+		root = body
+	}
+	evType := strings.ToLower(firstString(root, "EventType", "eventType", "event_type", "type"))
+	if evType != "messages_update" && !strings.Contains(evType, "receipt") && !strings.Contains(evType, "ack") {
+		return receiptEvent{}, false
+	}
+
+	var r receiptEvent
+	for _, key := range []string{"event", "message", "update", "data"} {
+		obj, ok := root[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		if r.Status == "" {
+			r.Status = receiptStatus(firstString(obj, "Type", "type", "Status", "status", "State", "state", "ack"))
+		}
+		if r.JID == "" {
+			r.JID = firstString(obj, "Chat", "chat", "chatid", "Sender", "from")
+		}
+		if ids, ok := obj["MessageIDs"].([]any); ok {
+			for _, v := range ids {
+				if id, ok := v.(string); ok && id != "" {
+					r.IDs = append(r.IDs, id)
+				}
+			}
+		}
+		if id := firstString(obj, "messageid", "messageId", "MessageID", "id"); id != "" && len(r.IDs) == 0 {
+			r.IDs = append(r.IDs, id)
+		}
+	}
+	if r.Status == "" {
+		r.Status = receiptStatus(firstString(root, "status", "state"))
+	}
+	return r, true
+}
+
+// handleReceipt avança o status das mensagens enviadas (ver outbox.go) e publica
+// as confirmações no barramento (teste de start, métricas).
+func (h *WebhookHandler) handleReceipt(ctx context.Context, r receiptEvent) {
+	phone, _ := h.resolveJID(ctx, r.JID)
+	for _, id := range r.IDs {
+		h.updateMessageStatus(ctx, id, r.Status)
+		h.publish(ctx, events.Event{Topic: events.MessageStatus, Phone: phone, ExtID: id, Type: r.Status})
+	}
+}
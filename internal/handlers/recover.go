@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/trace"
+)
+
+/*
+Recuperação de panics.
+
+Um panic numa goroutine de processamento derrubaria o processo inteiro; numa
+requisição, deixaria o cliente sem resposta e só o stack no log. Aqui o panic vira:
+
+  - registro em dead_letters (etapa, telefone, mensagem/rota e stack) e em failures;
+  - aviso internal_error ao cliente (FALLBACK_MESSAGES), quando havia uma mensagem dele;
+  - evento panic.recovered e alerta aos operadores (PANIC_ALERT_WEBHOOK /
+    PANIC_ALERT_NOTIFY, no máx. 1 por etapa a cada PANIC_ALERT_COOLDOWN_MINUTES).
+
+Recover envolve o mux HTTP; recoverMessage, o processamento de uma conversa;
+recoverWorker, tarefas em background; supervise reinicia os loops após um panic.
+
+	GET /admin/dead-letters?limit=   últimos panics registrados (analyst)
+*/
+
+// workerRestartDelay espaça o reinício de um loop que terminou em panic.
+const workerRestartDelay = time.Minute
+
+// recoverMessage, usado com defer no processamento de uma mensagem do cliente,
+// recupera um panic e avisa o cliente. payload é o texto que estava sendo processado.
+func (h *WebhookHandler) recoverMessage(ctx context.Context, phone, stage, payload string) {
+	if v := recover(); v != nil {
+		h.panicked(ctx, phone, stage, payload, v, debug.Stack(), true)
+	}
+}
+
+// recoverWorker, usado com defer em tarefas em background, recupera um panic sem
+// avisar o cliente.
+func (h *WebhookHandler) recoverWorker(ctx context.Context, stage string) {
+	if v := recover(); v != nil {
+		h.panicked(ctx, "", stage, "", v, debug.Stack(), false)
+	}
+}
+
+// supervise roda loop e o reinicia (após workerRestartDelay) se terminar em panic.
+func (h *WebhookHandler) supervise(ctx context.Context, name string, loop func(context.Context)) {
+	for h.runWorker(ctx, name, loop) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(workerRestartDelay):
+		}
+		log.Printf("worker %s restarting after panic", name)
+	}
+}
+
+// runWorker devolve true se loop terminou em panic.
+func (h *WebhookHandler) runWorker(ctx context.Context, name string, loop func(context.Context)) (panicked bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			panicked = true
+			h.panicked(ctx, "", "worker "+name, "", v, debug.Stack(), false)
+		}
+	}()
+	loop(ctx)
+	return false
+}
+
+// Recover é o middleware HTTP: o panic vira 500 com o X-Request-ID para
+// correlacionar com o dead letter. http.ErrAbortHandler segue adiante.
+func (h *WebhookHandler) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			// só o caminho: a query pode trazer tokens
+			route := r.Method + " " + r.URL.Path
+			h.panicked(r.Context(), "", "http "+route, route, v, debug.Stack(), false)
+			http.Error(w, "internal error (request "+trace.RequestID(r.Context())+")", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// panicked registra o panic, avisa o cliente (notify) e alerta os operadores.
+func (h *WebhookHandler) panicked(ctx context.Context, phone, stage, payload string, v any, stack []byte, notify bool) {
+	msg := fmt.Sprint(v)
+	log.Printf("panic recovered (%s, phone=%q): %s\n%s", stage, phone, msg, stack)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	payload, _ = h.redact.Apply(payload)
+	id, err := models.InsertDeadLetter(ctx, h.pool, models.DeadLetter{Phone: phone, Stage: stage, Payload: payload, Error: msg, Stack: string(stack)})
+	if err != nil {
+		log.Printf("db dead letter error: %v", err)
+	}
+	h.fail(phone, "panic "+stage, fmt.Errorf("panic: %s", msg))
+
+	if notify && phone != "" {
+		h.statuses.fail(phone, stage)
+		var clientID int64
+		if client, ok, err := h.clients.ByPhone(ctx, phone); err == nil && ok {
+			clientID = client.ID
+		}
+		h.notifyFailure(ctx, clientID, phone, fallbackInternal)
+	}
+
+	h.publish(ctx, events.Event{Topic: events.PanicRecovered, Phone: phone, Role: "system", Type: "panic",
+		Stage: stage, Error: msg, Content: "dead letter " + strconv.FormatInt(id, 10)})
+	cooldown := time.Duration(h.cfg.PanicAlertCooldownMinutes) * time.Minute
+	if !h.fallbacks.allow("panic|"+stage, cooldown) {
+		return
+	}
+	text := "🚨 Panic recuperado em " + stage
+	if phone != "" {
+		text += " (cliente " + phone + ")"
+	}
+	text += ": " + msg
+	if id > 0 {
+		text += " — dead letter #" + strconv.FormatInt(id, 10)
+	}
+	h.sendAlert("panic", h.cfg.PanicAlertWebhook, h.cfg.PanicAlertNotify, text)
+}
+
+// DeadLettersHandler expõe GET /admin/dead-letters.
+func (h *WebhookHandler) DeadLettersHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+		list, err := models.ListDeadLetters(r.Context(), h.pool, limit)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}))
+}
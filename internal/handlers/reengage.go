@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/reengage"
+)
+
+// isOptOut indica se o texto é exatamente uma das palavras de opt-out (ex.: "PARAR").
+func isOptOut(keywords []string, text string) bool {
+	t := strings.ToLower(strings.Trim(strings.TrimSpace(text), ".!"))
+	for _, k := range keywords {
+		if t == strings.ToLower(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleOptOut registra o opt-out de mensagens ativas e confirma ao cliente.
+func (h *WebhookHandler) handleOptOut(ctx context.Context, client models.Client, phone string) {
+	if err := models.SetClientOptOut(ctx, h.pool, client.ID, true); err != nil {
+		h.fail(phone, "db opt-out", err)
+		return
+	}
+	log.Printf("opt-out registered for %s", phone)
+	if h.cfg.OptOutReply == "" {
+		return
+	}
+	if _, err := h.deliverText(ctx, client.ID, phone, h.cfg.OptOutReply); err != nil {
+		log.Println("uazapi send opt-out reply error:", err)
+	}
+}
+
+// errReengageRunning indica que outra rodada (a noturna ou a de outra réplica)
+// segura o lease do reengajamento.
+var errReengageRunning = errors.New("reengage already running")
+
+// ReengageHandler expõe POST /admin/reengage para rodar o reengajamento agora.
+// ?dry=1 só gera as mensagens (sem enviar) para revisão. O envio passa pelo
+// mesmo lease da rodada noturna ("reengage"): com outra rodada em andamento,
+// responde 409 em vez de mandar os mesmos lembretes duas vezes.
+func (h *WebhookHandler) ReengageHandler(job *reengage.Job) http.Handler {
+	return h.auth.RequireRoot(RoleOperator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		dry := r.URL.Query().Get("dry") == "1"
+		ctx := r.Context()
+		if !dry {
+			// envio espaçado pode demorar: segue em segundo plano, avisando aqui
+			// se conseguiu o lease
+			acquired := make(chan error, 1)
+			go func() {
+				bg := context.Background()
+				defer h.recoverWorker(bg, "reengage manual run")
+				// panic antes do lease: a requisição não fica esperando
+				defer func() {
+					select {
+					case acquired <- errors.New("reengage run aborted"):
+					default:
+					}
+				}()
+				ran, err := h.sched.Once(bg, "reengage", time.Now().Truncate(time.Second), func(ctx context.Context) error {
+					acquired <- nil
+					_, err := job.Run(ctx, false)
+					return err
+				})
+				switch {
+				case !ran && err == nil:
+					acquired <- errReengageRunning
+				case !ran:
+					acquired <- err
+				case err != nil:
+					log.Printf("reengage error: %v", err)
+				}
+			}()
+			if err := <-acquired; err != nil {
+				if errors.Is(err, errReengageRunning) {
+					writeJSON(w, http.StatusConflict, map[string]any{"ok": false, "error": err.Error()})
+					return
+				}
+				writeErr(w, http.StatusInternalServerError, "reengage error", err)
+				return
+			}
+			writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "started": true})
+			return
+		}
+		nudges, err := job.Run(ctx, true)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "reengage error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, nudges)
+	}))
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Verificação do webhook (ativa quando WEBHOOK_SECRET está definido).
+
+Cabeçalhos esperados:
+
+	X-Webhook-Timestamp: 1718900000            (unix, segundos ou milissegundos)
+	X-Webhook-Nonce:     <valor único por evento>
+	X-Webhook-Signature: sha256=<hex>          (HMAC-SHA256 de "timestamp.nonce.corpo")
+
+O corpo assinado é o recebido (antes da descompressão). Eventos com timestamp fora
+da janela WEBHOOK_REPLAY_WINDOW_SECONDS, ou com nonce já visto nessa janela, são
+rejeitados: um payload capturado não pode ser reenviado para disparar respostas.
+O nonce só é registrado depois da contrapressão e é liberado se o evento terminar
+em 5xx: o reenvio legítimo do gateway (429 com Retry-After, erro interno) passa.
+
+Os nonces ficam em webhook_nonces (todas as réplicas, sobrevive a restarts; a
+retenção apaga os vencidos) e valem para todos os tenants, que assinam com o
+mesmo segredo: o evento capturado também não entra por /webhook/t/{outro}. O
+cache em memória (do processo) recusa a repetição sem ir ao banco e segura a
+verificação se o banco falhar.
+*/
+
+const (
+	headerWebhookTimestamp = "X-Webhook-Timestamp"
+	headerWebhookNonce     = "X-Webhook-Nonce"
+	headerWebhookSignature = "X-Webhook-Signature"
+)
+
+var (
+	errBadSignature = errors.New("invalid signature")
+	errStaleEvent   = errors.New("timestamp outside replay window")
+	errReplayed     = errors.New("nonce already used")
+)
+
+// nonceCache guarda os nonces vistos até expirarem (TTL = janela de replay).
+type nonceCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	seen    map[string]time.Time
+	lastGC  time.Time
+	maxSize int
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{ttl: ttl, seen: make(map[string]time.Time), maxSize: 100000}
+}
+
+// add registra o nonce; devolve false se ele já foi visto e ainda não expirou.
+// Cheio mesmo depois de tirar os vencidos, descarta o quarto que vence antes
+// (o banco continua com eles).
+func (c *nonceCache) add(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastGC) > c.ttl/4 || len(c.seen) >= c.maxSize {
+		for k, exp := range c.seen {
+			if now.After(exp) {
+				delete(c.seen, k)
+			}
+		}
+		c.lastGC = now
+	}
+	if len(c.seen) >= c.maxSize {
+		exps := make([]time.Time, 0, len(c.seen))
+		for _, exp := range c.seen {
+			exps = append(exps, exp)
+		}
+		slices.SortFunc(exps, time.Time.Compare)
+		cut := exps[len(exps)/4]
+		for k, exp := range c.seen {
+			if !exp.After(cut) {
+				delete(c.seen, k)
+			}
+		}
+	}
+	if exp, ok := c.seen[nonce]; ok && now.Before(exp) {
+		return false
+	}
+	c.seen[nonce] = now.Add(c.ttl)
+	return true
+}
+
+// remove esquece o nonce (o evento não foi processado e pode ser reenviado).
+func (c *nonceCache) remove(nonce string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.seen, nonce)
+}
+
+// verifyWebhook confere assinatura e janela de tempo e devolve o nonce, que ainda
+// não fica registrado: claimNonce só roda depois da contrapressão, para o reenvio
+// de um 429 (mesmos cabeçalhos assinados) não virar "replayed event". O corpo é
+// lido e recolocado em r.Body para o parse normal. Sem WEBHOOK_SECRET, nonce = "".
+func (h *WebhookHandler) verifyWebhook(r *http.Request) (string, error) {
+	if h.cfg.WebhookSecret == "" {
+		return "", nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	r.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	if len(body) > maxWebhookBody {
+		return "", errBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	tsHeader := strings.TrimSpace(r.Header.Get(headerWebhookTimestamp))
+	nonce := strings.TrimSpace(r.Header.Get(headerWebhookNonce))
+	sig := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(headerWebhookSignature)), "sha256=")
+	if tsHeader == "" || nonce == "" || sig == "" {
+		return "", errBadSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.cfg.WebhookSecret))
+	mac.Write([]byte(tsHeader + "." + nonce + "."))
+	mac.Write(body)
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac.Sum(nil)) {
+		return "", errBadSignature
+	}
+
+	ts, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return "", errStaleEvent
+	}
+	sent := time.Unix(ts, 0)
+	if ts > 1e12 {
+		sent = time.UnixMilli(ts)
+	}
+	window := time.Duration(h.cfg.WebhookReplayWindowSeconds) * time.Second
+	if d := time.Since(sent); d > window || d < -window {
+		return "", errStaleEvent
+	}
+	return nonce, nil
+}
+
+// claimNonce registra o nonce no cache do processo e em webhook_nonces; errReplayed
+// se ele já foi usado. Falha do banco: vale só a verificação local.
+func (h *WebhookHandler) claimNonce(ctx context.Context, nonce string) error {
+	if nonce == "" {
+		return nil
+	}
+	now := time.Now()
+	if !h.nonces.add(nonce, now) {
+		return errReplayed
+	}
+	if h.pool == nil { // sem banco (testes): só o cache local
+		return nil
+	}
+	fresh, err := models.ClaimWebhookNonce(ctx, h.pool, nonce, now.Add(h.nonces.ttl))
+	if err != nil {
+		log.Printf("db webhook nonce error (local check only): %v", err)
+		return nil
+	}
+	if !fresh {
+		return errReplayed
+	}
+	return nil
+}
+
+// releaseNonce libera o nonce de um evento que terminou em 5xx: o gateway reenvia
+// com os mesmos cabeçalhos e o reenvio tem de ser processado.
+func (h *WebhookHandler) releaseNonce(ctx context.Context, nonce string) {
+	if nonce == "" {
+		return
+	}
+	h.nonces.remove(nonce)
+	if h.pool == nil {
+		return
+	}
+	if err := models.ReleaseWebhookNonce(ctx, h.pool, nonce); err != nil {
+		log.Printf("db release webhook nonce error: %v", err)
+	}
+}
+
+// statusWriter guarda o status da resposta (releaseNonce em 5xx).
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// failingClients é um ClientRepo que conta as chamadas e falha: o evento chega
+// ao pipeline e termina em 500 sem precisar de banco.
+type failingClients struct{ calls int }
+
+func (f *failingClients) GetOrCreate(context.Context, string, *string) (models.Client, error) {
+	f.calls++
+	return models.Client{}, errors.New("db down")
+}
+
+func (f *failingClients) ByPhone(context.Context, string) (models.Client, bool, error) {
+	return models.Client{}, false, nil
+}
+
+func (f *failingClients) SetThread(context.Context, int64, string) error { return nil }
+
+func newSignedTestHandler(clients models.ClientRepo) *WebhookHandler {
+	cfg := config.Config{WebhookSecret: "segredo", WebhookReplayWindowSeconds: 300}
+	return &WebhookHandler{
+		cfg:     cfg,
+		nonces:  newNonceCache(10 * time.Minute),
+		load:    &loadShedder{maxRuns: 1, policy: shedPolicyReject, retryAfter: 5 * time.Second},
+		batches: &batchStats{},
+		clients: clients,
+	}
+}
+
+// signedRequest monta a requisição como o gateway: o reenvio usa os mesmos cabeçalhos.
+func signedRequest(secret, nonce, body string) func() *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "." + nonce + "." + body))
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		r.Header.Set(headerWebhookTimestamp, ts)
+		r.Header.Set(headerWebhookNonce, nonce)
+		r.Header.Set(headerWebhookSignature, sig)
+		return r
+	}
+}
+
+func serve(h *WebhookHandler, r *http.Request) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestShedRetryWithSameSignature(t *testing.T) {
+	clients := &failingClients{}
+	h := newSignedTestHandler(clients)
+	req := signedRequest("segredo", "n-1", `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"M1","messageType":"conversation","content":"oi"}}`)
+
+	h.load.runs.Store(1) // saturado
+	if code := serve(h, req()); code != http.StatusTooManyRequests {
+		t.Fatalf("saturated: status = %d, want 429", code)
+	}
+	if clients.calls != 0 {
+		t.Fatal("shed event reached the pipeline")
+	}
+
+	h.load.runs.Store(0)
+	if code := serve(h, req()); code != http.StatusInternalServerError || clients.calls != 1 {
+		t.Fatalf("retry after 429: status = %d, pipeline calls = %d; want 500 from the pipeline, 1", code, clients.calls)
+	}
+	// o 5xx libera o nonce: o próximo reenvio também é processado
+	if code := serve(h, req()); code != http.StatusInternalServerError || clients.calls != 2 {
+		t.Fatalf("retry after 500: status = %d, pipeline calls = %d; want 500, 2", code, clients.calls)
+	}
+}
+
+func TestReplayAfterSuccessRejected(t *testing.T) {
+	h := newSignedTestHandler(&failingClients{})
+	req := signedRequest("segredo", "n-2", `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"M2","fromMe":true}}`)
+
+	if code := serve(h, req()); code != http.StatusOK {
+		t.Fatalf("first delivery: status = %d, want 200", code)
+	}
+	if code := serve(h, req()); code != http.StatusConflict {
+		t.Fatalf("replay: status = %d, want 409", code)
+	}
+}
+
+func TestBadSignatureRejected(t *testing.T) {
+	h := newSignedTestHandler(&failingClients{})
+	r := signedRequest("outro", "n-3", `{"message":{"chatid":"5511988887777@s.whatsapp.net"}}`)()
+	if code := serve(h, r); code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", code)
+	}
+	// a assinatura inválida não consome o nonce
+	if !h.nonces.add("n-3", time.Now()) {
+		t.Fatal("nonce of a rejected request was claimed")
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/tools"
+)
+
+// waitRun faz polling da run até um status terminal ou RUN_TIMEOUT_SECONDS.
+// onSlow é chamado uma única vez se a run passar de INTERIM_AFTER_SECONDS.
+// Em "requires_action" executa as ferramentas pedidas (no contexto de call) e devolve os outputs.
+// Se uma mensagem nova substituir a run (RUN_SUPERSEDE), devolve runSuperseded.
+func (h *WebhookHandler) waitRun(ctx context.Context, threadID, runID string, call tools.Call, onSlow func()) (string, error) {
+	start := time.Now()
+	deadline := start.Add(time.Duration(h.cfg.RunTimeoutSeconds) * time.Second)
+	slowAfter := time.Duration(h.cfg.InterimAfterSeconds) * time.Second
+	slowFired := false
+
+	status := ""
+	active := activeRunFrom(ctx)
+	for time.Now().Before(deadline) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-active.stopped():
+		}
+		if active.superseded() {
+			return runSuperseded, nil
+		}
+		run, err := h.ai.GetRunDetails(ctx, threadID, runID)
+		status = run.Status
+		if err != nil {
+			return status, err
+		}
+		switch status {
+		case "completed", "failed", "expired", "cancelled", "incomplete":
+			h.recordRunUsage(ctx, call.ClientID, run)
+			return status, nil
+		case "requires_action":
+			if err := h.runTools(ctx, threadID, runID, call, run.ToolCalls()); err != nil {
+				return status, err
+			}
+			continue
+		}
+		if !slowFired && slowAfter > 0 && onSlow != nil && time.Since(start) >= slowAfter {
+			slowFired = true
+			onSlow()
+		}
+	}
+	return status, nil
+}
+
+// sendInterim avisa o usuário que a resposta está demorando ("estou verificando...").
+func (h *WebhookHandler) sendInterim(ctx context.Context, clientID int64, phone string) {
+	text := h.cfg.InterimMessage
+	if text == "" {
+		return
+	}
+	if _, err := h.deliverText(ctx, clientID, phone, text); err != nil {
+		log.Println("uazapi send interim error:", err)
+	}
+}
+
+// runTools executa as chamadas de função da run e submete os resultados.
+func (h *WebhookHandler) runTools(ctx context.Context, threadID, runID string, call tools.Call, calls []openai.ToolCall) error {
+	outputs := make([]openai.ToolOutput, 0, len(calls))
+	for _, tc := range calls {
+		out := h.tools.Execute(ctx, call, tc.Function.Name, tc.Function.Arguments)
+		log.Printf("tool %s for %s: %s", tc.Function.Name, call.Phone, out)
+		outputs = append(outputs, openai.ToolOutput{ToolCallID: tc.ID, Output: out})
+		if tc.Function.Name == "create_lead" {
+			h.publishLead(ctx, call, out)
+		}
+	}
+	return h.ai.SubmitToolOutputs(ctx, threadID, runID, outputs)
+}
+
+// publishLead publica events.LeadCreated quando create_lead registrou o lead
+// (out é o JSON do lead; erros da ferramenta não têm id).
+func (h *WebhookHandler) publishLead(ctx context.Context, call tools.Call, out string) {
+	var lead models.Lead
+	if json.Unmarshal([]byte(out), &lead) != nil || lead.ID == 0 {
+		return
+	}
+	h.publish(ctx, events.Event{Topic: events.LeadCreated, Phone: call.Phone, ClientID: call.ClientID, Role: "system", Type: lead.Kind, Content: lead.Title})
+}
+
+// errRunSuperseded: a segunda run foi substituída por uma mensagem nova.
+var errRunSuperseded = errors.New("run superseded")
+
+// emptyReplyInstruction acompanha a segunda run quando a primeira terminou sem texto.
+const emptyReplyInstruction = "Sua última execução terminou sem resposta ao cliente. Responda agora à última mensagem dele em texto simples, sem chamar ferramentas."
+
+// emptyReply indica run concluída sem resposta aproveitável: nenhuma mensagem de
+// texto do assistente ou só espaços/marcadores de citação.
+func emptyReply(msg openai.AssistantText, err error) bool {
+	if err != nil {
+		return errors.Is(err, openai.ErrNoAssistantText)
+	}
+	return strings.TrimSpace(removeRefs(msg.Value)) == ""
+}
+
+// retryEmptyReply roda o assistente mais uma vez pedindo resposta em texto.
+func (h *WebhookHandler) retryEmptyReply(ctx context.Context, threadID, assistantID, model, instructions string, call tools.Call) (openai.AssistantText, error) {
+	runID, err := h.ai.CreateRunForAssistant(ctx, threadID, assistantID, model, joinInstructions(instructions, emptyReplyInstruction))
+	if err != nil {
+		return openai.AssistantText{}, err
+	}
+	status, err := h.waitRun(ctx, threadID, runID, call, nil)
+	if err != nil {
+		return openai.AssistantText{}, err
+	}
+	if status == runSuperseded {
+		h.cancelRun(ctx, call.ClientID, call.Phone, threadID, runID)
+		return openai.AssistantText{}, errRunSuperseded
+	}
+	if status != "completed" {
+		return openai.AssistantText{}, fmt.Errorf("retry run not completed: %s", status)
+	}
+	return h.ai.GetLastAssistantMessage(ctx, threadID)
+}
+
+// runParams converte os parâmetros de run da configuração efetiva do bot
+// (OPENAI_RUN_* + bot_settings) no formato do client da OpenAI.
+func runParams(cfg config.Config) openai.RunParams {
+	p := openai.RunParams{
+		MaxPromptTokens:     cfg.RunMaxPromptTokens,
+		MaxCompletionTokens: cfg.RunMaxCompletionTokens,
+		Truncation:          cfg.RunTruncation,
+		ReasoningEffort:     cfg.RunReasoningEffort,
+	}
+	if cfg.RunTemperature >= 0 {
+		t := cfg.RunTemperature
+		p.Temperature = &t
+	}
+	if cfg.RunTopP >= 0 {
+		t := cfg.RunTopP
+		p.TopP = &t
+	}
+	return p
+}
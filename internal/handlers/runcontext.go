@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+var weekdaysPT = [...]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"}
+
+// runContext monta o bloco de contexto injetado em toda run: data/hora atual no fuso
+// do negócio e o perfil do cliente (nome, tags, dados do CRM, última conversa).
+func (h *WebhookHandler) runContext(ctx context.Context, client models.Client) string {
+	if !h.cfg.RunContextEnabled {
+		return ""
+	}
+	loc := h.cfg.Location()
+	now := time.Now().In(loc)
+
+	var b strings.Builder
+	b.WriteString("Contexto atual (use estes dados; não invente datas):\n")
+	fmt.Fprintf(&b, "- Agora: %s, %s (fuso %s)\n", weekdaysPT[now.Weekday()], now.Format("02/01/2006 15:04"), loc.String())
+	if client.Name != nil && strings.TrimSpace(*client.Name) != "" {
+		fmt.Fprintf(&b, "- Nome do cliente no WhatsApp: %s\n", strings.TrimSpace(*client.Name))
+	}
+	tags, err := models.ListClientTags(ctx, h.pool, client.ID)
+	if err != nil {
+		log.Printf("run context tags error: %v", err)
+	} else if len(tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(tags, ", "))
+	}
+	if crm := h.crmContext(ctx, client.ID); crm != "" {
+		b.WriteString(crm + "\n")
+	}
+	last, err := models.LastReplyAt(ctx, h.pool, client.ID)
+	switch {
+	case err != nil:
+		log.Printf("run context last reply error: %v", err)
+	case last == nil:
+		b.WriteString("- Primeiro contato: o cliente ainda não conversou com a gente\n")
+	default:
+		fmt.Fprintf(&b, "- Cliente já atendido; última conversa em %s (cliente desde %s)\n",
+			last.In(loc).Format("02/01/2006 15:04"), client.CreatedAt.In(loc).Format("02/01/2006"))
+	}
+	return strings.TrimSpace(b.String())
+}
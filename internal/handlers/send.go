@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/phone"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+	"github.com/your-org/leandro-agent/internal/unfurl"
+)
+
+/*
+POST /api/send — envio avulso pelo número do agente (status de pedido, confirmação de pagamento...).
+
+	{
+	  "phone": "5511999999999",
+	  "text": "Seu pedido saiu para entrega!",   // texto ou legenda
+	  "media_url": "https://...",                // opcional; baixado e enviado como mídia
+	  "media_type": "image",                     // image | audio | video | document (default image)
+	  "record": true,                            // grava no histórico como mensagem do assistente
+	  "bulk": true,                              // campanha: fila de envios em massa (OUTBOUND_*)
+	  "pending": {"kind": "payment", "reference": "pedido-123", "minutes": 60}
+	                                             // opcional: aguarda a confirmação (ver pending.go)
+	}
+
+Header opcional "Idempotency-Key: <chave>": o envio é gravado antes da chamada à
+Uazapi (ver outbox.go) e repetir a requisição com a mesma chave devolve o mesmo
+message_id com "duplicate": true, sem reenviar. Com o envio da mesma chave ainda
+em andamento responde 409.
+
+O número é verificado antes (NUMBER_CHECK_ENABLED); sem WhatsApp responde 422 e
+o cliente sai dos envios proativos. Com o limite diário da rampa atingido ou a fila
+da instância cheia demais (OUTBOUND_*), responde 429.
+
+Autenticação: "Authorization: Bearer <INGEST_TOKEN>".
+*/
+type sendRequest struct {
+	Phone     string `json:"phone"`
+	Text      string `json:"text"`
+	MediaURL  string `json:"media_url"`
+	MediaType string `json:"media_type"`
+	Record    bool   `json:"record"`
+	Bulk      bool   `json:"bulk"`
+
+	Pending *sendPending `json:"pending"`
+}
+
+// sendPart é uma das mensagens de um envio avulso.
+type sendPart struct {
+	kind    string
+	content string
+	send    func(context.Context) (uazapi.SendResult, error)
+}
+
+// SendHandler expõe POST /api/send.
+func (h *WebhookHandler) SendHandler() http.Handler {
+	return requireToken(h.cfg.IngestToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := h.scope(r.Context())
+
+		var req sendRequest
+		if err := json.NewDecoder(limitBody(r)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		number, ok := phone.Normalize(req.Phone)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "phone must be 10-15 digits"})
+			return
+		}
+		req.Phone = number
+		req.Text = strings.TrimSpace(req.Text)
+		if req.Text == "" && req.MediaURL == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "text or media_url is required"})
+			return
+		}
+
+		if !h.numberOnWhatsApp(ctx, req.Phone) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"ok": false, "error": "number is not on WhatsApp"})
+			return
+		}
+
+		if req.Bulk {
+			ctx = uazapi.WithPriority(ctx, uazapi.PriorityBulk)
+		}
+		var (
+			data []byte
+			kind = "text"
+		)
+		if req.MediaURL != "" {
+			kind = strings.ToLower(strings.TrimSpace(req.MediaType))
+			if kind == "" {
+				kind = "image"
+			}
+			var ferr error
+			if data, ferr = fetchMedia(ctx, req.MediaURL); ferr != nil {
+				writeErr(w, http.StatusBadGateway, "media fetch error", ferr)
+				return
+			}
+		}
+		// Partes do envio. Áudio não leva legenda: o texto vai numa segunda mensagem,
+		// com linha no histórico (e chave) própria — repetir a chamada depois de o
+		// texto falhar não reenvia o áudio.
+		mediaNote := "(" + kind + " enviado: " + req.MediaURL + ")"
+		var parts []sendPart
+		switch {
+		case req.MediaURL == "":
+			parts = append(parts, sendPart{kind: kind, content: req.Text, send: func(ctx context.Context) (uazapi.SendResult, error) {
+				return h.wpp.SendText(ctx, req.Phone, req.Text)
+			}})
+		case kind == "audio":
+			parts = append(parts, sendPart{kind: kind, content: mediaNote, send: func(ctx context.Context) (uazapi.SendResult, error) {
+				return h.wpp.SendMedia(ctx, req.Phone, kind, data)
+			}})
+			if req.Text != "" {
+				parts = append(parts, sendPart{kind: "text", content: req.Text, send: func(ctx context.Context) (uazapi.SendResult, error) {
+					return h.wpp.SendText(ctx, req.Phone, req.Text)
+				}})
+			}
+		default:
+			content := req.Text
+			if content == "" {
+				content = mediaNote
+			}
+			parts = append(parts, sendPart{kind: kind, content: content, send: func(ctx context.Context) (uazapi.SendResult, error) {
+				return h.wpp.SendMediaWithCaption(ctx, req.Phone, kind, data, req.Text)
+			}})
+		}
+
+		// Com record ou Idempotency-Key o envio passa pelo histórico em duas fases
+		// (outbox.go): repetir a chamada com a mesma chave não reenvia
+		key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+		waiting := req.Pending != nil && h.cfg.PendingActionEnabled
+		var client models.Client
+		if req.Record || key != "" || waiting {
+			var cerr error
+			if client, cerr = h.clients.GetOrCreate(ctx, req.Phone, nil); cerr != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", cerr)
+				return
+			}
+		}
+		var (
+			res    uazapi.SendResult
+			err    error
+			called bool
+		)
+		for i, part := range parts {
+			send := func(ctx context.Context) (uazapi.SendResult, error) {
+				called = true
+				return part.send(ctx)
+			}
+			var pres uazapi.SendResult
+			if req.Record || key != "" {
+				pkey := key
+				if key != "" && i > 0 {
+					pkey = key + ":" + part.kind
+				}
+				pres, err = h.deliver(withSendKey(ctx, pkey), req.Phone, models.Message{ClientID: client.ID, Type: part.kind, Content: part.content}, send)
+			} else {
+				pres, err = send(ctx)
+			}
+			if err != nil {
+				break
+			}
+			if i == 0 {
+				res = pres
+			}
+		}
+		if errors.Is(err, errSendInFlight) {
+			writeJSON(w, http.StatusConflict, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		if errors.Is(err, uazapi.ErrNotOnWhatsApp) {
+			h.markUnreachable(ctx, req.Phone)
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"ok": false, "error": "number is not on WhatsApp"})
+			return
+		}
+		if errors.Is(err, uazapi.ErrOutboundCap) || errors.Is(err, uazapi.ErrOutboundWait) {
+			writeJSON(w, http.StatusTooManyRequests, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		if err != nil {
+			writeErr(w, http.StatusBadGateway, "send error", err)
+			return
+		}
+
+		out := map[string]any{"ok": true, "message_id": res.MessageID}
+		if !called {
+			// mesma Idempotency-Key de um envio já aceito: nada foi reenviado
+			out["duplicate"] = true
+			waiting = false
+		}
+		if waiting {
+			link := req.MediaURL
+			if urls := unfurl.FindURLs(req.Text, 1); len(urls) > 0 {
+				link = urls[0]
+			}
+			pk := strings.ToLower(strings.TrimSpace(req.Pending.Kind))
+			if pk != "form" {
+				pk = "payment"
+			}
+			p, perr := h.openPendingAction(ctx, client.ID, req.Phone, pk, strings.TrimSpace(req.Pending.Reference), link,
+				time.Duration(req.Pending.Minutes)*time.Minute)
+			if perr != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", perr)
+				return
+			}
+			out["pending_action_id"] = p.ID
+		}
+		writeJSON(w, http.StatusOK, out)
+	}))
+}
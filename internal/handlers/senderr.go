@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+// instanceAlertCooldown espaça o log de token inválido/instância desconectada
+// (toda mensagem falharia igual até alguém corrigir).
+const instanceAlertCooldown = 10 * time.Minute
+
+// onSendError reage aos erros classificados da Uazapi: número sem WhatsApp sai dos
+// envios proativos; token inválido e instância desconectada pedem ação do operador.
+func (h *WebhookHandler) onSendError(phone string, err error) {
+	switch {
+	case errors.Is(err, uazapi.ErrNotOnWhatsApp):
+		h.markUnreachable(h.scope(context.Background()), phone)
+	case errors.Is(err, uazapi.ErrInvalidToken):
+		if h.fallbacks.allow("uazapi|token", instanceAlertCooldown) {
+			log.Printf("uazapi rejected the token (check UAZAPI_TOKEN_SEND): %v", err)
+		}
+	case errors.Is(err, uazapi.ErrDisconnected):
+		if h.fallbacks.allow("uazapi|disconnected", instanceAlertCooldown) {
+			log.Printf("uazapi instance disconnected (reconnect it with the QR code): %v", err)
+		}
+	}
+}
+
+// markUnreachable tira o cliente dos envios proativos até ele escrever de novo.
+func (h *WebhookHandler) markUnreachable(ctx context.Context, phone string) {
+	marked, err := models.MarkClientUnreachable(ctx, h.pool, phone)
+	if err != nil {
+		log.Printf("db mark unreachable error: %v", err)
+		return
+	}
+	if marked {
+		log.Printf("client %s is not on WhatsApp: marked unreachable", phone)
+	}
+}
+
+// numberOnWhatsApp verifica o número antes de um envio avulso (NUMBER_CHECK_*).
+// Vale o resultado gravado no cliente enquanto não vence; falha na consulta não
+// bloqueia o envio.
+func (h *WebhookHandler) numberOnWhatsApp(ctx context.Context, phone string) bool {
+	if !h.cfg.NumberCheckEnabled {
+		return true
+	}
+	exists, at, err := models.ClientNumberCheck(ctx, h.pool, phone)
+	if err != nil {
+		log.Printf("db number check load error: %v", err)
+	} else if at != nil && time.Since(*at) < time.Duration(h.cfg.NumberCheckCacheHours)*time.Hour {
+		return exists
+	}
+	res, err := h.wpp.CheckNumberExists(ctx, phone)
+	if err != nil {
+		log.Printf("uazapi number check %s error: %v", phone, err)
+		return true
+	}
+	if err := models.RecordNumberCheck(ctx, h.pool, phone, res.Exists); err != nil {
+		log.Printf("db number check record error: %v", err)
+	}
+	if !res.Exists {
+		log.Printf("client %s is not on WhatsApp: send skipped", phone)
+	}
+	return res.Exists
+}
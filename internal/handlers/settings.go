@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+// SettingsHandler expõe os ajustes por número do bot (tabela bot_settings):
+//
+//	GET    /admin/settings              lista todas as instâncias
+//	GET    /admin/settings/{instance}   ajustes + configuração efetiva
+//	PUT    /admin/settings/{instance}   {"reply_delay_min_ms":1000,"tts_voice":"nova","business_hours":"Seg-Sex 9h-18h",
+//	                                     "run_temperature":0.4,"run_max_completion_tokens":600,"run_truncation":"last_messages:20"}
+//	DELETE /admin/settings/{instance}   volta aos valores do ENV
+//
+// Campos omitidos/null usam o ENV. Leitura exige analyst; alteração, operator.
+func (h *WebhookHandler) SettingsHandler() http.Handler {
+	return h.auth.RequireRoot(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		instance := strings.TrimSpace(r.PathValue("instance"))
+
+		if r.Method != http.MethodGet && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if instance == "" && r.Method != http.MethodGet {
+			http.Error(w, "instance required", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if instance == "" {
+				all, err := models.ListBotSettings(ctx, h.pool)
+				if err != nil {
+					writeErr(w, http.StatusInternalServerError, "db error", err)
+					return
+				}
+				writeJSON(w, http.StatusOK, all)
+				return
+			}
+			s, ok, err := models.GetBotSettings(ctx, h.pool, instance)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			var overrides any
+			if ok {
+				overrides = s
+			}
+			eff := h.settings.Apply(ctx, instance, h.cfg)
+			writeJSON(w, http.StatusOK, map[string]any{
+				"instance":  instance,
+				"overrides": overrides,
+				"effective": map[string]any{
+					"reply_delay_min_ms":     eff.ReplyDelayMinMs,
+					"reply_delay_max_ms":     eff.ReplyDelayMaxMs,
+					"tts_voice":              eff.TTSVoice,
+					"tts_speed":              eff.TTSSpeed,
+					"buffer_timeout_seconds": eff.BufferTimeoutSeconds,
+					"business_hours":         eff.BusinessVars["horario_funcionamento"],
+					"run":                    runParams(eff),
+				},
+			})
+
+		case http.MethodPut:
+			var s models.BotSettings
+			if err := json.NewDecoder(limitBody(r)).Decode(&s); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			s.Instance = instance
+			if !nonNegative(s.ReplyDelayMinMs, s.ReplyDelayMaxMs, s.BufferTimeoutSeconds) {
+				http.Error(w, "delays and timeouts must be >= 0", http.StatusBadRequest)
+				return
+			}
+			if s.TTSSpeed != nil && (*s.TTSSpeed < 0.25 || *s.TTSSpeed > 4) {
+				http.Error(w, "tts_speed must be between 0.25 and 4", http.StatusBadRequest)
+				return
+			}
+			if msg := validRunSettings(s); msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			out, err := models.UpsertBotSettings(ctx, h.pool, s)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			h.settings.Invalidate(instance)
+			writeJSON(w, http.StatusOK, out)
+
+		case http.MethodDelete:
+			ok, err := models.DeleteBotSettings(ctx, h.pool, instance)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			h.settings.Invalidate(instance)
+			if !ok {
+				http.Error(w, "settings not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+// nonNegative indica se todos os valores informados (não nulos) são >= 0.
+func nonNegative(vals ...*int) bool {
+	for _, v := range vals {
+		if v != nil && *v < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// validRunSettings devolve o erro dos parâmetros de run informados ("" se válidos).
+func validRunSettings(s models.BotSettings) string {
+	switch {
+	case s.RunTemperature != nil && (*s.RunTemperature < 0 || *s.RunTemperature > 2):
+		return "run_temperature must be between 0 and 2"
+	case s.RunTopP != nil && (*s.RunTopP < 0 || *s.RunTopP > 1):
+		return "run_top_p must be between 0 and 1"
+	case s.RunMaxPromptTokens != nil && *s.RunMaxPromptTokens < 256,
+		s.RunMaxCompletionTokens != nil && *s.RunMaxCompletionTokens < 256:
+		return "run token limits must be >= 256"
+	}
+	if s.RunTruncation != nil {
+		if _, ok := openai.TruncationStrategy(*s.RunTruncation); !ok {
+			return "run_truncation must be auto or last_messages:N"
+		}
+	}
+	if s.RunReasoningEffort != nil {
+		switch *s.RunReasoningEffort {
+		case "", "low", "medium", "high":
+		default:
+			return "run_reasoning_effort must be low, medium or high"
+		}
+	}
+	return ""
+}
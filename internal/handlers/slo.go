@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+SLO de latência das respostas.
+
+Cada lote respondido grava em reply_latency o tempo desde a primeira mensagem do
+cliente (recebida no webhook) até o envio da resposta; runs que falharam entram
+como erro. O SLO é "SLO_OBJECTIVE das respostas em até SLO_TARGET_SECONDS" na
+janela de SLO_WINDOW_HOURS.
+
+A taxa de consumo (burn rate) é a fração de respostas fora do alvo dividida pelo
+orçamento de erro (1 - objetivo): 1 = gastando o orçamento no ritmo exato da
+janela. A cada minuto uma réplica calcula a taxa na janela curta
+(SLO_ALERT_WINDOW_MINUTES) e, acima de SLO_BURN_RATE, avisa os operadores
+(log, barramento, SLO_ALERT_WEBHOOK e SLO_ALERT_NOTIFY) no máx. 1 vez por cooldown.
+*/
+
+const sloCheckInterval = time.Minute
+
+// sloWindow é a situação de uma janela frente ao SLO.
+type sloWindow struct {
+	models.LatencyWindow
+	Since      time.Time `json:"since"`
+	Compliance float64   `json:"compliance"` // fração das respostas dentro do alvo (1 sem respostas)
+	BurnRate   float64   `json:"burn_rate"`
+}
+
+// sloReport é o que GET /admin/slo devolve.
+type sloReport struct {
+	TargetSeconds   int       `json:"target_seconds"`
+	Objective       float64   `json:"objective"`
+	Window          sloWindow `json:"window"`
+	AlertWindow     sloWindow `json:"alert_window"`
+	BudgetRemaining float64   `json:"error_budget_remaining"` // fração do orçamento de erro da janela ainda disponível
+	Burning         bool      `json:"burning"`                // janela curta acima de SLO_BURN_RATE
+}
+
+// recordLatency grava o tempo de resposta do lote em andamento do telefone.
+// ok=false registra a run que falhou. Sem status (ex.: fila da manutenção), não grava.
+func (h *WebhookHandler) recordLatency(ctx context.Context, clientID int64, phone string, ok bool) {
+	if h.cfg.SLOTargetSeconds <= 0 || clientID == 0 {
+		return
+	}
+	received := h.statuses.received(phone)
+	if received.IsZero() {
+		return
+	}
+	if err := models.RecordReplyLatency(ctx, h.pool, clientID, received, ok); err != nil {
+		log.Printf("db record latency error: %v", err)
+	}
+}
+
+// sloWindowSince calcula a situação das respostas enviadas depois de since.
+func (h *WebhookHandler) sloWindowSince(ctx context.Context, since time.Time) (sloWindow, error) {
+	target := time.Duration(h.cfg.SLOTargetSeconds) * time.Second
+	lw, err := models.ReplyLatencySince(ctx, h.pool, since, target)
+	if err != nil {
+		return sloWindow{}, err
+	}
+	w := sloWindow{LatencyWindow: lw, Since: since, Compliance: 1}
+	if lw.Total > 0 {
+		w.Compliance = float64(lw.Good) / float64(lw.Total)
+		w.BurnRate = (1 - w.Compliance) / (1 - h.cfg.SLOObjective)
+	}
+	return w, nil
+}
+
+// sloReport calcula o SLO do tenant de ctx (todos os tenants em ctx sem tenant).
+func (h *WebhookHandler) sloReport(ctx context.Context) (sloReport, error) {
+	now := time.Now()
+	rep := sloReport{TargetSeconds: h.cfg.SLOTargetSeconds, Objective: h.cfg.SLOObjective}
+	var err error
+	if rep.Window, err = h.sloWindowSince(ctx, now.Add(-time.Duration(h.cfg.SLOWindowHours)*time.Hour)); err != nil {
+		return rep, err
+	}
+	if rep.AlertWindow, err = h.sloWindowSince(ctx, now.Add(-time.Duration(h.cfg.SLOAlertWindowMinutes)*time.Minute)); err != nil {
+		return rep, err
+	}
+	rep.BudgetRemaining = 1 - rep.Window.BurnRate
+	rep.Burning = rep.AlertWindow.Total >= h.cfg.SLOMinEvents && rep.AlertWindow.BurnRate > h.cfg.SLOBurnRate
+	return rep, nil
+}
+
+// sloLoop verifica o SLO a cada minuto até o ctx ser cancelado.
+func (h *WebhookHandler) sloLoop(ctx context.Context) {
+	ctx = h.scope(ctx)
+	t := time.NewTicker(sloCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-t.C:
+			name := fmt.Sprintf("slo:%d", h.tenantID)
+			if _, err := h.sched.Once(ctx, name, tick.Truncate(sloCheckInterval), h.checkSLO); err != nil {
+				log.Printf("slo check error: %v", err)
+			}
+		}
+	}
+}
+
+// checkSLO alerta se a janela curta está consumindo o orçamento de erro rápido demais.
+func (h *WebhookHandler) checkSLO(ctx context.Context) error {
+	rep, err := h.sloReport(ctx)
+	if err != nil {
+		return err
+	}
+	if !rep.Burning {
+		return nil
+	}
+	cooldown := time.Duration(h.cfg.SLOAlertCooldownMinutes) * time.Minute
+	if !h.fallbacks.allow(fmt.Sprintf("slo|%d", h.tenantID), cooldown) {
+		return nil
+	}
+	w := rep.AlertWindow
+	h.sloAlert(fmt.Sprintf("⚠️ SLO de latência: %.1f%% das respostas em até %ds nos últimos %d min (objetivo %.1f%%, %d respostas, %d falhas, p95 %.1fs). Consumo do orçamento de erro: %.1fx; restante na janela de %dh: %.0f%%.",
+		w.Compliance*100, rep.TargetSeconds, h.cfg.SLOAlertWindowMinutes, rep.Objective*100, w.Total, w.Failed,
+		float64(w.P95Ms)/1000, w.BurnRate, h.cfg.SLOWindowHours, rep.BudgetRemaining*100))
+	return nil
+}
+
+// sloAlert avisa os operadores (log, barramento, SLO_ALERT_WEBHOOK e SLO_ALERT_NOTIFY).
+func (h *WebhookHandler) sloAlert(msg string) {
+	log.Printf("slo alert: %s", msg)
+	h.publish(context.Background(), events.Event{Topic: events.SLOAlert, Role: "system", Type: "slo", Content: msg})
+	h.sendAlert("slo", h.cfg.SLOAlertWebhook, h.cfg.SLOAlertNotify, msg)
+}
+
+// sendAlert envia msg em background ao webhook (se houver) e aos números de notify.
+func (h *WebhookHandler) sendAlert(kind, webhook string, notify []string, msg string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if webhook != "" {
+			if err := postAlertWebhook(ctx, webhook, msg); err != nil {
+				log.Printf("%s alert webhook error: %v", kind, err)
+			}
+		}
+		for _, op := range notify {
+			if _, err := h.wpp.SendText(ctx, op, msg); err != nil {
+				log.Printf("uazapi send %s alert error: %v", kind, err)
+			}
+		}
+	}()
+}
+
+// postAlertWebhook envia {"text": msg} — o formato dos incoming webhooks do Slack,
+// que a maioria dos receptores genéricos também aceita.
+func postAlertWebhook(ctx context.Context, url, msg string) error {
+	body, _ := json.Marshal(map[string]string{"text": msg})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SLOHandler expõe GET /admin/slo com a situação do SLO de latência (janela do SLO
+// e janela curta do alerta). Chaves de tenant veem só o próprio tenant.
+func (h *WebhookHandler) SLOHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.cfg.SLOTargetSeconds <= 0 {
+			http.Error(w, "slo disabled", http.StatusNotFound)
+			return
+		}
+		rep, err := h.sloReport(r.Context())
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, rep)
+	}))
+}
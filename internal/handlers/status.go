@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Situações de uma mensagem recebida pelo webhook.
+const (
+	statusCollecting = "collecting" // álbum aguardando as demais imagens
+	statusBuffered   = "buffered"   // no buffer, aguardando o agrupamento
+	statusQueued     = "queued"     // retida pelo modo manutenção
+	statusProcessing = "processing" // run do assistente em andamento
+	statusDone       = "done"       // tratada (respondida, opt-out, limite de orçamento...)
+	statusIgnored    = "ignored"    // não vai para a IA (abuso)
+	statusFailed     = "failed"     // falha no pipeline (Detail = etapa)
+)
+
+// processingStatus é o que GET /status/{id} devolve. Não inclui telefone nem conteúdo:
+// o ID aleatório é a única credencial.
+type processingStatus struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	Detail     string    `json:"detail,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// statusTracker acompanha cada mensagem do webhook até a resposta. Fica em memória
+// (na réplica que recebeu o evento) e expira após STATUS_TTL_MINUTES.
+type statusTracker struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	byID     map[string]*processingStatus
+	pending  map[string][]string // phone -> IDs aguardando o flush do buffer
+	inflight map[string][]string // phone -> IDs da run em andamento
+	lastGC   time.Time
+}
+
+func newStatusTracker(ttl time.Duration) *statusTracker {
+	return &statusTracker{
+		ttl:      ttl,
+		byID:     make(map[string]*processingStatus),
+		pending:  make(map[string][]string),
+		inflight: make(map[string][]string),
+	}
+}
+
+func newProcessingID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(b)
+}
+
+// track registra uma mensagem. Mensagens coletadas/no buffer/retidas entram na fila
+// do telefone e passam a "processing" no próximo flush.
+func (t *statusTracker) track(phone, status, detail string) string {
+	now := time.Now()
+	st := &processingStatus{ID: newProcessingID(), Status: status, Detail: detail, ReceivedAt: now, UpdatedAt: now}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.gcLocked(now)
+	t.byID[st.ID] = st
+	switch status {
+	case statusCollecting, statusBuffered, statusQueued:
+		t.pending[phone] = append(t.pending[phone], st.ID)
+	}
+	return st.ID
+}
+
+// set troca a situação de uma mensagem ainda na fila do telefone (ex.: buffer -> manutenção).
+func (t *statusTracker) set(id, status string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if st := t.byID[id]; st != nil {
+		st.Status, st.UpdatedAt = status, time.Now()
+	}
+}
+
+// begin marca como "processing" as mensagens aguardando o flush do telefone.
+func (t *statusTracker) begin(phone string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ids := t.pending[phone]
+	delete(t.pending, phone)
+	now := time.Now()
+	for _, id := range ids {
+		if st := t.byID[id]; st != nil && st.Status != statusFailed {
+			st.Status, st.UpdatedAt = statusProcessing, now
+		}
+	}
+	t.inflight[phone] = append(t.inflight[phone], ids...)
+	return ids
+}
+
+// received devolve quando chegou a mensagem mais antiga em andamento do telefone
+// (zero sem mensagens rastreadas).
+func (t *statusTracker) received(phone string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var first time.Time
+	for _, id := range t.inflight[phone] {
+		if st := t.byID[id]; st != nil && (first.IsZero() || st.ReceivedAt.Before(first)) {
+			first = st.ReceivedAt
+		}
+	}
+	return first
+}
+
+// fail marca as mensagens em andamento do telefone como falhas na etapa informada.
+func (t *statusTracker) fail(phone, stage string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for _, id := range t.inflight[phone] {
+		if st := t.byID[id]; st != nil && st.Status == statusProcessing {
+			st.Status, st.Detail, st.UpdatedAt = statusFailed, stage, now
+		}
+	}
+}
+
+// finish encerra a run: o que não falhou fica "done".
+func (t *statusTracker) finish(phone string, ids []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	done := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		done[id] = true
+		if st := t.byID[id]; st != nil && st.Status == statusProcessing {
+			st.Status, st.UpdatedAt = statusDone, now
+		}
+	}
+	rest := t.inflight[phone][:0]
+	for _, id := range t.inflight[phone] {
+		if !done[id] {
+			rest = append(rest, id)
+		}
+	}
+	if len(rest) == 0 {
+		delete(t.inflight, phone)
+	} else {
+		t.inflight[phone] = rest
+	}
+}
+
+func (t *statusTracker) get(id string) (processingStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st, ok := t.byID[id]
+	if !ok || time.Since(st.UpdatedAt) > t.ttl {
+		return processingStatus{}, false
+	}
+	return *st, true
+}
+
+// gcLocked remove os status expirados (e as referências nas filas por telefone).
+func (t *statusTracker) gcLocked(now time.Time) {
+	if now.Sub(t.lastGC) < t.ttl/4 {
+		return
+	}
+	t.lastGC = now
+	for id, st := range t.byID {
+		if now.Sub(st.UpdatedAt) > t.ttl {
+			delete(t.byID, id)
+		}
+	}
+	for _, m := range []map[string][]string{t.pending, t.inflight} {
+		for phone, ids := range m {
+			kept := ids[:0]
+			for _, id := range ids {
+				if _, ok := t.byID[id]; ok {
+					kept = append(kept, id)
+				}
+			}
+			if len(kept) == 0 {
+				delete(m, phone)
+			} else {
+				m[phone] = kept
+			}
+		}
+	}
+}
+
+// statusURL é o caminho consultado pelo gateway/scripts para acompanhar a mensagem.
+func statusURL(id string) string {
+	return "/status/" + id
+}
+
+// writeAccepted responde ao webhook com o ID de processamento e a URL de status.
+// extra acrescenta campos (ex.: "queued":"maintenance") mantendo o formato anterior.
+func (h *WebhookHandler) writeAccepted(w http.ResponseWriter, id string, extra map[string]any) {
+	st, _ := h.statuses.get(id)
+	body := map[string]any{"ok": true, "id": id, "status": st.Status, "status_url": statusURL(id)}
+	for k, v := range extra {
+		body[k] = v
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// StatusHandler expõe GET /status/{id}.
+func (h *WebhookHandler) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st, ok := h.statuses.get(r.PathValue("id"))
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, st)
+	})
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+/*
+Status (stories) do WhatsApp como canal de divulgação.
+
+	POST   /admin/status-posts   {"text":"Promoção!","media_type":"image","media_url":"https://...",
+	                              "background_color":7,"font":1,"send_at":"2024-06-01T09:00:00-03:00"}
+	GET    /admin/status-posts   agendadas e enviadas (analyst)
+	DELETE /admin/status-posts/{id}  cancela uma agendada
+
+Sem send_at (ou no passado) publica na hora; com send_at fica pendente e uma
+réplica publica no minuto agendado. Tudo fica registrado em status_posts.
+*/
+
+const statusPostInterval = time.Minute
+
+var statusMediaTypes = map[string]bool{"image": true, "video": true, "audio": true}
+
+// publishStatus envia o post e grava o resultado.
+func (h *WebhookHandler) publishStatus(ctx context.Context, p models.StatusPost) (models.StatusPost, error) {
+	res, err := h.wpp.SendStatus(ctx, uazapi.StatusPost{
+		Text: p.Text, MediaType: p.MediaType, MediaURL: p.MediaURL, BackgroundColor: p.BackgroundColor, Font: p.Font,
+	})
+	if ferr := models.FinishStatusPost(ctx, h.pool, p.ID, res.MessageID, err); ferr != nil {
+		log.Printf("db finish status post error: %v", ferr)
+	}
+	if err != nil {
+		p.Status = models.StatusPostFailed
+		return p, err
+	}
+	now := time.Now()
+	p.Status, p.SentAt = models.StatusPostSent, &now
+	if res.MessageID != "" {
+		p.MessageID = &res.MessageID
+	}
+	return p, nil
+}
+
+// statusPostLoop publica os posts agendados até o ctx ser cancelado.
+func (h *WebhookHandler) statusPostLoop(ctx context.Context) {
+	ctx = h.scope(ctx)
+	t := time.NewTicker(statusPostInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-t.C:
+			if h.maint.active() {
+				continue
+			}
+			// uma réplica por minuto: duas publicariam em dobro
+			name := fmt.Sprintf("status_posts:%d", h.tenantID)
+			if _, err := h.sched.Once(ctx, name, tick.Truncate(statusPostInterval), h.sendDueStatusPosts); err != nil {
+				log.Printf("status posts error: %v", err)
+			}
+		}
+	}
+}
+
+// sendDueStatusPosts publica os posts vencidos do tenant.
+func (h *WebhookHandler) sendDueStatusPosts(ctx context.Context) error {
+	due, err := models.DueStatusPosts(ctx, h.pool, 20)
+	if err != nil {
+		return err
+	}
+	for _, p := range due {
+		if _, err := h.publishStatus(ctx, p); err != nil {
+			log.Printf("status post %d error: %v", p.ID, err)
+			continue
+		}
+		log.Printf("status post %d published", p.ID)
+	}
+	return nil
+}
+
+// StatusPostsHandler expõe /admin/status-posts (ver comentário do arquivo).
+func (h *WebhookHandler) StatusPostsHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+		if r.Method != http.MethodGet && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			if limit <= 0 || limit > 500 {
+				limit = 100
+			}
+			list, err := models.ListStatusPosts(ctx, h.pool, limit)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+
+		case http.MethodPost:
+			var in struct {
+				Text            string     `json:"text"`
+				MediaType       string     `json:"media_type"`
+				MediaURL        string     `json:"media_url"`
+				BackgroundColor int        `json:"background_color"`
+				Font            int        `json:"font"`
+				SendAt          *time.Time `json:"send_at"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			p := models.StatusPost{
+				Text: strings.TrimSpace(in.Text), MediaURL: strings.TrimSpace(in.MediaURL),
+				BackgroundColor: in.BackgroundColor, Font: in.Font, SendAt: time.Now(),
+			}
+			switch {
+			case p.MediaURL != "":
+				p.MediaType = strings.ToLower(in.MediaType)
+				if p.MediaType == "" {
+					p.MediaType = "image"
+				}
+				if !statusMediaTypes[p.MediaType] {
+					http.Error(w, "invalid media_type", http.StatusBadRequest)
+					return
+				}
+			case p.Text == "":
+				http.Error(w, "text or media_url required", http.StatusBadRequest)
+				return
+			}
+			if in.BackgroundColor < 0 || in.BackgroundColor > 19 || in.Font < 0 || in.Font > 8 {
+				http.Error(w, "invalid background_color or font", http.StatusBadRequest)
+				return
+			}
+			scheduled := in.SendAt != nil && in.SendAt.After(p.SendAt)
+			if scheduled {
+				p.SendAt = *in.SendAt
+			}
+			principal, _ := principalFrom(ctx)
+			p.CreatedBy = principal.Name
+
+			saved, err := models.InsertStatusPost(ctx, h.pool, p)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if scheduled {
+				log.Printf("status post %d scheduled for %s by %s", saved.ID, saved.SendAt.Format(time.RFC3339), principal.Name)
+				writeJSON(w, http.StatusCreated, saved)
+				return
+			}
+			sent, err := h.publishStatus(ctx, saved)
+			if err != nil {
+				writeErr(w, http.StatusBadGateway, "send error", err)
+				return
+			}
+			log.Printf("status post %d published by %s", sent.ID, principal.Name)
+			writeJSON(w, http.StatusOK, sent)
+
+		case http.MethodDelete:
+			id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+			ok, err := models.CancelStatusPost(ctx, h.pool, id)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !ok {
+				http.Error(w, "pending post not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
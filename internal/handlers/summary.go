@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Resumo da conversa (SUMMARY_ENABLED).
+
+clients.summary guarda um resumo curto da conversa, atualizado em background
+depois de cada troca quando já há SUMMARY_EVERY_MESSAGES mensagens novas desde o
+último resumo. O modelo recebe o resumo anterior e só as mensagens novas, então
+o custo não cresce com o tamanho da conversa.
+
+Usado na transferência de assistente, no aviso de handoff aos operadores, no
+reengajamento e no painel:
+
+	GET  /admin/clients/{phone}/summary   resumo atual e mensagens ainda não resumidas (analyst)
+	POST /admin/clients/{phone}/summary   atualiza agora (operator)
+*/
+
+// summaryBatch limita as mensagens novas enviadas ao modelo por rodada.
+const summaryBatch = 60
+
+const summaryPrompt = "Você mantém o resumo de uma conversa de WhatsApp entre um cliente e o atendimento de uma empresa. " +
+	"Atualize o resumo anterior (se houver) com as mensagens novas: quem é o cliente, o que ele quer, o que já foi " +
+	"informado ou combinado e o que está pendente. Descarte o que deixou de importar. " +
+	"No máximo 8 frases, em Português. Responda só com o resumo."
+
+// refreshSummary resume as mensagens novas (se houver ao menos min) e devolve o
+// resumo atual. Com outra atualização do mesmo cliente em andamento, devolve o
+// resumo gravado.
+func (h *WebhookHandler) refreshSummary(ctx context.Context, clientID int64, min int) (string, error) {
+	if !h.summarizing.Acquire(clientID) {
+		cur, err := models.GetClientSummary(ctx, h.pool, clientID)
+		return cur.Text, err
+	}
+	defer h.summarizing.Release(clientID)
+
+	cur, err := models.GetClientSummary(ctx, h.pool, clientID)
+	if err != nil {
+		return "", err
+	}
+	// conversa longa sem resumo: algumas rodadas de summaryBatch mensagens
+	for round := 0; round < 3; round++ {
+		n, err := models.CountMessagesAfter(ctx, h.pool, clientID, cur.MessageID)
+		if err != nil {
+			return cur.Text, err
+		}
+		if n == 0 || n < min {
+			return cur.Text, nil
+		}
+		msgs, err := h.messages.After(ctx, clientID, cur.MessageID, summaryBatch)
+		if err != nil || len(msgs) == 0 {
+			return cur.Text, err
+		}
+		var b strings.Builder
+		if cur.Text != "" {
+			b.WriteString("Resumo até aqui:\n" + cur.Text + "\n\n")
+		}
+		b.WriteString("Mensagens novas:\n")
+		for _, m := range msgs {
+			who := "Cliente"
+			if m.Role == "assistant" || m.Role == "operator" {
+				who = "Atendente"
+			}
+			content := m.Content
+			if len(content) > 500 {
+				content = content[:500]
+			}
+			fmt.Fprintf(&b, "%s: %s\n", who, content)
+		}
+		out, err := h.ai.ChatComplete(ctx, summaryPrompt, b.String(), 350)
+		if err != nil {
+			return cur.Text, err
+		}
+		if out = strings.TrimSpace(out); out == "" {
+			return cur.Text, errors.New("empty summary")
+		}
+		last := msgs[len(msgs)-1].ID
+		if _, err := models.SetClientSummary(ctx, h.pool, clientID, out, last); err != nil {
+			return cur.Text, err
+		}
+		cur.Text, cur.MessageID = out, last
+		min = 1 // rodadas seguintes só completam o atraso
+	}
+	return cur.Text, nil
+}
+
+// updateSummary roda em background após cada troca; falhas só são logadas.
+func (h *WebhookHandler) updateSummary(ctx context.Context, clientID int64) {
+	if !h.cfg.SummaryEnabled {
+		return
+	}
+	defer h.recoverWorker(ctx, "summary")
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+	if _, err := h.refreshSummary(ctx, clientID, h.cfg.SummaryEveryMessages); err != nil {
+		log.Printf("summary update error (client %d): %v", clientID, err)
+	}
+}
+
+// currentSummary devolve o resumo já com as últimas mensagens ("" se desligado ou
+// sem conversa). Em caso de falha usa o resumo gravado.
+func (h *WebhookHandler) currentSummary(ctx context.Context, clientID int64) string {
+	if !h.cfg.SummaryEnabled {
+		return ""
+	}
+	s, err := h.refreshSummary(ctx, clientID, 1)
+	if err != nil {
+		log.Printf("summary refresh error (client %d): %v", clientID, err)
+	}
+	return s
+}
+
+// SummaryHandler expõe /admin/clients/{phone}/summary.
+func (h *WebhookHandler) SummaryHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Method == http.MethodPost && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		client, ok, err := h.clients.ByPhone(ctx, r.PathValue("phone"))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, "client not found", http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPost {
+			if !h.cfg.SummaryEnabled {
+				http.Error(w, "summary disabled (SUMMARY_ENABLED)", http.StatusConflict)
+				return
+			}
+			if _, err := h.refreshSummary(ctx, client.ID, 1); err != nil {
+				writeErr(w, http.StatusBadGateway, "summary error", err)
+				return
+			}
+		}
+		cur, err := models.GetClientSummary(ctx, h.pool, client.ID)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		behind, err := models.CountMessagesAfter(ctx, h.pool, client.ID, cur.MessageID)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"phone": client.Phone, "summary": cur.Text, "message_id": cur.MessageID,
+			"updated_at": cur.UpdatedAt, "unsummarized_messages": behind,
+		})
+	}))
+}
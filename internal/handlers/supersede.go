@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Mensagem nova durante uma run (RUN_SUPERSEDE).
+
+Se o cliente corrige ou completa a pergunta enquanto a run da mensagem anterior
+ainda roda, a resposta chegaria à pergunta velha. A run nova sinaliza a anterior,
+que cancela a sua na OpenAI (POST /threads/{id}/runs/{id}/cancel) e sai sem
+responder; a nova espera a thread liberar, acrescenta a mensagem e roda com as
+mensagens juntas (as duas já estão na thread) e uma instrução avisando da
+correção. Run já concluída não é cancelada: a resposta dela segue e a nova só
+espera o envio terminar.
+*/
+
+// runSuperseded é o status devolvido por waitRun quando uma mensagem nova
+// substituiu a run.
+const runSuperseded = "superseded"
+
+const supersedeInstruction = "O cliente mandou outra mensagem enquanto você preparava a resposta anterior, que foi descartada. Responda considerando juntas as últimas mensagens dele; se uma corrigir a outra, vale a mais recente."
+
+// activeRun é o processamento em andamento de um telefone.
+type activeRun struct {
+	stop    chan struct{} // fechado quando uma mensagem nova substitui a run
+	done    chan struct{} // fechado quando o processamento termina
+	once    sync.Once
+	dropped atomic.Bool // saiu sem responder (a próxima responde pelas duas)
+}
+
+func (r *activeRun) supersede() { r.once.Do(func() { close(r.stop) }) }
+
+// superseded indica que uma mensagem nova substituiu a run. nil-safe.
+func (r *activeRun) superseded() bool {
+	if r == nil {
+		return false
+	}
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// stopped devolve o canal fechado na substituição (nil bloqueia para sempre).
+func (r *activeRun) stopped() <-chan struct{} {
+	if r == nil {
+		return nil
+	}
+	return r.stop
+}
+
+type activeRunKey struct{}
+
+func activeRunFrom(ctx context.Context) *activeRun {
+	r, _ := ctx.Value(activeRunKey{}).(*activeRun)
+	return r
+}
+
+// runTracker guarda o processamento em andamento por telefone (em memória, na
+// réplica que fez o flush do buffer).
+type runTracker struct {
+	mu      sync.Mutex
+	byPhone map[string]*activeRun
+}
+
+func newRunTracker() *runTracker {
+	return &runTracker{byPhone: make(map[string]*activeRun)}
+}
+
+// begin registra a run do telefone e sinaliza a anterior, se houver.
+func (t *runTracker) begin(phone string) (cur, prev *activeRun) {
+	cur = &activeRun{stop: make(chan struct{}), done: make(chan struct{})}
+	t.mu.Lock()
+	prev = t.byPhone[phone]
+	t.byPhone[phone] = cur
+	t.mu.Unlock()
+	if prev != nil {
+		prev.supersede()
+	}
+	return cur, prev
+}
+
+func (t *runTracker) end(phone string, r *activeRun) {
+	close(r.done)
+	t.mu.Lock()
+	if t.byPhone[phone] == r {
+		delete(t.byPhone, phone)
+	}
+	t.mu.Unlock()
+}
+
+// beginRun registra o processamento do telefone. Havendo um anterior em
+// andamento, interrompe a run dele e espera a thread liberar (até
+// RUN_TIMEOUT_SECONDS); merged indica que a anterior saiu sem responder.
+func (h *WebhookHandler) beginRun(ctx context.Context, phone string) (_ context.Context, end func(), merged bool) {
+	if !h.cfg.RunSupersede {
+		return ctx, func() {}, false
+	}
+	cur, prev := h.runs.begin(phone)
+	ctx = context.WithValue(ctx, activeRunKey{}, cur)
+	end = func() { h.runs.end(phone, cur) }
+	if prev == nil {
+		return ctx, end, false
+	}
+	wait := time.Duration(h.cfg.RunTimeoutSeconds) * time.Second
+	select {
+	case <-prev.done:
+	case <-time.After(wait):
+		log.Printf("previous run for %s still busy after %s", phone, wait)
+	}
+	return ctx, end, prev.dropped.Load()
+}
+
+// dropRun marca a run de ctx como substituída: sai sem responder.
+func (h *WebhookHandler) dropRun(ctx context.Context, phone string) {
+	if r := activeRunFrom(ctx); r != nil {
+		r.dropped.Store(true)
+	}
+	log.Printf("run for %s superseded by a newer message", phone)
+}
+
+// cancelRun cancela a run na OpenAI e espera ela sair do estado ativo, para a run
+// nova poder acrescentar mensagens à thread.
+func (h *WebhookHandler) cancelRun(ctx context.Context, clientID int64, phone, threadID, runID string) {
+	h.dropRun(ctx, phone)
+	if err := h.ai.CancelRun(ctx, threadID, runID); err != nil {
+		// já terminou (400) ou falha de rede: o polling abaixo decide
+		log.Printf("openai cancel run %s error: %v", runID, err)
+	}
+	deadline := time.Now().Add(time.Duration(h.cfg.RunTimeoutSeconds) * time.Second)
+	for time.Now().Before(deadline) {
+		run, err := h.ai.GetRunDetails(ctx, threadID, runID)
+		if err != nil {
+			log.Printf("openai cancel run %s poll error: %v", runID, err)
+			return
+		}
+		switch run.Status {
+		case "completed", "failed", "expired", "cancelled", "incomplete":
+			h.recordRunUsage(ctx, clientID, run)
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	log.Printf("openai run %s still active after cancel", runID)
+}
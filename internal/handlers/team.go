@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Avisos ao grupo da equipe (TEAM_NOTIFY_GROUP).
+
+Os eventos de TEAM_NOTIFY_EVENTS (novo lead, pedido de atendente, pagamento...)
+viram uma mensagem no grupo, enviada pelo número do bot, com o texto de
+TEAM_NOTIFY_TEMPLATES ({phone}, {name}, {content}, {time}). Cada evento fica
+registrado em team_notifications: com EVENT_BUS=postgres todas as réplicas recebem
+o evento, mas só a que registrar primeiro envia. Dentro de TEAM_QUIET_HOURS o aviso
+espera; no fim do silêncio (ou depois de uma falha de envio) o loop "team notify"
+manda os pendentes numa mensagem só.
+
+Só o tenant padrão (credenciais do ENV) avisa o grupo.
+*/
+
+const (
+	teamNotifyInterval = time.Minute
+	teamNotifyBatch    = 20
+)
+
+// subscribeTeamNotify liga os avisos ao barramento de eventos.
+func (h *WebhookHandler) subscribeTeamNotify() {
+	if h.cfg.TeamNotifyGroup == "" || h.events == nil || len(h.cfg.TeamNotifyEvents) == 0 {
+		return
+	}
+	h.events.Subscribe(events.All, func(_ context.Context, ev events.Event) {
+		if ev.Tenant != h.tenantID || !slices.Contains(h.cfg.TeamNotifyEvents, ev.Topic) {
+			return
+		}
+		ctx := h.scope(context.Background())
+		defer h.recoverWorker(ctx, "team notify")
+		h.teamNotify(ctx, ev)
+	})
+}
+
+// teamNotify registra o aviso do evento e, fora do horário de silêncio, envia.
+func (h *WebhookHandler) teamNotify(ctx context.Context, ev events.Event) {
+	text := h.teamText(ctx, ev)
+	if text == "" {
+		return
+	}
+	id, claimed, err := models.ClaimTeamNotification(ctx, h.pool, teamEventKey(ev), ev.Topic, text)
+	if err != nil {
+		log.Printf("db team notification error: %v", err)
+		return
+	}
+	if !claimed || h.cfg.InTeamQuietHours(time.Now()) {
+		return
+	}
+	h.sendTeamNotice(ctx, []int64{id}, text)
+}
+
+// teamText monta o texto do aviso com o modelo do tópico ("" = tópico sem modelo).
+func (h *WebhookHandler) teamText(ctx context.Context, ev events.Event) string {
+	tpl := h.cfg.TeamNotifyTemplates[ev.Topic]
+	if tpl == "" {
+		return ""
+	}
+	name := "cliente"
+	if ev.Phone != "" && strings.Contains(tpl, "{name}") {
+		if c, ok, err := h.clients.ByPhone(ctx, ev.Phone); err == nil && ok && c.Name != nil && *c.Name != "" {
+			name = *c.Name
+		}
+	}
+	at := ev.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return strings.NewReplacer(
+		"{phone}", ev.Phone,
+		"{name}", name,
+		"{content}", ev.Content,
+		"{time}", at.In(h.cfg.Location()).Format("02/01 15:04"),
+	).Replace(tpl)
+}
+
+// teamEventKey identifica o evento igual em todas as réplicas (o barramento
+// distribui o mesmo At).
+func teamEventKey(ev events.Event) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		ev.Topic, strconv.FormatInt(ev.Tenant, 10), ev.Phone, ev.ExtID, ev.Content,
+		strconv.FormatInt(ev.At.UnixNano(), 10),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// sendTeamNotice envia text ao grupo e registra o resultado dos avisos ids.
+func (h *WebhookHandler) sendTeamNotice(ctx context.Context, ids []int64, text string) {
+	_, err := h.wpp.SendText(ctx, h.cfg.TeamNotifyGroup, text)
+	if err != nil {
+		log.Println("uazapi send team notice error:", err)
+	}
+	if ferr := models.FinishTeamNotifications(ctx, h.pool, ids, err); ferr != nil {
+		log.Printf("db finish team notification error: %v", ferr)
+	}
+}
+
+// teamNotifyLoop envia os avisos pendentes fora do horário de silêncio (uma réplica por vez).
+func (h *WebhookHandler) teamNotifyLoop(ctx context.Context) {
+	ctx = h.scope(ctx)
+	t := time.NewTicker(teamNotifyInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-t.C:
+			if h.cfg.InTeamQuietHours(tick) {
+				continue
+			}
+			name := fmt.Sprintf("team_notify:%d", h.tenantID)
+			if _, err := h.sched.Once(ctx, name, tick.Truncate(teamNotifyInterval), h.flushTeamNotices); err != nil {
+				log.Printf("team notify flush error: %v", err)
+			}
+		}
+	}
+}
+
+// flushTeamNotices junta os avisos pendentes numa mensagem.
+func (h *WebhookHandler) flushTeamNotices(ctx context.Context) error {
+	pending, err := models.PendingTeamNotifications(ctx, h.pool, teamNotifyBatch)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+	ids := make([]int64, 0, len(pending))
+	lines := make([]string, 0, len(pending))
+	for _, n := range pending {
+		ids = append(ids, n.ID)
+		lines = append(lines, n.Text)
+	}
+	text := lines[0]
+	if len(lines) > 1 {
+		text = fmt.Sprintf("%d avisos pendentes:\n\n%s", len(lines), strings.Join(lines, "\n\n"))
+	}
+	h.sendTeamNotice(ctx, ids, text)
+	return nil
+}
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+// Tenants guarda um pipeline (WebhookHandler) por tenant, montado na primeira
+// mensagem com as credenciais do tenant. O tenant padrão (id 0) usa as do ENV.
+// Auth, ajustes, orçamento, captura, feed e barramento são do processo e compartilhados.
+type Tenants struct {
+	def *WebhookHandler
+
+	mu       sync.Mutex
+	handlers map[int64]*WebhookHandler
+}
+
+func newTenants(def *WebhookHandler) *Tenants {
+	return &Tenants{def: def, handlers: map[int64]*WebhookHandler{}}
+}
+
+// Tenants devolve o registro de tenants (rotas que escolhem o pipeline pelo tenant).
+func (h *WebhookHandler) Tenants() *Tenants { return h.tenants }
+
+// handler devolve o pipeline do tenant, remontando-o se o cadastro mudou desde a
+// montagem. Os loops do pipeline antigo param (credenciais velhas ou revogadas);
+// o modo manutenção do tenant passa para o novo.
+func (t *Tenants) handler(tn models.Tenant) *WebhookHandler {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	old, ok := t.handlers[tn.ID]
+	if ok && old.tenantUpdated.Equal(tn.UpdatedAt) {
+		return old
+	}
+	maint := &maintenance{}
+	if ok {
+		old.stop()
+		maint = old.maint
+	}
+	h := newTenantHandler(t.def, tn, maint)
+	t.handlers[tn.ID] = h
+	return h
+}
+
+// drop tira do registro o pipeline do tenant desativado ou apagado e para os
+// loops dele, que senão seguiriam enviando com as credenciais do tenant.
+func (t *Tenants) drop(id int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.handlers[id]; ok {
+		delete(t.handlers, id)
+		old.stop()
+		log.Printf("tenant %d pipeline stopped (inactive or removed)", id)
+	}
+}
+
+// lookup resolve o tenant por find. nil sem tenant ativo.
+func (t *Tenants) lookup(ctx context.Context, find func(context.Context, models.DB, string) (models.Tenant, bool, error), key string) (*WebhookHandler, error) {
+	tn, ok, err := find(ctx, t.def.pool, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if !tn.Active {
+		t.drop(tn.ID)
+		return nil, nil
+	}
+	return t.handler(tn), nil
+}
+
+// ByID devolve o pipeline do tenant id (0 = padrão).
+func (t *Tenants) ByID(ctx context.Context, id int64) (*WebhookHandler, error) {
+	if id == 0 {
+		return t.def, nil
+	}
+	tn, ok, err := models.GetTenant(ctx, t.def.pool, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || !tn.Active {
+		t.drop(id)
+		return nil, nil
+	}
+	return t.handler(tn), nil
+}
+
+// tenantPruneInterval é a frequência com que cada réplica confere os pipelines
+// montados contra o cadastro (tenant desativado em outra réplica).
+const tenantPruneInterval = time.Minute
+
+// pruneLoop para os pipelines de tenants desativados ou apagados, mesmo sem
+// nova mensagem para eles.
+func (t *Tenants) pruneLoop(ctx context.Context) {
+	tick := time.NewTicker(tenantPruneInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			t.prune(ctx)
+		}
+	}
+}
+
+func (t *Tenants) prune(ctx context.Context) {
+	t.mu.Lock()
+	ids := make([]int64, 0, len(t.handlers))
+	for id := range t.handlers {
+		ids = append(ids, id)
+	}
+	t.mu.Unlock()
+	for _, id := range ids {
+		tn, ok, err := models.GetTenant(ctx, t.def.pool, id)
+		if err != nil {
+			log.Printf("db tenant %d prune error: %v", id, err)
+			continue
+		}
+		if !ok || !tn.Active {
+			t.drop(id)
+		}
+	}
+}
+
+// BySlug devolve o pipeline do tenant com o slug dado.
+func (t *Tenants) BySlug(ctx context.Context, slug string) (*WebhookHandler, error) {
+	return t.lookup(ctx, models.GetTenantBySlug, slug)
+}
+
+// ByToken devolve o pipeline do tenant dono do token.
+func (t *Tenants) ByToken(ctx context.Context, token string) (*WebhookHandler, error) {
+	return t.lookup(ctx, models.GetTenantByToken, token)
+}
+
+// all devolve os pipelines montados até agora, o padrão primeiro.
+func (t *Tenants) all() []*WebhookHandler {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := []*WebhookHandler{t.def}
+	for _, h := range t.handlers {
+		out = append(out, h)
+	}
+	return out
+}
+
+// newTenantHandler monta o pipeline do tenant: a configuração do ENV com as
+// credenciais do tenant por cima (campos vazios herdam o ENV).
+func newTenantHandler(def *WebhookHandler, tn models.Tenant, maint *maintenance) *WebhookHandler {
+	cfg := def.cfg
+	override := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	override(&cfg.OpenAIAssistantID, tn.OpenAIAssistantID)
+	override(&cfg.UazapiBaseSend, tn.UazapiBaseSend)
+	override(&cfg.UazapiTokenSend, tn.UazapiTokenSend)
+	override(&cfg.UazapiBaseDownload, tn.UazapiBaseDownload)
+	override(&cfg.UazapiTokenDownload, tn.UazapiTokenDownload)
+	if tn.OpenAIAssistantID != "" {
+		// os assistentes nomeados do ENV são do tenant padrão
+		cfg.Assistants = map[string]string{"default": tn.OpenAIAssistantID}
+	}
+	cfg.IngestToken = tn.Token
+
+	// mesmo transporte HTTP do tenant padrão, só trocando chave e assistente
+	ai := def.ai.WithOptions(openai.RequestOptions{APIKey: tn.OpenAIAPIKey, AssistantID: tn.OpenAIAssistantID})
+	h := newWebhookHandler(cfg, def.pool, def.feed, def.events, def.capture, def.upstream, ai)
+	h.auth = def.auth
+	h.settings = def.settings
+	h.budget = def.budget
+	h.sched = def.sched
+	h.batches = def.batches
+	h.load = def.load
+	h.nonces = def.nonces
+	h.queue = def.queue
+	h.audio = def.audio
+	h.tenants = def.tenants
+	h.maint = maint
+	h.tenantID = tn.ID
+	h.tenantUpdated = tn.UpdatedAt
+	h.start()
+	log.Printf("tenant %s (%d) pipeline ready", tn.Slug, tn.ID)
+	return h
+}
+
+// scope marca ctx com o tenant deste pipeline (consultas e eventos ficam nele).
+func (h *WebhookHandler) scope(ctx context.Context) context.Context {
+	return models.WithTenant(ctx, h.tenantID)
+}
+
+// scoped devolve o pipeline do tenant de ctx (rotas administrativas que enviam
+// mensagens ou falam com o assistente precisam das credenciais do tenant).
+func (h *WebhookHandler) scoped(ctx context.Context) *WebhookHandler {
+	id, ok := models.TenantFrom(ctx)
+	if !ok || id == h.tenantID {
+		return h
+	}
+	th, err := h.tenants.ByID(ctx, id)
+	if err != nil {
+		log.Printf("tenant %d lookup error: %v", id, err)
+	}
+	if th == nil {
+		return h
+	}
+	return th
+}
+
+// tenantToken lê o token do tenant no webhook (cabeçalho ou query, já que nem
+// todo provedor deixa configurar cabeçalhos).
+func tenantToken(r *http.Request) string {
+	if t := r.Header.Get("X-Tenant-Token"); t != "" {
+		return t
+	}
+	return r.URL.Query().Get("tenant_token")
+}
+
+// WebhookHandler despacha o webhook para o pipeline do tenant:
+//
+//	POST /webhook/t/{tenant}                         pelo slug
+//	POST /webhook/Leandro-JW  (X-Tenant-Token)       pelo token; sem token, tenant padrão
+func (t *Tenants) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			h   *WebhookHandler
+			err error
+		)
+		switch slug, token := r.PathValue("tenant"), tenantToken(r); {
+		case slug != "":
+			h, err = t.BySlug(r.Context(), slug)
+		case token != "":
+			h, err = t.ByToken(r.Context(), token)
+		default:
+			h = t.def
+		}
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if h == nil {
+			http.Error(w, "unknown tenant", http.StatusNotFound)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// byIngestToken escolhe o pipeline pelo token da API (INGEST_TOKEN = tenant padrão).
+// Sem tenant com o token, cai no padrão, que responde 401.
+func (t *Tenants) byIngestToken(w http.ResponseWriter, r *http.Request) (*WebhookHandler, bool) {
+	token := requestToken(r)
+	if token == "" || token == t.def.cfg.IngestToken {
+		return t.def, true
+	}
+	h, err := t.ByToken(r.Context(), token)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "db error", err)
+		return nil, false
+	}
+	if h == nil {
+		return t.def, true
+	}
+	return h, true
+}
+
+// IngestHandler expõe POST /api/v1/inbound para todos os tenants (token do tenant).
+func (t *Tenants) IngestHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := t.byIngestToken(w, r); ok {
+			h.IngestHandler().ServeHTTP(w, r)
+		}
+	})
+}
+
+// SendHandler expõe POST /api/send para todos os tenants (token do tenant).
+func (t *Tenants) SendHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := t.byIngestToken(w, r); ok {
+			h.SendHandler().ServeHTTP(w, r)
+		}
+	})
+}
+
+// ConfirmationHandler expõe POST /api/v1/confirmations para todos os tenants (token do tenant).
+func (t *Tenants) ConfirmationHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := t.byIngestToken(w, r); ok {
+			h.ConfirmationHandler().ServeHTTP(w, r)
+		}
+	})
+}
+
+// WidgetTokenHandler expõe POST /api/v1/widget/tokens para todos os tenants (token do tenant).
+func (t *Tenants) WidgetTokenHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := t.byIngestToken(w, r); ok {
+			h.WidgetTokenHandler().ServeHTTP(w, r)
+		}
+	})
+}
+
+// WidgetHandler expõe /widget/v1/messages: o token assinado do widget escolhe o
+// tenant e o telefone.
+func (t *Tenants) WidgetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t.def.widgetCORS(w, r) {
+			return
+		}
+		token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if token == "" {
+			token = r.URL.Query().Get("t")
+		}
+		claims, err := parseWidgetToken(t.def.cfg.WidgetSecret, token, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		h, err := t.ByID(r.Context(), claims.Tenant)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if h == nil {
+			http.Error(w, "unknown tenant", http.StatusNotFound)
+			return
+		}
+		h.serveWidget(w, r, claims.Phone)
+	})
+}
+
+// PaymentWebhookHandler expõe POST /webhook/payments/{provider}: ?tenant_token= escolhe
+// o tenant (sem ele, tenant padrão).
+func (t *Tenants) PaymentWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := t.def
+		if token := tenantToken(r); token != "" {
+			th, err := t.ByToken(r.Context(), token)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if th == nil {
+				http.Error(w, "unknown tenant", http.StatusNotFound)
+				return
+			}
+			h = th
+		}
+		h.PaymentWebhookHandler().ServeHTTP(w, r)
+	})
+}
+
+// StatusHandler expõe GET /status/{id} procurando em todos os pipelines.
+func (t *Tenants) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		for _, h := range t.all() {
+			if st, ok := h.statuses.get(id); ok {
+				writeJSON(w, http.StatusOK, st)
+				return
+			}
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+}
+
+var tenantSlugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// TenantsHandler cadastra os tenants (admin sem tenant):
+//
+//	GET  /admin/tenants
+//	POST /admin/tenants {"slug":"loja-x","name":"Loja X","token":"...","openai_api_key":"...",
+//	                     "openai_assistant_id":"asst_...","uazapi_base_send":"...","uazapi_token_send":"...",
+//	                     "uazapi_base_download":"...","uazapi_token_download":"...","active":true}
+//
+// POST com slug existente atualiza; segredos omitidos mantêm o valor salvo e nunca voltam na resposta.
+func (t *Tenants) TenantsHandler() http.Handler {
+	return t.def.auth.RequireRoot(RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		switch r.Method {
+		case http.MethodGet:
+			list, err := models.ListTenants(ctx, t.def.pool)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+
+		case http.MethodPost, http.MethodPut:
+			var in struct {
+				Slug                string `json:"slug"`
+				Name                string `json:"name"`
+				Token               string `json:"token"`
+				OpenAIAPIKey        string `json:"openai_api_key"`
+				OpenAIAssistantID   string `json:"openai_assistant_id"`
+				UazapiBaseSend      string `json:"uazapi_base_send"`
+				UazapiTokenSend     string `json:"uazapi_token_send"`
+				UazapiBaseDownload  string `json:"uazapi_base_download"`
+				UazapiTokenDownload string `json:"uazapi_token_download"`
+				Active              *bool  `json:"active"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			in.Slug = strings.ToLower(strings.TrimSpace(in.Slug))
+			if !tenantSlugRe.MatchString(in.Slug) || in.Slug == "default" {
+				http.Error(w, "invalid slug", http.StatusBadRequest)
+				return
+			}
+			if in.Name == "" {
+				in.Name = in.Slug
+			}
+			tn := models.Tenant{
+				Slug: in.Slug, Name: in.Name, Token: in.Token,
+				OpenAIAPIKey: in.OpenAIAPIKey, OpenAIAssistantID: in.OpenAIAssistantID,
+				UazapiBaseSend: in.UazapiBaseSend, UazapiTokenSend: in.UazapiTokenSend,
+				UazapiBaseDownload: in.UazapiBaseDownload, UazapiTokenDownload: in.UazapiTokenDownload,
+				Active: in.Active == nil || *in.Active,
+			}
+			saved, err := models.UpsertTenant(ctx, t.def.pool, tn)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			if !saved.Active {
+				t.drop(saved.ID)
+			}
+			p, _ := principalFrom(ctx)
+			log.Printf("tenant %s saved by %s", saved.Slug, p.Name)
+			writeJSON(w, http.StatusOK, saved)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
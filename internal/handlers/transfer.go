@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/tools"
+)
+
+var (
+	errUnknownAssistant = errors.New("unknown assistant")
+	errSameAssistant    = errors.New("conversation is already with this assistant")
+)
+
+// assistantFor devolve o nome e o ID (OpenAI) do assistente atual do cliente.
+// ID vazio = assistente padrão do cliente OpenAI.
+func (h *WebhookHandler) assistantFor(ctx context.Context, clientID int64) (string, string) {
+	name, err := models.GetClientAssistant(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("assistant load error: %v", err)
+		return models.DefaultAssistant, ""
+	}
+	return name, h.cfg.Assistants[name]
+}
+
+// transferConversation move o cliente para outro assistente: guarda a thread atual,
+// reutiliza (ou cria) a thread do destino, leva um resumo da conversa como contexto
+// e registra a transferência em assistant_transfers.
+func (h *WebhookHandler) transferConversation(ctx context.Context, client models.Client, to, reason, actor string) (models.Transfer, error) {
+	to = strings.TrimSpace(to)
+	if _, ok := h.cfg.Assistants[to]; !ok {
+		return models.Transfer{}, fmt.Errorf("%w %q", errUnknownAssistant, to)
+	}
+	from, _ := h.assistantFor(ctx, client.ID)
+	if from == to {
+		return models.Transfer{}, errSameAssistant
+	}
+
+	summary := h.conversationSummary(ctx, client.ID)
+
+	threadID, ok, err := models.GetAssistantThread(ctx, h.pool, client.ID, to)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	note := "[Contexto interno — conversa transferida do assistente \"" + from + "\"]"
+	if reason != "" {
+		note += "\nMotivo: " + reason
+	}
+	if summary != "" {
+		note += "\nResumo até aqui: " + summary
+	}
+	// a thread guardada pode ter sido apagada (retenção): cria outra se falhar
+	if !ok || h.ai.AddUserMessage(ctx, threadID, note) != nil {
+		if threadID, err = h.ai.CreateThread(ctx); err != nil {
+			return models.Transfer{}, err
+		}
+		if err := h.ai.AddUserMessage(ctx, threadID, note); err != nil {
+			return models.Transfer{}, err
+		}
+	}
+
+	var t models.Transfer
+	err = models.WithTx(ctx, h.pool, func(tx pgx.Tx) error {
+		if client.ThreadID != nil && *client.ThreadID != "" {
+			if err := models.SaveAssistantThread(ctx, tx, client.ID, from, *client.ThreadID); err != nil {
+				return err
+			}
+		}
+		if err := models.SaveAssistantThread(ctx, tx, client.ID, to, threadID); err != nil {
+			return err
+		}
+		if err := models.SetClientThread(ctx, tx, client.ID, threadID); err != nil {
+			return err
+		}
+		if err := models.SetClientAssistant(ctx, tx, client.ID, to); err != nil {
+			return err
+		}
+		t, err = models.RecordTransfer(ctx, tx, models.Transfer{
+			ClientID: client.ID, FromAssistant: from, ToAssistant: to, ThreadID: threadID,
+			Summary: summary, Reason: reason, Actor: actor,
+		})
+		return err
+	})
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	log.Printf("conversation %s transferred %s -> %s by %s", client.Phone, from, to, actor)
+	h.publish(ctx, events.Event{Topic: events.ConversationTransferred, Phone: client.Phone, ClientID: client.ID,
+		Role: "system", Type: "transfer", Content: "conversa transferida de " + from + " para " + to})
+	return t, nil
+}
+
+// conversationSummary resume a conversa para o assistente de destino: o resumo
+// contínuo (SUMMARY_ENABLED) ou, sem ele, as últimas mensagens.
+func (h *WebhookHandler) conversationSummary(ctx context.Context, clientID int64) string {
+	if s := h.currentSummary(ctx, clientID); s != "" {
+		return s
+	}
+	msgs, err := h.messages.Recent(ctx, clientID, 30)
+	if err != nil || len(msgs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, m := range msgs {
+		who := "Cliente"
+		if m.Role == "assistant" || m.Role == "operator" {
+			who = "Atendente"
+		}
+		content := m.Content
+		if len(content) > 500 {
+			content = content[:500]
+		}
+		fmt.Fprintf(&b, "%s: %s\n", who, content)
+	}
+	out, err := h.ai.ChatComplete(ctx,
+		"Resuma esta conversa de WhatsApp para outro atendente que vai continuar o atendimento: "+
+			"quem é o cliente, o que ele quer, o que já foi informado e o que está pendente. "+
+			"No máximo 6 frases, em Português.",
+		b.String(), 300)
+	if err != nil {
+		log.Printf("transfer summary error: %v", err)
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// registerTransferTool expõe transfer_conversation ao assistente quando há mais de
+// um assistente configurado. A troca vale a partir da próxima mensagem do cliente.
+func (h *WebhookHandler) registerTransferTool() {
+	if len(h.cfg.Assistants) < 2 {
+		return
+	}
+	names := make([]string, 0, len(h.cfg.Assistants))
+	for name := range h.cfg.Assistants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h.tools.Register(tools.Tool{
+		Def: openai.FunctionTool{
+			Name:        "transfer_conversation",
+			Description: "Transfere a conversa para outro assistente especializado (ex.: de vendas para suporte). Use quando o assunto for de outra área.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"to":     map[string]any{"type": "string", "enum": names, "description": "Assistente de destino"},
+					"reason": map[string]any{"type": "string", "description": "Motivo da transferência"},
+				},
+				"required": []string{"to"},
+			},
+		},
+		Run: func(ctx context.Context, call tools.Call, args json.RawMessage) (any, error) {
+			var in struct {
+				To     string `json:"to"`
+				Reason string `json:"reason"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			client, ok, err := h.clients.ByPhone(ctx, call.Phone)
+			if err != nil || !ok {
+				return nil, fmt.Errorf("client not found: %v", err)
+			}
+			t, err := h.scoped(ctx).transferConversation(ctx, client, in.To, in.Reason, "assistant")
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"ok": true, "to": t.ToAssistant}, nil
+		},
+	})
+}
+
+// TransferHandler move conversas entre assistentes:
+//
+//	GET  /admin/clients/{phone}/transfers               histórico (analyst)
+//	POST /admin/clients/{phone}/transfer {"to","reason"} transfere (operator)
+func (h *WebhookHandler) TransferHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		client, ok, err := h.clients.ByPhone(ctx, r.PathValue("phone"))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, "client not found", http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			list, err := models.ListTransfers(ctx, h.pool, client.ID)
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+
+		case http.MethodPost:
+			if !hasRole(r, RoleOperator) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			var in struct {
+				To     string `json:"to"`
+				Reason string `json:"reason"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			p, _ := principalFrom(ctx)
+			t, err := h.transferConversation(ctx, client, in.To, in.Reason, p.Name)
+			switch {
+			case errors.Is(err, errUnknownAssistant), errors.Is(err, errSameAssistant):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case err != nil:
+				writeErr(w, http.StatusInternalServerError, "transfer error", err)
+			default:
+				writeJSON(w, http.StatusOK, t)
+			}
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
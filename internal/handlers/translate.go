@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+/*
+Clientes que escrevem em outro idioma (TRANSLATE_MODE).
+
+Mensagens de texto e áudio que não parecem português passam por uma detecção de
+idioma no modelo de chat; o idioma fica em clients.language. Com o modo:
+
+  - translate: o assistente recebe a mensagem traduzida para o português e a
+    resposta volta traduzida para o idioma do cliente. O histórico guarda em
+    content o que passou pelo WhatsApp e em content_translated a versão em português;
+  - instruct: a mensagem vai como veio e a run recebe a instrução de responder no
+    idioma do cliente.
+
+Mensagens menores que TRANSLATE_MIN_CHARS ("ok", "sim") não trocam o idioma
+detectado. O operador pode fixar o idioma de um cliente:
+
+	GET /admin/clients/{phone}/language   idioma detectado e fixado (analyst)
+	PUT /admin/clients/{phone}/language   {"language": "en"} (operator); "pt" desliga a
+	                                      tradução do cliente, "" volta à detecção
+*/
+
+const detectLanguagePrompt = "Identifique o idioma da mensagem de WhatsApp a seguir. Na primeira linha responda só o " +
+	"código ISO 639-1 do idioma (ex.: pt, en, es). Se não for português, escreva nas linhas seguintes a tradução fiel " +
+	"para o português do Brasil, sem comentários."
+
+const translatePrompt = "Traduza a mensagem a seguir para %s. Mantenha a formatação do WhatsApp (*negrito*, _itálico_, " +
+	"listas), emojis, links, números e nomes próprios. Responda só com a tradução."
+
+var languageCodeRe = regexp.MustCompile(`^[a-z]{2}$`)
+
+var languageNames = map[string]string{
+	"pt": "português", "en": "inglês", "es": "espanhol", "fr": "francês", "it": "italiano",
+	"de": "alemão", "nl": "holandês", "ru": "russo", "zh": "chinês", "ja": "japonês",
+	"ko": "coreano", "ar": "árabe", "hi": "hindi", "he": "hebraico", "tr": "turco",
+}
+
+// languageName devolve o nome do idioma em português (o código se desconhecido).
+func languageName(code string) string {
+	if n, ok := languageNames[code]; ok {
+		return n + " (" + code + ")"
+	}
+	return code
+}
+
+// translateInbound devolve o texto para o assistente e, quando a mensagem é de
+// outro idioma, o idioma dela e a versão em português (para o histórico).
+func (h *WebhookHandler) translateInbound(ctx context.Context, clientID int64, phone, text string) (string, string, *string) {
+	if h.cfg.TranslateMode == "off" || strings.TrimSpace(text) == "" {
+		return text, "", nil
+	}
+	cur, err := models.GetClientLanguage(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("db client language error: %v", err)
+		return text, "", nil
+	}
+	if cur.Override == "pt" {
+		return text, "", nil
+	}
+	lang, pt := cur.Override, ""
+	if lang == "" {
+		short := len([]rune(strings.TrimSpace(text))) < h.cfg.TranslateMinChars
+		switch {
+		case short:
+			lang = cur.Detected
+		case processor.LooksPortuguese(text):
+			lang = "pt"
+		default:
+			if lang, pt, err = h.detectLanguage(ctx, text); err != nil {
+				log.Printf("language detection error (client %d): %v", clientID, err)
+				return text, "", nil
+			}
+		}
+		if !short && lang != cur.Detected {
+			if err := models.SetClientDetectedLanguage(ctx, h.pool, clientID, lang); err != nil {
+				log.Printf("db set client language error: %v", err)
+			}
+		}
+	}
+	if lang == "" || lang == "pt" {
+		return text, "", nil
+	}
+	if h.cfg.TranslateMode != "translate" {
+		return text, lang, nil
+	}
+	if pt == "" {
+		if pt, err = h.translateText(ctx, text, "pt"); err != nil {
+			h.fail(phone, "translate inbound", err)
+			return text, lang, nil
+		}
+	}
+	return pt, lang, &pt
+}
+
+// detectLanguage devolve o código do idioma de text e, se não for português, a tradução.
+func (h *WebhookHandler) detectLanguage(ctx context.Context, text string) (string, string, error) {
+	out, err := h.ai.ChatComplete(ctx, detectLanguagePrompt, text, 1200)
+	if err != nil {
+		return "", "", err
+	}
+	first, rest, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	lang := strings.ToLower(strings.Trim(strings.TrimSpace(first), ".:`\"'"))
+	if !languageCodeRe.MatchString(lang) {
+		return "", "", fmt.Errorf("unexpected language code %q", first)
+	}
+	if lang == "pt" {
+		return lang, "", nil
+	}
+	return lang, strings.TrimSpace(rest), nil
+}
+
+// translateText traduz text para o idioma lang.
+func (h *WebhookHandler) translateText(ctx context.Context, text, lang string) (string, error) {
+	out, err := h.ai.ChatComplete(ctx, fmt.Sprintf(translatePrompt, languageName(lang)), text, 1200)
+	if err != nil {
+		return "", err
+	}
+	if out = strings.TrimSpace(out); out == "" {
+		return "", errors.New("empty translation")
+	}
+	return out, nil
+}
+
+// replyLanguage devolve o idioma da conversa quando não é português ("" caso contrário).
+func (h *WebhookHandler) replyLanguage(ctx context.Context, clientID int64) string {
+	if h.cfg.TranslateMode == "off" {
+		return ""
+	}
+	cur, err := models.GetClientLanguage(ctx, h.pool, clientID)
+	if err != nil {
+		log.Printf("db client language error: %v", err)
+		return ""
+	}
+	if lang := cur.Effective(); lang != "pt" {
+		return lang
+	}
+	return ""
+}
+
+// languageInstruction pede ao assistente a resposta no idioma do cliente (modo instruct).
+func languageInstruction(lang string) string {
+	return "O cliente escreve em " + languageName(lang) + ": responda sempre nesse idioma."
+}
+
+type replyTranslationKey struct{}
+
+type replyTranslation struct {
+	lang, text, pt string
+}
+
+// translateReply traduz a resposta para lang. O ctx devolvido faz o histórico
+// guardar a resposta em português junto com a traduzida. Se a tradução falhar, a
+// resposta sai em português.
+func (h *WebhookHandler) translateReply(ctx context.Context, phone, lang, reply string) (context.Context, string) {
+	out, err := h.translateText(ctx, reply, lang)
+	if err != nil {
+		h.fail(phone, "translate reply", err)
+		return ctx, reply
+	}
+	return context.WithValue(ctx, replyTranslationKey{}, replyTranslation{lang: lang, text: out, pt: reply}), out
+}
+
+// withReplyTranslation completa a mensagem do assistente traduzida por translateReply.
+func withReplyTranslation(ctx context.Context, m models.Message) models.Message {
+	tr, ok := ctx.Value(replyTranslationKey{}).(replyTranslation)
+	if !ok || m.Role != "assistant" || m.Translated != nil || m.Content != tr.text {
+		return m
+	}
+	pt := tr.pt
+	m.Language, m.Translated = tr.lang, &pt
+	return m
+}
+
+// LanguageHandler expõe /admin/clients/{phone}/language.
+func (h *WebhookHandler) LanguageHandler() http.Handler {
+	return h.auth.Require(RoleAnalyst, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		h := h.scoped(ctx)
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Method == http.MethodPut && !hasRole(r, RoleOperator) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		client, ok, err := h.clients.ByPhone(ctx, r.PathValue("phone"))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if !ok {
+			http.Error(w, "client not found", http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPut {
+			var in struct {
+				Language string `json:"language"`
+			}
+			if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			lang := strings.ToLower(strings.TrimSpace(in.Language))
+			if lang != "" && !languageCodeRe.MatchString(lang) {
+				http.Error(w, "language must be an ISO 639-1 code (e.g. en, es) or empty", http.StatusBadRequest)
+				return
+			}
+			if err := models.SetClientLanguageOverride(ctx, h.pool, client.ID, lang); err != nil {
+				writeErr(w, http.StatusInternalServerError, "db error", err)
+				return
+			}
+		}
+		cur, err := models.GetClientLanguage(ctx, h.pool, client.ID)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"phone": client.Phone, "mode": h.cfg.TranslateMode, "detected": cur.Detected,
+			"override": cur.Override, "effective": cur.Effective(),
+		})
+	}))
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/tts"
+)
+
+// speechCacheKey identifica o áudio por voz, velocidade e texto normalizado.
+// Mudar TTS_PROVIDER/TTS_VOICE/TTS_SPEED gera chaves novas (invalidação implícita).
+func speechCacheKey(voice string, speed float64, text string) string {
+	sum := sha256.Sum256([]byte(voice + "|" + strconv.FormatFloat(speed, 'f', 3, 64) + "|" + strings.TrimSpace(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+// speech gera o áudio TTS (provedor de TTS_PROVIDER, voz/velocidade de cfg) usando o
+// cache para frases curtas e repetidas.
+func (h *WebhookHandler) speech(ctx context.Context, cfg config.Config, text string) ([]byte, error) {
+	cacheable := h.cfg.TTSCacheEnabled && len(text) <= h.cfg.TTSCacheMaxChars
+	if !cacheable {
+		return h.tts.Speech(ctx, text, cfg.TTSVoice, cfg.TTSSpeed)
+	}
+	ttl := time.Duration(h.cfg.TTSCacheTTLHours) * time.Hour
+	voice := tts.CacheVoice(h.tts, cfg.TTSVoice)
+	key := speechCacheKey(voice, cfg.TTSSpeed, text)
+	if audio, ok, err := models.GetCachedSpeech(ctx, h.pool, key, ttl); err != nil {
+		log.Printf("tts cache read error: %v", err)
+	} else if ok {
+		return audio, nil
+	}
+
+	audio, err := h.tts.Speech(ctx, text, cfg.TTSVoice, cfg.TTSSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.PutCachedSpeech(ctx, h.pool, key, voice, cfg.TTSSpeed, audio); err != nil {
+		log.Printf("tts cache write error: %v", err)
+	}
+	return audio, nil
+}
+
+// purgeSpeechCache remove entradas expiradas ou de outra voz/velocidade.
+// Vozes definidas em bot_settings saem daqui e são regeneradas sob demanda.
+func (h *WebhookHandler) purgeSpeechCache(ctx context.Context) {
+	if !h.cfg.TTSCacheEnabled {
+		return
+	}
+	ttl := time.Duration(h.cfg.TTSCacheTTLHours) * time.Hour
+	n, err := models.PurgeSpeechCache(ctx, h.pool, tts.CacheVoice(h.tts, h.cfg.TTSVoice), h.cfg.TTSSpeed, ttl)
+	if err != nil {
+		log.Printf("tts cache purge error: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("tts cache purged %d entries", n)
+	}
+}
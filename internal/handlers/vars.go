@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+// replyVars monta as variáveis disponíveis para as respostas do assistente:
+// dados do negócio (BUSINESS_VARS) + dados do cliente. As do cliente têm prioridade.
+func (h *WebhookHandler) replyVars(client models.Client) map[string]string {
+	cfg := h.botConfig(context.Background(), client.Phone)
+	vars := make(map[string]string, len(cfg.BusinessVars)+5)
+	for k, v := range cfg.BusinessVars {
+		vars[k] = v
+	}
+	name := ""
+	if client.Name != nil {
+		name = strings.TrimSpace(*client.Name)
+	}
+	first := name
+	if i := strings.IndexByte(first, ' '); i > 0 {
+		first = first[:i]
+	}
+	now := time.Now().In(h.cfg.Location())
+	vars["nome"] = name
+	vars["primeiro_nome"] = first
+	vars["telefone"] = client.Phone
+	vars["data_hoje"] = now.Format("02/01/2006")
+	vars["hora_agora"] = now.Format("15:04")
+	return vars
+}
+
+// interpolateReply substitui {{placeholders}} na resposta do assistente.
+func (h *WebhookHandler) interpolateReply(client models.Client, reply string) string {
+	return processor.Interpolate(reply, h.replyVars(client))
+}
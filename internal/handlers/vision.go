@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/tools"
+)
+
+/*
+Memória de imagens (VISION_FOLLOWUP).
+
+Cada foto recebida (mensagem avulsa, álbum ou /ingest) fica em client_images com
+o messageid do WhatsApp e a URL usada na descrição, e a descrição que vai ao
+assistente leva a marca da foto ("[imagem #57]"). Numa pergunta seguinte ("e o
+que está escrito no canto da foto?") o assistente chama inspect_image com a
+pergunta e a visão roda de novo na mesma imagem, em vez de ele adivinhar pela
+descrição anterior. A imagem é baixada de novo pela Uazapi (as URLs expiram).
+
+Fotos de visualização única não são guardadas. Depois de VISION_MEMORY_DAYS a
+foto não é mais consultada; a retenção apaga as linhas junto com as mensagens.
+*/
+
+// rememberImage guarda a foto do cliente e devolve a marca a pôr na descrição
+// ("" quando a memória está desligada ou a gravação falhou).
+func (h *WebhookHandler) rememberImage(ctx context.Context, clientID int64, extID, url, desc string) string {
+	if !h.cfg.VisionFollowup || clientID == 0 || url == "" {
+		return ""
+	}
+	img := models.ClientImage{ClientID: clientID, MediaURL: url, Description: strings.TrimSpace(desc)}
+	if extID != "" {
+		img.ExtID = &extID
+	}
+	id, err := models.InsertClientImage(ctx, h.pool, img)
+	if err != nil {
+		log.Printf("db insert client image error: %v", err)
+		return ""
+	}
+	return fmt.Sprintf("[imagem #%d]", id)
+}
+
+// withImageTag acrescenta a marca da foto ao rótulo da descrição.
+func withImageTag(label, tag string) string {
+	if tag == "" {
+		return label
+	}
+	return label + " " + tag
+}
+
+// registerImageTool expõe inspect_image ao assistente.
+func (h *WebhookHandler) registerImageTool() {
+	if !h.cfg.VisionFollowup {
+		return
+	}
+	h.tools.Register(tools.Tool{
+		Def: openai.FunctionTool{
+			Name: "inspect_image",
+			Description: "Olha de novo uma foto que o cliente enviou para responder uma pergunta sobre ela " +
+				"(texto escrito, detalhes, cores, medidas). Use quando a descrição anterior não responder.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"question": map[string]any{"type": "string", "description": "O que verificar na imagem, ex.: 'O que está escrito no canto inferior direito?'"},
+					"image_id": map[string]any{"type": "integer", "description": "Número da marca [imagem #N]; sem ele, a última foto do cliente"},
+				},
+				"required": []string{"question"},
+			},
+		},
+		Run: func(ctx context.Context, call tools.Call, args json.RawMessage) (any, error) {
+			var in struct {
+				Question string `json:"question"`
+				ImageID  int64  `json:"image_id"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			in.Question = strings.TrimSpace(in.Question)
+			if in.Question == "" {
+				return nil, errors.New("question is required")
+			}
+			return h.inspectImage(ctx, call.ClientID, in.ImageID, in.Question)
+		},
+	})
+}
+
+// inspectImage roda a visão de novo na foto guardada (id 0 = a última) com a pergunta.
+func (h *WebhookHandler) inspectImage(ctx context.Context, clientID, id int64, question string) (any, error) {
+	since := time.Now().AddDate(0, 0, -h.cfg.VisionMemoryDays)
+	var (
+		img models.ClientImage
+		ok  bool
+		err error
+	)
+	if id > 0 {
+		img, ok, err = models.ClientImageByID(ctx, h.pool, clientID, id, since)
+	} else {
+		img, ok, err = models.LatestClientImage(ctx, h.pool, clientID, since)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no image of this client in the last %d days", h.cfg.VisionMemoryDays)
+	}
+
+	url := img.MediaURL
+	if img.ExtID != nil {
+		// link novo: o da primeira descrição pode ter expirado
+		if _, fresh, err := h.wpp.DownloadByMessageID(ctx, *img.ExtID); err == nil && fresh != "" {
+			url = fresh
+		} else if err != nil {
+			log.Printf("inspect_image download %s error (using stored url): %v", *img.ExtID, err)
+		}
+	}
+	answer, err := h.ai.VisionAsk(ctx, url, question)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"image_id": img.ID, "answer": strings.TrimSpace(answer)}, nil
+}
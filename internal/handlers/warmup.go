@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/phone"
+)
+
+/*
+Teste do canal no start (WARMUP_NUMBER).
+
+/health só diz que o processo está de pé: com a instância da Uazapi desconectada
+ou o número banido, o agente recebe webhooks mas nenhuma resposta chega. No start
+o agente envia WARMUP_TEXT ao número de monitoramento e espera a confirmação de
+entrega (evento de recibo do webhook) por até WARMUP_ACK_TIMEOUT_SECONDS. Sem
+ela, o /readyz responde 503 com o motivo em "warmup".
+
+Enquanto o teste roda a readiness não cai (o recibo chega pelo próprio webhook).
+Com EVENT_BUS=postgres o recibo recebido por outra réplica também vale.
+*/
+
+// Situações do teste do canal.
+const (
+	warmupDisabled = "disabled"
+	warmupPending  = "pending"
+	warmupOK       = "ok"
+	warmupFailed   = "failed"
+)
+
+var errWarmupNoAck = errors.New("no delivery ack")
+
+// WarmupStatus é o resultado do teste do canal, exposto no /readyz.
+type WarmupStatus struct {
+	State     string     `json:"state"`
+	MessageID string     `json:"message_id,omitempty"`
+	Detail    string     `json:"detail,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	AckAt     *time.Time `json:"ack_at,omitempty"`
+}
+
+// warmupCheck guarda o resultado do teste; lido pelas requisições do /readyz.
+type warmupCheck struct {
+	mu sync.Mutex
+	st WarmupStatus
+}
+
+func (c *warmupCheck) get() WarmupStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.st
+}
+
+func (c *warmupCheck) update(fn func(st *WarmupStatus)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(&c.st)
+}
+
+// Warmup devolve o resultado do teste do canal (State disabled sem WARMUP_NUMBER).
+func (h *WebhookHandler) Warmup() WarmupStatus {
+	if h.warmup == nil {
+		return WarmupStatus{State: warmupDisabled}
+	}
+	return h.warmup.get()
+}
+
+// startWarmup dispara o teste do canal em background, se configurado.
+func (h *WebhookHandler) startWarmup() {
+	if h.cfg.WarmupNumber == "" || h.cfg.DryRun {
+		return
+	}
+	number, ok := phone.Normalize(h.cfg.WarmupNumber)
+	if !ok {
+		log.Printf("WARMUP_NUMBER inválido (%q): teste do canal desativado", h.cfg.WarmupNumber)
+		return
+	}
+	h.warmup = &warmupCheck{st: WarmupStatus{State: warmupPending}}
+	go func() {
+		ctx := context.Background()
+		defer h.recoverWorker(ctx, "warmup")
+		if err := h.runWarmup(ctx, number); err != nil {
+			h.warmup.update(func(st *WarmupStatus) { st.State, st.Detail = warmupFailed, err.Error() })
+			h.fail(number, "warmup", err)
+			return
+		}
+		log.Printf("warmup: delivery to %s confirmed", number)
+	}()
+}
+
+// runWarmup envia a mensagem de teste e espera a confirmação de entrega.
+func (h *WebhookHandler) runWarmup(ctx context.Context, number string) error {
+	acks := make(chan string, 16)
+	if h.events != nil {
+		cancel := h.events.Subscribe(events.MessageStatus, func(_ context.Context, ev events.Event) {
+			if (receiptEvent{Status: ev.Type}).delivered() {
+				select {
+				case acks <- ev.ExtID:
+				default:
+				}
+			}
+		})
+		defer cancel()
+	}
+
+	text := h.cfg.WarmupText
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, time.Now().Format("02/01 15:04"))
+	}
+	res, err := h.wpp.SendText(ctx, number, text)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	sent := time.Now()
+	h.warmup.update(func(st *WarmupStatus) { st.MessageID, st.SentAt = res.MessageID, &sent })
+	if res.MessageID == "" {
+		return errors.New("send: no message id in the response")
+	}
+
+	timeout := time.Duration(h.cfg.WarmupAckTimeoutSeconds) * time.Second
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		select {
+		case id := <-acks:
+			if !sameMessageID(id, res.MessageID) {
+				continue
+			}
+			at := time.Now()
+			h.warmup.update(func(st *WarmupStatus) { st.State, st.AckAt = warmupOK, &at })
+			return nil
+		case <-deadline.C:
+			return fmt.Errorf("%w in %s", errWarmupNoAck, timeout)
+		}
+	}
+}
+
+// sameMessageID compara IDs com ou sem o prefixo "owner:" usado por algumas instâncias.
+func sameMessageID(a, b string) bool {
+	return a != "" && bareMessageID(a) == bareMessageID(b)
+}
+
+// bareMessageID tira o prefixo "owner:" do id da mensagem.
+func bareMessageID(s string) string {
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -4,57 +4,365 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/abuse"
+	"github.com/your-org/leandro-agent/internal/budget"
 	"github.com/your-org/leandro-agent/internal/buffer"
+	"github.com/your-org/leandro-agent/internal/capture"
 	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/feed"
+	"github.com/your-org/leandro-agent/internal/intent"
+	"github.com/your-org/leandro-agent/internal/media"
 	"github.com/your-org/leandro-agent/internal/models"
 	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/phone"
 	"github.com/your-org/leandro-agent/internal/processor"
+	"github.com/your-org/leandro-agent/internal/redact"
+	"github.com/your-org/leandro-agent/internal/scheduler"
+	"github.com/your-org/leandro-agent/internal/settings"
+	"github.com/your-org/leandro-agent/internal/state"
+	"github.com/your-org/leandro-agent/internal/tools"
+	"github.com/your-org/leandro-agent/internal/trace"
+	"github.com/your-org/leandro-agent/internal/tts"
 	"github.com/your-org/leandro-agent/internal/uazapi"
+	"github.com/your-org/leandro-agent/internal/unfurl"
+	"github.com/your-org/leandro-agent/internal/upstream"
 )
 
-type webhookHandler struct {
+// WebhookHandler recebe os eventos da Uazapi e orquestra buffer, OpenAI e envio.
+// Também expõe as rotas administrativas que dependem do mesmo estado.
+type WebhookHandler struct {
 	cfg    config.Config
 	pool   *pgxpool.Pool
 	ai     *openai.Client
+	clients  models.ClientRepo  // clientes e histórico (models.Postgres sobre pool)
+	messages models.MessageRepo
 	wpp    *uazapi.Client
 	bufMgr *buffer.Manager
+	feed   *feed.Hub
+	events events.Bus
+	maint  *maintenance
+	tools  *tools.Registry
+	albums *albumCollector
+	auth   *Auth
+
+	settings  *settings.Store
+	instances state.Map[string, string]     // phone -> instância (owner) da última mensagem recebida
+	traces    state.Map[string, trace.Info] // phone -> trace.Info da última mensagem no buffer
+	runs      *runTracker // processamento em andamento por telefone (RUN_SUPERSEDE)
+	upstream  *upstream.Transport // conexões compartilhadas com OpenAI/Uazapi/Whisper
+	intents   intentCache         // regras de resposta direta (INTENTS_ENABLED)
+	pendingTopic *intent.Matcher  // assunto da ação pendente (PENDING_ACTION_KEYWORDS)
+	summarizing  state.InFlight[int64] // clientID -> resumo da conversa em atualização
+
+	abuse   *abuse.Detector
+	redact  *redact.Redactor // máscara antes de gravar (REDACT_*); nil = desligado
+	pipeline *processor.Pipeline // limpeza/máscara/corte de texto de entrada e saída (+ processor.Register)
+	unfurl  *unfurl.Fetcher
+	whisper *media.Whisper // transcrição local (TRANSCRIBE_BACKEND exec/http); nil = OpenAI
+	tts     tts.Provider   // voz dos áudios (TTS_PROVIDER)
+	staged  state.Map[string, []string] // phone -> documentos enviados à OpenAI aguardando a próxima run
+
+	fallbacks fallbackLimiter
+	nonces    *nonceCache // cache local dos nonces do webhook (todos os tenants; ver replay.go)
+	budget    *budget.Guard
+	statuses  *statusTracker
+	capture   *capture.Recorder
+	sched     *scheduler.Scheduler // loops que não podem rodar em duas réplicas
+	batches   *batchStats          // tamanhos dos lotes do webhook (todos os tenants)
+	load      *loadShedder         // contrapressão do webhook (todos os tenants)
+	queue     *runQueue            // fila das runs por faixa (RUN_WORKERS; todos os tenants); nil = runs na hora
+	audio     *audioStats          // entregas de respostas em áudio (todos os tenants)
+	warmup    *warmupCheck         // teste do canal no start (WARMUP_NUMBER); nil = desligado
+
+	tenantID      int64     // 0 = tenant padrão (credenciais do ENV)
+	tenantUpdated time.Time // updated_at do cadastro usado na montagem
+	tenants       *Tenants  // compartilhado por todos os tenants
+
+	life context.Context    // vida dos loops do pipeline; cancelado quando o tenant é remontado
+	stop context.CancelFunc // encerra os loops (Tenants.handler, ao trocar o pipeline)
+}
+
+func NewWebhookHandler(cfg config.Config, pool *pgxpool.Pool, hub *feed.Hub, bus events.Bus, up *upstream.Transport) *WebhookHandler {
+	if up == nil {
+		up = upstream.New(upstream.OptionsFrom(cfg))
+	}
+	rec := capture.New(cfg.DebugCaptureSize, cfg.DebugCaptureMaxKB<<10)
+	if cfg.DebugCaptureMinutes > 0 {
+		rec.Enable(time.Duration(cfg.DebugCaptureMinutes) * time.Minute)
+	}
+	ai := openai.New(cfg.OpenAIAPIKey, cfg.OpenAIAssistantID, cfg.OpenAIChatModel, cfg.OpenAITranscribeModel)
+	ai.TTSVoice = cfg.TTSVoice
+	ai.TTSSpeed = cfg.TTSSpeed
+	ai.MemoryModel = cfg.OpenAIMemoryModel
+	ai.BaseURL = cfg.OpenAIBaseURL
+	ai.SetTransport(trace.Transport(rec.Transport("openai", up)))
+	h := newWebhookHandler(cfg, pool, hub, bus, rec, up, ai)
+	h.auth = NewAuth(cfg, pool)
+	h.settings = settings.New(pool, time.Duration(cfg.SettingsCacheSeconds)*time.Second)
+	h.budget = h.newBudgetGuard(cfg)
+	h.sched = scheduler.New(pool)
+	h.batches = &batchStats{}
+	h.load = newLoadShedder(cfg)
+	h.queue = newRunQueue(cfg)
+	h.audio = &audioStats{}
+	h.tenants = newTenants(h)
+	h.subscribeEvents()
+	h.subscribeTeamNotify()
+	h.start()
+	h.startWarmup()
+
+	go h.purgeSpeechCache(context.Background())
+	go h.loadCooldowns(context.Background())
+
+	return h
 }
 
-func NewWebhookHandler(cfg config.Config, pool *pgxpool.Pool) http.Handler {
-	aiClient := openai.New(cfg.OpenAIAPIKey, cfg.OpenAIAssistantID, cfg.OpenAIChatModel, cfg.OpenAITranscribeModel)
-	aiClient.TTSVoice = cfg.TTSVoice
-	aiClient.TTSSpeed = cfg.TTSSpeed
-	wppClient := uazapi.New(cfg.UazapiBaseSend, cfg.UazapiTokenSend, cfg.UazapiBaseDownload, cfg.UazapiTokenDownload)
+// newWebhookHandler monta o pipeline com as credenciais Uazapi de cfg e o client da
+// OpenAI dado. O que é do processo (auth, ajustes, orçamento, tenants) fica com
+// NewWebhookHandler / newTenantHandler.
+func newWebhookHandler(cfg config.Config, pool *pgxpool.Pool, hub *feed.Hub, bus events.Bus, rec *capture.Recorder, up *upstream.Transport, aiClient *openai.Client) *WebhookHandler {
+	wppClient := uazapi.New(cfg.UazapiBaseSend, cfg.UazapiTokenSend, cfg.UazapiBaseDownload, cfg.UazapiTokenDownload).
+		WithDryRun(cfg.DryRun).
+		WithDownloadTimeout(time.Duration(cfg.UazapiDownloadTimeoutSeconds) * time.Second).
+		WithDownloadRetries(cfg.UazapiDownloadRetries).
+		WithTransport(trace.Transport(rec.Transport("uazapi", up)))
+	if cfg.MediaTranscode && media.HasFFmpeg(cfg.FFmpegPath) {
+		wppClient.WithTranscoder(media.NewTranscoder(cfg.FFmpegPath, cfg.MediaImageMaxMB<<20, cfg.MediaVideoMaxMB<<20))
+	}
 
-	h := &webhookHandler{
+	h := &WebhookHandler{
 		cfg:  cfg,
 		pool: pool,
 		ai:   aiClient,
+		clients:  models.Postgres{DB: pool},
+		messages: models.Postgres{DB: pool},
 		wpp:  wppClient,
+		feed:  hub,
+		events: bus,
+		maint: &maintenance{},
+		tools:  tools.NewRegistry(),
+		albums: newAlbumCollector(),
+		// nonces valem pela janela inteira (±window em torno do timestamp)
+		nonces: newNonceCache(2 * time.Duration(cfg.WebhookReplayWindowSeconds) * time.Second),
+		statuses: newStatusTracker(time.Duration(cfg.StatusTTLMinutes) * time.Minute),
+		capture:  rec,
+		upstream: up,
+		runs:     newRunTracker(),
+
+		unfurl: unfurl.New(time.Duration(cfg.LinkUnfurlTimeoutSeconds)*time.Second, int64(cfg.LinkUnfurlMaxKB)<<10),
+		abuse: abuse.NewDetector(abuse.Config{
+			MaxPerMinute:  cfg.AbuseMaxPerMinute,
+			RepeatLimit:   cfg.AbuseRepeatLimit,
+			LinkOnlyLimit: cfg.AbuseLinkOnlyLimit,
+			Cooldown:      time.Duration(cfg.AbuseCooldownMinutes) * time.Minute,
+		}),
+		redact: redact.New(redact.Config{Profanity: cfg.RedactProfanity, PII: cfg.RedactPII, Words: cfg.RedactWords}),
+		pendingTopic: intent.Compile([]models.IntentRule{{Name: "pending", Keywords: cfg.PendingActionKeywords, Active: true}},
+			cfg.PendingActionMaxWords),
+	}
+	h.life, h.stop = context.WithCancel(context.Background())
+	h.pipeline = processor.NewPipeline(nil)
+	if h.redact != nil {
+		h.pipeline = processor.NewPipeline(h.redact)
+	}
+	h.tts = tts.New(cfg, aiClient, trace.Transport(rec.Transport("elevenlabs", up)))
+	if cfg.TranscribeBackend != "openai" {
+		h.whisper = &media.Whisper{
+			Model:    cfg.TranscribeModel,
+			Language: cfg.TranscribeLanguage,
+			Timeout:  time.Duration(cfg.TranscribeTimeoutSeconds) * time.Second,
+			HTTP:     &http.Client{Transport: trace.Transport(rec.Transport("whisper", up))},
+		}
+		if cfg.TranscribeBackend == "exec" {
+			h.whisper.Command = cfg.TranscribeCommand
+		} else {
+			h.whisper.URL = cfg.TranscribeURL
+		}
+	}
+	tools.RegisterLeadTools(h.tools, pool)
+	if cfg.CatalogEnabled {
+		tools.RegisterProductTools(h.tools, pool)
 	}
+	h.registerTransferTool()
+	h.registerMuteTool()
+	h.registerImageTool()
 
 	// Timeout do buffer vem do ENV (cfg.BufferTimeoutSeconds)
 	timeout := time.Duration(cfg.BufferTimeoutSeconds) * time.Second
 	h.bufMgr = buffer.NewManager(timeout, func(phone, combined, lastKind string) {
-		go h.processCombinedMessage(context.Background(), phone, combined, lastKind)
+		h.dispatchRun(phone, func() {
+			h.load.begin()
+			defer h.load.end()
+			ids := h.statuses.begin(phone)
+			defer h.statuses.finish(phone, ids)
+			ctx := h.traced(h.scope(context.Background()), phone)
+			defer h.recoverMessage(ctx, phone, "process", combined)
+			h.processCombinedMessage(ctx, phone, combined, lastKind)
+		})
 	})
-
 	return h
 }
 
-// ===== Limpeza de referências tipo 【...】 =====
-var refRe = regexp.MustCompile(`【[^】]+】`)
+// start sincroniza as ferramentas do assistente, carrega o modo manutenção e
+// inicia a pesquisa de satisfação por inatividade.
+//
+// Goroutines do handler e quem as encerra:
+//
+//   - loops (maintenance, csat, file cleanup, unmute, status posts, slo): vivem enquanto o
+//     pipeline (h.life; o de um tenant acaba quando o cadastro muda e ele é
+//     remontado), com supervise reiniciando após um panic;
+//   - flush do buffer: uma por conversa, do timer do buffer até a resposta sair
+//     (runs controla a substituição, RUN_SUPERSEDE; statuses e load, a contagem);
+//   - tarefas disparadas por uma mensagem (memória, resumo, álbum, intent, exclusão
+//     de dados, alertas): terminam sozinhas, com recoverWorker e contexto próprio
+//     (context.WithoutCancel ou com timeout), sem depender da requisição.
+//
+// O estado que elas compartilham fica em contêineres de internal/state (instances,
+// traces, staged, summarizing, fallbacks) ou em tipos com mutex próprio.
+func (h *WebhookHandler) start() {
+	// Registra as funções no assistente (opcional; pode ser feito manualmente no painel)
+	if h.cfg.AssistantSyncTools {
+		go func() {
+			defer h.recoverWorker(h.life, "assistant tools sync")
+			if err := h.ai.EnsureAssistantTools(h.life, h.tools.Definitions()); err != nil {
+				log.Printf("assistant tools sync error: %v", err)
+			}
+		}()
+	}
+	// Modo manutenção (maintenance_windows) e mensagens retidas numa janela
+	// anterior ao restart
+	h.syncMaintenance(h.life)
+	go h.supervise(h.life, "maintenance", h.maintenanceLoop)
+	// Pesquisa de satisfação das conversas paradas
+	if h.cfg.CSATEnabled && h.cfg.CSATInactivityMinutes > 0 {
+		go h.supervise(h.life, "csat", h.csatLoop)
+	}
+	// Documentos enviados à OpenAI Files: apaga os vencidos
+	if h.cfg.DocumentFileSearch {
+		go h.supervise(h.life, "file cleanup", h.fileCleanupLoop)
+	}
+	// Fim do silêncio pedido pelo cliente
+	if h.cfg.MuteEnabled && h.cfg.UnmuteMessage != "" {
+		go h.supervise(h.life, "unmute", h.unmuteLoop)
+	}
+	// Status do WhatsApp agendados
+	go h.supervise(h.life, "status posts", h.statusPostLoop)
+	// SLO de latência das respostas
+	if h.cfg.SLOTargetSeconds > 0 {
+		go h.supervise(h.life, "slo", h.sloLoop)
+	}
+	// Envios presos em "pending" (processo parou no meio do envio) e pipelines de
+	// tenants desativados
+	if h.tenantID == 0 {
+		go h.supervise(h.life, "stale pending messages", h.stalePendingLoop)
+		go h.supervise(h.life, "tenant pipelines", h.tenants.pruneLoop)
+	}
+	// Avisos ao grupo da equipe adiados (silêncio, falha de envio)
+	if h.cfg.TeamNotifyGroup != "" && h.tenantID == 0 {
+		go h.supervise(h.life, "team notify", h.teamNotifyLoop)
+	}
+}
 
+// botConfig devolve a configuração efetiva para o número do bot que atende o telefone
+// (ENV + ajustes de bot_settings).
+func (h *WebhookHandler) botConfig(ctx context.Context, phone string) config.Config {
+	instance, _ := h.instances.Load(phone)
+	return h.settings.Apply(ctx, instance, h.cfg)
+}
+
+// saveMessage persiste a mensagem e a publica no barramento (feed ao vivo dos operadores).
+func (h *WebhookHandler) saveMessage(ctx context.Context, phone string, m models.Message) {
+	_, _ = h.saveMessageID(ctx, phone, m)
+}
+
+// saveMessageID é saveMessage devolvendo o id da mensagem gravada.
+func (h *WebhookHandler) saveMessageID(ctx context.Context, phone string, m models.Message) (int64, error) {
+	m = h.redactMessage(withReplyTranslation(ctx, m))
+	id, err := h.messages.Insert(ctx, m)
+	if err != nil {
+		log.Printf("db insert message error: %v", err)
+	}
+	topic := events.ReplySent
+	if m.Role == "user" {
+		topic = events.MessageReceived
+	}
+	ev := events.Event{Topic: topic, Phone: phone, ClientID: m.ClientID, Role: m.Role, Type: m.Type, Content: m.Content}
+	if m.ExtID != nil {
+		ev.ExtID = *m.ExtID
+	}
+	h.publish(ctx, ev)
+	return id, err
+}
+
+// historyMessage preenche m com o texto já processado pelo pipeline (versão a
+// gravar e, com REDACT_KEEP_ORIGINAL, a cópia sem máscara).
+func (h *WebhookHandler) historyMessage(m models.Message, t processor.Text) models.Message {
+	m.Content = t.Stored()
+	if t.Original != "" && h.cfg.RedactKeepOriginal {
+		original := t.Original
+		m.Original = &original
+	}
+	return m
+}
+
+// redactMessage mascara palavrões e dados pessoais do conteúdo a gravar (REDACT_*).
+// A cópia sem máscara vai junto só com REDACT_KEEP_ORIGINAL.
+func (h *WebhookHandler) redactMessage(m models.Message) models.Message {
+	if m.Translated != nil {
+		if t, changed := h.redact.Apply(*m.Translated); changed {
+			m.Translated = &t
+		}
+	}
+	masked, changed := h.redact.Apply(m.Content)
+	if !changed {
+		return m
+	}
+	if h.cfg.RedactKeepOriginal {
+		original := m.Content
+		m.Original = &original
+	}
+	m.Content = masked
+	return m
+}
+
+// fail loga o erro de uma etapa do pipeline e o registra em failures (usado no digest/alertas).
+func (h *WebhookHandler) fail(phone, stage string, err error) {
+	log.Printf("%s error: %v", stage, err)
+	if rerr := models.RecordFailure(context.Background(), h.pool, phone, stage, err.Error()); rerr != nil {
+		log.Printf("db record failure error: %v", rerr)
+	}
+	h.publish(context.Background(), events.Event{Topic: events.RunFailed, Phone: phone, Stage: stage, Error: err.Error()})
+	h.onSendError(phone, err)
+}
+
+// outboundMessage monta a linha do assistente com o ID/horário devolvidos pela Uazapi.
+func outboundMessage(clientID int64, kind, content string, res uazapi.SendResult) models.Message {
+	m := models.Message{ClientID: clientID, Role: "assistant", Type: kind, Content: content}
+	if res.MessageID != "" {
+		id := res.MessageID
+		m.ExtID = &id
+	}
+	if !res.Timestamp.IsZero() {
+		ts := res.Timestamp
+		m.ProviderAt = &ts
+	}
+	return m
+}
+
+// ===== Limpeza de referências tipo 【...】 =====
 func removeRefs(s string) string {
-	return refRe.ReplaceAllString(s, "")
+	return processor.RemoveRefs(s)
 }
 
 // ===== Estruturas de payload =====
@@ -73,6 +381,25 @@ type incomingMessage struct {
 	ButtonOrListID string          `json:"buttonOrListid"`
 	FromMe         bool            `json:"fromMe"`
 	WasSentByAPI   bool            `json:"wasSentByApi"`
+	SenderPN       string          `json:"sender_pn"`  // telefone real quando o remetente vem como @lid
+	SenderLID      string          `json:"sender_lid"` // identificador @lid do remetente
+	ChatLID        string          `json:"chatlid"`
+	Owner          string          `json:"owner"` // número do bot (instância) que recebeu a mensagem
+	IsForwarded    bool            `json:"isForwarded"`
+	ForwardScore   int             `json:"forwardingScore"` // quantas vezes foi encaminhada (>= 5: "com frequência")
+
+	// Preenchidos por unwrap() quando a mensagem vem embrulhada
+	Ephemeral bool `json:"-"`
+	ViewOnce  bool `json:"-"`
+
+	// Evento de presença (digitando/gravando) em vez de mensagem
+	Presence *presenceEvent `json:"-"`
+
+	// Chamada de voz/vídeo recebida em vez de mensagem
+	Call *callEvent `json:"-"`
+
+	// Confirmação de entrega/leitura de uma mensagem enviada
+	Receipt *receiptEvent `json:"-"`
 }
 
 type payloadBody struct{ Message incomingMessage `json:"message"` }
@@ -107,40 +434,167 @@ func (m *incomingMessage) norm() {
 			m.MessageID = m.MessageIDAlt
 		}
 	}
+	m.readContextInfo(m.Content)
+	m.unwrap()
+	if m.ForwardScore > 0 {
+		m.IsForwarded = true
+	}
 }
 
-var chatIDRe = regexp.MustCompile(`^(\d+)(?:@s\.whatsapp\.net|@c\.us|@g\.us|@newsletter)$`)
-var anyJIDRe = regexp.MustCompile(`(\d+@(?:s\.whatsapp\.net|c\.us|g\.us|newsletter))`)
+// unwrap trata ephemeralMessage / viewOnceMessage, que aninham a mensagem real um nível abaixo:
+//
+//	{"messageType":"viewOnceMessageV2","content":{"message":{"imageMessage":{...}}}}
+//
+// Após o unwrap, MessageType/Content apontam para a mensagem interna e as flags ficam registradas.
+func (m *incomingMessage) unwrap() {
+	for depth := 0; depth < 3; depth++ {
+		switch strings.ToLower(m.MessageType) {
+		case "ephemeralmessage":
+			m.Ephemeral = true
+		case "viewoncemessage", "viewoncemessagev2", "viewoncemessagev2extension":
+			m.ViewOnce = true
+		default:
+			return
+		}
+
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(m.Content, &wrapper); err != nil {
+			return
+		}
+		inner := wrapper
+		if raw, ok := wrapper["message"]; ok {
+			inner = nil
+			if err := json.Unmarshal(raw, &inner); err != nil {
+				return
+			}
+		}
+		k, ok := innerMessageKey(inner)
+		if !ok {
+			return
+		}
+		m.MessageType = k
+		m.Content = inner[k]
+		m.readContextInfo(m.Content)
+		// textos aninhados vêm como {"text":"..."}; normaliza para string JSON
+		var txt struct {
+			Text string `json:"text"`
+		}
+		if strings.EqualFold(m.MessageType, "extendedTextMessage") && json.Unmarshal(m.Content, &txt) == nil {
+			m.Content, _ = json.Marshal(txt.Text)
+		}
+	}
+}
+
+// innerMessageKeys é a ordem de preferência das chaves dentro de um wrapper: o
+// WhatsApp pode mandar mais de uma (ex.: a mídia e um texto de apoio) e a ordem
+// de um map não é fixa. Invólucros aninhados vêm primeiro, depois mídia e texto.
+var innerMessageKeys = []string{
+	"ephemeralMessage", "viewOnceMessage", "viewOnceMessageV2", "viewOnceMessageV2Extension",
+	"imageMessage", "videoMessage", "audioMessage", "pttMessage", "documentMessage",
+	"documentWithCaptionMessage", "stickerMessage", "locationMessage", "liveLocationMessage",
+	"contactMessage", "contactsArrayMessage", "pollCreationMessage", "reactionMessage",
+	"buttonsResponseMessage", "listResponseMessage", "templateButtonReplyMessage",
+	"extendedTextMessage", "conversation",
+}
 
-func extractPhoneFromJID(jid string) (string, bool) {
-	jid = strings.TrimSpace(jid)
-	m := chatIDRe.FindStringSubmatch(jid)
-	if len(m) == 2 {
-		return m[1], true
+// innerMessageKey escolhe a mensagem interna de um wrapper pela ordem de
+// innerMessageKeys; chaves desconhecidas valem depois, em ordem alfabética.
+func innerMessageKey(inner map[string]json.RawMessage) (string, bool) {
+	for _, k := range innerMessageKeys {
+		if _, ok := inner[k]; ok {
+			return k, true
+		}
 	}
-	return "", false
+	keys := make([]string, 0, len(inner))
+	for k := range inner {
+		if k != "messageContextInfo" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return keys[0], true
 }
 
 func parsePayload(r *http.Request) (incomingMessage, []byte, error) {
+	events, raw, err := parsePayloads(r)
+	if err != nil {
+		return incomingMessage{}, raw, err
+	}
+	return events[0].msg, events[0].raw, nil
+}
+
+// webhookEvent é um evento do webhook já interpretado, com o JSON original.
+type webhookEvent struct {
+	msg incomingMessage
+	raw []byte
+}
+
+// parsePayloads lê o corpo e interpreta cada evento. O gateway pode mandar um
+// array de eventos: todos são devolvidos, na ordem; elementos inválidos são
+// descartados (com log) e só dá erro se nenhum for válido.
+func parsePayloads(r *http.Request) ([]webhookEvent, []byte, error) {
 	defer r.Body.Close()
-	raw, _ := io.ReadAll(io.LimitReader(r.Body, 4<<20))
+	raw, err := readBody(r)
+	if err != nil {
+		return nil, raw, err
+	}
 	trimmed := bytes.TrimSpace(raw)
 
-	// Array de eventos: usa o primeiro elemento
 	if len(trimmed) > 0 && trimmed[0] == '[' {
 		var arr []json.RawMessage
 		if err := json.Unmarshal(trimmed, &arr); err == nil && len(arr) > 0 {
-			raw = arr[0]
-			trimmed = bytes.TrimSpace(raw)
+			out := make([]webhookEvent, 0, len(arr))
+			for i, el := range arr {
+				msg, err := parseEvent(bytes.TrimSpace(el))
+				if err != nil {
+					log.Printf("webhook batch: event %d/%d invalid: %s", i+1, len(arr), string(el))
+					continue
+				}
+				out = append(out, webhookEvent{msg: msg, raw: el})
+			}
+			if len(out) == 0 {
+				return nil, raw, io.EOF
+			}
+			return out, raw, nil
 		}
 	}
 
+	msg, err := parseEvent(trimmed)
+	if err != nil {
+		return nil, raw, err
+	}
+	return []webhookEvent{{msg: msg, raw: raw}}, raw, nil
+}
+
+// parseEvent interpreta um único evento nos formatos aceitos pelo webhook.
+func parseEvent(trimmed []byte) (incomingMessage, error) {
+	// Chamada recebida: não é mensagem
+	if c, ok := parseCall(trimmed); ok {
+		return incomingMessage{Call: &c}, nil
+	}
+
+	// Presença (digitando/gravando): não é mensagem
+	if p, ok := parsePresence(trimmed); ok {
+		return incomingMessage{Presence: &p}, nil
+	}
+
+	// Confirmação de entrega/leitura: não é mensagem
+	if rc, ok := parseReceipt(trimmed); ok {
+		return incomingMessage{Receipt: &rc}, nil
+	}
+
 	// Envelope completo com chat + message
 	{
 		var env eventEnvelope
 		if err := json.Unmarshal(trimmed, &env); err == nil {
 			msg := env.Body.Message
 			msg.norm()
+			if msg.Owner == "" {
+				msg.Owner = env.Body.Owner
+			}
 			if msg.ChatID == "" {
 				if env.Body.Chat.WaChatID != "" {
 					msg.ChatID = env.Body.Chat.WaChatID
@@ -149,7 +603,7 @@ func parsePayload(r *http.Request) (incomingMessage, []byte, error) {
 				}
 			}
 			if msg.ChatID != "" || msg.Sender != "" {
-				return msg, raw, nil
+				return msg, nil
 			}
 		}
 	}
@@ -161,7 +615,7 @@ func parsePayload(r *http.Request) (incomingMessage, []byte, error) {
 			msg := pr.Body.Message
 			msg.norm()
 			if msg.ChatID != "" || msg.Sender != "" {
-				return msg, raw, nil
+				return msg, nil
 			}
 		}
 	}
@@ -173,7 +627,7 @@ func parsePayload(r *http.Request) (incomingMessage, []byte, error) {
 			msg := pb.Message
 			msg.norm()
 			if msg.ChatID != "" || msg.Sender != "" {
-				return msg, raw, nil
+				return msg, nil
 			}
 		}
 	}
@@ -184,7 +638,7 @@ func parsePayload(r *http.Request) (incomingMessage, []byte, error) {
 		if err := json.Unmarshal(trimmed, &msg); err == nil {
 			msg.norm()
 			if msg.ChatID != "" || msg.Sender != "" {
-				return msg, raw, nil
+				return msg, nil
 			}
 		}
 	}
@@ -192,13 +646,13 @@ func parsePayload(r *http.Request) (incomingMessage, []byte, error) {
 	// fallback: 1º JID que aparecer
 	{
 		var msg incomingMessage
-		if m := anyJIDRe.FindStringSubmatch(string(trimmed)); len(m) == 2 {
-			msg.ChatID = m[1]
-			return msg, raw, nil
+		if jid, ok := phone.FindJID(string(trimmed)); ok {
+			msg.ChatID = jid
+			return msg, nil
 		}
 	}
 
-	return incomingMessage{}, raw, io.EOF
+	return incomingMessage{}, io.EOF
 }
 
 func writeErr(w http.ResponseWriter, code int, label string, err error) {
@@ -211,19 +665,96 @@ func writeErr(w http.ResponseWriter, code int, label string, err error) {
 	http.Error(w, label, code)
 }
 
-func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && h.serveChallenge(w, r) {
+		return
+	}
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	ctx := r.Context()
+	ctx := h.scope(r.Context())
 
-	msg, raw, err := parsePayload(r)
+	// Assinatura (se WEBHOOK_SECRET estiver definido); o nonce é registrado mais abaixo
+	nonce, err := h.verifyWebhook(r)
+	if err != nil {
+		switch {
+		case errors.Is(err, errBodyTooLarge):
+			writeErr(w, http.StatusRequestEntityTooLarge, "body too large", nil)
+		default:
+			writeErr(w, http.StatusUnauthorized, "unauthorized", err)
+		}
+		return
+	}
+
+	events, raw, err := parsePayloads(r)
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		writeErr(w, http.StatusRequestEntityTooLarge, "body too large", nil)
+		return
+	case errors.Is(err, errUnsupportedEncoding):
+		writeErr(w, http.StatusUnsupportedMediaType, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		log.Printf("webhook invalid json: %s", string(raw))
 		writeErr(w, http.StatusBadRequest, "invalid json", nil)
 		return
 	}
+	h.batches.observe(len(events))
+	if reason := h.overloaded(events); reason != "" {
+		h.shedLoad(w, len(events), reason)
+		return
+	}
+
+	// Proteção contra replay: só depois da contrapressão, e liberada se der 5xx
+	if err := h.claimNonce(ctx, nonce); err != nil {
+		writeErr(w, http.StatusConflict, "replayed event", nil)
+		return
+	}
+	sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+	w = sw
+	defer func() {
+		if v := recover(); v != nil {
+			h.releaseNonce(ctx, nonce)
+			panic(v)
+		}
+		if sw.code >= 500 {
+			h.releaseNonce(ctx, nonce)
+		}
+	}()
+
+	if len(events) > 1 {
+		h.serveBatch(ctx, w, events)
+		return
+	}
+	h.handleEvent(ctx, w, events[0].msg, events[0].raw)
+}
+
+// handleEvent processa um evento do webhook e escreve a resposta em w.
+func (h *WebhookHandler) handleEvent(ctx context.Context, w http.ResponseWriter, msg incomingMessage, raw []byte) {
+	// Ligação: recusa (opcional) e pede para o cliente escrever
+	if msg.Call != nil {
+		h.handleCall(ctx, *msg.Call)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true,"event":"call"}`))
+		return
+	}
+
+	// Usuário digitando: estende a janela do buffer para não responder no meio do raciocínio
+	if msg.Presence != nil {
+		h.handlePresence(ctx, *msg.Presence)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true,"event":"presence"}`))
+		return
+	}
+
+	if msg.Receipt != nil {
+		h.handleReceipt(ctx, *msg.Receipt)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true,"event":"receipt"}`))
+		return
+	}
 
 	// Ignora eco do próprio bot
 	if msg.FromMe || msg.WasSentByAPI {
@@ -232,142 +763,379 @@ func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extrai telefone
-	phone, ok := extractPhoneFromJID(msg.ChatID)
-	if !ok && msg.Sender != "" {
-		phone, ok = extractPhoneFromJID(msg.Sender)
-	}
-	if !ok {
-		if m := anyJIDRe.FindStringSubmatch(string(raw)); len(m) == 2 {
-			phone, ok = extractPhoneFromJID(m[1])
-		}
+	// Canal (newsletter): publicações não são conversa, não vão para a IA
+	if isNewsletterEvent(msg) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true,"ignored":"newsletter"}`))
+		return
 	}
+
+	// Extrai telefone (ou identidade @lid quando o telefone ainda é desconhecido)
+	phone, ok := h.resolvePhone(ctx, msg, raw)
 	if !ok {
 		writeErr(w, http.StatusBadRequest, "invalid chatid: "+msg.ChatID, nil)
 		return
 	}
 
+	if msg.Owner != "" {
+		h.instances.Store(phone, strings.TrimSpace(msg.Owner))
+	}
+
 	// Upsert cliente
 	var namePtr *string
 	if msg.SenderName != "" {
 		namePtr = &msg.SenderName
 	}
-	client, err := models.GetOrCreateClient(ctx, h.pool, phone, namePtr)
+	client, err := h.clients.GetOrCreate(ctx, phone, namePtr)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "db error", err)
 		return
 	}
+	// quem escreve tem WhatsApp: volta aos envios proativos
+	if err := models.ClearClientUnreachable(ctx, h.pool, client.ID); err != nil {
+		log.Printf("db clear unreachable error: %v", err)
+	}
+
+	// Álbum (várias imagens): reúne as imagens e processa em paralelo em segundo plano
+	if h.cfg.AlbumWaitSeconds > 0 && h.collectAlbum(phone, client.ID, msg) {
+		id := h.statuses.track(phone, statusCollecting, "")
+		h.writeAccepted(w, id, map[string]any{"album": "collecting"})
+		return
+	}
 
 	// Normaliza mensagem para texto e identifica tipo (text/audio/image/document)
-	textForLLM, msgType, err := h.normalizeInput(ctx, msg)
+	textForLLM, msgType, err := h.normalizeInput(ctx, client.ID, phone, msg)
 	if err != nil {
+		h.failAndNotify(client.ID, phone, "normalize", normalizeFallback(strings.ToLower(msg.MessageType), err), err)
 		writeErr(w, http.StatusInternalServerError, "normalize error", err)
 		return
 	}
 
+	// Outro idioma (TRANSLATE_MODE): o assistente recebe a tradução; o histórico, os dois
+	forLLM, lang, translated := textForLLM, "", (*string)(nil)
+	if msgType == "text" || msgType == "audio" {
+		forLLM, lang, translated = h.translateInbound(ctx, client.ID, phone, textForLLM)
+	}
+
 	// Registra cada mensagem individual
-	_ = models.InsertMessage(ctx, h.pool, models.Message{
+	h.saveMessage(ctx, phone, models.Message{
 		ClientID: client.ID, Role: "user", Type: msgType, Content: textForLLM, ExtID: &msg.MessageID,
+		Ephemeral: msg.Ephemeral, ViewOnce: msg.ViewOnce, Forwarded: msg.IsForwarded,
+		Language: lang, Translated: translated,
 	})
+	textForLLM = forLLM
+	// Áudio longo: o histórico fica com a transcrição completa; o assistente recebe o resumo
+	if msgType == "audio" {
+		textForLLM = h.summarizeAudio(ctx, msg.Content, textForLLM)
+	}
 
 	// NÂO chama /wait: usamos apenas delay no envio final (em processCombinedMessage)
 
-	// Enfileira no buffer (agrupamento)
-	h.bufMgr.AddMessage(phone, textForLLM, msgType)
+	// Opt-out ("PARAR"): confirma e não envia para a IA
+	if msgType == "text" && isOptOut(h.cfg.OptOutKeywords, textForLLM) {
+		h.handleOptOut(ctx, client, phone)
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "opt_out"), map[string]any{"event": "opt_out"})
+		return
+	}
+
+	// Silêncio pedido pelo cliente: o comando e as mensagens durante o silêncio
+	// ficam registrados, mas não vão para a IA
+	if msgType == "text" && h.handleMuteCommand(ctx, client, phone, textForLLM) {
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "mute"), map[string]any{"event": "mute"})
+		return
+	}
+	// Comandos do cliente ("menu", "atendente", "áudio off", "apagar meus dados")
+	if msgType == "text" && h.handleChatCommand(ctx, client, phone, textForLLM) {
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "command"), map[string]any{"event": "command"})
+		return
+	}
+	if h.isMuted(ctx, client.ID) {
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "muted"), map[string]any{"ignored": "muted"})
+		return
+	}
+
+	// Nota da pesquisa de satisfação: registra e agradece, sem passar pela IA
+	if (msgType == "text" || msgType == "emoji") && h.answerCSAT(ctx, client.ID, phone, textForLLM) {
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "csat"), map[string]any{"event": "csat"})
+		return
+	}
+
+	// Reação ou só emoji: registra (e no modo reply agradece) sem rodar o assistente
+	if msgType == "reaction" || (msgType == "emoji" && h.cfg.EmojiAction != "assistant") {
+		h.handleEmoji(ctx, client, phone, msgType, textForLLM)
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, msgType), map[string]any{"event": msgType})
+		return
+	}
+
+	// Corrente (encaminhada com frequência): responde o texto fixo ou só registra
+	if h.isChainMessage(msg) && h.cfg.ForwardedChainAction != "assistant" {
+		h.handleChainMessage(ctx, client, phone)
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "forwarded"), map[string]any{"event": "forwarded"})
+		return
+	}
+
+	// Spam/abuso: fica registrado, mas não vai para a IA
+	if h.screenAbuse(ctx, phone, textForLLM, msgType) {
+		h.writeAccepted(w, h.statuses.track(phone, statusIgnored, "abuse"), map[string]any{"ignored": "abuse"})
+		return
+	}
+
+	// Pergunta com resposta pronta (intent_rules): responde sem rodar o assistente
+	if msgType == "text" && h.answerIntent(ctx, client, phone, textForLLM) {
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "intent"), map[string]any{"event": "intent"})
+		return
+	}
+
+	// Link de pagamento/formulário aguardando confirmação: "já paguei" não vai para a IA
+	if msgType == "text" && h.holdPending(ctx, client, phone, textForLLM) {
+		h.writeAccepted(w, h.statuses.track(phone, statusDone, "pending_action"), map[string]any{"event": "pending_action"})
+		return
+	}
+
+	// Links: o assistente recebe o resumo da página junto com o texto
+	if msgType == "text" {
+		textForLLM = h.unfurlLinks(ctx, textForLLM)
+	}
+	if msg.IsForwarded {
+		textForLLM = forwardedContext(msg) + textForLLM
+	}
+
+	// registrado antes do buffer para o flush já encontrar o ID
+	id := h.statuses.track(phone, statusBuffered, "")
+	queued, err := h.dispatchInbound(ctx, phone, textForLLM, msgType)
+	if err != nil {
+		h.statuses.set(id, statusFailed)
+		writeErr(w, http.StatusInternalServerError, "db error", err)
+		return
+	}
+	if queued {
+		h.statuses.set(id, statusQueued)
+		h.writeAccepted(w, id, map[string]any{"queued": "maintenance"})
+		return
+	}
+
+	h.writeAccepted(w, id, nil)
+}
+
+// dispatchInbound encaminha uma mensagem já normalizada e persistida:
+// em modo manutenção fica retida (queued=true); senão vai para o buffer (agrupamento).
+func (h *WebhookHandler) dispatchInbound(ctx context.Context, phone, text, kind string) (bool, error) {
+	if h.maint.active() {
+		if err := h.holdForMaintenance(ctx, phone, text, kind); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if info := trace.From(ctx); info.TraceID != "" {
+		h.traces.Store(phone, info)
+	}
+	timeout := time.Duration(h.botConfig(ctx, phone).BufferTimeoutSeconds) * time.Second
+	h.bufMgr.AddMessageWithTimeout(phone, text, kind, timeout)
+	return false, nil
+}
 
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(`{"ok":true}`))
+// traced devolve ctx com o rastreio da última mensagem do telefone que entrou no
+// buffer: a run, as chamadas à OpenAI e o envio pela Uazapi levam o mesmo
+// X-Request-ID do webhook que a originou.
+func (h *WebhookHandler) traced(ctx context.Context, phone string) context.Context {
+	info, ok := h.traces.LoadAndDelete(phone)
+	if !ok {
+		return ctx
+	}
+	return trace.With(ctx, info)
 }
 
 // processCombinedMessage é acionado no flush do buffer.
-func (h *webhookHandler) processCombinedMessage(ctx context.Context, phone string, combined string, lastKind string) {
-	client, err := models.GetOrCreateClient(ctx, h.pool, phone, nil)
+func (h *WebhookHandler) processCombinedMessage(ctx context.Context, phone string, combined string, lastKind string) {
+	if id := trace.RequestID(ctx); id != "" {
+		log.Printf("run %s request_id=%s", phone, id)
+	}
+	// Run anterior do mesmo cliente ainda em andamento: é interrompida e esta
+	// responde pelas duas mensagens
+	ctx, endRun, merged := h.beginRun(ctx, phone)
+	defer endRun()
+	client, err := h.clients.GetOrCreate(ctx, phone, nil)
 	if err != nil {
-		log.Printf("buffer db error: %v", err)
+		h.failAndNotify(0, phone, "buffer db", fallbackBusy, err)
 		return
 	}
+	// "áudio off": respostas só em texto, mesmo a áudios
+	if strings.EqualFold(strings.TrimSpace(lastKind), "audio") && h.audioRepliesOff(ctx, client.ID) {
+		lastKind = "text"
+	}
 	threadID := ""
 	if client.ThreadID != nil && *client.ThreadID != "" {
 		threadID = *client.ThreadID
 	} else {
 		tid, err := h.ai.CreateThread(ctx)
 		if err != nil {
-			log.Println("openai thread error:", err)
+			h.failAndNotify(client.ID, phone, "openai thread", fallbackBusy, err)
 			return
 		}
-		if err := models.SetClientThread(ctx, h.pool, client.ID, tid); err != nil {
-			log.Println("db set thread error:", err)
+		if err := h.clients.SetThread(ctx, client.ID, tid); err != nil {
+			h.failAndNotify(client.ID, phone, "db set thread", fallbackBusy, err)
 			return
 		}
 		threadID = tid
 	}
 
-	_ = models.InsertMessage(ctx, h.pool, models.Message{
-		ClientID: client.ID, Role: "user", Type: "text", Content: combined,
-	})
-	if err := h.ai.AddUserMessage(ctx, threadID, combined); err != nil {
-		log.Println("openai add message error:", err)
+	// Experimento A/B ativo: a variante do cliente troca o assistente e/ou soma
+	// instruções; mensagens e consumo desta run ficam marcados com ela
+	arm, tag := h.experimentArm(ctx, client.ID)
+	if tag != "" {
+		ctx = models.WithVariant(ctx, tag)
+	}
+
+	// pipeline de entrada: o histórico fica com o texto completo (mascarado); o
+	// assistente recebe no máximo INBOUND_MAX_CHARS
+	in := h.pipeline.Run(ctx, processor.Text{Direction: processor.Inbound, Kind: lastKind, Content: combined, MaxChars: h.cfg.InboundMaxChars})
+	_, _ = h.messages.Insert(ctx, h.historyMessage(models.Message{
+		ClientID: client.ID, Role: "user", Type: "text",
+	}, in))
+	prompt := in.Content
+	if in.Truncated {
+		log.Printf("inbound from %s truncated to %d chars", phone, h.cfg.InboundMaxChars)
+	}
+	if err := h.addUserMessage(ctx, threadID, phone, prompt); err != nil {
+		h.failAndNotify(client.ID, phone, "openai add message", fallbackBusy, err)
+		return
+	}
+	// Primeiro contato: dados do CRM (ENRICH_URL) antes de montar o contexto
+	h.enrichClient(ctx, client)
+	instructions := joinInstructions(h.runContext(ctx, client), h.memoryInstructions(ctx, client.ID), arm.Instructions)
+	greeted := h.greetedRecently(ctx, client.ID)
+	if greeted {
+		instructions = joinInstructions(instructions, greetingInstruction)
+	}
+	if merged {
+		instructions = joinInstructions(instructions, supersedeInstruction)
+	}
+	lang := h.replyLanguage(ctx, client.ID)
+	if lang != "" && h.cfg.TranslateMode == "instruct" {
+		instructions = joinInstructions(instructions, languageInstruction(lang))
+	}
+	// Orçamento: acima do limite troca para o modelo barato ou responde "volte mais tarde"
+	model, allowed := h.budgetModel(ctx, client.ID)
+	if !allowed {
+		h.sendBudgetExceeded(ctx, client.ID, phone)
 		return
 	}
-	runID, err := h.ai.CreateRun(ctx, threadID)
+	_, assistantID := h.assistantFor(ctx, client.ID)
+	if arm.AssistantID != "" {
+		assistantID = arm.AssistantID
+	}
+	if activeRunFrom(ctx).superseded() {
+		// a mensagem já está na thread; a run da mensagem nova responde por ela
+		h.dropRun(ctx, phone)
+		return
+	}
+	// temperatura, limites de tokens etc. do número do bot (vale também para a 2ª run)
+	ctx = openai.WithRequestOptions(ctx, openai.RequestOptions{Run: runParams(h.botConfig(ctx, phone))})
+	runID, err := h.ai.CreateRunForAssistant(ctx, threadID, assistantID, model, instructions)
 	if err != nil {
-		log.Println("openai run error:", err)
+		h.failAndNotify(client.ID, phone, "openai run", fallbackBusy, err)
 		return
 	}
 
-	status := ""
-	for i := 0; i < 10; i++ {
-		time.Sleep(2 * time.Second)
-		status, err = h.ai.GetRun(ctx, threadID, runID)
-		if err != nil {
-			break
-		}
-		if status == "completed" || status == "failed" || status == "expired" {
-			break
-		}
+	status, _ := h.waitRun(ctx, threadID, runID, tools.Call{ClientID: client.ID, Phone: phone}, func() { h.sendInterim(ctx, client.ID, phone) })
+	if status == runSuperseded {
+		h.cancelRun(ctx, client.ID, phone, threadID, runID)
+		return
 	}
 	if status != "completed" {
-		log.Println("run not completed:", status)
+		h.failAndNotify(client.ID, phone, "openai run", fallbackBusy, fmt.Errorf("run not completed: %s", status))
 		return
 	}
 
-	reply, err := h.ai.GetLastAssistantText(ctx, threadID)
-		if err != nil {
-			log.Println("openai get message error:", err)
-			return
+	msg, err := h.ai.GetLastAssistantMessage(ctx, threadID)
+	if emptyReply(msg, err) && h.cfg.EmptyReplyRetry {
+		log.Printf("empty assistant reply for %s, retrying run", phone)
+		msg, err = h.retryEmptyReply(ctx, threadID, assistantID, model, instructions, tools.Call{ClientID: client.ID, Phone: phone})
+	}
+	if errors.Is(err, errRunSuperseded) {
+		return
+	}
+	if emptyReply(msg, err) {
+		if err == nil {
+			err = openai.ErrNoAssistantText
 		}
-	reply = removeRefs(reply)
+		h.failAndNotify(client.ID, phone, "openai empty reply", fallbackEmptyReply, err)
+		return
+	}
+	if err != nil {
+		h.failAndNotify(client.ID, phone, "openai get message", fallbackBusy, err)
+		return
+	}
+	reply, sources := h.renderCitations(ctx, msg)
+	reply = h.pipeline.Run(ctx, processor.Text{Direction: processor.Outbound, Kind: "text", Content: reply}).Content
 
-	// Calcula delay de resposta conforme as configurações
-	delay := h.cfg.ReplyDelay()          // retorna um time.Duration entre min e max
-	delayMs := int(delay / time.Millisecond) // converte para milissegundos
+	// Notas internas (<note>...</note>) ficam no histórico e nunca chegam ao cliente
+	reply, notes := processor.ExtractNotes(reply)
+	h.saveNotes(ctx, client.ID, phone, notes)
+	if strings.TrimSpace(reply) == "" {
+		log.Printf("assistant reply for %s had only notes: nothing sent", phone)
+		return
+	}
 
-	if strings.ToLower(strings.TrimSpace(lastKind)) == "audio" {
-		audioBytes, err := h.ai.GenerateSpeech(ctx, reply)
-		if err != nil {
-			log.Println("tts error:", err)
-			return
+	// Resposta estruturada (botões, mídia, handoff); se não for JSON válido, segue como texto
+	var env replyEnvelope
+	structured := false
+	if h.cfg.ReplyDirectives {
+		if env, structured = parseReplyEnvelope(reply); structured {
+			reply = env.Text
 		}
-		_ = models.InsertMessage(ctx, h.pool, models.Message{
-			ClientID: client.ID, Role: "assistant", Type: "audio", Content: reply,
-		})
-		// Envia áudio com delay
-		if err := h.wpp.SendMediaWithDelay(ctx, phone, "audio", audioBytes, delayMs); err != nil {
-			log.Println("uazapi send audio error:", err)
+	}
+	reply = h.throttleGreeting(ctx, client.ID, greeted, reply)
+	if sources != "" && reply != "" {
+		reply += "\n\nFontes:\n" + sources
+	}
+	reply = h.interpolateReply(client, reply)
+	if h.cfg.ReplyMarkdown {
+		mode := h.cfg.ReplyTableMode
+		if strings.EqualFold(strings.TrimSpace(lastKind), "audio") {
+			// resposta em áudio: bloco monoespaçado não faz sentido na voz
+			mode = processor.TableBullets
 		}
+		reply = processor.FormatWhatsApp(reply, mode)
+	}
+	if !strings.EqualFold(strings.TrimSpace(lastKind), "audio") {
+		reply = h.withFooter(ctx, client, reply)
+	}
+	if lang != "" && h.cfg.TranslateMode == "translate" {
+		ctx, reply = h.translateReply(ctx, phone, lang, reply)
+	}
+
+	// Calcula delay de resposta conforme as configurações
+	bcfg := h.botConfig(ctx, phone)
+	delay := bcfg.ReplyDelay()          // retorna um time.Duration entre min e max
+	delayMs := int(delay / time.Millisecond) // converte para milissegundos
+
+	if structured && env.hasDirectives() {
+		env.Text = reply
+		h.renderDirectives(ctx, client, phone, env, delayMs)
+	} else if strings.ToLower(strings.TrimSpace(lastKind)) == "audio" {
+		// Envia áudio com delay (texto se a voz ou o envio falhar)
+		h.sendAudioReply(ctx, client, phone, bcfg, reply, delayMs)
 	} else {
-		_ = models.InsertMessage(ctx, h.pool, models.Message{
-			ClientID: client.ID, Role: "assistant", Type: "text", Content: reply,
-		})
 		// Envia texto com delay
-		if err := h.wpp.SendTextWithDelay(ctx, phone, reply, delayMs); err != nil {
-			log.Println("uazapi send text error:", err)
+		// o marcador da variante só vai no WhatsApp; o histórico já tem messages.variant
+		text := reply + h.variantMarker(ctx)
+		_, err := h.deliver(ctx, phone, models.Message{ClientID: client.ID, Type: "text", Content: reply}, func(ctx context.Context) (uazapi.SendResult, error) {
+			return h.wpp.SendTextWithDelay(ctx, phone, text, delayMs)
+		})
+		if err != nil {
+			h.fail(phone, "uazapi send text", err)
 		}
 	}
+	h.trackPendingLink(ctx, client.ID, phone, reply)
+
+	h.recordLatency(ctx, client.ID, phone, true)
+	go h.updateMemory(context.Background(), client.ID, prompt, reply)
+	go h.updateSummary(context.Background(), client.ID)
 }
 
 // Converte uma mensagem individual em texto para o LLM e retorna o tipo.
-func (h *webhookHandler) normalizeInput(ctx context.Context, msg incomingMessage) (string, string, error) {
+func (h *WebhookHandler) normalizeInput(ctx context.Context, clientID int64, phone string, msg incomingMessage) (string, string, error) {
 	switch strings.ToLower(msg.MessageType) {
 	case "extendedtextmessage", "conversation":
 		var content string
@@ -378,14 +1146,20 @@ func (h *webhookHandler) normalizeInput(ctx context.Context, msg incomingMessage
 		if content == "" {
 			content = "(mensagem vazia)"
 		}
+		if processor.IsEmojiOnly(content) {
+			return processor.Emojis(content), "emoji", nil
+		}
 		return processor.SanitizeText(removeRefs(content)), "text", nil
 
+	case "reactionmessage", "reaction":
+		return reactionEmoji(msg.Content), "reaction", nil
+
 	case "audiomessage", "audio":
 		data, _, err := h.wpp.DownloadByMessageID(ctx, msg.MessageID)
 		if err != nil {
 			return "", "", err
 		}
-		t, err := h.ai.Transcribe(ctx, data, "audio.ogg")
+		t, err := h.transcribe(ctx, data, "audio.ogg")
 		if err != nil {
 			return "", "", err
 		}
@@ -400,25 +1174,27 @@ func (h *webhookHandler) normalizeInput(ctx context.Context, msg incomingMessage
 		if err != nil {
 			return "", "", err
 		}
-		return processor.SanitizeText(removeRefs("Descrição da imagem: " + desc)), "image", nil
+		tag := ""
+		if !msg.ViewOnce {
+			tag = h.rememberImage(ctx, clientID, msg.MessageID, url, desc)
+		}
+		return processor.SanitizeText(removeRefs(withImageTag("Descrição da imagem", tag) + ": " + desc)), "image", nil
 
 	case "documentmessage", "document":
 		data, _, err := h.wpp.DownloadByMessageID(ctx, msg.MessageID)
 		if err != nil {
 			return "", "", err
 		}
-		extracted, err := openai.ExtractPDFText(ctx, data)
-		if err != nil {
-			extracted = "(não foi possível extrair texto do PDF)"
+		if max := h.cfg.DocumentMaxMB << 20; max > 0 && len(data) > max {
+			return "", "", fmt.Errorf("%w: %d bytes", errDocumentTooLarge, len(data))
 		}
-		summary, err := h.ai.SummarizeText(ctx, extracted)
-		if err != nil {
-			if len(extracted) > 4000 {
-				extracted = extracted[:4000]
-			}
-			return processor.SanitizeText(removeRefs(extracted)), "document", nil
+		var meta struct {
+			Mimetype string `json:"mimetype"`
+			FileName string `json:"fileName"`
 		}
-		return processor.SanitizeText(removeRefs("Resumo do documento: " + summary)), "document", nil
+		_ = json.Unmarshal(msg.Content, &meta)
+		h.stageDocument(ctx, clientID, phone, data, meta.FileName)
+		return h.summarizeDocument(ctx, data, meta.Mimetype, meta.FileName), "document", nil
 
 	default:
 		var content string
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Payloads representativos do webhook da Uazapi.
+var benchPayloads = []struct{ name, body string }{
+	{"envelope", `{"EventType":"messages","owner":"5511999990000","chat":{"wa_chatid":"5511988887777@s.whatsapp.net"},` +
+		`"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"3EB0C0FFEE","messageType":"conversation",` +
+		`"content":"Olá, quero agendar uma consulta para amanhã","senderName":"Maria Silva"}}`},
+	{"viewonce", `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"3EB0C0FFEF","messageType":"viewOnceMessageV2",` +
+		`"content":{"message":{"imageMessage":{"caption":"foto"}}}}}`},
+	{"fallback", `{"data":{"foo":"bar","remoteJid":"5511988887777@s.whatsapp.net"}}`},
+}
+
+func BenchmarkParsePayload(b *testing.B) {
+	for _, p := range benchPayloads {
+		body := []byte(p.body)
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r, _ := http.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+				if _, _, err := parsePayload(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePayloadUnwrap(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantType  string
+		wantView  bool
+		wantEphem bool
+		wantIn    string // trecho esperado no conteúdo interno
+	}{
+		{
+			name: "imagem de visualização única",
+			body: `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"A1","messageType":"viewOnceMessageV2",` +
+				`"content":{"message":{"messageContextInfo":{"deviceListMetadata":{}},"imageMessage":{"caption":"foto","mimetype":"image/jpeg"}}}}}`,
+			wantType: "imageMessage", wantView: true, wantIn: `"caption":"foto"`,
+		},
+		{
+			name: "áudio de visualização única",
+			body: `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"A2","messageType":"viewOnceMessageV2Extension",` +
+				`"content":{"message":{"audioMessage":{"seconds":7,"ptt":true}}}}}`,
+			wantType: "audioMessage", wantView: true, wantIn: `"seconds":7`,
+		},
+		{
+			name: "mídia e texto no mesmo wrapper: a mídia vence",
+			body: `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"A3","messageType":"viewOnceMessage",` +
+				`"content":{"message":{"extendedTextMessage":{"text":"oi"},"imageMessage":{"caption":"foto"},"conversation":"oi"}}}}`,
+			wantType: "imageMessage", wantView: true, wantIn: `"caption":"foto"`,
+		},
+		{
+			name: "visualização única dentro de mensagem temporária",
+			body: `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"A4","messageType":"ephemeralMessage",` +
+				`"content":{"message":{"viewOnceMessageV2":{"message":{"audioMessage":{"seconds":3}}}}}}}`,
+			wantType: "audioMessage", wantView: true, wantEphem: true, wantIn: `"seconds":3`,
+		},
+		{
+			name: "texto temporário",
+			body: `{"message":{"chatid":"5511988887777@s.whatsapp.net","messageid":"A5","messageType":"ephemeralMessage",` +
+				`"content":{"message":{"extendedTextMessage":{"text":"tudo bem?"}}}}}`,
+			wantType: "extendedTextMessage", wantEphem: true, wantIn: `"tudo bem?"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a ordem de um map muda a cada execução: repete para pegar escolhas instáveis
+			for i := 0; i < 20; i++ {
+				r, _ := http.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+				msg, _, err := parsePayload(r)
+				if err != nil {
+					t.Fatalf("parsePayload() error: %v", err)
+				}
+				if msg.MessageType != tt.wantType || msg.ViewOnce != tt.wantView || msg.Ephemeral != tt.wantEphem {
+					t.Fatalf("parsePayload() = type %q viewOnce %v ephemeral %v; want %q %v %v",
+						msg.MessageType, msg.ViewOnce, msg.Ephemeral, tt.wantType, tt.wantView, tt.wantEphem)
+				}
+				if !strings.Contains(string(msg.Content), tt.wantIn) {
+					t.Fatalf("content = %s, want it to contain %s", msg.Content, tt.wantIn)
+				}
+			}
+		})
+	}
+}
+
+func TestInnerMessageKeyUnknown(t *testing.T) {
+	inner := map[string]json.RawMessage{"messageContextInfo": nil, "zMessage": nil, "bMessage": nil}
+	if k, ok := innerMessageKey(inner); !ok || k != "bMessage" {
+		t.Fatalf("innerMessageKey() = %q, %v; want bMessage, true", k, ok)
+	}
+	if _, ok := innerMessageKey(map[string]json.RawMessage{"messageContextInfo": nil}); ok {
+		t.Fatal("innerMessageKey() found a message in a wrapper with only messageContextInfo")
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/events"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/phone"
+	"github.com/your-org/leandro-agent/internal/processor"
+)
+
+/*
+Chat no site (WIDGET_SECRET).
+
+O widget entra na mesma conversa do WhatsApp (mesmo cliente, histórico e thread)
+com um token assinado que identifica tenant e telefone:
+
+	POST /api/v1/widget/tokens   {"phone": "5511999999999"}   (INGEST_TOKEN ou token do tenant)
+	                             → {"token", "expires_at", "url"}  (url = WIDGET_URL?t=token)
+
+Rotas do widget (token em "Authorization: Bearer" ou ?t=; CORS de WIDGET_ORIGINS):
+
+	GET  /widget/v1/messages?after=<id>&wait=<s>   mensagens depois do cursor (sem after: as
+	                                               últimas 50); wait segura até 25s esperando novidade
+	POST /widget/v1/messages   {"text": "...", "id": "uuid-do-widget"}
+
+A mensagem do POST é gravada antes da resposta, que devolve o id dela: um GET
+seguinte com after menor que esse id sempre a inclui (o widget lê o que escreveu;
+o cursor do widget continua sendo o do GET). O id do widget torna o POST
+idempotente (retentativas não duplicam). A mensagem segue o mesmo caminho do
+/api/v1/inbound; a resposta do assistente vai também para o WhatsApp, então as
+duas pontas ficam com a conversa inteira.
+*/
+
+const (
+	widgetTailLimit = 50
+	widgetMaxWait   = 25 * time.Second
+)
+
+type widgetClaims struct {
+	Tenant  int64  `json:"tid,omitempty"`
+	Phone   string `json:"p"`
+	Expires int64  `json:"exp"`
+}
+
+var errWidgetToken = errors.New("invalid widget token")
+
+// signWidgetToken monta "payload.assinatura" (base64url; HMAC-SHA256 com WIDGET_SECRET).
+func signWidgetToken(secret string, c widgetClaims) string {
+	raw, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseWidgetToken confere assinatura e validade.
+func parseWidgetToken(secret, token string, now time.Time) (widgetClaims, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return widgetClaims{}, errWidgetToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return widgetClaims{}, errWidgetToken
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return widgetClaims{}, errWidgetToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return widgetClaims{}, errWidgetToken
+	}
+	var c widgetClaims
+	if err := json.Unmarshal(raw, &c); err != nil || !phone.Valid(c.Phone) {
+		return widgetClaims{}, errWidgetToken
+	}
+	if now.Unix() >= c.Expires {
+		return widgetClaims{}, errors.New("widget token expired")
+	}
+	return c, nil
+}
+
+// widgetMessage é a mensagem como o widget a mostra.
+type widgetMessage struct {
+	ID   int64     `json:"id"`
+	From string    `json:"from"` // client | assistant | operator
+	Type string    `json:"type"`
+	Text string    `json:"text"`
+	At   time.Time `json:"at"`
+}
+
+func toWidgetMessages(list []models.Message) []widgetMessage {
+	out := make([]widgetMessage, 0, len(list))
+	for _, m := range list {
+		from := m.Role
+		if from == "user" {
+			from = "client"
+		}
+		out = append(out, widgetMessage{ID: m.ID, From: from, Type: m.Type, Text: m.Content, At: m.CreatedAt})
+	}
+	return out
+}
+
+// WidgetTokenHandler expõe POST /api/v1/widget/tokens.
+func (h *WebhookHandler) WidgetTokenHandler() http.Handler {
+	return requireToken(h.cfg.IngestToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if h.cfg.WidgetSecret == "" {
+			http.Error(w, "widget disabled (WIDGET_SECRET)", http.StatusNotFound)
+			return
+		}
+		ctx := h.scope(r.Context())
+		var in struct {
+			Phone string `json:"phone"`
+			Name  string `json:"name"`
+		}
+		if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		number, ok := phone.Normalize(in.Phone)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "phone must be 10-15 digits"})
+			return
+		}
+		var name *string
+		if in.Name = strings.TrimSpace(in.Name); in.Name != "" {
+			name = &in.Name
+		}
+		client, err := h.clients.GetOrCreate(ctx, number, name)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		exp := time.Now().Add(time.Duration(h.cfg.WidgetTokenHours) * time.Hour)
+		token := signWidgetToken(h.cfg.WidgetSecret, widgetClaims{Tenant: h.tenantID, Phone: client.Phone, Expires: exp.Unix()})
+		out := map[string]any{"ok": true, "token": token, "expires_at": exp}
+		if h.cfg.WidgetURL != "" {
+			sep := "?"
+			if strings.Contains(h.cfg.WidgetURL, "?") {
+				sep = "&"
+			}
+			out["url"] = h.cfg.WidgetURL + sep + "t=" + url.QueryEscape(token)
+		}
+		writeJSON(w, http.StatusOK, out)
+	}))
+}
+
+// widgetCORS libera a origem se estiver em WIDGET_ORIGINS; true = preflight respondido.
+func (h *WebhookHandler) widgetCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin != "" && (slices.Contains(h.cfg.WidgetOrigins, "*") || slices.Contains(h.cfg.WidgetOrigins, origin)) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Add("Vary", "Origin")
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+	return false
+}
+
+// serveWidget atende /widget/v1/messages para o telefone do token (já conferido).
+func (h *WebhookHandler) serveWidget(w http.ResponseWriter, r *http.Request, number string) {
+	ctx := h.scope(r.Context())
+	client, err := h.clients.GetOrCreate(ctx, number, nil)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "db error", err)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.widgetHistory(ctx, w, r, client)
+	case http.MethodPost:
+		h.widgetPost(ctx, w, r, client)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *WebhookHandler) widgetHistory(ctx context.Context, w http.ResponseWriter, r *http.Request, client models.Client) {
+	q := r.URL.Query()
+	after, _ := strconv.ParseInt(q.Get("after"), 10, 64)
+	wait, _ := strconv.Atoi(q.Get("wait"))
+	deadline := time.Now().Add(min(time.Duration(max(wait, 0))*time.Second, widgetMaxWait))
+
+	load := func() ([]models.Message, error) {
+		if q.Get("after") == "" {
+			return h.messages.Tail(ctx, client.ID, widgetTailLimit)
+		}
+		return h.messages.After(ctx, client.ID, after, 100)
+	}
+	list, err := load()
+	if err == nil && len(list) == 0 && time.Now().Before(deadline) && h.events != nil {
+		// espera uma mensagem nova do cliente (no barramento) ou o prazo
+		woke := make(chan struct{}, 1)
+		cancel := h.events.Subscribe(events.All, func(_ context.Context, ev events.Event) {
+			if ev.Tenant == h.tenantID && ev.ClientID == client.ID {
+				select {
+				case woke <- struct{}{}:
+				default:
+				}
+			}
+		})
+		defer cancel()
+		t := time.NewTicker(2 * time.Second) // réplicas sem barramento compartilhado
+		defer t.Stop()
+		for err == nil && len(list) == 0 && time.Now().Before(deadline) {
+			timer := time.NewTimer(time.Until(deadline))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-woke:
+			case <-t.C:
+			case <-timer.C:
+			}
+			timer.Stop()
+			list, err = load()
+		}
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "db error", err)
+		return
+	}
+	cursor := after
+	if len(list) > 0 {
+		cursor = list[len(list)-1].ID
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"messages": toWidgetMessages(list), "cursor": cursor})
+}
+
+func (h *WebhookHandler) widgetPost(ctx context.Context, w http.ResponseWriter, r *http.Request, client models.Client) {
+	var in struct {
+		Text string `json:"text"`
+		ID   string `json:"id"`
+	}
+	if err := json.NewDecoder(limitBody(r)).Decode(&in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+		return
+	}
+	text := processor.SanitizeText(strings.TrimSpace(in.Text))
+	if text == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "text is required"})
+		return
+	}
+	var extID *string
+	if id := strings.TrimSpace(in.ID); id != "" {
+		if len(id) > 100 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "id too long"})
+			return
+		}
+		ext := "widget:" + id
+		extID = &ext
+		prev, ok, err := h.messages.ByExtID(ctx, client.ID, ext)
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "db error", err)
+			return
+		}
+		if ok {
+			writeJSON(w, http.StatusOK, map[string]any{"ok": true, "duplicate": true, "message": toWidgetMessages([]models.Message{prev})[0]})
+			return
+		}
+	}
+
+	m := models.Message{ClientID: client.ID, Role: "user", Type: "text", Content: text, ExtID: extID}
+	id, err := h.saveMessageID(ctx, client.Phone, m)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "db error", err)
+		return
+	}
+	queued, err := h.dispatchInbound(ctx, client.Phone, text, "text")
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "db error", err)
+		return
+	}
+	m.ID, m.CreatedAt = id, time.Now()
+	m.Content, _ = h.redact.Apply(m.Content) // como ficou gravada
+	out := map[string]any{"ok": true, "message": toWidgetMessages([]models.Message{m})[0]}
+	if queued {
+		out["queued"] = "maintenance"
+	}
+	writeJSON(w, http.StatusOK, out)
+}
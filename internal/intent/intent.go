@@ -0,0 +1,130 @@
+// internal/intent/intent.go
+package intent
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Intenções determinísticas respondidas sem a IA.
+
+Uma regra casa quando qualquer palavra-chave aparece na mensagem (comparação sem
+acento nem caixa, por palavra inteira; "horario*" casa também "horarios") ou
+quando o pattern (regex RE2, sem caixa) casa. Mensagens longas (acima de
+maxWords palavras) não são respondidas por regra: costumam trazer mais de uma
+pergunta e vão para o assistente. Com várias regras casando, vence a de maior
+prioridade (a ordem de entrada).
+*/
+
+type rule struct {
+	models.IntentRule
+	words    []string // palavras-chave normalizadas
+	prefixes []string // palavras-chave terminadas em "*"
+	re       *regexp.Regexp
+}
+
+// Matcher guarda as regras ativas já compiladas.
+type Matcher struct {
+	rules    []rule
+	maxWords int
+}
+
+// Compile prepara as regras (na ordem de prioridade). Pattern inválido é
+// ignorado (a regra segue com as palavras-chave); ValidPattern valida antes de gravar.
+func Compile(rules []models.IntentRule, maxWords int) *Matcher {
+	m := &Matcher{maxWords: maxWords}
+	for _, r := range rules {
+		if !r.Active {
+			continue
+		}
+		c := rule{IntentRule: r}
+		for _, kw := range r.Keywords {
+			k := Normalize(strings.TrimSuffix(kw, "*"))
+			switch {
+			case k == "":
+			case strings.HasSuffix(kw, "*"):
+				c.prefixes = append(c.prefixes, k)
+			default:
+				c.words = append(c.words, k)
+			}
+		}
+		if r.Pattern != "" {
+			c.re, _ = regexp.Compile("(?i)" + r.Pattern)
+		}
+		if len(c.words) == 0 && len(c.prefixes) == 0 && c.re == nil {
+			continue
+		}
+		m.rules = append(m.rules, c)
+	}
+	return m
+}
+
+// Len devolve quantas regras podem casar.
+func (m *Matcher) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.rules)
+}
+
+// Match devolve a regra de maior prioridade que casa com text.
+func (m *Matcher) Match(text string) (models.IntentRule, bool) {
+	if m.Len() == 0 {
+		return models.IntentRule{}, false
+	}
+	norm := Normalize(text)
+	if norm == "" || (m.maxWords > 0 && len(strings.Fields(norm)) > m.maxWords) {
+		return models.IntentRule{}, false
+	}
+	padded := " " + norm + " "
+	for _, r := range m.rules {
+		if r.matches(text, padded) {
+			return r.IntentRule, true
+		}
+	}
+	return models.IntentRule{}, false
+}
+
+func (r rule) matches(text, padded string) bool {
+	for _, w := range r.words {
+		if strings.Contains(padded, " "+w+" ") {
+			return true
+		}
+	}
+	for _, p := range r.prefixes {
+		if strings.Contains(padded, " "+p) {
+			return true
+		}
+	}
+	return r.re != nil && r.re.MatchString(text)
+}
+
+// ValidPattern confere se o pattern compila.
+func ValidPattern(p string) error {
+	if p == "" {
+		return nil
+	}
+	_, err := regexp.Compile("(?i)" + p)
+	return err
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "î", "i", "ì", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "û", "u", "ù", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Normalize deixa só letras e dígitos minúsculos sem acento, separados por um espaço.
+func Normalize(s string) string {
+	s = accents.Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,65 @@
+// internal/media/audio.go
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Filtro do ffmpeg aplicado antes da transcrição:
+//   - remove silêncio no início e no fim (areverse + silenceremove);
+//   - normaliza o volume (loudnorm, EBU R128);
+//   - mono 16 kHz, que é o que o Whisper usa internamente.
+const transcribeFilter = "silenceremove=start_periods=1:start_threshold=-45dB:start_silence=0.2," +
+	"areverse,silenceremove=start_periods=1:start_threshold=-45dB:start_silence=0.2,areverse," +
+	"loudnorm=I=-16:TP=-1.5:LRA=11"
+
+// PrepareForTranscription converte o áudio (qualquer codec suportado pelo ffmpeg)
+// para MP3 mono 16 kHz com silêncio aparado e volume normalizado.
+// Retorna os bytes e o nome de arquivo a usar no upload.
+func PrepareForTranscription(ctx context.Context, ffmpeg string, audio []byte) ([]byte, string, error) {
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	dir, err := os.MkdirTemp("", "audio-*")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out.mp3")
+	if err := os.WriteFile(in, audio, 0o600); err != nil {
+		return nil, "", err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg, "-hide_banner", "-loglevel", "error", "-y",
+		"-i", in, "-af", transcribeFilter, "-ac", "1", "-ar", "16000", "-b:a", "48k", out)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("ffmpeg: %w: %s", err, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 {
+		// só silêncio: o filtro removeu tudo
+		return nil, "", fmt.Errorf("ffmpeg: empty output (silent audio)")
+	}
+	return data, "audio.mp3", nil
+}
+
+// HasFFmpeg informa se o binário do ffmpeg está disponível.
+func HasFFmpeg(ffmpeg string) bool {
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	_, err := exec.LookPath(ffmpeg)
+	return err == nil
+}
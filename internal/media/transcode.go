@@ -0,0 +1,118 @@
+// internal/media/transcode.go
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// Transcoder adapta imagens e vídeos enviados aos limites do WhatsApp com o ffmpeg:
+//   - imagens acima de ImageMaxBytes viram JPEG com o lado maior reduzido;
+//   - vídeos acima de VideoMaxBytes viram MP4 H.264 baseline + AAC (toca em
+//     qualquer aparelho), com resolução e qualidade reduzidas até caber.
+//
+// Mídias dentro dos limites passam sem alteração.
+type Transcoder struct {
+	FFmpeg        string
+	ImageMaxBytes int
+	VideoMaxBytes int
+}
+
+// NewTranscoder cria o transcodificador; limites <= 0 usam os do WhatsApp (5 MB / 16 MB).
+func NewTranscoder(ffmpeg string, imageMaxBytes, videoMaxBytes int) *Transcoder {
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	if imageMaxBytes <= 0 {
+		imageMaxBytes = 5 << 20
+	}
+	if videoMaxBytes <= 0 {
+		videoMaxBytes = 16 << 20
+	}
+	return &Transcoder{FFmpeg: ffmpeg, ImageMaxBytes: imageMaxBytes, VideoMaxBytes: videoMaxBytes}
+}
+
+// tentativas em ordem: a primeira que couber no limite é usada
+var (
+	imageSteps = []struct{ side, quality int }{{2048, 4}, {1600, 6}, {1280, 8}, {960, 10}}
+	videoSteps = []struct{ width, crf, audioKbps int }{{848, 28, 96}, {640, 32, 64}, {480, 36, 48}}
+)
+
+// Fit devolve data dentro do limite do tipo (image | video); outros tipos e
+// mídias que já cabem voltam como vieram. changed indica se houve conversão.
+func (t *Transcoder) Fit(ctx context.Context, mediaType string, data []byte) (out []byte, changed bool, err error) {
+	switch {
+	case mediaType == "image" && len(data) > t.ImageMaxBytes:
+		for _, s := range imageSteps {
+			scale := fmt.Sprintf("scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease", s.side, s.side)
+			if out, err = t.run(ctx, data, "out.jpg", "-vf", scale, "-frames:v", "1", "-q:v", strconv.Itoa(s.quality)); err != nil {
+				return nil, false, err
+			}
+			if len(out) <= t.ImageMaxBytes {
+				return out, true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("image still %d bytes after resizing (limit %d)", len(out), t.ImageMaxBytes)
+	case mediaType == "video" && len(data) > t.VideoMaxBytes:
+		for _, s := range videoSteps {
+			out, err = t.run(ctx, data, "out.mp4",
+				"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", s.width),
+				"-c:v", "libx264", "-profile:v", "baseline", "-level", "3.1", "-pix_fmt", "yuv420p",
+				"-preset", "veryfast", "-crf", strconv.Itoa(s.crf),
+				"-c:a", "aac", "-b:a", strconv.Itoa(s.audioKbps)+"k", "-ac", "2",
+				"-movflags", "+faststart")
+			if err != nil {
+				return nil, false, err
+			}
+			if len(out) <= t.VideoMaxBytes {
+				return out, true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("video still %d bytes after compressing (limit %d)", len(out), t.VideoMaxBytes)
+	}
+	return data, false, nil
+}
+
+// Thumbnail gera a miniatura JPEG (320 px de largura) de um vídeo, do quadro de 1 s
+// (ou do primeiro, em vídeos mais curtos).
+func (t *Transcoder) Thumbnail(ctx context.Context, video []byte) ([]byte, error) {
+	for _, at := range []string{"1", "0"} {
+		out, err := t.run(ctx, video, "thumb.jpg", "-ss", at, "-frames:v", "1", "-vf", "scale=320:-2", "-q:v", "6")
+		if err == nil && len(out) > 0 {
+			return out, nil
+		}
+		if err != nil && at == "0" {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("ffmpeg: empty thumbnail")
+}
+
+// run grava data num diretório temporário e roda o ffmpeg com args entre a
+// entrada e o arquivo de saída name.
+func (t *Transcoder) run(ctx context.Context, data []byte, name string, args ...string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "media-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, name)
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, err
+	}
+	full := append([]string{"-hide_banner", "-loglevel", "error", "-y", "-i", in}, args...)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.FFmpeg, append(full, out)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, stderr.String())
+	}
+	return os.ReadFile(out)
+}
@@ -0,0 +1,133 @@
+// internal/media/whisper.go
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Whisper transcreve áudio sem sair da infraestrutura, para implantações com
+// restrição de privacidade ou volume de áudio em que o custo da OpenAI pesa:
+//
+//   - Command: binário local (whisper.cpp, faster-whisper via script...) que
+//     imprime o texto no stdout. {file} vira o caminho do áudio (se ausente, vai
+//     como último argumento) e {lang} o idioma.
+//   - URL: servidor HTTP com a API de transcrição da OpenAI (faster-whisper-server,
+//     whisper.cpp server); recebe multipart "file" e devolve {"text": "..."} ou texto puro.
+type Whisper struct {
+	Command  string
+	URL      string
+	Model    string
+	Language string
+	Timeout  time.Duration
+	HTTP     *http.Client
+}
+
+// Transcribe devolve o texto do áudio pelo backend configurado.
+func (w *Whisper) Transcribe(ctx context.Context, audio []byte, filename string) (string, error) {
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+	if w.Command != "" {
+		return w.transcribeExec(ctx, audio, filename)
+	}
+	return w.transcribeHTTP(ctx, audio, filename)
+}
+
+func (w *Whisper) transcribeExec(ctx context.Context, audio []byte, filename string) (string, error) {
+	dir, err := os.MkdirTemp("", "whisper-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	// a extensão ajuda o binário a escolher o decodificador
+	in := filepath.Join(dir, "audio"+filepath.Ext(filename))
+	if err := os.WriteFile(in, audio, 0o600); err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(w.Command)
+	args := make([]string, 0, len(fields))
+	hasFile := false
+	for _, f := range fields[1:] {
+		if strings.Contains(f, "{file}") {
+			hasFile = true
+		}
+		f = strings.ReplaceAll(f, "{file}", in)
+		f = strings.ReplaceAll(f, "{lang}", w.Language)
+		args = append(args, f)
+	}
+	if !hasFile {
+		args = append(args, in)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, fields[0], args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("whisper exec: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (w *Whisper) transcribeHTTP(ctx context.Context, audio []byte, filename string) (string, error) {
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	if w.Model != "" {
+		_ = mw.WriteField("model", w.Model)
+	}
+	if w.Language != "" {
+		_ = mw.WriteField("language", w.Language)
+	}
+	_ = mw.WriteField("response_format", "json")
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(audio); err != nil {
+		return "", err
+	}
+	mw.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, &b)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	cli := w.HTTP
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whisper http: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode > 299 {
+		return "", fmt.Errorf("whisper http status %d: %s", resp.StatusCode, string(body))
+	}
+	var out struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &out); err == nil {
+		return strings.TrimSpace(out.Text), nil
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// AbuseEvent records a spam/abuse detection and the cooldown applied to the sender.
+type AbuseEvent struct {
+    ID            int64      `json:"id"`
+    Phone         string     `json:"phone"`
+    Reason        string     `json:"reason"`
+    Detail        string     `json:"detail"`
+    CooldownUntil time.Time  `json:"cooldown_until"`
+    LiftedAt      *time.Time `json:"lifted_at,omitempty"`
+    CreatedAt     time.Time  `json:"created_at"`
+}
+
+// RecordAbuseEvent stores a detection. Detail is truncated to 500 chars.
+func RecordAbuseEvent(ctx context.Context, db DB, phone, reason, detail string, until time.Time) error {
+    if len(detail) > 500 {
+        detail = detail[:500]
+    }
+    _, err := db.Exec(ctx, `
+        INSERT INTO abuse_events (phone, reason, detail, cooldown_until) VALUES ($1,$2,$3,$4)
+    `, phone, reason, detail, until)
+    return err
+}
+
+// ListAbuseEvents returns recent events, optionally for a single phone.
+func ListAbuseEvents(ctx context.Context, db DB, phone string, limit int) ([]AbuseEvent, error) {
+    if limit <= 0 || limit > 500 {
+        limit = 100
+    }
+    rows, err := db.Query(ctx, `
+        SELECT id, phone, reason, detail, cooldown_until, lifted_at, created_at FROM abuse_events
+        WHERE ($1 = '' OR phone = $1)
+        ORDER BY created_at DESC LIMIT $2
+    `, phone, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []AbuseEvent{}
+    for rows.Next() {
+        var e AbuseEvent
+        if err := rows.Scan(&e.ID, &e.Phone, &e.Reason, &e.Detail, &e.CooldownUntil, &e.LiftedAt, &e.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, e)
+    }
+    return out, rows.Err()
+}
+
+// ActiveCooldowns returns phone -> cooldown end for cooldowns not yet expired nor lifted.
+func ActiveCooldowns(ctx context.Context, db DB) (map[string]time.Time, error) {
+    rows, err := db.Query(ctx, `
+        SELECT phone, MAX(cooldown_until) FROM abuse_events
+        WHERE lifted_at IS NULL AND cooldown_until > now()
+        GROUP BY phone
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := make(map[string]time.Time)
+    for rows.Next() {
+        var phone string
+        var until time.Time
+        if err := rows.Scan(&phone, &until); err != nil {
+            return nil, err
+        }
+        out[phone] = until
+    }
+    return out, rows.Err()
+}
+
+// LiftCooldown marks the active cooldowns of a phone as lifted. Returns how many were lifted.
+func LiftCooldown(ctx context.Context, db DB, phone string) (int64, error) {
+    ct, err := db.Exec(ctx, `
+        UPDATE abuse_events SET lifted_at=now()
+        WHERE phone=$1 AND lifted_at IS NULL AND cooldown_until > now()
+    `, phone)
+    if err != nil {
+        return 0, err
+    }
+    return ct.RowsAffected(), nil
+}
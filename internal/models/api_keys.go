@@ -0,0 +1,91 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// APIKey is a role-scoped credential for the admin surface. Only the hash is stored.
+type APIKey struct {
+    ID         int64      `json:"id"`
+    Name       string     `json:"name"`
+    Role       string     `json:"role"`
+    Prefix     string     `json:"prefix"`
+    TenantID   *int64     `json:"tenant_id,omitempty"` // nil = all tenants
+    CreatedAt  time.Time  `json:"created_at"`
+    LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+    RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+const apiKeyColumns = `id, name, role, key_prefix, tenant_id, created_at, last_used_at, revoked_at`
+
+func scanAPIKey(row pgx.Row) (APIKey, error) {
+    var k APIKey
+    err := row.Scan(&k.ID, &k.Name, &k.Role, &k.Prefix, &k.TenantID, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt)
+    return k, err
+}
+
+// CreateAPIKey stores a new key by its hash. A non-nil tenantID restricts the key to that tenant.
+func CreateAPIKey(ctx context.Context, db DB, name, role, prefix, hash string, tenantID *int64) (APIKey, error) {
+    return scanAPIKey(db.QueryRow(ctx, `
+        INSERT INTO api_keys (name, role, key_prefix, key_hash, tenant_id) VALUES ($1,$2,$3,$4,$5)
+        RETURNING `+apiKeyColumns, name, role, prefix, hash, tenantID))
+}
+
+// LookupAPIKey returns the active (not revoked) key with the given hash and marks it as used
+// (last_used_at is refreshed at most once a minute). ok is false if not found.
+func LookupAPIKey(ctx context.Context, db DB, hash string) (APIKey, bool, error) {
+    k, err := scanAPIKey(db.QueryRow(ctx, `
+        SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash=$1 AND revoked_at IS NULL
+    `, hash))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return APIKey{}, false, nil
+    }
+    if err != nil {
+        return APIKey{}, false, err
+    }
+    _, _ = db.Exec(ctx, `
+        UPDATE api_keys SET last_used_at=now()
+        WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+    `, k.ID)
+    return k, true, nil
+}
+
+// ListAPIKeys returns all keys (of the tenant of ctx, if scoped), newest first
+// (revoked ones included).
+func ListAPIKeys(ctx context.Context, db DB) ([]APIKey, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+apiKeyColumns+` FROM api_keys
+        WHERE ($1 < 0 OR COALESCE(tenant_id, 0) = $1)
+        ORDER BY id DESC
+    `, tenantFilter(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []APIKey{}
+    for rows.Next() {
+        k, err := scanAPIKey(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, k)
+    }
+    return out, rows.Err()
+}
+
+// RevokeAPIKey revokes a key (of the tenant of ctx, if scoped). Returns false if it
+// does not exist or was already revoked.
+func RevokeAPIKey(ctx context.Context, db DB, id int64) (bool, error) {
+    ct, err := db.Exec(ctx, `
+        UPDATE api_keys SET revoked_at=now()
+        WHERE id=$1 AND revoked_at IS NULL AND ($2 < 0 OR COALESCE(tenant_id, 0) = $2)
+    `, id, tenantFilter(ctx))
+    if err != nil {
+        return false, err
+    }
+    return ct.RowsAffected() > 0, nil
+}
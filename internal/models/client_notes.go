@@ -0,0 +1,52 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// ClientNote is a free-text note an operator attached to a client.
+type ClientNote struct {
+    ID        int64     `json:"id"`
+    ClientID  int64     `json:"client_id"`
+    Note      string    `json:"note"`
+    Author    string    `json:"author"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// AddClientNote stores a note attributed to author.
+func AddClientNote(ctx context.Context, db DB, clientID int64, note, author string) (ClientNote, error) {
+    n := ClientNote{ClientID: clientID, Note: note, Author: author}
+    err := db.QueryRow(ctx, `
+        INSERT INTO client_notes (client_id, note, author) VALUES ($1,$2,$3)
+        RETURNING id, created_at
+    `, clientID, note, author).Scan(&n.ID, &n.CreatedAt)
+    return n, err
+}
+
+// ListClientNotes returns the notes of a client, newest first.
+func ListClientNotes(ctx context.Context, db DB, clientID int64) ([]ClientNote, error) {
+    rows, err := db.Query(ctx, `
+        SELECT id, client_id, note, author, created_at FROM client_notes
+        WHERE client_id=$1 ORDER BY created_at DESC, id DESC
+    `, clientID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []ClientNote{}
+    for rows.Next() {
+        var n ClientNote
+        if err := rows.Scan(&n.ID, &n.ClientID, &n.Note, &n.Author, &n.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, n)
+    }
+    return out, rows.Err()
+}
+
+// DeleteClientNote removes a note of the client. Reports whether it existed.
+func DeleteClientNote(ctx context.Context, db DB, clientID, id int64) (bool, error) {
+    tag, err := db.Exec(ctx, `DELETE FROM client_notes WHERE id=$1 AND client_id=$2`, id, clientID)
+    return tag.RowsAffected() > 0, err
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// ClientCRM returns the last CRM snapshot of a client and when it was fetched
+// (nil when the client was never enriched).
+func ClientCRM(ctx context.Context, db DB, clientID int64) (map[string]string, *time.Time, error) {
+    var (
+        fields map[string]string
+        at     *time.Time
+    )
+    err := db.QueryRow(ctx, `SELECT crm, crm_enriched_at FROM clients WHERE id=$1`, clientID).Scan(&fields, &at)
+    return fields, at, err
+}
+
+// SaveClientCRM stores the CRM snapshot (empty when the CRM does not know the
+// phone) and fills the client name if it is still unknown.
+func SaveClientCRM(ctx context.Context, db DB, clientID int64, fields map[string]string, name string) error {
+    if fields == nil {
+        fields = map[string]string{}
+    }
+    _, err := db.Exec(ctx, `
+        UPDATE clients SET crm=$2, crm_enriched_at=now(), name=COALESCE(name, NULLIF($3, ''))
+        WHERE id=$1
+    `, clientID, fields, name)
+    return err
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// CSATCandidate is a client whose conversation went idle after a reply.
+type CSATCandidate struct {
+    ClientID int64
+    Phone    string
+}
+
+// CSATQuery selects candidates: last message is a reply (assistant or operator) sent
+// between MaxAge and Idle ago, the client wrote in that window, is not opted out and
+// was not surveyed within Cooldown.
+type CSATQuery struct {
+    Idle     time.Duration
+    MaxAge   time.Duration
+    Cooldown time.Duration
+    Limit    int
+}
+
+// ListCSATCandidates returns the eligible clients of the tenant of ctx, oldest first.
+func ListCSATCandidates(ctx context.Context, db DB, q CSATQuery) ([]CSATCandidate, error) {
+    now := time.Now()
+    rows, err := db.Query(ctx, `
+        SELECT c.id, c.phone
+        FROM clients c
+        JOIN LATERAL (
+          SELECT role, created_at FROM messages WHERE client_id = c.id ORDER BY created_at DESC LIMIT 1
+        ) lm ON true
+        WHERE c.opted_out_at IS NULL AND c.unreachable_at IS NULL AND COALESCE(c.tenant_id, 0) = $5
+          AND lm.role IN ('assistant','operator') AND lm.created_at < $1 AND lm.created_at > $2
+          AND EXISTS (SELECT 1 FROM messages m WHERE m.client_id = c.id AND m.role = 'user' AND m.created_at > $2)
+          AND NOT EXISTS (SELECT 1 FROM csat s WHERE s.client_id = c.id AND s.sent_at > $3)
+        ORDER BY lm.created_at
+        LIMIT $4
+    `, now.Add(-q.Idle), now.Add(-q.MaxAge), now.Add(-q.Cooldown), q.Limit, tenantArg(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []CSATCandidate
+    for rows.Next() {
+        var c CSATCandidate
+        if err := rows.Scan(&c.ClientID, &c.Phone); err != nil {
+            return nil, err
+        }
+        out = append(out, c)
+    }
+    return out, rows.Err()
+}
+
+// SurveyedSince reports whether the client got a survey after since.
+func SurveyedSince(ctx context.Context, db DB, clientID int64, since time.Time) (bool, error) {
+    var ok bool
+    err := db.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM csat WHERE client_id=$1 AND sent_at > $2)
+    `, clientID, since).Scan(&ok)
+    return ok, err
+}
+
+// RecordCSATSurvey stores a sent survey. reason is "resolved" or "inactivity".
+func RecordCSATSurvey(ctx context.Context, db DB, clientID int64, reason string) error {
+    _, err := db.Exec(ctx, `INSERT INTO csat (client_id, reason) VALUES ($1,$2)`, clientID, reason)
+    return err
+}
+
+// PendingCSAT returns the latest unanswered survey of the client sent after since.
+func PendingCSAT(ctx context.Context, db DB, clientID int64, since time.Time) (int64, bool, error) {
+    var id int64
+    err := db.QueryRow(ctx, `
+        SELECT id FROM csat
+        WHERE client_id=$1 AND rating IS NULL AND sent_at > $2
+        ORDER BY sent_at DESC LIMIT 1
+    `, clientID, since).Scan(&id)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return 0, false, nil
+    }
+    if err != nil {
+        return 0, false, err
+    }
+    return id, true, nil
+}
+
+// AnswerCSAT stores the rating (1-5) of a survey.
+func AnswerCSAT(ctx context.Context, db DB, id int64, rating int) error {
+    _, err := db.Exec(ctx, `UPDATE csat SET rating=$2, answered_at=now() WHERE id=$1`, id, rating)
+    return err
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+    "context"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DB is the subset of pgx used by the models. It is satisfied by *pgxpool.Pool,
+// *pgxpool.Conn and pgx.Tx, so model calls can run inside a transaction and be
+// exercised with a mock (e.g. pgxmock) without a real database.
+type DB interface {
+    Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+    Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+    QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+var (
+    _ DB = (*pgxpool.Pool)(nil)
+    _ DB = (pgx.Tx)(nil)
+)
+
+// TxStarter is implemented by *pgxpool.Pool (and pgx.Tx, for nested savepoints).
+type TxStarter interface {
+    Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling back otherwise.
+func WithTx(ctx context.Context, db TxStarter, fn func(tx pgx.Tx) error) error {
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer func() { _ = tx.Rollback(ctx) }() // no-op after commit
+    if err := fn(tx); err != nil {
+        return err
+    }
+    return tx.Commit(ctx)
+}
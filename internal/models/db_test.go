@@ -0,0 +1,211 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// expectation is one statement the mock expects, in order, in the style of
+// pgxmock: the SQL must contain match, the arguments must equal args, and the
+// statement answers with row (scanned into the destinations), tag (Exec) or err.
+type expectation struct {
+    match string
+    args  []any
+    row   []any
+    tag   string
+    err   error
+}
+
+// mockTx is a pgx.Tx (and TxStarter) that replays expectations. Methods that the
+// models do not use are left to the embedded nil interface and panic if called.
+type mockTx struct {
+    pgx.Tx
+    t         *testing.T
+    expect    []expectation
+    began     bool
+    committed bool
+    rolled    bool
+}
+
+func (m *mockTx) Begin(context.Context) (pgx.Tx, error) {
+    m.began = true
+    return m, nil
+}
+
+func (m *mockTx) Commit(context.Context) error {
+    m.committed = true
+    return nil
+}
+
+func (m *mockTx) Rollback(context.Context) error {
+    if !m.committed {
+        m.rolled = true
+    }
+    return nil
+}
+
+func (m *mockTx) next(sql string, args []any) expectation {
+    m.t.Helper()
+    if len(m.expect) == 0 {
+        m.t.Fatalf("unexpected statement: %s", strings.TrimSpace(sql))
+    }
+    e := m.expect[0]
+    m.expect = m.expect[1:]
+    if !strings.Contains(sql, e.match) {
+        m.t.Fatalf("statement = %s\nwant one containing %q", strings.TrimSpace(sql), e.match)
+    }
+    if !reflect.DeepEqual(args, e.args) {
+        m.t.Fatalf("%s: args = %#v, want %#v", e.match, args, e.args)
+    }
+    return e
+}
+
+func (m *mockTx) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+    e := m.next(sql, args)
+    return pgconn.NewCommandTag(e.tag), e.err
+}
+
+func (m *mockTx) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+    e := m.next(sql, args)
+    return mockRow{e}
+}
+
+func (m *mockTx) Query(context.Context, string, ...any) (pgx.Rows, error) {
+    m.t.Fatal("Query is not mocked")
+    return nil, nil
+}
+
+func (m *mockTx) done() {
+    m.t.Helper()
+    if len(m.expect) > 0 {
+        m.t.Errorf("expected statements not run: %q", m.expect[0].match)
+    }
+}
+
+type mockRow struct{ e expectation }
+
+func (r mockRow) Scan(dest ...any) error {
+    if r.e.err != nil {
+        return r.e.err
+    }
+    if len(dest) != len(r.e.row) {
+        return errors.New("mock: scan arity mismatch")
+    }
+    for i, v := range r.e.row {
+        d := reflect.ValueOf(dest[i]).Elem()
+        if v == nil {
+            d.SetZero()
+            continue
+        }
+        d.Set(reflect.ValueOf(v))
+    }
+    return nil
+}
+
+func TestGetOrCreateClientInTx(t *testing.T) {
+    created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    name := "Maria"
+    var noName *string
+    ctx := WithTenant(context.Background(), 7)
+
+    tests := []struct {
+        name   string
+        phone  string
+        expect []expectation
+        want   Client
+        err    error
+    }{
+        {
+            name:  "new client, alternate not registered",
+            phone: "5511987654321",
+            expect: []expectation{
+                {match: "UPDATE clients SET name", args: []any{"551187654321", "5511987654321", &name, int64(7)}, err: pgx.ErrNoRows},
+                {match: "INSERT INTO clients", args: []any{"5511987654321", &name, int64(7)},
+                    row: []any{int64(42), "5511987654321", &name, noName, created}},
+            },
+            want: Client{ID: 42, Phone: "5511987654321", Name: &name, CreatedAt: created},
+        },
+        {
+            name:  "existing client under the ninth-digit alternate",
+            phone: "5511987654321",
+            expect: []expectation{
+                {match: "UPDATE clients SET name", args: []any{"551187654321", "5511987654321", &name, int64(7)},
+                    row: []any{int64(9), "551187654321", &name, noName, created}},
+            },
+            want: Client{ID: 9, Phone: "551187654321", Name: &name, CreatedAt: created},
+        },
+        {
+            name:  "landline has no alternate",
+            phone: "551133334444",
+            expect: []expectation{
+                {match: "INSERT INTO clients", args: []any{"551133334444", &name, int64(7)},
+                    row: []any{int64(3), "551133334444", &name, noName, created}},
+            },
+            want: Client{ID: 3, Phone: "551133334444", Name: &name, CreatedAt: created},
+        },
+        {
+            name:  "error rolls the transaction back",
+            phone: "551133334444",
+            expect: []expectation{
+                {match: "INSERT INTO clients", args: []any{"551133334444", &name, int64(7)}, err: errors.New("conn reset")},
+            },
+            err: errors.New("conn reset"),
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            mock := &mockTx{t: t, expect: tt.expect}
+            var got Client
+            err := WithTx(ctx, mock, func(tx pgx.Tx) error {
+                var err error
+                got, err = Postgres{DB: tx}.GetOrCreate(ctx, tt.phone, &name)
+                return err
+            })
+            mock.done()
+            if tt.err != nil {
+                if err == nil || err.Error() != tt.err.Error() {
+                    t.Fatalf("err = %v, want %v", err, tt.err)
+                }
+                if mock.committed || !mock.rolled {
+                    t.Errorf("committed=%v rolled back=%v, want rollback only", mock.committed, mock.rolled)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("GetOrCreate() error: %v", err)
+            }
+            if !mock.began || !mock.committed {
+                t.Errorf("began=%v committed=%v, want both", mock.began, mock.committed)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("GetOrCreate() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSetClientThread(t *testing.T) {
+    for _, tt := range []struct {
+        tag     string
+        wantErr bool
+    }{
+        {"UPDATE 1", false},
+        {"UPDATE 0", true}, // client deleted in the meantime
+    } {
+        mock := &mockTx{t: t, expect: []expectation{
+            {match: "UPDATE clients SET thread_id", args: []any{"thread_abc", int64(1)}, tag: tt.tag},
+        }}
+        err := Postgres{DB: mock}.SetThread(context.Background(), 1, "thread_abc")
+        mock.done()
+        if (err != nil) != tt.wantErr {
+            t.Errorf("%s: SetThread() error = %v, want error %v", tt.tag, err, tt.wantErr)
+        }
+    }
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// DeadLetter is a recovered panic: what was being processed when it happened and
+// the stack, so the work can be inspected and replayed by hand.
+type DeadLetter struct {
+    ID        int64     `json:"id"`
+    Phone     string    `json:"phone,omitempty"`
+    Stage     string    `json:"stage"`
+    Payload   string    `json:"payload,omitempty"`
+    Error     string    `json:"error"`
+    Stack     string    `json:"stack,omitempty"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// InsertDeadLetter records a recovered panic for the tenant of ctx. Payload and
+// stack are truncated to 20000 chars.
+func InsertDeadLetter(ctx context.Context, db DB, d DeadLetter) (int64, error) {
+    if len(d.Payload) > 20000 {
+        d.Payload = d.Payload[:20000]
+    }
+    if len(d.Stack) > 20000 {
+        d.Stack = d.Stack[:20000]
+    }
+    var id int64
+    err := db.QueryRow(ctx, `
+        INSERT INTO dead_letters (tenant_id, phone, stage, payload, error, stack)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6) RETURNING id
+    `, tenantArg(ctx), d.Phone, d.Stage, d.Payload, d.Error, d.Stack).Scan(&id)
+    return id, err
+}
+
+// ListDeadLetters returns the latest dead letters of the tenant of ctx, newest first.
+func ListDeadLetters(ctx context.Context, db DB, limit int) ([]DeadLetter, error) {
+    rows, err := db.Query(ctx, `
+        SELECT id, phone, stage, payload, error, stack, created_at FROM dead_letters
+        WHERE COALESCE(tenant_id, 0) = $1
+        ORDER BY created_at DESC, id DESC LIMIT $2
+    `, tenantArg(ctx), limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []DeadLetter{}
+    for rows.Next() {
+        var d DeadLetter
+        if err := rows.Scan(&d.ID, &d.Phone, &d.Stage, &d.Payload, &d.Error, &d.Stack, &d.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, d)
+    }
+    return out, rows.Err()
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+    "context"
+    "encoding/json"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// auditTables keep the phone on purpose: they are the record of what was erased.
+var auditTables = map[string]bool{"purge_audit": true, "data_erasures": true}
+
+// personalTable is a table holding data of a client, found by its columns.
+type personalTable struct {
+    name     string
+    clientID bool // rows reference clients(id)
+    tenantID bool // rows are scoped by tenant (phone-only tables)
+}
+
+// personalTables lists the tables with a client_id or phone column, besides
+// clients itself and the audit tables. Tables added by later migrations are
+// picked up without changes here.
+func personalTables(ctx context.Context, db DB) ([]personalTable, error) {
+    rows, err := db.Query(ctx, `
+        SELECT table_name,
+               bool_or(column_name = 'client_id'),
+               bool_or(column_name = 'tenant_id')
+        FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name <> 'clients'
+        GROUP BY table_name
+        HAVING bool_or(column_name IN ('client_id', 'phone'))
+        ORDER BY table_name
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []personalTable
+    for rows.Next() {
+        var t personalTable
+        if err := rows.Scan(&t.name, &t.clientID, &t.tenantID); err != nil {
+            return nil, err
+        }
+        if !auditTables[t.name] {
+            out = append(out, t)
+        }
+    }
+    return out, rows.Err()
+}
+
+// filter returns the condition selecting the rows of t about the client (by id
+// or by phone) and its arguments.
+func (t personalTable) filter(ctx context.Context, clientID int64, phone string) (string, []any) {
+    switch {
+    case t.clientID:
+        return "client_id = $1", []any{clientID}
+    case t.tenantID:
+        return "phone = $1 AND COALESCE(tenant_id, 0) = $2", []any{phone, tenantArg(ctx)}
+    }
+    return "phone = $1", []any{phone}
+}
+
+// ExportClient returns every row about the client, by table: the client itself,
+// rows referencing it and rows keyed by its phone.
+func ExportClient(ctx context.Context, db DB, c Client) (map[string][]json.RawMessage, error) {
+    tables, err := personalTables(ctx, db)
+    if err != nil {
+        return nil, err
+    }
+    out := map[string][]json.RawMessage{}
+    add := func(table, sql string, args ...any) error {
+        rows, err := db.Query(ctx, sql, args...)
+        if err != nil {
+            return err
+        }
+        defer rows.Close()
+        for rows.Next() {
+            var row string
+            if err := rows.Scan(&row); err != nil {
+                return err
+            }
+            out[table] = append(out[table], json.RawMessage(row))
+        }
+        return rows.Err()
+    }
+    if err := add("clients", `SELECT row_to_json(t)::text FROM clients t WHERE id = $1`, c.ID); err != nil {
+        return nil, err
+    }
+    for _, t := range tables {
+        // table names come from the catalog and are quoted as identifiers
+        where, args := t.filter(ctx, c.ID, c.Phone)
+        if err := add(t.name, `SELECT row_to_json(t)::text FROM `+pgx.Identifier{t.name}.Sanitize()+` t WHERE `+where, args...); err != nil {
+            return nil, err
+        }
+    }
+    return out, nil
+}
+
+// DeletePhoneRows deletes the rows keyed only by the client's phone (failures,
+// abuse events, queue...); rows referencing the client go with it by cascade.
+func DeletePhoneRows(ctx context.Context, db DB, phone string) (int64, error) {
+    tables, err := personalTables(ctx, db)
+    if err != nil {
+        return 0, err
+    }
+    var n int64
+    for _, t := range tables {
+        if t.clientID {
+            continue
+        }
+        where, args := t.filter(ctx, 0, phone)
+        tag, err := db.Exec(ctx, `DELETE FROM `+pgx.Identifier{t.name}.Sanitize()+` WHERE `+where, args...)
+        if err != nil {
+            return n, err
+        }
+        n += tag.RowsAffected()
+    }
+    return n, nil
+}
+
+// Erasure is a data deletion request of a client (LGPD) and its outcome.
+type Erasure struct {
+    ID          int64      `json:"id"`
+    Phone       string     `json:"phone"`
+    RequestedBy string     `json:"requested_by"` // client | admin
+    Status      string     `json:"status"`       // pending | completed | expired | failed
+    Code        string     `json:"-"`
+    ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+    Exported    bool       `json:"exported"`
+    Affected    int64      `json:"affected"`
+    Detail      string     `json:"detail,omitempty"`
+    CreatedAt   time.Time  `json:"created_at"`
+    CompletedAt *time.Time `json:"completed_at,omitempty"`
+    // NoticeStatus is the "erasure done" message sent to the client (pending | sent | failed).
+    NoticeStatus *string `json:"notice_status,omitempty"`
+}
+
+const erasureColumns = "id, phone, requested_by, status, code, expires_at, exported, affected, detail, created_at, completed_at, notice_status"
+
+func scanErasure(row pgx.Row, e *Erasure) error {
+    return row.Scan(&e.ID, &e.Phone, &e.RequestedBy, &e.Status, &e.Code, &e.ExpiresAt, &e.Exported, &e.Affected, &e.Detail, &e.CreatedAt, &e.CompletedAt, &e.NoticeStatus)
+}
+
+// CreateErasure opens a request for the phone in the tenant of ctx. Earlier
+// pending requests of the phone expire.
+func CreateErasure(ctx context.Context, db DB, e Erasure) (int64, error) {
+    if _, err := db.Exec(ctx, `
+        UPDATE data_erasures SET status='expired' WHERE phone=$1 AND COALESCE(tenant_id, 0)=$2 AND status='pending'
+    `, e.Phone, tenantArg(ctx)); err != nil {
+        return 0, err
+    }
+    var id int64
+    err := db.QueryRow(ctx, `
+        INSERT INTO data_erasures (tenant_id, phone, requested_by, status, code, expires_at)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6) RETURNING id
+    `, tenantArg(ctx), e.Phone, e.RequestedBy, e.Status, e.Code, e.ExpiresAt).Scan(&id)
+    return id, err
+}
+
+// PendingErasure returns the unexpired pending request of the phone, if any.
+func PendingErasure(ctx context.Context, db DB, phone string) (Erasure, bool, error) {
+    return Query[Erasure]{Scan: scanErasure, SQL: `
+        SELECT ` + erasureColumns + ` FROM data_erasures
+        WHERE phone=$1 AND COALESCE(tenant_id, 0)=$2 AND status='pending' AND expires_at > now()
+        ORDER BY id DESC LIMIT 1`}.One(ctx, db, phone, tenantArg(ctx))
+}
+
+// FinishErasure records the outcome of a request (completed or failed).
+func FinishErasure(ctx context.Context, db DB, id int64, status string, exported bool, affected int64, detail string) error {
+    _, err := db.Exec(ctx, `
+        UPDATE data_erasures SET status=$2, exported=$3, affected=$4, detail=$5, completed_at=now() WHERE id=$1
+    `, id, status, exported, affected, detail)
+    return err
+}
+
+// MarkErasureNotice records the state of the "erasure done" message of a request:
+// pending before the send, then sent (with the provider id) or failed.
+func MarkErasureNotice(ctx context.Context, db DB, id int64, status, extID string) error {
+    _, err := db.Exec(ctx, `
+        UPDATE data_erasures SET notice_status=$2, notice_ext_id=COALESCE(NULLIF($3, ''), notice_ext_id) WHERE id=$1
+    `, id, status, extID)
+    return err
+}
+
+// ListErasures returns the latest requests of the tenant of ctx, newest first.
+func ListErasures(ctx context.Context, db DB, limit int) ([]Erasure, error) {
+    out, err := Query[Erasure]{Scan: scanErasure, SQL: `
+        SELECT ` + erasureColumns + ` FROM data_erasures
+        WHERE COALESCE(tenant_id, 0)=$1 ORDER BY id DESC LIMIT $2`}.All(ctx, db, tenantArg(ctx), limit)
+    if out == nil {
+        out = []Erasure{}
+    }
+    return out, err
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ExperimentVariant is one arm of an experiment. Empty AssistantID keeps the
+// client's assistant; Instructions are appended to the run instructions.
+type ExperimentVariant struct {
+    Name         string `json:"name"`
+    Weight       int    `json:"weight"`
+    AssistantID  string `json:"assistant_id,omitempty"`
+    Instructions string `json:"instructions,omitempty"`
+}
+
+// Experiment splits clients between variants. At most one is active per tenant.
+type Experiment struct {
+    ID        int64               `json:"id"`
+    Name      string              `json:"name"`
+    Active    bool                `json:"active"`
+    Variants  []ExperimentVariant `json:"variants"`
+    CreatedAt time.Time           `json:"created_at"`
+    UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// Variant returns the variant with the given name.
+func (e Experiment) Variant(name string) (ExperimentVariant, bool) {
+    for _, v := range e.Variants {
+        if v.Name == name {
+            return v, true
+        }
+    }
+    return ExperimentVariant{}, false
+}
+
+// Tag is the label stored on messages and usage made under the variant.
+func (e Experiment) Tag(variant string) string {
+    return e.Name + ":" + variant
+}
+
+const experimentColumns = `id, name, active, variants, created_at, updated_at`
+
+func scanExperiment(row pgx.Row) (Experiment, error) {
+    var e Experiment
+    err := row.Scan(&e.ID, &e.Name, &e.Active, &e.Variants, &e.CreatedAt, &e.UpdatedAt)
+    return e, err
+}
+
+// ActiveExperiment returns the active experiment of the tenant of ctx.
+func ActiveExperiment(ctx context.Context, db DB) (Experiment, bool, error) {
+    e, err := scanExperiment(db.QueryRow(ctx, `
+        SELECT `+experimentColumns+` FROM experiments
+        WHERE active AND COALESCE(tenant_id, 0) = $1
+        ORDER BY updated_at DESC LIMIT 1
+    `, tenantArg(ctx)))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return Experiment{}, false, nil
+    }
+    if err != nil {
+        return Experiment{}, false, err
+    }
+    return e, true, nil
+}
+
+// GetExperimentByName returns the experiment of the tenant of ctx with the given name.
+func GetExperimentByName(ctx context.Context, db DB, name string) (Experiment, bool, error) {
+    e, err := scanExperiment(db.QueryRow(ctx, `
+        SELECT `+experimentColumns+` FROM experiments WHERE name=$1 AND COALESCE(tenant_id, 0) = $2
+    `, name, tenantArg(ctx)))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return Experiment{}, false, nil
+    }
+    if err != nil {
+        return Experiment{}, false, err
+    }
+    return e, true, nil
+}
+
+// ListExperiments returns the experiments of the tenant of ctx, newest first.
+func ListExperiments(ctx context.Context, db DB) ([]Experiment, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+experimentColumns+` FROM experiments WHERE COALESCE(tenant_id, 0) = $1 ORDER BY created_at DESC
+    `, tenantArg(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []Experiment{}
+    for rows.Next() {
+        e, err := scanExperiment(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, e)
+    }
+    return out, rows.Err()
+}
+
+// UpsertExperiment creates or updates an experiment by name in the tenant of ctx.
+// Activating it deactivates the other experiments of the tenant.
+func UpsertExperiment(ctx context.Context, db DB, e Experiment) (Experiment, error) {
+    tenant := tenantArg(ctx)
+    if e.Active {
+        if _, err := db.Exec(ctx, `
+            UPDATE experiments SET active=false, updated_at=now()
+            WHERE active AND name <> $1 AND COALESCE(tenant_id, 0) = $2
+        `, e.Name, tenant); err != nil {
+            return Experiment{}, err
+        }
+    }
+    return scanExperiment(db.QueryRow(ctx, `
+        INSERT INTO experiments (tenant_id, name, active, variants)
+        VALUES (NULLIF($1, 0), $2, $3, $4)
+        ON CONFLICT ((COALESCE(tenant_id, 0)), name) DO UPDATE SET
+          active = EXCLUDED.active,
+          variants = EXCLUDED.variants,
+          updated_at = now()
+        RETURNING `+experimentColumns, tenant, e.Name, e.Active, e.Variants))
+}
+
+// AssignVariant stores variant as the client's bucket unless the client already
+// has one, and returns the stored bucket (sticky across runs).
+func AssignVariant(ctx context.Context, db DB, experimentID, clientID int64, variant string) (string, error) {
+    var got string
+    err := db.QueryRow(ctx, `
+        WITH ins AS (
+          INSERT INTO experiment_assignments (experiment_id, client_id, variant) VALUES ($1,$2,$3)
+          ON CONFLICT (experiment_id, client_id) DO NOTHING
+          RETURNING variant
+        )
+        SELECT variant FROM ins
+        UNION ALL
+        SELECT variant FROM experiment_assignments WHERE experiment_id=$1 AND client_id=$2
+        LIMIT 1
+    `, experimentID, clientID, variant).Scan(&got)
+    return got, err
+}
+
+// VariantMetrics compares one variant of an experiment.
+type VariantMetrics struct {
+    Variant       string  `json:"variant"`
+    Clients       int     `json:"clients"`
+    Replies       int     `json:"replies"`
+    AvgReplyChars float64 `json:"avg_reply_chars"`
+    CSATAnswered  int     `json:"csat_answered"`
+    CSATAverage   float64 `json:"csat_average"`
+    Handoffs      int     `json:"handoffs"`     // clients with at least one handoff
+    HandoffRate   float64 `json:"handoff_rate"` // Handoffs / Clients
+}
+
+// ExperimentMetrics returns per-variant metrics since each client's assignment.
+func ExperimentMetrics(ctx context.Context, db DB, e Experiment) ([]VariantMetrics, error) {
+    rows, err := db.Query(ctx, `
+        SELECT v.variant, v.clients, r.replies, r.avg_len, s.answered, s.avg_rating, hd.handoffs
+        FROM (
+          SELECT variant, COUNT(*) AS clients FROM experiment_assignments
+          WHERE experiment_id=$1 GROUP BY variant
+        ) v
+        JOIN LATERAL (
+          SELECT COUNT(*) AS replies, COALESCE(AVG(length(content)), 0)::float8 AS avg_len
+          FROM messages WHERE role='assistant' AND variant = $2 || ':' || v.variant
+        ) r ON true
+        JOIN LATERAL (
+          SELECT COUNT(s.rating) AS answered, COALESCE(AVG(s.rating), 0)::float8 AS avg_rating
+          FROM csat s
+          JOIN experiment_assignments a ON a.client_id = s.client_id AND a.experiment_id=$1 AND a.variant = v.variant
+          WHERE s.sent_at >= a.assigned_at
+        ) s ON true
+        JOIN LATERAL (
+          SELECT COUNT(DISTINCT m.client_id) AS handoffs
+          FROM messages m
+          JOIN experiment_assignments a ON a.client_id = m.client_id AND a.experiment_id=$1 AND a.variant = v.variant
+          WHERE m.type='handoff' AND m.created_at >= a.assigned_at
+        ) hd ON true
+        ORDER BY v.variant
+    `, e.ID, e.Name)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []VariantMetrics{}
+    for rows.Next() {
+        var m VariantMetrics
+        if err := rows.Scan(&m.Variant, &m.Clients, &m.Replies, &m.AvgReplyChars, &m.CSATAnswered, &m.CSATAverage, &m.Handoffs); err != nil {
+            return nil, err
+        }
+        if m.Clients > 0 {
+            m.HandoffRate = float64(m.Handoffs) / float64(m.Clients)
+        }
+        out = append(out, m)
+    }
+    return out, rows.Err()
+}
+
+type variantKey struct{}
+
+// WithVariant tags messages and usage recorded with ctx with an experiment variant
+// ("experiment:variant", see Experiment.Tag).
+func WithVariant(ctx context.Context, tag string) context.Context {
+    return context.WithValue(ctx, variantKey{}, tag)
+}
+
+// VariantFrom returns the variant tag of ctx ("" outside experiments).
+func VariantFrom(ctx context.Context) string {
+    tag, _ := ctx.Value(variantKey{}).(string)
+    return tag
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// Fact is a durable piece of information about a client (name, preferences,
+// past purchases) extracted from conversations. Key is unique per client.
+type Fact struct {
+    Key       string
+    Value     string
+    UpdatedAt time.Time
+}
+
+// GetClientFacts returns all stored facts for a client ordered by key.
+func GetClientFacts(ctx context.Context, db DB, clientID int64) ([]Fact, error) {
+    rows, err := db.Query(ctx, `
+        SELECT key, value, updated_at FROM client_facts
+        WHERE client_id=$1 ORDER BY key
+    `, clientID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []Fact
+    for rows.Next() {
+        var f Fact
+        if err := rows.Scan(&f.Key, &f.Value, &f.UpdatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, f)
+    }
+    return out, rows.Err()
+}
+
+// UpsertClientFacts inserts or updates facts for a client. An empty value
+// deletes the fact (the model uses it to retract outdated information).
+func UpsertClientFacts(ctx context.Context, db DB, clientID int64, facts map[string]string) error {
+    for k, v := range facts {
+        if v == "" {
+            if _, err := db.Exec(ctx, `DELETE FROM client_facts WHERE client_id=$1 AND key=$2`, clientID, k); err != nil {
+                return err
+            }
+            continue
+        }
+        _, err := db.Exec(ctx, `
+            INSERT INTO client_facts (client_id, key, value)
+            VALUES ($1,$2,$3)
+            ON CONFLICT (client_id, key) DO UPDATE SET value=EXCLUDED.value, updated_at=now()
+        `, clientID, k, v)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
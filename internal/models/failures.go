@@ -0,0 +1,17 @@
+package models
+
+import (
+    "context"
+)
+
+// RecordFailure stores a pipeline failure for a phone at a given stage
+// (e.g. "openai run", "uazapi send text"). Errors are truncated to 2000 chars.
+func RecordFailure(ctx context.Context, db DB, phone, stage, errText string) error {
+    if len(errText) > 2000 {
+        errText = errText[:2000]
+    }
+    _, err := db.Exec(ctx, `
+        INSERT INTO failures (phone, stage, error) VALUES ($1,$2,$3)
+    `, phone, stage, errText)
+    return err
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// LastGreetedAt returns when the assistant last greeted the client, or nil if never.
+func LastGreetedAt(ctx context.Context, db DB, clientID int64) (*time.Time, error) {
+    var t *time.Time
+    err := db.QueryRow(ctx, `SELECT last_greeted_at FROM clients WHERE id=$1`, clientID).Scan(&t)
+    return t, err
+}
+
+// LastReplyAt returns when the client last got a reply (assistant or operator), or nil if never.
+func LastReplyAt(ctx context.Context, db DB, clientID int64) (*time.Time, error) {
+    var t *time.Time
+    err := db.QueryRow(ctx, `
+        SELECT max(created_at) FROM messages WHERE client_id=$1 AND role IN ('assistant','operator')
+    `, clientID).Scan(&t)
+    return t, err
+}
+
+// MarkGreeted records that the assistant greeted the client now.
+func MarkGreeted(ctx context.Context, db DB, clientID int64) error {
+    _, err := db.Exec(ctx, `UPDATE clients SET last_greeted_at=now() WHERE id=$1`, clientID)
+    return err
+}
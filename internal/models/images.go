@@ -0,0 +1,68 @@
+package models
+
+import (
+    "context"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ClientImage is an image received from a client, kept so follow-up questions
+// can run vision again on the same picture.
+type ClientImage struct {
+    ID          int64
+    ClientID    int64
+    ExtID       *string // WhatsApp message id (to download the media again)
+    MediaURL    string
+    Description string
+    CreatedAt   time.Time
+}
+
+const clientImageColumns = "id, client_id, ext_id, media_url, description, created_at"
+
+func scanClientImage(row pgx.Row, i *ClientImage) error {
+    return row.Scan(&i.ID, &i.ClientID, &i.ExtID, &i.MediaURL, &i.Description, &i.CreatedAt)
+}
+
+var (
+    clientImageQuery = Query[ClientImage]{Scan: scanClientImage, SQL: `
+        SELECT ` + clientImageColumns + ` FROM client_images
+        WHERE client_id=$1 AND id=$2 AND created_at >= $3`}
+
+    latestClientImageQuery = Query[ClientImage]{Scan: scanClientImage, SQL: `
+        SELECT ` + clientImageColumns + ` FROM client_images
+        WHERE client_id=$1 AND created_at >= $2
+        ORDER BY created_at DESC, id DESC LIMIT 1`}
+)
+
+// InsertClientImage remembers an image of the client and returns its id.
+func InsertClientImage(ctx context.Context, db DB, img ClientImage) (int64, error) {
+    var id int64
+    err := db.QueryRow(ctx, `
+        INSERT INTO client_images (client_id, tenant_id, ext_id, media_url, description)
+        VALUES ($1, (SELECT tenant_id FROM clients WHERE id=$1), $2, $3, $4)
+        RETURNING id
+    `, img.ClientID, img.ExtID, img.MediaURL, img.Description).Scan(&id)
+    return id, err
+}
+
+// ClientImageByID returns an image of the client received since the given time.
+// ok is false when there is none (unknown id, another client's or too old).
+func ClientImageByID(ctx context.Context, db DB, clientID, id int64, since time.Time) (ClientImage, bool, error) {
+    return clientImageQuery.One(ctx, db, clientID, id, since)
+}
+
+// LatestClientImage returns the last image the client sent since the given time.
+func LatestClientImage(ctx context.Context, db DB, clientID int64, since time.Time) (ClientImage, bool, error) {
+    return latestClientImageQuery.One(ctx, db, clientID, since)
+}
+
+// PurgeClientImagesBefore deletes images older than before and returns how many
+// were removed.
+func PurgeClientImagesBefore(ctx context.Context, db DB, before time.Time) (int64, error) {
+    ct, err := db.Exec(ctx, `DELETE FROM client_images WHERE created_at < $1`, before)
+    if err != nil {
+        return 0, err
+    }
+    return ct.RowsAffected(), nil
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrDuplicateIntent is returned when another rule of the tenant already has the name.
+var ErrDuplicateIntent = errors.New("intent name already in use")
+
+// IntentRule answers a deterministic intent (opening hours, address...) from a
+// template, without running the assistant. It matches when any keyword or the
+// pattern is found in the message.
+type IntentRule struct {
+    ID        int64      `json:"id"`
+    Name      string     `json:"name"`
+    Keywords  []string   `json:"keywords"`
+    Pattern   string     `json:"pattern,omitempty"`
+    Reply     string     `json:"reply"`
+    Priority  int        `json:"priority"`
+    Active    bool       `json:"active"`
+    Hits      int64      `json:"hits"`
+    LastHitAt *time.Time `json:"last_hit_at,omitempty"`
+    CreatedAt time.Time  `json:"created_at"`
+    UpdatedAt time.Time  `json:"updated_at"`
+}
+
+const intentRuleColumns = `id, name, keywords, pattern, reply, priority, active, hits, last_hit_at, created_at, updated_at`
+
+func scanIntentRule(row pgx.Row) (IntentRule, error) {
+    var r IntentRule
+    err := row.Scan(&r.ID, &r.Name, &r.Keywords, &r.Pattern, &r.Reply, &r.Priority, &r.Active, &r.Hits, &r.LastHitAt, &r.CreatedAt, &r.UpdatedAt)
+    return r, err
+}
+
+// intentConflict maps a unique violation on intent_rules.name to ErrDuplicateIntent.
+func intentConflict(err error) error {
+    var pgErr *pgconn.PgError
+    if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+        return ErrDuplicateIntent
+    }
+    return err
+}
+
+// ListIntentRules returns the rules of the tenant of ctx, highest priority first.
+// With activeOnly, inactive rules are left out.
+func ListIntentRules(ctx context.Context, db DB, activeOnly bool) ([]IntentRule, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+intentRuleColumns+` FROM intent_rules
+        WHERE COALESCE(tenant_id, 0) = $1 AND (NOT $2 OR active)
+        ORDER BY priority DESC, id
+    `, tenantArg(ctx), activeOnly)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []IntentRule{}
+    for rows.Next() {
+        r, err := scanIntentRule(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, r)
+    }
+    return out, rows.Err()
+}
+
+// GetIntentRule returns a rule of the tenant of ctx. ok is false if not found.
+func GetIntentRule(ctx context.Context, db DB, id int64) (IntentRule, bool, error) {
+    r, err := scanIntentRule(db.QueryRow(ctx, `
+        SELECT `+intentRuleColumns+` FROM intent_rules WHERE id=$1 AND COALESCE(tenant_id, 0) = $2
+    `, id, tenantArg(ctx)))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return IntentRule{}, false, nil
+    }
+    return r, err == nil, err
+}
+
+// CreateIntentRule inserts a rule for the tenant of ctx.
+func CreateIntentRule(ctx context.Context, db DB, r IntentRule) (IntentRule, error) {
+    out, err := scanIntentRule(db.QueryRow(ctx, `
+        INSERT INTO intent_rules (tenant_id, name, keywords, pattern, reply, priority, active)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7)
+        RETURNING `+intentRuleColumns,
+        tenantArg(ctx), r.Name, r.Keywords, r.Pattern, r.Reply, r.Priority, r.Active))
+    return out, intentConflict(err)
+}
+
+// UpdateIntentRule replaces the fields of a rule of the tenant of ctx (hits are
+// kept). ok is false if not found.
+func UpdateIntentRule(ctx context.Context, db DB, r IntentRule) (IntentRule, bool, error) {
+    out, err := scanIntentRule(db.QueryRow(ctx, `
+        UPDATE intent_rules SET name=$3, keywords=$4, pattern=$5, reply=$6, priority=$7, active=$8, updated_at=now()
+        WHERE id=$1 AND COALESCE(tenant_id, 0) = $2
+        RETURNING `+intentRuleColumns,
+        r.ID, tenantArg(ctx), r.Name, r.Keywords, r.Pattern, r.Reply, r.Priority, r.Active))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return IntentRule{}, false, nil
+    }
+    return out, err == nil, intentConflict(err)
+}
+
+// DeleteIntentRule removes a rule of the tenant of ctx. Reports whether it existed.
+func DeleteIntentRule(ctx context.Context, db DB, id int64) (bool, error) {
+    tag, err := db.Exec(ctx, `DELETE FROM intent_rules WHERE id=$1 AND COALESCE(tenant_id, 0) = $2`, id, tenantArg(ctx))
+    return tag.RowsAffected() > 0, err
+}
+
+// RecordIntentHit counts an answer given by the rule.
+func RecordIntentHit(ctx context.Context, db DB, id int64) error {
+    _, err := db.Exec(ctx, `UPDATE intent_rules SET hits = hits + 1, last_hit_at = now() WHERE id=$1`, id)
+    return err
+}
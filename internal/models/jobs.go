@@ -0,0 +1,97 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ScheduledJob is the run state of a background job shared by all replicas.
+type ScheduledJob struct {
+    Name       string     `json:"name"`
+    Owner      string     `json:"owner,omitempty"`
+    LeaseUntil *time.Time `json:"lease_until,omitempty"`
+    Slot       *time.Time `json:"slot,omitempty"`
+    Status     string     `json:"status"`
+    LastError  string     `json:"last_error,omitempty"`
+    StartedAt  *time.Time `json:"started_at,omitempty"`
+    FinishedAt *time.Time `json:"finished_at,omitempty"`
+    Runs       int64      `json:"runs"`
+}
+
+// AcquireJob takes the lease of the job for slot. It fails (false) while another
+// owner holds an unexpired lease or when the slot already ran (or is running).
+func AcquireJob(ctx context.Context, db DB, name, owner string, slot time.Time, lease time.Duration) (bool, error) {
+    var got string
+    err := db.QueryRow(ctx, `
+        INSERT INTO scheduled_jobs (name, owner, lease_until, slot, status, started_at, runs)
+        VALUES ($1, $2, now() + $4 * interval '1 second', $3, 'running', now(), 1)
+        ON CONFLICT (name) DO UPDATE SET
+          owner = EXCLUDED.owner,
+          lease_until = EXCLUDED.lease_until,
+          slot = EXCLUDED.slot,
+          status = 'running',
+          last_error = NULL,
+          started_at = now(),
+          finished_at = NULL,
+          runs = scheduled_jobs.runs + 1
+        WHERE (scheduled_jobs.lease_until IS NULL OR scheduled_jobs.lease_until < now())
+          AND (scheduled_jobs.slot IS NULL OR scheduled_jobs.slot < EXCLUDED.slot)
+        RETURNING name
+    `, name, owner, slot, lease.Seconds()).Scan(&got)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// RenewJob extends the lease held by owner. false means the lease was lost.
+func RenewJob(ctx context.Context, db DB, name, owner string, lease time.Duration) (bool, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE scheduled_jobs SET lease_until = now() + $3 * interval '1 second'
+        WHERE name=$1 AND owner=$2 AND status='running'
+    `, name, owner, lease.Seconds())
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// FinishJob releases the lease and records the result of the run.
+func FinishJob(ctx context.Context, db DB, name, owner string, runErr error) error {
+    status, msg := "ok", ""
+    if runErr != nil {
+        status, msg = "error", runErr.Error()
+    }
+    _, err := db.Exec(ctx, `
+        UPDATE scheduled_jobs SET lease_until=NULL, status=$3, last_error=NULLIF($4,''), finished_at=now()
+        WHERE name=$1 AND owner=$2
+    `, name, owner, status, msg)
+    return err
+}
+
+// ListScheduledJobs returns all jobs ordered by name.
+func ListScheduledJobs(ctx context.Context, db DB) ([]ScheduledJob, error) {
+    rows, err := db.Query(ctx, `
+        SELECT name, COALESCE(owner,''), lease_until, slot, status, COALESCE(last_error,''), started_at, finished_at, runs
+        FROM scheduled_jobs ORDER BY name
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []ScheduledJob{}
+    for rows.Next() {
+        var j ScheduledJob
+        if err := rows.Scan(&j.Name, &j.Owner, &j.LeaseUntil, &j.Slot, &j.Status, &j.LastError, &j.StartedAt, &j.FinishedAt, &j.Runs); err != nil {
+            return nil, err
+        }
+        out = append(out, j)
+    }
+    return out, rows.Err()
+}
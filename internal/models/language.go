@@ -0,0 +1,50 @@
+package models
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ClientLanguage is the language of a client's conversation. Detected is the
+// last language seen in their messages; Override, when set by an operator, wins
+// over it ("pt" turns translation off for the client).
+type ClientLanguage struct {
+    Detected string `json:"detected,omitempty"`
+    Override string `json:"override,omitempty"`
+}
+
+// Effective returns the language the conversation should use ("" = unknown).
+func (l ClientLanguage) Effective() string {
+    if l.Override != "" {
+        return l.Override
+    }
+    return l.Detected
+}
+
+// GetClientLanguage returns the detected and overridden languages of the client.
+func GetClientLanguage(ctx context.Context, db DB, clientID int64) (ClientLanguage, error) {
+    var l ClientLanguage
+    err := db.QueryRow(ctx, `
+        SELECT COALESCE(language, ''), COALESCE(language_override, '') FROM clients WHERE id=$1
+    `, clientID).Scan(&l.Detected, &l.Override)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return ClientLanguage{}, nil
+    }
+    return l, err
+}
+
+// SetClientDetectedLanguage records the language detected in the client's last message.
+func SetClientDetectedLanguage(ctx context.Context, db DB, clientID int64, lang string) error {
+    _, err := db.Exec(ctx, `
+        UPDATE clients SET language=NULLIF($2,'') WHERE id=$1 AND language IS DISTINCT FROM NULLIF($2,'')
+    `, clientID, lang)
+    return err
+}
+
+// SetClientLanguageOverride fixes the language of the client ("" = back to detection).
+func SetClientLanguageOverride(ctx context.Context, db DB, clientID int64, lang string) error {
+    _, err := db.Exec(ctx, `UPDATE clients SET language_override=NULLIF($2,'') WHERE id=$1`, clientID, lang)
+    return err
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// RecordReplyLatency stores how long the client waited for a reply to the batch
+// that started at receivedAt. ok=false marks a failed run (no reply).
+func RecordReplyLatency(ctx context.Context, db DB, clientID int64, receivedAt time.Time, ok bool) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO reply_latency (client_id, tenant_id, received_at, latency_ms, ok)
+        VALUES ($1, (SELECT tenant_id FROM clients WHERE id=$1), $2, $3, $4)
+    `, clientID, receivedAt, time.Since(receivedAt).Milliseconds(), ok)
+    return err
+}
+
+// LatencyWindow summarizes the replies of a window against a latency target.
+type LatencyWindow struct {
+    Total  int   `json:"total"`
+    Good   int   `json:"good"`   // replied within the target
+    Failed int   `json:"failed"` // runs that never replied
+    P50Ms  int64 `json:"p50_ms"`
+    P95Ms  int64 `json:"p95_ms"`
+}
+
+// ReplyLatencySince summarizes the replies sent after since for the tenant of ctx
+// (all tenants when ctx is unscoped). Failed runs never count as good.
+func ReplyLatencySince(ctx context.Context, db DB, since time.Time, target time.Duration) (LatencyWindow, error) {
+    var w LatencyWindow
+    var p50, p95 *float64
+    err := db.QueryRow(ctx, `
+        SELECT count(*),
+               count(*) FILTER (WHERE ok AND latency_ms <= $2),
+               count(*) FILTER (WHERE NOT ok),
+               percentile_cont(0.5) WITHIN GROUP (ORDER BY latency_ms) FILTER (WHERE ok),
+               percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms) FILTER (WHERE ok)
+        FROM reply_latency
+        WHERE replied_at > $1 AND ($3 < 0 OR COALESCE(tenant_id, 0) = $3)
+    `, since, target.Milliseconds(), tenantFilter(ctx)).Scan(&w.Total, &w.Good, &w.Failed, &p50, &p95)
+    if p50 != nil {
+        w.P50Ms = int64(*p50)
+    }
+    if p95 != nil {
+        w.P95Ms = int64(*p95)
+    }
+    return w, err
+}
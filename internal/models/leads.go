@@ -0,0 +1,136 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// LeadStatuses are the allowed lead/order statuses, in pipeline order.
+var LeadStatuses = []string{"novo", "em_andamento", "ganho", "perdido", "cancelado"}
+
+// ValidLeadStatus reports whether s is one of LeadStatuses.
+func ValidLeadStatus(s string) bool {
+    for _, v := range LeadStatuses {
+        if v == s {
+            return true
+        }
+    }
+    return false
+}
+
+// Lead is an order or sales lead created by the assistant for a client.
+type Lead struct {
+    ID        int64     `json:"id"`
+    ClientID  int64     `json:"client_id"`
+    Phone     string    `json:"phone,omitempty"`
+    Kind      string    `json:"kind"`
+    Title     string    `json:"title"`
+    Details   string    `json:"details"`
+    Status    string    `json:"status"`
+    Value     *float64  `json:"value,omitempty"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LeadFilter narrows ListLeads. Zero values mean "any".
+type LeadFilter struct {
+    ClientID int64
+    Phone    string
+    Status   string
+    Limit    int
+}
+
+const leadColumns = `l.id, l.client_id, c.phone, l.kind, l.title, l.details, l.status, l.value::float8, l.created_at, l.updated_at`
+
+func scanLead(row pgx.Row) (Lead, error) {
+    var l Lead
+    err := row.Scan(&l.ID, &l.ClientID, &l.Phone, &l.Kind, &l.Title, &l.Details, &l.Status, &l.Value, &l.CreatedAt, &l.UpdatedAt)
+    return l, err
+}
+
+// CreateLead inserts a lead with status "novo".
+func CreateLead(ctx context.Context, db DB, l Lead) (Lead, error) {
+    var id int64
+    err := db.QueryRow(ctx, `
+        INSERT INTO leads (client_id, kind, title, details, value) VALUES ($1,$2,$3,$4,$5)
+        RETURNING id
+    `, l.ClientID, l.Kind, l.Title, l.Details, l.Value).Scan(&id)
+    if err != nil {
+        return Lead{}, err
+    }
+    out, _, err := GetLead(ctx, db, id)
+    return out, err
+}
+
+// GetLead returns a lead by id (within the tenant of ctx, if scoped). ok is false if not found.
+func GetLead(ctx context.Context, db DB, id int64) (Lead, bool, error) {
+    l, err := scanLead(db.QueryRow(ctx, `
+        SELECT `+leadColumns+` FROM leads l JOIN clients c ON c.id = l.client_id
+        WHERE l.id=$1 AND ($2 < 0 OR COALESCE(c.tenant_id, 0) = $2)
+    `, id, tenantFilter(ctx)))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return Lead{}, false, nil
+    }
+    if err != nil {
+        return Lead{}, false, err
+    }
+    return l, true, nil
+}
+
+// UpdateLead sets the status and, if note is not empty, appends it to details.
+func UpdateLead(ctx context.Context, db DB, id int64, status, note string) (Lead, error) {
+    if !ValidLeadStatus(status) {
+        return Lead{}, fmt.Errorf("invalid status %q (allowed: %s)", status, strings.Join(LeadStatuses, ", "))
+    }
+    note = strings.TrimSpace(note)
+    ct, err := db.Exec(ctx, `
+        UPDATE leads SET status=$2,
+          details = CASE WHEN $3 = '' THEN details
+                         WHEN details = '' THEN $3
+                         ELSE details || E'\n' || $3 END,
+          updated_at=now()
+        WHERE id=$1 AND ($4 < 0 OR client_id IN (SELECT id FROM clients WHERE COALESCE(tenant_id, 0) = $4))
+    `, id, status, note, tenantFilter(ctx))
+    if err != nil {
+        return Lead{}, err
+    }
+    if ct.RowsAffected() == 0 {
+        return Lead{}, pgx.ErrNoRows
+    }
+    l, _, err := GetLead(ctx, db, id)
+    return l, err
+}
+
+// ListLeads returns leads matching f (within the tenant of ctx, if scoped), most
+// recently updated first.
+func ListLeads(ctx context.Context, db DB, f LeadFilter) ([]Lead, error) {
+    if f.Limit <= 0 || f.Limit > 500 {
+        f.Limit = 100
+    }
+    rows, err := db.Query(ctx, `
+        SELECT `+leadColumns+` FROM leads l JOIN clients c ON c.id = l.client_id
+        WHERE ($1 = 0 OR l.client_id = $1)
+          AND ($2 = '' OR c.phone = $2)
+          AND ($3 = '' OR l.status = $3)
+          AND ($5 < 0 OR COALESCE(c.tenant_id, 0) = $5)
+        ORDER BY l.updated_at DESC LIMIT $4
+    `, f.ClientID, f.Phone, f.Status, f.Limit, tenantFilter(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []Lead{}
+    for rows.Next() {
+        l, err := scanLead(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, l)
+    }
+    return out, rows.Err()
+}
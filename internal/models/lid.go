@@ -0,0 +1,40 @@
+package models
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// LookupLID returns the phone mapped to a WhatsApp LID ("123@lid"), if known.
+func LookupLID(ctx context.Context, db DB, lid string) (string, bool, error) {
+    var phone string
+    err := db.QueryRow(ctx, `SELECT phone FROM lid_map WHERE lid=$1`, lid).Scan(&phone)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return "", false, nil
+    }
+    if err != nil {
+        return "", false, err
+    }
+    return phone, true, nil
+}
+
+// SaveLID records the LID -> phone mapping. If a client was previously created
+// with the LID as its identity (phone unknown at the time), it is renamed to the
+// phone, unless a client with that phone already exists.
+func SaveLID(ctx context.Context, db DB, lid, phone string) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO lid_map (lid, phone) VALUES ($1,$2)
+        ON CONFLICT (lid) DO UPDATE SET phone=EXCLUDED.phone, updated_at=now()
+    `, lid, phone)
+    if err != nil {
+        return err
+    }
+    _, err = db.Exec(ctx, `
+        UPDATE clients SET phone=$2
+        WHERE phone=$1 AND NOT EXISTS (
+          SELECT 1 FROM clients c2 WHERE c2.phone=$2 AND COALESCE(c2.tenant_id, 0)=COALESCE(clients.tenant_id, 0))
+    `, lid, phone)
+    return err
+}
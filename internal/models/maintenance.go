@@ -0,0 +1,88 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// MaintenanceWindow is the maintenance mode of a tenant. Until is nil when the
+// window has no deadline.
+type MaintenanceWindow struct {
+    Enabled bool
+    Since   time.Time
+    Until   *time.Time
+    Message string
+}
+
+// GetMaintenance returns the maintenance window of the tenant of ctx (disabled
+// when none was ever set).
+func GetMaintenance(ctx context.Context, db DB) (MaintenanceWindow, error) {
+    var (
+        w     MaintenanceWindow
+        since *time.Time
+    )
+    err := db.QueryRow(ctx, `
+        SELECT enabled, since, until, message FROM maintenance_windows
+        WHERE COALESCE(tenant_id, 0)=$1
+    `, tenantArg(ctx)).Scan(&w.Enabled, &since, &w.Until, &w.Message)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return MaintenanceWindow{}, nil
+    }
+    if since != nil {
+        w.Since = *since
+    }
+    return w, err
+}
+
+// EnableMaintenance turns the window of the tenant of ctx on. An already open
+// window keeps its start (clients notified in it are not notified again).
+func EnableMaintenance(ctx context.Context, db DB, until *time.Time, message string) (MaintenanceWindow, error) {
+    w := MaintenanceWindow{Enabled: true, Until: until, Message: message}
+    err := db.QueryRow(ctx, `
+        INSERT INTO maintenance_windows (tenant_id, enabled, since, until, message)
+        VALUES (NULLIF($1, 0), TRUE, now(), $2, $3)
+        ON CONFLICT ((COALESCE(tenant_id, 0))) DO UPDATE SET
+          since = CASE WHEN maintenance_windows.enabled THEN maintenance_windows.since ELSE now() END,
+          enabled = TRUE, until = EXCLUDED.until, message = EXCLUDED.message, updated_at = now()
+        RETURNING since
+    `, tenantArg(ctx), until, message).Scan(&w.Since)
+    return w, err
+}
+
+// DisableMaintenance closes the window of the tenant of ctx. With expiredOnly it
+// closes it only when the deadline has passed. Reports whether this call closed
+// it (only one replica sees true for a given window).
+func DisableMaintenance(ctx context.Context, db DB, expiredOnly bool) (bool, error) {
+    var since time.Time
+    err := db.QueryRow(ctx, `
+        UPDATE maintenance_windows SET enabled = FALSE, updated_at = now()
+        WHERE COALESCE(tenant_id, 0)=$1 AND enabled AND (NOT $2 OR until <= now())
+        RETURNING since
+    `, tenantArg(ctx), expiredOnly).Scan(&since)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    _, err = db.Exec(ctx, `
+        DELETE FROM maintenance_notices WHERE COALESCE(tenant_id, 0)=$1 AND window_since <= $2
+    `, tenantArg(ctx), since)
+    return true, err
+}
+
+// ClaimMaintenanceNotice records that phone was told about the window that started
+// at since. False when it was already notified (by this or another replica).
+func ClaimMaintenanceNotice(ctx context.Context, db DB, phone string, since time.Time) (bool, error) {
+    tag, err := db.Exec(ctx, `
+        INSERT INTO maintenance_notices (tenant_id, phone, window_since) VALUES (NULLIF($1, 0), $2, $3)
+        ON CONFLICT ((COALESCE(tenant_id, 0)), phone, window_since) DO NOTHING
+    `, tenantArg(ctx), phone, since)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// Delivery statuses of an outbound message (two-phase send). A message is
+// stored as pending before the provider is called, then moves to sent or
+// failed with the provider response and on to delivered/read/played with the
+// ACK webhooks. Inbound and legacy rows have no status.
+const (
+    MessagePending   = "pending"
+    MessageSent      = "sent"
+    MessageDelivered = "delivered"
+    MessageRead      = "read"
+    MessagePlayed    = "played"
+    MessageFailed    = "failed"
+)
+
+// MessageSend is the delivery state of an outbound message.
+type MessageSend struct {
+    ID       int64      `json:"id"`
+    ClientID int64      `json:"client_id"`
+    Phone    string     `json:"phone,omitempty"`
+    Type     string     `json:"type"`
+    ExtID    *string    `json:"ext_id,omitempty"`
+    Status   string     `json:"status"`
+    Error    *string    `json:"error,omitempty"`
+    StatusAt *time.Time `json:"status_at,omitempty"`
+    Created  time.Time  `json:"created_at"`
+}
+
+// Done reports whether the provider accepted the message (sent or beyond).
+func (s MessageSend) Done() bool {
+    return s.Status != MessagePending && s.Status != MessageFailed
+}
+
+// statusRank orders the statuses so ACKs never move a message backwards (a
+// "delivered" arriving after "read" is ignored). failed ranks below sent: a
+// late ACK proves the message arrived after all.
+func statusRank(expr string) string {
+    return `(CASE ` + expr + ` WHEN 'pending' THEN 0 WHEN 'failed' THEN 1 WHEN 'sent' THEN 2
+        WHEN 'delivered' THEN 3 WHEN 'read' THEN 4 WHEN 'played' THEN 5 ELSE -1 END)`
+}
+
+const messageSendColumns = "m.id, m.client_id, c.phone, m.type, m.ext_id, m.status, m.send_error, m.status_at, m.created_at"
+
+func scanMessageSend(row pgx.Row, s *MessageSend) error {
+    return row.Scan(&s.ID, &s.ClientID, &s.Phone, &s.Type, &s.ExtID, &s.Status, &s.Error, &s.StatusAt, &s.Created)
+}
+
+var messageSendByKeyQuery = Query[MessageSend]{Scan: scanMessageSend, SQL: `
+    SELECT ` + messageSendColumns + ` FROM messages m JOIN clients c ON c.id = m.client_id
+    WHERE m.send_key = $1`}
+
+// ClaimMessageSend stores m as pending before it is sent. key (optional) makes
+// the send idempotent: when a message with the same key exists, claimed is
+// false and the stored state is returned, unless it failed, in which case it is
+// reset to pending and claimed again (a failed send is safe to retry).
+func ClaimMessageSend(ctx context.Context, db DB, m Message, key string) (s MessageSend, claimed bool, err error) {
+    err = db.QueryRow(ctx, `
+        INSERT INTO messages (client_id, role, type, content, ephemeral, view_once, variant, tenant_id, content_original, language, content_translated, status, status_at, send_key)
+        VALUES ($1,$2,$3,$4,$5,$6,NULLIF($7,''),(SELECT tenant_id FROM clients WHERE id=$1),$8,NULLIF($9,''),$10,'pending',now(),NULLIF($11,''))
+        ON CONFLICT (send_key) WHERE send_key IS NOT NULL DO NOTHING
+        RETURNING id, created_at
+    `, m.ClientID, m.Role, m.Type, m.Content, m.Ephemeral, m.ViewOnce, VariantFrom(ctx), m.Original, m.Language, m.Translated, key).Scan(&s.ID, &s.Created)
+    if err == nil {
+        s.ClientID, s.Type, s.Status = m.ClientID, m.Type, MessagePending
+        return s, true, nil
+    }
+    if !errors.Is(err, pgx.ErrNoRows) || key == "" {
+        return MessageSend{}, false, err
+    }
+    prior, ok, err := messageSendByKeyQuery.One(ctx, db, key)
+    if err != nil || !ok {
+        return prior, false, err
+    }
+    if prior.Status != MessageFailed {
+        return prior, false, nil
+    }
+    tag, err := db.Exec(ctx, `
+        UPDATE messages SET status='pending', status_at=now(), send_error=NULL
+        WHERE id=$1 AND status='failed'
+    `, prior.ID)
+    if err != nil {
+        return prior, false, err
+    }
+    if tag.RowsAffected() == 0 {
+        // another caller resumed the send at the same time
+        prior.Status = MessagePending
+        return prior, false, nil
+    }
+    prior.Status, prior.Error = MessagePending, nil
+    return prior, true, nil
+}
+
+// MarkMessageSent records the provider response of a pending message.
+func MarkMessageSent(ctx context.Context, db DB, id int64, extID string, providerAt *time.Time) error {
+    _, err := db.Exec(ctx, `
+        UPDATE messages SET status='sent', status_at=now(), send_error=NULL,
+               ext_id=COALESCE(NULLIF($2,''), ext_id), provider_at=COALESCE($3, provider_at)
+        WHERE id=$1 AND status IN ('pending','failed')
+    `, id, extID, providerAt)
+    return err
+}
+
+// MarkMessageFailed records that the provider rejected a pending message (or
+// never answered). Error text is truncated to 1000 chars.
+func MarkMessageFailed(ctx context.Context, db DB, id int64, sendErr error) error {
+    msg := "unknown error"
+    if sendErr != nil {
+        msg = sendErr.Error()
+    }
+    if len(msg) > 1000 {
+        msg = msg[:1000]
+    }
+    _, err := db.Exec(ctx, `
+        UPDATE messages SET status='failed', status_at=now(), send_error=$2
+        WHERE id=$1 AND status='pending'
+    `, id, msg)
+    return err
+}
+
+// AdvanceMessageStatus applies an ACK (sent, delivered, read, played or failed)
+// to the outbound messages of the tenant of ctx with the given ext_id. A status
+// never moves backwards; failed only applies to messages not yet delivered. It
+// returns how many messages changed.
+func AdvanceMessageStatus(ctx context.Context, db DB, extID, status string) (int64, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE messages SET status=$2::text, status_at=now()
+        WHERE ext_id=$1 AND role <> 'user' AND COALESCE(tenant_id, 0)=$3
+          AND CASE WHEN $2::text = 'failed' THEN COALESCE(status, 'sent') IN ('pending', 'sent')
+                   ELSE `+statusRank("status")+` < `+statusRank("$2::text")+` END
+    `, extID, status, tenantArg(ctx))
+    if err != nil {
+        return 0, err
+    }
+    return tag.RowsAffected(), nil
+}
+
+// FailStaleMessages marks as failed the messages pending for longer than
+// olderThan: the process stopped between storing and sending them, so whether
+// they reached the provider is unknown. They are not resent automatically.
+func FailStaleMessages(ctx context.Context, db DB, olderThan time.Duration) (int64, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE messages SET status='failed', status_at=now(), send_error='interrupted before the provider answered'
+        WHERE status='pending' AND status_at < now() - make_interval(secs => $1)
+    `, olderThan.Seconds())
+    if err != nil {
+        return 0, err
+    }
+    return tag.RowsAffected(), nil
+}
+
+// MessageStatusCount is the number of outbound messages in a status.
+type MessageStatusCount struct {
+    Status string `json:"status"`
+    Count  int64  `json:"count"`
+}
+
+// MessageStatusCounts counts the outbound messages of the tenant of ctx created
+// since the given time, by delivery status.
+func MessageStatusCounts(ctx context.Context, db DB, since time.Time) ([]MessageStatusCount, error) {
+    rows, err := db.Query(ctx, `
+        SELECT status, count(*) FROM messages
+        WHERE status IS NOT NULL AND COALESCE(tenant_id, 0)=$1 AND created_at >= $2
+        GROUP BY status ORDER BY `+statusRank("status")+`
+    `, tenantArg(ctx), since)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []MessageStatusCount{}
+    for rows.Next() {
+        var c MessageStatusCount
+        if err := rows.Scan(&c.Status, &c.Count); err != nil {
+            return nil, err
+        }
+        out = append(out, c)
+    }
+    return out, rows.Err()
+}
+
+// ListMessageSends returns the latest outbound messages of the tenant of ctx in
+// the given status ("" = any), newest first.
+func ListMessageSends(ctx context.Context, db DB, status string, since time.Time, limit int) ([]MessageSend, error) {
+    list, err := Query[MessageSend]{Scan: scanMessageSend, SQL: `
+        SELECT ` + messageSendColumns + ` FROM messages m JOIN clients c ON c.id = m.client_id
+        WHERE m.status IS NOT NULL AND ($1 = '' OR m.status = $1)
+          AND COALESCE(m.tenant_id, 0)=$2 AND m.created_at >= $3
+        ORDER BY m.created_at DESC, m.id DESC LIMIT $4`}.All(ctx, db, status, tenantArg(ctx), since, limit)
+    if list == nil && err == nil {
+        list = []MessageSend{}
+    }
+    return list, err
+}
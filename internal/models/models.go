@@ -5,7 +5,8 @@ import (
     "errors"
     "time"
 
-    "github.com/jackc/pgx/v5/pgxpool"
+    "github.com/jackc/pgx/v5"
+    "github.com/your-org/leandro-agent/internal/phone"
 )
 
 // Client represents a WhatsApp contact. Each contact can have a thread ID associated
@@ -19,35 +20,59 @@ type Client struct {
 }
 
 // Message stores each inbound and outbound message exchanged with a client. It helps
-// persist conversation history. Role is "user", "assistant", or "system". Type is
+// persist conversation history. Role is "user", "assistant", "operator" (a human
+// replying through the bot's number), or "system". Type is
 // the modality of the content.
 type Message struct {
-    ID        int64
-    ClientID  int64
-    Role      string // "user" | "assistant" | "system"
-    Type      string // "text" | "audio" | "image" | "document"
-    Content   string
-    ExtID     *string // messageid from WhatsApp
-    CreatedAt time.Time
+    ID         int64
+    ClientID   int64
+    Role       string // "user" | "assistant" | "operator" | "system"
+    Type       string // "text" | "audio" | "image" | "document"
+    Content    string
+    Original   *string    // unredacted content, kept only when redaction changed it (REDACT_KEEP_ORIGINAL)
+    ExtID      *string    // messageid from WhatsApp
+    Ephemeral  bool       // sent as a disappearing (ephemeral) message
+    ViewOnce   bool       // sent as view-once media
+    Forwarded  bool       // forwarded by the sender (not written by them)
+    ProviderAt *time.Time // timestamp reported by the provider on send
+    Language   string     // language of Content when the conversation is translated (TRANSLATE_MODE)
+    Translated *string    // Portuguese version of Content, when translated
+    CreatedAt  time.Time
 }
 
-// GetOrCreateClient inserts or retrieves a client row by phone. If the phone
-// already exists, it updates the name if previously null. It returns the
-// up-to-date Client.
-func GetOrCreateClient(ctx context.Context, pool *pgxpool.Pool, phone string, name *string) (Client, error) {
+// GetOrCreateClient inserts or retrieves a client row by phone, within the tenant
+// of ctx (see WithTenant). If the phone
+// already exists, it updates the name if previously null. A Brazilian mobile
+// already stored in its other spelling (with or without the 9th digit) is
+// reused instead of creating a duplicate. It returns the up-to-date Client.
+func GetOrCreateClient(ctx context.Context, db DB, number string, name *string) (Client, error) {
     var c Client
-    err := pool.QueryRow(ctx, `
-        INSERT INTO clients (phone, name)
-        VALUES ($1, $2)
-        ON CONFLICT (phone) DO UPDATE SET name = COALESCE(clients.name, EXCLUDED.name)
+    if alt, ok := phone.Alternate(number); ok {
+        err := db.QueryRow(ctx, `
+            UPDATE clients SET name = COALESCE(name, $3)
+            WHERE phone=$1 AND COALESCE(tenant_id, 0)=$4
+              AND NOT EXISTS (SELECT 1 FROM clients WHERE phone=$2 AND COALESCE(tenant_id, 0)=$4)
+            RETURNING id, phone, name, thread_id, created_at
+        `, alt, number, name, tenantArg(ctx)).Scan(&c.ID, &c.Phone, &c.Name, &c.ThreadID, &c.CreatedAt)
+        if err == nil {
+            return c, nil
+        }
+        if !errors.Is(err, pgx.ErrNoRows) {
+            return c, err
+        }
+    }
+    err := db.QueryRow(ctx, `
+        INSERT INTO clients (phone, name, tenant_id)
+        VALUES ($1, $2, NULLIF($3, 0))
+        ON CONFLICT ((COALESCE(tenant_id, 0)), phone) DO UPDATE SET name = COALESCE(clients.name, EXCLUDED.name)
         RETURNING id, phone, name, thread_id, created_at
-    `, phone, name).Scan(&c.ID, &c.Phone, &c.Name, &c.ThreadID, &c.CreatedAt)
+    `, number, name, tenantArg(ctx)).Scan(&c.ID, &c.Phone, &c.Name, &c.ThreadID, &c.CreatedAt)
     return c, err
 }
 
 // SetClientThread sets the thread_id for a given client.
-func SetClientThread(ctx context.Context, pool *pgxpool.Pool, clientID int64, threadID string) error {
-    ct, err := pool.Exec(ctx, `UPDATE clients SET thread_id=$1 WHERE id=$2`, threadID, clientID)
+func SetClientThread(ctx context.Context, db DB, clientID int64, threadID string) error {
+    ct, err := db.Exec(ctx, `UPDATE clients SET thread_id=$1 WHERE id=$2`, threadID, clientID)
     if err != nil {
         return err
     }
@@ -57,11 +82,11 @@ func SetClientThread(ctx context.Context, pool *pgxpool.Pool, clientID int64, th
     return nil
 }
 
-// InsertMessage inserts a new message row.
-func InsertMessage(ctx context.Context, pool *pgxpool.Pool, m Message) error {
-    _, err := pool.Exec(ctx, `
-        INSERT INTO messages (client_id, role, type, content, ext_id)
-        VALUES ($1,$2,$3,$4,$5)
-    `, m.ClientID, m.Role, m.Type, m.Content, m.ExtID)
+// InsertMessage inserts a new message row, tagged with the experiment variant of ctx.
+func InsertMessage(ctx context.Context, db DB, m Message) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO messages (client_id, role, type, content, ext_id, ephemeral, view_once, forwarded, provider_at, variant, tenant_id, content_original, language, content_translated)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,NULLIF($10,''),(SELECT tenant_id FROM clients WHERE id=$1),$11,NULLIF($12,''),$13)
+    `, m.ClientID, m.Role, m.Type, m.Content, m.ExtID, m.Ephemeral, m.ViewOnce, m.Forwarded, m.ProviderAt, VariantFrom(ctx), m.Original, m.Language, m.Translated)
     return err
 }
\ No newline at end of file
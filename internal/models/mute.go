@@ -0,0 +1,77 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// SetClientMute silences the bot for the client until until (nil unmutes).
+func SetClientMute(ctx context.Context, db DB, clientID int64, until *time.Time) error {
+    _, err := db.Exec(ctx, `UPDATE clients SET muted_until=$2 WHERE id=$1`, clientID, until)
+    return err
+}
+
+// ClientMutedUntil returns when the client's mute ends, or nil when not muted.
+func ClientMutedUntil(ctx context.Context, db DB, clientID int64) (*time.Time, error) {
+    var until *time.Time
+    err := db.QueryRow(ctx, `
+        SELECT muted_until FROM clients WHERE id=$1 AND muted_until > now()
+    `, clientID).Scan(&until)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, nil
+    }
+    return until, err
+}
+
+// MuteExpired is a client whose mute just ended.
+type MuteExpired struct {
+    ClientID int64
+    Phone    string
+}
+
+// ClearExpiredMutes unmutes up to limit clients of the tenant of ctx whose mute
+// ended and returns them (each one is returned only once).
+func ClearExpiredMutes(ctx context.Context, db DB, limit int) ([]MuteExpired, error) {
+    rows, err := db.Query(ctx, `
+        UPDATE clients SET muted_until = NULL
+        WHERE id IN (
+          SELECT id FROM clients
+          WHERE muted_until <= now() AND COALESCE(tenant_id, 0) = $1
+          ORDER BY muted_until LIMIT $2
+          FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, phone
+    `, tenantArg(ctx), limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []MuteExpired
+    for rows.Next() {
+        var m MuteExpired
+        if err := rows.Scan(&m.ClientID, &m.Phone); err != nil {
+            return nil, err
+        }
+        out = append(out, m)
+    }
+    return out, rows.Err()
+}
+
+// ClientAudioOff reports whether the client asked for text-only replies.
+func ClientAudioOff(ctx context.Context, db DB, clientID int64) (bool, error) {
+    var off bool
+    err := db.QueryRow(ctx, `SELECT audio_replies_off FROM clients WHERE id=$1`, clientID).Scan(&off)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return false, nil
+    }
+    return off, err
+}
+
+// SetClientAudioOff turns text-only replies on or off for the client.
+func SetClientAudioOff(ctx context.Context, db DB, clientID int64, off bool) error {
+    _, err := db.Exec(ctx, `UPDATE clients SET audio_replies_off=$2 WHERE id=$1`, clientID, off)
+    return err
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// ClaimWebhookNonce records a nonce of a signed webhook until expires, for the
+// tenant of ctx. False when the nonce was already used and has not expired yet
+// (a replayed event), whichever replica or tenant received it.
+func ClaimWebhookNonce(ctx context.Context, db DB, nonce string, expires time.Time) (bool, error) {
+    tag, err := db.Exec(ctx, `
+        INSERT INTO webhook_nonces (nonce, tenant_id, expires_at) VALUES ($1, NULLIF($2, 0), $3)
+        ON CONFLICT (nonce) DO UPDATE SET tenant_id = EXCLUDED.tenant_id, expires_at = EXCLUDED.expires_at
+        WHERE webhook_nonces.expires_at <= now()
+    `, nonce, tenantArg(ctx), expires)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseWebhookNonce forgets a nonce whose event was not processed, so the
+// sender can retry it with the same signed headers.
+func ReleaseWebhookNonce(ctx context.Context, db DB, nonce string) error {
+    _, err := db.Exec(ctx, `DELETE FROM webhook_nonces WHERE nonce=$1`, nonce)
+    return err
+}
+
+// PurgeExpiredWebhookNonces removes the nonces past their expiry.
+func PurgeExpiredWebhookNonces(ctx context.Context, db DB) (int64, error) {
+    ct, err := db.Exec(ctx, `DELETE FROM webhook_nonces WHERE expires_at <= now()`)
+    if err != nil {
+        return 0, err
+    }
+    return ct.RowsAffected(), nil
+}
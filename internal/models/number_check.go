@@ -0,0 +1,39 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ClientNumberCheck returns the last WhatsApp verification of the client of the
+// tenant of ctx with that phone. at is nil when the number was never checked (or
+// there is no such client).
+func ClientNumberCheck(ctx context.Context, db DB, phone string) (exists bool, at *time.Time, err error) {
+    var ex *bool
+    err = db.QueryRow(ctx, `
+        SELECT whatsapp_exists, whatsapp_checked_at FROM clients
+        WHERE phone=$1 AND COALESCE(tenant_id, 0)=$2
+    `, phone, tenantArg(ctx)).Scan(&ex, &at)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return false, nil, nil
+    }
+    if ex != nil {
+        exists = *ex
+    }
+    return exists, at, err
+}
+
+// RecordNumberCheck stores a verification result on the client of the tenant of
+// ctx with that phone. A number not on WhatsApp is also marked unreachable; one
+// that exists has the flag cleared. Unknown phones are ignored.
+func RecordNumberCheck(ctx context.Context, db DB, phone string, exists bool) error {
+    _, err := db.Exec(ctx, `
+        UPDATE clients SET whatsapp_exists=$3, whatsapp_checked_at=now(),
+               unreachable_at = CASE WHEN $3 THEN NULL ELSE COALESCE(unreachable_at, now()) END
+        WHERE phone=$1 AND COALESCE(tenant_id, 0)=$2
+    `, phone, tenantArg(ctx), exists)
+    return err
+}
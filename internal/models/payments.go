@@ -0,0 +1,91 @@
+package models
+
+import (
+    "context"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// Payment is a payment reported by a provider webhook (Mercado Pago, Stripe, Pix).
+type Payment struct {
+    ID          int64      `json:"id"`
+    Provider    string     `json:"provider"`
+    ExternalID  string     `json:"external_id"`
+    Reference   string     `json:"reference,omitempty"`
+    ClientID    *int64     `json:"client_id,omitempty"`
+    Phone       string     `json:"phone,omitempty"`
+    Status      string     `json:"status"`
+    AmountCents int64      `json:"amount_cents"`
+    Currency    string     `json:"currency"`
+    PaidAt      *time.Time `json:"paid_at,omitempty"`
+    NotifiedAt  *time.Time `json:"notified_at,omitempty"`
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+const paymentColumns = `p.id, p.provider, p.external_id, p.reference, p.client_id, COALESCE(c.phone, ''), p.status,
+    p.amount_cents, p.currency, p.paid_at, p.notified_at, p.created_at, p.updated_at`
+
+func scanPayment(row pgx.Row) (Payment, error) {
+    var p Payment
+    err := row.Scan(&p.ID, &p.Provider, &p.ExternalID, &p.Reference, &p.ClientID, &p.Phone, &p.Status,
+        &p.AmountCents, &p.Currency, &p.PaidAt, &p.NotifiedAt, &p.CreatedAt, &p.UpdatedAt)
+    return p, err
+}
+
+// UpsertPayment records a provider notification for the tenant of ctx. Repeated
+// notifications of the same payment update the row; a late "pending" never
+// overrides a payment already paid or refunded, and paid_at keeps the first value.
+func UpsertPayment(ctx context.Context, db DB, p Payment, raw []byte) (Payment, error) {
+    return scanPayment(db.QueryRow(ctx, `
+        WITH p AS (
+          INSERT INTO payments (tenant_id, provider, external_id, reference, client_id, status, amount_cents, currency, paid_at, raw)
+          VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8, $9, $10)
+          ON CONFLICT ((COALESCE(tenant_id, 0)), provider, external_id) DO UPDATE SET
+            reference = COALESCE(NULLIF(EXCLUDED.reference, ''), payments.reference),
+            client_id = COALESCE(EXCLUDED.client_id, payments.client_id),
+            status = CASE WHEN EXCLUDED.status = 'pending' AND payments.status IN ('paid', 'refunded')
+                          THEN payments.status ELSE EXCLUDED.status END,
+            amount_cents = CASE WHEN EXCLUDED.amount_cents > 0 THEN EXCLUDED.amount_cents ELSE payments.amount_cents END,
+            currency = EXCLUDED.currency,
+            paid_at = COALESCE(payments.paid_at, EXCLUDED.paid_at),
+            raw = EXCLUDED.raw,
+            updated_at = now()
+          RETURNING *
+        )
+        SELECT `+paymentColumns+` FROM p LEFT JOIN clients c ON c.id = p.client_id
+    `, tenantArg(ctx), p.Provider, p.ExternalID, p.Reference, p.ClientID, p.Status, p.AmountCents, p.Currency, p.PaidAt, raw))
+}
+
+// ClaimPaymentNotice marks a paid payment as notified. It returns true only for
+// the first caller, so provider retries do not message the client twice.
+func ClaimPaymentNotice(ctx context.Context, db DB, id int64) (bool, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE payments SET notified_at = now() WHERE id=$1 AND status='paid' AND notified_at IS NULL
+    `, id)
+    return tag.RowsAffected() > 0, err
+}
+
+// ListPayments returns the latest payments of the tenant of ctx, optionally only
+// those of one client (clientID > 0).
+func ListPayments(ctx context.Context, db DB, clientID int64, limit int) ([]Payment, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+paymentColumns+` FROM payments p LEFT JOIN clients c ON c.id = p.client_id
+        WHERE COALESCE(p.tenant_id, 0) = $1 AND ($2 = 0 OR p.client_id = $2)
+        ORDER BY p.created_at DESC, p.id DESC LIMIT $3
+    `, tenantArg(ctx), clientID, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []Payment{}
+    for rows.Next() {
+        p, err := scanPayment(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, p)
+    }
+    return out, rows.Err()
+}
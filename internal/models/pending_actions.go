@@ -0,0 +1,115 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// PendingAction is a payment link or form sent to the client that is waiting for
+// an external confirmation. While it is open (and not expired) the assistant does
+// not answer messages about it.
+type PendingAction struct {
+    ID         int64      `json:"id"`
+    ClientID   int64      `json:"client_id"`
+    Phone      string     `json:"phone"`
+    Kind       string     `json:"kind"`
+    Reference  string     `json:"reference,omitempty"`
+    URL        string     `json:"url,omitempty"`
+    Held       int        `json:"held"`
+    ExpiresAt  time.Time  `json:"expires_at"`
+    ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+    Resolution *string    `json:"resolution,omitempty"`
+    CreatedAt  time.Time  `json:"created_at"`
+}
+
+const pendingActionColumns = `p.id, p.client_id, c.phone, p.kind, p.reference, p.url, p.held, p.expires_at, p.resolved_at, p.resolution, p.created_at`
+
+func scanPendingAction(row pgx.Row) (PendingAction, error) {
+    var p PendingAction
+    err := row.Scan(&p.ID, &p.ClientID, &p.Phone, &p.Kind, &p.Reference, &p.URL, &p.Held, &p.ExpiresAt, &p.ResolvedAt, &p.Resolution, &p.CreatedAt)
+    return p, err
+}
+
+// CreatePendingAction opens a pending action for the client. An action still open
+// for the same client is closed as "replaced": only the latest link is waited on.
+func CreatePendingAction(ctx context.Context, db DB, clientID int64, kind, reference, url string, expiresAt time.Time) (PendingAction, error) {
+    return scanPendingAction(db.QueryRow(ctx, `
+        WITH closed AS (
+          UPDATE pending_actions SET resolved_at = now(), resolution = 'replaced'
+          WHERE client_id = $1 AND resolved_at IS NULL
+        ), p AS (
+          INSERT INTO pending_actions (client_id, kind, reference, url, expires_at)
+          VALUES ($1, $2, $3, $4, $5)
+          RETURNING *
+        )
+        SELECT `+pendingActionColumns+` FROM p JOIN clients c ON c.id = p.client_id
+    `, clientID, kind, reference, url, expiresAt))
+}
+
+// ActivePendingAction returns the open, unexpired action of the client. ok is
+// false when there is none.
+func ActivePendingAction(ctx context.Context, db DB, clientID int64) (PendingAction, bool, error) {
+    p, err := scanPendingAction(db.QueryRow(ctx, `
+        SELECT `+pendingActionColumns+` FROM pending_actions p JOIN clients c ON c.id = p.client_id
+        WHERE p.client_id = $1 AND p.resolved_at IS NULL AND p.expires_at > now()
+        ORDER BY p.id DESC LIMIT 1
+    `, clientID))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return PendingAction{}, false, nil
+    }
+    return p, err == nil, err
+}
+
+// HoldPendingAction counts a client message held while the action is open.
+func HoldPendingAction(ctx context.Context, db DB, id int64) error {
+    _, err := db.Exec(ctx, `UPDATE pending_actions SET held = held + 1 WHERE id=$1`, id)
+    return err
+}
+
+// ResolvePendingActions closes the open actions of the tenant of ctx that match
+// reference (when not empty) or the client's phone, expired or not, and returns
+// them.
+func ResolvePendingActions(ctx context.Context, db DB, reference, phone, resolution string) ([]PendingAction, error) {
+    rows, err := db.Query(ctx, `
+        WITH p AS (
+          UPDATE pending_actions p SET resolved_at = now(), resolution = $4
+          FROM clients c
+          WHERE c.id = p.client_id AND COALESCE(c.tenant_id, 0) = $1 AND p.resolved_at IS NULL
+            AND (($2 <> '' AND p.reference = $2) OR ($2 = '' AND c.phone = $3))
+          RETURNING p.*
+        )
+        SELECT `+pendingActionColumns+` FROM p JOIN clients c ON c.id = p.client_id
+        ORDER BY p.id
+    `, tenantArg(ctx), reference, phone, resolution)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []PendingAction{}
+    for rows.Next() {
+        p, err := scanPendingAction(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, p)
+    }
+    return out, rows.Err()
+}
+
+// PendingActionPhone finds the client phone of the latest pending action of the
+// tenant of ctx with the given reference (open or not). ok is false when there is none.
+func PendingActionPhone(ctx context.Context, db DB, reference string) (string, bool, error) {
+    var phone string
+    err := db.QueryRow(ctx, `
+        SELECT c.phone FROM pending_actions p JOIN clients c ON c.id = p.client_id
+        WHERE p.reference = $1 AND COALESCE(c.tenant_id, 0) = $2
+        ORDER BY p.id DESC LIMIT 1
+    `, reference, tenantArg(ctx)).Scan(&phone)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return "", false, nil
+    }
+    return phone, err == nil, err
+}
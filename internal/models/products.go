@@ -0,0 +1,107 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrDuplicateSKU is returned when another product of the tenant already has the SKU.
+var ErrDuplicateSKU = errors.New("sku already in use")
+
+// skuConflict maps a unique violation on products.sku to ErrDuplicateSKU.
+func skuConflict(err error) error {
+    var pgErr *pgconn.PgError
+    if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+        return ErrDuplicateSKU
+    }
+    return err
+}
+
+// Product is a catalog item the assistant can quote. A nil Price means "price on request".
+type Product struct {
+    ID          int64     `json:"id"`
+    SKU         string    `json:"sku,omitempty"`
+    Name        string    `json:"name"`
+    Description string    `json:"description,omitempty"`
+    Category    string    `json:"category,omitempty"`
+    Price       *float64  `json:"price"`
+    Unit        string    `json:"unit,omitempty"`
+    Active      bool      `json:"active"`
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+const productColumns = `id, sku, name, description, category, price::float8, unit, active, created_at, updated_at`
+
+func scanProduct(row pgx.Row) (Product, error) {
+    var p Product
+    err := row.Scan(&p.ID, &p.SKU, &p.Name, &p.Description, &p.Category, &p.Price, &p.Unit, &p.Active, &p.CreatedAt, &p.UpdatedAt)
+    return p, err
+}
+
+// ListProducts returns the catalog of the tenant of ctx ordered by name. With
+// activeOnly, inactive products are left out.
+func ListProducts(ctx context.Context, db DB, activeOnly bool) ([]Product, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+productColumns+` FROM products
+        WHERE COALESCE(tenant_id, 0) = $1 AND (NOT $2 OR active)
+        ORDER BY name, id
+    `, tenantArg(ctx), activeOnly)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []Product{}
+    for rows.Next() {
+        p, err := scanProduct(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, p)
+    }
+    return out, rows.Err()
+}
+
+// GetProduct returns a product of the tenant of ctx. ok is false if not found.
+func GetProduct(ctx context.Context, db DB, id int64) (Product, bool, error) {
+    p, err := scanProduct(db.QueryRow(ctx, `
+        SELECT `+productColumns+` FROM products WHERE id=$1 AND COALESCE(tenant_id, 0) = $2
+    `, id, tenantArg(ctx)))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return Product{}, false, nil
+    }
+    return p, err == nil, err
+}
+
+// CreateProduct inserts a product for the tenant of ctx.
+func CreateProduct(ctx context.Context, db DB, p Product) (Product, error) {
+    out, err := scanProduct(db.QueryRow(ctx, `
+        INSERT INTO products (tenant_id, sku, name, description, category, price, unit, active)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)
+        RETURNING `+productColumns,
+        tenantArg(ctx), p.SKU, p.Name, p.Description, p.Category, p.Price, p.Unit, p.Active))
+    return out, skuConflict(err)
+}
+
+// UpdateProduct replaces the fields of a product of the tenant of ctx. ok is false if not found.
+func UpdateProduct(ctx context.Context, db DB, p Product) (Product, bool, error) {
+    out, err := scanProduct(db.QueryRow(ctx, `
+        UPDATE products SET sku=$3, name=$4, description=$5, category=$6, price=$7, unit=$8, active=$9, updated_at=now()
+        WHERE id=$1 AND COALESCE(tenant_id, 0) = $2
+        RETURNING `+productColumns,
+        p.ID, tenantArg(ctx), p.SKU, p.Name, p.Description, p.Category, p.Price, p.Unit, p.Active))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return Product{}, false, nil
+    }
+    return out, err == nil, skuConflict(err)
+}
+
+// DeleteProduct removes a product of the tenant of ctx. Reports whether it existed.
+func DeleteProduct(ctx context.Context, db DB, id int64) (bool, error) {
+    tag, err := db.Exec(ctx, `DELETE FROM products WHERE id=$1 AND COALESCE(tenant_id, 0) = $2`, id, tenantArg(ctx))
+    return tag.RowsAffected() > 0, err
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// Query is a typed SQL statement: the SQL text plus how one row maps to T. The
+// column list and the matching Scan live next to each other, so queries that
+// return the same type cannot drift apart.
+type Query[T any] struct {
+    SQL  string
+    Scan func(row pgx.Row, v *T) error
+}
+
+// One runs the query and scans the first row. ok is false when there is none.
+func (q Query[T]) One(ctx context.Context, db DB, args ...any) (T, bool, error) {
+    var v T
+    err := q.Scan(db.QueryRow(ctx, q.SQL, args...), &v)
+    if errors.Is(err, pgx.ErrNoRows) {
+        var zero T
+        return zero, false, nil
+    }
+    return v, err == nil, err
+}
+
+// All runs the query and scans every row.
+func (q Query[T]) All(ctx context.Context, db DB, args ...any) ([]T, error) {
+    rows, err := db.Query(ctx, q.SQL, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []T
+    for rows.Next() {
+        var v T
+        if err := q.Scan(rows, &v); err != nil {
+            return nil, err
+        }
+        out = append(out, v)
+    }
+    return out, rows.Err()
+}
+
+// clientColumns are the columns read by scanClient.
+const clientColumns = "id, phone, name, thread_id, created_at"
+
+func scanClient(row pgx.Row, c *Client) error {
+    return row.Scan(&c.ID, &c.Phone, &c.Name, &c.ThreadID, &c.CreatedAt)
+}
+
+// messageColumns are the columns read by scanMessage.
+const messageColumns = "id, client_id, role, type, content, created_at"
+
+func scanMessage(row pgx.Row, m *Message) error {
+    return row.Scan(&m.ID, &m.ClientID, &m.Role, &m.Type, &m.Content, &m.CreatedAt)
+}
+
+var (
+    clientByPhoneQuery = Query[Client]{Scan: scanClient, SQL: `
+        SELECT ` + clientColumns + ` FROM clients WHERE phone=$1 AND COALESCE(tenant_id, 0)=$2`}
+
+    messageByExtIDQuery = Query[Message]{Scan: scanMessage, SQL: `
+        SELECT ` + messageColumns + ` FROM messages
+        WHERE client_id=$1 AND ext_id=$2 ORDER BY id LIMIT 1`}
+
+    messagesAfterQuery = Query[Message]{Scan: scanMessage, SQL: `
+        SELECT ` + messageColumns + ` FROM messages
+        WHERE client_id=$1 AND id > $2 AND role IN ('user', 'assistant', 'operator')
+          AND status IS DISTINCT FROM 'failed'
+        ORDER BY id LIMIT $3`}
+
+    recentMessagesQuery = Query[Message]{Scan: scanMessage, SQL: `
+        SELECT ` + messageColumns + ` FROM (
+          SELECT ` + messageColumns + ` FROM messages
+          WHERE client_id=$1 ORDER BY created_at DESC LIMIT $2
+        ) t ORDER BY created_at`}
+
+    conversationTailQuery = Query[Message]{Scan: scanMessage, SQL: `
+        SELECT ` + messageColumns + ` FROM (
+          SELECT ` + messageColumns + ` FROM messages
+          WHERE client_id=$1 AND role IN ('user', 'assistant', 'operator')
+            AND status IS DISTINCT FROM 'failed'
+          ORDER BY id DESC LIMIT $2
+        ) t ORDER BY id`}
+)
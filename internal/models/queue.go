@@ -0,0 +1,54 @@
+package models
+
+import (
+    "cmp"
+    "context"
+    "slices"
+    "time"
+)
+
+// QueuedInbound is an inbound message held back (e.g. during maintenance) to be
+// fed into the buffer later. Content is already normalized text.
+type QueuedInbound struct {
+    ID        int64
+    Phone     string
+    Content   string
+    Kind      string
+    CreatedAt time.Time
+}
+
+// EnqueueInbound stores a normalized inbound message of the tenant of ctx for later processing.
+func EnqueueInbound(ctx context.Context, db DB, phone, content, kind string) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO inbound_queue (phone, content, kind, tenant_id) VALUES ($1,$2,$3,NULLIF($4, 0))
+    `, phone, content, kind, tenantArg(ctx))
+    return err
+}
+
+// ClaimQueuedInbound removes and returns the queued messages of the tenant of ctx,
+// in arrival order. Rows locked by a concurrent claim (another replica draining)
+// are skipped, so each message is handed to exactly one buffer.
+func ClaimQueuedInbound(ctx context.Context, db DB) ([]QueuedInbound, error) {
+    rows, err := db.Query(ctx, `
+        DELETE FROM inbound_queue WHERE id IN (
+          SELECT id FROM inbound_queue WHERE COALESCE(tenant_id, 0)=$1
+          ORDER BY id FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, phone, content, kind, created_at
+    `, tenantArg(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []QueuedInbound
+    for rows.Next() {
+        var q QueuedInbound
+        if err := rows.Scan(&q.ID, &q.Phone, &q.Content, &q.Kind, &q.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, q)
+    }
+    // RETURNING does not keep the ORDER BY of the subquery
+    slices.SortFunc(out, func(a, b QueuedInbound) int { return cmp.Compare(a.ID, b.ID) })
+    return out, rows.Err()
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// ReengageCandidate is a silent client eligible for a re-engagement nudge.
+type ReengageCandidate struct {
+    ClientID int64
+    Phone    string
+    Name     *string
+    ThreadID *string
+    LastAt   time.Time
+}
+
+// ReengageQuery selects candidates: last message between MaxAge and MinAge ago, not opted out,
+// optionally tagged, and with fewer than MaxAttempts nudges since the client last wrote.
+type ReengageQuery struct {
+    MinAge      time.Duration
+    MaxAge      time.Duration
+    Tag         string
+    MaxAttempts int
+    Limit       int
+}
+
+// ListReengageCandidates returns the oldest eligible clients of the tenant of ctx first.
+func ListReengageCandidates(ctx context.Context, db DB, q ReengageQuery) ([]ReengageCandidate, error) {
+    now := time.Now()
+    rows, err := db.Query(ctx, `
+        SELECT c.id, c.phone, c.name, c.thread_id, lm.last_at
+        FROM clients c
+        JOIN LATERAL (SELECT MAX(created_at) AS last_at FROM messages WHERE client_id = c.id) lm ON true
+        LEFT JOIN LATERAL (SELECT MAX(created_at) AS last_user FROM messages WHERE client_id = c.id AND role = 'user') lu ON true
+        WHERE c.opted_out_at IS NULL AND c.unreachable_at IS NULL AND COALESCE(c.tenant_id, 0) = $6
+          AND lm.last_at < $1 AND lm.last_at > $2
+          AND ($3 = '' OR EXISTS (SELECT 1 FROM client_tags t WHERE t.client_id = c.id AND t.tag = $3))
+          AND (SELECT COUNT(*) FROM reengagements r
+               WHERE r.client_id = c.id AND r.created_at > COALESCE(lu.last_user, 'epoch'::timestamptz)) < $4
+        ORDER BY lm.last_at
+        LIMIT $5
+    `, now.Add(-q.MinAge), now.Add(-q.MaxAge), NormalizeTag(q.Tag), q.MaxAttempts, q.Limit, tenantArg(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []ReengageCandidate
+    for rows.Next() {
+        var c ReengageCandidate
+        if err := rows.Scan(&c.ClientID, &c.Phone, &c.Name, &c.ThreadID, &c.LastAt); err != nil {
+            return nil, err
+        }
+        out = append(out, c)
+    }
+    return out, rows.Err()
+}
+
+// RecordReengagement stores a sent nudge.
+func RecordReengagement(ctx context.Context, db DB, clientID int64, message string) error {
+    _, err := db.Exec(ctx, `INSERT INTO reengagements (client_id, message) VALUES ($1,$2)`, clientID, message)
+    return err
+}
+
+// SetClientOptOut marks (or clears) the client's opt-out from proactive messages.
+func SetClientOptOut(ctx context.Context, db DB, clientID int64, optOut bool) error {
+    _, err := db.Exec(ctx, `
+        UPDATE clients SET opted_out_at = CASE WHEN $2 THEN COALESCE(opted_out_at, now()) ELSE NULL END
+        WHERE id=$1
+    `, clientID, optOut)
+    return err
+}
+
+// RecentMessages returns the last limit messages of a client in chronological order.
+func RecentMessages(ctx context.Context, db DB, clientID int64, limit int) ([]Message, error) {
+    return recentMessagesQuery.All(ctx, db, clientID, limit)
+}
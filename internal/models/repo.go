@@ -0,0 +1,67 @@
+package models
+
+import "context"
+
+// ClientRepo is the storage of clients, scoped to the tenant of ctx (see
+// WithTenant). Postgres implements it with the functions of this package; code
+// that depends on the interface can run on another backend, or on a fake in tests.
+type ClientRepo interface {
+    GetOrCreate(ctx context.Context, phone string, name *string) (Client, error)
+    ByPhone(ctx context.Context, phone string) (Client, bool, error)
+    SetThread(ctx context.Context, clientID int64, threadID string) error
+}
+
+// MessageRepo is the storage of the conversation history.
+type MessageRepo interface {
+    Insert(ctx context.Context, m Message) (int64, error)
+    ByExtID(ctx context.Context, clientID int64, extID string) (Message, bool, error)
+    // Recent returns the last limit messages of any role, oldest first.
+    Recent(ctx context.Context, clientID int64, limit int) ([]Message, error)
+    // Tail returns the last limit conversation messages (user, assistant and
+    // operator), oldest first.
+    Tail(ctx context.Context, clientID int64, limit int) ([]Message, error)
+    // After returns up to limit conversation messages with id greater than afterID.
+    After(ctx context.Context, clientID, afterID int64, limit int) ([]Message, error)
+}
+
+// Postgres implements the repositories on a DB (pool or transaction).
+type Postgres struct {
+    DB DB
+}
+
+var (
+    _ ClientRepo  = Postgres{}
+    _ MessageRepo = Postgres{}
+)
+
+func (p Postgres) GetOrCreate(ctx context.Context, phone string, name *string) (Client, error) {
+    return GetOrCreateClient(ctx, p.DB, phone, name)
+}
+
+func (p Postgres) ByPhone(ctx context.Context, phone string) (Client, bool, error) {
+    return GetClientByPhone(ctx, p.DB, phone)
+}
+
+func (p Postgres) SetThread(ctx context.Context, clientID int64, threadID string) error {
+    return SetClientThread(ctx, p.DB, clientID, threadID)
+}
+
+func (p Postgres) Insert(ctx context.Context, m Message) (int64, error) {
+    return InsertMessageID(ctx, p.DB, m)
+}
+
+func (p Postgres) ByExtID(ctx context.Context, clientID int64, extID string) (Message, bool, error) {
+    return MessageByExtID(ctx, p.DB, clientID, extID)
+}
+
+func (p Postgres) Recent(ctx context.Context, clientID int64, limit int) ([]Message, error) {
+    return RecentMessages(ctx, p.DB, clientID, limit)
+}
+
+func (p Postgres) Tail(ctx context.Context, clientID int64, limit int) ([]Message, error) {
+    return ConversationTail(ctx, p.DB, clientID, limit)
+}
+
+func (p Postgres) After(ctx context.Context, clientID, afterID int64, limit int) ([]Message, error) {
+    return MessagesAfter(ctx, p.DB, clientID, afterID, limit)
+}
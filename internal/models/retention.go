@@ -0,0 +1,79 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// StaleThread is a client whose OpenAI thread has had no activity since a cutoff.
+type StaleThread struct {
+    ClientID int64
+    Phone    string
+    ThreadID string
+}
+
+// PurgeMessagesBefore deletes messages older than before and returns how many were removed.
+func PurgeMessagesBefore(ctx context.Context, db DB, before time.Time) (int64, error) {
+    ct, err := db.Exec(ctx, `DELETE FROM messages WHERE created_at < $1`, before)
+    if err != nil {
+        return 0, err
+    }
+    return ct.RowsAffected(), nil
+}
+
+// ListStaleThreads returns clients with a thread whose last message is older than before
+// (or that have no messages at all).
+func ListStaleThreads(ctx context.Context, db DB, before time.Time, limit int) ([]StaleThread, error) {
+    rows, err := db.Query(ctx, `
+        SELECT c.id, c.phone, c.thread_id FROM clients c
+        WHERE c.thread_id IS NOT NULL
+          AND COALESCE((SELECT MAX(created_at) FROM messages m WHERE m.client_id = c.id), c.created_at) < $1
+        ORDER BY c.id LIMIT $2
+    `, before, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []StaleThread
+    for rows.Next() {
+        var s StaleThread
+        if err := rows.Scan(&s.ClientID, &s.Phone, &s.ThreadID); err != nil {
+            return nil, err
+        }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+// ClearClientThread unsets thread_id so the next conversation starts a new thread.
+func ClearClientThread(ctx context.Context, db DB, clientID int64) error {
+    _, err := db.Exec(ctx, `UPDATE clients SET thread_id=NULL WHERE id=$1`, clientID)
+    return err
+}
+
+// GetClientByPhone returns the client with the given phone in the tenant of ctx.
+// ok is false if not found.
+func GetClientByPhone(ctx context.Context, db DB, phone string) (Client, bool, error) {
+    return clientByPhoneQuery.One(ctx, db, phone, tenantArg(ctx))
+}
+
+// DeleteClient removes a client and, by cascade, all its messages and facts.
+// Returns the number of messages that were deleted with it.
+func DeleteClient(ctx context.Context, db DB, clientID int64) (int64, error) {
+    var n int64
+    if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM messages WHERE client_id=$1`, clientID).Scan(&n); err != nil {
+        return 0, err
+    }
+    if _, err := db.Exec(ctx, `DELETE FROM clients WHERE id=$1`, clientID); err != nil {
+        return 0, err
+    }
+    return n, nil
+}
+
+// RecordPurge writes an entry to the purge audit trail.
+func RecordPurge(ctx context.Context, db DB, kind string, phone *string, detail string, affected int64) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO purge_audit (kind, phone, detail, affected) VALUES ($1,$2,$3,$4)
+    `, kind, phone, detail, affected)
+    return err
+}
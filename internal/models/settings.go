@@ -0,0 +1,107 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// BotSettings holds per-instance (bot number) overrides. Nil fields fall back to env config.
+type BotSettings struct {
+    Instance             string    `json:"instance"`
+    ReplyDelayMinMs      *int      `json:"reply_delay_min_ms"`
+    ReplyDelayMaxMs      *int      `json:"reply_delay_max_ms"`
+    TTSVoice             *string   `json:"tts_voice"`
+    TTSSpeed             *float64  `json:"tts_speed"`
+    BufferTimeoutSeconds *int      `json:"buffer_timeout_seconds"`
+    BusinessHours        *string   `json:"business_hours"`
+
+    // Assistant run parameters (see openai.RunParams).
+    RunTemperature         *float64 `json:"run_temperature"`
+    RunTopP                *float64 `json:"run_top_p"`
+    RunMaxPromptTokens     *int     `json:"run_max_prompt_tokens"`
+    RunMaxCompletionTokens *int     `json:"run_max_completion_tokens"`
+    RunTruncation          *string  `json:"run_truncation"`
+    RunReasoningEffort     *string  `json:"run_reasoning_effort"`
+
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+const botSettingsColumns = `instance, reply_delay_min_ms, reply_delay_max_ms, tts_voice, tts_speed, buffer_timeout_seconds, business_hours,
+    run_temperature, run_top_p, run_max_prompt_tokens, run_max_completion_tokens, run_truncation, run_reasoning_effort, updated_at`
+
+func scanBotSettings(row pgx.Row) (BotSettings, error) {
+    var s BotSettings
+    err := row.Scan(&s.Instance, &s.ReplyDelayMinMs, &s.ReplyDelayMaxMs, &s.TTSVoice, &s.TTSSpeed,
+        &s.BufferTimeoutSeconds, &s.BusinessHours, &s.RunTemperature, &s.RunTopP, &s.RunMaxPromptTokens,
+        &s.RunMaxCompletionTokens, &s.RunTruncation, &s.RunReasoningEffort, &s.UpdatedAt)
+    return s, err
+}
+
+// GetBotSettings returns the overrides for an instance. ok is false if there are none.
+func GetBotSettings(ctx context.Context, db DB, instance string) (BotSettings, bool, error) {
+    s, err := scanBotSettings(db.QueryRow(ctx, `
+        SELECT `+botSettingsColumns+` FROM bot_settings WHERE instance=$1
+    `, instance))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return BotSettings{}, false, nil
+    }
+    if err != nil {
+        return BotSettings{}, false, err
+    }
+    return s, true, nil
+}
+
+// ListBotSettings returns the overrides of all instances.
+func ListBotSettings(ctx context.Context, db DB) ([]BotSettings, error) {
+    rows, err := db.Query(ctx, `SELECT `+botSettingsColumns+` FROM bot_settings ORDER BY instance`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []BotSettings{}
+    for rows.Next() {
+        s, err := scanBotSettings(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+// UpsertBotSettings replaces the overrides of an instance.
+func UpsertBotSettings(ctx context.Context, db DB, s BotSettings) (BotSettings, error) {
+    return scanBotSettings(db.QueryRow(ctx, `
+        INSERT INTO bot_settings (instance, reply_delay_min_ms, reply_delay_max_ms, tts_voice, tts_speed, buffer_timeout_seconds, business_hours,
+          run_temperature, run_top_p, run_max_prompt_tokens, run_max_completion_tokens, run_truncation, run_reasoning_effort)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+        ON CONFLICT (instance) DO UPDATE SET
+          reply_delay_min_ms=EXCLUDED.reply_delay_min_ms,
+          reply_delay_max_ms=EXCLUDED.reply_delay_max_ms,
+          tts_voice=EXCLUDED.tts_voice,
+          tts_speed=EXCLUDED.tts_speed,
+          buffer_timeout_seconds=EXCLUDED.buffer_timeout_seconds,
+          business_hours=EXCLUDED.business_hours,
+          run_temperature=EXCLUDED.run_temperature,
+          run_top_p=EXCLUDED.run_top_p,
+          run_max_prompt_tokens=EXCLUDED.run_max_prompt_tokens,
+          run_max_completion_tokens=EXCLUDED.run_max_completion_tokens,
+          run_truncation=EXCLUDED.run_truncation,
+          run_reasoning_effort=EXCLUDED.run_reasoning_effort,
+          updated_at=now()
+        RETURNING `+botSettingsColumns,
+        s.Instance, s.ReplyDelayMinMs, s.ReplyDelayMaxMs, s.TTSVoice, s.TTSSpeed, s.BufferTimeoutSeconds, s.BusinessHours,
+        s.RunTemperature, s.RunTopP, s.RunMaxPromptTokens, s.RunMaxCompletionTokens, s.RunTruncation, s.RunReasoningEffort))
+}
+
+// DeleteBotSettings removes the overrides of an instance (it goes back to env defaults).
+func DeleteBotSettings(ctx context.Context, db DB, instance string) (bool, error) {
+    ct, err := db.Exec(ctx, `DELETE FROM bot_settings WHERE instance=$1`, instance)
+    if err != nil {
+        return false, err
+    }
+    return ct.RowsAffected() > 0, nil
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// Status post states.
+const (
+    StatusPostPending  = "pending"
+    StatusPostSent     = "sent"
+    StatusPostFailed   = "failed"
+    StatusPostCanceled = "canceled"
+)
+
+// StatusPost is a WhatsApp Status (story) publication, sent now or at SendAt.
+type StatusPost struct {
+    ID              int64      `json:"id"`
+    Text            string     `json:"text,omitempty"`
+    MediaType       string     `json:"media_type,omitempty"`
+    MediaURL        string     `json:"media_url,omitempty"`
+    BackgroundColor int        `json:"background_color,omitempty"`
+    Font            int        `json:"font,omitempty"`
+    SendAt          time.Time  `json:"send_at"`
+    Status          string     `json:"status"`
+    MessageID       *string    `json:"message_id,omitempty"`
+    Error           *string    `json:"error,omitempty"`
+    CreatedBy       string     `json:"created_by"`
+    CreatedAt       time.Time  `json:"created_at"`
+    SentAt          *time.Time `json:"sent_at,omitempty"`
+}
+
+const statusPostColumns = `id, text, media_type, media_url, background_color, font, send_at, status,
+    message_id, error, created_by, created_at, sent_at`
+
+func scanStatusPost(row interface{ Scan(...any) error }) (StatusPost, error) {
+    var p StatusPost
+    err := row.Scan(&p.ID, &p.Text, &p.MediaType, &p.MediaURL, &p.BackgroundColor, &p.Font, &p.SendAt, &p.Status,
+        &p.MessageID, &p.Error, &p.CreatedBy, &p.CreatedAt, &p.SentAt)
+    return p, err
+}
+
+// InsertStatusPost stores a pending post for the tenant of ctx.
+func InsertStatusPost(ctx context.Context, db DB, p StatusPost) (StatusPost, error) {
+    return scanStatusPost(db.QueryRow(ctx, `
+        INSERT INTO status_posts (tenant_id, text, media_type, media_url, background_color, font, send_at, created_by)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)
+        RETURNING `+statusPostColumns,
+        tenantArg(ctx), p.Text, p.MediaType, p.MediaURL, p.BackgroundColor, p.Font, p.SendAt, p.CreatedBy))
+}
+
+// DueStatusPosts returns the pending posts of the tenant of ctx whose time has come, oldest first.
+func DueStatusPosts(ctx context.Context, db DB, limit int) ([]StatusPost, error) {
+    return listStatusPosts(ctx, db, `
+        SELECT `+statusPostColumns+` FROM status_posts
+        WHERE status = 'pending' AND send_at <= now() AND COALESCE(tenant_id, 0) = $1
+        ORDER BY send_at LIMIT $2
+    `, tenantArg(ctx), limit)
+}
+
+// ListStatusPosts returns the posts of the tenant of ctx (all tenants when ctx is
+// unscoped), most recent schedule first.
+func ListStatusPosts(ctx context.Context, db DB, limit int) ([]StatusPost, error) {
+    return listStatusPosts(ctx, db, `
+        SELECT `+statusPostColumns+` FROM status_posts
+        WHERE ($1 < 0 OR COALESCE(tenant_id, 0) = $1)
+        ORDER BY send_at DESC LIMIT $2
+    `, tenantFilter(ctx), limit)
+}
+
+func listStatusPosts(ctx context.Context, db DB, query string, args ...any) ([]StatusPost, error) {
+    rows, err := db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []StatusPost{}
+    for rows.Next() {
+        p, err := scanStatusPost(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, p)
+    }
+    return out, rows.Err()
+}
+
+// FinishStatusPost records the outcome of a send. sendErr != nil marks it failed.
+func FinishStatusPost(ctx context.Context, db DB, id int64, messageID string, sendErr error) error {
+    status, errText := StatusPostSent, ""
+    if sendErr != nil {
+        status, errText = StatusPostFailed, sendErr.Error()
+    }
+    _, err := db.Exec(ctx, `
+        UPDATE status_posts SET status=$2, message_id=NULLIF($3, ''), error=NULLIF($4, ''), sent_at=now()
+        WHERE id=$1
+    `, id, status, messageID, errText)
+    return err
+}
+
+// CancelStatusPost cancels a pending post of the tenant of ctx. Reports whether
+// there was a pending post with that id.
+func CancelStatusPost(ctx context.Context, db DB, id int64) (bool, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE status_posts SET status='canceled'
+        WHERE id=$1 AND status='pending' AND ($2 < 0 OR COALESCE(tenant_id, 0) = $2)
+    `, id, tenantFilter(ctx))
+    return tag.RowsAffected() > 0, err
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// ClientSummary is the rolling summary of a client's conversation. MessageID is
+// the last message already covered by Text.
+type ClientSummary struct {
+    Text      string     `json:"summary"`
+    MessageID int64      `json:"message_id"`
+    UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// GetClientSummary returns the stored summary of the client (empty if none yet).
+func GetClientSummary(ctx context.Context, db DB, clientID int64) (ClientSummary, error) {
+    var s ClientSummary
+    err := db.QueryRow(ctx, `
+        SELECT COALESCE(summary, ''), COALESCE(summary_message_id, 0), summary_updated_at FROM clients WHERE id=$1
+    `, clientID).Scan(&s.Text, &s.MessageID, &s.UpdatedAt)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return ClientSummary{}, nil
+    }
+    return s, err
+}
+
+// SetClientSummary stores a new summary covering messages up to messageID. A
+// summary that covers fewer messages than the stored one is ignored (two updates
+// racing), and the return value is false.
+func SetClientSummary(ctx context.Context, db DB, clientID int64, text string, messageID int64) (bool, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE clients SET summary=$2, summary_message_id=$3, summary_updated_at=now()
+        WHERE id=$1 AND COALESCE(summary_message_id, 0) < $3
+    `, clientID, text, messageID)
+    return tag.RowsAffected() > 0, err
+}
+
+// CountMessagesAfter counts the client's conversation messages (user, assistant
+// and operator) with id greater than afterID.
+func CountMessagesAfter(ctx context.Context, db DB, clientID, afterID int64) (int, error) {
+    var n int
+    err := db.QueryRow(ctx, `
+        SELECT COUNT(*) FROM messages
+        WHERE client_id=$1 AND id > $2 AND role IN ('user', 'assistant', 'operator')
+    `, clientID, afterID).Scan(&n)
+    return n, err
+}
+
+// MessagesAfter returns up to limit conversation messages of the client with id
+// greater than afterID (failed sends excluded), oldest first.
+func MessagesAfter(ctx context.Context, db DB, clientID, afterID int64, limit int) ([]Message, error) {
+    return messagesAfterQuery.All(ctx, db, clientID, afterID, limit)
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+    "context"
+    "strings"
+    "time"
+)
+
+// NormalizeTag lowercases and trims a tag.
+func NormalizeTag(tag string) string {
+    return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// AddClientTag tags a client (no-op if already tagged). by is the operator that
+// added it; empty for tags set by the assistant.
+func AddClientTag(ctx context.Context, db DB, clientID int64, tag, by string) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO client_tags (client_id, tag, created_by) VALUES ($1,$2,NULLIF($3,'')) ON CONFLICT DO NOTHING
+    `, clientID, NormalizeTag(tag), by)
+    return err
+}
+
+// RemoveClientTag removes a tag from a client. Reports whether the client had it.
+func RemoveClientTag(ctx context.Context, db DB, clientID int64, tag string) (bool, error) {
+    tg, err := db.Exec(ctx, `DELETE FROM client_tags WHERE client_id=$1 AND tag=$2`, clientID, NormalizeTag(tag))
+    return tg.RowsAffected() > 0, err
+}
+
+// ListClientTags returns the tags of a client in alphabetical order.
+func ListClientTags(ctx context.Context, db DB, clientID int64) ([]string, error) {
+    rows, err := db.Query(ctx, `SELECT tag FROM client_tags WHERE client_id=$1 ORDER BY tag`, clientID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []string{}
+    for rows.Next() {
+        var t string
+        if err := rows.Scan(&t); err != nil {
+            return nil, err
+        }
+        out = append(out, t)
+    }
+    return out, rows.Err()
+}
+
+// ClientTag is a tag with its attribution.
+type ClientTag struct {
+    Tag       string    `json:"tag"`
+    CreatedBy *string   `json:"created_by"` // nil = assistant
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// ListClientTagDetails returns the tags of a client with who added them, in alphabetical order.
+func ListClientTagDetails(ctx context.Context, db DB, clientID int64) ([]ClientTag, error) {
+    rows, err := db.Query(ctx, `SELECT tag, created_by, created_at FROM client_tags WHERE client_id=$1 ORDER BY tag`, clientID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []ClientTag{}
+    for rows.Next() {
+        var t ClientTag
+        if err := rows.Scan(&t.Tag, &t.CreatedBy, &t.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, t)
+    }
+    return out, rows.Err()
+}
+
+// ClientTagsByPhone returns the tags of the client with the phone in the tenant
+// of ctx (none when the client is not known yet).
+func ClientTagsByPhone(ctx context.Context, db DB, phone string) ([]string, error) {
+    rows, err := db.Query(ctx, `
+        SELECT t.tag FROM client_tags t JOIN clients c ON c.id = t.client_id
+        WHERE c.phone=$1 AND COALESCE(c.tenant_id, 0)=$2 ORDER BY t.tag
+    `, phone, tenantArg(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []string{}
+    for rows.Next() {
+        var t string
+        if err := rows.Scan(&t); err != nil {
+            return nil, err
+        }
+        out = append(out, t)
+    }
+    return out, rows.Err()
+}
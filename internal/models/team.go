@@ -0,0 +1,71 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// TeamNotification is a notice for the team group, one per bus event.
+type TeamNotification struct {
+    ID        int64      `json:"id"`
+    Topic     string     `json:"topic"`
+    Text      string     `json:"text"`
+    Status    string     `json:"status"` // pending | sent | failed
+    Attempts  int        `json:"attempts"`
+    Error     string     `json:"error,omitempty"`
+    CreatedAt time.Time  `json:"created_at"`
+    SentAt    *time.Time `json:"sent_at,omitempty"`
+}
+
+// teamNotifyMaxAttempts is how many failed sends mark a notice as failed.
+const teamNotifyMaxAttempts = 3
+
+const teamNotificationColumns = "id, topic, text, status, attempts, error, created_at, sent_at"
+
+func scanTeamNotification(row pgx.Row, n *TeamNotification) error {
+    return row.Scan(&n.ID, &n.Topic, &n.Text, &n.Status, &n.Attempts, &n.Error, &n.CreatedAt, &n.SentAt)
+}
+
+// ClaimTeamNotification records the notice of an event in the tenant of ctx.
+// ok is false when another replica already recorded the same key.
+func ClaimTeamNotification(ctx context.Context, db DB, key, topic, text string) (id int64, ok bool, err error) {
+    err = db.QueryRow(ctx, `
+        INSERT INTO team_notifications (tenant_id, event_key, topic, text)
+        VALUES (NULLIF($1, 0), $2, $3, $4)
+        ON CONFLICT (event_key) DO NOTHING
+        RETURNING id
+    `, tenantArg(ctx), key, topic, text).Scan(&id)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return 0, false, nil
+    }
+    return id, err == nil, err
+}
+
+// PendingTeamNotifications returns the unsent notices of the tenant of ctx, oldest first.
+func PendingTeamNotifications(ctx context.Context, db DB, limit int) ([]TeamNotification, error) {
+    return Query[TeamNotification]{Scan: scanTeamNotification, SQL: `
+        SELECT ` + teamNotificationColumns + ` FROM team_notifications
+        WHERE COALESCE(tenant_id, 0)=$1 AND status='pending'
+        ORDER BY id LIMIT $2`}.All(ctx, db, tenantArg(ctx), limit)
+}
+
+// FinishTeamNotifications marks the notices as sent, or counts a failed attempt
+// (failed after teamNotifyMaxAttempts).
+func FinishTeamNotifications(ctx context.Context, db DB, ids []int64, sendErr error) error {
+    if sendErr == nil {
+        _, err := db.Exec(ctx, `
+            UPDATE team_notifications SET status='sent', sent_at=now(), error='' WHERE id = ANY($1)
+        `, ids)
+        return err
+    }
+    _, err := db.Exec(ctx, `
+        UPDATE team_notifications
+        SET attempts = attempts + 1, error = $2,
+            status = CASE WHEN attempts + 1 >= $3 THEN 'failed' ELSE 'pending' END
+        WHERE id = ANY($1)
+    `, ids, sendErr.Error(), teamNotifyMaxAttempts)
+    return err
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// Tenant is a business served by the same deployment, with its own credentials.
+// Empty credentials fall back to the env config. Secrets are never serialized.
+type Tenant struct {
+    ID                  int64     `json:"id"`
+    Slug                string    `json:"slug"`
+    Name                string    `json:"name"`
+    Token               string    `json:"-"`
+    OpenAIAPIKey        string    `json:"-"`
+    OpenAIAssistantID   string    `json:"openai_assistant_id,omitempty"`
+    UazapiBaseSend      string    `json:"uazapi_base_send,omitempty"`
+    UazapiTokenSend     string    `json:"-"`
+    UazapiBaseDownload  string    `json:"uazapi_base_download,omitempty"`
+    UazapiTokenDownload string    `json:"-"`
+    Active              bool      `json:"active"`
+    CreatedAt           time.Time `json:"created_at"`
+    UpdatedAt           time.Time `json:"updated_at"`
+}
+
+const tenantColumns = `id, slug, name, COALESCE(token,''), COALESCE(openai_api_key,''), COALESCE(openai_assistant_id,''),
+    COALESCE(uazapi_base_send,''), COALESCE(uazapi_token_send,''), COALESCE(uazapi_base_download,''),
+    COALESCE(uazapi_token_download,''), active, created_at, updated_at`
+
+func scanTenant(row pgx.Row) (Tenant, error) {
+    var t Tenant
+    err := row.Scan(&t.ID, &t.Slug, &t.Name, &t.Token, &t.OpenAIAPIKey, &t.OpenAIAssistantID,
+        &t.UazapiBaseSend, &t.UazapiTokenSend, &t.UazapiBaseDownload, &t.UazapiTokenDownload,
+        &t.Active, &t.CreatedAt, &t.UpdatedAt)
+    return t, err
+}
+
+func getTenant(ctx context.Context, db DB, where string, arg any) (Tenant, bool, error) {
+    t, err := scanTenant(db.QueryRow(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE `+where, arg))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return Tenant{}, false, nil
+    }
+    if err != nil {
+        return Tenant{}, false, err
+    }
+    return t, true, nil
+}
+
+// GetTenant returns the tenant with the given id.
+func GetTenant(ctx context.Context, db DB, id int64) (Tenant, bool, error) {
+    return getTenant(ctx, db, `id=$1`, id)
+}
+
+// GetTenantBySlug returns the tenant with the given slug.
+func GetTenantBySlug(ctx context.Context, db DB, slug string) (Tenant, bool, error) {
+    return getTenant(ctx, db, `slug=$1`, slug)
+}
+
+// GetTenantByToken returns the tenant that owns the given token.
+func GetTenantByToken(ctx context.Context, db DB, token string) (Tenant, bool, error) {
+    if token == "" {
+        return Tenant{}, false, nil
+    }
+    return getTenant(ctx, db, `token=$1`, token)
+}
+
+// ListTenants returns all tenants ordered by slug.
+func ListTenants(ctx context.Context, db DB) ([]Tenant, error) {
+    rows, err := db.Query(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY slug`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []Tenant{}
+    for rows.Next() {
+        t, err := scanTenant(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, t)
+    }
+    return out, rows.Err()
+}
+
+// UpsertTenant creates the tenant or updates the one with the same slug. Empty
+// secrets keep the stored value, so the API never has to echo them back.
+func UpsertTenant(ctx context.Context, db DB, t Tenant) (Tenant, error) {
+    return scanTenant(db.QueryRow(ctx, `
+        INSERT INTO tenants (slug, name, token, openai_api_key, openai_assistant_id, uazapi_base_send,
+                             uazapi_token_send, uazapi_base_download, uazapi_token_download, active)
+        VALUES ($1,$2,NULLIF($3,''),NULLIF($4,''),NULLIF($5,''),NULLIF($6,''),NULLIF($7,''),NULLIF($8,''),NULLIF($9,''),$10)
+        ON CONFLICT (slug) DO UPDATE SET
+          name = EXCLUDED.name,
+          token = COALESCE(EXCLUDED.token, tenants.token),
+          openai_api_key = COALESCE(EXCLUDED.openai_api_key, tenants.openai_api_key),
+          openai_assistant_id = EXCLUDED.openai_assistant_id,
+          uazapi_base_send = EXCLUDED.uazapi_base_send,
+          uazapi_token_send = COALESCE(EXCLUDED.uazapi_token_send, tenants.uazapi_token_send),
+          uazapi_base_download = EXCLUDED.uazapi_base_download,
+          uazapi_token_download = COALESCE(EXCLUDED.uazapi_token_download, tenants.uazapi_token_download),
+          active = EXCLUDED.active,
+          updated_at = now()
+        RETURNING `+tenantColumns,
+        t.Slug, t.Name, t.Token, t.OpenAIAPIKey, t.OpenAIAssistantID, t.UazapiBaseSend,
+        t.UazapiTokenSend, t.UazapiBaseDownload, t.UazapiTokenDownload, t.Active))
+}
+
+type tenantKey struct{}
+
+// WithTenant scopes model calls made with ctx to a tenant (0 = default tenant).
+func WithTenant(ctx context.Context, tenantID int64) context.Context {
+    return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFrom returns the tenant ctx is scoped to. ok is false for unscoped
+// contexts (e.g. the root admin), which lookups by phone treat as the default tenant.
+func TenantFrom(ctx context.Context) (int64, bool) {
+    id, ok := ctx.Value(tenantKey{}).(int64)
+    return id, ok
+}
+
+// tenantArg returns the tenant of ctx as a query argument (0 = default tenant).
+func tenantArg(ctx context.Context) int64 {
+    id, _ := TenantFrom(ctx)
+    return id
+}
+
+// tenantFilter returns the tenant for list queries, or -1 when ctx is unscoped.
+func tenantFilter(ctx context.Context) int64 {
+    if id, ok := TenantFrom(ctx); ok {
+        return id
+    }
+    return -1
+}
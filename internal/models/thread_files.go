@@ -0,0 +1,64 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// ThreadFile is a client document uploaded to OpenAI Files for file_search.
+type ThreadFile struct {
+    ID       int64
+    ClientID *int64
+    FileID   string
+    Filename string
+}
+
+// InsertThreadFile records an uploaded file that must be deleted after expiresAt.
+func InsertThreadFile(ctx context.Context, db DB, clientID int64, fileID, filename string, size int, expiresAt time.Time) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO thread_files (client_id, tenant_id, file_id, filename, bytes, expires_at)
+        VALUES ($1, (SELECT tenant_id FROM clients WHERE id=$1), $2, $3, $4, $5)
+    `, clientID, fileID, filename, size, expiresAt)
+    return err
+}
+
+// MarkThreadFilesAttached records that the files were attached to a thread message.
+func MarkThreadFilesAttached(ctx context.Context, db DB, fileIDs []string) error {
+    _, err := db.Exec(ctx, `UPDATE thread_files SET attached_at=now() WHERE file_id = ANY($1)`, fileIDs)
+    return err
+}
+
+// MarkThreadFileDeleted records that the file was deleted on OpenAI.
+func MarkThreadFileDeleted(ctx context.Context, db DB, fileID string) error {
+    _, err := db.Exec(ctx, `UPDATE thread_files SET deleted_at=now() WHERE file_id=$1`, fileID)
+    return err
+}
+
+func listThreadFiles(ctx context.Context, db DB, where string, args ...any) ([]ThreadFile, error) {
+    rows, err := db.Query(ctx, `
+        SELECT id, client_id, file_id, filename FROM thread_files
+        WHERE deleted_at IS NULL AND `+where, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []ThreadFile
+    for rows.Next() {
+        var f ThreadFile
+        if err := rows.Scan(&f.ID, &f.ClientID, &f.FileID, &f.Filename); err != nil {
+            return nil, err
+        }
+        out = append(out, f)
+    }
+    return out, rows.Err()
+}
+
+// ListExpiredThreadFiles returns files of the tenant of ctx past their expiry.
+func ListExpiredThreadFiles(ctx context.Context, db DB, limit int) ([]ThreadFile, error) {
+    return listThreadFiles(ctx, db, `expires_at < now() AND COALESCE(tenant_id, 0) = $1 ORDER BY expires_at LIMIT $2`, tenantArg(ctx), limit)
+}
+
+// ListClientThreadFiles returns the live files of a client (thread or client purge).
+func ListClientThreadFiles(ctx context.Context, db DB, clientID int64) ([]ThreadFile, error) {
+    return listThreadFiles(ctx, db, `client_id = $1`, clientID)
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// DefaultAssistant is the assistant name used when a client has none set.
+const DefaultAssistant = "default"
+
+// Transfer is an audit record of a conversation moved between assistants.
+type Transfer struct {
+    ID            int64     `json:"id"`
+    ClientID      int64     `json:"client_id"`
+    FromAssistant string    `json:"from_assistant"`
+    ToAssistant   string    `json:"to_assistant"`
+    ThreadID      string    `json:"thread_id"`
+    Summary       string    `json:"summary"`
+    Reason        string    `json:"reason"`
+    Actor         string    `json:"actor"`
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+// GetClientAssistant returns the name of the assistant currently serving the client.
+func GetClientAssistant(ctx context.Context, db DB, clientID int64) (string, error) {
+    var name *string
+    if err := db.QueryRow(ctx, `SELECT assistant FROM clients WHERE id=$1`, clientID).Scan(&name); err != nil {
+        return "", err
+    }
+    if name == nil || *name == "" {
+        return DefaultAssistant, nil
+    }
+    return *name, nil
+}
+
+// SetClientAssistant sets the assistant serving the client.
+func SetClientAssistant(ctx context.Context, db DB, clientID int64, assistant string) error {
+    _, err := db.Exec(ctx, `UPDATE clients SET assistant=$1 WHERE id=$2`, assistant, clientID)
+    return err
+}
+
+// GetAssistantThread returns the thread kept for the client with the given assistant.
+func GetAssistantThread(ctx context.Context, db DB, clientID int64, assistant string) (string, bool, error) {
+    var tid string
+    err := db.QueryRow(ctx, `
+        SELECT thread_id FROM client_threads WHERE client_id=$1 AND assistant=$2
+    `, clientID, assistant).Scan(&tid)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return "", false, nil
+    }
+    return tid, err == nil, err
+}
+
+// SaveAssistantThread remembers the client's thread with an assistant.
+func SaveAssistantThread(ctx context.Context, db DB, clientID int64, assistant, threadID string) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO client_threads (client_id, assistant, thread_id) VALUES ($1,$2,$3)
+        ON CONFLICT (client_id, assistant) DO UPDATE SET thread_id=EXCLUDED.thread_id, updated_at=now()
+    `, clientID, assistant, threadID)
+    return err
+}
+
+// RecordTransfer appends a transfer to the audit trail.
+func RecordTransfer(ctx context.Context, db DB, t Transfer) (Transfer, error) {
+    err := db.QueryRow(ctx, `
+        INSERT INTO assistant_transfers (client_id, from_assistant, to_assistant, thread_id, summary, reason, actor)
+        VALUES ($1,$2,$3,$4,$5,$6,$7)
+        RETURNING id, created_at
+    `, t.ClientID, t.FromAssistant, t.ToAssistant, t.ThreadID, t.Summary, t.Reason, t.Actor).Scan(&t.ID, &t.CreatedAt)
+    return t, err
+}
+
+// ListTransfers returns the transfers of a client, newest first.
+func ListTransfers(ctx context.Context, db DB, clientID int64) ([]Transfer, error) {
+    rows, err := db.Query(ctx, `
+        SELECT id, client_id, from_assistant, to_assistant, thread_id, summary, reason, actor, created_at
+        FROM assistant_transfers WHERE client_id=$1 ORDER BY created_at DESC
+    `, clientID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    out := []Transfer{}
+    for rows.Next() {
+        var t Transfer
+        if err := rows.Scan(&t.ID, &t.ClientID, &t.FromAssistant, &t.ToAssistant, &t.ThreadID,
+            &t.Summary, &t.Reason, &t.Actor, &t.CreatedAt); err != nil {
+            return nil, err
+        }
+        out = append(out, t)
+    }
+    return out, rows.Err()
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+)
+
+// GetCachedSpeech returns cached TTS audio for key if it is younger than ttl.
+// The second return value is false on a cache miss.
+func GetCachedSpeech(ctx context.Context, db DB, key string, ttl time.Duration) ([]byte, bool, error) {
+    var audio []byte
+    err := db.QueryRow(ctx, `
+        UPDATE tts_cache SET hits = hits + 1
+        WHERE key=$1 AND created_at > $2
+        RETURNING audio
+    `, key, time.Now().Add(-ttl)).Scan(&audio)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, false, nil
+    }
+    if err != nil {
+        return nil, false, err
+    }
+    return audio, true, nil
+}
+
+// PutCachedSpeech stores (or refreshes) generated TTS audio under key.
+func PutCachedSpeech(ctx context.Context, db DB, key, voice string, speed float64, audio []byte) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO tts_cache (key, voice, speed, audio)
+        VALUES ($1,$2,$3,$4)
+        ON CONFLICT (key) DO UPDATE SET audio=EXCLUDED.audio, created_at=now(), hits=0
+    `, key, voice, speed, audio)
+    return err
+}
+
+// PurgeSpeechCache removes expired entries and entries generated with a voice/speed
+// other than the current configuration. Returns the number of rows removed.
+func PurgeSpeechCache(ctx context.Context, db DB, voice string, speed float64, ttl time.Duration) (int64, error) {
+    ct, err := db.Exec(ctx, `
+        DELETE FROM tts_cache WHERE voice<>$1 OR speed<>$2 OR created_at <= $3
+    `, voice, speed, time.Now().Add(-ttl))
+    if err != nil {
+        return 0, err
+    }
+    return ct.RowsAffected(), nil
+}
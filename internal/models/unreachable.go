@@ -0,0 +1,22 @@
+package models
+
+import (
+    "context"
+)
+
+// MarkClientUnreachable flags the client of the tenant of ctx with that phone as
+// not on WhatsApp, so proactive jobs skip it. Reports whether a client was flagged
+// now (false when unknown or already flagged).
+func MarkClientUnreachable(ctx context.Context, db DB, phone string) (bool, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE clients SET unreachable_at = now()
+        WHERE phone=$1 AND COALESCE(tenant_id, 0)=$2 AND unreachable_at IS NULL
+    `, phone, tenantArg(ctx))
+    return tag.RowsAffected() > 0, err
+}
+
+// ClearClientUnreachable removes the flag (the client wrote to us, so the number works).
+func ClearClientUnreachable(ctx context.Context, db DB, clientID int64) error {
+    _, err := db.Exec(ctx, `UPDATE clients SET unreachable_at = NULL WHERE id=$1 AND unreachable_at IS NOT NULL`, clientID)
+    return err
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+    "context"
+    "time"
+)
+
+// Usage is the token consumption of one OpenAI call.
+type Usage struct {
+    ClientID         *int64
+    Model            string
+    PromptTokens     int64
+    CompletionTokens int64
+    TotalTokens      int64
+    CostUSD          float64
+}
+
+// UsageTotals aggregates consumption over a period.
+type UsageTotals struct {
+    Tokens  int64   `json:"tokens"`
+    CostUSD float64 `json:"cost_usd"`
+}
+
+// RecordUsage stores the usage of one OpenAI call, tagged with the experiment variant of ctx.
+func RecordUsage(ctx context.Context, db DB, u Usage) error {
+    _, err := db.Exec(ctx, `
+        INSERT INTO openai_usage (client_id, model, prompt_tokens, completion_tokens, total_tokens, cost_usd, variant)
+        VALUES ($1,$2,$3,$4,$5,$6,NULLIF($7,''))
+    `, u.ClientID, u.Model, u.PromptTokens, u.CompletionTokens, u.TotalTokens, u.CostUSD, VariantFrom(ctx))
+    return err
+}
+
+// UsageSince returns the totals since day and since month (day >= month), for all
+// clients when clientID is nil.
+func UsageSince(ctx context.Context, db DB, clientID *int64, day, month time.Time) (UsageTotals, UsageTotals, error) {
+    var d, m UsageTotals
+    err := db.QueryRow(ctx, `
+        SELECT COALESCE(SUM(total_tokens) FILTER (WHERE created_at >= $2), 0),
+               COALESCE(SUM(cost_usd)     FILTER (WHERE created_at >= $2), 0),
+               COALESCE(SUM(total_tokens), 0),
+               COALESCE(SUM(cost_usd), 0)
+        FROM openai_usage
+        WHERE created_at >= $3 AND ($1::bigint IS NULL OR client_id = $1)
+    `, clientID, day, month).Scan(&d.Tokens, &d.CostUSD, &m.Tokens, &m.CostUSD)
+    return d, m, err
+}
@@ -0,0 +1,27 @@
+package models
+
+import "context"
+
+// InsertMessageID is InsertMessage returning the id of the new row.
+func InsertMessageID(ctx context.Context, db DB, m Message) (int64, error) {
+    var id int64
+    err := db.QueryRow(ctx, `
+        INSERT INTO messages (client_id, role, type, content, ext_id, ephemeral, view_once, forwarded, provider_at, variant, tenant_id, content_original, language, content_translated)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,NULLIF($10,''),(SELECT tenant_id FROM clients WHERE id=$1),$11,NULLIF($12,''),$13)
+        RETURNING id
+    `, m.ClientID, m.Role, m.Type, m.Content, m.ExtID, m.Ephemeral, m.ViewOnce, m.Forwarded, m.ProviderAt, VariantFrom(ctx), m.Original, m.Language, m.Translated).Scan(&id)
+    return id, err
+}
+
+// MessageByExtID returns the message of the client with the given ext_id. ok is
+// false when there is none.
+func MessageByExtID(ctx context.Context, db DB, clientID int64, extID string) (Message, bool, error) {
+    return messageByExtIDQuery.One(ctx, db, clientID, extID)
+}
+
+// ConversationTail returns the last limit messages of the conversation visible to
+// the client (user, assistant and operator roles; failed sends are left out),
+// oldest first.
+func ConversationTail(ctx context.Context, db DB, clientID int64, limit int) ([]Message, error) {
+    return conversationTailQuery.All(ctx, db, clientID, limit)
+}
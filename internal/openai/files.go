@@ -0,0 +1,61 @@
+package openai
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "mime/multipart"
+    "net/http"
+)
+
+// UploadFile uploads a file for the assistants (purpose "assistants") and returns its id.
+func (c *Client) UploadFile(ctx context.Context, data []byte, filename string) (string, error) {
+    var b bytes.Buffer
+    w := multipart.NewWriter(&b)
+    _ = w.WriteField("purpose", "assistants")
+    fw, err := w.CreateFormFile("file", filename)
+    if err != nil {
+        return "", err
+    }
+    if _, err := fw.Write(data); err != nil {
+        return "", err
+    }
+    w.Close()
+
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/files", &b)
+    req.Header.Set("Content-Type", w.FormDataContentType())
+    resp, err := c.do(req)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        bb, _ := io.ReadAll(resp.Body)
+        return "", fmt.Errorf("upload file status %d: %s", resp.StatusCode, string(bb))
+    }
+    var f struct{ ID string `json:"id"` }
+    if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+        return "", err
+    }
+    c.fileNames.Store(f.ID, filename)
+    return f.ID, nil
+}
+
+// DeleteFile deletes an uploaded file. A 404 is treated as success since the
+// file is already gone.
+func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
+    req, _ := http.NewRequestWithContext(ctx, "DELETE", c.BaseURL+"/files/"+fileID, nil)
+    resp, err := c.do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 && resp.StatusCode != http.StatusNotFound {
+        b, _ := io.ReadAll(resp.Body)
+        return fmt.Errorf("delete file status %d: %s", resp.StatusCode, string(b))
+    }
+    c.fileNames.Delete(fileID)
+    return nil
+}
@@ -12,6 +12,7 @@ import (
     "os"
     "os/exec"
     "strings"
+    "sync"
     "time"
 )
 
@@ -19,40 +20,56 @@ import (
 // summarisation and text-to-speech. Fields like TTSVoice and TTSSpeed can be
 // configured via Config.
 type Client struct {
-    apiKey          string
-    assistantID     string
-    chatModel       string
-    transcribeModel string
-    http            *http.Client
+    opts RequestOptions // defaults; see WithOptions and WithRequestOptions
+    http *http.Client   // shared by clients derived with WithOptions
 
     TTSVoice string
     TTSSpeed float64
+
+    // MemoryModel is the (cheap) chat model used to extract client facts.
+    // Falls back to chatModel when empty.
+    MemoryModel string
+
+    // BaseURL is the API root, without trailing slash. Override it to point the
+    // client at a proxy or a fake upstream (e.g. cmd/loadtest).
+    BaseURL string
+
+    fileNames *sync.Map // file_id -> filename (FileName)
 }
 
 // New returns a new Client. Caller should set TTSVoice and TTSSpeed on the
 // returned instance if they differ from defaults.
 func New(apiKey, assistantID, chatModel, transcribeModel string) *Client {
     return &Client{
-        apiKey:          apiKey,
-        assistantID:     assistantID,
-        chatModel:       chatModel,
-        transcribeModel: transcribeModel,
-        http:            &http.Client{Timeout: 60 * time.Second},
-        TTSVoice:        "onyx",
-        TTSSpeed:        1.0,
+        opts: RequestOptions{
+            APIKey:          apiKey,
+            AssistantID:     assistantID,
+            ChatModel:       chatModel,
+            TranscribeModel: transcribeModel,
+        },
+        http:      &http.Client{Timeout: 60 * time.Second},
+        TTSVoice:  "onyx",
+        TTSSpeed:  1.0,
+        BaseURL:   "https://api.openai.com/v1",
+        fileNames: &sync.Map{},
     }
 }
 
-// do sends the HTTP request with authentication header. The caller must set
-// appropriate Content-Type if not JSON.
+// SetTransport replaces the HTTP transport, e.g. to capture requests for debugging.
+func (c *Client) SetTransport(t http.RoundTripper) {
+    c.http.Transport = t
+}
+
+// do sends the HTTP request with the authentication header of the request's
+// options. The caller must set appropriate Content-Type if not JSON.
 func (c *Client) do(req *http.Request) (*http.Response, error) {
-    req.Header.Set("Authorization", "Bearer "+c.apiKey)
+    req.Header.Set("Authorization", "Bearer "+c.options(req.Context()).APIKey)
     return c.http.Do(req)
 }
 
 // CreateThread creates a new empty thread for assistants v2.
 func (c *Client) CreateThread(ctx context.Context) (string, error) {
-    req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/threads", bytes.NewReader([]byte(`{}`)))
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/threads", bytes.NewReader([]byte(`{}`)))
     req.Header.Set("OpenAI-Beta", "assistants=v2")
     req.Header.Set("Content-Type", "application/json")
     resp, err := c.do(req)
@@ -71,14 +88,58 @@ func (c *Client) CreateThread(ctx context.Context) (string, error) {
     return tr.ID, nil
 }
 
+// DeleteThread deletes a thread (and its messages) on OpenAI. A 404 is treated
+// as success since the thread is already gone.
+func (c *Client) DeleteThread(ctx context.Context, threadID string) error {
+    req, _ := http.NewRequestWithContext(ctx, "DELETE", c.BaseURL+"/threads/"+threadID, nil)
+    req.Header.Set("OpenAI-Beta", "assistants=v2")
+    resp, err := c.do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 && resp.StatusCode != http.StatusNotFound {
+        b, _ := io.ReadAll(resp.Body)
+        return fmt.Errorf("delete thread status %d: %s", resp.StatusCode, string(b))
+    }
+    return nil
+}
+
 // AddUserMessage appends a user message with plain text to a thread.
 func (c *Client) AddUserMessage(ctx context.Context, threadID string, text string) error {
+    return c.addMessage(ctx, threadID, "user", text)
+}
+
+// AddAssistantMessage appends an assistant message to the thread, e.g. a proactive
+// message sent outside a run, so the assistant sees it as context later.
+func (c *Client) AddAssistantMessage(ctx context.Context, threadID string, text string) error {
+    return c.addMessage(ctx, threadID, "assistant", text)
+}
+
+// AddUserMessageWithFiles appends a user message with uploaded files attached for
+// file_search: OpenAI indexes them in the thread's vector store, so the assistant can
+// search them in every later run of the thread (the assistant needs file_search enabled).
+func (c *Client) AddUserMessageWithFiles(ctx context.Context, threadID, text string, fileIDs []string) error {
+    return c.addMessage(ctx, threadID, "user", text, fileIDs...)
+}
+
+func (c *Client) addMessage(ctx context.Context, threadID, role, text string, fileIDs ...string) error {
     body := map[string]any{
-        "role":    "user",
+        "role":    role,
         "content": []map[string]string{{"type": "text", "text": text}},
     }
+    if len(fileIDs) > 0 {
+        attachments := make([]map[string]any, 0, len(fileIDs))
+        for _, id := range fileIDs {
+            attachments = append(attachments, map[string]any{
+                "file_id": id,
+                "tools":   []map[string]string{{"type": "file_search"}},
+            })
+        }
+        body["attachments"] = attachments
+    }
     buf, _ := json.Marshal(body)
-    u := fmt.Sprintf("https://api.openai.com/v1/threads/%s/messages", threadID)
+    u := fmt.Sprintf("%s/threads/%s/messages", c.BaseURL, threadID)
     req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(buf))
     req.Header.Set("OpenAI-Beta", "assistants=v2")
     req.Header.Set("Content-Type", "application/json")
@@ -96,9 +157,33 @@ func (c *Client) AddUserMessage(ctx context.Context, threadID string, text strin
 
 // CreateRun creates a run for a given thread.
 func (c *Client) CreateRun(ctx context.Context, threadID string) (string, error) {
-    body := map[string]any{ "assistant_id": c.assistantID }
+    return c.CreateRunWithInstructions(ctx, threadID, "")
+}
+
+// CreateRunWithInstructions creates a run passing additional_instructions, which
+// are appended to the assistant instructions for this run only.
+func (c *Client) CreateRunWithInstructions(ctx context.Context, threadID, additional string) (string, error) {
+    return c.CreateRunForAssistant(ctx, threadID, "", "", additional)
+}
+
+// CreateRunForAssistant is CreateRunWithInstructions with an explicit assistant and
+// model override. An empty assistantID uses the client's default assistant and an
+// empty model keeps the assistant's own model. The RunParams of the request
+// options (see WithRequestOptions) are sent along.
+func (c *Client) CreateRunForAssistant(ctx context.Context, threadID, assistantID, model, additional string) (string, error) {
+    if assistantID == "" {
+        assistantID = c.options(ctx).AssistantID
+    }
+    body := map[string]any{ "assistant_id": assistantID }
+    if model != "" {
+        body["model"] = model
+    }
+    if strings.TrimSpace(additional) != "" {
+        body["additional_instructions"] = additional
+    }
+    c.options(ctx).Run.apply(body)
     buf, _ := json.Marshal(body)
-    u := fmt.Sprintf("https://api.openai.com/v1/threads/%s/runs", threadID)
+    u := fmt.Sprintf("%s/threads/%s/runs", c.BaseURL, threadID)
     req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(buf))
     req.Header.Set("OpenAI-Beta", "assistants=v2")
     req.Header.Set("Content-Type", "application/json")
@@ -118,67 +203,293 @@ func (c *Client) CreateRun(ctx context.Context, threadID string) (string, error)
     return rr.ID, nil
 }
 
+// ToolCall is a function call requested by a run in status "requires_action".
+type ToolCall struct {
+    ID       string `json:"id"`
+    Type     string `json:"type"`
+    Function struct {
+        Name      string `json:"name"`
+        Arguments string `json:"arguments"`
+    } `json:"function"`
+}
+
+// Usage is the token usage reported for a run or completion.
+type Usage struct {
+    PromptTokens     int64 `json:"prompt_tokens"`
+    CompletionTokens int64 `json:"completion_tokens"`
+    TotalTokens      int64 `json:"total_tokens"`
+}
+
+// Run is the subset of the run object used by the handler.
+type Run struct {
+    ID             string `json:"id"`
+    Status         string `json:"status"`
+    Model          string `json:"model"`
+    Usage          *Usage `json:"usage,omitempty"` // set once the run is terminal
+    RequiredAction *struct {
+        SubmitToolOutputs struct {
+            ToolCalls []ToolCall `json:"tool_calls"`
+        } `json:"submit_tool_outputs"`
+    } `json:"required_action,omitempty"`
+}
+
+// ToolCalls returns the pending tool calls when the run requires action.
+func (r Run) ToolCalls() []ToolCall {
+    if r.RequiredAction == nil {
+        return nil
+    }
+    return r.RequiredAction.SubmitToolOutputs.ToolCalls
+}
+
 // GetRun returns the run status.
 func (c *Client) GetRun(ctx context.Context, threadID, runID string) (string, error) {
-    u := fmt.Sprintf("https://api.openai.com/v1/threads/%s/runs/%s", threadID, runID)
+    r, err := c.GetRunDetails(ctx, threadID, runID)
+    return r.Status, err
+}
+
+// GetRunDetails returns the run including any required action (tool calls).
+func (c *Client) GetRunDetails(ctx context.Context, threadID, runID string) (Run, error) {
+    u := fmt.Sprintf("%s/threads/%s/runs/%s", c.BaseURL, threadID, runID)
     req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
     req.Header.Set("OpenAI-Beta", "assistants=v2")
     resp, err := c.do(req)
     if err != nil {
-        return "", err
+        return Run{}, err
     }
     defer resp.Body.Close()
     if resp.StatusCode > 299 {
         b, _ := io.ReadAll(resp.Body)
-        return "", fmt.Errorf("get run status %d: %s", resp.StatusCode, string(b))
+        return Run{}, fmt.Errorf("get run status %d: %s", resp.StatusCode, string(b))
     }
-    var rs struct{ ID string `json:"id"`; Status string `json:"status"` }
+    var rs Run
     if err := json.NewDecoder(resp.Body).Decode(&rs); err != nil {
-        return "", err
+        return Run{}, err
+    }
+    return rs, nil
+}
+
+// CancelRun asks OpenAI to cancel an in-progress run. The run moves to
+// "cancelling" and then "cancelled"; poll GetRun before adding messages to the thread.
+func (c *Client) CancelRun(ctx context.Context, threadID, runID string) error {
+    u := fmt.Sprintf("%s/threads/%s/runs/%s/cancel", c.BaseURL, threadID, runID)
+    req, _ := http.NewRequestWithContext(ctx, "POST", u, nil)
+    req.Header.Set("OpenAI-Beta", "assistants=v2")
+    resp, err := c.do(req)
+    if err != nil {
+        return err
     }
-    return rs.Status, nil
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        b, _ := io.ReadAll(resp.Body)
+        return fmt.Errorf("cancel run status %d: %s", resp.StatusCode, string(b))
+    }
+    return nil
+}
+
+// ToolOutput is the result of one tool call, submitted back to the run.
+type ToolOutput struct {
+    ToolCallID string `json:"tool_call_id"`
+    Output     string `json:"output"`
+}
+
+// SubmitToolOutputs sends tool results so a run in "requires_action" can continue.
+func (c *Client) SubmitToolOutputs(ctx context.Context, threadID, runID string, outputs []ToolOutput) error {
+    buf, _ := json.Marshal(map[string]any{"tool_outputs": outputs})
+    u := fmt.Sprintf("%s/threads/%s/runs/%s/submit_tool_outputs", c.BaseURL, threadID, runID)
+    req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(buf))
+    req.Header.Set("OpenAI-Beta", "assistants=v2")
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := c.do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        b, _ := io.ReadAll(resp.Body)
+        return fmt.Errorf("submit tool outputs status %d: %s", resp.StatusCode, string(b))
+    }
+    return nil
+}
+
+// FunctionTool is a function definition registered on the assistant.
+type FunctionTool struct {
+    Name        string         `json:"name"`
+    Description string         `json:"description"`
+    Parameters  map[string]any `json:"parameters"`
+}
+
+// EnsureAssistantTools merges the given function tools into the assistant's tool
+// list (replacing functions with the same name, keeping file_search/code_interpreter).
+func (c *Client) EnsureAssistantTools(ctx context.Context, fns []FunctionTool) error {
+    u := c.BaseURL + "/assistants/" + c.options(ctx).AssistantID
+    req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+    req.Header.Set("OpenAI-Beta", "assistants=v2")
+    resp, err := c.do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        b, _ := io.ReadAll(resp.Body)
+        return fmt.Errorf("get assistant status %d: %s", resp.StatusCode, string(b))
+    }
+    var asst struct {
+        Tools []map[string]any `json:"tools"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&asst); err != nil {
+        return err
+    }
+
+    ours := make(map[string]bool, len(fns))
+    for _, f := range fns {
+        ours[f.Name] = true
+    }
+    tools := make([]any, 0, len(asst.Tools)+len(fns))
+    for _, t := range asst.Tools {
+        if fn, ok := t["function"].(map[string]any); ok && ours[fmt.Sprint(fn["name"])] {
+            continue
+        }
+        tools = append(tools, t)
+    }
+    for _, f := range fns {
+        tools = append(tools, map[string]any{"type": "function", "function": f})
+    }
+
+    buf, _ := json.Marshal(map[string]any{"tools": tools})
+    req2, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(buf))
+    req2.Header.Set("OpenAI-Beta", "assistants=v2")
+    req2.Header.Set("Content-Type", "application/json")
+    resp2, err := c.do(req2)
+    if err != nil {
+        return err
+    }
+    defer resp2.Body.Close()
+    if resp2.StatusCode > 299 {
+        b, _ := io.ReadAll(resp2.Body)
+        return fmt.Errorf("update assistant status %d: %s", resp2.StatusCode, string(b))
+    }
+    return nil
+}
+
+// Annotation is a citation attached to a span of an assistant message (file search
+// or code interpreter). Text is the marker as it appears in the message, e.g. "【4:0†manual.pdf】".
+type Annotation struct {
+    Type         string `json:"type"` // file_citation | file_path
+    Text         string `json:"text"`
+    StartIndex   int    `json:"start_index"`
+    EndIndex     int    `json:"end_index"`
+    FileCitation *struct {
+        FileID string `json:"file_id"`
+    } `json:"file_citation,omitempty"`
+    FilePath *struct {
+        FileID string `json:"file_id"`
+    } `json:"file_path,omitempty"`
+}
+
+// FileID returns the file referenced by the annotation, if any.
+func (a Annotation) FileID() string {
+    switch {
+    case a.FileCitation != nil:
+        return a.FileCitation.FileID
+    case a.FilePath != nil:
+        return a.FilePath.FileID
+    }
+    return ""
 }
 
+// AssistantText is the text of an assistant message with its annotations.
+type AssistantText struct {
+    Value       string       `json:"value"`
+    Annotations []Annotation `json:"annotations"`
+}
+
+// ErrNoAssistantText means the latest thread message is not an assistant reply with text.
+var ErrNoAssistantText = errors.New("no assistant text found")
+
 // GetLastAssistantText fetches the most recent assistant message text from a thread.
 func (c *Client) GetLastAssistantText(ctx context.Context, threadID string) (string, error) {
-    u := fmt.Sprintf("https://api.openai.com/v1/threads/%s/messages?order=desc&limit=1", threadID)
+    t, err := c.GetLastAssistantMessage(ctx, threadID)
+    return t.Value, err
+}
+
+// GetLastAssistantMessage is GetLastAssistantText including the citation annotations.
+func (c *Client) GetLastAssistantMessage(ctx context.Context, threadID string) (AssistantText, error) {
+    u := fmt.Sprintf("%s/threads/%s/messages?order=desc&limit=1", c.BaseURL, threadID)
     req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
     req.Header.Set("OpenAI-Beta", "assistants=v2")
     resp, err := c.do(req)
     if err != nil {
-        return "", err
+        return AssistantText{}, err
     }
     defer resp.Body.Close()
     if resp.StatusCode > 299 {
         b, _ := io.ReadAll(resp.Body)
-        return "", fmt.Errorf("list messages status %d: %s", resp.StatusCode, string(b))
+        return AssistantText{}, fmt.Errorf("list messages status %d: %s", resp.StatusCode, string(b))
     }
     var lm struct {
         Data []struct{
+            Role    string `json:"role"`
             Content []struct{
                 Type string `json:"type"`
-                Text *struct{ Value string `json:"value"` } `json:"text,omitempty"`
+                Text *AssistantText `json:"text,omitempty"`
             } `json:"content"`
         } `json:"data"`
     }
     if err := json.NewDecoder(resp.Body).Decode(&lm); err != nil {
+        return AssistantText{}, err
+    }
+    // the run may finish without a new assistant message (the latest one is then
+    // the user's) or with only non-text blocks, e.g. an image file
+    if len(lm.Data) == 0 || lm.Data[0].Role != "assistant" {
+        return AssistantText{}, ErrNoAssistantText
+    }
+    for _, c := range lm.Data[0].Content {
+        if c.Type == "text" && c.Text != nil {
+            return *c.Text, nil
+        }
+    }
+    return AssistantText{}, ErrNoAssistantText
+}
+
+// FileName returns the filename of an uploaded file (GET /files/{id}). Names are
+// cached for the lifetime of the client.
+func (c *Client) FileName(ctx context.Context, fileID string) (string, error) {
+    if v, ok := c.fileNames.Load(fileID); ok {
+        return v.(string), nil
+    }
+    req, _ := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/files/"+fileID, nil)
+    resp, err := c.do(req)
+    if err != nil {
         return "", err
     }
-    if len(lm.Data) == 0 || len(lm.Data[0].Content) == 0 || lm.Data[0].Content[0].Text == nil {
-        return "", errors.New("no assistant text found")
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        b, _ := io.ReadAll(resp.Body)
+        return "", fmt.Errorf("get file status %d: %s", resp.StatusCode, string(b))
+    }
+    var f struct{ Filename string `json:"filename"` }
+    if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+        return "", err
     }
-    return lm.Data[0].Content[0].Text.Value, nil
+    c.fileNames.Store(fileID, f.Filename)
+    return f.Filename, nil
 }
 
 // VisionDescribe calls chat completions with an image URL to generate a description.
 func (c *Client) VisionDescribe(ctx context.Context, imageURL string) (string, error) {
+    return c.VisionAsk(ctx, imageURL, "Analise e descreva objetivamente a imagem:")
+}
+
+// VisionAsk calls chat completions with an image URL and a question about it
+// (e.g. a follow-up on a detail the first description left out).
+func (c *Client) VisionAsk(ctx context.Context, imageURL, question string) (string, error) {
     body := map[string]any{
-        "model": c.chatModel,
+        "model": c.options(ctx).ChatModel,
         "messages": []any{
             map[string]any{
                 "role": "user",
                 "content": []any{
-                    map[string]string{"type": "text", "text": "Analise e descreva objetivamente a imagem:"},
+                    map[string]string{"type": "text", "text": question},
                     map[string]any{"type": "image_url", "image_url": map[string]string{"url": imageURL}},
                 },
             },
@@ -186,7 +497,7 @@ func (c *Client) VisionDescribe(ctx context.Context, imageURL string) (string, e
         "max_tokens": 400,
     }
     buf, _ := json.Marshal(body)
-    req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewReader(buf))
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewReader(buf))
     req.Header.Set("Content-Type", "application/json")
     resp, err := c.do(req)
     if err != nil {
@@ -213,7 +524,7 @@ func (c *Client) VisionDescribe(ctx context.Context, imageURL string) (string, e
 func (c *Client) Transcribe(ctx context.Context, audio []byte, filename string) (string, error) {
     var b bytes.Buffer
     w := multipart.NewWriter(&b)
-    _ = w.WriteField("model", c.transcribeModel)
+    _ = w.WriteField("model", c.options(ctx).TranscribeModel)
     fw, err := w.CreateFormFile("file", filename)
     if err != nil {
         return "", err
@@ -223,10 +534,9 @@ func (c *Client) Transcribe(ctx context.Context, audio []byte, filename string)
     }
     w.Close()
 
-    req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/audio/transcriptions", &b)
-    req.Header.Set("Authorization", "Bearer "+c.apiKey)
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/audio/transcriptions", &b)
     req.Header.Set("Content-Type", w.FormDataContentType())
-    resp, err := c.http.Do(req)
+    resp, err := c.do(req)
     if err != nil {
         return "", err
     }
@@ -245,18 +555,23 @@ func (c *Client) Transcribe(ctx context.Context, audio []byte, filename string)
 // GenerateSpeech uses the OpenAI TTS endpoint to convert text to speech.
 // It returns the raw audio bytes (mp3 by default).
 func (c *Client) GenerateSpeech(ctx context.Context, text string) ([]byte, error) {
+    return c.GenerateSpeechWith(ctx, text, c.TTSVoice, c.TTSSpeed)
+}
+
+// GenerateSpeechWith is GenerateSpeech with an explicit voice and speed
+// (used for per-instance overrides).
+func (c *Client) GenerateSpeechWith(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
     body := map[string]any{
         "model":           "tts-1",
         "input":           text,
-        "voice":           c.TTSVoice,
-        "speed":           c.TTSSpeed,
+        "voice":           voice,
+        "speed":           speed,
         "response_format": "mp3",
     }
     buf, _ := json.Marshal(body)
-    req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/audio/speech", bytes.NewReader(buf))
-    req.Header.Set("Authorization", "Bearer "+c.apiKey)
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/audio/speech", bytes.NewReader(buf))
     req.Header.Set("Content-Type", "application/json")
-    resp, err := c.http.Do(req)
+    resp, err := c.do(req)
     if err != nil {
         return nil, err
     }
@@ -277,7 +592,7 @@ func (c *Client) SummarizeText(ctx context.Context, text string) (string, error)
         text = text[:maxInputLen]
     }
     body := map[string]any{
-        "model": c.chatModel,
+        "model": c.options(ctx).ChatModel,
         "messages": []any{
             map[string]string{
                 "role":    "system",
@@ -292,10 +607,9 @@ func (c *Client) SummarizeText(ctx context.Context, text string) (string, error)
         "temperature": 0.3,
     }
     buf, _ := json.Marshal(body)
-    req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewReader(buf))
-    req.Header.Set("Authorization", "Bearer "+c.apiKey)
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewReader(buf))
     req.Header.Set("Content-Type", "application/json")
-    resp, err := c.http.Do(req)
+    resp, err := c.do(req)
     if err != nil {
         return text, err
     }
@@ -320,6 +634,114 @@ func (c *Client) SummarizeText(ctx context.Context, text string) (string, error)
     return strings.TrimSpace(out.Choices[0].Message.Content), nil
 }
 
+// ChatComplete runs a single-turn chat completion with a system and user prompt
+// using the chat model and returns the trimmed reply text.
+func (c *Client) ChatComplete(ctx context.Context, system, user string, maxTokens int) (string, error) {
+    body := map[string]any{
+        "model": c.options(ctx).ChatModel,
+        "messages": []any{
+            map[string]string{"role": "system", "content": system},
+            map[string]string{"role": "user", "content": user},
+        },
+        "max_tokens":  maxTokens,
+        "temperature": 0.2,
+    }
+    buf, _ := json.Marshal(body)
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewReader(buf))
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := c.do(req)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        b, _ := io.ReadAll(resp.Body)
+        return "", fmt.Errorf("chat status %d: %s", resp.StatusCode, string(b))
+    }
+    var out struct {
+        Choices []struct{ Message struct{ Content string `json:"content"` } `json:"message"` } `json:"choices"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+        return "", err
+    }
+    if len(out.Choices) == 0 {
+        return "", errors.New("no chat choice")
+    }
+    return strings.TrimSpace(out.Choices[0].Message.Content), nil
+}
+
+// ExtractFacts asks a cheap chat model for durable facts about the client found in
+// the conversation excerpt. Known facts are passed so the model can update or retract
+// them (empty value). Returns a key/value map; keys are short snake_case labels.
+func (c *Client) ExtractFacts(ctx context.Context, conversation string, known map[string]string) (map[string]string, error) {
+    o := c.options(ctx)
+    model := o.MemoryModel
+    if model == "" {
+        model = o.ChatModel
+    }
+    knownJSON, _ := json.Marshal(known)
+    body := map[string]any{
+        "model": model,
+        "messages": []any{
+            map[string]string{
+                "role": "system",
+                "content": "Você extrai fatos duráveis sobre o cliente de uma conversa de atendimento: nome, preferências, produtos comprados, cidade, dados relevantes para futuros atendimentos. " +
+                    "Ignore informações passageiras. Responda SOMENTE com um objeto JSON plano {\"chave_snake_case\": \"valor\"} contendo fatos novos ou alterados. " +
+                    "Para remover um fato conhecido que deixou de ser verdade, devolva a chave com valor vazio. Se não houver nada, responda {}.",
+            },
+            map[string]string{
+                "role":    "user",
+                "content": "Fatos já conhecidos: " + string(knownJSON) + "\n\nConversa:\n" + conversation,
+            },
+        },
+        "response_format": map[string]string{"type": "json_object"},
+        "max_tokens":      300,
+        "temperature":     0,
+    }
+    buf, _ := json.Marshal(body)
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewReader(buf))
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := c.do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        b, _ := io.ReadAll(resp.Body)
+        return nil, fmt.Errorf("extract facts status %d: %s", resp.StatusCode, string(b))
+    }
+    var out struct {
+        Choices []struct{ Message struct{ Content string `json:"content"` } `json:"message"` } `json:"choices"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+        return nil, err
+    }
+    if len(out.Choices) == 0 {
+        return nil, errors.New("no facts choice")
+    }
+    var raw map[string]any
+    if err := json.Unmarshal([]byte(out.Choices[0].Message.Content), &raw); err != nil {
+        return nil, fmt.Errorf("facts json: %w", err)
+    }
+    facts := make(map[string]string, len(raw))
+    for k, v := range raw {
+        k = strings.TrimSpace(k)
+        if k == "" {
+            continue
+        }
+        switch t := v.(type) {
+        case string:
+            facts[k] = strings.TrimSpace(t)
+        case nil:
+            facts[k] = ""
+        default:
+            b, _ := json.Marshal(t)
+            facts[k] = string(b)
+        }
+    }
+    return facts, nil
+}
+
 // ExtractPDFText extracts plain text from a PDF. It writes the bytes to a temporary
 // file and uses pdftotext, which must be available on the system. Returns the
 // extracted text.
@@ -345,4 +767,44 @@ func ExtractPDFText(ctx context.Context, pdfBytes []byte) (string, error) {
         return "", err
     }
     return string(out), nil
-}
\ No newline at end of file
+}
+// Moderation is the result of a moderation check.
+type Moderation struct {
+    Flagged    bool
+    Categories []string
+}
+
+// Moderate runs text through the OpenAI moderation endpoint (omni-moderation-latest).
+func (c *Client) Moderate(ctx context.Context, text string) (Moderation, error) {
+    buf, _ := json.Marshal(map[string]any{"model": "omni-moderation-latest", "input": text})
+    req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/moderations", bytes.NewReader(buf))
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := c.do(req)
+    if err != nil {
+        return Moderation{}, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode > 299 {
+        b, _ := io.ReadAll(resp.Body)
+        return Moderation{}, fmt.Errorf("moderation status %d: %s", resp.StatusCode, string(b))
+    }
+    var out struct {
+        Results []struct {
+            Flagged    bool            `json:"flagged"`
+            Categories map[string]bool `json:"categories"`
+        } `json:"results"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+        return Moderation{}, err
+    }
+    var m Moderation
+    for _, r := range out.Results {
+        m.Flagged = m.Flagged || r.Flagged
+        for cat, on := range r.Categories {
+            if on {
+                m.Categories = append(m.Categories, cat)
+            }
+        }
+    }
+    return m, nil
+}
@@ -0,0 +1,147 @@
+package openai
+
+import (
+    "context"
+    "strconv"
+    "strings"
+)
+
+// RequestOptions selects the credentials and models of a call. Empty fields keep
+// the value of the client (or of the options already in the context), so the
+// same transport serves every tenant and experiment.
+type RequestOptions struct {
+    APIKey          string
+    AssistantID     string
+    ChatModel       string
+    TranscribeModel string
+    MemoryModel     string
+
+    // Run tunes the assistant runs (sampling, token limits, truncation).
+    Run RunParams
+}
+
+// merge returns o with the non-empty fields of over applied on top.
+func (o RequestOptions) merge(over RequestOptions) RequestOptions {
+    set := func(dst *string, v string) {
+        if v != "" {
+            *dst = v
+        }
+    }
+    set(&o.APIKey, over.APIKey)
+    set(&o.AssistantID, over.AssistantID)
+    set(&o.ChatModel, over.ChatModel)
+    set(&o.TranscribeModel, over.TranscribeModel)
+    set(&o.MemoryModel, over.MemoryModel)
+    o.Run = o.Run.merge(over.Run)
+    return o
+}
+
+type optionsKey struct{}
+
+// WithRequestOptions overrides the client's options for calls made with ctx
+// (e.g. an A/B experiment choosing the assistant or model per conversation).
+// Nested calls merge: the innermost non-empty field wins.
+func WithRequestOptions(ctx context.Context, o RequestOptions) context.Context {
+    if prev, ok := ctx.Value(optionsKey{}).(RequestOptions); ok {
+        o = prev.merge(o)
+    }
+    return context.WithValue(ctx, optionsKey{}, o)
+}
+
+// WithOptions returns a client with o applied over c's options. It shares c's
+// HTTP client (and transport), file name cache and settings; nothing is dialed anew.
+func (c *Client) WithOptions(o RequestOptions) *Client {
+    d := *c
+    d.opts = c.opts.merge(o)
+    if o.MemoryModel != "" {
+        d.MemoryModel = o.MemoryModel
+    }
+    return &d
+}
+
+// options resolves the effective options of a call: client defaults, then ctx.
+func (c *Client) options(ctx context.Context) RequestOptions {
+    o := c.opts
+    if o.MemoryModel == "" {
+        o.MemoryModel = c.MemoryModel
+    }
+    if over, ok := ctx.Value(optionsKey{}).(RequestOptions); ok {
+        o = o.merge(over)
+    }
+    return o
+}
+
+// RunParams are the per-run overrides of the assistant settings. Zero values keep
+// what is configured on the assistant in OpenAI.
+type RunParams struct {
+    Temperature         *float64 `json:"temperature,omitempty"`           // 0-2
+    TopP                *float64 `json:"top_p,omitempty"`                 // 0-1
+    MaxPromptTokens     int      `json:"max_prompt_tokens,omitempty"`     // >= 256
+    MaxCompletionTokens int      `json:"max_completion_tokens,omitempty"` // >= 256
+    Truncation          string   `json:"truncation,omitempty"`            // "auto" or "last_messages:N"
+    ReasoningEffort     string   `json:"reasoning_effort,omitempty"`      // low | medium | high (reasoning models only)
+}
+
+// merge returns p with the set fields of over applied on top.
+func (p RunParams) merge(over RunParams) RunParams {
+    if over.Temperature != nil {
+        p.Temperature = over.Temperature
+    }
+    if over.TopP != nil {
+        p.TopP = over.TopP
+    }
+    if over.MaxPromptTokens > 0 {
+        p.MaxPromptTokens = over.MaxPromptTokens
+    }
+    if over.MaxCompletionTokens > 0 {
+        p.MaxCompletionTokens = over.MaxCompletionTokens
+    }
+    if over.Truncation != "" {
+        p.Truncation = over.Truncation
+    }
+    if over.ReasoningEffort != "" {
+        p.ReasoningEffort = over.ReasoningEffort
+    }
+    return p
+}
+
+// apply adds the set fields to a create-run request body.
+func (p RunParams) apply(body map[string]any) {
+    if p.Temperature != nil {
+        body["temperature"] = *p.Temperature
+    }
+    if p.TopP != nil {
+        body["top_p"] = *p.TopP
+    }
+    if p.MaxPromptTokens > 0 {
+        body["max_prompt_tokens"] = p.MaxPromptTokens
+    }
+    if p.MaxCompletionTokens > 0 {
+        body["max_completion_tokens"] = p.MaxCompletionTokens
+    }
+    if ts, ok := TruncationStrategy(p.Truncation); ok && ts != nil {
+        body["truncation_strategy"] = ts
+    }
+    if p.ReasoningEffort != "" {
+        body["reasoning_effort"] = p.ReasoningEffort
+    }
+}
+
+// TruncationStrategy parses "auto" or "last_messages:N" into the API object.
+// ok is false for anything else; an empty string is valid (assistant default).
+func TruncationStrategy(s string) (map[string]any, bool) {
+    s = strings.TrimSpace(s)
+    switch {
+    case s == "":
+        return nil, true
+    case s == "auto":
+        return map[string]any{"type": "auto"}, true
+    case strings.HasPrefix(s, "last_messages:"):
+        n, err := strconv.Atoi(strings.TrimPrefix(s, "last_messages:"))
+        if err != nil || n < 1 {
+            return nil, false
+        }
+        return map[string]any{"type": "last_messages", "last_messages": n}, true
+    }
+    return nil, false
+}
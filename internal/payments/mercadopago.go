@@ -0,0 +1,147 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MercadoPagoNotification extrai o id do pagamento de uma notificação do Mercado
+// Pago (webhook com {"type":"payment","data":{"id":...}} ou IPN com
+// ?topic=payment&id=...). ok é false para outros tópicos. O id é o mesmo que
+// entra na assinatura: se a query e o corpo trazem ids diferentes, devolve
+// ErrSignature (uma notificação assinada para um pagamento não aplica outro).
+func MercadoPagoNotification(payload []byte, q url.Values) (id string, ok bool, err error) {
+	var n struct {
+		Type string `json:"type"`
+		Data struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(payload, &n)
+	var bodyID string
+	if n.Type == "payment" && len(n.Data.ID) > 0 {
+		bodyID = strings.Trim(string(n.Data.ID), `"`)
+	}
+	queryID := q.Get("data.id")
+	if queryID == "" && q.Get("topic") == "payment" {
+		queryID = q.Get("id")
+	}
+	switch {
+	case bodyID != "" && queryID != "" && !strings.EqualFold(bodyID, queryID):
+		return "", false, ErrSignature
+	case bodyID != "":
+		return bodyID, true, nil
+	case queryID != "" && (q.Get("type") == "payment" || q.Get("topic") == "payment"):
+		return queryID, true, nil
+	}
+	return "", false, nil
+}
+
+// VerifyMercadoPago confere o cabeçalho x-signature ("ts=...,v1=...") com o
+// manifesto "id:<data.id>;request-id:<x-request-id>;ts:<ts>;".
+func VerifyMercadoPago(header, requestID, dataID, secret string, now time.Time) error {
+	ts, sigs := signedParts(header)
+	var b strings.Builder
+	if dataID != "" {
+		b.WriteString("id:" + strings.ToLower(dataID) + ";")
+	}
+	if requestID != "" {
+		b.WriteString("request-id:" + requestID + ";")
+	}
+	b.WriteString("ts:" + ts + ";")
+	return checkHMAC(secret, b.String(), ts, sigs, now)
+}
+
+// MercadoPago consulta os pagamentos: a notificação só traz o id, o status e o
+// valor vêm da API.
+type MercadoPago struct {
+	Token   string
+	BaseURL string // "" = https://api.mercadopago.com
+	HTTP    *http.Client
+}
+
+type mpPayment struct {
+	ID                json.Number    `json:"id"`
+	Status            string         `json:"status"`
+	ExternalReference string         `json:"external_reference"`
+	TransactionAmount float64        `json:"transaction_amount"`
+	CurrencyID        string         `json:"currency_id"`
+	DateApproved      string         `json:"date_approved"`
+	Metadata          map[string]any `json:"metadata"`
+	Payer             struct {
+		Phone struct {
+			AreaCode string `json:"area_code"`
+			Number   string `json:"number"`
+		} `json:"phone"`
+	} `json:"payer"`
+}
+
+// Payment busca o pagamento id.
+func (m *MercadoPago) Payment(ctx context.Context, id string) (Payment, error) {
+	base := m.BaseURL
+	if base == "" {
+		base = "https://api.mercadopago.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/payments/"+url.PathEscape(id), nil)
+	if err != nil {
+		return Payment{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.Token)
+	client := m.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Payment{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Payment{}, err
+	}
+	if resp.StatusCode > 299 {
+		return Payment{}, fmt.Errorf("mercadopago status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var mp mpPayment
+	if err := json.Unmarshal(raw, &mp); err != nil {
+		return Payment{}, err
+	}
+	p := Payment{
+		Provider:    "mercadopago",
+		ExternalID:  mp.ID.String(),
+		Reference:   mp.ExternalReference,
+		Phone:       mp.Payer.Phone.AreaCode + mp.Payer.Phone.Number,
+		AmountCents: cents(mp.TransactionAmount),
+		Currency:    strings.ToUpper(mp.CurrencyID),
+		Raw:         raw,
+	}
+	if v, ok := mp.Metadata["phone"]; ok {
+		p.Phone = fmt.Sprint(v)
+	}
+	switch mp.Status {
+	case "approved":
+		p.Status = StatusPaid
+		p.PaidAt = parseTime(mp.DateApproved)
+		if p.PaidAt == nil {
+			now := time.Now()
+			p.PaidAt = &now
+		}
+	case "rejected", "cancelled":
+		p.Status = StatusFailed
+	case "refunded", "charged_back":
+		p.Status = StatusRefunded
+	default: // pending, in_process, authorized, in_mediation
+		p.Status = StatusPending
+	}
+	if p.ExternalID == "" {
+		p.ExternalID = id
+	}
+	return p, nil
+}
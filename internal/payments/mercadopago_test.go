@@ -0,0 +1,35 @@
+package payments
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestMercadoPagoNotification(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		query   string
+		want    string
+		ok      bool
+		wantErr error
+	}{
+		{"corpo", `{"type":"payment","data":{"id":"123"}}`, "", "123", true, nil},
+		{"corpo numérico", `{"type":"payment","data":{"id":123}}`, "type=payment&data.id=123", "123", true, nil},
+		{"só a query", `{}`, "type=payment&data.id=123", "123", true, nil},
+		{"IPN", ``, "topic=payment&id=123", "123", true, nil},
+		{"ids diferentes", `{"type":"payment","data":{"id":"999"}}`, "type=payment&data.id=123", "", false, ErrSignature},
+		{"IPN com corpo de outro pagamento", `{"type":"payment","data":{"id":"999"}}`, "topic=payment&id=123", "", false, ErrSignature},
+		{"outro tópico", `{"type":"merchant_order","data":{"id":"5"}}`, "topic=merchant_order&id=5", "", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			id, ok, err := MercadoPagoNotification([]byte(tt.body), q)
+			if id != tt.want || ok != tt.ok || !errors.Is(err, tt.wantErr) {
+				t.Errorf("MercadoPagoNotification() = %q, %v, %v; want %q, %v, %v", id, ok, err, tt.want, tt.ok, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// internal/payments/payments.go
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Webhooks de pagamento (Mercado Pago, Stripe e Pix padrão Bacen).
+
+Cada provedor tem formato e assinatura próprios; aqui eles viram Payment, com o
+status normalizado (paid | pending | failed | refunded), o valor em centavos, a
+referência externa (pedido, txid) e o telefone, quando o provedor traz. Quem
+recebe decide a que cliente o pagamento pertence.
+*/
+
+// Status normalizados.
+const (
+	StatusPaid     = "paid"
+	StatusPending  = "pending"
+	StatusFailed   = "failed"
+	StatusRefunded = "refunded"
+)
+
+// Payment é um pagamento informado pelo provedor.
+type Payment struct {
+	Provider    string
+	ExternalID  string // id do pagamento no provedor (chave de idempotência)
+	Reference   string // referência externa: pedido, client_reference_id, txid
+	Phone       string // telefone do pagador/metadata, quando existe (sem normalizar)
+	Status      string
+	AmountCents int64
+	Currency    string
+	PaidAt      *time.Time
+	Raw         json.RawMessage
+}
+
+// ErrSignature indica assinatura ausente, inválida ou fora da janela.
+var ErrSignature = errors.New("invalid webhook signature")
+
+// signatureTolerance é a idade máxima aceita para o timestamp assinado.
+const signatureTolerance = 5 * time.Minute
+
+// cents converte um valor decimal (reais, dólares) em centavos.
+func cents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+// signedParts lê cabeçalhos no formato "t=123,v1=abc" (Stripe) ou "ts=123,v1=abc" (Mercado Pago).
+func signedParts(header string) (ts string, sigs []string) {
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t", "ts":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	return ts, sigs
+}
+
+// checkHMAC confere sigs contra o HMAC-SHA256 de msg e a idade do timestamp.
+func checkHMAC(secret, msg, ts string, sigs []string, now time.Time) error {
+	if secret == "" || ts == "" || len(sigs) == 0 {
+		return ErrSignature
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	if sec > 1e11 { // Mercado Pago pode mandar em milissegundos
+		sec /= 1000
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > signatureTolerance || d < -signatureTolerance {
+		return ErrSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(msg))
+	want := mac.Sum(nil)
+	for _, s := range sigs {
+		if got, err := hex.DecodeString(s); err == nil && hmac.Equal(got, want) {
+			return nil
+		}
+	}
+	return ErrSignature
+}
+
+func parseTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,49 @@
+package payments
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsePix lê a notificação de Pix recebido no padrão da API Pix do Bacen
+// ({"pix":[{"endToEndId","txid","valor","horario","infoPagador"}]}), usada por
+// Efí, Itaú, Inter e outros PSPs. Cada item é um pagamento; o txid é a referência.
+func ParsePix(payload []byte) ([]Payment, error) {
+	var body struct {
+		Pix []struct {
+			EndToEndID string `json:"endToEndId"`
+			TxID       string `json:"txid"`
+			Valor      string `json:"valor"`
+			Horario    string `json:"horario"`
+		} `json:"pix"`
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, err
+	}
+	out := make([]Payment, 0, len(body.Pix))
+	for _, px := range body.Pix {
+		if px.EndToEndID == "" {
+			continue
+		}
+		v, _ := strconv.ParseFloat(strings.TrimSpace(px.Valor), 64)
+		item, _ := json.Marshal(px)
+		p := Payment{
+			Provider:    "pix",
+			ExternalID:  px.EndToEndID,
+			Reference:   px.TxID,
+			Status:      StatusPaid,
+			AmountCents: cents(v),
+			Currency:    "BRL",
+			PaidAt:      parseTime(px.Horario),
+			Raw:         item,
+		}
+		if p.PaidAt == nil {
+			now := time.Now()
+			p.PaidAt = &now
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
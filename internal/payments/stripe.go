@@ -0,0 +1,93 @@
+package payments
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// VerifyStripe confere o cabeçalho Stripe-Signature ("t=...,v1=...") do corpo bruto.
+func VerifyStripe(payload []byte, header, secret string, now time.Time) error {
+	ts, sigs := signedParts(header)
+	return checkHMAC(secret, ts+"."+string(payload), ts, sigs, now)
+}
+
+type stripeEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+type stripeObject struct {
+	ID                string            `json:"id"`
+	PaymentIntent     string            `json:"payment_intent"`
+	PaymentStatus     string            `json:"payment_status"`
+	ClientReferenceID string            `json:"client_reference_id"`
+	AmountTotal       int64             `json:"amount_total"`
+	AmountReceived    int64             `json:"amount_received"`
+	Amount            int64             `json:"amount"`
+	Currency          string            `json:"currency"`
+	Created           int64             `json:"created"`
+	Metadata          map[string]string `json:"metadata"`
+	CustomerDetails   struct {
+		Phone string `json:"phone"`
+	} `json:"customer_details"`
+}
+
+// ParseStripe lê um evento da Stripe. Eventos sem relação com pagamento devolvem nil.
+// O id é o do PaymentIntent quando existe, para checkout.session.completed e
+// payment_intent.succeeded do mesmo pagamento caírem no mesmo registro.
+func ParseStripe(payload []byte) ([]Payment, error) {
+	var ev stripeEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil, err
+	}
+	var status string
+	switch ev.Type {
+	case "checkout.session.completed", "checkout.session.async_payment_succeeded", "payment_intent.succeeded":
+		status = StatusPaid
+	case "checkout.session.async_payment_failed", "payment_intent.payment_failed":
+		status = StatusFailed
+	case "charge.refunded":
+		status = StatusRefunded
+	default:
+		return nil, nil
+	}
+	var o stripeObject
+	if err := json.Unmarshal(ev.Data.Object, &o); err != nil {
+		return nil, err
+	}
+	if ev.Type == "checkout.session.completed" && o.PaymentStatus != "paid" {
+		status = StatusPending // boleto/Pix: o pagamento chega depois (async_payment_succeeded)
+	}
+	p := Payment{
+		Provider:    "stripe",
+		ExternalID:  o.ID,
+		Reference:   o.ClientReferenceID,
+		Phone:       o.CustomerDetails.Phone,
+		Status:      status,
+		AmountCents: max(o.AmountTotal, o.AmountReceived, o.Amount),
+		Currency:    strings.ToUpper(o.Currency),
+		Raw:         payload,
+	}
+	if strings.HasPrefix(ev.Type, "checkout.session") || ev.Type == "charge.refunded" {
+		if o.PaymentIntent != "" {
+			p.ExternalID = o.PaymentIntent
+		}
+	}
+	if p.Reference == "" {
+		p.Reference = o.Metadata["reference"]
+	}
+	if p.Phone == "" {
+		p.Phone = o.Metadata["phone"]
+	}
+	if status == StatusPaid {
+		t := time.Unix(o.Created, 0)
+		if o.Created == 0 {
+			t = time.Now()
+		}
+		p.PaidAt = &t
+	}
+	return []Payment{p}, nil
+}
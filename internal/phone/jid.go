@@ -0,0 +1,103 @@
+package phone
+
+import (
+	"regexp"
+	"strings"
+)
+
+// JID é um endereço do WhatsApp: "usuário@servidor". O sufixo de aparelho
+// ("5511...:12@s.whatsapp.net") é descartado no parse.
+type JID struct {
+	User   string
+	Server string
+}
+
+// ParseJID aceita JIDs de contato, grupo, LID e canal cujo usuário é numérico
+// (grupos no formato antigo "criador-timestamp" também valem).
+func ParseJID(s string) (JID, bool) {
+	user, server, ok := strings.Cut(strings.TrimSpace(s), "@")
+	if !ok {
+		return JID{}, false
+	}
+	if i := strings.IndexByte(user, ':'); i >= 0 {
+		user = user[:i]
+	}
+	server = strings.ToLower(server)
+	switch server {
+	case ServerUser, ServerLegacy, ServerLID, ServerNewsletter:
+		if !allDigits(user) {
+			return JID{}, false
+		}
+	case ServerGroup:
+		if !allDigits(strings.Replace(user, "-", "", 1)) || strings.HasPrefix(user, "-") || strings.HasSuffix(user, "-") {
+			return JID{}, false
+		}
+	default:
+		return JID{}, false
+	}
+	return JID{User: user, Server: server}, true
+}
+
+func (j JID) String() string { return j.User + "@" + j.Server }
+
+// IsLID indica uma identidade @lid (telefone desconhecido).
+func (j JID) IsLID() bool { return j.Server == ServerLID }
+
+// IsGroup indica um JID de grupo.
+func (j JID) IsGroup() bool { return j.Server == ServerGroup }
+
+// IsNewsletter indica um JID de canal (newsletter).
+func (j JID) IsNewsletter() bool { return j.Server == ServerNewsletter }
+
+// Newsletter normaliza um canal: aceita o JID ("123@newsletter") ou só o número.
+func Newsletter(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "@") && s != "" && allDigits(s) {
+		s += "@" + ServerNewsletter
+	}
+	j, ok := ParseJID(s)
+	if !ok || !j.IsNewsletter() {
+		return "", false
+	}
+	return j.String(), true
+}
+
+// UserJID monta o JID de contato de um telefone.
+func UserJID(p string) string { return Digits(p) + "@" + ServerUser }
+
+// FromJID devolve o identificador numérico de um JID de contato, grupo ou canal
+// (o que o pipeline usa como "telefone" do cliente). LIDs não valem.
+func FromJID(s string) (string, bool) {
+	j, ok := ParseJID(s)
+	if !ok || j.IsLID() || !allDigits(j.User) {
+		return "", false
+	}
+	return j.User, true
+}
+
+// LID devolve o JID @lid normalizado ("123@lid").
+func LID(s string) (string, bool) {
+	j, ok := ParseJID(s)
+	if !ok || !j.IsLID() {
+		return "", false
+	}
+	return j.String(), true
+}
+
+var anyJIDRe = regexp.MustCompile(`\d+@(?:s\.whatsapp\.net|c\.us|g\.us|newsletter|lid)`)
+
+// FindJID devolve o primeiro JID que aparecer em um texto qualquer (ex.: o corpo
+// bruto de um webhook em formato desconhecido).
+func FindJID(s string) (string, bool) {
+	m := anyJIDRe.FindString(s)
+	return m, m != ""
+}
+
+// Destination prepara o destino de um envio: JIDs @lid (telefone desconhecido),
+// de grupo e de canal seguem intactos; o resto vira só dígitos.
+func Destination(s string) string {
+	if j, ok := ParseJID(s); ok && (j.IsLID() || j.IsGroup() || j.IsNewsletter()) {
+		return j.String()
+	}
+	return Digits(s)
+}
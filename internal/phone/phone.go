@@ -0,0 +1,123 @@
+// internal/phone/phone.go
+package phone
+
+import "strings"
+
+// Servidores de JID conhecidos.
+const (
+	ServerUser       = "s.whatsapp.net" // contato (telefone)
+	ServerLegacy     = "c.us"           // contato, formato antigo
+	ServerGroup      = "g.us"           // grupo
+	ServerLID        = "lid"            // identidade oculta (telefone desconhecido)
+	ServerNewsletter = "newsletter"     // canal
+)
+
+// DefaultCountry é o DDI assumido para números em formato nacional.
+const DefaultCountry = "55"
+
+// Digits descarta tudo que não for dígito.
+func Digits(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Valid aplica a validação estrita: 10 a 15 dígitos (E.164 sem '+').
+func Valid(p string) bool {
+	return len(p) >= 10 && len(p) <= 15 && allDigits(p)
+}
+
+// Normalize converte o que o usuário digitou ("+55 (11) 99999-9999", "0055...",
+// "(11) 99999-9999", "5511...@s.whatsapp.net") em E.164 sem '+'. Números
+// brasileiros em formato nacional (com ou sem o 0 de tronco) ganham o DDI 55.
+func Normalize(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if j, ok := ParseJID(s); ok {
+		if j.Server != ServerUser && j.Server != ServerLegacy {
+			return "", false
+		}
+		s = j.User
+	}
+	international := strings.HasPrefix(s, "+")
+	d := Digits(s)
+	if !international && strings.HasPrefix(d, "00") {
+		d, international = d[2:], true
+	}
+	if !international {
+		if national := strings.TrimPrefix(d, "0"); isBrazilianNational(national) {
+			d = DefaultCountry + national
+		}
+	}
+	if !Valid(d) {
+		return "", false
+	}
+	return d, true
+}
+
+// E164 devolve o número normalizado com '+'.
+func E164(s string) (string, bool) {
+	d, ok := Normalize(s)
+	if !ok {
+		return "", false
+	}
+	return "+" + d, true
+}
+
+// isBrazilianNational reconhece DDD + fixo (8 dígitos, começa em 2-5) ou
+// DDD + celular (9 dígitos, começa em 9).
+func isBrazilianNational(d string) bool {
+	if !validDDD(d) {
+		return false
+	}
+	switch len(d) {
+	case 10:
+		return d[2] >= '2' && d[2] <= '5'
+	case 11:
+		return d[2] == '9'
+	}
+	return false
+}
+
+func validDDD(d string) bool {
+	return len(d) >= 2 && d[0] >= '1' && d[0] <= '9' && d[1] >= '1' && d[1] <= '9'
+}
+
+// AddNinthDigit insere o 9 em celulares brasileiros no formato antigo de 8 dígitos
+// ("55 11 8765-4321" → "55 11 98765-4321"), como alguns JIDs ainda chegam.
+func AddNinthDigit(p string) (string, bool) {
+	if len(p) != 12 || !strings.HasPrefix(p, DefaultCountry) || !allDigits(p) || !validDDD(p[2:]) {
+		return "", false
+	}
+	if p[4] < '6' { // fixo: sem nono dígito
+		return "", false
+	}
+	return p[:4] + "9" + p[4:], true
+}
+
+// RemoveNinthDigit faz o inverso de AddNinthDigit.
+func RemoveNinthDigit(p string) (string, bool) {
+	if len(p) != 13 || !strings.HasPrefix(p, DefaultCountry) || !allDigits(p) || !validDDD(p[2:]) {
+		return "", false
+	}
+	if p[4] != '9' || p[5] < '6' {
+		return "", false
+	}
+	return p[:4] + p[5:], true
+}
+
+// Alternate devolve a outra grafia de um celular brasileiro (com ou sem o nono
+// dígito), usada para achar o mesmo contato registrado no outro formato.
+func Alternate(p string) (string, bool) {
+	if alt, ok := AddNinthDigit(p); ok {
+		return alt, true
+	}
+	return RemoveNinthDigit(p)
+}
+
+func allDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,172 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"+55 (11) 99999-9999", "5511999999999", true},
+		{"5511999999999", "5511999999999", true},
+		{"0055 11 99999-9999", "5511999999999", true},
+		{"(11) 99999-9999", "5511999999999", true},
+		{"011 99999-9999", "5511999999999", true},
+		{"(11) 3333-4444", "551133334444", true},
+		{" 5511999999999@s.whatsapp.net ", "5511999999999", true},
+		{"5511999999999:12@s.whatsapp.net", "5511999999999", true},
+		{"5511999999999@c.us", "5511999999999", true},
+		{"+1 (415) 555-0100", "14155550100", true},
+		{"120363025246125486@g.us", "", false},
+		{"123456789@lid", "", false},
+		{"120363025246125486@newsletter", "", false},
+		{"99999-9999", "", false},
+		{"+1234567890123456", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Normalize(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestE164(t *testing.T) {
+	if got, ok := E164("(11) 99999-9999"); !ok || got != "+5511999999999" {
+		t.Errorf("E164() = %q, %v; want +5511999999999, true", got, ok)
+	}
+	if _, ok := E164("123"); ok {
+		t.Error("E164(\"123\") ok = true, want false")
+	}
+}
+
+func TestNinthDigit(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(string) (string, bool)
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"add celular antigo", AddNinthDigit, "551187654321", "5511987654321", true},
+		{"add fixo", AddNinthDigit, "551133334444", "", false},
+		{"add já com nono dígito", AddNinthDigit, "5511987654321", "", false},
+		{"add outro país", AddNinthDigit, "141555501000", "", false},
+		{"add DDD inválido", AddNinthDigit, "550187654321", "", false},
+		{"remove celular", RemoveNinthDigit, "5511987654321", "551187654321", true},
+		{"remove sem nono dígito", RemoveNinthDigit, "551187654321", "", false},
+		{"remove 9 seguido de fixo", RemoveNinthDigit, "5511933334444", "", false},
+		{"remove não numérico", RemoveNinthDigit, "55119876543a1", "", false},
+		{"alternate sem nono", Alternate, "551187654321", "5511987654321", true},
+		{"alternate com nono", Alternate, "5511987654321", "551187654321", true},
+		{"alternate fixo", Alternate, "551133334444", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.fn(tt.in)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseJID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want JID
+		ok   bool
+	}{
+		{"5511999999999@s.whatsapp.net", JID{"5511999999999", ServerUser}, true},
+		{"5511999999999:7@s.whatsapp.net", JID{"5511999999999", ServerUser}, true},
+		{"5511999999999@C.US", JID{"5511999999999", ServerLegacy}, true},
+		{"120363025246125486@g.us", JID{"120363025246125486", ServerGroup}, true},
+		{"5511999999999-1610000000@g.us", JID{"5511999999999-1610000000", ServerGroup}, true},
+		{"123456789012345@lid", JID{"123456789012345", ServerLID}, true},
+		{"120363199999999999@newsletter", JID{"120363199999999999", ServerNewsletter}, true},
+		{"-1610000000@g.us", JID{}, false},
+		{"5511-@g.us", JID{}, false},
+		{"abc@s.whatsapp.net", JID{}, false},
+		{"5511999999999@broadcast", JID{}, false},
+		{"5511999999999", JID{}, false},
+		{"@s.whatsapp.net", JID{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseJID(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseJID(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestJIDKinds(t *testing.T) {
+	for in, want := range map[string][3]bool{ // lid, group, newsletter
+		"5511999999999@s.whatsapp.net":  {false, false, false},
+		"123@lid":                       {true, false, false},
+		"120363025246125486@g.us":       {false, true, false},
+		"120363199999999999@newsletter": {false, false, true},
+	} {
+		j, ok := ParseJID(in)
+		if !ok {
+			t.Fatalf("ParseJID(%q) failed", in)
+		}
+		if got := [3]bool{j.IsLID(), j.IsGroup(), j.IsNewsletter()}; got != want {
+			t.Errorf("%q kinds = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFromJID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"5511999999999@s.whatsapp.net", "5511999999999", true},
+		{"5511999999999:3@c.us", "5511999999999", true},
+		{"120363025246125486@g.us", "120363025246125486", true},
+		{"120363199999999999@newsletter", "120363199999999999", true},
+		{"5511999999999-1610000000@g.us", "", false}, // grupo antigo: não é numérico
+		{"123456789012345@lid", "", false},
+		{"5511999999999", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := FromJID(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FromJID(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestJIDHelpers(t *testing.T) {
+	if got, ok := LID(" 123@LID "); !ok || got != "123@lid" {
+		t.Errorf("LID() = %q, %v; want 123@lid, true", got, ok)
+	}
+	if _, ok := LID("5511999999999@s.whatsapp.net"); ok {
+		t.Error("LID(contact) ok = true, want false")
+	}
+	if got, ok := Newsletter("120363199999999999"); !ok || got != "120363199999999999@newsletter" {
+		t.Errorf("Newsletter() = %q, %v", got, ok)
+	}
+	if _, ok := Newsletter("120363025246125486@g.us"); ok {
+		t.Error("Newsletter(group) ok = true, want false")
+	}
+	if got := UserJID("+55 11 99999-9999"); got != "5511999999999@s.whatsapp.net" {
+		t.Errorf("UserJID() = %q", got)
+	}
+	if got, ok := FindJID(`{"chat":"x","from":"5511999999999@s.whatsapp.net"}`); !ok || got != "5511999999999@s.whatsapp.net" {
+		t.Errorf("FindJID() = %q, %v", got, ok)
+	}
+	for in, want := range map[string]string{
+		"123@lid":                      "123@lid",
+		"120363025246125486@g.us":      "120363025246125486@g.us",
+		"5511999999999@s.whatsapp.net": "5511999999999",
+		"+55 (11) 99999-9999":          "5511999999999",
+	} {
+		if got := Destination(in); got != want {
+			t.Errorf("Destination(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
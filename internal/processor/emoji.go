@@ -0,0 +1,48 @@
+package processor
+
+import (
+    "strings"
+    "unicode"
+)
+
+// IsEmojiOnly reports whether s has at least one emoji and nothing else besides
+// whitespace and emoji modifiers ("👍", "❤️", "🙏🏽 🙏🏽", "👨‍👩‍👧").
+func IsEmojiOnly(s string) bool {
+    found := false
+    for _, r := range s {
+        switch {
+        case unicode.IsSpace(r), isEmojiModifier(r):
+        case isEmoji(r):
+            found = true
+        default:
+            return false
+        }
+    }
+    return found
+}
+
+// Emojis returns the emoji of s without spaces (used as the analytics label).
+func Emojis(s string) string {
+    return strings.Join(strings.Fields(s), "")
+}
+
+func isEmoji(r rune) bool {
+    switch {
+    case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, flags, skin tones
+        return true
+    case r >= 0x2600 && r <= 0x27BF: // misc symbols and dingbats (☀ ✅ ❤)
+        return true
+    case r >= 0x2B00 && r <= 0x2BFF: // arrows and stars (⭐ ⬆)
+        return true
+    case r == 0x00A9 || r == 0x00AE || r == 0x203C || r == 0x2049 || r == 0x2122:
+        return true
+    case r >= 0x2190 && r <= 0x21FF, r >= 0x2300 && r <= 0x23FF: // ↩ ⌚ ⏰
+        return true
+    }
+    return false
+}
+
+// isEmojiModifier covers joiners and selectors that only make sense next to an emoji.
+func isEmojiModifier(r rune) bool {
+    return r == 0x200D || r == 0xFE0E || r == 0xFE0F || r == 0x20E3 || (r >= 0xE0020 && r <= 0xE007F)
+}
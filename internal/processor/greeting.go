@@ -0,0 +1,99 @@
+package processor
+
+import (
+    "strings"
+    "unicode"
+)
+
+// greetingPrefixes are the openings treated as a greeting (compared lowercased).
+var greetingPrefixes = []string{
+    "olá", "ola", "oi", "oie", "opa", "bom dia", "boa tarde", "boa noite",
+    "seja bem-vindo", "seja bem-vinda", "seja bem vindo", "seja bem vinda",
+    "bem-vindo", "bem-vinda", "tudo bem", "como vai", "hello", "hi",
+    "como posso ajudar", "como posso te ajudar", "em que posso ajudar", "em que posso te ajudar",
+}
+
+// maxGreetingLen bounds how long a sentence can be and still count as "just a greeting".
+const maxGreetingLen = 60
+
+// IsGreeting reports whether the sentence is a short greeting such as "Olá!" or
+// "Como posso ajudar?".
+func IsGreeting(sentence string) bool {
+    p := strings.ToLower(strings.TrimSpace(sentence))
+    if p == "" || len([]rune(p)) > maxGreetingLen {
+        return false
+    }
+    for _, g := range greetingPrefixes {
+        if rest, ok := strings.CutPrefix(p, g); ok {
+            if rest == "" {
+                return true
+            }
+            r := []rune(rest)[0]
+            if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// StartsWithGreeting reports whether the reply opens with a greeting sentence.
+func StartsWithGreeting(reply string) bool {
+    first, _, _ := strings.Cut(strings.TrimSpace(reply), "\n")
+    sentences := splitSentences(first)
+    return len(sentences) > 0 && IsGreeting(sentences[0])
+}
+
+// StripGreeting removes the greeting sentences that open the reply. When the whole
+// first paragraph is a greeting it is dropped; the reply is returned unchanged
+// when nothing would remain after stripping.
+func StripGreeting(reply string) (string, bool) {
+    s := strings.TrimSpace(reply)
+    first, rest, _ := strings.Cut(s, "\n")
+
+    sentences := splitSentences(first)
+    i := 0
+    for i < len(sentences) && IsGreeting(sentences[i]) {
+        i++
+    }
+    switch {
+    case i == 0:
+        return reply, false
+    case i == len(sentences):
+        if strings.TrimSpace(rest) == "" {
+            return reply, false
+        }
+        return strings.TrimSpace(rest), true
+    }
+    out := strings.TrimSpace(strings.Join(sentences[i:], ""))
+    if rest != "" {
+        out += "\n" + rest
+    }
+    return out, true
+}
+
+// splitSentences splits after ".", "!" or "?" keeping the punctuation and the
+// following whitespace with each piece.
+func splitSentences(s string) []string {
+    var out []string
+    start := 0
+    rs := []rune(s)
+    for i := 0; i < len(rs); i++ {
+        if rs[i] == '.' || rs[i] == '!' || rs[i] == '?' {
+            j := i + 1
+            for j < len(rs) && (rs[j] == '!' || rs[j] == '?' || rs[j] == '.') {
+                j++
+            }
+            for j < len(rs) && unicode.IsSpace(rs[j]) {
+                j++
+            }
+            out = append(out, string(rs[start:j]))
+            start = j
+            i = j - 1
+        }
+    }
+    if start < len(rs) {
+        out = append(out, string(rs[start:]))
+    }
+    return out
+}
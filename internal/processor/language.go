@@ -0,0 +1,45 @@
+package processor
+
+import (
+    "strings"
+    "unicode"
+)
+
+// portugueseWords are frequent Portuguese words that are rare in other languages
+// (words shared with Spanish such as "de", "que", "no" and "para" are left out).
+var portugueseWords = map[string]bool{
+    "não": true, "nao": true, "você": true, "voce": true, "vc": true, "vocês": true,
+    "obrigado": true, "obrigada": true, "oi": true, "olá": true, "tudo": true, "bem": true,
+    "sim": true, "quero": true, "queria": true, "tem": true, "têm": true, "está": true,
+    "isso": true, "isto": true, "muito": true, "também": true, "tambem": true, "então": true,
+    "entao": true, "mais": true, "meu": true, "minha": true, "seu": true, "sua": true,
+    "um": true, "uma": true, "com": true, "pra": true, "pro": true, "na": true,
+    "nos": true, "nas": true, "do": true, "dos": true, "das": true, "ao": true,
+    "é": true, "eu": true, "ele": true, "ela": true, "quanto": true, "qual": true,
+    "quando": true, "onde": true, "bom": true, "boa": true, "noite": true, "preciso": true,
+    "gostaria": true, "pode": true, "posso": true, "agora": true, "hoje": true, "amanhã": true,
+    "ainda": true, "já": true, "aqui": true, "preço": true,
+}
+
+// LooksPortuguese reports whether text is most likely Portuguese, using the share
+// of common Portuguese words and letters (ã, õ, ç). It is a cheap filter: texts
+// it rejects are not necessarily in another language.
+func LooksPortuguese(text string) bool {
+    lower := strings.ToLower(text)
+    if strings.ContainsAny(lower, "ãõç") {
+        return true
+    }
+    words := strings.FieldsFunc(lower, func(r rune) bool {
+        return !unicode.IsLetter(r)
+    })
+    if len(words) == 0 {
+        return true // numbers, emoji: nothing to translate
+    }
+    hits := 0
+    for _, w := range words {
+        if portugueseWords[w] {
+            hits++
+        }
+    }
+    return hits > 0 && hits*5 >= len(words)
+}
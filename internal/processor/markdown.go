@@ -0,0 +1,235 @@
+package processor
+
+import (
+    "regexp"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+// Table rendering modes accepted by FormatWhatsApp.
+const (
+    TableAuto      = "auto"      // monospace when it fits a phone screen, bullets otherwise
+    TableMonospace = "monospace" // aligned columns inside a ``` block
+    TableBullets   = "bullets"   // one bullet per row
+)
+
+// maxMonospaceWidth is roughly how many monospace characters fit on a phone line.
+const maxMonospaceWidth = 34
+
+var (
+    tableSepRe  = regexp.MustCompile(`^\s*\|?\s*:?-{2,}:?\s*(\|\s*:?-{2,}:?\s*)*\|?\s*$`)
+    bulletRe    = regexp.MustCompile(`^(\s*)[-*+•]\s+(.*)$`)
+    numberedRe  = regexp.MustCompile(`^(\s*)(\d{1,3})[.)]\s+(.*)$`)
+    headingRe   = regexp.MustCompile(`^\s*#{1,6}\s+(.*?)\s*#*\s*$`)
+    ruleRe      = regexp.MustCompile(`^\s*([-*_]\s*){3,}$`)
+    mdBoldRe    = regexp.MustCompile(`\*\*([^*\n]+?)\*\*|__([^_\n]+?)__`)
+    mdStrikeRe  = regexp.MustCompile(`~~([^~\n]+?)~~`)
+    mdLinkRe    = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^)\s]+)\)`)
+    waMarkersRe = regexp.MustCompile("[*_~`]")
+)
+
+// FormatWhatsApp rewrites the markdown an assistant tends to produce into text that
+// renders well in WhatsApp: tables become aligned monospace blocks or bullet
+// summaries (see the Table* modes), list markers become "•" and sequential numbers,
+// headings become bold lines and **bold**/~~strike~~/[links](url) use WhatsApp syntax.
+// Text inside ``` blocks is left untouched.
+func FormatWhatsApp(s, tableMode string) string {
+    if !strings.ContainsAny(s, "|-*+#_~[0123456789•") {
+        return s
+    }
+    lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+    out := make([]string, 0, len(lines))
+    inFence := false
+    numbers := map[int]int{} // indent level -> last number used in the current list
+
+    for i := 0; i < len(lines); i++ {
+        line := lines[i]
+        if strings.HasPrefix(strings.TrimSpace(line), "```") {
+            inFence = !inFence
+            out = append(out, line)
+            continue
+        }
+        if inFence {
+            out = append(out, line)
+            continue
+        }
+
+        if strings.Contains(line, "|") && i+1 < len(lines) && tableSepRe.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "|") {
+            header := splitRow(line)
+            var rows [][]string
+            j := i + 2
+            for ; j < len(lines) && strings.Contains(lines[j], "|") && strings.TrimSpace(lines[j]) != ""; j++ {
+                rows = append(rows, splitRow(lines[j]))
+            }
+            out = append(out, renderTable(header, rows, tableMode)...)
+            numbers = map[int]int{}
+            i = j - 1
+            continue
+        }
+
+        switch {
+        case ruleRe.MatchString(line):
+            out = append(out, "")
+            continue
+        case headingRe.MatchString(line):
+            title := headingRe.FindStringSubmatch(line)[1]
+            out = append(out, "*"+strings.Trim(formatInline(title), "*")+"*")
+            numbers = map[int]int{}
+            continue
+        }
+
+        if m := bulletRe.FindStringSubmatch(line); m != nil {
+            level := indentLevel(m[1])
+            out = append(out, strings.Repeat("   ", level)+"• "+formatInline(m[2]))
+            continue
+        }
+        if m := numberedRe.FindStringSubmatch(line); m != nil {
+            level := indentLevel(m[1])
+            n, _ := strconv.Atoi(m[2])
+            // "1. 1. 1." (common in markdown) becomes 1, 2, 3; explicit numbering is kept
+            if last, ok := numbers[level]; ok && n <= last {
+                n = last + 1
+            }
+            numbers[level] = n
+            for l := range numbers {
+                if l > level {
+                    delete(numbers, l)
+                }
+            }
+            out = append(out, strings.Repeat("   ", level)+strconv.Itoa(n)+". "+formatInline(m[3]))
+            continue
+        }
+
+        if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") {
+            numbers = map[int]int{}
+        }
+        out = append(out, formatInline(line))
+    }
+    return strings.Join(out, "\n")
+}
+
+// formatInline converts inline markdown to WhatsApp markers. Single *x* is left
+// alone because it is already bold in WhatsApp.
+func formatInline(s string) string {
+    s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+        sub := mdLinkRe.FindStringSubmatch(m)
+        if sub[1] == sub[2] {
+            return sub[2]
+        }
+        return sub[1] + " (" + sub[2] + ")"
+    })
+    s = mdBoldRe.ReplaceAllStringFunc(s, func(m string) string {
+        sub := mdBoldRe.FindStringSubmatch(m)
+        return "*" + sub[1] + sub[2] + "*"
+    })
+    return mdStrikeRe.ReplaceAllString(s, "~$1~")
+}
+
+func indentLevel(indent string) int {
+    n := strings.Count(indent, "\t")*4 + strings.Count(indent, " ")
+    return n / 2
+}
+
+func splitRow(line string) []string {
+    line = strings.TrimSpace(line)
+    line = strings.TrimPrefix(line, "|")
+    line = strings.TrimSuffix(line, "|")
+    cells := strings.Split(line, "|")
+    for i, c := range cells {
+        cells[i] = strings.TrimSpace(formatInline(c))
+    }
+    return cells
+}
+
+func renderTable(header []string, rows [][]string, mode string) []string {
+    widths := make([]int, len(header))
+    measure := func(cells []string) {
+        for i, c := range cells {
+            if i >= len(widths) {
+                widths = append(widths, 0)
+            }
+            if n := utf8.RuneCountInString(plain(c)); n > widths[i] {
+                widths[i] = n
+            }
+        }
+    }
+    measure(header)
+    for _, r := range rows {
+        measure(r)
+    }
+    total := 2 * (len(widths) - 1)
+    for _, w := range widths {
+        total += w
+    }
+
+    switch mode {
+    case TableMonospace:
+    case TableBullets:
+        return tableBullets(header, rows)
+    default:
+        if total > maxMonospaceWidth {
+            return tableBullets(header, rows)
+        }
+    }
+
+    // WhatsApp does not render *bold* inside ```, so the markers are dropped
+    out := []string{"```"}
+    out = append(out, padRow(header, widths))
+    seps := make([]string, len(widths))
+    for i, w := range widths {
+        seps[i] = strings.Repeat("-", w)
+    }
+    out = append(out, strings.Join(seps, "  "))
+    for _, r := range rows {
+        out = append(out, padRow(r, widths))
+    }
+    return append(out, "```")
+}
+
+func padRow(cells []string, widths []int) string {
+    parts := make([]string, len(widths))
+    for i, w := range widths {
+        c := ""
+        if i < len(cells) {
+            c = plain(cells[i])
+        }
+        parts[i] = c + strings.Repeat(" ", w-utf8.RuneCountInString(c))
+    }
+    return strings.TrimRight(strings.Join(parts, "  "), " ")
+}
+
+// tableBullets summarises each row as "• *first cell* — Header: value; ...".
+func tableBullets(header []string, rows [][]string) []string {
+    out := make([]string, 0, len(rows))
+    for _, r := range rows {
+        if len(r) == 0 || strings.Join(r, "") == "" {
+            continue
+        }
+        var fields []string
+        for i := 1; i < len(r); i++ {
+            if r[i] == "" {
+                continue
+            }
+            if i < len(header) && header[i] != "" && len(r) > 2 {
+                fields = append(fields, plain(header[i])+": "+r[i])
+            } else {
+                fields = append(fields, r[i])
+            }
+        }
+        line := "• *" + plain(r[0]) + "*"
+        if len(fields) > 0 {
+            sep := " — "
+            if len(r) == 2 {
+                sep = ": "
+            }
+            line += sep + strings.Join(fields, "; ")
+        }
+        out = append(out, line)
+    }
+    return out
+}
+
+func plain(s string) string {
+    return waMarkersRe.ReplaceAllString(s, "")
+}
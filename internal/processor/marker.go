@@ -0,0 +1,68 @@
+package processor
+
+import "strings"
+
+// Invisible variant marker: the tag is written bit by bit with zero-width
+// characters between two word joiners, so WhatsApp shows nothing but the text
+// still carries the A/B variant when it is copied, forwarded or exported.
+const (
+    markerEdge = "\u2060" // word joiner
+    markerZero = '\u200b' // zero-width space
+    markerOne  = '\u200c' // zero-width non-joiner
+)
+
+// maxMarkerTag bounds the encoded tag (8 invisible runes per byte).
+const maxMarkerTag = 32
+
+// VariantMarker encodes tag as an invisible suffix ("" for an empty or too long tag).
+func VariantMarker(tag string) string {
+    if tag == "" || len(tag) > maxMarkerTag {
+        return ""
+    }
+    var b strings.Builder
+    b.WriteString(markerEdge)
+    for i := 0; i < len(tag); i++ {
+        for bit := 7; bit >= 0; bit-- {
+            if tag[i]>>bit&1 == 1 {
+                b.WriteRune(markerOne)
+            } else {
+                b.WriteRune(markerZero)
+            }
+        }
+    }
+    b.WriteString(markerEdge)
+    return b.String()
+}
+
+// ExtractVariantMarker removes a trailing marker from s and returns the decoded tag
+// ("" when there is none).
+func ExtractVariantMarker(s string) (clean, tag string) {
+    end := strings.LastIndex(s, markerEdge)
+    if end < 0 {
+        return s, ""
+    }
+    start := strings.LastIndex(s[:end], markerEdge)
+    if start < 0 {
+        return s, ""
+    }
+    bits := []rune(s[start+len(markerEdge) : end])
+    if len(bits) == 0 || len(bits)%8 != 0 {
+        return s, ""
+    }
+    out := make([]byte, 0, len(bits)/8)
+    for i := 0; i < len(bits); i += 8 {
+        var c byte
+        for _, r := range bits[i : i+8] {
+            switch r {
+            case markerZero:
+                c <<= 1
+            case markerOne:
+                c = c<<1 | 1
+            default:
+                return s, ""
+            }
+        }
+        out = append(out, c)
+    }
+    return s[:start] + s[end+len(markerEdge):], string(out)
+}
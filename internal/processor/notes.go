@@ -0,0 +1,41 @@
+package processor
+
+import (
+    "regexp"
+    "strings"
+)
+
+// Note is an internal note the assistant wrote to itself. Key is set for
+// <note key="...">, which also records the text as a client attribute.
+type Note struct {
+    Key  string
+    Text string
+}
+
+var (
+    noteRe         = regexp.MustCompile(`(?is)<note(?:\s+key\s*=\s*["']([^"']*)["'])?\s*>(.*?)</note\s*>`)
+    openNoteRe     = regexp.MustCompile(`(?is)<note\b[^>]*>.*$`)
+    blankLinesRe   = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+    spacesBeforeNl = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// ExtractNotes removes <note>...</note> blocks from the reply and returns them
+// separately. An unclosed <note> swallows the rest of the reply, so a malformed
+// note never reaches the user.
+func ExtractNotes(s string) (string, []Note) {
+    if !strings.Contains(strings.ToLower(s), "<note") {
+        return s, nil
+    }
+    var notes []Note
+    out := noteRe.ReplaceAllStringFunc(s, func(m string) string {
+        sm := noteRe.FindStringSubmatch(m)
+        if text := strings.TrimSpace(sm[2]); text != "" {
+            notes = append(notes, Note{Key: strings.ToLower(strings.TrimSpace(sm[1])), Text: text})
+        }
+        return ""
+    })
+    out = openNoteRe.ReplaceAllString(out, "")
+    out = spacesBeforeNl.ReplaceAllString(out, "\n")
+    out = blankLinesRe.ReplaceAllString(out, "\n\n")
+    return strings.TrimSpace(out), notes
+}
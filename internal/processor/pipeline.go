@@ -0,0 +1,167 @@
+package processor
+
+import (
+    "context"
+    "regexp"
+    "slices"
+    "sync"
+)
+
+// Direction is the way a text travels through the bot.
+type Direction int
+
+const (
+    Inbound  Direction = iota // client → assistant
+    Outbound                  // assistant → client
+)
+
+// Text is the unit processed by a Pipeline. Content is what goes on (to the
+// assistant when inbound, to WhatsApp when outbound); History is the version to
+// persist, when a stage made it differ from Content.
+type Text struct {
+    Direction Direction
+    Kind      string // message type: text, audio, image, document...
+    Content   string
+    History   string // "" = same as Content
+    Original  string // History before masking, set by the redact stage when it changed the text
+    MaxChars  int    // limit applied by the truncate stage (0 = none)
+    Truncated bool   // set by the truncate stage when it cut Content
+}
+
+// Stored returns the version of the text to persist.
+func (t *Text) Stored() string {
+    if t.History != "" {
+        return t.History
+    }
+    return t.Content
+}
+
+// Stage transforms a Text in place.
+type Stage func(ctx context.Context, t *Text)
+
+type namedStage struct {
+    name  string
+    stage Stage
+}
+
+// Pipeline is an ordered chain of named stages, safe for concurrent use. The
+// zero value is an empty pipeline.
+type Pipeline struct {
+    mu     sync.RWMutex
+    stages []namedStage
+}
+
+// Use appends a stage. A stage with the same name is replaced in place.
+func (p *Pipeline) Use(name string, s Stage) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    for i := range p.stages {
+        if p.stages[i].name == name {
+            p.stages[i].stage = s
+            return
+        }
+    }
+    p.stages = append(p.stages, namedStage{name, s})
+}
+
+// Remove drops the stage with the given name, if any.
+func (p *Pipeline) Remove(name string) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.stages = slices.DeleteFunc(p.stages, func(s namedStage) bool { return s.name == name })
+}
+
+// Names returns the stage names in execution order.
+func (p *Pipeline) Names() []string {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+    out := make([]string, len(p.stages))
+    for i, s := range p.stages {
+        out[i] = s.name
+    }
+    return out
+}
+
+// Run passes t through every stage, in order, and returns the result.
+func (p *Pipeline) Run(ctx context.Context, t Text) Text {
+    p.mu.RLock()
+    stages := slices.Clone(p.stages)
+    p.mu.RUnlock()
+    for _, s := range stages {
+        s.stage(ctx, &t)
+    }
+    return t
+}
+
+var (
+    hooksMu sync.Mutex
+    hooks   []namedStage
+)
+
+// Register adds a custom stage to every pipeline built by NewPipeline afterwards
+// (call it from an init function). Hooks run after the built-in stages, in
+// registration order.
+func Register(name string, s Stage) {
+    hooksMu.Lock()
+    defer hooksMu.Unlock()
+    hooks = append(hooks, namedStage{name, s})
+}
+
+// Masker hides sensitive data in a text; *redact.Redactor implements it.
+type Masker interface {
+    Apply(text string) (string, bool)
+}
+
+// NewPipeline returns the default chain: sanitize → redact (when m is not nil)
+// → truncate → registered hooks.
+func NewPipeline(m Masker) *Pipeline {
+    p := &Pipeline{}
+    p.Use("sanitize", Sanitize)
+    if m != nil {
+        p.Use("redact", Redact(m))
+    }
+    p.Use("truncate", TruncateStage)
+    hooksMu.Lock()
+    defer hooksMu.Unlock()
+    for _, h := range hooks {
+        p.Use(h.name, h.stage)
+    }
+    return p
+}
+
+var refRe = regexp.MustCompile(`【[^】]+】`)
+
+// RemoveRefs drops file_search citation markers such as 【4:0†source】.
+func RemoveRefs(s string) string {
+    return refRe.ReplaceAllString(s, "")
+}
+
+// Sanitize removes citation markers and stray brackets from both versions of t.
+func Sanitize(_ context.Context, t *Text) {
+    t.Content = SanitizeText(RemoveRefs(t.Content))
+    if t.History != "" {
+        t.History = SanitizeText(RemoveRefs(t.History))
+    }
+}
+
+// Redact masks the persisted version of t; Content (what the assistant or the
+// client sees) is left untouched.
+func Redact(m Masker) Stage {
+    return func(_ context.Context, t *Text) {
+        stored := t.Stored()
+        if masked, changed := m.Apply(stored); changed {
+            t.Original, t.History = stored, masked
+        }
+    }
+}
+
+// TruncateStage cuts inbound Content to MaxChars; History keeps the full text.
+func TruncateStage(_ context.Context, t *Text) {
+    if t.Direction != Inbound || t.MaxChars <= 0 {
+        return
+    }
+    if cut := Truncate(t.Content, t.MaxChars); cut != t.Content {
+        t.History = t.Stored()
+        t.Content, t.Truncated = cut, true
+    }
+}
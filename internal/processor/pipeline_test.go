@@ -0,0 +1,54 @@
+package processor
+
+import (
+    "context"
+    "strings"
+    "testing"
+
+    "github.com/your-org/leandro-agent/internal/redact"
+)
+
+// benchText mixes a citation marker, variables and a greeting, so every stage
+// has work to do.
+var benchText = "【4:0†fonte】 Olá, {{primeiro_nome}}! Seu horário é {{data_hoje}} às 10h. " +
+    "CPF 529.982.247-25. " + strings.Repeat("Mais detalhes. ", 20)
+
+func BenchmarkPipeline(b *testing.B) {
+    ctx := context.Background()
+    pipelines := []struct {
+        name string
+        p    *Pipeline
+    }{
+        {"default", NewPipeline(nil)},
+        {"redact", NewPipeline(redact.New(redact.Config{Profanity: true, PII: true}))},
+    }
+    for _, pl := range pipelines {
+        for _, dir := range []Direction{Inbound, Outbound} {
+            name := pl.name + "/inbound"
+            if dir == Outbound {
+                name = pl.name + "/outbound"
+            }
+            b.Run(name, func(b *testing.B) {
+                b.ReportAllocs()
+                for i := 0; i < b.N; i++ {
+                    pl.p.Run(ctx, Text{Direction: dir, Kind: "text", Content: benchText, MaxChars: 500})
+                }
+            })
+        }
+    }
+}
+
+func BenchmarkInterpolate(b *testing.B) {
+    vars := map[string]string{"primeiro_nome": "Maria", "data_hoje": "01/02/2025", "nome": "Maria Silva"}
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        Interpolate(benchText, vars)
+    }
+}
+
+func BenchmarkStripGreeting(b *testing.B) {
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        StripGreeting(benchText)
+    }
+}
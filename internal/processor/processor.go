@@ -1,6 +1,9 @@
 package processor
 
-import "strings"
+import (
+    "strconv"
+    "strings"
+)
 
 // SanitizeText performs simple cleaning of the text: removes certain tags used
 // by the original n8n flow (\u3010 and \u3011) and trims whitespace.
@@ -8,4 +11,17 @@ func SanitizeText(s string) string {
     s = strings.ReplaceAll(s, "\u3010", "")
     s = strings.ReplaceAll(s, "\u3011", "")
     return strings.TrimSpace(s)
-}
\ No newline at end of file
+}
+// Truncate cuts s to at most max characters (runes), appending a marker that
+// tells the model how much was left out. max <= 0 disables it.
+func Truncate(s string, max int) string {
+    if max <= 0 {
+        return s
+    }
+    r := []rune(s)
+    if len(r) <= max {
+        return s
+    }
+    omitted := len(r) - max
+    return strings.TrimRight(string(r[:max]), " \t\n") + "\n\n[mensagem truncada, " + strconv.Itoa(omitted) + " caracteres omitidos]"
+}
@@ -0,0 +1,29 @@
+package processor
+
+import (
+    "regexp"
+    "strings"
+)
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.]+)\s*\}\}`)
+var spaceBeforePunctRe = regexp.MustCompile(`[ \t]+([,.!?;:])`)
+
+// Interpolate replaces {{name}} placeholders with values from vars (keys are
+// case-insensitive). Unknown placeholders are removed instead of reaching the
+// user, and spaces left dangling before punctuation are collapsed.
+func Interpolate(s string, vars map[string]string) string {
+    if !strings.Contains(s, "{{") {
+        return s
+    }
+    lower := make(map[string]string, len(vars))
+    for k, v := range vars {
+        lower[strings.ToLower(k)] = v
+    }
+    out := placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+        key := strings.ToLower(placeholderRe.FindStringSubmatch(m)[1])
+        return lower[key]
+    })
+    out = spaceBeforePunctRe.ReplaceAllString(out, "$1")
+    out = strings.ReplaceAll(out, "  ", " ")
+    return strings.TrimSpace(out)
+}
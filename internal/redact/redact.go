@@ -0,0 +1,147 @@
+// internal/redact/redact.go
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Config escolhe o que é mascarado antes de gravar as mensagens.
+type Config struct {
+	Profanity bool     // palavrões (lista padrão + Words)
+	PII       bool     // CPF e números de cartão (validados por dígito verificador/Luhn)
+	Words     []string // palavras extras tratadas como palavrão
+}
+
+// Redactor mascara o texto conforme Config. nil não mascara nada.
+type Redactor struct {
+	cfg   Config
+	words map[string]bool
+}
+
+// defaultWords são os palavrões mais comuns em português (sem acento, minúsculos).
+var defaultWords = []string{
+	"porra", "caralho", "merda", "bosta", "puta", "puto", "putaria", "foda", "fodase",
+	"foder", "fudeu", "fodido", "cacete", "buceta", "boceta", "arrombado", "arrombada",
+	"viado", "corno", "babaca", "otario", "otaria", "vagabundo", "vagabunda", "desgracado",
+	"desgracada", "cuzao", "piranha", "pqp", "vsf", "fdp", "krl", "crl",
+}
+
+// New monta o Redactor; devolve nil se nada estiver habilitado.
+func New(cfg Config) *Redactor {
+	if !cfg.Profanity && !cfg.PII {
+		return nil
+	}
+	r := &Redactor{cfg: cfg, words: map[string]bool{}}
+	if cfg.Profanity {
+		for _, w := range append(append([]string{}, defaultWords...), cfg.Words...) {
+			if w = fold(strings.TrimSpace(w)); w != "" {
+				r.words[w] = true
+			}
+		}
+	}
+	return r
+}
+
+// Apply devolve o texto mascarado e se algo mudou.
+func (r *Redactor) Apply(text string) (string, bool) {
+	if r == nil || text == "" {
+		return text, false
+	}
+	out := text
+	if r.cfg.PII {
+		out = cardRe.ReplaceAllStringFunc(out, maskCard)
+		out = cpfRe.ReplaceAllStringFunc(out, maskCPF)
+	}
+	if r.cfg.Profanity {
+		out = wordRe.ReplaceAllStringFunc(out, func(w string) string {
+			if !r.words[fold(w)] {
+				return w
+			}
+			first, size := utf8.DecodeRuneInString(w)
+			return string(first) + strings.Repeat("*", utf8.RuneCountInString(w[size:]))
+		})
+	}
+	return out, out != text
+}
+
+var (
+	wordRe = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	// CPF com ou sem pontuação; o dígito verificador descarta telefones e afins
+	cpfRe = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`)
+	// 13 a 19 dígitos, agrupados ou não por espaço/hífen; Luhn confirma
+	cardRe = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+func maskCPF(s string) string {
+	d := digits(s)
+	if len(d) != 11 || !validCPF(d) {
+		return s
+	}
+	return "***.***.***-**"
+}
+
+func maskCard(s string) string {
+	d := digits(s)
+	if len(d) < 13 || len(d) > 19 || !luhn(d) {
+		return s
+	}
+	return "**** **** **** " + d[len(d)-4:]
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// validCPF confere os dois dígitos verificadores (e rejeita 000..., 111...).
+func validCPF(d string) bool {
+	if strings.Count(d, d[:1]) == len(d) {
+		return false
+	}
+	for _, n := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(d[i]-'0') * (n + 1 - i)
+		}
+		v := sum * 10 % 11
+		if v == 10 {
+			v = 0
+		}
+		if v != int(d[n]-'0') {
+			return false
+		}
+	}
+	return true
+}
+
+func luhn(d string) bool {
+	sum, double := 0, false
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if double {
+			if n *= 2; n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+)
+
+// fold normaliza para comparação: minúsculo e sem acentos.
+func fold(s string) string {
+	return accents.Replace(strings.ToLower(s))
+}
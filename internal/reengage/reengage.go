@@ -0,0 +1,255 @@
+// internal/reengage/reengage.go
+package reengage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/clock"
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/scheduler"
+	"github.com/your-org/leandro-agent/internal/uazapi"
+)
+
+// Job envia, uma vez por noite (REENGAGE_HOUR), uma mensagem personalizada aos
+// clientes que pararam de responder. Respeita opt-out e espaça os envios.
+type Job struct {
+	cfg  config.Config
+	pool *pgxpool.Pool
+	ai   *openai.Client
+	wpp  *uazapi.Client
+
+	sched   *scheduler.Scheduler
+	clock   clock.Clock
+	deliver Deliver
+}
+
+// Deliver grava a mensagem no histórico antes de chamar send e registra o
+// resultado (envio em duas fases do webhook, handlers/outbox.go).
+type Deliver func(ctx context.Context, phone string, m models.Message, send func(context.Context) (uazapi.SendResult, error)) (uazapi.SendResult, error)
+
+// Nudge é uma mensagem gerada (e enviada, fora do modo simulação) para um cliente.
+type Nudge struct {
+	Phone   string `json:"phone"`
+	Message string `json:"message"`
+	Sent    bool   `json:"sent"`
+	Error   string `json:"error,omitempty"`
+}
+
+func New(cfg config.Config, pool *pgxpool.Pool, ai *openai.Client, wpp *uazapi.Client) *Job {
+	return &Job{cfg: cfg, pool: pool, ai: ai, wpp: wpp, clock: clock.Real}
+}
+
+// WithScheduler faz a rodada diária acontecer em uma só réplica.
+func (j *Job) WithScheduler(s *scheduler.Scheduler) *Job {
+	j.sched = s
+	return j
+}
+
+// WithDeliver faz os envios passarem pelo histórico em duas fases. Sem ele a
+// mensagem é gravada depois do envio.
+func (j *Job) WithDeliver(d Deliver) *Job {
+	j.deliver = d
+	return j
+}
+
+// WithClock troca o relógio da rodada diária e do espaçamento entre envios (testes).
+func (j *Job) WithClock(c clock.Clock) *Job {
+	j.clock = clock.Or(c)
+	return j
+}
+
+// Start roda o loop diário até o ctx ser cancelado.
+func (j *Job) Start(ctx context.Context) {
+	if !j.cfg.ReengageEnabled {
+		return
+	}
+	loc := j.cfg.Location()
+	for {
+		now := j.clock.Now().In(loc)
+		next := time.Date(now.Year(), now.Month(), now.Day(), j.cfg.ReengageHour, 0, 0, 0, loc)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.clock.After(next.Sub(now)):
+		}
+		_, err := j.sched.Once(ctx, "reengage", next, func(ctx context.Context) error {
+			_, err := j.Run(ctx, false)
+			return err
+		})
+		if err != nil {
+			log.Printf("reengage error: %v", err)
+		}
+	}
+}
+
+// Run seleciona os candidatos, gera as mensagens e (se !dry) envia com intervalo
+// de REENGAGE_PACE_SECONDS entre clientes.
+func (j *Job) Run(ctx context.Context, dry bool) ([]Nudge, error) {
+	cands, err := models.ListReengageCandidates(ctx, j.pool, models.ReengageQuery{
+		MinAge:      time.Duration(j.cfg.ReengageAfterDays) * 24 * time.Hour,
+		MaxAge:      time.Duration(j.cfg.ReengageMaxDays) * 24 * time.Hour,
+		Tag:         j.cfg.ReengageTag,
+		MaxAttempts: j.cfg.ReengageMaxAttempts,
+		Limit:       j.cfg.ReengageBatch,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pace := time.Duration(j.cfg.ReengagePaceSeconds) * time.Second
+	out := make([]Nudge, 0, len(cands))
+	for i, c := range cands {
+		if i > 0 && !dry && pace > 0 {
+			select {
+			case <-ctx.Done():
+				return out, ctx.Err()
+			case <-j.clock.After(pace):
+			}
+		}
+		n := Nudge{Phone: c.Phone}
+		if !dry && !j.reachable(ctx, c.Phone) {
+			n.Error = "number is not on WhatsApp"
+			out = append(out, n)
+			continue
+		}
+		msg, err := j.compose(ctx, c)
+		if err != nil {
+			n.Error = err.Error()
+			out = append(out, n)
+			continue
+		}
+		n.Message = msg
+		if !dry {
+			if err := j.send(ctx, c, msg); err != nil {
+				n.Error = err.Error()
+				if errors.Is(err, uazapi.ErrNotOnWhatsApp) {
+					// sem WhatsApp: não entra mais como candidato a cada noite
+					if _, merr := models.MarkClientUnreachable(ctx, j.pool, c.Phone); merr != nil {
+						log.Printf("reengage mark unreachable error: %v", merr)
+					}
+				}
+			} else {
+				n.Sent = true
+			}
+		}
+		out = append(out, n)
+	}
+	if !dry {
+		log.Printf("reengage: %d candidates processed", len(out))
+	}
+	return out, nil
+}
+
+// compose pede ao modelo uma mensagem curta usando o histórico e os fatos do cliente.
+func (j *Job) compose(ctx context.Context, c models.ReengageCandidate) (string, error) {
+	var b strings.Builder
+	if c.Name != nil && *c.Name != "" {
+		fmt.Fprintf(&b, "Nome do cliente: %s\n", *c.Name)
+	}
+	fmt.Fprintf(&b, "Última interação: %s\n", c.LastAt.In(j.cfg.Location()).Format("02/01/2006"))
+	if facts, err := models.GetClientFacts(ctx, j.pool, c.ClientID); err == nil && len(facts) > 0 {
+		b.WriteString("\nO que sabemos do cliente:\n")
+		for _, f := range facts {
+			fmt.Fprintf(&b, "- %s: %s\n", f.Key, f.Value)
+		}
+	}
+	if s, err := models.GetClientSummary(ctx, j.pool, c.ClientID); err == nil && s.Text != "" {
+		b.WriteString("\nResumo da conversa:\n" + s.Text + "\n")
+	}
+	if msgs, err := models.RecentMessages(ctx, j.pool, c.ClientID, 10); err == nil && len(msgs) > 0 {
+		b.WriteString("\nÚltimas mensagens:\n")
+		for _, m := range msgs {
+			who := "Cliente"
+			if m.Role == "assistant" || m.Role == "operator" {
+				who = "Atendente"
+			}
+			content := m.Content
+			if len(content) > 400 {
+				content = content[:400]
+			}
+			fmt.Fprintf(&b, "%s: %s\n", who, content)
+		}
+	}
+
+	msg, err := j.ai.ChatComplete(ctx,
+		"Você é o atendente de uma empresa no WhatsApp e vai retomar contato com um cliente que parou de responder. "+
+			"Escreva UMA mensagem curta (até 3 frases), cordial e personalizada com base no histórico, retomando o assunto "+
+			"e convidando a continuar a conversa. Não invente ofertas, preços ou prazos. Responda só com a mensagem, em Português.",
+		b.String(), 200)
+	if err != nil {
+		return "", err
+	}
+	msg = strings.TrimSpace(strings.Trim(msg, `"`))
+	if msg == "" {
+		return "", fmt.Errorf("empty nudge")
+	}
+	if f := strings.TrimSpace(j.cfg.ReengageFooter); f != "" {
+		msg += "\n\n" + f
+	}
+	return msg, nil
+}
+
+// reachable verifica o número antes de gastar a mensagem (NUMBER_CHECK_*); o
+// resultado fica no cliente e, sem WhatsApp, ele deixa de ser candidato.
+func (j *Job) reachable(ctx context.Context, phone string) bool {
+	if !j.cfg.NumberCheckEnabled {
+		return true
+	}
+	_, at, err := models.ClientNumberCheck(ctx, j.pool, phone)
+	if err == nil && at != nil && j.clock.Now().Sub(*at) < time.Duration(j.cfg.NumberCheckCacheHours)*time.Hour {
+		return true // verificado recentemente; sem WhatsApp nem seria candidato
+	}
+	res, err := j.wpp.CheckNumberExists(ctx, phone)
+	if err != nil {
+		log.Printf("reengage number check %s error: %v", phone, err)
+		return true
+	}
+	if err := models.RecordNumberCheck(ctx, j.pool, phone, res.Exists); err != nil {
+		log.Printf("reengage number check record error: %v", err)
+	}
+	return res.Exists
+}
+
+func (j *Job) send(ctx context.Context, c models.ReengageCandidate, msg string) error {
+	m := models.Message{ClientID: c.ClientID, Role: "assistant", Type: "text", Content: msg}
+	send := func(ctx context.Context) (uazapi.SendResult, error) {
+		return j.wpp.SendText(uazapi.WithPriority(ctx, uazapi.PriorityBulk), c.Phone, msg)
+	}
+	if j.deliver != nil {
+		if _, err := j.deliver(ctx, c.Phone, m, send); err != nil {
+			return err
+		}
+	} else {
+		res, err := send(ctx)
+		if err != nil {
+			return err
+		}
+		if res.MessageID != "" {
+			m.ExtID = &res.MessageID
+		}
+		if err := models.InsertMessage(ctx, j.pool, m); err != nil {
+			log.Printf("reengage insert message error: %v", err)
+		}
+	}
+	if err := models.RecordReengagement(ctx, j.pool, c.ClientID, msg); err != nil {
+		log.Printf("reengage record error: %v", err)
+	}
+	// a thread precisa saber da mensagem para a resposta do cliente fazer sentido
+	if c.ThreadID != nil && *c.ThreadID != "" {
+		if err := j.ai.AddAssistantMessage(ctx, *c.ThreadID, msg); err != nil {
+			log.Printf("reengage thread message error: %v", err)
+		}
+	}
+	return nil
+}
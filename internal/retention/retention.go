@@ -0,0 +1,195 @@
+// internal/retention/retention.go
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/clock"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+	"github.com/your-org/leandro-agent/internal/scheduler"
+)
+
+// Job aplica a política de retenção (LGPD): apaga mensagens antigas e threads
+// inativas (inclusive na OpenAI), registrando cada expurgo em purge_audit.
+type Job struct {
+	pool     *pgxpool.Pool
+	ai       *openai.Client
+	days     int
+	interval time.Duration
+	sched    *scheduler.Scheduler
+	clock    clock.Clock
+}
+
+func New(pool *pgxpool.Pool, ai *openai.Client, days int, interval time.Duration) *Job {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &Job{pool: pool, ai: ai, days: days, interval: interval, clock: clock.Real}
+}
+
+// WithScheduler faz cada intervalo rodar em uma só réplica.
+func (j *Job) WithScheduler(s *scheduler.Scheduler) *Job {
+	j.sched = s
+	return j
+}
+
+// WithClock troca o relógio do intervalo e do corte de idade (testes).
+func (j *Job) WithClock(c clock.Clock) *Job {
+	j.clock = clock.Or(c)
+	return j
+}
+
+// Start executa a política periodicamente. Com days <= 0, não faz nada.
+func (j *Job) Start(ctx context.Context) {
+	if j.days <= 0 {
+		return
+	}
+	t := j.clock.NewTicker(j.interval)
+	defer t.Stop()
+	for {
+		if _, err := j.sched.Once(ctx, "retention", j.clock.Now().Truncate(j.interval), j.RunOnce); err != nil {
+			log.Printf("retention error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+	}
+}
+
+// RunOnce expurga threads inativas e mensagens mais antigas que o limite.
+func (j *Job) RunOnce(ctx context.Context) error {
+	cutoff := j.clock.Now().AddDate(0, 0, -j.days)
+
+	// Threads primeiro: depois que as mensagens somem, a última atividade se perde.
+	for {
+		stale, err := models.ListStaleThreads(ctx, j.pool, cutoff, 100)
+		if err != nil {
+			return err
+		}
+		if len(stale) == 0 {
+			break
+		}
+		for _, s := range stale {
+			if err := j.purgeThread(ctx, s.ClientID, s.Phone, s.ThreadID, "retention"); err != nil {
+				return err
+			}
+		}
+	}
+
+	n, err := models.PurgeMessagesBefore(ctx, j.pool, cutoff)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		detail := fmt.Sprintf("retention: messages before %s", cutoff.Format(time.RFC3339))
+		if err := models.RecordPurge(ctx, j.pool, "messages", nil, detail, n); err != nil {
+			return err
+		}
+		log.Printf("retention purged %d messages", n)
+	}
+
+	// Memória de imagens (VISION_FOLLOWUP) vai junto com as mensagens
+	n, err = models.PurgeClientImagesBefore(ctx, j.pool, cutoff)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		detail := fmt.Sprintf("retention: images before %s", cutoff.Format(time.RFC3339))
+		if err := models.RecordPurge(ctx, j.pool, "client_images", nil, detail, n); err != nil {
+			return err
+		}
+		log.Printf("retention purged %d remembered images", n)
+	}
+
+	// Nonces vencidos do webhook assinado (sem dado pessoal: não entram no registro de expurgos)
+	n, err = models.PurgeExpiredWebhookNonces(ctx, j.pool)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("retention purged %d expired webhook nonces", n)
+	}
+	return nil
+}
+
+func (j *Job) purgeThread(ctx context.Context, clientID int64, phone, threadID, reason string) error {
+	return purgeThread(ctx, j.pool, j.ai, clientID, phone, threadID, reason)
+}
+
+func purgeThread(ctx context.Context, pool *pgxpool.Pool, ai *openai.Client, clientID int64, phone, threadID, reason string) error {
+	if err := ai.DeleteThread(ctx, threadID); err != nil {
+		return fmt.Errorf("delete thread %s: %w", threadID, err)
+	}
+	if err := purgeFiles(ctx, pool, ai, clientID); err != nil {
+		return err
+	}
+	if err := models.ClearClientThread(ctx, pool, clientID); err != nil {
+		return err
+	}
+	return models.RecordPurge(ctx, pool, "thread", &phone, reason+": "+threadID, 1)
+}
+
+// purgeFiles apaga na OpenAI os documentos do cliente (DOCUMENT_FILE_SEARCH).
+func purgeFiles(ctx context.Context, pool *pgxpool.Pool, ai *openai.Client, clientID int64) error {
+	files, err := models.ListClientThreadFiles(ctx, pool, clientID)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := ai.DeleteFile(ctx, f.FileID); err != nil {
+			return fmt.Errorf("delete file %s: %w", f.FileID, err)
+		}
+		if err := models.MarkThreadFileDeleted(ctx, pool, f.FileID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteClient apaga o cliente (mensagens e fatos em cascata) e a thread remota.
+// Retorna ok=false se o telefone não existir.
+func (j *Job) DeleteClient(ctx context.Context, phone, reason string) (bool, error) {
+	c, ok, err := models.GetClientByPhone(ctx, j.pool, phone)
+	if err != nil || !ok {
+		return ok, err
+	}
+	_, err = EraseClient(ctx, j.pool, j.ai, c, reason)
+	return true, err
+}
+
+// EraseClient apaga tudo do cliente: thread e documentos na OpenAI (com a chave de
+// ai, a do tenant), o cadastro com o que depende dele (em cascata) e as linhas
+// guardadas só pelo telefone, com registro em purge_audit. Devolve quantas linhas
+// foram apagadas (mensagens e linhas do telefone).
+func EraseClient(ctx context.Context, pool *pgxpool.Pool, ai *openai.Client, c models.Client, reason string) (int64, error) {
+	if c.ThreadID != nil && *c.ThreadID != "" {
+		if err := purgeThread(ctx, pool, ai, c.ID, c.Phone, *c.ThreadID, reason); err != nil {
+			return 0, err
+		}
+	} else if err := purgeFiles(ctx, pool, ai, c.ID); err != nil {
+		return 0, err
+	}
+	// exclusão e registro de auditoria na mesma transação
+	var total int64
+	err := models.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		n, err := models.DeleteClient(ctx, tx, c.ID)
+		if err != nil {
+			return err
+		}
+		m, err := models.DeletePhoneRows(ctx, tx, c.Phone)
+		if err != nil {
+			return err
+		}
+		total = n + m
+		return models.RecordPurge(ctx, tx, "client", &c.Phone, reason, total)
+	})
+	return total, err
+}
@@ -0,0 +1,125 @@
+// internal/scheduler/scheduler.go
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/clock"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+/*
+Execução única de jobs entre réplicas.
+
+Cada réplica continua com seu próprio relógio (ticker, horário do digest...); na
+hora de rodar, pede o lease do job em scheduled_jobs para a janela (slot) atual.
+Só quem consegue o lease roda, e a janela fica marcada: uma réplica atrasada não
+roda o mesmo dia/intervalo de novo. O lease é renovado enquanto o job roda; se a
+réplica morrer, ele expira e a próxima janela roda normalmente.
+*/
+
+// DefaultLease é o lease inicial; renovado a cada terço enquanto o job roda.
+const DefaultLease = 2 * time.Minute
+
+// Scheduler coordena os jobs pela tabela scheduled_jobs. O zero-value de *Scheduler
+// (nil) roda tudo localmente, sem coordenação.
+type Scheduler struct {
+	db    models.DB
+	owner string
+	lease time.Duration
+	clock clock.Clock
+}
+
+// New cria o coordenador desta réplica (dono = host:pid).
+func New(db models.DB) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{db: db, owner: fmt.Sprintf("%s:%d", host, os.Getpid()), lease: DefaultLease, clock: clock.Real}
+}
+
+// WithClock troca o relógio da renovação do lease (testes).
+func (s *Scheduler) WithClock(c clock.Clock) *Scheduler {
+	if s != nil {
+		s.clock = clock.Or(c)
+	}
+	return s
+}
+
+// Owner identifica a réplica nos leases.
+func (s *Scheduler) Owner() string {
+	if s == nil {
+		return ""
+	}
+	return s.owner
+}
+
+// Once roda fn se esta réplica conseguir o lease do job para slot. ran=false
+// quando outra réplica já rodou (ou está rodando) a janela. O erro devolvido é o
+// de fn ou o da coordenação.
+func (s *Scheduler) Once(ctx context.Context, name string, slot time.Time, fn func(context.Context) error) (bool, error) {
+	if s == nil {
+		return true, run(ctx, name, fn)
+	}
+	ok, err := models.AcquireJob(ctx, s.db, name, s.owner, slot, s.lease)
+	if err != nil {
+		return false, fmt.Errorf("job %s lease: %w", name, err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go s.heartbeat(runCtx, name, cancel, done)
+	runErr := run(runCtx, name, fn)
+	cancel()
+	<-done
+
+	// o ctx do job pode ter sido cancelado; o resultado ainda precisa ser gravado
+	fctx, fcancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer fcancel()
+	if err := models.FinishJob(fctx, s.db, name, s.owner, runErr); err != nil {
+		log.Printf("job %s finish error: %v", name, err)
+	}
+	return true, runErr
+}
+
+// run executa fn convertendo um panic em erro: o job fica registrado como falho
+// e o processo segue.
+func run(ctx context.Context, name string, fn func(context.Context) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("job %s panic: %v\n%s", name, v, debug.Stack())
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return fn(ctx)
+}
+
+// heartbeat renova o lease até ctx acabar; perdido o lease, cancela o job.
+func (s *Scheduler) heartbeat(ctx context.Context, name string, cancel context.CancelFunc, done chan<- struct{}) {
+	defer close(done)
+	t := s.clock.NewTicker(s.lease / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+		ok, err := models.RenewJob(ctx, s.db, name, s.owner, s.lease)
+		if err != nil {
+			log.Printf("job %s renew error: %v", name, err)
+			continue
+		}
+		if !ok {
+			log.Printf("job %s lost its lease, cancelling", name)
+			cancel()
+			return
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// internal/settings/settings.go
+package settings
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/models"
+)
+
+// Store lê os ajustes por instância (bot_settings) com cache em memória,
+// para deploys com vários números no mesmo processo.
+type Store struct {
+	pool *pgxpool.Pool
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]entry
+}
+
+type entry struct {
+	s     models.BotSettings
+	ok    bool
+	until time.Time
+}
+
+func New(pool *pgxpool.Pool, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &Store{pool: pool, ttl: ttl, cache: make(map[string]entry)}
+}
+
+// Get devolve os ajustes da instância (cacheados por ttl). Erros de leitura
+// são logados e tratados como "sem ajustes" para não travar o atendimento.
+func (s *Store) Get(ctx context.Context, instance string) (models.BotSettings, bool) {
+	if instance == "" {
+		return models.BotSettings{}, false
+	}
+	s.mu.Lock()
+	e, hit := s.cache[instance]
+	s.mu.Unlock()
+	if hit && time.Now().Before(e.until) {
+		return e.s, e.ok
+	}
+
+	bs, ok, err := models.GetBotSettings(ctx, s.pool, instance)
+	if err != nil {
+		log.Printf("bot settings load error (%s): %v", instance, err)
+		if hit {
+			return e.s, e.ok // mantém o último valor conhecido
+		}
+		return models.BotSettings{}, false
+	}
+	s.mu.Lock()
+	s.cache[instance] = entry{s: bs, ok: ok, until: time.Now().Add(s.ttl)}
+	s.mu.Unlock()
+	return bs, ok
+}
+
+// Invalidate descarta o cache da instância (após alteração pela API).
+func (s *Store) Invalidate(instance string) {
+	s.mu.Lock()
+	delete(s.cache, instance)
+	s.mu.Unlock()
+}
+
+// Apply devolve uma cópia de base com os ajustes da instância aplicados.
+func (s *Store) Apply(ctx context.Context, instance string, base config.Config) config.Config {
+	bs, ok := s.Get(ctx, instance)
+	if !ok {
+		return base
+	}
+	cfg := base
+	if bs.ReplyDelayMinMs != nil {
+		cfg.ReplyDelayMinMs = *bs.ReplyDelayMinMs
+	}
+	if bs.ReplyDelayMaxMs != nil {
+		cfg.ReplyDelayMaxMs = *bs.ReplyDelayMaxMs
+	}
+	if cfg.ReplyDelayMaxMs > 0 && cfg.ReplyDelayMaxMs < cfg.ReplyDelayMinMs {
+		cfg.ReplyDelayMaxMs = cfg.ReplyDelayMinMs
+	}
+	if bs.TTSVoice != nil && *bs.TTSVoice != "" {
+		cfg.TTSVoice = *bs.TTSVoice
+	}
+	if bs.TTSSpeed != nil && *bs.TTSSpeed > 0 {
+		cfg.TTSSpeed = *bs.TTSSpeed
+	}
+	if bs.BufferTimeoutSeconds != nil && *bs.BufferTimeoutSeconds > 0 {
+		cfg.BufferTimeoutSeconds = *bs.BufferTimeoutSeconds
+	}
+	if bs.BusinessHours != nil && *bs.BusinessHours != "" {
+		vars := make(map[string]string, len(base.BusinessVars)+1)
+		for k, v := range base.BusinessVars {
+			vars[k] = v
+		}
+		vars["horario_funcionamento"] = *bs.BusinessHours
+		cfg.BusinessVars = vars
+	}
+	if bs.RunTemperature != nil {
+		cfg.RunTemperature = *bs.RunTemperature
+	}
+	if bs.RunTopP != nil {
+		cfg.RunTopP = *bs.RunTopP
+	}
+	if bs.RunMaxPromptTokens != nil {
+		cfg.RunMaxPromptTokens = *bs.RunMaxPromptTokens
+	}
+	if bs.RunMaxCompletionTokens != nil {
+		cfg.RunMaxCompletionTokens = *bs.RunMaxCompletionTokens
+	}
+	if bs.RunTruncation != nil {
+		cfg.RunTruncation = *bs.RunTruncation
+	}
+	if bs.RunReasoningEffort != nil {
+		cfg.RunReasoningEffort = *bs.RunReasoningEffort
+	}
+	return cfg
+}
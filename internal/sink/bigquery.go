@@ -0,0 +1,211 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BigQuery usa a API REST (tables.insert + tabledata.insertAll) autenticada
+// com uma service account (JWT assinado localmente, sem SDK).
+type BigQuery struct {
+	project string
+	dataset string
+	http    *http.Client
+
+	email    string
+	key      *rsa.PrivateKey
+	tokenURI string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewBigQuery(project, dataset, credentialsFile string) (*BigQuery, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var sa struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+		ProjectID   string `json:"project_id"`
+	}
+	if err := json.Unmarshal(raw, &sa); err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, errors.New("bigquery: invalid private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("bigquery: private key is not RSA")
+	}
+	if project == "" {
+		project = sa.ProjectID
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &BigQuery{
+		project:  project,
+		dataset:  dataset,
+		http:     &http.Client{Timeout: 60 * time.Second},
+		email:    sa.ClientEmail,
+		key:      key,
+		tokenURI: sa.TokenURI,
+	}, nil
+}
+
+// accessToken troca um JWT assinado por um access token OAuth (cacheado até expirar).
+func (b *BigQuery) accessToken(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.token != "" && time.Now().Before(b.expires) {
+		return b.token, nil
+	}
+
+	enc := base64.RawURLEncoding
+	now := time.Now()
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   b.email,
+		"scope": "https://www.googleapis.com/auth/bigquery",
+		"aud":   b.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	signing := header + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, b.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", signing+"."+enc.EncodeToString(sig))
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, b.tokenURI, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bigquery token status %d: %s", resp.StatusCode, string(body))
+	}
+	var tr struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", err
+	}
+	b.token = tr.AccessToken
+	b.expires = now.Add(time.Duration(tr.ExpiresIn)*time.Second - time.Minute)
+	return b.token, nil
+}
+
+func (b *BigQuery) post(ctx context.Context, path string, body any) (int, []byte, error) {
+	tok, err := b.accessToken(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	buf, _ := json.Marshal(body)
+	u := "https://bigquery.googleapis.com/bigquery/v2/projects/" + b.project + "/datasets/" + b.dataset + path
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(buf))
+	req.Header.Set("Authorization", "Bearer "+tok)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, rb, nil
+}
+
+func bigqueryType(t string) string {
+	switch t {
+	case "int":
+		return "INT64"
+	case "bool":
+		return "BOOL"
+	case "time":
+		return "TIMESTAMP"
+	default:
+		return "STRING"
+	}
+}
+
+// EnsureSchema cria as tabelas no dataset; 409 (já existe) é ignorado.
+func (b *BigQuery) EnsureSchema(ctx context.Context, tables []Table) error {
+	for _, t := range tables {
+		fields := make([]map[string]string, 0, len(t.Columns))
+		for _, c := range t.Columns {
+			fields = append(fields, map[string]string{"name": c.Name, "type": bigqueryType(c.Type)})
+		}
+		body := map[string]any{
+			"tableReference": map[string]string{"projectId": b.project, "datasetId": b.dataset, "tableId": t.Name},
+			"schema":         map[string]any{"fields": fields},
+		}
+		code, rb, err := b.post(ctx, "/tables", body)
+		if err != nil {
+			return err
+		}
+		if code > 299 && code != http.StatusConflict {
+			return fmt.Errorf("bigquery create %s status %d: %s", t.Name, code, string(rb))
+		}
+	}
+	return nil
+}
+
+// Insert usa streaming insert; insertId = tabela-id deduplica reenvios (best effort).
+func (b *BigQuery) Insert(ctx context.Context, t Table, rows []Row) error {
+	items := make([]map[string]any, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, map[string]any{
+			"insertId": fmt.Sprintf("%s-%v", t.Name, r["id"]),
+			"json":     encodeRow(r),
+		})
+	}
+	code, rb, err := b.post(ctx, "/tables/"+t.Name+"/insertAll", map[string]any{"rows": items})
+	if err != nil {
+		return err
+	}
+	if code > 299 {
+		return fmt.Errorf("bigquery insert %s status %d: %s", t.Name, code, string(rb))
+	}
+	var out struct {
+		InsertErrors []json.RawMessage `json:"insertErrors"`
+	}
+	if json.Unmarshal(rb, &out) == nil && len(out.InsertErrors) > 0 {
+		return fmt.Errorf("bigquery insert %s: %d row errors: %s", t.Name, len(out.InsertErrors), string(out.InsertErrors[0]))
+	}
+	return nil
+}
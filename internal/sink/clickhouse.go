@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClickHouse usa a interface HTTP (porta 8123) com INSERT ... FORMAT JSONEachRow.
+type ClickHouse struct {
+	baseURL  string
+	database string
+	user     string
+	password string
+	http     *http.Client
+}
+
+func NewClickHouse(baseURL, database, user, password string) *ClickHouse {
+	if database == "" {
+		database = "default"
+	}
+	return &ClickHouse{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		database: database,
+		user:     user,
+		password: password,
+		http:     &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (c *ClickHouse) exec(ctx context.Context, query string, body []byte) error {
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("database", c.database)
+	q.Set("date_time_input_format", "best_effort")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/?"+q.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if c.user != "" {
+		req.Header.Set("X-ClickHouse-User", c.user)
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("clickhouse status %d: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func clickhouseType(t string) string {
+	switch t {
+	case "int":
+		return "Int64"
+	case "bool":
+		return "Bool"
+	case "time":
+		return "DateTime64(3, 'UTC')"
+	default:
+		return "String"
+	}
+}
+
+// EnsureSchema cria as tabelas com ReplacingMergeTree (deduplica reexportações do backfill).
+func (c *ClickHouse) EnsureSchema(ctx context.Context, tables []Table) error {
+	for _, t := range tables {
+		cols := make([]string, 0, len(t.Columns))
+		for _, col := range t.Columns {
+			cols = append(cols, col.Name+" "+clickhouseType(col.Type))
+		}
+		ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) ENGINE = ReplacingMergeTree ORDER BY id",
+			t.Name, strings.Join(cols, ", "))
+		if err := c.exec(ctx, ddl, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ClickHouse) Insert(ctx context.Context, t Table, rows []Row) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range rows {
+		if err := enc.Encode(encodeRow(r)); err != nil {
+			return err
+		}
+	}
+	return c.exec(ctx, "INSERT INTO "+t.Name+" FORMAT JSONEachRow", buf.Bytes())
+}
+
+// encodeRow converte tempos para RFC3339 em UTC (aceito por ClickHouse e BigQuery).
+func encodeRow(r Row) map[string]any {
+	out := make(map[string]any, len(r))
+	for k, v := range r {
+		if tm, ok := v.(time.Time); ok {
+			out[k] = tm.UTC().Format(time.RFC3339Nano)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+// internal/sink/sink.go
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/config"
+)
+
+/*
+Sink opcional de analytics: exporta, em lotes e de forma incremental, as tabelas
+do Postgres para um data warehouse (BigQuery ou ClickHouse).
+
+- Cada tabela tem um watermark (maior id já exportado) em sink_state.
+- EnsureSchema cria as tabelas no destino se não existirem.
+- Backfill reposiciona o watermark numa data e reexporta dali em diante.
+*/
+
+// Column descreve uma coluna exportada. Type: "int" | "string" | "bool" | "time".
+type Column struct {
+	Name string
+	Type string
+}
+
+// Table é uma tabela exportada. Query recebe ($1 = último id, $2 = limite) e deve
+// devolver as colunas na ordem de Columns, com o id na primeira posição.
+type Table struct {
+	Name    string
+	Columns []Column
+	Query   string
+	// SinceQuery devolve o menor id com created_at >= $1 (usado no backfill).
+	SinceQuery string
+}
+
+// Row é uma linha exportada (nome da coluna -> valor).
+type Row map[string]any
+
+// Sink é o destino dos dados.
+type Sink interface {
+	EnsureSchema(ctx context.Context, tables []Table) error
+	Insert(ctx context.Context, table Table, rows []Row) error
+}
+
+// Tables exportadas por padrão.
+var Tables = []Table{
+	{
+		Name: "messages",
+		Columns: []Column{
+			{"id", "int"}, {"client_id", "int"}, {"phone", "string"}, {"role", "string"},
+			{"type", "string"}, {"content", "string"}, {"ext_id", "string"}, {"created_at", "time"},
+		},
+		Query: `SELECT m.id, m.client_id, c.phone, m.role, m.type, m.content, COALESCE(m.ext_id, ''), m.created_at
+			FROM messages m JOIN clients c ON c.id = m.client_id
+			WHERE m.id > $1 ORDER BY m.id LIMIT $2`,
+		SinceQuery: `SELECT COALESCE(MIN(id), 0) FROM messages WHERE created_at >= $1`,
+	},
+	{
+		Name: "clients",
+		Columns: []Column{
+			{"id", "int"}, {"phone", "string"}, {"name", "string"}, {"created_at", "time"},
+		},
+		Query: `SELECT id, phone, COALESCE(name, ''), created_at
+			FROM clients WHERE id > $1 ORDER BY id LIMIT $2`,
+		SinceQuery: `SELECT COALESCE(MIN(id), 0) FROM clients WHERE created_at >= $1`,
+	},
+	{
+		Name: "failures",
+		Columns: []Column{
+			{"id", "int"}, {"phone", "string"}, {"stage", "string"}, {"error", "string"}, {"created_at", "time"},
+		},
+		Query: `SELECT id, phone, stage, error, created_at
+			FROM failures WHERE id > $1 ORDER BY id LIMIT $2`,
+		SinceQuery: `SELECT COALESCE(MIN(id), 0) FROM failures WHERE created_at >= $1`,
+	},
+	{
+		Name: "client_notes",
+		Columns: []Column{
+			{"id", "int"}, {"client_id", "int"}, {"phone", "string"}, {"note", "string"},
+			{"author", "string"}, {"created_at", "time"},
+		},
+		Query: `SELECT n.id, n.client_id, c.phone, n.note, n.author, n.created_at
+			FROM client_notes n JOIN clients c ON c.id = n.client_id
+			WHERE n.id > $1 ORDER BY n.id LIMIT $2`,
+		SinceQuery: `SELECT COALESCE(MIN(id), 0) FROM client_notes WHERE created_at >= $1`,
+	},
+	{
+		// tags adicionadas (remoções não são exportadas)
+		Name: "client_tags",
+		Columns: []Column{
+			{"id", "int"}, {"client_id", "int"}, {"phone", "string"}, {"tag", "string"},
+			{"created_by", "string"}, {"created_at", "time"},
+		},
+		Query: `SELECT t.id, t.client_id, c.phone, t.tag, COALESCE(t.created_by, ''), t.created_at
+			FROM client_tags t JOIN clients c ON c.id = t.client_id
+			WHERE t.id > $1 ORDER BY t.id LIMIT $2`,
+		SinceQuery: `SELECT COALESCE(MIN(id), 0) FROM client_tags WHERE created_at >= $1`,
+	},
+}
+
+// New cria o sink configurado em SINK_KIND. Retorna nil se desativado.
+func New(cfg config.Config) (Sink, error) {
+	switch cfg.SinkKind {
+	case "":
+		return nil, nil
+	case "clickhouse":
+		return NewClickHouse(cfg.ClickHouseURL, cfg.ClickHouseDatabase, cfg.ClickHouseUser, cfg.ClickHousePassword), nil
+	case "bigquery":
+		return NewBigQuery(cfg.BigQueryProject, cfg.BigQueryDataset, cfg.BigQueryCredentialsFile)
+	default:
+		return nil, fmt.Errorf("unknown SINK_KIND %q", cfg.SinkKind)
+	}
+}
+
+// Exporter move lotes do Postgres para o Sink.
+type Exporter struct {
+	pool     *pgxpool.Pool
+	sink     Sink
+	batch    int
+	interval time.Duration
+}
+
+func NewExporter(pool *pgxpool.Pool, s Sink, batch int, interval time.Duration) *Exporter {
+	if batch <= 0 {
+		batch = 500
+	}
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &Exporter{pool: pool, sink: s, batch: batch, interval: interval}
+}
+
+// Run garante o schema e exporta periodicamente até o ctx ser cancelado.
+func (e *Exporter) Run(ctx context.Context) {
+	if err := e.sink.EnsureSchema(ctx, Tables); err != nil {
+		log.Printf("sink schema error: %v", err)
+	}
+	t := time.NewTicker(e.interval)
+	defer t.Stop()
+	for {
+		if _, err := e.ExportAll(ctx); err != nil {
+			log.Printf("sink export error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// ExportAll exporta todas as tabelas até alcançar o fim. Retorna o total de linhas.
+func (e *Exporter) ExportAll(ctx context.Context) (int, error) {
+	total := 0
+	var errs []error
+	for _, t := range Tables {
+		for {
+			n, err := e.exportBatch(ctx, t)
+			total += n
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", t.Name, err))
+				break
+			}
+			if n < e.batch {
+				break
+			}
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// Backfill reposiciona os watermarks para since e reexporta tudo dali em diante.
+// Destinos sem deduplicação podem receber linhas repetidas.
+func (e *Exporter) Backfill(ctx context.Context, since time.Time) (int, error) {
+	if err := e.sink.EnsureSchema(ctx, Tables); err != nil {
+		return 0, err
+	}
+	for _, t := range Tables {
+		var minID int64
+		if err := e.pool.QueryRow(ctx, t.SinceQuery, since).Scan(&minID); err != nil {
+			return 0, err
+		}
+		last := int64(0)
+		if minID > 0 {
+			last = minID - 1
+		} else {
+			// nada a partir de since: posiciona no fim
+			if err := e.pool.QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM `+t.Name).Scan(&last); err != nil {
+				return 0, err
+			}
+		}
+		if err := e.setWatermark(ctx, t.Name, last); err != nil {
+			return 0, err
+		}
+	}
+	return e.ExportAll(ctx)
+}
+
+func (e *Exporter) watermark(ctx context.Context, name string) (int64, error) {
+	var id int64
+	err := e.pool.QueryRow(ctx, `SELECT last_id FROM sink_state WHERE name=$1`, name).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+func (e *Exporter) setWatermark(ctx context.Context, name string, id int64) error {
+	_, err := e.pool.Exec(ctx, `
+		INSERT INTO sink_state (name, last_id) VALUES ($1,$2)
+		ON CONFLICT (name) DO UPDATE SET last_id=EXCLUDED.last_id, updated_at=now()
+	`, name, id)
+	return err
+}
+
+func (e *Exporter) exportBatch(ctx context.Context, t Table) (int, error) {
+	last, err := e.watermark(ctx, t.Name)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := e.pool.Query(ctx, t.Query, last, e.batch)
+	if err != nil {
+		return 0, err
+	}
+	var out []Row
+	var maxID int64
+	for rows.Next() {
+		vals, err := rows.Values()
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		r := make(Row, len(t.Columns))
+		for i, c := range t.Columns {
+			if i < len(vals) {
+				r[c.Name] = vals[i]
+			}
+		}
+		if id, ok := vals[0].(int64); ok && id > maxID {
+			maxID = id
+		}
+		out = append(out, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(out) == 0 {
+		return 0, nil
+	}
+	if err := e.sink.Insert(ctx, t, out); err != nil {
+		return 0, err
+	}
+	return len(out), e.setWatermark(ctx, t.Name, maxID)
+}
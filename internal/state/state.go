@@ -0,0 +1,145 @@
+// Package state reúne os contêineres de estado em memória compartilhado entre
+// goroutines (webhook, flush do buffer, workers em background, rotas admin).
+//
+// Regras de posse:
+//
+//   - cada contêiner pertence a um único WebhookHandler (um por tenant) e vive
+//     enquanto ele; o estado do processo inteiro (contadores, tenants) fica em
+//     ponteiros compartilhados, como antes;
+//   - o valor zero é utilizável e os contêineres não podem ser copiados depois do
+//     primeiro uso (go vet acusa, pelo mutex interno);
+//   - as funções passadas a Update e Range rodam com o lock: não podem bloquear,
+//     fazer I/O nem chamar o mesmo contêiner. Range percorre uma cópia;
+//   - valores guardados não são copiados: slices e ponteiros devolvidos não devem
+//     ser alterados fora de Update.
+package state
+
+import "sync"
+
+// Map é um mapa tipado protegido por mutex (substitui sync.Map e os pares
+// mutex + map espalhados nos handlers).
+type Map[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]V
+}
+
+// Load devolve o valor de k.
+func (m *Map[K, V]) Load(k K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.m[k]
+	return v, ok
+}
+
+// Store grava v em k.
+func (m *Map[K, V]) Store(k K, v V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = make(map[K]V)
+	}
+	m.m[k] = v
+}
+
+// LoadOrStore devolve o valor de k se existir (loaded true); senão grava v.
+func (m *Map[K, V]) LoadOrStore(k K, v V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cur, ok := m.m[k]; ok {
+		return cur, true
+	}
+	if m.m == nil {
+		m.m = make(map[K]V)
+	}
+	m.m[k] = v
+	return v, false
+}
+
+// LoadAndDelete remove k e devolve o valor que havia.
+func (m *Map[K, V]) LoadAndDelete(k K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.m[k]
+	delete(m.m, k)
+	return v, ok
+}
+
+// Delete remove k.
+func (m *Map[K, V]) Delete(k K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.m, k)
+}
+
+// Update troca o valor de k pelo que fn devolver, atomicamente; keep false remove k.
+func (m *Map[K, V]) Update(k K, fn func(cur V, ok bool) (next V, keep bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur, ok := m.m[k]
+	next, keep := fn(cur, ok)
+	if !keep {
+		delete(m.m, k)
+		return
+	}
+	if m.m == nil {
+		m.m = make(map[K]V)
+	}
+	m.m[k] = next
+}
+
+// DeleteFunc remove as chaves para as quais fn devolve true.
+func (m *Map[K, V]) DeleteFunc(fn func(k K, v V) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, v := range m.m {
+		if fn(k, v) {
+			delete(m.m, k)
+		}
+	}
+}
+
+// Len devolve o número de chaves.
+func (m *Map[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.m)
+}
+
+// Range chama fn para cada par de uma cópia do mapa (fn pode usar o Map); para
+// quando fn devolve false.
+func (m *Map[K, V]) Range(fn func(k K, v V) bool) {
+	m.mu.Lock()
+	snap := make(map[K]V, len(m.m))
+	for k, v := range m.m {
+		snap[k] = v
+	}
+	m.mu.Unlock()
+	for k, v := range snap {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// InFlight marca tarefas em andamento por chave, para não rodar duas vezes a
+// mesma (ex.: o resumo de uma conversa).
+type InFlight[K comparable] struct {
+	m Map[K, struct{}]
+}
+
+// Acquire marca k; devolve false se já estava marcada. Quem recebe true chama
+// Release ao terminar (normalmente com defer).
+func (f *InFlight[K]) Acquire(k K) bool {
+	_, busy := f.m.LoadOrStore(k, struct{}{})
+	return !busy
+}
+
+// Release desmarca k.
+func (f *InFlight[K]) Release(k K) {
+	f.m.Delete(k)
+}
+
+// Len devolve quantas tarefas estão em andamento.
+func (f *InFlight[K]) Len() int {
+	return f.m.Len()
+}
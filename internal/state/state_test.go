@@ -0,0 +1,139 @@
+package state
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Os testes abaixo valem sobretudo com -race: várias goroutines usam o mesmo
+// contêiner ao mesmo tempo, como o webhook, o flush do buffer e as rotas admin.
+
+const (
+	workers = 16
+	rounds  = 500
+)
+
+func parallel(fn func(w int)) {
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(w)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMapZeroValue(t *testing.T) {
+	var m Map[string, int]
+	if _, ok := m.Load("x"); ok {
+		t.Fatal("Load on zero Map found a value")
+	}
+	m.Delete("x")
+	if v, ok := m.LoadAndDelete("x"); ok || v != 0 {
+		t.Fatalf("LoadAndDelete on zero Map = %d, %v", v, ok)
+	}
+	m.Range(func(string, int) bool { t.Fatal("Range on zero Map called fn"); return false })
+	if v, loaded := m.LoadOrStore("x", 1); loaded || v != 1 {
+		t.Fatalf("LoadOrStore() = %d, %v; want 1, false", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("x", 2); !loaded || v != 1 {
+		t.Fatalf("LoadOrStore() = %d, %v; want 1, true", v, loaded)
+	}
+}
+
+func TestMapConcurrentUpdate(t *testing.T) {
+	var m Map[string, int]
+	parallel(func(int) {
+		for i := 0; i < rounds; i++ {
+			m.Update("n", func(cur int, _ bool) (int, bool) { return cur + 1, true })
+		}
+	})
+	if v, _ := m.Load("n"); v != workers*rounds {
+		t.Fatalf("counter = %d, want %d", v, workers*rounds)
+	}
+	m.Update("n", func(int, bool) (int, bool) { return 0, false })
+	if m.Len() != 0 {
+		t.Fatalf("Len() = %d after Update with keep=false, want 0", m.Len())
+	}
+}
+
+func TestMapConcurrentAccess(t *testing.T) {
+	var m Map[int, []int]
+	parallel(func(w int) {
+		for i := 0; i < rounds; i++ {
+			k := i % 32
+			switch (w + i) % 6 {
+			case 0:
+				m.Store(k, []int{w, i})
+			case 1:
+				m.Load(k)
+			case 2:
+				m.LoadOrStore(k, []int{w})
+			case 3:
+				m.LoadAndDelete(k)
+			case 4:
+				// Range percorre uma cópia: fn pode usar o próprio Map
+				m.Range(func(k int, _ []int) bool {
+					m.Delete(k)
+					return true
+				})
+			case 5:
+				m.DeleteFunc(func(k int, v []int) bool { return len(v) > 1 && k%2 == 0 })
+				_ = m.Len()
+			}
+		}
+	})
+	m.DeleteFunc(func(int, []int) bool { return true })
+	if m.Len() != 0 {
+		t.Fatalf("Len() = %d after DeleteFunc(all), want 0", m.Len())
+	}
+}
+
+func TestInFlightExclusive(t *testing.T) {
+	var (
+		f       InFlight[int64]
+		running atomic.Int32
+		ran     atomic.Int32
+	)
+	parallel(func(int) {
+		for i := 0; i < rounds; i++ {
+			if !f.Acquire(42) {
+				continue
+			}
+			if n := running.Add(1); n != 1 {
+				t.Errorf("%d holders of the same key", n)
+			}
+			ran.Add(1)
+			running.Add(-1)
+			f.Release(42)
+		}
+	})
+	if ran.Load() == 0 {
+		t.Fatal("no goroutine acquired the key")
+	}
+	if f.Len() != 0 {
+		t.Fatalf("Len() = %d after all releases, want 0", f.Len())
+	}
+}
+
+func TestInFlightKeys(t *testing.T) {
+	var f InFlight[int]
+	parallel(func(w int) {
+		if !f.Acquire(w) {
+			t.Errorf("Acquire(%d) = false on a free key", w)
+		}
+	})
+	if f.Len() != workers {
+		t.Fatalf("Len() = %d, want %d", f.Len(), workers)
+	}
+	if f.Acquire(0) {
+		t.Fatal("Acquire on a held key = true")
+	}
+	parallel(f.Release)
+	if !f.Acquire(0) {
+		t.Fatal("Acquire after Release = false")
+	}
+}
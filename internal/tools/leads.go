@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+// RegisterLeadTools expõe create_lead, update_status e get_status ao assistente.
+func RegisterLeadTools(r *Registry, pool *pgxpool.Pool) {
+	r.Register(Tool{
+		Def: openai.FunctionTool{
+			Name:        "create_lead",
+			Description: "Registra um lead ou pedido do cliente atual (ex.: orçamento solicitado, compra combinada).",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind":    map[string]any{"type": "string", "enum": []string{"lead", "order"}, "description": "lead (interesse) ou order (pedido fechado)"},
+					"title":   map[string]any{"type": "string", "description": "Resumo curto, ex.: 'Orçamento de 3 portas de vidro'"},
+					"details": map[string]any{"type": "string", "description": "Detalhes relevantes (quantidades, prazos, endereço)"},
+					"value":   map[string]any{"type": "number", "description": "Valor estimado em reais, se conhecido"},
+				},
+				"required": []string{"kind", "title"},
+			},
+		},
+		Run: func(ctx context.Context, call Call, args json.RawMessage) (any, error) {
+			var in struct {
+				Kind    string   `json:"kind"`
+				Title   string   `json:"title"`
+				Details string   `json:"details"`
+				Value   *float64 `json:"value"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			if in.Title == "" {
+				return nil, errors.New("title is required")
+			}
+			if in.Kind != "order" {
+				in.Kind = "lead"
+			}
+			l, err := models.CreateLead(ctx, pool, models.Lead{
+				ClientID: call.ClientID, Kind: in.Kind, Title: in.Title, Details: in.Details, Value: in.Value,
+			})
+			if err != nil {
+				return nil, err
+			}
+			// tag usada por filtros como o reengajamento (REENGAGE_TAG=lead)
+			_ = models.AddClientTag(ctx, pool, call.ClientID, in.Kind, "")
+			return l, nil
+		},
+	})
+
+	r.Register(Tool{
+		Def: openai.FunctionTool{
+			Name:        "update_status",
+			Description: "Atualiza o status de um lead/pedido do cliente atual.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":     map[string]any{"type": "integer"},
+					"status": map[string]any{"type": "string", "enum": models.LeadStatuses},
+					"note":   map[string]any{"type": "string", "description": "Observação opcional anexada aos detalhes"},
+				},
+				"required": []string{"id", "status"},
+			},
+		},
+		Run: func(ctx context.Context, call Call, args json.RawMessage) (any, error) {
+			var in struct {
+				ID     int64  `json:"id"`
+				Status string `json:"status"`
+				Note   string `json:"note"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			l, ok, err := models.GetLead(ctx, pool, in.ID)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || l.ClientID != call.ClientID {
+				return nil, fmt.Errorf("lead %d not found for this client", in.ID)
+			}
+			return models.UpdateLead(ctx, pool, in.ID, in.Status, in.Note)
+		},
+	})
+
+	r.Register(Tool{
+		Def: openai.FunctionTool{
+			Name:        "get_status",
+			Description: "Lista os leads/pedidos do cliente atual com seus status (mais recentes primeiro).",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			},
+		},
+		Run: func(ctx context.Context, call Call, _ json.RawMessage) (any, error) {
+			return models.ListLeads(ctx, pool, models.LeadFilter{ClientID: call.ClientID, Limit: 10})
+		},
+	})
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-org/leandro-agent/internal/catalog"
+	"github.com/your-org/leandro-agent/internal/models"
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+// maxProductResults limita os produtos devolvidos por busca.
+const maxProductResults = 8
+
+// productResult é o produto como o assistente recebe: preço já formatado e o
+// texto pronto para o WhatsApp.
+type productResult struct {
+	ID          int64    `json:"id"`
+	SKU         string   `json:"sku,omitempty"`
+	Name        string   `json:"name"`
+	Category    string   `json:"category,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Price       *float64 `json:"price"`
+	PriceText   string   `json:"price_text"`
+	Unit        string   `json:"unit,omitempty"`
+	WhatsApp    string   `json:"whatsapp"`
+}
+
+func toProductResult(p models.Product) productResult {
+	return productResult{
+		ID: p.ID, SKU: p.SKU, Name: p.Name, Category: p.Category, Description: p.Description,
+		Price: p.Price, PriceText: catalog.Price(p.Price), Unit: p.Unit, WhatsApp: catalog.Format(p),
+	}
+}
+
+// notInCatalog orienta o modelo quando nada foi encontrado (não inventar preço).
+const notInCatalog = "Produto não encontrado no catálogo. Não informe preço; ofereça verificar com a equipe."
+
+// RegisterProductTools expõe search_products e get_price: preços vêm do catálogo
+// (tabela products), nunca do modelo.
+func RegisterProductTools(r *Registry, pool *pgxpool.Pool) {
+	r.Register(Tool{
+		Def: openai.FunctionTool{
+			Name:        "search_products",
+			Description: "Busca produtos no catálogo da empresa (tolera erros de digitação). Use sempre que o cliente perguntar por produtos, disponibilidade ou preços; nunca informe preços que não vieram do catálogo.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{"type": "string", "description": "O que o cliente procura, ex.: 'porta de vidro temperado'"},
+					"limit": map[string]any{"type": "integer", "description": "Máximo de resultados (padrão 5)"},
+				},
+				"required": []string{"query"},
+			},
+		},
+		Run: func(ctx context.Context, call Call, args json.RawMessage) (any, error) {
+			var in struct {
+				Query string `json:"query"`
+				Limit int    `json:"limit"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			list, err := models.ListProducts(ctx, pool, true)
+			if err != nil {
+				return nil, err
+			}
+			found := catalog.Search(list, in.Query, min(max(in.Limit, 0), maxProductResults))
+			if len(found) == 0 {
+				return map[string]any{"results": []productResult{}, "message": notInCatalog}, nil
+			}
+			out := make([]productResult, len(found))
+			for i, p := range found {
+				out[i] = toProductResult(p)
+			}
+			return map[string]any{"results": out}, nil
+		},
+	})
+
+	r.Register(Tool{
+		Def: openai.FunctionTool{
+			Name:        "get_price",
+			Description: "Consulta o preço atual de um produto do catálogo pelo id (de search_products), SKU ou nome.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":   map[string]any{"type": "integer"},
+					"sku":  map[string]any{"type": "string"},
+					"name": map[string]any{"type": "string", "description": "Nome do produto, se não houver id/SKU"},
+				},
+			},
+		},
+		Run: func(ctx context.Context, call Call, args json.RawMessage) (any, error) {
+			var in struct {
+				ID   int64  `json:"id"`
+				SKU  string `json:"sku"`
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, err
+			}
+			p, ok, err := findProduct(ctx, pool, in.ID, in.SKU, in.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return map[string]any{"found": false, "message": notInCatalog}, nil
+			}
+			return map[string]any{"found": true, "product": toProductResult(p)}, nil
+		},
+	})
+}
+
+// findProduct resolve id, SKU exato ou o nome mais parecido (só produtos ativos).
+func findProduct(ctx context.Context, pool *pgxpool.Pool, id int64, sku, name string) (models.Product, bool, error) {
+	if id > 0 {
+		p, ok, err := models.GetProduct(ctx, pool, id)
+		return p, ok && p.Active, err
+	}
+	list, err := models.ListProducts(ctx, pool, true)
+	if err != nil {
+		return models.Product{}, false, err
+	}
+	if sku = strings.TrimSpace(sku); sku != "" {
+		for _, p := range list {
+			if strings.EqualFold(p.SKU, sku) {
+				return p, true, nil
+			}
+		}
+	}
+	if strings.TrimSpace(name) == "" {
+		return models.Product{}, false, nil
+	}
+	found := catalog.Search(list, name, 1)
+	if len(found) == 0 {
+		return models.Product{}, false, nil
+	}
+	return found[0], true, nil
+}
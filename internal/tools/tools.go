@@ -0,0 +1,77 @@
+// internal/tools/tools.go
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+// Call identifica o cliente da conversa em que a ferramenta foi chamada.
+type Call struct {
+	ClientID int64
+	Phone    string
+}
+
+// Tool é uma função exposta ao assistente (function calling).
+type Tool struct {
+	Def openai.FunctionTool
+	Run func(ctx context.Context, call Call, args json.RawMessage) (any, error)
+}
+
+// Registry guarda as ferramentas disponíveis, na ordem de registro.
+type Registry struct {
+	tools map[string]Tool
+	order []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+// Register adiciona (ou substitui) uma ferramenta.
+func (r *Registry) Register(t Tool) {
+	if _, ok := r.tools[t.Def.Name]; !ok {
+		r.order = append(r.order, t.Def.Name)
+	}
+	r.tools[t.Def.Name] = t
+}
+
+// Definitions devolve as definições para registrar no assistente.
+func (r *Registry) Definitions() []openai.FunctionTool {
+	out := make([]openai.FunctionTool, 0, len(r.order))
+	for _, name := range r.order {
+		out = append(out, r.tools[name].Def)
+	}
+	return out
+}
+
+// Execute roda a ferramenta e devolve o output em JSON para submit_tool_outputs.
+// Erros viram {"error": "..."} para o modelo poder reagir em vez de a run falhar.
+func (r *Registry) Execute(ctx context.Context, call Call, name, args string) string {
+	t, ok := r.tools[name]
+	if !ok {
+		return errorJSON(fmt.Errorf("unknown tool %q", name))
+	}
+	if args == "" {
+		args = "{}"
+	}
+	res, err := t.Run(ctx, call, json.RawMessage(args))
+	if err != nil {
+		log.Printf("tool %s error: %v", name, err)
+		return errorJSON(err)
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return errorJSON(err)
+	}
+	return string(b)
+}
+
+func errorJSON(err error) string {
+	b, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(b)
+}
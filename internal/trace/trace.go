@@ -0,0 +1,119 @@
+// internal/trace/trace.go
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+/*
+Correlação entre sistemas.
+
+Middleware aceita o X-Request-ID do gateway (ou gera um) e o traceparent W3C
+(ou inicia um trace novo), devolve o X-Request-ID na resposta e guarda os dois no
+ctx. Transport injeta traceparent (com um span novo por chamada) e X-Request-ID
+nas requisições de saída (Uazapi, OpenAI), então uma mesma interação do cliente
+aparece com o mesmo id nos logs do gateway, deste serviço e dos provedores.
+*/
+
+// HeaderRequestID é o cabeçalho de correlação aceito e propagado.
+const HeaderRequestID = "X-Request-ID"
+
+// Info é o contexto de rastreio de uma interação.
+type Info struct {
+	RequestID string // X-Request-ID (do gateway ou gerado)
+	TraceID   string // 32 hex do traceparent
+	ParentID  string // span de quem chamou (16 hex; vazio se o trace começou aqui)
+	Sampled   bool
+}
+
+type ctxKey struct{}
+
+// With devolve ctx carregando info.
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// From devolve o rastreio de ctx (zero-value se não houver).
+func From(ctx context.Context) Info {
+	info, _ := ctx.Value(ctxKey{}).(Info)
+	return info
+}
+
+// RequestID devolve o X-Request-ID de ctx ("" fora de uma requisição).
+func RequestID(ctx context.Context) string {
+	return From(ctx).RequestID
+}
+
+var (
+	traceparentRe = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+	requestIDRe   = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+)
+
+// FromRequest lê X-Request-ID e traceparent da requisição, gerando o que faltar.
+func FromRequest(r *http.Request) Info {
+	var info Info
+	if m := traceparentRe.FindStringSubmatch(strings.TrimSpace(r.Header.Get("traceparent"))); m != nil &&
+		m[1] != strings.Repeat("0", 32) && m[2] != strings.Repeat("0", 16) {
+		info.TraceID, info.ParentID, info.Sampled = m[1], m[2], m[3] == "01"
+	} else {
+		info.TraceID, info.Sampled = newID(16), true
+	}
+	if id := strings.TrimSpace(r.Header.Get(HeaderRequestID)); requestIDRe.MatchString(id) {
+		info.RequestID = id
+	} else {
+		info.RequestID = info.TraceID
+	}
+	return info
+}
+
+// Middleware coloca o rastreio no ctx e devolve o X-Request-ID na resposta.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := FromRequest(r)
+		w.Header().Set(HeaderRequestID, info.RequestID)
+		next.ServeHTTP(w, r.WithContext(With(r.Context(), info)))
+	})
+}
+
+// Transport injeta traceparent e X-Request-ID nas requisições cujo ctx tem
+// rastreio. base nil usa http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	info := From(req.Context())
+	if info.TraceID == "" {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTripper não pode alterar a requisição original
+	out := req.Clone(req.Context())
+	flags := "00"
+	if info.Sampled {
+		flags = "01"
+	}
+	out.Header.Set("traceparent", "00-"+info.TraceID+"-"+newID(8)+"-"+flags)
+	if info.RequestID != "" {
+		out.Header.Set(HeaderRequestID, info.RequestID)
+	}
+	return t.base.RoundTrip(out)
+}
+
+// newID gera n bytes aleatórios em hex.
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
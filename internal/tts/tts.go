@@ -0,0 +1,123 @@
+// internal/tts/tts.go
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+	"github.com/your-org/leandro-agent/internal/openai"
+)
+
+/*
+Provedores de voz (TTS) dos áudios enviados ao cliente.
+
+TTS_PROVIDER escolhe quem gera o áudio: openai (vozes prontas: onyx, nova...) ou
+elevenlabs, que aceita vozes próprias da conta, inclusive clonadas a partir da
+gravação do atendente. Em ambos a voz vem de TTS_VOICE (ou de tts_voice em
+bot_settings, por instância): nome da voz na OpenAI, voice_id na ElevenLabs.
+Os dois devolvem mp3.
+*/
+
+// Provider gera o áudio de text com a voz e a velocidade dadas.
+type Provider interface {
+	Name() string
+	Speech(ctx context.Context, text, voice string, speed float64) ([]byte, error)
+}
+
+// New monta o provedor de cfg.TTSProvider. rt é o transporte das chamadas à
+// ElevenLabs (nil = http.DefaultTransport); a OpenAI usa o do próprio client.
+func New(cfg config.Config, ai *openai.Client, rt http.RoundTripper) Provider {
+	if cfg.TTSProvider == "elevenlabs" {
+		return &ElevenLabs{
+			APIKey:     cfg.ElevenLabsAPIKey,
+			BaseURL:    cfg.ElevenLabsBaseURL,
+			Model:      cfg.ElevenLabsModel,
+			Stability:  cfg.ElevenLabsStability,
+			Similarity: cfg.ElevenLabsSimilarity,
+			HTTP:       &http.Client{Timeout: 60 * time.Second, Transport: rt},
+		}
+	}
+	return OpenAI{Client: ai}
+}
+
+// CacheVoice identifica a voz no cache de áudio (tts_cache). Vozes da OpenAI
+// ficam sem prefixo, para o cache existente continuar valendo.
+func CacheVoice(p Provider, voice string) string {
+	if p.Name() == "openai" {
+		return voice
+	}
+	return p.Name() + ":" + voice
+}
+
+// OpenAI usa o endpoint /audio/speech (tts-1).
+type OpenAI struct {
+	Client *openai.Client
+}
+
+func (OpenAI) Name() string { return "openai" }
+
+func (p OpenAI) Speech(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	return p.Client.GenerateSpeechWith(ctx, text, voice, speed)
+}
+
+// ElevenLabs usa o endpoint text-to-speech da ElevenLabs; voice é o voice_id.
+type ElevenLabs struct {
+	APIKey     string
+	BaseURL    string // "" = https://api.elevenlabs.io
+	Model      string
+	Stability  float64
+	Similarity float64
+	HTTP       *http.Client
+}
+
+func (*ElevenLabs) Name() string { return "elevenlabs" }
+
+func (p *ElevenLabs) Speech(ctx context.Context, text, voice string, speed float64) ([]byte, error) {
+	if voice == "" {
+		return nil, fmt.Errorf("elevenlabs: voice_id required")
+	}
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.elevenlabs.io"
+	}
+	// a ElevenLabs aceita velocidade só entre 0.7 e 1.2
+	speed = min(max(speed, 0.7), 1.2)
+	body, _ := json.Marshal(map[string]any{
+		"text":     text,
+		"model_id": p.Model,
+		"voice_settings": map[string]any{
+			"stability":        p.Stability,
+			"similarity_boost": p.Similarity,
+			"speed":            speed,
+		},
+	})
+	u := base + "/v1/text-to-speech/" + url.PathEscape(voice) + "?output_format=mp3_44100_128"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "audio/mpeg")
+	req.Header.Set("xi-api-key", p.APIKey)
+	client := p.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, fmt.Errorf("elevenlabs tts status %d: %s", resp.StatusCode, bytes.TrimSpace(b))
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,152 @@
+package uazapi
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+Circuit breaker por (base URL, caminho).
+
+Os envios tentam vários caminhos (/send/text, /api/send/text, ...) e cada um ainda
+tem retries; com a instância fora do ar ou um caminho inexistente, toda mensagem
+martelava todos eles. Após N falhas seguidas o caminho fica aberto (pulado) pelo
+cooldown; depois uma única requisição de teste passa (meio-aberto) e, se der certo,
+o caminho volta ao normal.
+
+O estado é do processo: vale para todos os clients (webhook, digest, tenants) que
+falam com a mesma URL.
+*/
+
+// ErrCircuitOpen indica que o caminho está com o circuito aberto e não foi chamado.
+var ErrCircuitOpen = errors.New("uazapi: circuit open")
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerState descreve um caminho para /readyz.
+type BreakerState struct {
+	URL       string     `json:"url"`
+	State     string     `json:"state"`
+	Failures  int        `json:"consecutive_failures"`
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+	Trips     int        `json:"trips"` // quantas vezes abriu desde o start
+}
+
+type breaker struct {
+	failures  int
+	openUntil time.Time
+	probing   bool // meio-aberto: requisição de teste em andamento
+	trips     int
+}
+
+type breakerSet struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	m         map[string]*breaker
+}
+
+var breakers = &breakerSet{threshold: 5, cooldown: 30 * time.Second, m: map[string]*breaker{}}
+
+// SetBreakerPolicy define quantas falhas seguidas abrem o circuito (0 desativa)
+// e por quanto tempo o caminho fica sem ser chamado.
+func SetBreakerPolicy(failures int, cooldown time.Duration) {
+	breakers.mu.Lock()
+	defer breakers.mu.Unlock()
+	breakers.threshold = failures
+	if cooldown > 0 {
+		breakers.cooldown = cooldown
+	}
+}
+
+// Breakers devolve o estado dos caminhos já chamados, ordenados por URL.
+func Breakers() []BreakerState {
+	breakers.mu.Lock()
+	defer breakers.mu.Unlock()
+	now := time.Now()
+	out := make([]BreakerState, 0, len(breakers.m))
+	for url, b := range breakers.m {
+		st := BreakerState{URL: url, State: BreakerClosed, Failures: b.failures, Trips: b.trips}
+		switch {
+		case now.Before(b.openUntil):
+			until := b.openUntil
+			st.State, st.OpenUntil = BreakerOpen, &until
+		case !b.openUntil.IsZero():
+			st.State = BreakerHalfOpen
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
+
+// allow indica se o caminho pode ser chamado agora. Vencido o cooldown, só uma
+// requisição de teste passa até o resultado dela ser registrado.
+func (s *breakerSet) allow(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.threshold <= 0 {
+		return true
+	}
+	b := s.m[url]
+	if b == nil || b.openUntil.IsZero() {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record registra o resultado de uma chamada ao caminho.
+func (s *breakerSet) record(url string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.m[url]
+	if b == nil {
+		b = &breaker{}
+		s.m[url] = b
+	}
+	b.probing = false
+	if ok {
+		b.failures, b.openUntil = 0, time.Time{}
+		return
+	}
+	b.failures++
+	if s.threshold > 0 && (b.failures >= s.threshold || !b.openUntil.IsZero()) {
+		// atingiu o limite ou o teste do meio-aberto falhou: (re)abre
+		now := time.Now()
+		if !now.Before(b.openUntil) {
+			b.trips++
+		}
+		b.openUntil = now.Add(s.cooldown)
+	}
+}
+
+// release libera o teste do meio-aberto sem registrar resultado.
+func (s *breakerSet) release(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b := s.m[url]; b != nil {
+		b.probing = false
+	}
+}
+
+// pathFailure indica resposta de caminho morto ou instância com problema (não
+// conta erros do pedido em si, como número inválido ou sem WhatsApp).
+func pathFailure(code int, body []byte) bool {
+	switch failureKind(code, body) {
+	case ErrNotOnWhatsApp:
+		return false
+	case ErrInvalidToken, ErrDisconnected:
+		return true
+	}
+	return code >= 500 || code == 404 || code == 405
+}
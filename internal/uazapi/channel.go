@@ -0,0 +1,66 @@
+package uazapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/your-org/leandro-agent/internal/phone"
+)
+
+// ErrNotChannel indica um destino que não é um canal (JID @newsletter).
+var ErrNotChannel = errors.New("uazapi: destination is not a newsletter channel")
+
+// ChannelPost é uma publicação em canal: texto, ou mídia (URL pública) com legenda.
+type ChannelPost struct {
+	Text      string
+	MediaType string // image | video | document | audio; vazio = só texto
+	MediaURL  string
+}
+
+// SendToChannel publica num canal do WhatsApp (newsletter) administrado pela
+// instância. channel aceita o JID ("120363...@newsletter") ou só o número.
+// Canais não têm leitura nem "digitando...", então o payload vai sem readchat/delay.
+func (c *Client) SendToChannel(ctx context.Context, channel string, post ChannelPost) (SendResult, error) {
+	jid, ok := phone.Newsletter(channel)
+	if !ok {
+		return SendResult{}, ErrNotChannel
+	}
+	kind, paths := "text", textPaths
+	body := map[string]any{"number": jid}
+	if post.MediaURL != "" {
+		kind, paths = post.MediaType, mediaPaths
+		body["type"] = post.MediaType
+		body["file"] = post.MediaURL
+		if post.Text != "" {
+			body["text"] = post.Text
+		}
+	} else {
+		body["text"] = post.Text
+		body["linkPreview"] = true
+	}
+	if c.dryRun {
+		return c.dryRunResult("channel "+kind, jid, post.Text+post.MediaURL), nil
+	}
+	if err := c.shape(ctx, PriorityBulk); err != nil {
+		return SendResult{}, err
+	}
+
+	var lastCode int
+	var lastBody []byte
+	var lastErr error
+	for _, p := range paths {
+		url := joinURL(c.baseSend, p)
+		code, b, err := c.post(ctx, url, c.tokenSend, body)
+		if err == nil && code >= 200 && code < 300 {
+			return parseSendResult(b), nil
+		}
+		lastCode, lastBody, lastErr = code, b, err
+		if err == nil && failureKind(code, b) != nil {
+			break
+		}
+	}
+	if lastErr != nil {
+		return SendResult{}, lastErr
+	}
+	return SendResult{}, newAPIError("send channel", lastCode, lastBody)
+}
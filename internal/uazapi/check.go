@@ -0,0 +1,87 @@
+package uazapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/your-org/leandro-agent/internal/phone"
+)
+
+// NumberCheck é o resultado da verificação de um número no WhatsApp.
+type NumberCheck struct {
+	Number string // como consultado
+	Exists bool
+	JID    string // JID canônico devolvido (pode trazer o 9º dígito corrigido)
+}
+
+var checkPaths = []string{
+	"/chat/check",
+	"/api/chat/check",
+}
+
+// CheckNumberExists consulta se o número tem conta no WhatsApp (POST /chat/check).
+// Em DRY_RUN não chama a API e considera que existe.
+func (c *Client) CheckNumberExists(ctx context.Context, number string) (NumberCheck, error) {
+	number = phone.Destination(number)
+	if c.dryRun {
+		return NumberCheck{Number: number, Exists: true}, nil
+	}
+	body := map[string]any{"numbers": []string{number}}
+
+	var lastCode int
+	var lastBody []byte
+	var lastErr error
+	for _, p := range checkPaths {
+		url := joinURL(c.baseSend, p)
+		code, b, err := c.post(ctx, url, c.tokenSend, body)
+		if err == nil && code >= 200 && code < 300 {
+			return parseNumberCheck(number, b)
+		}
+		lastCode, lastBody, lastErr = code, b, err
+		if err == nil && failureKind(code, b) != nil {
+			break
+		}
+	}
+	if lastErr != nil {
+		return NumberCheck{}, lastErr
+	}
+	return NumberCheck{}, newAPIError("chat check", lastCode, lastBody)
+}
+
+// parseNumberCheck lê [{"query":"...","isInWhatsapp":true,"jid":"..."}] (algumas
+// versões embrulham em {"numbers":[...]} ou usam "exists").
+func parseNumberCheck(number string, b []byte) (NumberCheck, error) {
+	type item struct {
+		Query        string `json:"query"`
+		IsInWhatsapp *bool  `json:"isInWhatsapp"`
+		Exists       *bool  `json:"exists"`
+		JID          string `json:"jid"`
+	}
+	var items []item
+	if err := json.Unmarshal(b, &items); err != nil {
+		var wrapped struct {
+			Numbers []item `json:"numbers"`
+			Result  []item `json:"result"`
+		}
+		if err := json.Unmarshal(b, &wrapped); err != nil {
+			return NumberCheck{}, fmt.Errorf("uazapi chat check: invalid response: %w", err)
+		}
+		items = append(wrapped.Numbers, wrapped.Result...)
+	}
+	if len(items) == 0 {
+		return NumberCheck{}, fmt.Errorf("uazapi chat check: empty response")
+	}
+	it := items[0]
+	out := NumberCheck{Number: number, JID: it.JID}
+	switch {
+	case it.IsInWhatsapp != nil:
+		out.Exists = *it.IsInWhatsapp
+	case it.Exists != nil:
+		out.Exists = *it.Exists
+	default:
+		out.Exists = strings.HasSuffix(it.JID, "@s.whatsapp.net")
+	}
+	return out, nil
+}
@@ -0,0 +1,232 @@
+package uazapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Download de mídia (fileURL devolvido por /message/download).
+
+CDNs lentas costumam derrubar a conexão no meio do arquivo. Cada tentativa tem
+timeout próprio (WithDownloadTimeout, separado do timeout de envio) e, se já
+recebemos parte dos bytes, a próxima tentativa pede só o restante com
+"Range: bytes=N-". Se o servidor ignorar o Range (200), recomeçamos do zero.
+
+Ao final, o tamanho é conferido com Content-Length/Content-Range e, quando o
+servidor informa, o MD5 com Content-MD5 ou x-goog-hash.
+*/
+
+// ErrIncompleteDownload indica que o arquivo não chegou inteiro após as tentativas.
+var ErrIncompleteDownload = errors.New("uazapi: incomplete media download")
+
+// ErrChecksumMismatch indica que o conteúdo baixado não bate com o hash informado pelo servidor.
+var ErrChecksumMismatch = errors.New("uazapi: media checksum mismatch")
+
+// WithDownloadTimeout define o timeout de cada tentativa de download de mídia.
+func (c *Client) WithDownloadTimeout(d time.Duration) *Client {
+	if d > 0 {
+		c.downloadTimeout = d
+	}
+	return c
+}
+
+// WithDownloadRetries define quantas tentativas extras são feitas ao baixar mídia.
+func (c *Client) WithDownloadRetries(n int) *Client {
+	if n >= 0 {
+		c.downloadRetries = n
+	}
+	return c
+}
+
+// mediaDownload acumula o estado entre tentativas.
+type mediaDownload struct {
+	buf   bytes.Buffer
+	total int64  // tamanho esperado (-1 = desconhecido)
+	md5   []byte // hash informado pelo servidor, se houver
+}
+
+func (c *Client) fetchMedia(ctx context.Context, fileURL string) ([]byte, error) {
+	dl := &mediaDownload{total: -1}
+	var lastErr error
+	for try := 0; try <= c.downloadRetries; try++ {
+		if try > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.backoff * time.Duration(try)):
+			}
+			if c.logReq {
+				fmt.Printf("[uazapi] download retry %d from byte %d: %v\n", try, dl.buf.Len(), lastErr)
+			}
+		}
+		done, err := c.fetchMediaOnce(ctx, fileURL, dl)
+		if done {
+			return dl.finish()
+		}
+		if err == nil {
+			err = ErrIncompleteDownload
+		}
+		if !retryableDownloadErr(err) {
+			return nil, err
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	if dl.buf.Len() > 0 {
+		return nil, fmt.Errorf("%w: got %d of %d bytes: %v", ErrIncompleteDownload, dl.buf.Len(), dl.total, lastErr)
+	}
+	return nil, lastErr
+}
+
+// fetchMediaOnce faz uma tentativa, continuando de onde a anterior parou.
+// Retorna done=true quando o corpo foi lido até o fim.
+func (c *Client) fetchMediaOnce(ctx context.Context, fileURL string, dl *mediaDownload) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.downloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return false, err
+	}
+	offset := int64(dl.buf.Len())
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := c.downloadHTTP.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			// intervalo inesperado: descarta o parcial e recomeça na próxima tentativa
+			dl.buf.Reset()
+			return false, retryable{fmt.Errorf("download media: unexpected Content-Range %q", resp.Header.Get("Content-Range"))}
+		}
+		if total >= 0 {
+			dl.total = total
+		}
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		// servidor sem suporte a Range (ou primeira tentativa): corpo completo
+		dl.buf.Reset()
+		dl.total = resp.ContentLength
+		if resp.StatusCode == http.StatusPartialContent {
+			if _, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok {
+				dl.total = total
+			}
+		}
+		dl.md5 = headerMD5(resp.Header)
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		dl.buf.Reset()
+		return false, retryable{fmt.Errorf("download media %d: range not satisfiable", resp.StatusCode)}
+	default:
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		err := fmt.Errorf("download media %d: %s", resp.StatusCode, string(b))
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return false, retryable{err}
+		}
+		return false, err
+	}
+
+	if _, err := io.Copy(&dl.buf, resp.Body); err != nil {
+		return false, err
+	}
+	if dl.total >= 0 && int64(dl.buf.Len()) < dl.total {
+		return false, io.ErrUnexpectedEOF
+	}
+	return true, nil
+}
+
+// finish confere tamanho e hash do arquivo completo.
+func (dl *mediaDownload) finish() ([]byte, error) {
+	data := dl.buf.Bytes()
+	if dl.total >= 0 && int64(len(data)) != dl.total {
+		return nil, fmt.Errorf("%w: got %d bytes, expected %d", ErrIncompleteDownload, len(data), dl.total)
+	}
+	if dl.md5 != nil {
+		sum := md5.Sum(data)
+		if !bytes.Equal(sum[:], dl.md5) {
+			return nil, ErrChecksumMismatch
+		}
+	}
+	return data, nil
+}
+
+// retryable marca erros HTTP que valem nova tentativa.
+type retryable struct{ error }
+
+func (r retryable) Unwrap() error { return r.error }
+
+func retryableDownloadErr(err error) bool {
+	var r retryable
+	if errors.As(err, &r) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrIncompleteDownload) {
+		return true
+	}
+	return isRetryableNetErr(err)
+}
+
+// parseContentRange lê "bytes start-end/total" (total pode ser "*").
+func parseContentRange(v string) (start, total int64, ok bool) {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "bytes ") {
+		return 0, 0, false
+	}
+	rng, tot, found := strings.Cut(strings.TrimPrefix(v, "bytes "), "/")
+	if !found {
+		return 0, 0, false
+	}
+	s, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if tot != "*" {
+		if total, err = strconv.ParseInt(tot, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
+
+// headerMD5 extrai o MD5 de Content-MD5 ou x-goog-hash (md5=...), ambos em base64.
+func headerMD5(h http.Header) []byte {
+	candidates := []string{h.Get("Content-MD5")}
+	for _, v := range h.Values("X-Goog-Hash") {
+		for _, part := range strings.Split(v, ",") {
+			if k, val, ok := strings.Cut(strings.TrimSpace(part), "="); ok && k == "md5" {
+				candidates = append(candidates, val)
+			}
+		}
+	}
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if b, err := base64.StdEncoding.DecodeString(c); err == nil && len(b) == md5.Size {
+			return b
+		}
+	}
+	return nil
+}
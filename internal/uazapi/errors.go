@@ -0,0 +1,130 @@
+package uazapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+Erros da Uazapi.
+
+O corpo de erro varia entre versões ({"error":"..."}, {"message":"..."},
+{"error":{"message":"..."}}) e antes chegava ao chamador como string crua. Os
+casos que pedem reação diferente viram erros tipados (errors.Is):
+
+	ErrInvalidToken   token recusado (401 / "invalid token")
+	ErrNotOnWhatsApp  o número não tem WhatsApp: não adianta tentar de novo
+	ErrDisconnected   instância desconectada (QR code pendente, sessão caiu)
+
+Com um desses, o envio não tenta os caminhos alternativos nem faz retry: a
+resposta veio da própria Uazapi e seria a mesma.
+*/
+
+var (
+	ErrInvalidToken  = errors.New("uazapi: invalid token")
+	ErrNotOnWhatsApp = errors.New("uazapi: number not on WhatsApp")
+	ErrDisconnected  = errors.New("uazapi: instance disconnected")
+)
+
+// APIError é uma resposta de erro da Uazapi. Unwrap devolve a classe (Kind),
+// então errors.Is(err, ErrNotOnWhatsApp) funciona.
+type APIError struct {
+	Op      string // "send text", "send media", "download"...
+	Status  int
+	Message string // mensagem do corpo (ou o corpo cru, se não for JSON)
+	Kind    error  // ErrInvalidToken | ErrNotOnWhatsApp | ErrDisconnected | nil
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("uazapi %s %d: %s", e.Op, e.Status, e.Message)
+}
+
+func (e *APIError) Unwrap() error { return e.Kind }
+
+func newAPIError(op string, code int, body []byte) *APIError {
+	msg := errorMessage(body)
+	return &APIError{Op: op, Status: code, Message: msg, Kind: classify(code, msg)}
+}
+
+// errorMessage extrai a mensagem dos formatos conhecidos; sem JSON, devolve o corpo.
+func errorMessage(body []byte) string {
+	var out struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Msg     string          `json:"msg"`
+		Details string          `json:"details"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+	var parts []string
+	if len(out.Error) > 0 {
+		var s string
+		var nested struct {
+			Message string `json:"message"`
+		}
+		switch {
+		case json.Unmarshal(out.Error, &s) == nil:
+			parts = append(parts, s)
+		case json.Unmarshal(out.Error, &nested) == nil:
+			parts = append(parts, nested.Message)
+		}
+	}
+	parts = append(parts, out.Message, out.Msg, out.Details)
+	seen := map[string]bool{}
+	var msgs []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" && !seen[p] {
+			seen[p] = true
+			msgs = append(msgs, p)
+		}
+	}
+	if len(msgs) == 0 {
+		return strings.TrimSpace(string(body))
+	}
+	return strings.Join(msgs, ": ")
+}
+
+var (
+	notOnWhatsAppHints = []string{"not on whatsapp", "not exists on whatsapp", "not registered", "not a whatsapp", "não está no whatsapp", "não possui whatsapp", "no whatsapp account", "invalid number", "number not exists", "jid not found"}
+	disconnectedHints  = []string{"disconnected", "not connected", "desconectad", "no session", "not logged", "qrcode", "qr code"}
+	invalidTokenHints  = []string{"invalid token", "token inválido", "token invalido", "unauthorized", "missing token"}
+)
+
+// classify mapeia status + mensagem para a classe do erro (nil = genérico).
+func classify(code int, msg string) error {
+	if code >= 200 && code < 300 {
+		return nil
+	}
+	m := strings.ToLower(msg)
+	switch {
+	case code == http.StatusUnauthorized || containsAny(m, invalidTokenHints):
+		return ErrInvalidToken
+	case containsAny(m, notOnWhatsAppHints):
+		return ErrNotOnWhatsApp
+	case containsAny(m, disconnectedHints):
+		return ErrDisconnected
+	}
+	return nil
+}
+
+// failureKind classifica uma resposta HTTP (nil para sucesso ou erro genérico).
+func failureKind(code int, body []byte) error {
+	if code >= 200 && code < 300 || len(bytes.TrimSpace(body)) == 0 && code != http.StatusUnauthorized {
+		return nil
+	}
+	return classify(code, errorMessage(body))
+}
+
+func containsAny(s string, hints []string) bool {
+	for _, h := range hints {
+		if strings.Contains(s, h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package uazapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/clock"
+)
+
+/*
+Modelagem da taxa de envio por instância (número do WhatsApp).
+
+Rajadas de mensagens de um número, principalmente de um número novo, são o que
+mais leva a banimento. Todo envio de mensagem (texto, mídia, botões, status,
+canal) passa por aqui antes de chamar a Uazapi:
+
+  - no máximo MaxPerMinute mensagens em qualquer janela de 60s;
+  - intervalo mínimo entre mensagens mais um atraso aleatório (Jitter);
+  - rampa de aquecimento de números novos: limite diário nos primeiros dias a
+    partir de RampStart. O limite segura só envios em massa (campanhas,
+    reengajamento, pesquisas, status); respostas a quem escreveu continuam saindo.
+
+Respostas têm prioridade: um envio em massa só sai quando não há resposta
+esperando na fila da instância. O estado é do processo (como o circuit breaker)
+e vale para todos os clients da mesma instância; a contagem diária recomeça num
+restart.
+*/
+
+// Priority é a fila de um envio na instância.
+type Priority int
+
+const (
+	PriorityReply Priority = iota // resposta a uma conversa (padrão)
+	PriorityBulk                  // campanhas, reengajamento, pesquisas, status, canais
+)
+
+type priorityKey struct{}
+
+// WithPriority marca os envios feitos com ctx.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p == PriorityBulk {
+		return p
+	}
+	return PriorityReply
+}
+
+// RampStep limita a PerDay mensagens até o dia Day da rampa (o dia 1 é RampStart).
+type RampStep struct {
+	Day    int
+	PerDay int
+}
+
+// ParseRamp lê "1:50,3:150,7:400" (dia:limite diário), em ordem crescente de dia.
+func ParseRamp(s string) ([]RampStep, error) {
+	var out []RampStep
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, limit, ok := strings.Cut(part, ":")
+		d, err1 := strconv.Atoi(strings.TrimSpace(day))
+		n, err2 := strconv.Atoi(strings.TrimSpace(limit))
+		if !ok || err1 != nil || err2 != nil || d < 1 || n < 1 {
+			return nil, fmt.Errorf("invalid ramp step %q (expected day:limit)", part)
+		}
+		out = append(out, RampStep{Day: d, PerDay: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day < out[j].Day })
+	return out, nil
+}
+
+// ShaperPolicy define a taxa de envio de cada instância. Zero desativa.
+type ShaperPolicy struct {
+	MaxPerMinute int
+	MinInterval  time.Duration
+	Jitter       time.Duration
+	RampStart    time.Time
+	Ramp         []RampStep
+	MaxWait      time.Duration  // espera máxima na fila (0 = só o ctx limita)
+	Location     *time.Location // virada do dia do limite diário
+	Clock        clock.Clock    // nil = clock.Real
+	Rand         clock.Rand     // sorteio do Jitter; nil = clock.Crypto
+}
+
+func (p ShaperPolicy) enabled() bool {
+	return p.MaxPerMinute > 0 || p.MinInterval > 0 || p.Jitter > 0 || p.dailyCap(p.Clock.Now()) > 0
+}
+
+// dailyCap devolve o limite diário da rampa em now (0 = sem limite).
+func (p ShaperPolicy) dailyCap(now time.Time) int {
+	if p.RampStart.IsZero() || len(p.Ramp) == 0 {
+		return 0
+	}
+	day := int(now.Sub(p.RampStart)/(24*time.Hour)) + 1
+	for _, s := range p.Ramp {
+		if day <= s.Day {
+			return s.PerDay
+		}
+	}
+	return 0
+}
+
+var (
+	// ErrOutboundCap: limite diário da rampa atingido (só envios em massa).
+	ErrOutboundCap = errors.New("uazapi: daily outbound cap reached (ramp-up)")
+	// ErrOutboundWait: o envio esperou mais que MaxWait na fila da instância.
+	ErrOutboundWait = errors.New("uazapi: outbound queue wait exceeded")
+)
+
+// ShaperState descreve a fila de uma instância para /admin/outbound.
+type ShaperState struct {
+	Instance       string     `json:"instance"`
+	SentLastMinute int        `json:"sent_last_minute"`
+	SentToday      int        `json:"sent_today"`
+	DailyCap       int        `json:"daily_cap,omitempty"` // rampa (só envios em massa)
+	WaitingReplies int        `json:"waiting_replies"`
+	WaitingBulk    int        `json:"waiting_bulk"`
+	NextAt         *time.Time `json:"next_at,omitempty"` // próximo envio liberado pelo intervalo
+	Sent           int64      `json:"sent"`              // desde o start
+	Delayed        int64      `json:"delayed"`           // envios que esperaram
+	Rejected       int64      `json:"rejected"`          // limite diário ou espera máxima
+}
+
+type lane struct {
+	name    string
+	sent    []time.Time // envios nos últimos 60s
+	next    time.Time
+	day     string
+	today   int
+	waiting [2]int
+
+	total, delayed, rejected int64
+}
+
+type shaperSet struct {
+	mu     sync.Mutex
+	policy ShaperPolicy
+	m      map[string]*lane
+}
+
+var shapers = &shaperSet{policy: ShaperPolicy{Location: time.Local, Clock: clock.Real, Rand: clock.Crypto}, m: map[string]*lane{}}
+
+// SetShaperPolicy define a taxa de envio (vale para todos os clients).
+func SetShaperPolicy(p ShaperPolicy) {
+	if p.Location == nil {
+		p.Location = time.Local
+	}
+	p.Clock = clock.Or(p.Clock)
+	if p.Rand == nil {
+		p.Rand = clock.Crypto
+	}
+	shapers.mu.Lock()
+	defer shapers.mu.Unlock()
+	shapers.policy = p
+}
+
+// Shapers devolve a fila das instâncias que já enviaram, ordenadas por nome.
+func Shapers() []ShaperState {
+	shapers.mu.Lock()
+	defer shapers.mu.Unlock()
+	now := shapers.policy.Clock.Now()
+	out := make([]ShaperState, 0, len(shapers.m))
+	for _, l := range shapers.m {
+		shapers.roll(l, now)
+		st := ShaperState{
+			Instance: l.name, SentLastMinute: len(l.sent), SentToday: l.today,
+			DailyCap: shapers.policy.dailyCap(now), WaitingReplies: l.waiting[PriorityReply], WaitingBulk: l.waiting[PriorityBulk],
+			Sent: l.total, Delayed: l.delayed, Rejected: l.rejected,
+		}
+		if l.next.After(now) {
+			next := l.next
+			st.NextAt = &next
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Instance < out[j].Instance })
+	return out
+}
+
+// wait bloqueia até o envio poder sair pela instância (key = URL + token).
+func (s *shaperSet) wait(ctx context.Context, key, name string, prio Priority) error {
+	s.mu.Lock()
+	p := s.policy
+	if !p.enabled() {
+		s.mu.Unlock()
+		return nil
+	}
+	l := s.m[key]
+	if l == nil {
+		l = &lane{name: name}
+		s.m[key] = l
+	}
+	l.waiting[prio]++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		l.waiting[prio]--
+		s.mu.Unlock()
+	}()
+
+	var deadline time.Time
+	if p.MaxWait > 0 {
+		deadline = p.Clock.Now().Add(p.MaxWait)
+	}
+	for waited := false; ; waited = true {
+		s.mu.Lock()
+		now := p.Clock.Now()
+		d, err := s.reserve(l, prio, now, waited)
+		s.mu.Unlock()
+		if err != nil || d <= 0 {
+			return err
+		}
+		if !deadline.IsZero() && now.Add(d).After(deadline) {
+			s.mu.Lock()
+			l.rejected++
+			s.mu.Unlock()
+			return ErrOutboundWait
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.Clock.After(d):
+		}
+	}
+}
+
+// roll descarta os envios fora da janela de 60s e vira o dia.
+func (s *shaperSet) roll(l *lane, now time.Time) {
+	cut := now.Add(-time.Minute)
+	i := 0
+	for i < len(l.sent) && !l.sent[i].After(cut) {
+		i++
+	}
+	l.sent = l.sent[i:]
+	if day := now.In(s.policy.Location).Format("2006-01-02"); day != l.day {
+		l.day, l.today = day, 0
+	}
+}
+
+// reserve registra o envio e devolve 0, ou quanto esperar antes de tentar de novo.
+func (s *shaperSet) reserve(l *lane, prio Priority, now time.Time, waited bool) (time.Duration, error) {
+	p := s.policy
+	s.roll(l, now)
+	if prio == PriorityBulk {
+		if limit := p.dailyCap(now); limit > 0 && l.today >= limit {
+			l.rejected++
+			return 0, ErrOutboundCap
+		}
+		if l.waiting[PriorityReply] > 0 {
+			return 250 * time.Millisecond, nil
+		}
+	}
+	var d time.Duration
+	if p.MaxPerMinute > 0 && len(l.sent) >= p.MaxPerMinute {
+		d = l.sent[len(l.sent)-p.MaxPerMinute].Add(time.Minute).Sub(now)
+	}
+	if w := l.next.Sub(now); w > d {
+		d = w
+	}
+	if d > 0 {
+		return d, nil
+	}
+	gap := p.MinInterval
+	if p.Jitter > 0 {
+		gap += time.Duration(p.Rand.Int63n(int64(p.Jitter)))
+	}
+	l.sent = append(l.sent, now)
+	l.next = now.Add(gap)
+	l.today++
+	l.total++
+	if waited {
+		l.delayed++
+	}
+	return 0, nil
+}
+
+// shape espera a vez do envio na instância do client.
+func (c *Client) shape(ctx context.Context, prio Priority) error {
+	if prio == PriorityReply {
+		prio = priorityFrom(ctx)
+	}
+	name := c.baseSend
+	if n := len(c.tokenSend); n > 4 {
+		name += " (…" + c.tokenSend[n-4:] + ")"
+	}
+	return shapers.wait(ctx, c.baseSend+"|"+c.tokenSend, name, prio)
+}
@@ -0,0 +1,76 @@
+package uazapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/clock"
+)
+
+var shaperStart = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestShaper devolve um shaperSet próprio (não o global) com relógio manual
+// e jitter sempre na metade da faixa.
+func newTestShaper(p ShaperPolicy) (*shaperSet, *clock.Fake) {
+	fake := clock.NewFake(shaperStart)
+	p.Location = time.UTC
+	p.Clock = fake
+	p.Rand = clock.RandFunc(func(n int64) int64 { return n / 2 })
+	return &shaperSet{policy: p, m: map[string]*lane{}}, fake
+}
+
+func TestShaperGapWithJitter(t *testing.T) {
+	s, fake := newTestShaper(ShaperPolicy{MinInterval: time.Second, Jitter: 500 * time.Millisecond})
+	l := &lane{name: "a"}
+
+	if d, err := s.reserve(l, PriorityReply, fake.Now(), false); d != 0 || err != nil {
+		t.Fatalf("first send: wait %v, err %v; want 0, nil", d, err)
+	}
+	// intervalo mínimo + metade do jitter
+	if d, _ := s.reserve(l, PriorityReply, fake.Now(), false); d != 1250*time.Millisecond {
+		t.Fatalf("second send: wait %v, want 1.25s", d)
+	}
+	if d, _ := s.reserve(l, PriorityReply, fake.Now().Add(1250*time.Millisecond), true); d != 0 {
+		t.Fatalf("after the gap: wait %v, want 0", d)
+	}
+	if l.total != 2 || l.delayed != 1 {
+		t.Errorf("total=%d delayed=%d, want 2, 1", l.total, l.delayed)
+	}
+}
+
+func TestShaperMaxPerMinute(t *testing.T) {
+	s, fake := newTestShaper(ShaperPolicy{MaxPerMinute: 2})
+	l := &lane{name: "a"}
+	for i := 0; i < 2; i++ {
+		if d, _ := s.reserve(l, PriorityReply, fake.Now(), false); d != 0 {
+			t.Fatalf("send %d: wait %v, want 0", i, d)
+		}
+	}
+	fake.Advance(20 * time.Second)
+	if d, _ := s.reserve(l, PriorityReply, fake.Now(), false); d != 40*time.Second {
+		t.Fatalf("third send: wait %v, want 40s (oldest send leaves the window)", d)
+	}
+}
+
+func TestShaperWaitUsesClock(t *testing.T) {
+	s, fake := newTestShaper(ShaperPolicy{MinInterval: 2 * time.Second})
+	ctx := context.Background()
+	if err := s.wait(ctx, "k", "a", PriorityReply); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.wait(ctx, "k", "a", PriorityReply) }()
+	for fake.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("second send left before the interval: %v", err)
+	default:
+	}
+	fake.Advance(2 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
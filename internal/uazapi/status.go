@@ -0,0 +1,67 @@
+package uazapi
+
+import (
+	"context"
+	"fmt"
+)
+
+// StatusPost é uma publicação no Status (stories) da instância: texto sobre fundo
+// colorido, ou mídia (URL pública) com legenda.
+type StatusPost struct {
+	Text            string
+	MediaType       string // image | video | audio; vazio = status de texto
+	MediaURL        string
+	BackgroundColor int // status de texto: cor de fundo (1-19; 0 = padrão da Uazapi)
+	Font            int // status de texto: fonte (0-8; 0 = padrão)
+}
+
+var statusPaths = []string{
+	"/send/status",
+	"/api/send/status",
+	"/message/status",
+	"/api/message/status",
+}
+
+// SendStatus publica no Status do WhatsApp da instância (visível aos contatos).
+func (c *Client) SendStatus(ctx context.Context, post StatusPost) (SendResult, error) {
+	body := map[string]any{"type": "text"}
+	if post.Text != "" {
+		body["text"] = post.Text
+	}
+	if post.MediaURL != "" {
+		body["type"] = post.MediaType
+		body["file"] = post.MediaURL
+	} else {
+		if post.BackgroundColor > 0 {
+			body["background_color"] = post.BackgroundColor
+		}
+		if post.Font > 0 {
+			body["font"] = post.Font
+		}
+	}
+	if c.dryRun {
+		return c.dryRunResult(fmt.Sprintf("status %v", body["type"]), "status", post.Text+post.MediaURL), nil
+	}
+	if err := c.shape(ctx, PriorityBulk); err != nil {
+		return SendResult{}, err
+	}
+
+	var lastCode int
+	var lastBody []byte
+	var lastErr error
+	for _, p := range statusPaths {
+		url := joinURL(c.baseSend, p)
+		code, b, err := c.post(ctx, url, c.tokenSend, body)
+		if err == nil && code >= 200 && code < 300 {
+			return parseSendResult(b), nil
+		}
+		lastCode, lastBody, lastErr = code, b, err
+		if err == nil && failureKind(code, b) != nil {
+			break
+		}
+	}
+	if lastErr != nil {
+		return SendResult{}, lastErr
+	}
+	return SendResult{}, newAPIError("send status", lastCode, lastBody)
+}
@@ -13,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/your-org/leandro-agent/internal/phone"
 )
 
 /*
@@ -41,6 +43,23 @@ type Client struct {
 	// formato do payload
 	minimalPayload bool // se true, envia só number/text/delay
 	delayAsString  bool // se true, "delay" vai como string
+
+	dryRun bool // se true, envios são apenas logados (downloads continuam reais)
+
+	// download de mídia: cliente sem timeout global (cada tentativa tem o seu)
+	downloadHTTP    *http.Client
+	downloadTimeout time.Duration
+	downloadRetries int
+
+	transcoder Transcoder // adapta imagens/vídeos acima do limite do WhatsApp; nil = envia como veio
+}
+
+// Transcoder adapta a mídia aos limites do WhatsApp antes do envio (media.Transcoder).
+type Transcoder interface {
+	// Fit devolve a mídia dentro do limite do tipo; changed false = sem alteração.
+	Fit(ctx context.Context, mediaType string, data []byte) (out []byte, changed bool, err error)
+	// Thumbnail gera a miniatura JPEG de um vídeo.
+	Thumbnail(ctx context.Context, video []byte) ([]byte, error)
 }
 
 func New(baseSend, tokenSend, baseDownload, tokenDown string) *Client {
@@ -53,6 +72,9 @@ func New(baseSend, tokenSend, baseDownload, tokenDown string) *Client {
 		maxRetries:   3,
 		backoff:      250 * time.Millisecond,
 		minVisibleMs: 1000,
+		downloadHTTP:    &http.Client{},
+		downloadTimeout: 60 * time.Second,
+		downloadRetries: 3,
 	}
 }
 
@@ -66,6 +88,27 @@ func (c *Client) WithLogging(enabled bool) *Client { c.logReq = enabled; return
 func (c *Client) WithMinVisibleDelay(ms int) *Client { if ms > 0 { c.minVisibleMs = ms }; return c }
 func (c *Client) WithMinimalPayload(enabled bool) *Client { c.minimalPayload = enabled; return c }
 func (c *Client) WithDelayAsString(enabled bool) *Client  { c.delayAsString = enabled; return c }
+func (c *Client) WithDryRun(enabled bool) *Client         { c.dryRun = enabled; return c }
+
+// WithTranscoder faz SendMedia* adaptar imagens e vídeos grandes demais (t nil desliga).
+func (c *Client) WithTranscoder(t Transcoder) *Client {
+	c.transcoder = t
+	return c
+}
+
+// WithTransport troca o transporte dos envios e dos downloads (ex.: captura de depuração).
+func (c *Client) WithTransport(t http.RoundTripper) *Client {
+	c.http.Transport = t
+	c.downloadHTTP.Transport = t
+	return c
+}
+
+// dryRunResult registra o envio que seria feito e devolve um ID sintético ("dryrun-...").
+func (c *Client) dryRunResult(kind, number string, detail string) SendResult {
+	now := time.Now()
+	fmt.Printf("[uazapi] DRY_RUN send %s to %s: %s\n", kind, number, detail)
+	return SendResult{MessageID: "dryrun-" + strconv.FormatInt(now.UnixNano(), 36), Timestamp: now}
+}
 
 // ----------------- HTTP helpers -----------------
 
@@ -99,23 +142,32 @@ func (c *Client) doJSONOnce(ctx context.Context, url string, token string, body
 	return resp.StatusCode, b, nil
 }
 
+// post chama o caminho respeitando o circuit breaker (ErrCircuitOpen sem chamar).
+func (c *Client) post(ctx context.Context, url string, token string, body any) (int, []byte, error) {
+	if !breakers.allow(url) {
+		return 0, nil, ErrCircuitOpen
+	}
+	code, b, err := c.doJSONWithRetry(ctx, url, token, body)
+	if err != nil && ctx.Err() != nil {
+		breakers.release(url) // cancelado pelo chamador: não diz nada sobre o caminho
+		return code, b, err
+	}
+	breakers.record(url, err == nil && !pathFailure(code, b))
+	return code, b, err
+}
+
 func (c *Client) doJSONWithRetry(ctx context.Context, url string, token string, body any) (int, []byte, error) {
-	var lastCode int
-	var lastBody []byte
-	var lastErr error
 	for try := 1; ; try++ {
 		code, b, err := c.doJSONOnce(ctx, url, token, body)
 		if err != nil {
-			lastErr = err
 			if try <= c.maxRetries && isRetryableNetErr(err) {
 				time.Sleep(c.backoff * time.Duration(try))
 				continue
 			}
 			return 0, nil, err
 		}
-		lastCode, lastBody = code, b
 		if code >= 200 && code < 300 { return code, b, nil }
-		if code >= 500 && code <= 599 && try <= c.maxRetries {
+		if code >= 500 && code <= 599 && try <= c.maxRetries && failureKind(code, b) == nil {
 			time.Sleep(c.backoff * time.Duration(try))
 			continue
 		}
@@ -134,15 +186,6 @@ func isRetryableNetErr(err error) bool {
 		strings.Contains(s, "unexpected eof")
 }
 
-func onlyDigits(s string) string {
-	var b strings.Builder
-	for i := 0; i < len(s); i++ {
-		ch := s[i]
-		if ch >= '0' && ch <= '9' { b.WriteByte(ch) }
-	}
-	return b.String()
-}
-
 // ----------------- /send/text -----------------
 
 var textPaths = []string{
@@ -156,14 +199,71 @@ var textPaths = []string{
 	"/api/messages/text",
 }
 
-func (c *Client) SendText(ctx context.Context, number, text string) error {
+// SendResult traz o identificador devolvido pela Uazapi para correlacionar com ACKs.
+type SendResult struct {
+	MessageID string
+	Timestamp time.Time // zero se o provedor não informar
+}
+
+// parseSendResult lê o corpo de resposta do envio. Formatos variam entre instâncias:
+// messageid | messageId | id ("owner:ID") | key.id, e messageTimestamp em segundos ou ms.
+func parseSendResult(b []byte) SendResult {
+	var out struct {
+		MessageID  string          `json:"messageid"`
+		MessageID2 string          `json:"messageId"`
+		ID         string          `json:"id"`
+		Key        struct{ ID string `json:"id"` } `json:"key"`
+		Timestamp  json.Number     `json:"messageTimestamp"`
+		Message    json.RawMessage `json:"message"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return SendResult{}
+	}
+	// algumas versões embrulham em {"message":{...}}
+	if out.MessageID == "" && out.MessageID2 == "" && out.ID == "" && out.Key.ID == "" && len(out.Message) > 0 && out.Message[0] == '{' {
+		return parseSendResult(out.Message)
+	}
+
+	var r SendResult
+	switch {
+	case out.MessageID != "":
+		r.MessageID = out.MessageID
+	case out.MessageID2 != "":
+		r.MessageID = out.MessageID2
+	case out.Key.ID != "":
+		r.MessageID = out.Key.ID
+	case out.ID != "":
+		r.MessageID = out.ID
+		if i := strings.IndexByte(out.ID, ':'); i >= 0 && i+1 < len(out.ID) {
+			r.MessageID = out.ID[i+1:]
+		}
+	}
+	if n, err := out.Timestamp.Int64(); err == nil && n > 0 {
+		if n > 1e12 {
+			r.Timestamp = time.UnixMilli(n)
+		} else {
+			r.Timestamp = time.Unix(n, 0)
+		}
+	}
+	return r
+}
+
+func (c *Client) SendText(ctx context.Context, number, text string) (SendResult, error) {
 	return c.SendTextWithDelay(ctx, number, text, 0)
 }
 
 // Gera payload mínimo se WithMinimalPayload(true) estiver ligado.
 // Se WithDelayAsString(true), envia "delay":"1000"; senão, delay:1000 (integer — recomendado).
-func (c *Client) SendTextWithDelay(ctx context.Context, jidOrNumber, text string, delayMs int) error {
-	number := onlyDigits(jidOrNumber)
+func (c *Client) SendTextWithDelay(ctx context.Context, jidOrNumber, text string, delayMs int) (SendResult, error) {
+	number := phone.Destination(jidOrNumber)
+	if c.dryRun {
+		return c.dryRunResult("text", number, text), nil
+	}
+	if err := c.shape(ctx, PriorityReply); err != nil {
+		return SendResult{}, err
+	}
 
 	var body map[string]any
     // Incluímos sempre campos adicionais como readchat e linkPreview para
@@ -192,12 +292,13 @@ func (c *Client) SendTextWithDelay(ctx context.Context, jidOrNumber, text string
 	var lastErr error
 	for _, p := range textPaths {
 		url := joinURL(c.baseSend, p)
-		code, b, err := c.doJSONWithRetry(ctx, url, c.tokenSend, body)
-		if err == nil && code >= 200 && code < 300 { return nil }
+		code, b, err := c.post(ctx, url, c.tokenSend, body)
+		if err == nil && code >= 200 && code < 300 { return parseSendResult(b), nil }
 		lastCode, lastBody, lastErr = code, b, err
+		if err == nil && failureKind(code, b) != nil { break } // resposta da Uazapi: outro caminho diria o mesmo
 	}
-	if lastErr != nil { return lastErr }
-	return fmt.Errorf("uazapi send text %d: %s", lastCode, string(lastBody))
+	if lastErr != nil { return SendResult{}, lastErr }
+	return SendResult{}, newAPIError("send text", lastCode, lastBody)
 }
 
 // ----------------- /send/media -----------------
@@ -213,19 +314,78 @@ var mediaPaths = []string{
 	"/api/messages/media",
 }
 
-func (c *Client) SendMedia(ctx context.Context, number string, mediaType string, data []byte) error {
+func (c *Client) SendMedia(ctx context.Context, number string, mediaType string, data []byte) (SendResult, error) {
 	return c.SendMediaWithDelay(ctx, number, mediaType, data, 0)
 }
-func (c *Client) SendMediaWithDelay(ctx context.Context, number string, mediaType string, data []byte, delayMs int) error {
-	enc := base64.StdEncoding.EncodeToString(data)
+func (c *Client) SendMediaWithDelay(ctx context.Context, number string, mediaType string, data []byte, delayMs int) (SendResult, error) {
+	return c.sendMedia(ctx, number, mediaType, data, delayMs, "")
+}
+
+// SendMediaWithCaption envia imagem/vídeo/documento com legenda ("text" no payload).
+func (c *Client) SendMediaWithCaption(ctx context.Context, number string, mediaType string, data []byte, caption string) (SendResult, error) {
+	return c.sendMedia(ctx, number, mediaType, data, 0, caption)
+}
+
+// SendMediaURL envia mídia hospedada numa URL pública (a Uazapi baixa o arquivo).
+func (c *Client) SendMediaURL(ctx context.Context, number string, mediaType string, fileURL string, caption string) (SendResult, error) {
+	if c.dryRun {
+		return c.dryRunResult(mediaType, phone.Destination(number), fileURL), nil
+	}
+	return c.sendMediaFile(ctx, number, mediaType, fileURL, 0, caption, nil)
+}
+
+func (c *Client) sendMedia(ctx context.Context, number string, mediaType string, data []byte, delayMs int, caption string) (SendResult, error) {
+	data, thumb := c.fitMedia(ctx, mediaType, data)
+	if c.dryRun {
+		return c.dryRunResult(mediaType, phone.Destination(number), strconv.Itoa(len(data))+" bytes"), nil
+	}
+	return c.sendMediaFile(ctx, number, mediaType, base64.StdEncoding.EncodeToString(data), delayMs, caption, thumb)
+}
+
+// fitMedia passa imagens e vídeos pelo transcoder; vídeos convertidos ganham
+// miniatura. Se a conversão falhar, envia o original (a Uazapi decide).
+func (c *Client) fitMedia(ctx context.Context, mediaType string, data []byte) ([]byte, []byte) {
+	if c.transcoder == nil || (mediaType != "image" && mediaType != "video") {
+		return data, nil
+	}
+	out, changed, err := c.transcoder.Fit(ctx, mediaType, data)
+	if err != nil {
+		fmt.Printf("[uazapi] transcode %s (%d bytes) error: %v\n", mediaType, len(data), err)
+		return data, nil
+	}
+	if !changed {
+		return data, nil
+	}
+	fmt.Printf("[uazapi] transcoded %s: %d -> %d bytes\n", mediaType, len(data), len(out))
+	if mediaType != "video" {
+		return out, nil
+	}
+	thumb, err := c.transcoder.Thumbnail(ctx, out)
+	if err != nil {
+		fmt.Printf("[uazapi] video thumbnail error: %v\n", err)
+	}
+	return out, thumb
+}
+
+// sendMediaFile envia o campo "file" como veio (base64 ou URL) e a miniatura, se houver.
+func (c *Client) sendMediaFile(ctx context.Context, number string, mediaType string, enc string, delayMs int, caption string, thumb []byte) (SendResult, error) {
+	if err := c.shape(ctx, PriorityReply); err != nil {
+		return SendResult{}, err
+	}
     // Incluímos readchat true para compatibilidade com o comportamento do
     // client usado no projeto Luna, que define readchat em envios de mídia.
     body := map[string]any{
-        "number":   onlyDigits(number),
+        "number":   phone.Destination(number),
         "type":     mediaType,
         "file":     enc,
         "readchat": true,
     }
+	if caption != "" {
+		body["text"] = caption
+	}
+	if len(thumb) > 0 {
+		body["thumbnail"] = base64.StdEncoding.EncodeToString(thumb)
+	}
 	if delayMs > 0 {
 		if delayMs < c.minVisibleMs { delayMs = c.minVisibleMs }
 		body["delay"] = delayMs
@@ -235,12 +395,70 @@ func (c *Client) SendMediaWithDelay(ctx context.Context, number string, mediaTyp
 	var lastErr error
 	for _, p := range mediaPaths {
 		url := joinURL(c.baseSend, p)
-		code, b, err := c.doJSONWithRetry(ctx, url, c.tokenSend, body)
-		if err == nil && code >= 200 && code < 300 { return nil }
+		code, b, err := c.post(ctx, url, c.tokenSend, body)
+		if err == nil && code >= 200 && code < 300 { return parseSendResult(b), nil }
 		lastCode, lastBody, lastErr = code, b, err
+		if err == nil && failureKind(code, b) != nil { break } // resposta da Uazapi: outro caminho diria o mesmo
 	}
-	if lastErr != nil { return lastErr }
-	return fmt.Errorf("uazapi send media %d: %s", lastCode, string(lastBody))
+	if lastErr != nil { return SendResult{}, lastErr }
+	return SendResult{}, newAPIError("send media", lastCode, lastBody)
+}
+
+// ----------------- /send/menu -----------------
+
+var menuPaths = []string{
+	"/send/menu",
+	"/api/send/menu",
+}
+
+// SendButtons envia texto com botões de resposta rápida (POST /send/menu, type "button").
+// O WhatsApp aceita no máximo 3 botões.
+func (c *Client) SendButtons(ctx context.Context, number, text string, buttons []string, footer string) (SendResult, error) {
+	number = phone.Destination(number)
+	if c.dryRun {
+		return c.dryRunResult("buttons", number, text+" ["+strings.Join(buttons, " | ")+"]"), nil
+	}
+	if err := c.shape(ctx, PriorityReply); err != nil {
+		return SendResult{}, err
+	}
+	body := map[string]any{
+		"number":   number,
+		"type":     "button",
+		"text":     text,
+		"choices":  buttons,
+		"readchat": true,
+	}
+	if footer != "" {
+		body["footerText"] = footer
+	}
+	var lastCode int
+	var lastBody []byte
+	var lastErr error
+	for _, p := range menuPaths {
+		url := joinURL(c.baseSend, p)
+		code, b, err := c.post(ctx, url, c.tokenSend, body)
+		if err == nil && code >= 200 && code < 300 { return parseSendResult(b), nil }
+		lastCode, lastBody, lastErr = code, b, err
+		if err == nil && failureKind(code, b) != nil { break } // resposta da Uazapi: outro caminho diria o mesmo
+	}
+	if lastErr != nil { return SendResult{}, lastErr }
+	return SendResult{}, newAPIError("send menu", lastCode, lastBody)
+}
+
+// ----------------- chamadas -----------------
+
+// RejectCall recusa uma chamada recebida (POST /call/reject).
+func (c *Client) RejectCall(ctx context.Context, number, callID string) error {
+	number = phone.Destination(number)
+	if c.dryRun {
+		c.dryRunResult("call reject", number, callID)
+		return nil
+	}
+	body := map[string]any{ "number": number, "id": callID }
+	code, b, err := c.post(ctx, joinURL(c.baseSend, "/call/reject"), c.tokenSend, body)
+	if err != nil { return err }
+	if code > 299 { return newAPIError("call reject", code, b) }
+	return nil
 }
 
 // ----------------- download -----------------
@@ -249,32 +467,23 @@ func (c *Client) DownloadByMessageID(ctx context.Context, messageID string) ([]b
 	body := map[string]any{ "id": messageID, "return_link": true }
 	url := joinURL(c.baseDownload, "/message/download")
 
-	code, b, err := c.doJSONWithRetry(ctx, url, c.tokenDown, body)
+	code, b, err := c.post(ctx, url, c.tokenDown, body)
 	if err != nil { return nil, "", err }
-	if code > 299 { return nil, "", fmt.Errorf("uazapi download %d: %s", code, string(b)) }
+	if code > 299 { return nil, "", newAPIError("download", code, b) }
 
 	var out struct{ FileURL string `json:"fileURL"` }
 	if err := json.Unmarshal(b, &out); err != nil { return nil, "", err }
 	if out.FileURL == "" { return nil, "", fmt.Errorf("empty fileURL") }
 
-	req2, _ := http.NewRequestWithContext(ctx, http.MethodGet, out.FileURL, nil)
-	resp2, err := c.http.Do(req2)
-	if err != nil { return nil, out.FileURL, err }
-	defer resp2.Body.Close()
-
-	if resp2.StatusCode > 299 {
-		b2, _ := io.ReadAll(resp2.Body)
-		return nil, out.FileURL, fmt.Errorf("download media %d: %s", resp2.StatusCode, string(b2))
-	}
-	data, err := io.ReadAll(resp2.Body)
+	data, err := c.fetchMedia(ctx, out.FileURL)
 	return data, out.FileURL, err
 }
 
 // ----------------- helpers “After” -----------------
 
-func (c *Client) SendTextAfter(ctx context.Context, jidOrNumber, text string, d time.Duration, _ bool) error {
+func (c *Client) SendTextAfter(ctx context.Context, jidOrNumber, text string, d time.Duration, _ bool) (SendResult, error) {
 	return c.SendTextWithDelay(ctx, jidOrNumber, text, int(d/time.Millisecond))
 }
-func (c *Client) SendMediaAfter(ctx context.Context, jidOrNumber string, mediaType string, data []byte, d time.Duration, _ bool) error {
-	return c.SendMediaWithDelay(ctx, onlyDigits(jidOrNumber), mediaType, data, int(d/time.Millisecond))
+func (c *Client) SendMediaAfter(ctx context.Context, jidOrNumber string, mediaType string, data []byte, d time.Duration, _ bool) (SendResult, error) {
+	return c.SendMediaWithDelay(ctx, phone.Destination(jidOrNumber), mediaType, data, int(d/time.Millisecond))
 }
@@ -0,0 +1,295 @@
+// internal/unfurl/unfurl.go
+package unfurl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+/*
+Busca páginas enviadas pelos clientes para o assistente saber do que se trata o link.
+
+- Respeita robots.txt (User-agent "LeandroBot" ou "*").
+- Timeout, limite de tamanho e só text/html ou text/plain.
+- Bloqueia endereços internos (loopback, rede privada, link-local) para evitar SSRF.
+*/
+
+const userAgent = "LeandroBot/1.0 (+link preview)"
+
+// Page é o conteúdo legível extraído de uma URL.
+type Page struct {
+	URL   string
+	Title string
+	Text  string
+}
+
+// Fetcher busca e extrai páginas. Seguro para uso concorrente.
+type Fetcher struct {
+	client  *http.Client
+	maxSize int64
+
+	mu     sync.Mutex
+	robots map[string]robotsEntry // host -> regras (cache de 1h)
+}
+
+type robotsEntry struct {
+	rules []robotsRule
+	until time.Time
+}
+
+type robotsRule struct {
+	allow  bool
+	prefix string
+}
+
+var ErrBlocked = errors.New("blocked by robots.txt")
+
+func New(timeout time.Duration, maxSize int64) *Fetcher {
+	if timeout <= 0 {
+		timeout = 8 * time.Second
+	}
+	if maxSize <= 0 {
+		maxSize = 1 << 20
+	}
+	dialer := &net.Dialer{Timeout: timeout, Control: denyPrivate}
+	tr := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: tr,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+		maxSize: maxSize,
+		robots:  make(map[string]robotsEntry),
+	}
+}
+
+// denyPrivate recusa conexões para endereços internos (também após redirects/DNS).
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("address %s not allowed", host)
+	}
+	return nil
+}
+
+var urlRe = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"']+`)
+
+// FindURLs devolve até max URLs http(s) distintas encontradas no texto.
+func FindURLs(text string, max int) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, u := range urlRe.FindAllString(text, -1) {
+		u = strings.TrimRight(u, ".,;:!?)]}")
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		out = append(out, u)
+		if max > 0 && len(out) >= max {
+			break
+		}
+	}
+	return out
+}
+
+// Fetch baixa a URL (se o robots.txt permitir) e extrai título e texto.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Page, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Page{}, fmt.Errorf("invalid url %q", rawURL)
+	}
+	if !f.allowed(ctx, u) {
+		return Page{}, ErrBlocked
+	}
+
+	body, ctype, err := f.get(ctx, u.String())
+	if err != nil {
+		return Page{}, err
+	}
+	mt, _, _ := mime.ParseMediaType(ctype)
+	p := Page{URL: u.String()}
+	switch mt {
+	case "text/html", "application/xhtml+xml", "":
+		p.Title, p.Text = extractHTML(body)
+	case "text/plain":
+		p.Text = collapseSpace(body)
+	default:
+		return Page{}, fmt.Errorf("unsupported content-type %q", mt)
+	}
+	if p.Text == "" && p.Title == "" {
+		return Page{}, errors.New("no readable content")
+	}
+	return p, nil
+}
+
+func (f *Fetcher) get(ctx context.Context, u string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return "", "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > f.maxSize {
+		return "", "", fmt.Errorf("page too large (%d bytes)", resp.ContentLength)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, f.maxSize))
+	if err != nil {
+		return "", "", err
+	}
+	return string(b), resp.Header.Get("Content-Type"), nil
+}
+
+// ---------- robots.txt ----------
+
+func (f *Fetcher) allowed(ctx context.Context, u *url.URL) bool {
+	key := u.Scheme + "://" + u.Host
+	f.mu.Lock()
+	e, ok := f.robots[key]
+	f.mu.Unlock()
+	if !ok || time.Now().After(e.until) {
+		e = robotsEntry{until: time.Now().Add(time.Hour)}
+		// robots.txt ausente ou com erro: tudo permitido
+		if body, _, err := f.get(ctx, key+"/robots.txt"); err == nil {
+			e.rules = parseRobots(body)
+		}
+		f.mu.Lock()
+		f.robots[key] = e
+		f.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// regra mais específica (prefixo mais longo) vence; empate favorece Allow
+	best, allow := -1, true
+	for _, r := range e.rules {
+		if strings.HasPrefix(path, r.prefix) && (len(r.prefix) > best || (len(r.prefix) == best && r.allow)) {
+			best, allow = len(r.prefix), r.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots lê as regras do grupo "LeandroBot" (ou "*" na ausência dele).
+func parseRobots(body string) []robotsRule {
+	var ours, star []robotsRule
+	var agents []string
+	inRules := false
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		k = strings.ToLower(strings.TrimSpace(k))
+		v = strings.TrimSpace(v)
+		switch k {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(v))
+		case "allow", "disallow":
+			inRules = true
+			if v == "" {
+				continue // "Disallow:" vazio = tudo liberado
+			}
+			r := robotsRule{allow: k == "allow", prefix: strings.TrimSuffix(v, "*")}
+			for _, a := range agents {
+				switch {
+				case a == "leandrobot":
+					ours = append(ours, r)
+				case a == "*":
+					star = append(star, r)
+				}
+			}
+		}
+	}
+	if ours != nil {
+		return ours
+	}
+	return star
+}
+
+// ---------- extração de texto ----------
+
+var (
+	titleRe    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	metaDescRe = regexp.MustCompile(`(?is)<meta[^>]+(?:name|property)=["'](?:og:)?description["'][^>]*content=["']([^"']*)["']`)
+	dropRe     = regexp.MustCompile(`(?is)<(script|style|noscript|svg|head|nav|footer|iframe)[^>]*>.*?</(script|style|noscript|svg|head|nav|footer|iframe)>|<!--.*?-->`)
+	blockRe    = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr|/section|/article)[^>]*>`)
+	tagRe      = regexp.MustCompile(`(?s)<[^>]+>`)
+	spaceRe    = regexp.MustCompile(`[ \t\r\f\v]+`)
+	nlRe       = regexp.MustCompile(`\n\s*\n+`)
+)
+
+func extractHTML(doc string) (title, text string) {
+	if m := titleRe.FindStringSubmatch(doc); len(m) == 2 {
+		title = collapseSpace(html.UnescapeString(tagRe.ReplaceAllString(m[1], "")))
+	}
+	desc := ""
+	if m := metaDescRe.FindStringSubmatch(doc); len(m) == 2 {
+		desc = collapseSpace(html.UnescapeString(m[1]))
+	}
+	body := dropRe.ReplaceAllString(doc, " ")
+	body = blockRe.ReplaceAllString(body, "\n")
+	body = tagRe.ReplaceAllString(body, " ")
+	text = collapseSpace(html.UnescapeString(body))
+	if desc != "" && !strings.Contains(text, desc) {
+		text = desc + "\n" + text
+	}
+	return title, text
+}
+
+func collapseSpace(s string) string {
+	s = spaceRe.ReplaceAllString(s, " ")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(nlRe.ReplaceAllString(s, "\n"))
+}
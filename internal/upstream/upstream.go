@@ -0,0 +1,182 @@
+// internal/upstream/upstream.go
+package upstream
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/leandro-agent/internal/config"
+)
+
+/*
+Transporte HTTP compartilhado das chamadas aos provedores (OpenAI, Uazapi, Whisper).
+
+O http.DefaultTransport guarda só 2 conexões ociosas por host: sob carga, cada
+mensagem acaba abrindo conexão TCP + TLS nova com a OpenAI e a Uazapi, e esse
+handshake domina a latência. Aqui um único transporte é usado por todos os
+clients, com pool maior por host (HTTP_MAX_IDLE_PER_HOST), cache de sessão TLS
+(reconexões retomam a sessão sem o handshake completo), HTTP/2 opcional
+(HTTP2_ENABLED) e conexões pré-aquecidas na subida (Warm).
+
+Stats conta conexões reaproveitadas e novas e o tempo médio de abertura, para
+medir o efeito (GET /admin/webhook/load).
+*/
+
+// Options ajusta o transporte.
+type Options struct {
+	MaxIdlePerHost int           // conexões ociosas mantidas por host
+	IdleTimeout    time.Duration // fecha conexões ociosas após esse tempo
+	HTTP2          bool          // negocia HTTP/2 quando o servidor aceita
+}
+
+// OptionsFrom lê as opções de HTTP_MAX_IDLE_PER_HOST, HTTP_IDLE_TIMEOUT_SECONDS e HTTP2_ENABLED.
+func OptionsFrom(cfg config.Config) Options {
+	return Options{
+		MaxIdlePerHost: cfg.HTTPMaxIdlePerHost,
+		IdleTimeout:    time.Duration(cfg.HTTPIdleTimeoutSeconds) * time.Second,
+		HTTP2:          cfg.HTTP2Enabled,
+	}
+}
+
+// Transport é o http.RoundTripper compartilhado, com contadores de conexão.
+type Transport struct {
+	base *http.Transport
+
+	requests   atomic.Int64
+	reused     atomic.Int64
+	opened     atomic.Int64
+	setupNanos atomic.Int64
+}
+
+// New monta o transporte. Valores zerados usam os defaults (32 por host, 90s).
+func New(o Options) *Transport {
+	if o.MaxIdlePerHost <= 0 {
+		o.MaxIdlePerHost = 32
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = 90 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	base := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          o.MaxIdlePerHost * 4,
+		MaxIdleConnsPerHost:   o.MaxIdlePerHost,
+		IdleConnTimeout:       o.IdleTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(128)},
+		ForceAttemptHTTP2:     o.HTTP2,
+	}
+	if !o.HTTP2 {
+		// mapa vazio (não nil) desliga o HTTP/2 automático
+		base.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return &Transport{base: base}
+}
+
+// RoundTrip envia pela pool compartilhada, contando se a conexão foi reaproveitada.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	var start time.Time
+	ct := &httptrace.ClientTrace{
+		GetConn: func(string) { start = time.Now() },
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.reused.Add(1)
+				return
+			}
+			t.opened.Add(1)
+			t.setupNanos.Add(int64(time.Since(start)))
+		},
+	}
+	return t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), ct)))
+}
+
+// CloseIdleConnections fecha as conexões ociosas.
+func (t *Transport) CloseIdleConnections() { t.base.CloseIdleConnections() }
+
+// Stats resume o uso das conexões desde a subida.
+type Stats struct {
+	Requests    int64   `json:"requests"`
+	Reused      int64   `json:"reused_conns"`
+	Opened      int64   `json:"new_conns"`
+	ReuseRate   float64 `json:"reuse_rate"`
+	AvgSetupMs  float64 `json:"avg_conn_setup_ms"`
+	MaxIdleHost int     `json:"max_idle_per_host"`
+	HTTP2       bool    `json:"http2"`
+}
+
+// Stats devolve os contadores atuais.
+func (t *Transport) Stats() Stats {
+	s := Stats{
+		Requests: t.requests.Load(), Reused: t.reused.Load(), Opened: t.opened.Load(),
+		MaxIdleHost: t.base.MaxIdleConnsPerHost, HTTP2: t.base.ForceAttemptHTTP2,
+	}
+	if n := s.Reused + s.Opened; n > 0 {
+		s.ReuseRate = float64(s.Reused) / float64(n)
+	}
+	if s.Opened > 0 {
+		s.AvgSetupMs = float64(t.setupNanos.Load()) / float64(s.Opened) / float64(time.Millisecond)
+	}
+	return s
+}
+
+// Warm abre conns conexões com cada host de urls (HEAD na raiz, em paralelo) e
+// as deixa ociosas na pool, para as primeiras mensagens não pagarem o
+// handshake. Falhas só são registradas no log.
+func (t *Transport) Warm(ctx context.Context, urls []string, conns int) {
+	if conns <= 0 {
+		return
+	}
+	seen := map[string]bool{}
+	var wg sync.WaitGroup
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" || seen[u.Scheme+"://"+u.Host] {
+			continue
+		}
+		origin := u.Scheme + "://" + u.Host
+		seen[origin] = true
+		start := time.Now()
+		var ok atomic.Int32
+		var hostWG sync.WaitGroup
+		for i := 0; i < conns; i++ {
+			hostWG.Add(1)
+			go func() {
+				defer hostWG.Done()
+				ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				defer cancel()
+				req, err := http.NewRequestWithContext(ctx, http.MethodHead, origin+"/", nil)
+				if err != nil {
+					return
+				}
+				// fora dos contadores: não é tráfego de mensagem
+				resp, err := t.base.RoundTrip(req)
+				if err != nil {
+					log.Printf("upstream warm %s error: %v", origin, err)
+					return
+				}
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				ok.Add(1)
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hostWG.Wait()
+			log.Printf("upstream warm %s: %d/%d conns in %s", origin, ok.Load(), conns, time.Since(start).Round(time.Millisecond))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,10 @@
+-- Long-term memory: durable facts extracted from conversations, per client
+
+CREATE TABLE IF NOT EXISTS client_facts (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  key TEXT NOT NULL,
+  value TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (client_id, key)
+);
@@ -0,0 +1,4 @@
+-- Flags for WhatsApp ephemeral (disappearing) and view-once messages
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS ephemeral BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS view_once BOOLEAN NOT NULL DEFAULT false;
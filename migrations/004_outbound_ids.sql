@@ -0,0 +1,4 @@
+-- Provider message id/timestamp on outbound rows (correlation with delivery ACKs)
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS provider_at TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_ext_id ON messages (ext_id);
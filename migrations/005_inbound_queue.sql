@@ -0,0 +1,9 @@
+-- Inbound messages held while the agent is in maintenance mode
+
+CREATE TABLE IF NOT EXISTS inbound_queue (
+  id BIGSERIAL PRIMARY KEY,
+  phone TEXT NOT NULL,
+  content TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,11 @@
+-- Pipeline failures (OpenAI, TTS, Uazapi), used by reports and alerting
+
+CREATE TABLE IF NOT EXISTS failures (
+  id BIGSERIAL PRIMARY KEY,
+  phone TEXT NOT NULL,
+  stage TEXT NOT NULL,
+  error TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_failures_time ON failures (created_at DESC);
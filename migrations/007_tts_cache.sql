@@ -0,0 +1,10 @@
+-- Cache of generated TTS audio keyed by hash(voice, speed, text)
+
+CREATE TABLE IF NOT EXISTS tts_cache (
+  key TEXT PRIMARY KEY,
+  voice TEXT NOT NULL,
+  speed DOUBLE PRECISION NOT NULL,
+  audio BYTEA NOT NULL,
+  hits BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,7 @@
+-- Export watermarks for the optional analytics sink (BigQuery/ClickHouse)
+
+CREATE TABLE IF NOT EXISTS sink_state (
+  name TEXT PRIMARY KEY,
+  last_id BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,9 @@
+-- WhatsApp LID (@lid) identifiers mapped to phone numbers when known
+
+CREATE TABLE IF NOT EXISTS lid_map (
+  lid TEXT PRIMARY KEY,
+  phone TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lid_map_phone ON lid_map (phone);
@@ -0,0 +1,10 @@
+-- Audit trail of data purges (retention policy / client deletion), for LGPD
+
+CREATE TABLE IF NOT EXISTS purge_audit (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,       -- messages | thread | client
+  phone TEXT NULL,
+  detail TEXT NOT NULL DEFAULT '',
+  affected BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,16 @@
+-- Leads/pedidos criados pelo assistente (function calling) com acompanhamento de status
+
+CREATE TABLE IF NOT EXISTS leads (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL DEFAULT 'lead',       -- lead | order
+  title TEXT NOT NULL,
+  details TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'novo',     -- novo | em_andamento | ganho | perdido | cancelado
+  value NUMERIC(12,2) NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_leads_client ON leads (client_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_leads_status ON leads (status, updated_at DESC);
@@ -0,0 +1,13 @@
+-- Chaves de API com papéis (analyst | operator | admin) para as rotas /admin.
+-- Guarda apenas o SHA-256 da chave; o prefixo serve para identificá-la na listagem.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  role TEXT NOT NULL,
+  key_prefix TEXT NOT NULL,
+  key_hash TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_used_at TIMESTAMPTZ NULL,
+  revoked_at TIMESTAMPTZ NULL
+);
@@ -0,0 +1,13 @@
+-- Ajustes por número/instância do bot (sobrepõem as variáveis de ambiente).
+-- instance = número do bot (campo "owner" do webhook). Colunas NULL usam o valor do ENV.
+
+CREATE TABLE IF NOT EXISTS bot_settings (
+  instance TEXT PRIMARY KEY,
+  reply_delay_min_ms INT NULL,
+  reply_delay_max_ms INT NULL,
+  tts_voice TEXT NULL,
+  tts_speed DOUBLE PRECISION NULL,
+  buffer_timeout_seconds INT NULL,
+  business_hours TEXT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
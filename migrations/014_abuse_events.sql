@@ -0,0 +1,14 @@
+-- Eventos de spam/abuso detectados (para revisão) e o cooldown aplicado
+
+CREATE TABLE IF NOT EXISTS abuse_events (
+  id BIGSERIAL PRIMARY KEY,
+  phone TEXT NOT NULL,
+  reason TEXT NOT NULL,       -- frequency | repeated | link_only | moderation
+  detail TEXT NOT NULL DEFAULT '',
+  cooldown_until TIMESTAMPTZ NOT NULL,
+  lifted_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_abuse_events_phone ON abuse_events (phone, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_abuse_events_active ON abuse_events (cooldown_until) WHERE lifted_at IS NULL;
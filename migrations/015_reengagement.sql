@@ -0,0 +1,22 @@
+-- Reengajamento de leads silenciosos: tags de cliente, opt-out e histórico de envios
+
+CREATE TABLE IF NOT EXISTS client_tags (
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  tag TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (client_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_tags_tag ON client_tags (tag);
+
+-- Cliente pediu para não receber mensagens ativas (ex.: respondeu "PARAR")
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS opted_out_at TIMESTAMPTZ NULL;
+
+CREATE TABLE IF NOT EXISTS reengagements (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  message TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reengagements_client ON reengagements (client_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- Última saudação enviada a cada cliente (evita "Olá! Como posso ajudar?" a cada resposta)
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS last_greeted_at TIMESTAMPTZ NULL;
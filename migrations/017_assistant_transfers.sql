@@ -0,0 +1,28 @@
+-- Transferência de conversa entre assistentes (ex.: vendas -> suporte)
+
+-- Assistente atual do cliente (nome em ASSISTANTS); NULL = default
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS assistant TEXT NULL;
+
+-- Uma thread por cliente e assistente, para retomar ao voltar a um assistente
+CREATE TABLE IF NOT EXISTS client_threads (
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  assistant TEXT NOT NULL,
+  thread_id TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (client_id, assistant)
+);
+
+-- Trilha de auditoria das transferências
+CREATE TABLE IF NOT EXISTS assistant_transfers (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  from_assistant TEXT NOT NULL,
+  to_assistant TEXT NOT NULL,
+  thread_id TEXT NOT NULL,
+  summary TEXT NOT NULL DEFAULT '',
+  reason TEXT NOT NULL DEFAULT '',
+  actor TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistant_transfers_client ON assistant_transfers (client_id, created_at DESC);
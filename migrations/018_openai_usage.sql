@@ -0,0 +1,15 @@
+-- Consumo real de tokens por run da OpenAI (orçamento diário/mensal)
+
+CREATE TABLE IF NOT EXISTS openai_usage (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  model TEXT NOT NULL DEFAULT '',
+  prompt_tokens BIGINT NOT NULL DEFAULT 0,
+  completion_tokens BIGINT NOT NULL DEFAULT 0,
+  total_tokens BIGINT NOT NULL DEFAULT 0,
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_openai_usage_created ON openai_usage (created_at);
+CREATE INDEX IF NOT EXISTS idx_openai_usage_client ON openai_usage (client_id, created_at);
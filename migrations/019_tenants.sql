@@ -0,0 +1,28 @@
+-- Multi-tenant: várias empresas no mesmo deploy, cada uma com suas credenciais
+
+-- tenant_id NULL = tenant padrão (credenciais do ENV)
+CREATE TABLE IF NOT EXISTS tenants (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL UNIQUE,              -- usado na rota /webhook/t/{slug}
+  name TEXT NOT NULL DEFAULT '',
+  token TEXT NULL UNIQUE,                 -- identifica o tenant no webhook e nas integrações
+  openai_api_key TEXT NULL,
+  openai_assistant_id TEXT NULL,
+  uazapi_base_send TEXT NULL,
+  uazapi_token_send TEXT NULL,
+  uazapi_base_download TEXT NULL,
+  uazapi_token_download TEXT NULL,
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL;
+ALTER TABLE inbound_queue ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE;
+
+-- o mesmo telefone pode ser cliente de mais de um tenant
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_phone_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_clients_tenant_phone ON clients ((COALESCE(tenant_id, 0)), phone);
+CREATE INDEX IF NOT EXISTS idx_messages_tenant_time ON messages (tenant_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- Flag for forwarded WhatsApp messages (forwardingScore >= FORWARDED_CHAIN_SCORE = chain message)
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS forwarded BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,13 @@
+-- Pesquisa de satisfação (CSAT): uma linha por pesquisa enviada; a nota chega depois
+
+CREATE TABLE IF NOT EXISTS csat (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  reason TEXT NOT NULL,                 -- resolved | inactivity
+  rating SMALLINT NULL CHECK (rating BETWEEN 1 AND 5),
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  answered_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_csat_client ON csat (client_id, sent_at DESC);
+CREATE INDEX IF NOT EXISTS idx_csat_sent ON csat (sent_at);
@@ -0,0 +1,26 @@
+-- Experimentos A/B de assistentes e instruções: divisão por peso, bucket fixo por cliente
+
+CREATE TABLE IF NOT EXISTS experiments (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT false,
+  variants JSONB NOT NULL DEFAULT '[]',  -- [{"name","weight","assistant_id","instructions"}]
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_experiments_tenant_name ON experiments ((COALESCE(tenant_id, 0)), name);
+
+CREATE TABLE IF NOT EXISTS experiment_assignments (
+  experiment_id BIGINT NOT NULL REFERENCES experiments(id) ON DELETE CASCADE,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  variant TEXT NOT NULL,
+  assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (experiment_id, client_id)
+);
+
+-- "experimento:variante" das mensagens e runs feitas sob o experimento
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS variant TEXT NULL;
+ALTER TABLE openai_usage ADD COLUMN IF NOT EXISTS variant TEXT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_variant ON messages (variant) WHERE variant IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Jobs em segundo plano com execução única entre réplicas (lease por janela)
+
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+  name TEXT PRIMARY KEY,
+  owner TEXT NULL,                      -- réplica (host:pid) que detém o lease
+  lease_until TIMESTAMPTZ NULL,
+  slot TIMESTAMPTZ NULL,                -- janela da última execução (ex.: dia do digest)
+  status TEXT NOT NULL DEFAULT 'idle',  -- idle | running | ok | error
+  last_error TEXT NULL,
+  started_at TIMESTAMPTZ NULL,
+  finished_at TIMESTAMPTZ NULL,
+  runs BIGINT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,17 @@
+-- Documentos do cliente enviados à OpenAI (file_search na thread), com validade
+
+CREATE TABLE IF NOT EXISTS thread_files (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  file_id TEXT NOT NULL UNIQUE,
+  filename TEXT NOT NULL,
+  bytes BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  attached_at TIMESTAMPTZ NULL,   -- anexado a uma mensagem da thread
+  expires_at TIMESTAMPTZ NOT NULL,
+  deleted_at TIMESTAMPTZ NULL     -- apagado na OpenAI
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_files_expiry ON thread_files (expires_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_thread_files_client ON thread_files (client_id) WHERE deleted_at IS NULL;
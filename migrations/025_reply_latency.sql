@@ -0,0 +1,13 @@
+-- Tempo entre a mensagem do cliente e a resposta (SLO de latência)
+
+CREATE TABLE IF NOT EXISTS reply_latency (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  received_at TIMESTAMPTZ NOT NULL,  -- primeira mensagem do lote no webhook
+  replied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  latency_ms BIGINT NOT NULL,
+  ok BOOLEAN NOT NULL DEFAULT true   -- false = a run falhou (conta como fora do SLO)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reply_latency_replied ON reply_latency (replied_at);
@@ -0,0 +1,15 @@
+-- Notas e tags manuais dos operadores por cliente (API admin), com autoria
+
+CREATE TABLE IF NOT EXISTS client_notes (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  note TEXT NOT NULL,
+  author TEXT NOT NULL,           -- nome da chave de API (ou "admin")
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_notes_client ON client_notes (client_id, created_at DESC);
+
+-- id para a exportação incremental (sink) e autoria das tags; NULL = assistente/sistema
+ALTER TABLE client_tags ADD COLUMN IF NOT EXISTS id BIGINT GENERATED BY DEFAULT AS IDENTITY;
+ALTER TABLE client_tags ADD COLUMN IF NOT EXISTS created_by TEXT NULL;
@@ -0,0 +1,4 @@
+-- Dados do cliente vindos do CRM (enriquecimento no primeiro contato)
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS crm JSONB NULL;              -- último retorno do CRM (campos planos)
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS crm_enriched_at TIMESTAMPTZ NULL;
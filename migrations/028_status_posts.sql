@@ -0,0 +1,20 @@
+-- Publicações no Status (stories) do WhatsApp, imediatas ou agendadas
+
+CREATE TABLE IF NOT EXISTS status_posts (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  text TEXT NOT NULL DEFAULT '',
+  media_type TEXT NOT NULL DEFAULT '',     -- vazio = status de texto
+  media_url TEXT NOT NULL DEFAULT '',
+  background_color INT NOT NULL DEFAULT 0,
+  font INT NOT NULL DEFAULT 0,
+  send_at TIMESTAMPTZ NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',  -- pending | sent | failed | canceled
+  message_id TEXT NULL,
+  error TEXT NULL,
+  created_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  sent_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_posts_due ON status_posts (send_at) WHERE status = 'pending';
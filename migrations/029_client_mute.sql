@@ -0,0 +1,5 @@
+-- Cliente silenciou o bot ("silenciar por 1 dia"): sem respostas até muted_until
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS muted_until TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_clients_muted_until ON clients (muted_until) WHERE muted_until IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Número sem WhatsApp (erro da Uazapi no envio): fora dos envios proativos até
+-- o cliente escrever de novo
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS unreachable_at TIMESTAMPTZ NULL;
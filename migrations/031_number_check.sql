@@ -0,0 +1,4 @@
+-- Verificação do número no WhatsApp (/chat/check) antes dos envios proativos
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS whatsapp_exists BOOLEAN NULL;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS whatsapp_checked_at TIMESTAMPTZ NULL;
@@ -0,0 +1,3 @@
+-- Mensagens mascaradas (REDACT_*): cópia sem máscara só com REDACT_KEEP_ORIGINAL
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_original TEXT NULL;
@@ -0,0 +1,18 @@
+-- Catálogo de produtos consultado pelo assistente (search_products / get_price)
+
+CREATE TABLE IF NOT EXISTS products (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  sku TEXT NOT NULL DEFAULT '',
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  category TEXT NOT NULL DEFAULT '',
+  price NUMERIC(12,2) NULL,               -- NULL = sob consulta
+  unit TEXT NOT NULL DEFAULT '',          -- un, kg, m²...
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products ((COALESCE(tenant_id, 0)), sku) WHERE sku <> '';
+CREATE INDEX IF NOT EXISTS idx_products_tenant ON products ((COALESCE(tenant_id, 0)), active);
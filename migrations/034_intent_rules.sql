@@ -0,0 +1,18 @@
+-- Respostas automáticas por intenção (horário, endereço, tabela de preços) sem passar pela IA
+
+CREATE TABLE IF NOT EXISTS intent_rules (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  keywords TEXT[] NOT NULL DEFAULT '{}',  -- qualquer uma casa (sem acento/caixa)
+  pattern TEXT NOT NULL DEFAULT '',       -- regex opcional (Go RE2, sem caixa)
+  reply TEXT NOT NULL,                    -- aceita {{nome}} e demais variáveis da resposta
+  priority INT NOT NULL DEFAULT 0,        -- maior vence quando várias casam
+  active BOOLEAN NOT NULL DEFAULT true,
+  hits BIGINT NOT NULL DEFAULT 0,
+  last_hit_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_intent_rules_name ON intent_rules ((COALESCE(tenant_id, 0)), name);
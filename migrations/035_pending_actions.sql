@@ -0,0 +1,17 @@
+-- Ação pendente (link de pagamento/formulário enviado): segura as respostas do assistente sobre o assunto até a confirmação
+
+CREATE TABLE IF NOT EXISTS pending_actions (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL DEFAULT 'payment',   -- payment | form
+  reference TEXT NOT NULL DEFAULT '',     -- id do pedido/cobrança no sistema externo
+  url TEXT NOT NULL DEFAULT '',
+  held INT NOT NULL DEFAULT 0,            -- mensagens do cliente seguradas na espera
+  expires_at TIMESTAMPTZ NOT NULL,
+  resolved_at TIMESTAMPTZ NULL,
+  resolution TEXT NULL,                   -- paid | completed | failed | cancelled | replaced
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_actions_open ON pending_actions (client_id) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pending_actions_reference ON pending_actions (reference) WHERE reference <> '';
@@ -0,0 +1,21 @@
+-- Pagamentos informados pelos webhooks dos provedores (Mercado Pago, Stripe, Pix)
+
+CREATE TABLE IF NOT EXISTS payments (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,                 -- mercadopago | stripe | pix
+  external_id TEXT NOT NULL,              -- id do pagamento no provedor
+  reference TEXT NOT NULL DEFAULT '',     -- pedido / client_reference_id / txid
+  client_id BIGINT NULL REFERENCES clients(id) ON DELETE SET NULL,
+  status TEXT NOT NULL,                   -- paid | pending | failed | refunded
+  amount_cents BIGINT NOT NULL DEFAULT 0,
+  currency TEXT NOT NULL DEFAULT 'BRL',
+  paid_at TIMESTAMPTZ NULL,
+  notified_at TIMESTAMPTZ NULL,           -- cliente avisado (uma vez por pagamento)
+  raw JSONB NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_external ON payments ((COALESCE(tenant_id, 0)), provider, external_id);
+CREATE INDEX IF NOT EXISTS idx_payments_client ON payments (client_id, created_at DESC);
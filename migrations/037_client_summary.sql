@@ -0,0 +1,5 @@
+-- Resumo da conversa mantido incrementalmente (handoff, reengajamento, painel)
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS summary TEXT NULL;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS summary_message_id BIGINT NULL;   -- última mensagem já resumida
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS summary_updated_at TIMESTAMPTZ NULL;
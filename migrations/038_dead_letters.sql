@@ -0,0 +1,14 @@
+-- Panics recuperados (requisições HTTP, processamento de mensagens, workers)
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL DEFAULT '',         -- cliente afetado ('' em workers/rotas sem cliente)
+  stage TEXT NOT NULL,                    -- process | album | http POST /webhook | worker csat ...
+  payload TEXT NOT NULL DEFAULT '',       -- mensagem combinada / rota, para reprocessar à mão
+  error TEXT NOT NULL,
+  stack TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_created ON dead_letters ((COALESCE(tenant_id, 0)), created_at DESC);
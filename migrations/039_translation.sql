@@ -0,0 +1,6 @@
+-- Tradução automática de conversas em outro idioma (TRANSLATE_MODE)
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS language TEXT NULL;            -- idioma detectado (ISO 639-1)
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS language_override TEXT NULL;   -- fixado pelo operador ('pt' = nunca traduzir)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS language TEXT NULL;           -- idioma de content, quando traduzida
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_translated TEXT NULL; -- versão em português de content
@@ -0,0 +1,3 @@
+-- Cliente pediu respostas só em texto ("áudio off")
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS audio_replies_off BOOLEAN NOT NULL DEFAULT false;
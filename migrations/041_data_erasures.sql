@@ -0,0 +1,19 @@
+-- Pedidos de exclusão de dados (LGPD): confirmação pelo WhatsApp e trilha de auditoria
+
+CREATE TABLE IF NOT EXISTS data_erasures (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL,
+  requested_by TEXT NOT NULL,                 -- client | admin
+  status TEXT NOT NULL DEFAULT 'pending',     -- pending | completed | expired | failed
+  code TEXT NOT NULL DEFAULT '',              -- código que o cliente responde para confirmar
+  expires_at TIMESTAMPTZ NULL,
+  exported BOOLEAN NOT NULL DEFAULT false,    -- cópia dos dados enviada antes da exclusão
+  affected BIGINT NOT NULL DEFAULT 0,         -- linhas apagadas
+  detail TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  completed_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_erasures_pending ON data_erasures ((COALESCE(tenant_id, 0)), phone) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_data_erasures_created ON data_erasures ((COALESCE(tenant_id, 0)), created_at DESC);
@@ -0,0 +1,8 @@
+-- Parâmetros das runs do assistente por número do bot (NULL = ENV / assistente)
+
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_temperature DOUBLE PRECISION NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_top_p DOUBLE PRECISION NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_max_prompt_tokens INT NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_max_completion_tokens INT NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_truncation TEXT NULL;
+ALTER TABLE bot_settings ADD COLUMN IF NOT EXISTS run_reasoning_effort TEXT NULL;
@@ -0,0 +1,17 @@
+-- Avisos ao grupo da equipe (TEAM_NOTIFY_GROUP): um registro por evento, para que só
+-- uma réplica envie, e fila do que chegou no horário de silêncio
+
+CREATE TABLE IF NOT EXISTS team_notifications (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  event_key TEXT NOT NULL UNIQUE,            -- hash do evento (o mesmo em todas as réplicas)
+  topic TEXT NOT NULL,
+  text TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',    -- pending | sent | failed
+  attempts INT NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  sent_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_notifications_pending ON team_notifications ((COALESCE(tenant_id, 0)), id) WHERE status = 'pending';
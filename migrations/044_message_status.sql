@@ -0,0 +1,12 @@
+-- Envio em duas fases: a mensagem de saída é gravada como pending antes da chamada
+-- à Uazapi e avança para sent/failed com a resposta e para delivered/read/played com
+-- os recibos do webhook. send_key (opcional) torna o envio idempotente
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status TEXT NULL;       -- pending | sent | delivered | read | played | failed (NULL = entrada/legado)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status_at TIMESTAMPTZ NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS send_error TEXT NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS send_key TEXT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_send_key ON messages (send_key) WHERE send_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_pending ON messages (created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_messages_status_time ON messages ((COALESCE(tenant_id, 0)), created_at DESC) WHERE status IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Memória de imagens: cada foto recebida fica ligada ao cliente e à mensagem do
+-- WhatsApp, para que perguntas seguintes rodem a visão de novo na mesma imagem
+
+CREATE TABLE IF NOT EXISTS client_images (
+  id BIGSERIAL PRIMARY KEY,
+  client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  ext_id TEXT NULL,                -- messageid do WhatsApp (baixa de novo pela Uazapi)
+  media_url TEXT NOT NULL,         -- URL usada na descrição (pode expirar)
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_images_client ON client_images (client_id, created_at DESC);
@@ -0,0 +1,23 @@
+-- Modo manutenção no banco: a janela vale para todas as réplicas e sobrevive a
+-- restarts (prazo incluso); o aviso ao cliente sai uma vez por janela
+
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  since TIMESTAMPTZ NULL,
+  until TIMESTAMPTZ NULL,          -- NULL = sem prazo
+  message TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_maintenance_windows_tenant ON maintenance_windows ((COALESCE(tenant_id, 0)));
+
+CREATE TABLE IF NOT EXISTS maintenance_notices (
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  phone TEXT NOT NULL,
+  window_since TIMESTAMPTZ NOT NULL,   -- since da janela em que o aviso saiu
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_maintenance_notices ON maintenance_notices ((COALESCE(tenant_id, 0)), phone, window_since);
@@ -0,0 +1,5 @@
+-- Aviso de exclusão concluída: o cadastro (e o histórico) já não existe quando ele
+-- sai, então o envio em duas fases fica registrado no próprio pedido
+
+ALTER TABLE data_erasures ADD COLUMN IF NOT EXISTS notice_status TEXT NULL;   -- pending | sent | failed
+ALTER TABLE data_erasures ADD COLUMN IF NOT EXISTS notice_ext_id TEXT NULL;   -- messageid do aviso na Uazapi
@@ -0,0 +1,12 @@
+-- Nonces do webhook assinado (WEBHOOK_SECRET): vistos por todas as réplicas e
+-- mantidos após restarts até expirar. Únicos por nonce, não por tenant: todos os
+-- tenants assinam com o mesmo segredo, e um evento capturado reenviado para
+-- /webhook/t/{outro} também precisa ser recusado
+
+CREATE TABLE IF NOT EXISTS webhook_nonces (
+  nonce TEXT PRIMARY KEY,
+  tenant_id BIGINT NULL REFERENCES tenants(id) ON DELETE CASCADE,   -- quem recebeu primeiro
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_nonces_expires ON webhook_nonces (expires_at);